@@ -3,7 +3,9 @@ module github.com/PietPadda/aggregator
 go 1.24.2
 
 require (
-	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/BurntSushi/toml v1.6.0
+	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,136 @@
+// adaptive.go
+package adaptive
+
+import (
+	// std go libraries
+	"context" // waiting for a slot
+	"sync"    // guards the limit
+	"time"    // latencies and cooldown
+)
+
+// package-wide constants
+const cooldown = time.Second // at most one backoff per cooldown, a burst of slow calls is one signal
+
+// a concurrency limiter that backs off when its calls get slow or fail (AIMD)
+// the limit halves on a slow or failed call, and grows by one after a limit's worth of healthy ones
+type Limiter struct {
+	mu       sync.Mutex
+	min      int           // the limit never drops below this
+	max      int           // or grows above this
+	target   time.Duration // calls slower than this are a sign of overload
+	limit    int           // calls allowed at once right now
+	inFlight int           // calls running now
+	healthy  int           // healthy calls since the limit last changed
+	backoff  time.Time     // when the limit was last lowered
+	freed    chan struct{} // closed and replaced whenever a slot may have opened
+}
+
+// create a limiter allowing lo to hi calls at once, starting at hi
+// lo == hi is a plain semaphore that never adapts
+func New(lo, hi int, target time.Duration) *Limiter {
+	l := &Limiter{freed: make(chan struct{})}
+	l.SetBounds(lo, hi, target)
+	return l
+}
+
+// change the limits, eg after a config reload (the current limit is clamped into them)
+func (l *Limiter) SetBounds(lo, hi int, target time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// sane bounds check
+	hi = fixBound(hi)
+	lo = min(fixBound(lo), hi)
+
+	// first call check, start at full speed
+	if l.max == 0 {
+		l.limit = hi
+	}
+	l.min, l.max, l.target = lo, hi, target
+	l.limit = clamp(l.limit, lo, hi)
+	l.wake()
+}
+
+// wait for a free slot, or until ctx is done
+func (l *Limiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+
+		// free slot check
+		if l.inFlight < l.limit {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		freed := l.freed
+		l.mu.Unlock()
+
+		// wait for a slot to open (or ctrl+c!)
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// give a slot back
+func (l *Limiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.wake()
+}
+
+// report how a call went, slow or failed calls lower the limit, healthy ones raise it again
+// returns the old and new limit, equal if it didn't change
+func (l *Limiter) Observe(latency time.Duration, err error) (int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.limit
+
+	// overload check, halve the limit (once per cooldown)
+	if err != nil || (l.target > 0 && latency > l.target) {
+		l.healthy = 0
+		if time.Since(l.backoff) >= cooldown {
+			l.limit = max(l.min, l.limit/2)
+			l.backoff = time.Now()
+		}
+		return old, l.limit
+	}
+
+	// healthy, grow by one after a limit's worth of healthy calls
+	l.healthy++
+	if l.healthy >= l.limit && l.limit < l.max {
+		l.limit++
+		l.healthy = 0
+		l.wake()
+	}
+	return old, l.limit
+}
+
+// calls allowed at once right now
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// wake the waiters helper, they recheck for a slot (call with mu held)
+func (l *Limiter) wake() {
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
+
+// at least one call at a time helper
+func fixBound(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// clamp helper
+func clamp(n, lo, hi int) int {
+	return min(max(n, lo), hi)
+}
@@ -0,0 +1,477 @@
+// api.go
+package api
+
+import (
+	// std go libraries
+	"database/sql"  // null columns and no rows
+	"encoding/json" // response bodies
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // printing errors
+	"log/slog"      // request logging
+	"net/http"      // the server
+	"strconv"       // parsing limit and offset
+	"time"          // timestamps and request durations
+
+	// external packages
+	"github.com/google/uuid" // ids in responses
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database"   // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/imagecache" // the image proxy
+)
+
+// page sizes of list endpoints (?limit=)
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// api server struct, serves the same data as the CLI as json
+type Server struct {
+	db       database.Store    // database instance, shared with the cli's handlers
+	images   *imagecache.Cache // images agg cached, nil = cache_images is off
+	failures *failureLimiter   // wrong passwords per client
+	auth     *authCache        // passwords checked lately
+}
+
+// create a new api server over the database, images nil = no image proxy
+func NewServer(db database.Store, images *imagecache.Cache) *Server {
+	return &Server{db: db, images: images, failures: newFailureLimiter(), auth: newAuthCache()}
+}
+
+// get the server's routes, wrapped in request logging
+func (srv *Server) Handler() http.Handler {
+	// create the router
+	mux := http.NewServeMux()
+
+	// register the endpoints (the data is read-only, only sessions and syncs change it)
+	// NOTE: all but health and login need an api token, password or session, see auth.go and session.go
+	mux.HandleFunc("GET /api/health", srv.handleHealth)
+	mux.HandleFunc("POST /api/session", srv.handleLogin)
+	mux.HandleFunc("GET /api/session", srv.requireAuth(srv.handleSession))
+	mux.HandleFunc("DELETE /api/session", srv.requireAuth(srv.handleLogout))
+	mux.HandleFunc("GET /api/users", srv.requireAuth(srv.handleUsers))
+	mux.HandleFunc("GET /api/users/{name}", srv.requireAuth(srv.handleUser))
+	mux.HandleFunc("GET /api/users/{name}/follows", srv.requireAuth(srv.handleFollows))
+	mux.HandleFunc("GET /api/users/{name}/posts", srv.requireAuth(srv.handlePosts))
+	mux.HandleFunc("GET /api/feeds", srv.requireAuth(srv.handleFeeds))
+	mux.HandleFunc("GET /api/trending", srv.requireAuth(srv.handleTrending))
+
+	// syncing with another gator instance of the user's, see sync.go
+	mux.HandleFunc("POST /api/sync", srv.requireAuth(srv.handleSync))
+
+	// the fever sync api, for existing feed readers (GET or POST, see fever.go)
+	mux.HandleFunc("/fever/", srv.handleFever)
+
+	// shared timelines as rss, for any feed reader (no auth, users opt in with sharefeed, see rss.go)
+	mux.HandleFunc("GET /users/{name}/feed.xml", srv.handleTimelineFeed)
+
+	// public profiles, the feeds a user follows as a blogroll and opml (no auth, users opt in with profile, see profile.go)
+	mux.HandleFunc("GET /u/{name}", srv.handleProfile)
+	mux.HandleFunc("GET /u/{name}/feeds.opml", srv.handleProfileOPML)
+
+	// unsubscribing from email digests, the link in each one (no auth, the token is the proof, see digest.go)
+	mux.HandleFunc("GET /digest/unsubscribe", srv.handleDigestUnsubscribe)
+	mux.HandleFunc("POST /digest/unsubscribe", srv.handleDigestUnsubscribe)
+
+	// the image proxy, cached post images (no auth, img tags can't send tokens, see images.go)
+	mux.HandleFunc("GET /images/{key}", srv.handleImage)
+
+	// unknown paths get json errors too
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint: "+r.URL.Path)
+	})
+
+	// return the routes
+	return logRequests(mux)
+}
+
+// a page of a list endpoint's items
+type page[T any] struct {
+	Items      []T  `json:"items"`
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset"` // null on the last page
+}
+
+// user response
+type user struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// feed response
+type feed struct {
+	Name                string     `json:"name"`
+	URL                 string     `json:"url"`
+	User                string     `json:"user"` // who added it
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	LastError           *string    `json:"last_error"`
+	LastErrorAt         *time.Time `json:"last_error_at"`
+}
+
+// feed follow response
+type follow struct {
+	FeedID     uuid.UUID `json:"feed_id"`
+	FeedName   string    `json:"feed_name"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// post response
+type post struct {
+	ID          uuid.UUID   `json:"id"`
+	FeedID      uuid.UUID   `json:"feed_id"`
+	Title       string      `json:"title"`
+	URL         string      `json:"url"`
+	Description *string     `json:"description"`
+	PublishedAt *time.Time  `json:"published_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Images      []string    `json:"images"`     // image proxy paths when cached, else the original urls
+	Enclosures  []enclosure `json:"enclosures"` // attachments as the feed listed them (podcast audio, video, images...)
+}
+
+// a post's attachment in api responses, from its feed's <enclosure> (or atom enclosure link)
+type enclosure struct {
+	URL      string  `json:"url"`
+	MimeType *string `json:"mime_type"` // null if the feed left it out
+	Length   *int64  `json:"length"`    // bytes, null if the feed left it out
+}
+
+// health endpoint, checks the database answers
+func (srv *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	// ping the database with a cheap query
+	_, err := srv.db.GetUsers(r.Context())
+
+	// ping check
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "database unavailable")
+		slog.Error("api health check failed", "err", err)
+		return
+	}
+
+	// healthy
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// users endpoint, every user's name (paged)
+func (srv *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	// get the page
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// get the users
+	names, err := srv.db.GetUsers(r.Context())
+
+	// get users check
+	if err != nil {
+		writeServerError(w, "error getting users", err)
+		return
+	}
+
+	// return the page (users are few, so it's paged here rather than in sql)
+	writeJSON(w, http.StatusOK, pageOf(names, limit, offset))
+}
+
+// user endpoint, a single user
+func (srv *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	// get the user
+	dbUser, ok := srv.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	// return the user
+	writeJSON(w, http.StatusOK, toUser(dbUser))
+}
+
+// follows endpoint, the feeds a user follows (paged)
+func (srv *Server) handleFollows(w http.ResponseWriter, r *http.Request) {
+	// get the page
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// get the user
+	dbUser, ok := srv.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	// get the user's follows
+	rows, err := srv.db.GetFeedFollowsForUser(r.Context(), dbUser.ID)
+
+	// get follows check
+	if err != nil {
+		writeServerError(w, "error getting feed follows", err)
+		return
+	}
+
+	// convert to responses
+	follows := make([]follow, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, follow{FeedID: row.FeedID, FeedName: row.Feedname, FollowedAt: row.CreatedAt})
+	}
+
+	// return the page
+	writeJSON(w, http.StatusOK, pageOf(follows, limit, offset))
+}
+
+// posts endpoint, a user's posts newest first (paged)
+// ?folder= only shows that folder's feeds, ?q= searches titles and descriptions
+func (srv *Server) handlePosts(w http.ResponseWriter, r *http.Request) {
+	// get the page
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// get the user
+	dbUser, ok := srv.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	// get the optional filters
+	folder := r.URL.Query().Get("folder")
+	query := r.URL.Query().Get("q")
+
+	// folder exists check (otherwise a typo looks like an empty folder)
+	if folder != "" {
+		_, err := srv.db.GetFolderByName(r.Context(), database.GetFolderByNameParams{UserID: dbUser.ID, Name: folder})
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "no folder named "+folder)
+			return
+		}
+		if err != nil {
+			writeServerError(w, "error getting folder", err)
+			return
+		}
+	}
+
+	// get one more than the page, to know if there's a next one
+	rows, err := srv.db.ListPostsForUser(r.Context(), database.ListPostsForUserParams{
+		UserID:     dbUser.ID,
+		Folder:     sql.NullString{String: folder, Valid: folder != ""},
+		Query:      sql.NullString{String: query, Valid: query != ""},
+		PostLimit:  int32(limit + 1),
+		PostOffset: int32(offset),
+	})
+
+	// get posts check
+	if err != nil {
+		writeServerError(w, "error getting posts", err)
+		return
+	}
+
+	// convert to responses
+	posts := make([]post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, post{
+			ID:          row.ID,
+			FeedID:      row.FeedID,
+			Title:       row.Title,
+			URL:         row.Url,
+			Description: nullString(row.Description),
+			PublishedAt: nullTime(row.PublishedAt),
+			CreatedAt:   row.CreatedAt,
+		})
+	}
+
+	// create the page (the offset is already applied in sql)
+	result := page[post]{Items: posts, Limit: limit, Offset: offset}
+
+	// more posts check, drop the extra one
+	if len(posts) > limit {
+		next := offset + limit
+		result.Items = posts[:limit]
+		result.NextOffset = &next
+	}
+
+	// the posts' enclosures and images
+	srv.postMedia(r, result.Items)
+
+	// return the page
+	writeJSON(w, http.StatusOK, result)
+}
+
+// feeds endpoint, every feed with who added it (paged)
+func (srv *Server) handleFeeds(w http.ResponseWriter, r *http.Request) {
+	// get the page
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// get the feeds
+	rows, err := srv.db.ListFeedsWithCreator(r.Context())
+
+	// get feeds check
+	if err != nil {
+		writeServerError(w, "error getting feeds", err)
+		return
+	}
+
+	// convert to responses
+	feeds := make([]feed, 0, len(rows))
+	for _, row := range rows {
+		feeds = append(feeds, feed{
+			Name:                row.Feedname,
+			URL:                 row.Feedurl,
+			User:                row.Username,
+			ConsecutiveFailures: row.ConsecutiveFailures,
+			LastError:           nullString(row.LastError),
+			LastErrorAt:         nullTime(row.LastErrorAt),
+		})
+	}
+
+	// return the page
+	writeJSON(w, http.StatusOK, pageOf(feeds, limit, offset))
+}
+
+// get the {name} user helper, writing a 403 unless it's the authenticated user
+func (srv *Server) lookupUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	// own user check (also a 403 for users that don't exist, so names can't be probed)
+	name := r.PathValue("name")
+	dbUser := authUser(r)
+	if dbUser.Name != name {
+		writeError(w, http.StatusForbidden, "you can only read "+dbUser.Name)
+		return database.User{}, false
+	}
+
+	// return the user
+	return dbUser, true
+}
+
+// parse ?limit= and ?offset= helper, writing a 400 if they're invalid
+func pagination(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	// defaults
+	limit, offset := defaultLimit, 0
+
+	// limit check
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q (use 1 to %d)", value, maxLimit))
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+
+	// offset check
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset %q (use 0 or more)", value))
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+
+	// return the page
+	return limit, offset, true
+}
+
+// cut a page out of all the items helper
+func pageOf[T any](items []T, limit, offset int) page[T] {
+	// clamp the window to the items
+	start := min(offset, len(items))
+	end := min(start+limit, len(items))
+
+	// create the page (never a null items array)
+	result := page[T]{Items: items[start:end], Limit: limit, Offset: offset}
+	if result.Items == nil {
+		result.Items = []T{}
+	}
+
+	// more items check
+	if end < len(items) {
+		result.NextOffset = &end
+	}
+
+	// return the page
+	return result
+}
+
+// write a json response helper
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(body)
+
+	// encode check (the client probably went away, the status is already sent)
+	if err != nil {
+		slog.Debug("error writing api response", "err", err)
+	}
+}
+
+// write a json error response helper
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// write a 500 helper, the details go to the log rather than the client
+func writeServerError(w http.ResponseWriter, message string, err error) {
+	slog.Error(message, "err", err)
+	writeError(w, http.StatusInternalServerError, message)
+}
+
+// user response helper
+func toUser(dbUser database.User) user {
+	return user{ID: dbUser.ID, Name: dbUser.Name, CreatedAt: dbUser.CreatedAt}
+}
+
+// nullable string helper, null in json
+func nullString(str sql.NullString) *string {
+	// valid check
+	if !str.Valid {
+		return nil
+	}
+	return &str.String
+}
+
+// nullable number helper, null in json
+func nullInt64(n sql.NullInt64) *int64 {
+	// valid check
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// nullable time helper, null in json
+func nullTime(t sql.NullTime) *time.Time {
+	// valid check
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// a response writer that remembers its status, for logRequests
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader method to remember the status
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// log every request helper
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// serve the request, timing it
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		// log it
+		slog.Info("api request", "method", r.Method, "path", r.URL.Path, "status", sw.status, "duration", time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -0,0 +1,213 @@
+// auth.go
+package api
+
+import (
+	// std go libraries
+	"context"       // the request's user
+	"crypto/rand"   // generating tokens
+	"crypto/sha256" // hashing stored tokens
+	"database/sql"  // no rows and last used
+	"encoding/hex"  // tokens and hashes as hex
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // printing errors
+	"net/http"      // the middleware
+	"strconv"       // retry after seconds
+	"strings"       // parsing the authorization header
+	"time"          // last used
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/password" // checking basic auth passwords
+)
+
+// api token prefix, so a leaked token is easy to recognise (and grep for)
+const tokenPrefix = "gator_"
+
+// request context key of the authenticated user
+type userKey struct{}
+
+// generate a new api token, returned only once by token create
+func NewAPIToken() (string, error) {
+	// random part
+	token, err := randomToken()
+
+	// random check
+	if err != nil {
+		return "", fmt.Errorf("error generating api token: %w", err)
+	}
+	return tokenPrefix + token, nil
+}
+
+// 32 random bytes as hex helper, for tokens, sessions and csrf tokens
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+
+	// random check
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hash an api token for storing, so the database never holds the token itself
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// require an api token or a password helper
+// NOTE: a token as "Authorization: Bearer <token>", or basic auth with a user's name and password (see passwd)
+// NOTE: the authenticated user goes in the request context, see authUser
+func (srv *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// basic auth check, for users with a password
+		if name, given, ok := r.BasicAuth(); ok {
+			srv.passwordAuth(w, r, next, name, given)
+			return
+		}
+
+		// get the token
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		// session cookie check, when there's no token
+		if cookie, err := r.Cookie(sessionCookie); !ok && err == nil {
+			srv.sessionAuth(w, r, next, cookie.Value)
+			return
+		}
+
+		// token check
+		if !ok || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator"`)
+			writeError(w, http.StatusUnauthorized, "not logged in (send an api token as Authorization: Bearer <token>, basic auth or a session cookie)")
+			return
+		}
+
+		// get the user the token belongs to
+		dbUser, err := srv.db.GetUserByAPIToken(r.Context(), database.GetUserByAPITokenParams{
+			UsedAt:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			TokenHash: HashAPIToken(strings.TrimSpace(token)),
+		})
+
+		// valid token check
+		if errors.Is(err, sql.ErrNoRows) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "invalid or revoked api token")
+			return
+		}
+		if err != nil {
+			writeServerError(w, "error checking api token", err)
+			return
+		}
+
+		// serve the request as the token's user
+		next(w, r.WithContext(context.WithValue(r.Context(), userKey{}, dbUser)))
+	}
+}
+
+// basic auth helper, users without a password can't use it
+func (srv *Server) passwordAuth(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, name, given string) {
+	// check the name and password
+	dbUser, ok, err := srv.checkPassword(r, name, given)
+
+	// check check
+	if writeBlocked(w, err) {
+		return
+	}
+	if err != nil {
+		writeServerError(w, "error checking password", err)
+		return
+	}
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="gator"`)
+		writeError(w, http.StatusUnauthorized, "wrong user name or password")
+		return
+	}
+
+	// serve the request as the user
+	next(w, r.WithContext(context.WithValue(r.Context(), userKey{}, dbUser)))
+}
+
+// too many wrong passwords from a client error, it has to wait
+type blockedError struct {
+	wait time.Duration
+}
+
+func (e blockedError) Error() string {
+	return fmt.Sprintf("too many wrong passwords, try again in %s", e.wait.Round(time.Second))
+}
+
+// answer 429 if the password check was blocked helper, true if it was
+func writeBlocked(w http.ResponseWriter, err error) bool {
+	var blocked blockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(blocked.wait.Seconds()+1)))
+	writeError(w, http.StatusTooManyRequests, blocked.Error())
+	return true
+}
+
+// check a user's name and password helper, false for unknown names, users without a password and wrong passwords alike
+// NOTE: they all take as long (a hash is checked either way), so the response time doesn't tell which names exist
+// NOTE: a client's wrong passwords are limited (blockedError), and a right one is remembered for a while (see authCache)
+func (srv *Server) checkPassword(r *http.Request, name, given string) (database.User, bool, error) {
+	// blocked check, before hashing anything
+	now := time.Now()
+	client := clientIP(r)
+	if wait, blocked := srv.failures.blocked(client, now); blocked {
+		return database.User{}, false, blockedError{wait: wait}
+	}
+
+	// get the user
+	dbUser, err := srv.db.GetUser(r.Context(), name)
+
+	// get user check
+	if errors.Is(err, sql.ErrNoRows) {
+		password.VerifyNone(given)
+		srv.failures.fail(client, now)
+		return database.User{}, false, nil
+	}
+	if err != nil {
+		return database.User{}, false, fmt.Errorf("error getting user: %w", err)
+	}
+
+	// get their password hash
+	hash, err := srv.db.GetUserPasswordHash(r.Context(), dbUser.ID)
+
+	// has a password check
+	if errors.Is(err, sql.ErrNoRows) {
+		password.VerifyNone(given)
+		srv.failures.fail(client, now)
+		return database.User{}, false, nil
+	}
+	if err != nil {
+		return database.User{}, false, fmt.Errorf("error getting password: %w", err)
+	}
+
+	// checked lately check, serving the user as just read (a demoted admin isn't one anymore)
+	key := authKey(name, given, hash)
+	if userID, ok := srv.auth.get(key, now); ok && userID == dbUser.ID {
+		return dbUser, true, nil
+	}
+
+	// verify the password
+	ok, err := password.Verify(given, hash)
+
+	// verify check
+	if err != nil {
+		return database.User{}, false, err
+	}
+	if !ok {
+		srv.failures.fail(client, now)
+		return database.User{}, false, nil
+	}
+	srv.auth.add(key, dbUser.ID, now)
+	return dbUser, true, nil
+}
+
+// get the request's user helper, set by requireAuth
+func authUser(r *http.Request) database.User {
+	dbUser, _ := r.Context().Value(userKey{}).(database.User)
+	return dbUser
+}
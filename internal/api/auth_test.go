@@ -0,0 +1,135 @@
+// auth_test.go
+package api
+
+import (
+	// std go libraries
+	"context"           // for context
+	"database/sql"      // for sql errors
+	"net/http"          // requests
+	"net/http/httptest" // recording responses
+	"testing"           // go tests
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for the fake store
+	"github.com/PietPadda/aggregator/internal/password" // the stored hash
+	"github.com/google/uuid"                            // user ids
+)
+
+// a fake database with one user and their password hash
+// NOTE: the embedded Store is nil, so a query that isn't faked here panics and shows up in the test
+type fakeAuthStore struct {
+	database.Store
+	user   database.User
+	hash   string
+	hashes int // password hash lookups
+}
+
+func (f *fakeAuthStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	if name != f.user.Name {
+		return database.User{}, sql.ErrNoRows
+	}
+	return f.user, nil
+}
+
+func (f *fakeAuthStore) GetUserPasswordHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	f.hashes++
+	return f.hash, nil
+}
+
+// send a basic auth request from a client, returning the status
+func basicAuthStatus(srv *Server, client, name, given string) int {
+	handler := srv.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/session", nil)
+	req.RemoteAddr = client + ":50000"
+	req.SetBasicAuth(name, given)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec.Code
+}
+
+// wrong passwords (and unknown names) are limited per client, right ones are remembered
+func TestPasswordAuth(t *testing.T) {
+	hash, err := password.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+	store := &fakeAuthStore{user: database.User{ID: uuid.New(), Name: "kahya"}, hash: hash}
+	srv := NewServer(store, nil)
+
+	// the right password works, from any client
+	if status := basicAuthStatus(srv, "192.0.2.1", "kahya", "correct horse"); status != http.StatusNoContent {
+		t.Fatalf("right password answered %d, want 204", status)
+	}
+
+	// wrong passwords and unknown names count alike, then the client is blocked
+	attempts := []string{"kahya", "nobody", "kahya", "nobody", "kahya"}
+	for _, name := range attempts {
+		if status := basicAuthStatus(srv, "192.0.2.2", name, "guess"); status != http.StatusUnauthorized {
+			t.Fatalf("wrong password for %s answered %d, want 401", name, status)
+		}
+	}
+	if status := basicAuthStatus(srv, "192.0.2.2", "kahya", "correct horse"); status != http.StatusTooManyRequests {
+		t.Errorf("blocked client answered %d, want 429", status)
+	}
+
+	// other clients aren't blocked, and the remembered password still checks the stored hash
+	lookups := store.hashes
+	if status := basicAuthStatus(srv, "192.0.2.3", "kahya", "correct horse"); status != http.StatusNoContent {
+		t.Errorf("other client answered %d, want 204", status)
+	}
+	if store.hashes != lookups+1 {
+		t.Errorf("remembered password didn't look up the stored hash")
+	}
+
+	// a changed password ends the remembered one
+	store.hash, err = password.Hash("battery staple")
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+	if status := basicAuthStatus(srv, "192.0.2.3", "kahya", "correct horse"); status != http.StatusUnauthorized {
+		t.Errorf("old password after a change answered %d, want 401", status)
+	}
+}
+
+// a remembered password serves the user as stored now, a demoted admin isn't one until the entry expires
+func TestPasswordAuthReloadsUser(t *testing.T) {
+	hash, err := password.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+	store := &fakeAuthStore{user: database.User{ID: uuid.New(), Name: "kahya", IsAdmin: true}, hash: hash}
+	srv := NewServer(store, nil)
+
+	// the handler's user
+	var got database.User
+	handler := srv.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		got = authUser(r)
+	})
+	request := func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/session", nil)
+		req.SetBasicAuth("kahya", "correct horse")
+		handler(httptest.NewRecorder(), req)
+	}
+
+	// admin check, then demoted between requests
+	request()
+	if !got.IsAdmin {
+		t.Fatalf("admin served as %+v", got)
+	}
+	store.user.IsAdmin = false
+	got = database.User{}
+	request()
+	if got.ID != store.user.ID || got.IsAdmin {
+		t.Errorf("demoted admin served as %+v from the remembered password, want a plain user", got)
+	}
+
+	// a user with the same name but another id isn't served the remembered one
+	store.user.ID = uuid.New()
+	got = database.User{}
+	request()
+	if got.ID != store.user.ID {
+		t.Errorf("recreated user served as %s, want %s", got.ID, store.user.ID)
+	}
+}
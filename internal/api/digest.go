@@ -0,0 +1,74 @@
+// digest.go
+package api
+
+import (
+	// std go libraries
+	"database/sql"  // no rows
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // printing errors
+	"html/template" // the unsubscribe pages
+	"log/slog"      // write errors
+	"net/http"      // the handlers
+)
+
+// the unsubscribe pages, a confirm form first so link scanners in mail clients can't unsubscribe anyone
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Unsubscribe from gator digests</title></head>
+<body style="font-family: sans-serif;">
+{{if .Done}}<p>{{if .Email}}{{.Email}} won't get gator digests anymore.{{else}}You're already unsubscribed.{{end}}</p>
+{{else}}<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<p>Stop emailing gator digests?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+// a new unsubscribe token, for a digest subscription's unsubscribe links
+func NewUnsubscribeToken() (string, error) {
+	token, err := randomToken()
+
+	// random check
+	if err != nil {
+		return "", fmt.Errorf("error generating unsubscribe token: %w", err)
+	}
+	return token, nil
+}
+
+// digest unsubscribe endpoint, the link in each digest (no auth, the token is the proof)
+// NOTE: GET asks to confirm, POST unsubscribes (also what one-click List-Unsubscribe-Post mail clients send)
+func (srv *Server) handleDigestUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	// token check
+	token := r.FormValue("token")
+	if token == "" {
+		http.Error(w, "missing unsubscribe token", http.StatusBadRequest)
+		return
+	}
+
+	// confirm check
+	page := struct {
+		Token string
+		Done  bool
+		Email string
+	}{Token: token}
+	if r.Method == http.MethodPost {
+		email, err := srv.db.UnsubscribeDigestByToken(r.Context(), token)
+
+		// unsubscribe check (an unknown token was already used, say it's done either way)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("error unsubscribing from digests", "err", err)
+			http.Error(w, "error unsubscribing", http.StatusInternalServerError)
+			return
+		}
+		page.Done, page.Email = true, email
+	}
+
+	// write the page
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := unsubscribePage.Execute(w, page)
+	if err != nil {
+		slog.Error("error writing unsubscribe page", "err", err)
+	}
+}
@@ -0,0 +1,359 @@
+// fever.go
+package api
+
+import (
+	// std go libraries
+	"crypto/md5"    // fever api keys
+	"crypto/sha256" // hashing stored api keys
+	"database/sql"  // no rows
+	"encoding/hex"  // api keys as hex
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // printing errors
+	"net/http"      // the endpoint
+	"strconv"       // parsing ids and times
+	"strings"       // id lists
+	"time"          // unix timestamps
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// fever api constants
+const (
+	feverVersion   = 3  // the api version fever clients expect
+	feverItemLimit = 50 // items per items request, as fever clients page by 50
+)
+
+// a fever request the client got wrong (400), other errors are the server's (500)
+var errBadRequest = errors.New("bad request")
+
+// get the fever api key of a user, md5("name:password") as hex like fever clients compute it
+func FeverAPIKey(name, password string) string {
+	sum := md5.Sum([]byte(name + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// hash a fever api key for storing, so the database never holds the key itself
+func HashFeverAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(apiKey)))
+	return hex.EncodeToString(sum[:])
+}
+
+// fever endpoint, the sync api of Reeder, ReadKit, Unread and other readers
+// NOTE: one url, what to do is in the query (?api&items&since_id=...), the api_key in the post body
+func (srv *Server) handleFever(w http.ResponseWriter, r *http.Request) {
+	// parse the query and post body
+	err := r.ParseForm()
+
+	// parse check
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form: "+err.Error())
+		return
+	}
+
+	// api check, fever only answers ?api requests
+	if !r.Form.Has("api") {
+		writeError(w, http.StatusBadRequest, "not a fever api request (add ?api)")
+		return
+	}
+
+	// the base response, auth 0 until the key checks out
+	response := map[string]any{"api_version": feverVersion, "auth": 0}
+
+	// get the user the api key belongs to
+	user, err := srv.db.GetUserByFeverAPIKey(r.Context(), HashFeverAPIKey(r.Form.Get("api_key")))
+
+	// auth check (fever clients expect a 200 with auth 0)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	if err != nil {
+		writeServerError(w, "error checking fever api key", err)
+		return
+	}
+	response["auth"] = 1
+
+	// get the followed feeds, most responses need them
+	feeds, err := srv.db.ListFeverFeeds(r.Context(), user.ID)
+
+	// get feeds check
+	if err != nil {
+		writeServerError(w, "error getting fever feeds", err)
+		return
+	}
+
+	// when the feeds were last fetched
+	var lastRefreshed time.Time
+	for _, feed := range feeds {
+		if feed.LastFetchedAt.Valid && feed.LastFetchedAt.Time.After(lastRefreshed) {
+			lastRefreshed = feed.LastFetchedAt.Time
+		}
+	}
+	response["last_refreshed_on_time"] = unixTime(lastRefreshed)
+
+	// marks first, so the lists below already show them
+	if r.Form.Has("mark") {
+		err = srv.feverMark(r, user)
+
+		// mark check
+		if err != nil {
+			writeFeverError(w, err)
+			return
+		}
+	}
+
+	// groups, the user's folders
+	if r.Form.Has("groups") {
+		groups, err := srv.db.ListFeverGroups(r.Context(), user.ID)
+
+		// get groups check
+		if err != nil {
+			writeServerError(w, "error getting fever groups", err)
+			return
+		}
+
+		// add the groups
+		list := make([]map[string]any, 0, len(groups))
+		for _, group := range groups {
+			list = append(list, map[string]any{"id": group.FeverID, "title": group.Name})
+		}
+		response["groups"] = list
+		response["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+
+	// feeds, the followed feeds
+	if r.Form.Has("feeds") {
+		list := make([]map[string]any, 0, len(feeds))
+		for _, feed := range feeds {
+			list = append(list, map[string]any{
+				"id":                   feed.FeverID,
+				"favicon_id":           0,
+				"title":                feed.Name,
+				"url":                  feed.Url,
+				"site_url":             feed.Url,
+				"is_spark":             0,
+				"last_updated_on_time": unixTime(feed.LastFetchedAt.Time),
+			})
+		}
+		response["feeds"] = list
+		response["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+
+	// favicons and links, gator has neither
+	if r.Form.Has("favicons") {
+		response["favicons"] = []any{}
+	}
+	if r.Form.Has("links") {
+		response["links"] = []any{}
+	}
+
+	// items, a page of posts
+	if r.Form.Has("items") {
+		items, total, err := srv.feverItems(r, user)
+
+		// get items check
+		if err != nil {
+			writeFeverError(w, err)
+			return
+		}
+		response["items"] = items
+		response["total_items"] = total
+	}
+
+	// unread and saved item ids, as comma separated strings
+	if r.Form.Has("unread_item_ids") {
+		ids, err := srv.db.ListUnreadFeverItemIDs(r.Context(), user.ID)
+
+		// get unread check
+		if err != nil {
+			writeServerError(w, "error getting unread items", err)
+			return
+		}
+		response["unread_item_ids"] = joinIDs(ids)
+	}
+	if r.Form.Has("saved_item_ids") {
+		ids, err := srv.db.ListSavedFeverItemIDs(r.Context(), user.ID)
+
+		// get saved check
+		if err != nil {
+			writeServerError(w, "error getting saved items", err)
+			return
+		}
+		response["saved_item_ids"] = joinIDs(ids)
+	}
+
+	// return the response
+	writeJSON(w, http.StatusOK, response)
+}
+
+// apply a fever mark helper: mark=item&as=read|unread|saved|unsaved&id=, or mark=feed|group&as=read&id=&before=
+func (srv *Server) feverMark(r *http.Request, user database.User) error {
+	// get the id
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+
+	// id check
+	if err != nil {
+		return fmt.Errorf("%w: invalid id %q", errBadRequest, r.Form.Get("id"))
+	}
+
+	// match what to mark
+	mark, as := r.Form.Get("mark"), r.Form.Get("as")
+	switch {
+	case mark == "item" && (as == "read" || as == "unread"):
+		_, err = srv.db.SetPostRead(r.Context(), database.SetPostReadParams{UserID: user.ID, Read: as == "read", FeverID: id})
+	case mark == "item" && (as == "saved" || as == "unsaved"):
+		_, err = srv.db.SetPostSaved(r.Context(), database.SetPostSavedParams{UserID: user.ID, Saved: as == "saved", FeverID: id})
+	case (mark == "feed" || mark == "group") && as == "read":
+		// get the time the client last refreshed, newer posts stay unread
+		before, parseErr := strconv.ParseInt(r.Form.Get("before"), 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("%w: invalid before %q", errBadRequest, r.Form.Get("before"))
+		}
+		beforeTime := time.Unix(before, 0).UTC()
+
+		// mark the feed's or group's posts
+		if mark == "feed" {
+			_, err = srv.db.MarkFeverFeedRead(r.Context(), database.MarkFeverFeedReadParams{UserID: user.ID, FeedID: id, Before: beforeTime})
+		} else {
+			_, err = srv.db.MarkFeverGroupRead(r.Context(), database.MarkFeverGroupReadParams{UserID: user.ID, GroupID: id, Before: beforeTime})
+		}
+	default:
+		return fmt.Errorf("%w: unsupported mark=%s&as=%s", errBadRequest, mark, as)
+	}
+
+	// mark check
+	if err != nil {
+		return fmt.Errorf("error marking %s: %w", mark, err)
+	}
+	return nil
+}
+
+// get a page of fever items helper: since_id pages up, max_id pages down, with_ids picks up to 50
+func (srv *Server) feverItems(r *http.Request, user database.User) ([]map[string]any, int64, error) {
+	// create the query
+	params := database.ListFeverItemsParams{UserID: user.ID, ItemLimit: feverItemLimit}
+
+	// since_id and max_id check
+	for name, field := range map[string]*int64{"since_id": &params.SinceID, "max_id": &params.MaxID} {
+		if value := r.Form.Get(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 0 {
+				return nil, 0, fmt.Errorf("%w: invalid %s %q", errBadRequest, name, value)
+			}
+			*field = parsed
+		}
+	}
+	params.NewestFirst = params.MaxID > 0 && params.SinceID == 0
+
+	// with_ids check
+	if value := r.Form.Get("with_ids"); value != "" {
+		params.Ids = []int64{} // not nil, so an empty list matches nothing
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: invalid with_ids %q", errBadRequest, value)
+			}
+			params.Ids = append(params.Ids, id)
+		}
+		if len(params.Ids) > feverItemLimit {
+			params.Ids = params.Ids[:feverItemLimit]
+		}
+	}
+
+	// get the items
+	rows, err := srv.db.ListFeverItems(r.Context(), params)
+
+	// get items check
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting items: %w", err)
+	}
+
+	// get the total
+	total, err := srv.db.CountFeverItems(r.Context(), user.ID)
+
+	// count check
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting items: %w", err)
+	}
+
+	// convert to fever items
+	items := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, map[string]any{
+			"id":              row.FeverID,
+			"feed_id":         row.FeedFeverID,
+			"title":           row.Title,
+			"author":          "",
+			"html":            row.Description.String,
+			"url":             row.Url,
+			"is_saved":        boolInt(row.IsSaved),
+			"is_read":         boolInt(row.IsRead),
+			"created_on_time": unixTime(row.CreatedOn),
+		})
+	}
+
+	// return the items
+	return items, total, nil
+}
+
+// write a fever helper's error, 400 for the client's mistakes and 500 for the rest
+func writeFeverError(w http.ResponseWriter, err error) {
+	// bad request check
+	if errors.Is(err, errBadRequest) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeServerError(w, "error handling fever request", err)
+}
+
+// fever's feeds_groups helper, each group with its feed ids as a comma separated string
+func feverFeedsGroups(feeds []database.ListFeverFeedsRow) []map[string]any {
+	// collect the feed ids per group, in feed order
+	var groupIDs []int64
+	feedIDs := map[int64][]int64{}
+	for _, feed := range feeds {
+		// no folder check
+		if !feed.GroupID.Valid {
+			continue
+		}
+		if _, ok := feedIDs[feed.GroupID.Int64]; !ok {
+			groupIDs = append(groupIDs, feed.GroupID.Int64)
+		}
+		feedIDs[feed.GroupID.Int64] = append(feedIDs[feed.GroupID.Int64], feed.FeverID)
+	}
+
+	// build the list
+	list := make([]map[string]any, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		list = append(list, map[string]any{"group_id": groupID, "feed_ids": joinIDs(feedIDs[groupID])})
+	}
+	return list
+}
+
+// comma separated ids helper
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// unix timestamp helper, 0 for the zero time
+func unixTime(t time.Time) int64 {
+	// zero check
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fever's 0/1 booleans helper
+func boolInt(b bool) int {
+	// true check
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+// images.go
+package api
+
+import (
+	// std go libraries
+	"cmp"          // proxied or original url
+	"database/sql" // null descriptions
+	"log/slog"     // read errors
+	"net/http"     // the handler
+	"strings"      // image enclosures
+
+	// external packages
+	"github.com/google/uuid" // post ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/extract"    // images in descriptions
+	"github.com/PietPadda/aggregator/internal/imagecache" // the cache
+)
+
+// image proxy endpoint, serves an image agg cached (cache_images) by its key, so clients never load it from its host
+// NOTE: no auth (img tags can't send tokens), the key is the sha256 of the image's url so only known images are served
+func (srv *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	// cache check
+	if srv.images == nil {
+		writeError(w, http.StatusNotFound, "image caching is off")
+		return
+	}
+
+	// open the image
+	file, contentType, err := srv.images.Open(r.PathValue("key"))
+	if imagecache.IsNotCached(err) {
+		writeError(w, http.StatusNotFound, "no such image")
+		return
+	}
+	if err != nil {
+		writeServerError(w, "error reading image", err)
+		return
+	}
+	defer file.Close()
+
+	// serve it, cached by clients for good (a key is always the same image)
+	info, err := file.Stat()
+	if err != nil {
+		writeServerError(w, "error reading image", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
+
+// the image proxy path of a cached image helper, "" if it isn't cached (or caching is off)
+func (srv *Server) imagePath(imageURL string) string {
+	// cached check
+	if srv.images == nil || !srv.images.Has(imageURL) {
+		return ""
+	}
+	return "/images/" + imagecache.Key(imageURL)
+}
+
+// a page of posts' enclosures and images helper, images are image enclosures then description images, proxied when cached
+// with the cache on, also points the descriptions' img tags at the proxy, so cached posts render without their image hosts
+func (srv *Server) postMedia(r *http.Request, posts []post) {
+	// the page's enclosures
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	enclosures, err := srv.db.GetEnclosuresForPosts(r.Context(), ids)
+	if err != nil {
+		// the posts are still worth serving without their enclosures and images
+		slog.Error("error getting enclosures", "err", err)
+	}
+	postEnclosures := map[uuid.UUID][]enclosure{}
+	postImages := map[uuid.UUID][]string{}
+	for _, e := range enclosures {
+		postEnclosures[e.PostID] = append(postEnclosures[e.PostID], enclosure{
+			URL:      e.Url,
+			MimeType: nullString(e.MimeType),
+			Length:   nullInt64(e.Length),
+		})
+		if strings.HasPrefix(e.MimeType.String, "image/") {
+			postImages[e.PostID] = append(postImages[e.PostID], e.Url)
+		}
+	}
+
+	// each post's enclosures and images
+	for i := range posts {
+		posts[i].Enclosures = postEnclosures[posts[i].ID]
+		if posts[i].Enclosures == nil {
+			posts[i].Enclosures = []enclosure{} // [] rather than null
+		}
+
+		// the images
+		var description string
+		if posts[i].Description != nil {
+			description = *posts[i].Description
+		}
+		images := []string{}
+		for _, imageURL := range append(postImages[posts[i].ID], extract.Images(description)...) {
+			images = append(images, cmp.Or(srv.imagePath(imageURL), imageURL))
+		}
+		posts[i].Images = images
+
+		// proxy the description's images, and drop its tracking pixels
+		if posts[i].Description != nil && srv.images != nil {
+			proxied := extract.ReplaceImages(extract.RemoveTrackingPixels(description), srv.imagePath)
+			posts[i].Description = nullString(sql.NullString{String: proxied, Valid: true})
+		}
+	}
+}
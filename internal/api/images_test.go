@@ -0,0 +1,56 @@
+// images_test.go
+package api
+
+import (
+	// std go libraries
+	"context"           // for context
+	"database/sql"      // nullable enclosure fields
+	"encoding/json"     // checking the json shape
+	"net/http"          // requests
+	"net/http/httptest" // fake requests
+	"strings"           // matching json
+	"testing"           // go tests
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for the fake store
+	"github.com/google/uuid"                            // post ids
+)
+
+// a fake database with a page's enclosures
+type fakeEnclosureStore struct {
+	database.Store
+	enclosures []database.Enclosure
+}
+
+func (f *fakeEnclosureStore) GetEnclosuresForPosts(ctx context.Context, postIDs []uuid.UUID) ([]database.Enclosure, error) {
+	return f.enclosures, nil
+}
+
+// every enclosure is listed with its type and length, image ones are images too
+func TestPostMedia(t *testing.T) {
+	episode, plain := uuid.New(), uuid.New()
+	store := &fakeEnclosureStore{enclosures: []database.Enclosure{
+		{PostID: episode, Url: "https://example.com/ep1.mp3", MimeType: sql.NullString{String: "audio/mpeg", Valid: true}, Length: sql.NullInt64{Int64: 1234, Valid: true}},
+		{PostID: episode, Url: "https://example.com/cover.jpg", MimeType: sql.NullString{String: "image/jpeg", Valid: true}},
+	}}
+	srv := NewServer(store, nil)
+	posts := []post{{ID: episode}, {ID: plain}}
+	srv.postMedia(httptest.NewRequest(http.MethodGet, "/api/users/kahya/posts", nil), posts)
+
+	if len(posts[0].Enclosures) != 2 || posts[0].Enclosures[0].URL != "https://example.com/ep1.mp3" || *posts[0].Enclosures[0].Length != 1234 {
+		t.Errorf("enclosures %+v, want the mp3 and the jpg", posts[0].Enclosures)
+	}
+	if len(posts[0].Images) != 1 || posts[0].Images[0] != "https://example.com/cover.jpg" {
+		t.Errorf("images %v, want the jpg enclosure", posts[0].Images)
+	}
+
+	data, err := json.Marshal(posts)
+	if err != nil {
+		t.Fatalf("error marshalling: %v", err)
+	}
+	for _, want := range []string{`"mime_type":"audio/mpeg","length":1234`, `"mime_type":"image/jpeg","length":null`, `"images":[],"enclosures":[]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("json %s, want it to contain %s", data, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// limit.go
+package api
+
+import (
+	// std go libraries
+	"crypto/sha256" // auth cache keys
+	"net"           // client addresses
+	"net/http"      // the request's client
+	"sync"          // requests run in parallel
+	"time"          // windows and expiry
+
+	// external packages
+	"github.com/google/uuid" // user ids
+)
+
+// password check limits
+const (
+	maxFailedLogins = 5               // wrong passwords per client per window, then it has to wait
+	failedLoginsFor = time.Minute     // the window, from the first wrong password
+	authCacheTTL    = 5 * time.Minute // how long a checked password is remembered, so basic auth clients don't hash it every request
+	authSweepAt     = 1024            // entries kept before expired ones are swept out
+)
+
+// failed password checks per client, so nobody can guess passwords (or keep the cpu busy hashing them) without end
+type failureLimiter struct {
+	mu       sync.Mutex
+	failures map[string]failureWindow // by client ip
+}
+
+// a client's failures in its current window
+type failureWindow struct {
+	count int
+	start time.Time
+}
+
+// create an empty failure limiter
+func newFailureLimiter() *failureLimiter {
+	return &failureLimiter{failures: map[string]failureWindow{}}
+}
+
+// blocked check, with how long until the client may try again
+func (l *failureLimiter) blocked(client string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	window, ok := l.failures[client]
+	if !ok || now.Sub(window.start) >= failedLoginsFor || window.count < maxFailedLogins {
+		return 0, false
+	}
+	return window.start.Add(failedLoginsFor).Sub(now), true
+}
+
+// note a wrong password from a client
+func (l *failureLimiter) fail(client string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// sweep out old windows once there are many
+	if len(l.failures) >= authSweepAt {
+		for key, window := range l.failures {
+			if now.Sub(window.start) >= failedLoginsFor {
+				delete(l.failures, key)
+			}
+		}
+	}
+
+	// start a new window, or count in the current one
+	window, ok := l.failures[client]
+	if !ok || now.Sub(window.start) >= failedLoginsFor {
+		window = failureWindow{start: now}
+	}
+	window.count++
+	l.failures[client] = window
+}
+
+// the client's ip helper, what failures are counted by
+// NOTE: X-Forwarded-For isn't trusted, anyone can send it; behind a proxy every client shares its limit
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// checked passwords, a session for basic auth clients (they send the password with every request)
+// NOTE: keyed by the name, password and stored hash, so a changed or removed password ends it right away
+// only the user's id is kept, the user itself (is_admin, deleted_at) is read fresh on every request
+type authCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]authEntry
+}
+
+// a checked password's user
+type authEntry struct {
+	userID  uuid.UUID
+	expires time.Time
+}
+
+// create an empty auth cache
+func newAuthCache() *authCache {
+	return &authCache{entries: map[[sha256.Size]byte]authEntry{}}
+}
+
+// the cache key of a name, password and stored hash helper
+func authKey(name, given, hash string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(name + "\x00" + given + "\x00" + hash))
+}
+
+// get a checked password's user id, false if it wasn't checked lately
+func (c *authCache) get(key [sha256.Size]byte, now time.Time) (uuid.UUID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expires) {
+		return uuid.Nil, false
+	}
+	return entry.userID, true
+}
+
+// remember a checked password
+func (c *authCache) add(key [sha256.Size]byte, userID uuid.UUID, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= authSweepAt {
+		for cached, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, cached)
+			}
+		}
+	}
+	c.entries[key] = authEntry{userID: userID, expires: now.Add(authCacheTTL)}
+}
@@ -0,0 +1,171 @@
+// profile.go
+package api
+
+import (
+	// std go libraries
+	"database/sql"  // no rows
+	"encoding/xml"  // rendering the opml
+	"errors"        // matching sql.ErrNoRows
+	"html/template" // the profile page
+	"log/slog"      // write errors
+	"net/http"      // the handlers
+	"time"          // opml dates
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// the profile page, a blogroll of the feeds a user follows
+var profilePage = template.Must(template.New("profile").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}'s feeds</title>
+<link rel="alternate" type="text/x-opml" title="{{.Name}}'s feeds" href="{{.OPML}}">
+</head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto;">
+<h1>{{.Name}}'s feeds</h1>
+<p>The feeds {{.Name}} follows on gator. Follow them all in your own reader with <a href="{{.OPML}}">this OPML file</a>.</p>
+{{range .Folders}}{{if .Name}}<h2>{{.Name}}</h2>
+{{end}}<ul>
+{{range .Feeds}}<li><a href="{{.Url}}">{{.Name}}</a></li>
+{{end}}</ul>
+{{else}}<p>{{.Name}} doesn't follow any feeds yet.</p>
+{{end}}</body>
+</html>
+`))
+
+// a folder of feeds on the profile page (no name = not in a folder)
+type profileFolder struct {
+	Name  string
+	Feeds []database.ListProfileFeedsRow
+}
+
+// an opml 2.0 document, just what a feed list needs
+type opmlDocument struct {
+	XMLName xml.Name    `xml:"opml"`
+	Version string      `xml:"version,attr"`
+	Head    opmlHead    `xml:"head"`
+	Body    []opmlEntry `xml:"body>outline"`
+}
+
+// the document's head
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated"`
+}
+
+// a feed, or a folder of them
+type opmlEntry struct {
+	Text     string      `xml:"text,attr"`
+	Title    string      `xml:"title,attr,omitempty"`
+	Type     string      `xml:"type,attr,omitempty"`
+	XMLURL   string      `xml:"xmlUrl,attr,omitempty"`
+	Outlines []opmlEntry `xml:"outline"`
+}
+
+// profile endpoint, the feeds a user follows as a page (no auth, but only if they shared it)
+func (srv *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	// get the user and their feeds
+	owner, feeds, ok := srv.profileFeeds(w, r)
+	if !ok {
+		return
+	}
+
+	// group the feeds by folder (they're sorted by folder already)
+	folders := []profileFolder{}
+	for _, feed := range feeds {
+		if len(folders) == 0 || folders[len(folders)-1].Name != feed.Folder {
+			folders = append(folders, profileFolder{Name: feed.Folder})
+		}
+		folders[len(folders)-1].Feeds = append(folders[len(folders)-1].Feeds, feed)
+	}
+
+	// write the page
+	page := struct {
+		Name    string
+		OPML    string
+		Folders []profileFolder
+	}{Name: owner.Name, OPML: r.URL.Path + "/feeds.opml", Folders: folders}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := profilePage.Execute(w, page)
+	if err != nil {
+		slog.Error("error writing profile page", "err", err)
+	}
+}
+
+// profile opml endpoint, the feeds a user follows as opml, to import in any feed reader
+func (srv *Server) handleProfileOPML(w http.ResponseWriter, r *http.Request) {
+	// get the user and their feeds
+	owner, feeds, ok := srv.profileFeeds(w, r)
+	if !ok {
+		return
+	}
+
+	// create the document, folders are outlines of feeds
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       owner.Name + "'s feeds on gator",
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, feed := range feeds {
+		entry := opmlEntry{Text: feed.Name, Title: feed.Name, Type: "rss", XMLURL: feed.Url}
+
+		// not in a folder check
+		if feed.Folder == "" {
+			doc.Body = append(doc.Body, entry)
+			continue
+		}
+
+		// new folder check (they're sorted by folder)
+		last := len(doc.Body) - 1
+		if last < 0 || doc.Body[last].XMLURL != "" || doc.Body[last].Text != feed.Folder {
+			doc.Body = append(doc.Body, opmlEntry{Text: feed.Folder, Title: feed.Folder})
+			last++
+		}
+		doc.Body[last].Outlines = append(doc.Body[last].Outlines, entry)
+	}
+
+	// write the document
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err := encoder.Encode(doc)
+
+	// encode check (the client probably went away, the status is already sent)
+	if err != nil {
+		slog.Debug("error writing profile opml", "err", err)
+	}
+}
+
+// get the {name} profile's user and feeds helper, writing a 404 unless they shared it
+func (srv *Server) profileFeeds(w http.ResponseWriter, r *http.Request) (database.GetSharedProfileUserRow, []database.ListProfileFeedsRow, bool) {
+	// get the user, if they share their profile (404 either way, so names can't be probed)
+	name := r.PathValue("name")
+	owner, err := srv.db.GetSharedProfileUser(r.Context(), name)
+
+	// shared check
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "no profile for "+name, http.StatusNotFound)
+		return owner, nil, false
+	}
+	if err != nil {
+		slog.Error("error getting profile", "err", err)
+		http.Error(w, "error getting profile", http.StatusInternalServerError)
+		return owner, nil, false
+	}
+
+	// get their feeds
+	feeds, err := srv.db.ListProfileFeeds(r.Context(), owner.ID)
+
+	// get feeds check
+	if err != nil {
+		slog.Error("error getting profile feeds", "err", err)
+		http.Error(w, "error getting profile feeds", http.StatusInternalServerError)
+		return owner, nil, false
+	}
+	return owner, feeds, true
+}
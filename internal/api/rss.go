@@ -0,0 +1,147 @@
+// rss.go
+package api
+
+import (
+	// std go libraries
+	"database/sql" // no rows
+	"encoding/xml" // rendering the feed
+	"errors"       // matching sql.ErrNoRows
+	"log/slog"     // write errors
+	"net/http"     // the handler
+	"time"         // pub dates
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// posts in a shared timeline's feed
+const timelinePosts = 50
+
+// an rss 2.0 document, just what a timeline needs
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// the timeline's channel
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Generator     string    `xml:"generator"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          rssSelf   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+// the channel's own url, feed readers like it
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// a post in the timeline
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description,omitempty"`
+}
+
+// a post's guid, its url
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// shared timeline endpoint, a user's latest followed posts as rss (no auth, but only if they shared it)
+func (srv *Server) handleTimelineFeed(w http.ResponseWriter, r *http.Request) {
+	// get the user, if they share their timeline (404 either way, so names can't be probed)
+	name := r.PathValue("name")
+	owner, err := srv.db.GetSharedTimelineUser(r.Context(), name)
+
+	// shared check
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "no shared timeline for "+name, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("error getting shared timeline", "err", err)
+		http.Error(w, "error getting shared timeline", http.StatusInternalServerError)
+		return
+	}
+
+	// get their latest posts
+	posts, err := srv.db.ListPostsForUser(r.Context(), database.ListPostsForUserParams{
+		UserID:    owner.ID,
+		PostLimit: timelinePosts,
+	})
+
+	// get posts check
+	if err != nil {
+		slog.Error("error getting timeline posts", "err", err)
+		http.Error(w, "error getting timeline posts", http.StatusInternalServerError)
+		return
+	}
+
+	// the feed's own url, as the client reached it
+	scheme := "http"
+	if isHTTPS(r) {
+		scheme = "https"
+	}
+	self := scheme + "://" + r.Host + r.URL.Path
+
+	// create the feed
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       owner.Name + "'s gator timeline",
+			Link:        self,
+			Description: "The latest posts of the feeds " + owner.Name + " follows on gator",
+			Generator:   "Gator",
+			Self:        rssSelf{Href: self, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for i, post := range posts {
+		// newest post check, the feed was last built then
+		if i == 0 {
+			doc.Channel.LastBuildDate = postDate(post).Format(time.RFC1123Z)
+		}
+		item := rssItem{
+			Title:   post.Title,
+			Link:    post.Url,
+			GUID:    rssGUID{Value: post.Url, IsPermaLink: true},
+			PubDate: postDate(post).Format(time.RFC1123Z),
+		}
+		if post.Description.Valid {
+			item.Description = post.Description.String
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	// write the feed
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+
+	// encode check (the client probably went away, the status is already sent)
+	if err != nil {
+		slog.Debug("error writing timeline feed", "err", err)
+	}
+}
+
+// a post's date helper, when it was published, else when gator found it
+func postDate(post database.Post) time.Time {
+	// published check
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
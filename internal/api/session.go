@@ -0,0 +1,228 @@
+// session.go
+package api
+
+import (
+	// std go libraries
+	"context"       // the request's session
+	"crypto/subtle" // comparing csrf tokens in constant time
+	"database/sql"  // no rows
+	"encoding/json" // login bodies
+	"errors"        // matching sql.ErrNoRows
+	"log/slog"      // logging cleanup errors
+	"mime"          // the login content type
+	"net/http"      // the endpoints
+	"time"          // expiry
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// session constants
+const (
+	sessionCookie = "gator_session"
+	sessionTTL    = 30 * 24 * time.Hour // log in again after a month
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// request context key of the request's session, if it came with a cookie
+type sessionKey struct{}
+
+// a logged in session, in the request context
+type session struct {
+	tokenHash string
+	csrfToken string
+	expiresAt time.Time
+}
+
+// login request body
+type loginRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// session response
+type sessionResponse struct {
+	User      user       `json:"user"`
+	CSRFToken *string    `json:"csrf_token"` // null unless logged in with a cookie
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// login endpoint, starts a cookie session for a user with a password (see passwd)
+func (srv *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	// json only check, a cross-site html form can't send it, so nobody can be logged in behind their back
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "send the login as application/json")
+		return
+	}
+
+	// parse the body
+	var login loginRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&login)
+
+	// parse check
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid login: "+err.Error())
+		return
+	}
+
+	// check the name and password
+	dbUser, ok, err := srv.checkPassword(r, login.Name, login.Password)
+
+	// check check
+	if writeBlocked(w, err) {
+		return
+	}
+	if err != nil {
+		writeServerError(w, "error checking password", err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "wrong user name or password")
+		return
+	}
+
+	// create the session's token and csrf token
+	token, err := randomToken()
+	if err != nil {
+		writeServerError(w, "error creating session", err)
+		return
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		writeServerError(w, "error creating session", err)
+		return
+	}
+
+	// store the session
+	now := time.Now().UTC()
+	expiresAt := now.Add(sessionTTL)
+	err = srv.db.CreateSession(r.Context(), database.CreateSessionParams{
+		TokenHash: HashAPIToken(token),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+		UserID:    dbUser.ID,
+		CsrfToken: csrfToken,
+	})
+
+	// create check
+	if err != nil {
+		writeServerError(w, "error creating session", err)
+		return
+	}
+
+	// clean up expired sessions while at it
+	_, err = srv.db.DeleteExpiredSessions(r.Context(), now)
+	if err != nil {
+		slog.Warn("error deleting expired sessions", "err", err)
+	}
+
+	// set the cookie, out of reach of scripts and other sites
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	// return the session
+	writeJSON(w, http.StatusCreated, sessionResponse{
+		User:      toUser(dbUser),
+		CSRFToken: &csrfToken,
+		ExpiresAt: &expiresAt,
+	})
+}
+
+// session endpoint, who's logged in (and the csrf token, for cookie sessions)
+func (srv *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	// build the response
+	response := sessionResponse{User: toUser(authUser(r))}
+	if sess, ok := r.Context().Value(sessionKey{}).(session); ok {
+		response.CSRFToken, response.ExpiresAt = &sess.csrfToken, &sess.expiresAt
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// logout endpoint, ends the cookie session
+func (srv *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	// cookie session check
+	sess, ok := r.Context().Value(sessionKey{}).(session)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "not logged in with a session cookie")
+		return
+	}
+
+	// delete the session
+	_, err := srv.db.DeleteSession(r.Context(), sess.tokenHash)
+
+	// delete check
+	if err != nil {
+		writeServerError(w, "error deleting session", err)
+		return
+	}
+
+	// clear the cookie
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session cookie auth helper, requests that change things must also send the csrf token
+func (srv *Server) sessionAuth(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, token string) {
+	// get the session's user
+	tokenHash := HashAPIToken(token)
+	row, err := srv.db.GetSessionUser(r.Context(), database.GetSessionUserParams{
+		TokenHash: tokenHash,
+		Now:       time.Now().UTC(),
+	})
+
+	// valid session check
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusUnauthorized, "session expired or logged out, log in again")
+		return
+	}
+	if err != nil {
+		writeServerError(w, "error checking session", err)
+		return
+	}
+
+	// csrf check, only reads are safe without it
+	if !isSafeMethod(r.Method) && subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(row.CsrfToken)) != 1 {
+		writeError(w, http.StatusForbidden, "missing or wrong "+csrfHeader+" header")
+		return
+	}
+
+	// serve the request as the session's user
+	dbUser := database.User{
+		ID:                 row.ID,
+		CreatedAt:          row.CreatedAt,
+		UpdatedAt:          row.UpdatedAt,
+		Name:               row.Name,
+		DeletedAt:          row.DeletedAt,
+		BrowseCursorAt:     row.BrowseCursorAt,
+		BrowseCursorPostID: row.BrowseCursorPostID,
+		IsAdmin:            row.IsAdmin,
+	}
+	ctx := context.WithValue(r.Context(), userKey{}, dbUser)
+	ctx = context.WithValue(ctx, sessionKey{}, session{tokenHash: tokenHash, csrfToken: row.CsrfToken, expiresAt: row.ExpiresAt})
+	next(w, r.WithContext(ctx))
+}
+
+// methods that don't change anything helper
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// served over https helper, directly or behind a reverse proxy
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
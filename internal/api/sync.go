@@ -0,0 +1,374 @@
+// sync.go
+package api
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"database/sql"  // no rows
+	"encoding/json" // the sync bodies
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"net/http"      // the endpoint and its client
+	"strings"       // already following errors, base urls
+	"time"          // change times
+
+	// external packages
+	"github.com/google/uuid" // the user, post and follow ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/lang"     // post languages
+	"github.com/PietPadda/aggregator/internal/readtime" // post reading times
+	"github.com/PietPadda/aggregator/internal/rssfeed"  // the shared http transport (the config's http section)
+	"github.com/PietPadda/aggregator/internal/urlnorm"  // normalizing followed urls
+)
+
+// sync constants
+const (
+	maxSyncBody    = 64 << 20        // a first sync sends every post state, so this is generous
+	syncTimeout    = 5 * time.Minute // how long a sync exchange may take
+	syncPostsBatch = 1000            // posts stored per insert
+)
+
+// shared client for syncing with another instance, through the http config's proxy
+func syncClient() *http.Client {
+	return rssfeed.Service(syncTimeout)
+}
+
+// a follow, sent to the other instance
+type SyncFollow struct {
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// a post's state, sent to the other instance with the post (so it can be stored there)
+// the newer updated_at wins on the other side, nil times are unread, unstarred and unmuted
+type SyncState struct {
+	URL         string     `json:"url"`
+	FeedURL     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at"`
+	StarredAt   *time.Time `json:"starred_at"`
+	MutedAt     *time.Time `json:"muted_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// sync request body, the client's changes and where it's up to with the server's
+type SyncRequest struct {
+	Since   time.Time    `json:"since"` // the until of the last reply, zero = everything
+	Follows []SyncFollow `json:"follows"`
+	States  []SyncState  `json:"states"`
+}
+
+// sync reply body, the server's changes since the request's since
+type SyncReply struct {
+	Until   time.Time    `json:"until"` // the next request's since
+	Follows []SyncFollow `json:"follows"`
+	States  []SyncState  `json:"states"`
+	Applied int64        `json:"applied"` // how many of the request's states were newer and taken
+}
+
+// sync endpoint, exchanges follows and post states with another gator instance of the user's
+// the server's changes are gathered before the client's are applied, so the client isn't sent its own back
+func (srv *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	dbUser := authUser(r)
+
+	// parse the body
+	var request SyncRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSyncBody)).Decode(&request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid sync body: "+err.Error())
+		return
+	}
+
+	// the server's changes
+	reply := SyncReply{}
+	reply.Follows, reply.States, reply.Until, err = SyncChanges(r.Context(), srv.db, dbUser.ID, request.Since)
+	if err != nil {
+		writeServerError(w, "error getting changes", err)
+		return
+	}
+
+	// the client's follows, then its states (their posts need the feeds)
+	for _, follow := range request.Follows {
+		err = srv.syncFollow(r.Context(), dbUser, follow)
+		if err != nil {
+			writeServerError(w, "error following "+follow.URL, err)
+			return
+		}
+	}
+	reply.Applied, err = ApplySyncStates(r.Context(), srv.db, dbUser.ID, request.States)
+	if err != nil {
+		writeServerError(w, "error storing post states", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reply)
+}
+
+// follow a feed the client follows helper, adding it first if nobody has yet
+func (srv *Server) syncFollow(ctx context.Context, dbUser database.User, follow SyncFollow) error {
+	// check it like addfeed, it's fetched as given
+	feedURL, err := urlnorm.Clean(follow.URL)
+	if err != nil {
+		return nil // nothing gator could fetch, skipped
+	}
+	key, _ := urlnorm.Key(feedURL) // checked by Clean
+	urlKey := sql.NullString{String: key, Valid: true}
+
+	// stored spelled differently check (http:// vs https://, a trailing slash...)
+	if stored, err := srv.db.GetFeedByURLKey(ctx, urlKey); err == nil {
+		feedURL = stored.Url
+	}
+
+	// stored check
+	feedID := uuid.Nil
+	feed, err := srv.db.GetFeedByURL(ctx, feedURL)
+	switch {
+	case err == nil:
+		feedID = feed.ID
+	case errors.Is(err, sql.ErrNoRows):
+		name := follow.Name
+		if name == "" {
+			name = feedURL
+		}
+		now := time.Now()
+		created, err := srv.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Name:      name,
+			Url:       feedURL,
+			UserID:    dbUser.ID,
+			UrlKey:    urlKey,
+		})
+		if err != nil {
+			return err
+		}
+		feedID = created.ID
+	default:
+		return err
+	}
+
+	// follow it (following it already is fine)
+	now := time.Now()
+	_, err = srv.db.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    dbUser.ID,
+		FeedID:    feedID,
+	})
+	if err != nil && !strings.Contains(err.Error(), "unique constraint") {
+		return err
+	}
+	return nil
+}
+
+// a user's follows and post states changed after since, and the time the next sync starts from
+// NOTE: until is the newest change's own time (not the clock), so both sides compare times from one database
+func SyncChanges(ctx context.Context, db database.Store, userID uuid.UUID, since time.Time) ([]SyncFollow, []SyncState, time.Time, error) {
+	until := since
+
+	// follows
+	follows, err := db.ListFollowsChangedSince(ctx, database.ListFollowsChangedSinceParams{UserID: userID, Since: since})
+	if err != nil {
+		return nil, nil, since, fmt.Errorf("error getting follows: %w", err)
+	}
+	syncFollows := make([]SyncFollow, 0, len(follows))
+	for _, follow := range follows {
+		syncFollows = append(syncFollows, SyncFollow{Name: follow.Name, URL: follow.Url, FollowedAt: follow.CreatedAt})
+		if follow.CreatedAt.After(until) {
+			until = follow.CreatedAt
+		}
+	}
+
+	// post states
+	states, err := db.ListPostStatesChangedSince(ctx, database.ListPostStatesChangedSinceParams{UserID: userID, Since: since})
+	if err != nil {
+		return nil, nil, since, fmt.Errorf("error getting post states: %w", err)
+	}
+	syncStates := make([]SyncState, 0, len(states))
+	for _, state := range states {
+		syncStates = append(syncStates, SyncState{
+			URL:         state.Url,
+			FeedURL:     state.FeedUrl,
+			Title:       state.Title,
+			Description: state.Description.String,
+			PublishedAt: nullTimePtr(state.PublishedAt),
+			ReadAt:      nullTimePtr(state.ReadAt),
+			StarredAt:   nullTimePtr(state.SavedAt),
+			MutedAt:     nullTimePtr(state.MutedAt),
+			UpdatedAt:   state.UpdatedAt,
+		})
+		if state.UpdatedAt.After(until) {
+			until = state.UpdatedAt
+		}
+	}
+	return syncFollows, syncStates, until, nil
+}
+
+// store another instance's post states, the newer change wins
+// posts that aren't stored yet are stored first if their feed is, returns how many states were taken
+func ApplySyncStates(ctx context.Context, db database.Store, userID uuid.UUID, states []SyncState) (int64, error) {
+	// nothing to do check
+	if len(states) == 0 {
+		return 0, nil
+	}
+
+	// once per post, the newest (a row can only be changed once per insert)
+	newest := map[string]int{}
+	unique := make([]SyncState, 0, len(states))
+	for _, state := range states {
+		if i, ok := newest[state.URL]; ok {
+			if state.UpdatedAt.After(unique[i].UpdatedAt) {
+				unique[i] = state
+			}
+			continue
+		}
+		newest[state.URL] = len(unique)
+		unique = append(unique, state)
+	}
+
+	// the posts, per feed (posts already stored are kept as they are)
+	feeds := map[string]uuid.UUID{} // feed url -> id, uuid.Nil = not stored here
+	batches := map[uuid.UUID]*database.InsertPostsParams{}
+	params := database.SyncPostStatesParams{UserID: userID}
+	createdAt := time.Now()
+	for _, state := range unique {
+		// the state
+		params.Urls = append(params.Urls, state.URL)
+		params.ReadAts = append(params.ReadAts, timeOrZero(state.ReadAt))
+		params.SavedAts = append(params.SavedAts, timeOrZero(state.StarredAt))
+		params.MutedAts = append(params.MutedAts, timeOrZero(state.MutedAt))
+		params.UpdatedAts = append(params.UpdatedAts, state.UpdatedAt)
+
+		// its feed
+		feedID, ok := feeds[state.FeedURL]
+		if !ok {
+			feed, err := db.GetFeedByURL(ctx, state.FeedURL)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return 0, fmt.Errorf("error getting feed %s: %w", state.FeedURL, err)
+			}
+			feedID = feed.ID
+			feeds[state.FeedURL] = feedID
+		}
+		if feedID == uuid.Nil || state.Title == "" {
+			continue
+		}
+
+		// its post
+		batch := batches[feedID]
+		if batch == nil {
+			batch = &database.InsertPostsParams{CreatedAt: createdAt, FeedID: feedID}
+			batches[feedID] = batch
+		}
+		batch.Ids = append(batch.Ids, uuid.New())
+		batch.Titles = append(batch.Titles, state.Title)
+		batch.Urls = append(batch.Urls, state.URL)
+		batch.Descriptions = append(batch.Descriptions, state.Description)
+		batch.PublishedAts = append(batch.PublishedAts, timeOrZero(state.PublishedAt))
+		batch.Languages = append(batch.Languages, lang.Detect(state.Title+"\n"+state.Description))
+		batch.ReadingMinutes = append(batch.ReadingMinutes, int32(readtime.Minutes(state.Description)))
+		batch.Guids = append(batch.Guids, "")
+		if len(batch.Urls) >= syncPostsBatch {
+			_, err := db.InsertPosts(ctx, *batch)
+			if err != nil {
+				return 0, fmt.Errorf("error storing synced posts: %w", err)
+			}
+			delete(batches, feedID)
+		}
+	}
+	for _, batch := range batches {
+		_, err := db.InsertPosts(ctx, *batch)
+		if err != nil {
+			return 0, fmt.Errorf("error storing synced posts: %w", err)
+		}
+	}
+
+	// then the states
+	applied, err := db.SyncPostStates(ctx, params)
+	if err != nil {
+		return 0, fmt.Errorf("error storing post states: %w", err)
+	}
+	return applied, nil
+}
+
+// exchange changes with another gator instance's sync endpoint, as the api token's user
+func SyncWith(ctx context.Context, baseURL, token string, request SyncRequest) (SyncReply, error) {
+	var reply SyncReply
+	body, err := json.Marshal(request)
+	if err != nil {
+		return reply, fmt.Errorf("error encoding sync request: %w", err)
+	}
+	err = callInstance(ctx, http.MethodPost, baseURL, "/api/sync", token, bytes.NewReader(body), &reply)
+	return reply, err
+}
+
+// check an api token of another gator instance works, returns the name of its user
+func CheckInstance(ctx context.Context, baseURL, token string) (string, error) {
+	var reply sessionResponse
+	err := callInstance(ctx, http.MethodGet, baseURL, "/api/session", token, nil, &reply)
+	return reply.User.Name, err
+}
+
+// another gator instance's api helper, decoding the json reply into reply
+func callInstance(ctx context.Context, method, baseURL, path, token string, body io.Reader, reply any) error {
+	// build the request
+	target := strings.TrimRight(baseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("error creating request to %s: %w", target, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// send it
+	res, err := syncClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", target, err)
+	}
+	defer res.Body.Close()
+
+	// status check, the api's errors are {"error": "..."}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var apiError struct {
+			Error string `json:"error"`
+		}
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		if json.Unmarshal(detail, &apiError) != nil || apiError.Error == "" {
+			apiError.Error = strings.TrimSpace(string(detail))
+		}
+		return fmt.Errorf("error: %s returned %s: %s", target, res.Status, apiError.Error)
+	}
+
+	// decode the reply
+	err = json.NewDecoder(res.Body).Decode(reply)
+	if err != nil {
+		return fmt.Errorf("error decoding reply from %s: %w", target, err)
+	}
+	return nil
+}
+
+// nullable time as a pointer helper, NULL = nil
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// pointer time as an array value helper, nil = the zero time (NULL to SyncPostStates)
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
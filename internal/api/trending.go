@@ -0,0 +1,85 @@
+// trending.go
+package api
+
+import (
+	// std go libraries
+	"net/http" // the handler
+	"time"     // trending windows
+
+	// external packages
+	"github.com/google/uuid" // post ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// the trending windows, by name (like the trending cmd)
+var trendingPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// trending post response
+type trendingPost struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	Users       int64      `json:"users"` // how many users read or starred it
+	Reads       int64      `json:"reads"`
+	Stars       int64      `json:"stars"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// trending endpoint, the instance's most read and starred posts, a shared front page
+// NOTE: ?period=24h (default) or 7d
+func (srv *Server) handleTrending(w http.ResponseWriter, r *http.Request) {
+	// get the page
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// period check
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "24h"
+	}
+	window, ok := trendingPeriods[period]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid period "+period+" (use 24h or 7d)")
+		return
+	}
+
+	// get the posts up to the page, and one more to know if there's a next one
+	rows, err := srv.db.ListTrendingPosts(r.Context(), database.ListTrendingPostsParams{
+		Since:     time.Now().Add(-window),
+		PostLimit: int32(offset + limit + 1),
+	})
+
+	// trending check
+	if err != nil {
+		writeServerError(w, "error getting trending posts", err)
+		return
+	}
+
+	// convert to responses
+	posts := make([]trendingPost, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, trendingPost{
+			ID:          row.ID,
+			Title:       row.Title,
+			URL:         row.Url,
+			Feed:        row.FeedName,
+			Users:       row.Users,
+			Reads:       row.Reads,
+			Stars:       row.Stars,
+			PublishedAt: nullTime(row.PublishedAt),
+			CreatedAt:   row.CreatedAt,
+		})
+	}
+
+	// send the page
+	writeJSON(w, http.StatusOK, pageOf(posts, limit, offset))
+}
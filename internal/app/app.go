@@ -3,17 +3,33 @@ package app
 
 import (
 	// std go libraries
-	"fmt" // printing errors
+	"context"      // cancelling commands
+	"database/sql" // raw db connection
+	"fmt"          // printing errors
+	"io"           // output writers
+	"io/fs"        // embedded migrations
+	"strings"      // joining suggestions
 
 	// internal packages
 	"github.com/PietPadda/aggregator/internal/config"
 	"github.com/PietPadda/aggregator/internal/database"
+	"github.com/PietPadda/aggregator/internal/dialect"
+	"github.com/PietPadda/aggregator/internal/output"
+	"github.com/PietPadda/aggregator/internal/plugins"
+	"github.com/PietPadda/aggregator/internal/rssfeed"
 )
 
 // app state struct
 type State struct {
-	Config *config.Config    // config instance, ptr Config, Config type from config package
-	DB     *database.Queries // database instance, ptr to Queries type from database package
+	Config     *config.Config  // config instance, ptr Config, Config type from config package
+	DB         database.Store  // database instance, the sqlc Queries (or a fake in tests)
+	Conn       *sql.DB         // raw database connection, for transactions (DB.WithTx)
+	Output     output.Format   // output format from the --output global flag
+	Migrations fs.FS           // embedded sql/schema migration files, for migrate
+	Dialect    dialect.Dialect // postgres, cockroachdb or neon, gates unsupported features
+	HTTP       *rssfeed.Client // shared feed fetching client, from the config's http section
+	Stdout     io.Writer       // where handlers print their output (os.Stdout, a buffer in tests and telegram chats)
+	Stderr     io.Writer       // where handlers print progress and notes that aren't output (os.Stderr)
 }
 
 // cli command struct
@@ -22,32 +38,48 @@ type Command struct {
 	Args []string // args passed to command
 }
 
+// MaxArgs of a command that takes any number of args (lists, flags), its handler checks them
+const AnyArgs = -1
+
+// command descriptor struct, what Run checks before the handler and help prints
+type Spec struct {
+	Name     string   // command name called in CLI
+	Summary  string   // one line, for help
+	Usage    []string // its forms, the args after the name, eg "<username>" or "promote <username>"
+	MinArgs  int      // fewest args it takes
+	MaxArgs  int      // most args it takes, AnyArgs = no limit
+	LoggedIn bool     // needs a logged in user (its handler is wrapped in MiddlewareLoggedIn)
+}
+
 // commands handler struct
 type Commands struct {
-	Handler map[string]func(s *State, cmd Command) error // cmd map of key strs, takes state and cmd input
+	Handler map[string]func(ctx context.Context, s *State, cmd Command) error // cmd map of key strs, takes ctx, state and cmd input
+	Specs   map[string]Spec                                                   // each command's descriptor, same keys as Handler
 }
 
 // register new command method
-func (c *Commands) Register(name string, f func(*State, Command) error) error {
+func (c *Commands) Register(spec Spec, f func(context.Context, *State, Command) error) error {
 	// nil ptr check
 	if c == nil {
 		return fmt.Errorf("commands is nil")
 	}
 
-	// handler map init check
-	if c.Handler == nil {
-		return fmt.Errorf("Handler map is nil")
+	// handler and spec maps init check
+	if c.Handler == nil || c.Specs == nil {
+		return fmt.Errorf("Handler or Specs map is nil")
 	}
 
 	// register new command handler
-	c.Handler[name] = f // register func f as key "name" to the Handler map in commands (c)
+	c.Handler[spec.Name] = f // register func f as key "name" to the Handler map in commands (c)
+	c.Specs[spec.Name] = spec
 
 	// return success
 	return nil
 }
 
 // run a registered cmd method
-func (c *Commands) Run(s *State, cmd Command) error {
+// ctx is handed to the handler, so cancelling it (timeout, ctrl+c) stops the command
+func (c *Commands) Run(ctx context.Context, s *State, cmd Command) error {
 	// nil ptr check
 	if c == nil {
 		return fmt.Errorf("error: commands is nil")
@@ -71,10 +103,57 @@ func (c *Commands) Run(s *State, cmd Command) error {
 
 	// exist check
 	if !ok {
-		return fmt.Errorf("error: command is not registered: %s", commandName)
+		// plugin check, an aggregator-<name> executable on PATH (built-in commands always win)
+		if plugin, found := plugins.Find(commandName); found {
+			return runPlugin(ctx, s, plugin, cmd.Args)
+		}
+		return c.notRegistered(commandName)
+	}
+
+	// logged in check (no user lookup, the handler's middleware does that)
+	spec := c.Specs[commandName]
+	if spec.LoggedIn && (s.Config == nil || s.Config.Name == nil || *s.Config.Name == "") {
+		return fmt.Errorf("error: %s needs a logged in user, run login <username> first", commandName)
+	}
+
+	// arg count check
+	if len(cmd.Args) < spec.MinArgs || (spec.MaxArgs != AnyArgs && len(cmd.Args) > spec.MaxArgs) {
+		return spec.UsageError()
 	}
 
 	// return handler (which pass through an error)
-	return handler(s, cmd)
-	// we chose handler as name, and pass state and command, per func signature
+	return handler(ctx, s, cmd)
+	// we chose handler as name, and pass ctx, state and command, per func signature
+}
+
+// unknown command error helper, with the close registered commands (eg typos)
+func (c *Commands) notRegistered(name string) error {
+	// find close registered commands
+	suggestions := c.suggest(name)
+
+	// no close ones? just say it's not registered
+	if len(suggestions) == 0 {
+		return fmt.Errorf("error: command is not registered: %s", name)
+	}
+	return fmt.Errorf("error: command is not registered: %s (did you mean: %s?)", name, strings.Join(suggestions, ", "))
+}
+
+// run a plugin helper, it gets the config, user, output format and database the cli would use
+func runPlugin(ctx context.Context, s *State, plugin plugins.Plugin, args []string) error {
+	// what the plugin runs with
+	env := plugins.Env{Output: string(s.Output)}
+	if s.Config != nil {
+		env.Config, _ = s.Config.Path()
+		env.DBURL, _ = s.Config.DatabaseURL() // "" = not set, the plugin may not need it
+		if s.Config.Name != nil {
+			env.User = *s.Config.Name
+		}
+	}
+
+	// run it
+	err := plugin.Run(ctx, args, env, s.Stdout, s.Stderr)
+	if err != nil {
+		return fmt.Errorf("error: plugin %s (%s) failed: %w", plugin.Name, plugin.Path, err)
+	}
+	return nil
 }
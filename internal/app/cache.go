@@ -0,0 +1,230 @@
+// cache.go
+package app
+
+import (
+	// std go libraries
+	"context"      // query signatures
+	"database/sql" // transactions
+	"sync"         // the api serves requests in parallel
+	"time"         // entry expiry
+
+	// external packages
+	"github.com/google/uuid" // feed ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database"
+)
+
+// package-wide constants
+// NOTE: other processes (another agg, the cli next to serve) can't invalidate this one's cache,
+// so the ttl is what bounds how long their changes go unseen
+const StoreCacheTTL = 30 * time.Second
+
+// ttl cache struct, a map whose entries expire
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]ttlEntry[V]
+}
+
+// cached value with its expiry
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// create a ttl cache
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, entries: map[string]ttlEntry[V]{}}
+}
+
+// get a value, if it's there and hasn't expired
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// store a value until the ttl is up
+func (c *ttlCache[V]) set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlEntry[V]{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+// drop every value, after a write that may have changed any of them
+func (c *ttlCache[V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// caching store struct, remembers users by name and feeds by url for a while
+// NOTE: every command resolves its user and serve resolves one per request, this saves those round trips
+// NOTE: only hits are cached (a missing user or feed is asked again), and writes to users or feeds clear the cache
+type CachedStore struct {
+	database.Store
+	users *ttlCache[database.User]
+	feeds *ttlCache[database.GetFeedByURLRow]
+}
+
+// wrap a store in a cache whose entries live for ttl
+func NewCachedStore(store database.Store, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		Store: store,
+		users: newTTLCache[database.User](ttl),
+		feeds: newTTLCache[database.GetFeedByURLRow](ttl),
+	}
+}
+
+// CACHED READS
+
+// get a user by name, from the cache if it's there
+func (c *CachedStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	if user, ok := c.users.get(name); ok {
+		return user, nil
+	}
+	user, err := c.Store.GetUser(ctx, name)
+	if err != nil {
+		return user, err
+	}
+	c.users.set(name, user)
+	return user, nil
+}
+
+// get a feed by url, from the cache if it's there
+func (c *CachedStore) GetFeedByURL(ctx context.Context, url string) (database.GetFeedByURLRow, error) {
+	if feed, ok := c.feeds.get(url); ok {
+		return feed, nil
+	}
+	feed, err := c.Store.GetFeedByURL(ctx, url)
+	if err != nil {
+		return feed, err
+	}
+	c.feeds.set(url, feed)
+	return feed, nil
+}
+
+// WRITES THAT INVALIDATE
+
+// a transaction may write anything, so both caches are cleared
+// NOTE: a read between here and the commit can cache the old row again, until the ttl is up
+func (c *CachedStore) WithTx(tx *sql.Tx) *database.Queries {
+	c.users.clear()
+	c.feeds.clear()
+	return c.Store.WithTx(tx)
+}
+
+// clear a cache after a write helper, a failed write changed nothing
+func clearAfter[T any](cache *ttlCache[T], err error) {
+	if err == nil {
+		cache.clear()
+	}
+}
+
+func (c *CachedStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	user, err := c.Store.CreateUser(ctx, arg)
+	clearAfter(c.users, err)
+	return user, err
+}
+
+func (c *CachedStore) SetBrowseCursor(ctx context.Context, arg database.SetBrowseCursorParams) error {
+	err := c.Store.SetBrowseCursor(ctx, arg)
+	clearAfter(c.users, err)
+	return err
+}
+
+func (c *CachedStore) SetUserAdmin(ctx context.Context, arg database.SetUserAdminParams) (int64, error) {
+	rows, err := c.Store.SetUserAdmin(ctx, arg)
+	clearAfter(c.users, err)
+	return rows, err
+}
+
+// deleting users deletes (or soft deletes) their feeds too
+func (c *CachedStore) DeleteUser(ctx context.Context, name string) (int64, error) {
+	rows, err := c.Store.DeleteUser(ctx, name)
+	clearAfter(c.users, err)
+	clearAfter(c.feeds, err)
+	return rows, err
+}
+
+func (c *CachedStore) SoftDeleteUser(ctx context.Context, name string) (database.SoftDeleteUserRow, error) {
+	row, err := c.Store.SoftDeleteUser(ctx, name)
+	clearAfter(c.users, err)
+	clearAfter(c.feeds, err)
+	return row, err
+}
+
+func (c *CachedStore) UndeleteUser(ctx context.Context, name string) (database.UndeleteUserRow, error) {
+	row, err := c.Store.UndeleteUser(ctx, name)
+	clearAfter(c.users, err)
+	clearAfter(c.feeds, err)
+	return row, err
+}
+
+func (c *CachedStore) Reset(ctx context.Context) error {
+	err := c.Store.Reset(ctx)
+	clearAfter(c.users, err)
+	clearAfter(c.feeds, err)
+	return err
+}
+
+func (c *CachedStore) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	feed, err := c.Store.CreateFeed(ctx, arg)
+	clearAfter(c.feeds, err)
+	return feed, err
+}
+
+func (c *CachedStore) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	err := c.Store.MarkFeedFetched(ctx, id)
+	clearAfter(c.feeds, err)
+	return err
+}
+
+func (c *CachedStore) MarkFeedFailed(ctx context.Context, arg database.MarkFeedFailedParams) error {
+	err := c.Store.MarkFeedFailed(ctx, arg)
+	clearAfter(c.feeds, err)
+	return err
+}
+
+func (c *CachedStore) SetFeedNextFetch(ctx context.Context, arg database.SetFeedNextFetchParams) error {
+	err := c.Store.SetFeedNextFetch(ctx, arg)
+	clearAfter(c.feeds, err)
+	return err
+}
+
+func (c *CachedStore) SetFeedRefreshInterval(ctx context.Context, arg database.SetFeedRefreshIntervalParams) (int64, error) {
+	rows, err := c.Store.SetFeedRefreshInterval(ctx, arg)
+	clearAfter(c.feeds, err)
+	return rows, err
+}
+
+func (c *CachedStore) SetFeedSchedule(ctx context.Context, arg database.SetFeedScheduleParams) (int64, error) {
+	rows, err := c.Store.SetFeedSchedule(ctx, arg)
+	clearAfter(c.feeds, err)
+	return rows, err
+}
+
+func (c *CachedStore) DeleteFeedByURL(ctx context.Context, url string) (int64, error) {
+	rows, err := c.Store.DeleteFeedByURL(ctx, url)
+	clearAfter(c.feeds, err)
+	return rows, err
+}
+
+func (c *CachedStore) SoftDeleteFeedByURL(ctx context.Context, url string) (int64, error) {
+	rows, err := c.Store.SoftDeleteFeedByURL(ctx, url)
+	clearAfter(c.feeds, err)
+	return rows, err
+}
+
+func (c *CachedStore) UndeleteFeedByURL(ctx context.Context, url string) (int64, error) {
+	rows, err := c.Store.UndeleteFeedByURL(ctx, url)
+	clearAfter(c.feeds, err)
+	return rows, err
+}
@@ -0,0 +1,121 @@
+// help.go
+package app
+
+import (
+	// std go libraries
+	"context"        // handler signature
+	"fmt"            // printing
+	"sort"           // ordering commands
+	"strings"        // joining usage forms
+	"text/tabwriter" // aligning the command list
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/output"  // --output formats
+	"github.com/PietPadda/aggregator/internal/plugins" // plugins on PATH
+)
+
+// the command's forms with its name, eg "admin promote <username>"
+func (spec Spec) UsageLines() []string {
+	// no forms = no args
+	if len(spec.Usage) == 0 {
+		return []string{spec.Name}
+	}
+
+	// one line per form
+	lines := make([]string, 0, len(spec.Usage))
+	for _, form := range spec.Usage {
+		lines = append(lines, strings.TrimSpace(spec.Name+" "+form))
+	}
+	return lines
+}
+
+// the usage error for wrong args, in the same shape the handlers' own usage errors have
+func (spec Spec) UsageError() error {
+	return fmt.Errorf("error: usage: %s", strings.Join(spec.UsageLines(), " | "))
+}
+
+// help handler logic
+// NOTE: cmd will be help [command], lists the registered commands or prints one's usage
+// NOTE: registered as the method value cmds.Help, so it sees the commands it describes
+func (c *Commands) Help(ctx context.Context, s *State, cmd Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// one command check
+	if len(cmd.Args) == 1 {
+		spec, ok := c.Specs[cmd.Args[0]]
+		if !ok {
+			// plugin check, they print their own usage
+			if plugin, found := plugins.Find(cmd.Args[0]); found {
+				fmt.Fprintf(s.Stdout, "%s is a plugin (%s), see aggregator %s --help\n", plugin.Name, plugin.Path, plugin.Name)
+				return nil
+			}
+			return c.notRegistered(cmd.Args[0])
+		}
+		fmt.Fprintln(s.Stdout, "Usage:")
+		for _, line := range spec.UsageLines() {
+			fmt.Fprintf(s.Stdout, "  aggregator %s\n", line)
+		}
+		fmt.Fprintf(s.Stdout, "\n%s\n", spec.Summary)
+		if spec.LoggedIn {
+			fmt.Fprintln(s.Stdout, "Needs a logged in user.")
+		}
+		return nil
+	}
+
+	// sort the commands (maps are random order!)
+	specs := make([]Spec, 0, len(c.Specs))
+	for _, spec := range c.Specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+
+	// and the plugins on PATH, minus any a built-in command hides
+	var found []plugins.Plugin
+	for _, plugin := range plugins.List() {
+		if _, builtin := c.Specs[plugin.Name]; !builtin {
+			found = append(found, plugin)
+		}
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "usage", "summary", "logged_in"}}
+		for _, spec := range specs {
+			table.Add(spec.Name, strings.Join(spec.UsageLines(), " | "), spec.Summary, spec.LoggedIn)
+		}
+		for _, plugin := range found {
+			table.Add(plugin.Name, plugin.Name+" [args...]", "plugin: "+plugin.Path, false)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// print the list, aligned
+	fmt.Fprintln(s.Stdout, "Usage: aggregator [global flags] <command> [args...]")
+	fmt.Fprintln(s.Stdout, "\nCommands:")
+	w := tabwriter.NewWriter(s.Stdout, 0, 0, 2, ' ', 0)
+	for _, spec := range specs {
+		fmt.Fprintf(w, "  %s\t%s\n", spec.Name, spec.Summary)
+	}
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	// plugins check
+	if len(found) > 0 {
+		fmt.Fprintln(s.Stdout, "\nPlugins:")
+		w = tabwriter.NewWriter(s.Stdout, 0, 0, 2, ' ', 0)
+		for _, plugin := range found {
+			fmt.Fprintf(w, "  %s\t%s\n", plugin.Name, plugin.Path)
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(s.Stdout, "\nRun aggregator help <command> for its usage.")
+	return nil
+}
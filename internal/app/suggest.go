@@ -0,0 +1,74 @@
+// suggest.go
+package app
+
+import (
+	// std go libraries
+	"sort" // ordering suggestions
+)
+
+// package-wide constants
+const maxSuggestDistance = 3 // max edits for a command to count as "close"
+
+// find the registered commands closest to an unknown command name
+func (c *Commands) suggest(name string) []string {
+	// short names allow fewer edits (else "x" would match every 3 letter command!)
+	maxDistance := min(maxSuggestDistance, max(1, len([]rune(name))/2))
+
+	// best distance so far, and the commands at that distance
+	best := maxDistance + 1
+	var closest []string
+
+	// compare name to every registered command
+	for registered := range c.Handler {
+		distance := levenshtein(name, registered)
+
+		// closer check (new best, start over)
+		if distance < best {
+			best = distance
+			closest = []string{registered}
+			continue
+		}
+
+		// tie check (keep all equally close ones)
+		if distance == best {
+			closest = append(closest, registered)
+		}
+	}
+
+	// sort for stable output (maps are random order!)
+	sort.Strings(closest)
+	return closest
+}
+
+// levenshtein distance helper, the number of single char edits from a to b
+func levenshtein(a, b string) int {
+	// work on runes, not bytes
+	ra, rb := []rune(a), []rune(b)
+
+	// previous row of the distance matrix (distance from "" to b[:j])
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	// fill in the matrix one row at a time
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i // distance from a[:i] to ""
+
+		for j := 1; j <= len(rb); j++ {
+			// substitution is free if the runes match
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			// cheapest of delete, insert, substitute
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	// bottom right of the matrix
+	return prev[len(rb)]
+}
@@ -0,0 +1,163 @@
+// archive.go
+package archive
+
+import (
+	// std go libraries
+	"bufio"         // reading archives line by line
+	"compress/gzip" // compressing archives
+	"encoding/json" // one json object per line
+	"fmt"           // printing errors
+	"os"            // archive files
+	"path/filepath" // archive paths
+	"regexp"        // archive search
+	"time"          // archive file names
+)
+
+// archived post struct, one json line per post
+// NOTE: feed name/url are copied in, as the feed may be gone by the time we search
+type Post struct {
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"` // nil = no pubdate
+	CreatedAt   time.Time  `json:"created_at"`
+	FeedName    string     `json:"feed_name"`
+	FeedURL     string     `json:"feed_url"`
+}
+
+// search match struct, a post and the archive it was found in
+type Match struct {
+	Post Post
+	File string // archive file name
+}
+
+// write posts to a new gzipped JSONL archive in dir, returns its path
+func Write(dir string, posts []Post) (string, error) {
+	// create the archive dir (and parents) if needed
+	err := os.MkdirAll(dir, 0700)
+
+	// mkdir check
+	if err != nil {
+		return "", fmt.Errorf("error creating archive dir: %w", err)
+	}
+
+	// one archive per prune, named by time so they sort
+	path := filepath.Join(dir, fmt.Sprintf("posts-%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z")))
+
+	// create the file (O_EXCL, never overwrite an older archive!)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+
+	// create check
+	if err != nil {
+		return "", fmt.Errorf("error creating archive: %w", err)
+	}
+	defer file.Close() // closed again below, this is for the error returns
+
+	// gzip everything we write to the file
+	zw := gzip.NewWriter(file)
+	encoder := json.NewEncoder(zw) // Encode adds the newline, ie JSONL
+
+	// write each post as a line
+	for _, post := range posts {
+		err := encoder.Encode(post)
+
+		// encode check
+		if err != nil {
+			os.Remove(path) // don't leave half an archive behind
+			return "", fmt.Errorf("error writing archive: %w", err)
+		}
+	}
+
+	// flush gzip and the file, either can fail (eg disk full)
+	err = zw.Close()
+	if err == nil {
+		err = file.Close()
+	}
+
+	// close check
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("error closing archive: %w", err)
+	}
+
+	// return the archive path
+	return path, nil
+}
+
+// search all archives in dir for posts whose title, url or description match re
+func Search(dir string, re *regexp.Regexp) ([]Match, error) {
+	// find the archives (sorted by name, ie oldest first)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+
+	// glob check
+	if err != nil {
+		return nil, fmt.Errorf("error listing archives: %w", err)
+	}
+
+	// create nil slice for the matches
+	var matches []Match
+
+	// search each archive
+	for _, path := range paths {
+		// search the file using helper
+		found, err := searchFile(path, re)
+
+		// search check
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+
+	// return the matches
+	return matches, nil
+}
+
+// search a single archive file helper
+func searchFile(path string, re *regexp.Regexp) ([]Match, error) {
+	// open the archive
+	file, err := os.Open(path)
+
+	// open check
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %w", err)
+	}
+	defer file.Close()
+
+	// decompress as we read
+	zr, err := gzip.NewReader(file)
+
+	// gzip check
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	// create nil slice for the matches
+	var matches []Match
+
+	// read line by line (descriptions can be long, so a big buffer)
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		// decode the post
+		var post Post
+		err := json.Unmarshal(scanner.Bytes(), &post)
+
+		// decode check
+		if err != nil {
+			return nil, fmt.Errorf("error decoding archive %s: %w", path, err)
+		}
+
+		// match check
+		if re.MatchString(post.Title) || re.MatchString(post.URL) || re.MatchString(post.Description) {
+			matches = append(matches, Match{Post: post, File: filepath.Base(path)})
+		}
+	}
+
+	// return matches, and any read error
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading archive %s: %w", path, err)
+	}
+	return matches, nil
+}
@@ -0,0 +1,90 @@
+// bookmarks.go
+package bookmarks
+
+import (
+	// std go libraries
+	"fmt"     // printing errors
+	"html"    // unescaping titles and urls
+	"io"      // reading the export
+	"regexp"  // finding tags
+	"strings" // building folder paths
+)
+
+// a bookmark in a browser's export
+type Bookmark struct {
+	Title  string // as the browser shows it
+	URL    string // as bookmarked
+	Folder string // its folders, "Bookmarks bar/News" ("" = top level)
+}
+
+// the parts of an export that matter, in order: folder names, bookmarks and the lists that nest them
+var (
+	token   = regexp.MustCompile(`(?is)<h3\b[^>]*>(.*?)</h3\s*>|<a\b([^>]*)>(.*?)</a\s*>|<dl\b[^>]*>|</dl\s*>`)
+	href    = regexp.MustCompile(`(?is)\shref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	anyTag  = regexp.MustCompile(`(?s)<[^>]*>`)
+	doctype = regexp.MustCompile(`(?i)<!doctype\s+netscape-bookmark-file`)
+)
+
+// parse a netscape bookmarks html export (what every browser's "export bookmarks" writes)
+// NOTE: only http and https bookmarks are kept, not javascript: bookmarklets, place: queries...
+func Parse(r io.Reader) ([]Bookmark, error) {
+	// read the export
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bookmarks: %w", err)
+	}
+	page := string(data)
+
+	// format check
+	if !doctype.MatchString(page) {
+		return nil, fmt.Errorf("error: not a bookmarks export (no NETSCAPE-Bookmark-file doctype)")
+	}
+
+	// walk the tags, a folder's name comes right before its list
+	var bookmarks []Bookmark
+	var folders []string // the open lists' folder names ("" = not a folder's, eg the top one)
+	pending := ""        // the last folder name, waiting for its list
+	for _, match := range token.FindAllStringSubmatch(page, -1) {
+		tag := strings.ToLower(match[0])
+		switch {
+		case strings.HasPrefix(tag, "<h3"):
+			pending = text(match[1])
+		case strings.HasPrefix(tag, "<dl"):
+			folders = append(folders, pending)
+			pending = ""
+		case strings.HasPrefix(tag, "</dl"):
+			if len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+			}
+		default:
+			// a bookmark, web pages only
+			link := href.FindStringSubmatch(match[2])
+			if link == nil {
+				continue
+			}
+			url := strings.TrimSpace(html.UnescapeString(link[1] + link[2] + link[3]))
+			lower := strings.ToLower(url)
+			if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+				continue
+			}
+			bookmarks = append(bookmarks, Bookmark{Title: text(match[3]), URL: url, Folder: folderPath(folders)})
+		}
+	}
+	return bookmarks, nil
+}
+
+// a tag's contents as plain text helper
+func text(fragment string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(anyTag.ReplaceAllString(fragment, ""))), " ")
+}
+
+// the open folders as a path helper, skipping the lists that aren't a folder's
+func folderPath(folders []string) string {
+	var names []string
+	for _, name := range folders {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "/")
+}
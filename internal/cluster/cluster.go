@@ -0,0 +1,159 @@
+// cluster.go
+package cluster
+
+import (
+	// std go libraries
+	"hash/fnv" // hashing shingles
+	"math"     // empty signatures
+	"strings"  // splitting titles
+	"time"     // the time window
+	"unicode"  // splitting titles
+)
+
+// package-wide constants
+const (
+	hashes        = 128            // minhash signature length, more = a closer jaccard estimate
+	minShingles   = 3              // titles with fewer words are too generic to cluster
+	minSimilarity = 0.4            // estimated share of words two titles have in common, to be related
+	Window        = 48 * time.Hour // posts further apart than this are separate stories
+)
+
+// words too common to say anything about a story
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "how": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true,
+	"what": true, "why": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// a post to cluster
+type Post struct {
+	Title string
+	Time  time.Time // when it was published (or found)
+}
+
+// group posts covering the same story: similar titles (minhash of their words) within Window of each other
+// returns groups of indexes into posts, each in the posts' order, ordered by their first post
+func Group(posts []Post) [][]int {
+	// the minhash signature of each title (nil = too short to cluster)
+	signatures := make([][]uint64, len(posts))
+	for i, post := range posts {
+		signatures[i] = signature(shingles(post.Title))
+	}
+
+	// union the related posts (union-find, so a story's posts chain together)
+	parent := make([]int, len(posts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range posts {
+		for j := i + 1; j < len(posts); j++ {
+			// window check
+			gap := posts[i].Time.Sub(posts[j].Time)
+			if gap < -Window || gap > Window {
+				continue
+			}
+
+			// similarity check, the earlier post stays the root so groups keep the posts' order
+			if similarity(signatures[i], signatures[j]) >= minSimilarity {
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+
+	// collect the groups by root, in order of first post
+	groups := [][]int{}
+	index := map[int]int{}
+	for i := range posts {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// a title's shingles helper, its lowercase words without stopwords
+func shingles(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopwords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// minhash signature helper: per hash function, the smallest hash of any shingle
+// nil if there are too few shingles
+func signature(words map[string]bool) []uint64 {
+	// too short check
+	if len(words) < minShingles {
+		return nil
+	}
+
+	// start every slot at the max
+	sig := make([]uint64, hashes)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	// hash each shingle once, and derive the hash functions from it
+	for word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		base := h.Sum64()
+		for i := range sig {
+			if v := mix(base ^ seed(i)); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// estimated jaccard similarity helper, the share of equal signature slots
+func similarity(a, b []uint64) float64 {
+	// no signature check
+	if a == nil || b == nil {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// the i-th hash function's seed helper
+func seed(i int) uint64 {
+	return mix(uint64(i+1) * 0x9e3779b97f4a7c15)
+}
+
+// splitmix64 finalizer helper, scrambles the bits so each seed acts as its own hash function
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
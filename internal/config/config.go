@@ -3,21 +3,97 @@ package config
 
 import (
 	// import standard Go libraries
+	"bytes"         // decoding converted files
 	"encoding/json" // decoding json to go
+	"errors"        // keyring errors
 	"fmt"           // printing
+	"log/slog"      // config upgrade logs
+	"net/url"       // adding the schema to db urls
 	"os"            // for os file access
 	"path/filepath" // pilepath without str interpolation
+	"regexp"        // validating schema names
+	"slices"        // section settings
+	"strconv"       // parsing env overrides
+	"strings"       // checking the db url form
+	"time"          // agg intervals
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/keyring" // db passwords from the os keyring
+	"github.com/PietPadda/aggregator/internal/output"  // default output format
 )
 
 // package-wide constants
 const configFileName = ".gatorconfig.json"
+const defaultMinAggInterval = 10 * time.Second // agg refuses shorter intervals without --force
+const defaultServeAddr = ":8080"               // serve listens here without serve_addr
+const defaultNewsletterAddr = ":2525"          // newsletter receive listens here without newsletter_addr
 
 // . = makes it hidden on system! standard gopher practice for config files!
 
+// schema names we accept, plain lowercase identifiers (no quoting needed anywhere)
+var schemaName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// telegram bot tokens, <bot id>:<secret> (the secret ends up in api urls, so no odd characters)
+var telegramToken = regexp.MustCompile(`^[0-9]+:[A-Za-z0-9_-]+$`)
+
 // config struct
 type Config struct {
-	URL  *string `json:"db_url"`            // url of DB
-	Name *string `json:"current_user_name"` // username
+	// config shape version, old files are upgraded automatically (see migrate.go)
+	SchemaVersion int     `json:"schema_version"`
+	URL           *string `json:"db_url"`            // url of DB
+	Name          *string `json:"current_user_name"` // username
+	// os keyring alias holding db_url's password, so it's not stored in this file (optional, see DatabaseURL)
+	DBPasswordKeyring *string `json:"db_password_keyring,omitempty"`
+	// default --output format (optional, text if unset)
+	Output *string `json:"output,omitempty"`
+	// default agg interval, so agg runs without args (optional, eg "10m")
+	AggInterval *string `json:"agg_interval,omitempty"`
+	// log level when no -v/-q flag is given: trace, debug, info, warn or error (optional, default info)
+	LogLevel *string `json:"log_level,omitempty"`
+	// shortest agg interval allowed without --force, so a typo can't hammer feed hosts (optional, default 10s)
+	AggMinInterval *string `json:"agg_min_interval,omitempty"`
+	// where prune --archive writes old posts (optional, see ArchivePath)
+	ArchiveDir *string `json:"archive_dir,omitempty"`
+	// debug mode, agg keeps the last raw body of every feed (see snapshot cmd)
+	SnapshotFeeds bool `json:"snapshot_feeds,omitempty"`
+	// agg downloads new posts' images, serve's image proxy serves them (see ImageCachePath)
+	CacheImages bool `json:"cache_images,omitempty"`
+	// agg resolves shortened post links (t.co, bit.ly, feedproxy...) to the real ones before storing them
+	UnshortenLinks bool `json:"unshorten_links,omitempty"`
+	// how many due feeds agg fetches per tick, never fetched feeds first (0 = all, see agg --batch)
+	AggBatchSize int `json:"agg_batch_size,omitempty"`
+	// postgres-compatible database in use: postgres (default), cockroachdb or neon
+	DBDialect *string `json:"db_dialect,omitempty"`
+	// postgres schema of this instance, so several can share one database (optional, see DatabaseURL)
+	Schema *string `json:"schema,omitempty"`
+	// address the serve command listens on (optional, default :8080)
+	ServeAddr *string `json:"serve_addr,omitempty"`
+	// localhost address agg and serve expose net/http/pprof on (optional, default off)
+	PprofAddr *string `json:"pprof_addr,omitempty"`
+	// the feed fetching http client's settings (optional, see http.go)
+	HTTP *HTTPConfig `json:"http,omitempty"`
+	// the smtp server digests are sent through (optional, see smtp.go)
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+	// the provider browse --translate translates posts with (optional, see translate.go)
+	Translate *TranslateConfig `json:"translate,omitempty"`
+	// the summarizer agg writes post summaries with (optional, see summarize.go)
+	Summarize *SummarizeConfig `json:"summarize,omitempty"`
+	// how often agg emails digests to subscribed users (optional, default off, see digest)
+	DigestInterval *string `json:"digest_interval,omitempty"`
+	// the url serve is reached at from outside, for links in emails (optional, eg https://gator.example.com)
+	PublicURL *string `json:"public_url,omitempty"`
+	// the telegram bot token telegram bot runs as, from @BotFather (optional)
+	TelegramToken *string `json:"telegram_token,omitempty"`
+	// the domain newsletter addresses are at, its mx points at newsletter receive (optional, eg news.example.com)
+	NewsletterDomain *string `json:"newsletter_domain,omitempty"`
+	// address newsletter receive listens on for smtp (optional, default :2525)
+	NewsletterAddr *string `json:"newsletter_addr,omitempty"`
+	// opt-in anonymous usage reports, agg posts one a day to telemetry_url (see telemetry cmd, default off)
+	Telemetry bool `json:"telemetry,omitempty"`
+	// where the usage reports are posted (optional, telemetry is off without it)
+	TelemetryURL *string `json:"telemetry_url,omitempty"`
+
+	path string // the file it was read from, where SetUser and Set write (see ReadFrom)
 }
 
 // String method to format the Config struct when printing
@@ -38,18 +114,144 @@ func (c Config) String() string {
 	return fmt.Sprintf("Config{URL: %q, Name: %q}", url, name)
 }
 
-// read gatorconfig & return struct
+// read gatorconfig & return struct, with any GATOR_* env vars overriding the file values
+// NOTE: no config file is fine, so containers and CI can configure gator with env vars only
 func Read() (Config, error) {
-	// get config file path using helper
-	configPath, err := getConfigPath()
+	return ReadFrom("")
+}
+
+// read the config file at path (--config), "" = GATOR_CONFIG or the default location
+// the config remembers its path, so SetUser and Set write back to the same file
+func ReadFrom(path string) (Config, error) {
+	// default path check
+	if path == "" {
+		configPath, err := getConfigPath()
+
+		// configpath check
+		if err != nil {
+			return Config{}, fmt.Errorf("error getting filepath from helper: %w", err)
+		}
+		path = configPath
+	}
 
-	// configpath check
+	// read the file
+	cfg, err := readFile(path)
+	cfg.path = path
+
+	// read file check
+	if err != nil {
+		return cfg, err
+	}
+
+	// apply the env overrides
+	err = applyEnv(&cfg)
+
+	// env check
 	if err != nil {
-		return Config{}, fmt.Errorf("error getting filepath from helper: %w", err)
+		return cfg, err
+	}
+
+	// return Go config
+	return cfg, nil
+}
+
+// env var overrides, GATOR_<json key in caps> (GATOR_CONFIG picks the file itself, see getConfigPath)
+func applyEnv(cfg *Config) error {
+	// string settings
+	for env, field := range map[string]**string{
+		"GATOR_DB_URL":              &cfg.URL,
+		"GATOR_CURRENT_USER":        &cfg.Name,
+		"GATOR_DB_PASSWORD_KEYRING": &cfg.DBPasswordKeyring,
+		"GATOR_OUTPUT":              &cfg.Output,
+		"GATOR_AGG_INTERVAL":        &cfg.AggInterval,
+		"GATOR_AGG_MIN_INTERVAL":    &cfg.AggMinInterval,
+		"GATOR_LOG_LEVEL":           &cfg.LogLevel,
+		"GATOR_ARCHIVE_DIR":         &cfg.ArchiveDir,
+		"GATOR_DB_DIALECT":          &cfg.DBDialect,
+		"GATOR_SCHEMA":              &cfg.Schema,
+		"GATOR_SERVE_ADDR":          &cfg.ServeAddr,
+		"GATOR_PPROF_ADDR":          &cfg.PprofAddr,
+		"GATOR_DIGEST_INTERVAL":     &cfg.DigestInterval,
+		"GATOR_PUBLIC_URL":          &cfg.PublicURL,
+		"GATOR_TELEGRAM_TOKEN":      &cfg.TelegramToken,
+		"GATOR_NEWSLETTER_DOMAIN":   &cfg.NewsletterDomain,
+		"GATOR_NEWSLETTER_ADDR":     &cfg.NewsletterAddr,
+		"GATOR_TELEMETRY_URL":       &cfg.TelemetryURL,
+	} {
+		if value, ok := os.LookupEnv(env); ok {
+			*field = &value
+		}
 	}
 
-	// read raw json data
-	file, err := os.Open(configPath)
+	// bool settings
+	if value, ok := os.LookupEnv("GATOR_SNAPSHOT_FEEDS"); ok {
+		snapshot, err := strconv.ParseBool(value)
+
+		// parse check
+		if err != nil {
+			return fmt.Errorf("error: invalid GATOR_SNAPSHOT_FEEDS %q (use true or false)", value)
+		}
+		cfg.SnapshotFeeds = snapshot
+	}
+	if value, ok := os.LookupEnv("GATOR_CACHE_IMAGES"); ok {
+		cache, err := strconv.ParseBool(value)
+
+		// parse check
+		if err != nil {
+			return fmt.Errorf("error: invalid GATOR_CACHE_IMAGES %q (use true or false)", value)
+		}
+		cfg.CacheImages = cache
+	}
+	if value, ok := os.LookupEnv("GATOR_UNSHORTEN_LINKS"); ok {
+		unshorten, err := strconv.ParseBool(value)
+
+		// parse check
+		if err != nil {
+			return fmt.Errorf("error: invalid GATOR_UNSHORTEN_LINKS %q (use true or false)", value)
+		}
+		cfg.UnshortenLinks = unshorten
+	}
+	if value, ok := os.LookupEnv("GATOR_TELEMETRY"); ok {
+		telemetry, err := strconv.ParseBool(value)
+
+		// parse check
+		if err != nil {
+			return fmt.Errorf("error: invalid GATOR_TELEMETRY %q (use true or false)", value)
+		}
+		cfg.Telemetry = telemetry
+	}
+
+	// int settings
+	if value, ok := os.LookupEnv("GATOR_AGG_BATCH_SIZE"); ok {
+		batch, err := strconv.Atoi(value)
+
+		// parse check
+		if err != nil {
+			return fmt.Errorf("error: invalid GATOR_AGG_BATCH_SIZE %q (use a number of feeds)", value)
+		}
+		cfg.AggBatchSize = batch
+	}
+
+	// section settings, validated like config set
+	for _, setting := range slices.Concat(httpSettings, smtpSettings, translateSettings, summarizeSettings) {
+		if value, ok := os.LookupEnv(setting.env); ok {
+			err := setting.set(cfg, value)
+
+			// value check
+			if err != nil {
+				return fmt.Errorf("%w (from %s)", err, setting.env)
+			}
+		}
+	}
+
+	// return success
+	return nil
+}
+
+// read the config file only helper (no env overrides)
+func readFile(configPath string) (Config, error) {
+	// read raw file data
+	raw, err := os.ReadFile(configPath)
 
 	// read check
 	if err != nil {
@@ -63,19 +265,44 @@ func Read() (Config, error) {
 		}
 	}
 
-	defer file.Close() // end reading after return for filesafety
+	// toml and yaml files check, convert them to json first (json is left as is)
+	data, err := toJSON(raw, formatOf(configPath))
+
+	// convert check
+	if err != nil {
+		return Config{}, fmt.Errorf("error: %s is invalid (%w), run: aggregator config validate", configPath, err)
+	}
+
+	// upgrade files from older gator versions
+	upgraded, version, err := migrateJSON(data)
+
+	// migrate check
+	if err != nil {
+		return Config{}, fmt.Errorf("error: %s is invalid (%w), run: aggregator config validate", configPath, err)
+	}
 
 	// create nil slice for external data use
 	var cfg Config
 
 	// decode (unmarshal less efficient, negligible for config) raw json data to Go struct
-	decoder := json.NewDecoder(file)
+	decoder := json.NewDecoder(bytes.NewReader(upgraded))
+	decoder.DisallowUnknownFields() // strict, a typo'd key would otherwise be silently ignored
 	err = decoder.Decode(&cfg)
 	// reminder on unmarhsal: err := json.Unmarshal(file, &cfg)
 
-	// decode check
+	// decode check, config validate explains every problem (not just the first)
 	if err != nil {
-		return cfg, fmt.Errorf("error decoding config file: %w", err)
+		return cfg, fmt.Errorf("error: %s is invalid (%w), run: aggregator config validate", configPath, err)
+	}
+
+	// upgraded check, save the new shape (keeping the old file as a backup)
+	if version < currentVersion {
+		err = upgradeFile(configPath, raw, cfg, version)
+
+		// upgrade check
+		if err != nil {
+			return cfg, err
+		}
 	}
 
 	// return Go config
@@ -94,8 +321,25 @@ func (c *Config) SetUser(userName string) error {
 	// *cfg.Name = "gator bites!" - this will cause Go panic - field is still nil!
 	c.Name = &userName // safe way to update field
 
+	// get the file it was read from
+	configPath, err := c.Path()
+
+	// path check
+	if err != nil {
+		return err
+	}
+
+	// re-read the file, so env overrides (GATOR_DB_URL...) don't get written into it
+	fileCfg, err := readFile(configPath)
+
+	// read file check
+	if err != nil {
+		return err
+	}
+	fileCfg.Name = &userName
+
 	// write the updated config file using helper
-	err := write(*c)
+	err = write(configPath, fileCfg)
 
 	// write check
 	if err != nil {
@@ -106,8 +350,298 @@ func (c *Config) SetUser(userName string) error {
 	return nil
 }
 
-// get config file path helper function
+// get the archive dir, archive_dir from the config or ~/.gator/archive by default
+func (c Config) ArchivePath() (string, error) {
+	// configured check
+	if c.ArchiveDir != nil && *c.ArchiveDir != "" {
+		return *c.ArchiveDir, nil
+	}
+
+	// get home path
+	homePath, err := os.UserHomeDir()
+
+	// homepath check
+	if err != nil {
+		return "", fmt.Errorf("error getting home dir: %w", err)
+	}
+
+	// default archive dir
+	return filepath.Join(homePath, ".gator", "archive"), nil
+}
+
+// get the dir agg keeps its pid file, control socket and daemon log in (~/.gator)
+func (c Config) RunDir() (string, error) {
+	// get home path
+	homePath, err := os.UserHomeDir()
+
+	// homepath check
+	if err != nil {
+		return "", fmt.Errorf("error getting home dir: %w", err)
+	}
+
+	// the gator dir
+	return filepath.Join(homePath, ".gator"), nil
+}
+
+// get the file telemetry counts commands in until agg reports them (~/.gator/telemetry.json)
+func (c Config) TelemetryPath() (string, error) {
+	// get the gator dir
+	runDir, err := c.RunDir()
+
+	// run dir check
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runDir, "telemetry.json"), nil
+}
+
+// get the dir cache_images keeps images in (~/.gator/images)
+func (c Config) ImageCachePath() (string, error) {
+	// get the gator dir
+	runDir, err := c.RunDir()
+
+	// run dir check
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runDir, "images"), nil
+}
+
+// get the dir digests sent to files go in, a subdir per user (~/.gator/digests)
+func (c Config) DigestPath() (string, error) {
+	// get the gator dir
+	runDir, err := c.RunDir()
+
+	// run dir check
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runDir, "digests"), nil
+}
+
+// get the configured schema, "" if none (the server's default search_path, ie public)
+func (c Config) SchemaName() string {
+	// configured check
+	if c.Schema == nil {
+		return ""
+	}
+	return *c.Schema
+}
+
+// get the address serve listens on, serve_addr or :8080 by default
+func (c Config) ServeAddress() string {
+	// configured check
+	if c.ServeAddr == nil || *c.ServeAddr == "" {
+		return defaultServeAddr
+	}
+	return *c.ServeAddr
+}
+
+// get the address newsletter receive listens on, newsletter_addr or :2525 by default
+func (c Config) NewsletterAddress() string {
+	// configured check
+	if c.NewsletterAddr == nil || *c.NewsletterAddr == "" {
+		return defaultNewsletterAddr
+	}
+	return *c.NewsletterAddr
+}
+
+// get how often agg sends digests, 0 if it doesn't
+func (c Config) DigestEvery() time.Duration {
+	// configured check
+	if c.DigestInterval == nil {
+		return 0
+	}
+
+	// parse it (checked by config set, a hand edited bad one turns digests off)
+	interval, err := time.ParseDuration(*c.DigestInterval)
+	if err != nil || interval < time.Hour {
+		return 0
+	}
+	return interval
+}
+
+// get serve's public url without a trailing slash, "" if it's not set
+func (c Config) PublicBaseURL() string {
+	// configured check
+	if c.PublicURL == nil {
+		return ""
+	}
+	return strings.TrimRight(*c.PublicURL, "/")
+}
+
+// get the address pprof is served on, "" if it's off
+// NOTE: profiles show the process's internals, so only loopback addresses are allowed
+func (c Config) PprofAddress() (string, error) {
+	// configured check
+	if c.PprofAddr == nil || *c.PprofAddr == "" {
+		return "", nil
+	}
+
+	// localhost check (GATOR_PPROF_ADDR skips Set's check)
+	err := checkLoopback("pprof_addr", *c.PprofAddr)
+	if err != nil {
+		return "", err
+	}
+	return *c.PprofAddr, nil
+}
+
+// get the default output format, text if none
+func (c Config) OutputFormat() (output.Format, error) {
+	// configured check
+	if c.Output == nil || *c.Output == "" {
+		return output.Text, nil
+	}
+	return output.ParseFormat(*c.Output)
+}
+
+// get the shortest agg interval allowed without --force, agg_min_interval or 10s by default (0 = no minimum)
+func (c Config) MinAggInterval() (time.Duration, error) {
+	// configured check
+	if c.AggMinInterval == nil || *c.AggMinInterval == "" {
+		return defaultMinAggInterval, nil
+	}
+
+	// parse the duration
+	interval, err := time.ParseDuration(*c.AggMinInterval)
+
+	// duration check
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("error: invalid agg_min_interval %q (use a duration like 10s, or 0 for no minimum)", *c.AggMinInterval)
+	}
+
+	// return the minimum
+	return interval, nil
+}
+
+// get the configured log level name, "" if none (info)
+func (c Config) LogLevelName() string {
+	// configured check
+	if c.LogLevel == nil {
+		return ""
+	}
+	return *c.LogLevel
+}
+
+// get the configured db dialect, "" if none (plain postgres)
+func (c Config) DialectName() string {
+	// configured check
+	if c.DBDialect == nil {
+		return ""
+	}
+	return *c.DBDialect
+}
+
+// get the db url to connect with, db_url with the keyring password and the schema as its search_path
+// NOTE: every query is unqualified, so search_path alone makes them (and migrate) use the schema
+func (c Config) DatabaseURL() (string, error) {
+	// db url check
+	if c.URL == nil || *c.URL == "" {
+		return "", fmt.Errorf("error: db_url is not set in the config file (or GATOR_DB_URL), set it with: aggregator config set db_url <url>")
+	}
+
+	// add the password from the keyring (if configured)
+	dbURL, err := c.withKeyringPassword(*c.URL)
+
+	// keyring check
+	if err != nil {
+		return "", err
+	}
+
+	// no schema check, use the url as is
+	schema := c.SchemaName()
+	if schema == "" {
+		return dbURL, nil
+	}
+
+	// schema name check
+	if !schemaName.MatchString(schema) {
+		return "", fmt.Errorf("error: invalid schema %q (use lowercase letters, digits and _)", schema)
+	}
+
+	// key=value form check, eg "host=localhost dbname=gator"
+	if !strings.HasPrefix(dbURL, "postgres://") && !strings.HasPrefix(dbURL, "postgresql://") {
+		return dbURL + " search_path=" + schema, nil
+	}
+
+	// url form, add it as a query param (lib/pq sends unknown params to the server as settings)
+	u, err := url.Parse(dbURL)
+
+	// parse check
+	if err != nil {
+		return "", fmt.Errorf("error parsing db_url: %w", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	// return the schema's url
+	return u.String(), nil
+}
+
+// add the db_password_keyring password to a db url helper, replacing any password in it
+func (c Config) withKeyringPassword(dbURL string) (string, error) {
+	// configured check
+	if c.DBPasswordKeyring == nil || *c.DBPasswordKeyring == "" {
+		return dbURL, nil
+	}
+	alias := *c.DBPasswordKeyring
+
+	// get the password
+	password, err := keyring.Get(alias)
+
+	// not stored yet check
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("error: no password for keyring alias %q, store it with: config set-password %s", alias, alias)
+	}
+
+	// keyring check
+	if err != nil {
+		return "", err
+	}
+
+	// key=value form check, quoted so spaces and quotes in the password are fine
+	if !strings.HasPrefix(dbURL, "postgres://") && !strings.HasPrefix(dbURL, "postgresql://") {
+		quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password)
+		return dbURL + " password='" + quoted + "'", nil
+	}
+
+	// url form, set the password on the user
+	u, err := url.Parse(dbURL)
+
+	// parse check
+	if err != nil {
+		return "", fmt.Errorf("error parsing db_url: %w", err)
+	}
+
+	// user check, a password needs a user to go with
+	if u.User == nil || u.User.Username() == "" {
+		return "", fmt.Errorf("error: db_url has no user for the keyring password (eg postgres://gator@localhost:5432/gator)")
+	}
+	u.User = url.UserPassword(u.User.Username(), password)
+
+	// return the url with the password
+	return u.String(), nil
+}
+
+// get the config file's path, the one it was read from (or the default location)
+func (c Config) Path() (string, error) {
+	// read from check
+	if c.path != "" {
+		return c.path, nil
+	}
+	return getConfigPath()
+}
+
+// get config file path helper function, GATOR_CONFIG if set
+// otherwise the first existing of ~/.gatorconfig.{json,toml,yaml,yml} and ~/.config/gator/config.{json,toml,yaml,yml}
+// (the format goes by the extension), or ~/.gatorconfig.json for a new file
 func getConfigPath() (string, error) {
+	// env override check
+	if configPath := os.Getenv("GATOR_CONFIG"); configPath != "" {
+		return configPath, nil
+	}
+
 	// get home path
 	homePath, err := os.UserHomeDir()
 
@@ -120,19 +654,55 @@ func getConfigPath() (string, error) {
 	configPath := filepath.Join(homePath, configFileName)
 	// NOTE: filepath.Join() handles /'s for you
 
+	// candidate files, the json dotfile first so existing setups keep working
+	base := strings.TrimSuffix(configPath, ".json")
+	candidates := []string{configPath, base + ".toml", base + ".yaml", base + ".yml"}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dir := filepath.Join(configDir, "gator")
+		for _, name := range []string{"config.json", "config.toml", "config.yaml", "config.yml"} {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+
+	// first existing file check
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
 	// return the config filepath
 	return configPath, nil
 }
 
-// write config file helper function
-func write(cfg Config) error {
-	// get config file path using helper
-	configPath, err := getConfigPath()
+// save an upgraded config file helper, the old one is kept as <file>.v<version>.bak
+func upgradeFile(configPath string, raw []byte, cfg Config, version int) error {
+	// back up the old file first, so a bad migration can't lose anything
+	backupPath := fmt.Sprintf("%s.v%d.bak", configPath, version)
+	err := os.WriteFile(backupPath, raw, 0600)
+
+	// backup check
+	if err != nil {
+		return fmt.Errorf("error backing up config file before upgrading it: %w", err)
+	}
+
+	// write the upgraded config
+	err = write(configPath, cfg)
 
-	// configpath check
+	// write check
 	if err != nil {
-		return fmt.Errorf("error getting filepath from helper: %w", err)
+		return fmt.Errorf("error writing upgraded config file: %w", err)
 	}
+	slog.Info("upgraded config file", "path", configPath, "from_version", version, "to_version", currentVersion, "backup", backupPath)
+
+	// return success
+	return nil
+}
+
+// write config file helper function, always at the current schema_version
+func write(configPath string, cfg Config) error {
+	// stamp the version, so a new file (or one from an old gator) is never migrated twice
+	cfg.SchemaVersion = currentVersion
 
 	// marshal the struct back to json (marshalindent prettifies it with newlines!)
 	jsonData, err := json.MarshalIndent(cfg, "", "  ")
@@ -143,15 +713,29 @@ func write(cfg Config) error {
 		return fmt.Errorf("error marshalling cfg back to json: %w", err)
 	}
 
+	// toml and yaml files check, written back in their own format
+	jsonData, err = fromJSON(jsonData, formatOf(configPath))
+
+	// convert check
+	if err != nil {
+		return fmt.Errorf("error converting cfg to %s: %w", formatOf(configPath), err)
+	}
+
 	// write to fille the updated json
-	err = os.WriteFile(configPath, jsonData, 0644) // writefile only returns ERR
-	// 0644 = octal persmission code for owner can read & write
+	err = os.WriteFile(configPath, jsonData, 0600) // writefile only returns ERR
+	// 0600 = octal persmission code for owner can read & write, nobody else (it holds passwords and api keys)
 
 	// writefile check
 	if err != nil {
 		return fmt.Errorf("error writing updated json: %w", err)
 	}
 
+	// tighten an existing file too, writefile only sets the permissions of new files
+	err = os.Chmod(configPath, 0600)
+	if err != nil {
+		return fmt.Errorf("error restricting config file permissions: %w", err)
+	}
+
 	// return success
 	return nil
 }
@@ -0,0 +1,138 @@
+// config_test.go
+package config
+
+import (
+	// import standard Go libraries
+	"os"            // writing test files
+	"path/filepath" // test file paths
+	"reflect"       // comparing configs
+	"strings"       // matching files and errors
+	"testing"       // go tests
+)
+
+// the same config as json, toml and yaml, sections and comments included
+var formatFiles = map[string]string{
+	"gator.json": `{
+  "schema_version": 1,
+  "db_url": "postgres://localhost:5432/gator?sslmode=disable",
+  "agg_interval": "10m",
+  "cache_images": true,
+  "http": {"timeout": "30s", "concurrency": 4}
+}`,
+	"gator.toml": `# gator's config
+schema_version = 1
+db_url = "postgres://localhost:5432/gator?sslmode=disable"
+agg_interval = "10m" # agg runs without args
+cache_images = true
+
+[http]
+timeout = "30s"
+concurrency = 4
+`,
+	"gator.yaml": `# gator's config
+schema_version: 1
+db_url: postgres://localhost:5432/gator?sslmode=disable
+agg_interval: 10m # agg runs without args
+cache_images: true
+http:
+  timeout: 30s
+  concurrency: 4
+`,
+}
+
+// json, toml and yaml files read into the same config, and are written back in their own format
+func TestFormatsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	var want Config
+	for _, name := range []string{"gator.json", "gator.toml", "gator.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			err := os.WriteFile(path, []byte(formatFiles[name]), 0600)
+			if err != nil {
+				t.Fatalf("error writing: %v", err)
+			}
+
+			// read check, every format gives the json file's config
+			cfg, err := readFile(path)
+			if err != nil {
+				t.Fatalf("readFile error = %v", err)
+			}
+			if cfg.URL == nil || *cfg.URL != "postgres://localhost:5432/gator?sslmode=disable" || cfg.HTTP == nil || cfg.HTTP.Concurrency != 4 {
+				t.Fatalf("readFile = %+v", cfg)
+			}
+			if name == "gator.json" {
+				want = cfg
+			} else if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("readFile = %+v, want %+v", cfg, want)
+			}
+
+			// write check, in the file's own format and read back the same
+			user := "kahya"
+			cfg.Name = &user
+			err = write(path, cfg)
+			if err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+			data, _ := os.ReadFile(path)
+			switch name {
+			case "gator.toml":
+				if !strings.Contains(string(data), "[http]") || !strings.Contains(string(data), "concurrency = 4\n") {
+					t.Errorf("written toml:\n%s", data)
+				}
+			case "gator.yaml":
+				if !strings.Contains(string(data), "http:\n  concurrency: 4\n") {
+					t.Errorf("written yaml:\n%s", data)
+				}
+			}
+			reread, err := readFile(path)
+			if err != nil {
+				t.Fatalf("readFile of the written file error = %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(reread, cfg) {
+				t.Errorf("readFile of the written file = %+v, want %+v", reread, cfg)
+			}
+		})
+	}
+}
+
+// broken toml and yaml files are refused with where they broke, and strict keys apply to them too
+func TestReadFileFormatErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"gator.toml", "db_url = \"postgres://localhost/gator\"\nagg_interval = 10m\n", "line 2"},
+		{"gator.yaml", "db_url: postgres://localhost/gator\n  agg_interval: 10m\n", "line 2"},
+		{"gator.toml", "agg_intervall = \"10m\"\n", "unknown field"},
+		{"gator.yml", "http: fast\n", "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			err := os.WriteFile(path, []byte(tt.data), 0600)
+			if err != nil {
+				t.Fatalf("error writing: %v", err)
+			}
+			_, err = readFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readFile error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// an empty toml or yaml file is an empty config, like a missing one
+func TestReadFileEmpty(t *testing.T) {
+	for _, name := range []string{"gator.toml", "gator.yaml"} {
+		path := filepath.Join(t.TempDir(), name)
+		err := os.WriteFile(path, nil, 0600)
+		if err != nil {
+			t.Fatalf("error writing: %v", err)
+		}
+		cfg, err := readFile(path)
+		if err != nil || cfg.URL != nil {
+			t.Errorf("%s read %+v, %v, want an empty config", name, cfg, err)
+		}
+	}
+}
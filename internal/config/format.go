@@ -0,0 +1,164 @@
+// format.go
+package config
+
+import (
+	// import standard Go libraries
+	"bytes"         // decoding json numbers
+	"encoding/json" // everything goes through json
+	"fmt"           // printing errors
+	"path/filepath" // file extensions
+	"strings"       // matching extensions
+
+	// external packages
+	"github.com/BurntSushi/toml" // toml files
+	"gopkg.in/yaml.v3"           // yaml files
+)
+
+// config file format, picked by the file's extension
+type format string
+
+// supported formats
+const (
+	formatJSON format = "json"
+	formatTOML format = "toml"
+	formatYAML format = "yaml"
+)
+
+// get a config file's format from its extension (json if unknown, the original format)
+func formatOf(path string) format {
+	// match the extension
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return formatTOML
+	case ".yaml", ".yml":
+		return formatYAML
+	}
+	return formatJSON
+}
+
+// convert a config file to json, so every format decodes (and validates) the same way
+// NOTE: the keys are the json ones, sections (http, smtp...) are toml tables or yaml mappings
+func toJSON(data []byte, f format) ([]byte, error) {
+	// decode the file into plain values
+	var values map[string]any
+	var err error
+	switch f {
+	case formatTOML:
+		err = toml.Unmarshal(data, &values)
+	case formatYAML:
+		err = yaml.Unmarshal(data, &values)
+	default:
+		return data, nil // json, nothing to convert
+	}
+
+	// parse check
+	if err != nil {
+		return nil, err
+	}
+
+	// empty file check, json wants an object
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	// encode as json
+	return json.Marshal(jsonValue(values))
+}
+
+// make a decoded toml or yaml value json friendly helper
+// yaml mappings with non-string keys (eg 1: x) become string keys, so json can encode them
+func jsonValue(value any) any {
+	// match the value's type
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+		return v
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []any:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	}
+	return value
+}
+
+// convert the config's json back to a config file's format helper, for write
+// NOTE: unset (null) keys are left out of toml and yaml, toml has no null
+func fromJSON(data []byte, f format) ([]byte, error) {
+	// json check, nothing to convert
+	if f == formatJSON {
+		return data, nil
+	}
+
+	// decode the json, keeping whole numbers whole (or toml writes 4.0)
+	var values map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&values)
+
+	// decode check
+	if err != nil {
+		return nil, err
+	}
+	values = fileValue(values).(map[string]any)
+
+	// encode in the file's format
+	var buf bytes.Buffer
+	switch f {
+	case formatTOML:
+		err = toml.NewEncoder(&buf).Encode(values)
+	case formatYAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		err = encoder.Encode(values)
+		if err == nil {
+			err = encoder.Close()
+		}
+	}
+
+	// encode check
+	if err != nil {
+		return nil, err
+	}
+
+	// return the file
+	return buf.Bytes(), nil
+}
+
+// make a decoded json value toml and yaml friendly helper
+// numbers become ints (or floats), and null keys are dropped
+func fileValue(value any) any {
+	// match the value's type
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			// null check
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = fileValue(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = fileValue(item)
+		}
+		return v
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return number
+		}
+		number, _ := v.Float64()
+		return number
+	}
+	return value
+}
@@ -0,0 +1,502 @@
+// http.go
+package config
+
+import (
+	// import standard Go libraries
+	"fmt"     // printing errors
+	"net/url" // parsing proxy urls
+	"strconv" // parsing numbers
+	"time"    // parsing timeouts
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // http client options
+)
+
+// package-wide constants
+const maxFetchConcurrency = 64                        // more parallel fetches than this is a DoS, not an aggregator
+const defaultDBLatencyTarget = 500 * time.Millisecond // agg fetches fewer feeds at once when the database is slower
+
+// the config's http section, the shared feed fetching client's settings (all optional)
+type HTTPConfig struct {
+	// whole request timeout, body included (default 10s)
+	Timeout *string `json:"timeout,omitempty"`
+	// redirects followed before giving up (default 10)
+	MaxRedirects *int `json:"max_redirects,omitempty"`
+	// proxy url (http, https or socks5), "none" to ignore HTTP_PROXY/HTTPS_PROXY (default: the env)
+	Proxy *string `json:"proxy,omitempty"`
+	// User-Agent header sent to feed hosts (default Gator/0.1 (+repo url))
+	UserAgent *string `json:"user_agent,omitempty"`
+	// feeds agg fetches in parallel (default 1), the most when min_concurrency is set
+	Concurrency int `json:"concurrency,omitempty"`
+	// the fewest feeds agg fetches in parallel when the database is struggling (default: concurrency, ie fixed)
+	MinConcurrency int `json:"min_concurrency,omitempty"`
+	// database calls slower than this make agg fetch fewer feeds at once (default 500ms)
+	DBLatencyTarget *string `json:"db_latency_target,omitempty"`
+	// dial and tls handshake timeout, each (default 5s)
+	ConnectTimeout *string `json:"connect_timeout,omitempty"`
+	// how long an unused connection to a feed host is kept open for the next fetch (default 90s)
+	IdleTimeout *string `json:"idle_timeout,omitempty"`
+	// unused connections kept open per feed host (default 8)
+	MaxIdlePerHost int `json:"max_idle_per_host,omitempty"`
+	// negotiate http/2 with hosts that offer it (default true)
+	HTTP2 *bool `json:"http2,omitempty"`
+	// the longest a feed host's dns answer is cached, shorter if its ttl is, "off" = no cache (default 5m)
+	DNSCacheTTL *string `json:"dns_cache_ttl,omitempty"`
+}
+
+// get the http client options from the http section, defaults for what's not set
+func (c Config) HTTPOptions() (rssfeed.Options, error) {
+	// create options with defaults
+	opts := rssfeed.Options{MaxRedirects: -1}
+
+	// section check
+	if c.HTTP == nil {
+		return opts, nil
+	}
+
+	// timeout check
+	if c.HTTP.Timeout != nil {
+		timeout, err := parseTimeout(*c.HTTP.Timeout)
+		if err != nil {
+			return opts, err
+		}
+		opts.Timeout = timeout
+	}
+
+	// max redirects check
+	if c.HTTP.MaxRedirects != nil {
+		if *c.HTTP.MaxRedirects < 0 {
+			return opts, fmt.Errorf("error: invalid http.max_redirects %d (use 0 or more)", *c.HTTP.MaxRedirects)
+		}
+		opts.MaxRedirects = *c.HTTP.MaxRedirects
+	}
+
+	// proxy check
+	if c.HTTP.Proxy != nil {
+		proxy, err := parseProxy(*c.HTTP.Proxy)
+		if err != nil {
+			return opts, err
+		}
+		opts.Proxy = proxy
+		opts.NoProxy = *c.HTTP.Proxy == "none"
+	}
+
+	// user agent check
+	if c.HTTP.UserAgent != nil {
+		opts.UserAgent = *c.HTTP.UserAgent
+	}
+
+	// transport checks
+	if c.HTTP.ConnectTimeout != nil {
+		timeout, err := parseTransportTimeout("http.connect_timeout", *c.HTTP.ConnectTimeout)
+		if err != nil {
+			return opts, err
+		}
+		opts.ConnectTimeout = timeout
+	}
+	if c.HTTP.IdleTimeout != nil {
+		timeout, err := parseTransportTimeout("http.idle_timeout", *c.HTTP.IdleTimeout)
+		if err != nil {
+			return opts, err
+		}
+		opts.IdleTimeout = timeout
+	}
+	if c.HTTP.MaxIdlePerHost < 0 {
+		return opts, fmt.Errorf("error: invalid http.max_idle_per_host %d (use 1 or more)", c.HTTP.MaxIdlePerHost)
+	}
+	opts.MaxIdlePerHost = c.HTTP.MaxIdlePerHost
+	opts.NoHTTP2 = c.HTTP.HTTP2 != nil && !*c.HTTP.HTTP2
+
+	// dns cache check
+	if c.HTTP.DNSCacheTTL != nil {
+		ttl, err := parseDNSCacheTTL(*c.HTTP.DNSCacheTTL)
+		if err != nil {
+			return opts, err
+		}
+		opts.DNSCacheTTL = ttl
+		opts.NoDNSCache = ttl == 0
+	}
+
+	// return the options
+	return opts, nil
+}
+
+// get how many feeds agg fetches in parallel, http.concurrency or 1
+func (c Config) FetchConcurrency() int {
+	// configured check
+	if c.HTTP == nil || c.HTTP.Concurrency < 1 {
+		return 1
+	}
+	return min(c.HTTP.Concurrency, maxFetchConcurrency)
+}
+
+// get agg's adaptive fetch concurrency, the fewest and most feeds in parallel and the database latency it backs off above
+// without http.min_concurrency both are http.concurrency, a fixed concurrency
+func (c Config) FetchLimits() (int, int, time.Duration, error) {
+	// most check
+	most := c.FetchConcurrency()
+
+	// fewest check (never above the most)
+	fewest := most
+	if c.HTTP != nil && c.HTTP.MinConcurrency > 0 {
+		fewest = min(c.HTTP.MinConcurrency, most)
+	}
+
+	// latency target check
+	target := defaultDBLatencyTarget
+	if c.HTTP != nil && c.HTTP.DBLatencyTarget != nil {
+		parsed, err := parseLatencyTarget(*c.HTTP.DBLatencyTarget)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		target = parsed
+	}
+
+	// return the limits
+	return fewest, most, target, nil
+}
+
+// http section helper, creates it if needed (for setting keys)
+func httpSection(c *Config) *HTTPConfig {
+	// nil check
+	if c.HTTP == nil {
+		c.HTTP = &HTTPConfig{}
+	}
+	return c.HTTP
+}
+
+// drop an empty http section helper, so unsetting every key leaves no "http": {} behind
+func trimHTTP(c *Config) {
+	// empty check
+	if c.HTTP != nil && *c.HTTP == (HTTPConfig{}) {
+		c.HTTP = nil
+	}
+}
+
+// parse an http.timeout helper
+func parseTimeout(value string) (time.Duration, error) {
+	// duration check
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("error: invalid http.timeout %q (use a duration like 10s or 1m)", value)
+	}
+	return timeout, nil
+}
+
+// parse an http.db_latency_target helper
+func parseLatencyTarget(value string) (time.Duration, error) {
+	// duration check
+	target, err := time.ParseDuration(value)
+	if err != nil || target <= 0 {
+		return 0, fmt.Errorf("error: invalid http.db_latency_target %q (use a duration like 500ms or 2s)", value)
+	}
+	return target, nil
+}
+
+// parse an http.connect_timeout or http.idle_timeout helper
+func parseTransportTimeout(key, value string) (time.Duration, error) {
+	// duration check
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("error: invalid %s %q (use a duration like 5s or 1m)", key, value)
+	}
+	return timeout, nil
+}
+
+// parse an http.dns_cache_ttl helper, 0 for "off"
+func parseDNSCacheTTL(value string) (time.Duration, error) {
+	// off check
+	if value == "off" {
+		return 0, nil
+	}
+
+	// duration check
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("error: invalid http.dns_cache_ttl %q (use a duration like 5m, or off)", value)
+	}
+	return ttl, nil
+}
+
+// parse an http.proxy helper, nil for "none"
+func parseProxy(value string) (*url.URL, error) {
+	// direct check
+	if value == "none" {
+		return nil, nil
+	}
+
+	// url check
+	proxy, err := url.Parse(value)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("error: invalid http.proxy %q (eg http://proxy:3128, socks5://localhost:1080 or none)", value)
+	}
+
+	// scheme check
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+		return proxy, nil
+	}
+	return nil, fmt.Errorf("error: invalid http.proxy scheme %q (use http, https or socks5)", proxy.Scheme)
+}
+
+// http section config keys, for config get/set and GATOR_HTTP_* env vars
+var httpSettings = []setting{
+	{
+		key: "http.timeout", env: "GATOR_HTTP_TIMEOUT", desc: "feed request timeout, body included (default 10s)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.Timeout)
+		},
+		set: func(c *Config, value string) error {
+			// duration check
+			if value != "" {
+				if _, err := parseTimeout(value); err != nil {
+					return err
+				}
+			}
+			httpSection(c).Timeout = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+	{
+		key: "http.max_redirects", env: "GATOR_HTTP_MAX_REDIRECTS", desc: "redirects followed per feed request (default 10)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil || c.HTTP.MaxRedirects == nil {
+				return "", false
+			}
+			return strconv.Itoa(*c.HTTP.MaxRedirects), true
+		},
+		set: func(c *Config, value string) error {
+			// unset check
+			if value == "" {
+				httpSection(c).MaxRedirects = nil
+				trimHTTP(c)
+				return nil
+			}
+
+			// number check
+			redirects, err := strconv.Atoi(value)
+			if err != nil || redirects < 0 {
+				return fmt.Errorf("error: invalid http.max_redirects %q (use 0 or more)", value)
+			}
+			httpSection(c).MaxRedirects = &redirects
+			return nil
+		},
+	},
+	{
+		key: "http.proxy", env: "GATOR_HTTP_PROXY", desc: "proxy url for feed requests, none = direct (default: HTTP_PROXY/HTTPS_PROXY)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.Proxy)
+		},
+		set: func(c *Config, value string) error {
+			// proxy check
+			if value != "" {
+				if _, err := parseProxy(value); err != nil {
+					return err
+				}
+			}
+			httpSection(c).Proxy = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+	{
+		key: "http.user_agent", env: "GATOR_HTTP_USER_AGENT", desc: "User-Agent sent to feed hosts",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.UserAgent)
+		},
+		set: func(c *Config, value string) error {
+			httpSection(c).UserAgent = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+	{
+		key: "http.concurrency", env: "GATOR_HTTP_CONCURRENCY", desc: "feeds agg fetches in parallel (default 1)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil || c.HTTP.Concurrency == 0 {
+				return "1", false
+			}
+			return strconv.Itoa(c.HTTP.Concurrency), true
+		},
+		set: func(c *Config, value string) error {
+			// unset check
+			if value == "" {
+				httpSection(c).Concurrency = 0
+				trimHTTP(c)
+				return nil
+			}
+
+			// number check
+			concurrency, err := strconv.Atoi(value)
+			if err != nil || concurrency < 1 || concurrency > maxFetchConcurrency {
+				return fmt.Errorf("error: invalid http.concurrency %q (use 1 to %d)", value, maxFetchConcurrency)
+			}
+			httpSection(c).Concurrency = concurrency
+			return nil
+		},
+	}, {
+		key: "http.min_concurrency", env: "GATOR_HTTP_MIN_CONCURRENCY", desc: "fewest feeds agg fetches in parallel when the database is slow (default: http.concurrency, fixed)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil || c.HTTP.MinConcurrency == 0 {
+				return "", false
+			}
+			return strconv.Itoa(c.HTTP.MinConcurrency), true
+		},
+		set: func(c *Config, value string) error {
+			// unset check
+			if value == "" {
+				httpSection(c).MinConcurrency = 0
+				trimHTTP(c)
+				return nil
+			}
+
+			// number check (above http.concurrency is capped to it)
+			concurrency, err := strconv.Atoi(value)
+			if err != nil || concurrency < 1 || concurrency > maxFetchConcurrency {
+				return fmt.Errorf("error: invalid http.min_concurrency %q (use 1 to %d)", value, maxFetchConcurrency)
+			}
+			httpSection(c).MinConcurrency = concurrency
+			return nil
+		},
+	},
+	{
+		key: "http.db_latency_target", env: "GATOR_HTTP_DB_LATENCY_TARGET", desc: "database latency above which agg fetches fewer feeds at once (default 500ms)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.DBLatencyTarget)
+		},
+		set: func(c *Config, value string) error {
+			// duration check
+			if value != "" {
+				if _, err := parseLatencyTarget(value); err != nil {
+					return err
+				}
+			}
+			httpSection(c).DBLatencyTarget = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+	{
+		key: "http.connect_timeout", env: "GATOR_HTTP_CONNECT_TIMEOUT", desc: "dial and tls handshake timeout for feed hosts (default 5s)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.ConnectTimeout)
+		},
+		set: func(c *Config, value string) error {
+			// duration check
+			if value != "" {
+				if _, err := parseTransportTimeout("http.connect_timeout", value); err != nil {
+					return err
+				}
+			}
+			httpSection(c).ConnectTimeout = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+	{
+		key: "http.idle_timeout", env: "GATOR_HTTP_IDLE_TIMEOUT", desc: "how long unused connections to feed hosts are kept open (default 90s)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.IdleTimeout)
+		},
+		set: func(c *Config, value string) error {
+			// duration check
+			if value != "" {
+				if _, err := parseTransportTimeout("http.idle_timeout", value); err != nil {
+					return err
+				}
+			}
+			httpSection(c).IdleTimeout = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+	{
+		key: "http.max_idle_per_host", env: "GATOR_HTTP_MAX_IDLE_PER_HOST", desc: "unused connections kept open per feed host (default 8)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil || c.HTTP.MaxIdlePerHost == 0 {
+				return "8", false
+			}
+			return strconv.Itoa(c.HTTP.MaxIdlePerHost), true
+		},
+		set: func(c *Config, value string) error {
+			// unset check
+			if value == "" {
+				httpSection(c).MaxIdlePerHost = 0
+				trimHTTP(c)
+				return nil
+			}
+
+			// number check
+			idle, err := strconv.Atoi(value)
+			if err != nil || idle < 1 {
+				return fmt.Errorf("error: invalid http.max_idle_per_host %q (use 1 or more)", value)
+			}
+			httpSection(c).MaxIdlePerHost = idle
+			return nil
+		},
+	},
+	{
+		key: "http.http2", env: "GATOR_HTTP_HTTP2", desc: "use http/2 with feed hosts that offer it (default true)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil || c.HTTP.HTTP2 == nil {
+				return "true", false
+			}
+			return strconv.FormatBool(*c.HTTP.HTTP2), true
+		},
+		set: func(c *Config, value string) error {
+			// unset check
+			if value == "" {
+				httpSection(c).HTTP2 = nil
+				trimHTTP(c)
+				return nil
+			}
+
+			// bool check
+			http2, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("error: invalid http.http2 %q (use true or false)", value)
+			}
+			httpSection(c).HTTP2 = &http2
+			return nil
+		},
+	},
+	{
+		key: "http.dns_cache_ttl", env: "GATOR_HTTP_DNS_CACHE_TTL", desc: "longest a feed host's dns answer is cached, off = no cache (default 5m)",
+		get: func(c *Config) (string, bool) {
+			if c.HTTP == nil {
+				return "", false
+			}
+			return stringValue(c.HTTP.DNSCacheTTL)
+		},
+		set: func(c *Config, value string) error {
+			// duration check
+			if value != "" {
+				if _, err := parseDNSCacheTTL(value); err != nil {
+					return err
+				}
+			}
+			httpSection(c).DNSCacheTTL = optionalString(value)
+			trimHTTP(c)
+			return nil
+		},
+	},
+}
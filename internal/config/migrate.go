@@ -0,0 +1,94 @@
+// migrate.go
+package config
+
+import (
+	// import standard Go libraries
+	"encoding/json" // decoding json to go
+	"fmt"           // printing errors
+)
+
+// package-wide constants
+const currentVersion = 1 // the config shape this gator writes, bump it with every new migration
+
+// config migrations, migrations[i] upgrades a file's raw keys from version i to i+1
+// NOTE: they get the keys before strict decoding, so they can rename, move and nest keys freely
+var migrations = []func(keys map[string]any) error{
+	// 0 -> 1: files from before versioning, same shape, they only gain schema_version
+	func(keys map[string]any) error { return nil },
+}
+
+// upgrade a config file's json to the current version helper
+// returns the upgraded json, and the version the file was at
+func migrateJSON(data []byte) ([]byte, int, error) {
+	// decode the raw keys
+	var keys map[string]any
+	err := json.Unmarshal(data, &keys)
+
+	// decode check
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// object check (null decodes without an error, into no map at all)
+	if keys == nil {
+		return nil, 0, fmt.Errorf("the config file is null, it should be a json object like {\"db_url\": \"...\"}")
+	}
+
+	// get the file's version (none = 0, from before versioning)
+	version, err := fileVersion(keys)
+
+	// version check
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// newer than us check, we'd drop or misread keys we don't know
+	if version > currentVersion {
+		return nil, version, fmt.Errorf("schema_version %d is newer than this gator supports (%d), upgrade gator", version, currentVersion)
+	}
+
+	// up to date check
+	if version == currentVersion {
+		return data, version, nil
+	}
+
+	// run the migrations after the file's version
+	for v := version; v < currentVersion; v++ {
+		err = migrations[v](keys)
+
+		// migration check
+		if err != nil {
+			return nil, version, fmt.Errorf("error upgrading config from version %d to %d: %w", v, v+1, err)
+		}
+	}
+	keys["schema_version"] = currentVersion
+
+	// encode the upgraded keys
+	upgraded, err := json.Marshal(keys)
+
+	// encode check
+	if err != nil {
+		return nil, version, fmt.Errorf("error encoding upgraded config: %w", err)
+	}
+
+	// return the upgraded json
+	return upgraded, version, nil
+}
+
+// get schema_version from a file's raw keys helper (0 if missing)
+func fileVersion(keys map[string]any) (int, error) {
+	// missing check
+	raw, ok := keys["schema_version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+
+	// whole number check (json numbers decode as float64)
+	number, ok := raw.(float64)
+	if !ok || number < 0 || number != float64(int(number)) {
+		return 0, fmt.Errorf("invalid schema_version %v (it's a whole number, set by gator)", raw)
+	}
+
+	// return the version
+	return int(number), nil
+}
@@ -0,0 +1,56 @@
+// migrate_test.go
+package config
+
+import (
+	// import standard Go libraries
+	"encoding/json" // reading the upgraded keys
+	"strings"       // matching errors
+	"testing"       // go tests
+)
+
+// files are upgraded to the current version, and files that aren't config objects are refused (not a panic)
+func TestMigrateJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+		wantErr     string
+	}{
+		{"unversioned", `{"db_url": "postgres://localhost/gator"}`, 0, ""},
+		{"current", `{"schema_version": 1, "db_url": "postgres://localhost/gator"}`, 1, ""},
+		{"empty object", `{}`, 0, ""},
+		{"null", `null`, 0, "is null"},
+		{"array", `[]`, 0, "cannot unmarshal"},
+		{"newer", `{"schema_version": 99}`, 99, "newer than this gator supports"},
+		{"bad version", `{"schema_version": "one"}`, 0, "invalid schema_version"},
+		{"fractional version", `{"schema_version": 0.5}`, 0, "invalid schema_version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgraded, version, err := migrateJSON([]byte(tt.data))
+
+			// error check
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version %d, want %d", version, tt.wantVersion)
+			}
+
+			// stamped check
+			var keys map[string]any
+			if err := json.Unmarshal(upgraded, &keys); err != nil {
+				t.Fatalf("upgraded config isn't json: %v", err)
+			}
+			if keys["schema_version"] != float64(currentVersion) {
+				t.Errorf("schema_version %v, want %d", keys["schema_version"], currentVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// smtp.go
+package config
+
+import (
+	// import standard Go libraries
+	"fmt" // printing errors
+	"net" // validating the address
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/mail" // smtp options
+)
+
+// the config's smtp section, the server digests are sent through (all optional, but digests need addr and from)
+type SMTPConfig struct {
+	// host:port of the smtp server, eg smtp.example.com:587
+	Addr *string `json:"addr,omitempty"`
+	// login name, none = no auth
+	Username *string `json:"username,omitempty"`
+	// login password (or GATOR_SMTP_PASSWORD, to keep it out of the file)
+	Password *string `json:"password,omitempty"`
+	// sender address, eg "Gator <gator@example.com>"
+	From *string `json:"from,omitempty"`
+	// starttls (default), tls or none
+	TLS *string `json:"tls,omitempty"`
+}
+
+// get the smtp options from the smtp section, erroring if it's not set up
+func (c Config) SMTPOptions() (mail.Options, error) {
+	// configured check
+	if c.SMTP == nil || c.SMTP.Addr == nil || c.SMTP.From == nil {
+		return mail.Options{}, fmt.Errorf("error: no smtp server configured, set smtp.addr and smtp.from (eg config set smtp.addr smtp.example.com:587)")
+	}
+
+	// create the options
+	opts := mail.Options{Addr: *c.SMTP.Addr, From: *c.SMTP.From, TLS: mail.TLSStartTLS}
+	opts.Username, _ = stringValue(c.SMTP.Username)
+	opts.Password, _ = stringValue(c.SMTP.Password)
+	if c.SMTP.TLS != nil {
+		opts.TLS = *c.SMTP.TLS
+	}
+
+	// return the options
+	return opts, nil
+}
+
+// smtp section helper, creates it if needed (for setting keys)
+func smtpSection(c *Config) *SMTPConfig {
+	// nil check
+	if c.SMTP == nil {
+		c.SMTP = &SMTPConfig{}
+	}
+	return c.SMTP
+}
+
+// drop an empty smtp section helper, so unsetting every key leaves no "smtp": {} behind
+func trimSMTP(c *Config) {
+	// empty check
+	if c.SMTP != nil && *c.SMTP == (SMTPConfig{}) {
+		c.SMTP = nil
+	}
+}
+
+// smtp section config keys, for config get/set and GATOR_SMTP_* env vars
+var smtpSettings = []setting{
+	{
+		key: "smtp.addr", env: "GATOR_SMTP_ADDR", desc: "smtp server digests are sent through, host:port",
+		get: func(c *Config) (string, bool) {
+			if c.SMTP == nil {
+				return "", false
+			}
+			return stringValue(c.SMTP.Addr)
+		},
+		set: func(c *Config, value string) error {
+			// host:port check
+			if value != "" {
+				if host, _, err := net.SplitHostPort(value); err != nil || host == "" {
+					return fmt.Errorf("error: invalid smtp.addr %q (use host:port, eg smtp.example.com:587)", value)
+				}
+			}
+			smtpSection(c).Addr = optionalString(value)
+			trimSMTP(c)
+			return nil
+		},
+	},
+	{
+		key: "smtp.username", env: "GATOR_SMTP_USERNAME", desc: "smtp login name (default: no login)",
+		get: func(c *Config) (string, bool) {
+			if c.SMTP == nil {
+				return "", false
+			}
+			return stringValue(c.SMTP.Username)
+		},
+		set: func(c *Config, value string) error {
+			smtpSection(c).Username = optionalString(value)
+			trimSMTP(c)
+			return nil
+		},
+	},
+	{
+		key: "smtp.password", env: "GATOR_SMTP_PASSWORD", desc: "smtp login password", secret: true,
+		get: func(c *Config) (string, bool) {
+			if c.SMTP == nil {
+				return "", false
+			}
+			return stringValue(c.SMTP.Password)
+		},
+		set: func(c *Config, value string) error {
+			smtpSection(c).Password = optionalString(value)
+			trimSMTP(c)
+			return nil
+		},
+	},
+	{
+		key: "smtp.from", env: "GATOR_SMTP_FROM", desc: "sender of digests, eg \"Gator <gator@example.com>\"",
+		get: func(c *Config) (string, bool) {
+			if c.SMTP == nil {
+				return "", false
+			}
+			return stringValue(c.SMTP.From)
+		},
+		set: func(c *Config, value string) error {
+			// address check
+			if value != "" {
+				if _, err := mail.ParseAddress(value); err != nil {
+					return fmt.Errorf("error: invalid smtp.from %q (eg gator@example.com or \"Gator <gator@example.com>\")", value)
+				}
+			}
+			smtpSection(c).From = optionalString(value)
+			trimSMTP(c)
+			return nil
+		},
+	},
+	{
+		key: "smtp.tls", env: "GATOR_SMTP_TLS", desc: "smtp connection security: starttls (default), tls or none",
+		get: func(c *Config) (string, bool) {
+			if c.SMTP == nil {
+				return "", false
+			}
+			return stringValue(c.SMTP.TLS)
+		},
+		set: func(c *Config, value string) error {
+			// mode check
+			switch value {
+			case "", mail.TLSStartTLS, mail.TLSImplicit, mail.TLSNone:
+			default:
+				return fmt.Errorf("error: invalid smtp.tls %q (use starttls, tls or none)", value)
+			}
+			smtpSection(c).TLS = optionalString(value)
+			trimSMTP(c)
+			return nil
+		},
+	},
+}
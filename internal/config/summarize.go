@@ -0,0 +1,182 @@
+// summarize.go
+package config
+
+import (
+	// import standard Go libraries
+	"fmt"     // printing errors
+	"net/url" // validating the url
+	"strconv" // the daily limit
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/summarize" // summarizer providers
+)
+
+// package-wide constants
+const defaultSummarizeDailyLimit = 100 // posts agg summarizes per day without summarize.daily_limit
+
+// the config's summarize section, the summarizer agg writes post summaries with (all optional, off without provider)
+type SummarizeConfig struct {
+	// openai (any openai-compatible api, local ones too) or command
+	Provider *string `json:"provider,omitempty"`
+	// openai api url (default https://api.openai.com/v1, eg http://localhost:11434/v1 for ollama)
+	URL *string `json:"url,omitempty"`
+	// openai api key (or GATOR_SUMMARIZE_API_KEY, to keep it out of the file)
+	APIKey *string `json:"api_key,omitempty"`
+	// openai model (default gpt-4o-mini)
+	Model *string `json:"model,omitempty"`
+	// the command provider's program and args, gets the post on stdin and prints the summary
+	Command *string `json:"command,omitempty"`
+	// most posts summarized per day, across all users (default 100)
+	DailyLimit int `json:"daily_limit,omitempty"`
+}
+
+// get the summarizer's options and daily limit from the summarize section, erroring if it's not set up
+func (c Config) SummarizeOptions() (summarize.Options, int, error) {
+	// configured check
+	if c.Summarize == nil || c.Summarize.Provider == nil {
+		return summarize.Options{}, 0, fmt.Errorf("error: no summarizer configured, set summarize.provider (eg config set summarize.provider openai)")
+	}
+
+	// create the options
+	opts := summarize.Options{Provider: *c.Summarize.Provider}
+	opts.URL, _ = stringValue(c.Summarize.URL)
+	opts.APIKey, _ = stringValue(c.Summarize.APIKey)
+	opts.Model, _ = stringValue(c.Summarize.Model)
+	opts.Command, _ = stringValue(c.Summarize.Command)
+	limit := c.Summarize.DailyLimit
+	if limit == 0 {
+		limit = defaultSummarizeDailyLimit
+	}
+
+	// return the options
+	return opts, limit, nil
+}
+
+// summarize section helper, creates it if needed (for setting keys)
+func summarizeSection(c *Config) *SummarizeConfig {
+	// nil check
+	if c.Summarize == nil {
+		c.Summarize = &SummarizeConfig{}
+	}
+	return c.Summarize
+}
+
+// drop an empty summarize section helper, so unsetting every key leaves no "summarize": {} behind
+func trimSummarize(c *Config) {
+	// empty check
+	if c.Summarize != nil && *c.Summarize == (SummarizeConfig{}) {
+		c.Summarize = nil
+	}
+}
+
+// summarize section config keys, for config get/set and GATOR_SUMMARIZE_* env vars
+var summarizeSettings = []setting{
+	{
+		key: "summarize.provider", env: "GATOR_SUMMARIZE_PROVIDER", desc: "post summarizer: openai or command (default: off)",
+		get: func(c *Config) (string, bool) {
+			if c.Summarize == nil {
+				return "", false
+			}
+			return stringValue(c.Summarize.Provider)
+		},
+		set: func(c *Config, value string) error {
+			// provider check
+			switch value {
+			case "", summarize.OpenAI, summarize.Command:
+			default:
+				return fmt.Errorf("error: invalid summarize.provider %q (use openai or command)", value)
+			}
+			summarizeSection(c).Provider = optionalString(value)
+			trimSummarize(c)
+			return nil
+		},
+	},
+	{
+		key: "summarize.url", env: "GATOR_SUMMARIZE_URL", desc: "summarizer api url (default https://api.openai.com/v1)",
+		get: func(c *Config) (string, bool) {
+			if c.Summarize == nil {
+				return "", false
+			}
+			return stringValue(c.Summarize.URL)
+		},
+		set: func(c *Config, value string) error {
+			// url check
+			if value != "" {
+				u, err := url.Parse(value)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("error: invalid summarize.url %q (use an http(s) url, eg http://localhost:11434/v1)", value)
+				}
+			}
+			summarizeSection(c).URL = optionalString(value)
+			trimSummarize(c)
+			return nil
+		},
+	},
+	{
+		key: "summarize.api_key", env: "GATOR_SUMMARIZE_API_KEY", desc: "summarizer api key", secret: true,
+		get: func(c *Config) (string, bool) {
+			if c.Summarize == nil {
+				return "", false
+			}
+			return stringValue(c.Summarize.APIKey)
+		},
+		set: func(c *Config, value string) error {
+			summarizeSection(c).APIKey = optionalString(value)
+			trimSummarize(c)
+			return nil
+		},
+	},
+	{
+		key: "summarize.model", env: "GATOR_SUMMARIZE_MODEL", desc: "summarizer model (default gpt-4o-mini)",
+		get: func(c *Config) (string, bool) {
+			if c.Summarize == nil {
+				return "", false
+			}
+			return stringValue(c.Summarize.Model)
+		},
+		set: func(c *Config, value string) error {
+			summarizeSection(c).Model = optionalString(value)
+			trimSummarize(c)
+			return nil
+		},
+	},
+	{
+		key: "summarize.command", env: "GATOR_SUMMARIZE_COMMAND", desc: "command summarizer program and args, reads the post on stdin",
+		get: func(c *Config) (string, bool) {
+			if c.Summarize == nil {
+				return "", false
+			}
+			return stringValue(c.Summarize.Command)
+		},
+		set: func(c *Config, value string) error {
+			summarizeSection(c).Command = optionalString(value)
+			trimSummarize(c)
+			return nil
+		},
+	},
+	{
+		key: "summarize.daily_limit", env: "GATOR_SUMMARIZE_DAILY_LIMIT", desc: "most posts summarized per day (default 100)",
+		get: func(c *Config) (string, bool) {
+			if c.Summarize == nil || c.Summarize.DailyLimit == 0 {
+				return strconv.Itoa(defaultSummarizeDailyLimit), false
+			}
+			return strconv.Itoa(c.Summarize.DailyLimit), true
+		},
+		set: func(c *Config, value string) error {
+			// unset check
+			if value == "" {
+				summarizeSection(c).DailyLimit = 0
+				trimSummarize(c)
+				return nil
+			}
+
+			// number check
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 {
+				return fmt.Errorf("error: invalid summarize.daily_limit %q (use a number of posts, at least 1)", value)
+			}
+			summarizeSection(c).DailyLimit = limit
+			return nil
+		},
+	},
+}
@@ -0,0 +1,130 @@
+// translate.go
+package config
+
+import (
+	// import standard Go libraries
+	"fmt"     // printing errors
+	"net/url" // validating the url
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/translate" // translation providers
+)
+
+// the config's translate section, the provider browse --translate and auto-translated follows use (all optional)
+type TranslateConfig struct {
+	// deepl, libretranslate or openai (any openai-compatible api)
+	Provider *string `json:"provider,omitempty"`
+	// api url, needed for libretranslate (default: the provider's own)
+	URL *string `json:"url,omitempty"`
+	// api key (or GATOR_TRANSLATE_API_KEY, to keep it out of the file)
+	APIKey *string `json:"api_key,omitempty"`
+	// openai model (default gpt-4o-mini)
+	Model *string `json:"model,omitempty"`
+}
+
+// get the translation provider's options from the translate section, erroring if it's not set up
+func (c Config) TranslateOptions() (translate.Options, error) {
+	// configured check
+	if c.Translate == nil || c.Translate.Provider == nil {
+		return translate.Options{}, fmt.Errorf("error: no translation provider configured, set translate.provider (eg config set translate.provider deepl)")
+	}
+
+	// create the options
+	opts := translate.Options{Provider: *c.Translate.Provider}
+	opts.URL, _ = stringValue(c.Translate.URL)
+	opts.APIKey, _ = stringValue(c.Translate.APIKey)
+	opts.Model, _ = stringValue(c.Translate.Model)
+
+	// return the options
+	return opts, nil
+}
+
+// translate section helper, creates it if needed (for setting keys)
+func translateSection(c *Config) *TranslateConfig {
+	// nil check
+	if c.Translate == nil {
+		c.Translate = &TranslateConfig{}
+	}
+	return c.Translate
+}
+
+// drop an empty translate section helper, so unsetting every key leaves no "translate": {} behind
+func trimTranslate(c *Config) {
+	// empty check
+	if c.Translate != nil && *c.Translate == (TranslateConfig{}) {
+		c.Translate = nil
+	}
+}
+
+// translate section config keys, for config get/set and GATOR_TRANSLATE_* env vars
+var translateSettings = []setting{
+	{
+		key: "translate.provider", env: "GATOR_TRANSLATE_PROVIDER", desc: "translation provider: deepl, libretranslate or openai",
+		get: func(c *Config) (string, bool) {
+			if c.Translate == nil {
+				return "", false
+			}
+			return stringValue(c.Translate.Provider)
+		},
+		set: func(c *Config, value string) error {
+			// provider check
+			switch value {
+			case "", translate.DeepL, translate.LibreTranslate, translate.OpenAI:
+			default:
+				return fmt.Errorf("error: invalid translate.provider %q (use deepl, libretranslate or openai)", value)
+			}
+			translateSection(c).Provider = optionalString(value)
+			trimTranslate(c)
+			return nil
+		},
+	},
+	{
+		key: "translate.url", env: "GATOR_TRANSLATE_URL", desc: "translation api url (default: the provider's)",
+		get: func(c *Config) (string, bool) {
+			if c.Translate == nil {
+				return "", false
+			}
+			return stringValue(c.Translate.URL)
+		},
+		set: func(c *Config, value string) error {
+			// url check
+			if value != "" {
+				u, err := url.Parse(value)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("error: invalid translate.url %q (use an http(s) url, eg http://localhost:5000)", value)
+				}
+			}
+			translateSection(c).URL = optionalString(value)
+			trimTranslate(c)
+			return nil
+		},
+	},
+	{
+		key: "translate.api_key", env: "GATOR_TRANSLATE_API_KEY", desc: "translation api key", secret: true,
+		get: func(c *Config) (string, bool) {
+			if c.Translate == nil {
+				return "", false
+			}
+			return stringValue(c.Translate.APIKey)
+		},
+		set: func(c *Config, value string) error {
+			translateSection(c).APIKey = optionalString(value)
+			trimTranslate(c)
+			return nil
+		},
+	},
+	{
+		key: "translate.model", env: "GATOR_TRANSLATE_MODEL", desc: "model of the openai provider (default gpt-4o-mini)",
+		get: func(c *Config) (string, bool) {
+			if c.Translate == nil {
+				return "", false
+			}
+			return stringValue(c.Translate.Model)
+		},
+		set: func(c *Config, value string) error {
+			translateSection(c).Model = optionalString(value)
+			trimTranslate(c)
+			return nil
+		},
+	},
+}
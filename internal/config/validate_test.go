@@ -0,0 +1,98 @@
+// validate_test.go
+package config
+
+import (
+	// import standard Go libraries
+	"os"            // writing test files
+	"path/filepath" // test file paths
+	"strings"       // matching problems
+	"testing"       // go tests
+)
+
+// every problem of a file is found, each with its key, and the valid keys are kept
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string // the problems, each key: a part of its message
+	}{
+		{"valid", `{"db_url": "postgres://localhost/gator", "agg_interval": "10m", "http": {"timeout": "5s"}}`, nil},
+		{"syntax error", "{\n  \"db_url\": \"postgres://localhost/gator\",\n}", []string{"invalid json at line 3, column 1"}},
+		{"not an object", `["db_url"]`, []string{"must be a json object"}},
+		{"unknown key", `{"db_url": "postgres://localhost/gator", "agg_intervall": "10m"}`, []string{`agg_intervall: unknown key, did you mean "agg_interval"?`}},
+		{"unknown key, nothing close", `{"colour": "green"}`, []string{"colour: unknown key"}},
+		{"wrong type", `{"db_url": 5432}`, []string{"db_url: should be a string, not a number"}},
+		{"invalid value", `{"agg_interval": "often"}`, []string{`agg_interval: invalid agg_interval "often"`}},
+		{"section isn't an object", `{"http": "fast"}`, []string{"http: should be a section of keys"}},
+		{"unknown section key", `{"http": {"timeot": "5s"}}`, []string{`http.timeot: unknown key, did you mean "http.timeout"?`}},
+		{"every problem, in key order", `{"log_level": "loud", "agg_interval": "often", "colour": "green"}`, []string{"agg_interval:", "colour:", "log_level:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := validateFile([]byte(tt.data), formatJSON)
+			if len(problems) != len(tt.want) {
+				t.Fatalf("validateFile problems = %v, want %d", problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i].String(), want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], want)
+				}
+			}
+		})
+	}
+
+	// valid keys check, next to invalid ones
+	cfg, _ := validateFile([]byte(`{"agg_interval": "10m", "log_level": "loud"}`), formatJSON)
+	if cfg.AggInterval == nil || *cfg.AggInterval != "10m" {
+		t.Errorf("validateFile kept agg_interval %v, want 10m", cfg.AggInterval)
+	}
+}
+
+// toml and yaml files are validated key by key like json ones
+func TestValidateFileFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		f    format
+		want []string
+	}{
+		{"valid toml", "db_url = \"postgres://localhost/gator\"\n[http]\ntimeout = \"5s\"\n", formatTOML, nil},
+		{"valid yaml", "db_url: postgres://localhost/gator\nhttp:\n  timeout: 5s\n", formatYAML, nil},
+		{"toml syntax error", "db_url = postgres\n", formatTOML, []string{"invalid toml:"}},
+		{"yaml syntax error", "db_url: [postgres\n", formatYAML, []string{"invalid yaml:"}},
+		{"toml problems", "agg_interval = 10\n[http]\ntimeot = \"5s\"\n", formatTOML, []string{"agg_interval: should be a string, not a number", `http.timeot: unknown key, did you mean "http.timeout"?`}},
+		{"yaml problems", "log_level: loud\nhttp: fast\n", formatYAML, []string{"http: should be a section of keys", "log_level:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := validateFile([]byte(tt.data), tt.f)
+			if len(problems) != len(tt.want) {
+				t.Fatalf("validateFile problems = %v, want %d", problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i].String(), want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+// a missing db_url and a bad env var are problems too
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gator.json")
+	err := os.WriteFile(path, []byte(`{"agg_interval": "10m"}`), 0600)
+	if err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	t.Setenv("GATOR_LOG_LEVEL", "loud")
+
+	problems, err := Config{path: path}.Validate()
+	if err != nil {
+		t.Fatalf("Validate error = %v", err)
+	}
+	if !hasProblem(problems, "GATOR_LOG_LEVEL") || !hasProblem(problems, "db_url") {
+		t.Errorf("Validate problems = %v, want GATOR_LOG_LEVEL and db_url", problems)
+	}
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,7 +27,7 @@ WITH inserted_feed_follow AS (
     -- value placeholders
     VALUES (
         $1, $2, $3, $4, $5
-    ) RETURNING id, created_at, updated_at, user_id, feed_id -- return after insert (populates the CTE record!)
+    ) RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, notify, translate_to -- return after insert (populates the CTE record!)
 )
 SELECT
     iff.id,
@@ -91,7 +92,7 @@ USING feeds f
 WHERE f.url = $1         -- matches url
   AND ff.user_id = $2    -- matches user_id
   AND ff.feed_id = f.id  -- feed follow id matches feed id
-RETURNING ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id
+RETURNING ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id, ff.folder_id, ff.notify, ff.translate_to, ff.weight, ff.snoozed_until
 `
 
 type DeleteFeedFollowByUserAndFeedParams struct {
@@ -111,6 +112,11 @@ func (q *Queries) DeleteFeedFollowByUserAndFeed(ctx context.Context, arg DeleteF
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.Notify,
+		&i.TranslateTo,
+		&i.Weight,
+		&i.SnoozedUntil,
 	)
 	return i, err
 }
@@ -128,6 +134,7 @@ FROM feed_follows ff
 INNER JOIN users u ON u.id = ff.user_id
 INNER JOIN feeds f ON f.id = ff.feed_id
 WHERE ff.user_id = $1
+AND f.deleted_at IS NULL
 ORDER BY ff.created_at DESC
 `
 
@@ -144,7 +151,7 @@ type GetFeedFollowsForUserRow struct {
 // get all feed follows for a user
 // inner join users (omit other users)
 // inner join feeds (omit other feeds)
-// where clause to filter by user_id
+// where clause to filter by user_id (and hide soft deleted feeds)
 // order by created_at descending (otherwise random with where clause)
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
 	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
@@ -176,3 +183,413 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const listFeedWeights = `-- name: ListFeedWeights :many
+SELECT
+    f.name,
+    f.url,
+    ff.weight,
+    COUNT(p.id) AS posts,
+    COUNT(ps.read_at) AS reads,
+    COUNT(ps.saved_at) AS stars
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+LEFT JOIN posts p ON p.feed_id = ff.feed_id AND p.created_at > $1::timestamp
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = $2
+GROUP BY f.name, f.url, ff.weight
+ORDER BY ff.weight DESC, f.name
+`
+
+type ListFeedWeightsParams struct {
+	Since  time.Time
+	UserID uuid.UUID
+}
+
+type ListFeedWeightsRow struct {
+	Name   string
+	Url    string
+	Weight float32
+	Posts  int64
+	Reads  int64
+	Stars  int64
+}
+
+// a user's follows with their weight, and how many of their posts since a time the user read and starred
+func (q *Queries) ListFeedWeights(ctx context.Context, arg ListFeedWeightsParams) ([]ListFeedWeightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedWeights, arg.Since, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeedWeightsRow
+	for rows.Next() {
+		var i ListFeedWeightsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Weight,
+			&i.Posts,
+			&i.Reads,
+			&i.Stars,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowsChangedSince = `-- name: ListFollowsChangedSince :many
+SELECT
+    f.name,
+    f.url,
+    ff.created_at
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = $1
+AND ff.created_at > $2::timestamp
+ORDER BY ff.created_at, f.url
+`
+
+type ListFollowsChangedSinceParams struct {
+	UserID uuid.UUID
+	Since  time.Time
+}
+
+type ListFollowsChangedSinceRow struct {
+	Name      string
+	Url       string
+	CreatedAt time.Time
+}
+
+// a user's follows made after a time, oldest first (gator sync sends them to the other instance)
+func (q *Queries) ListFollowsChangedSince(ctx context.Context, arg ListFollowsChangedSinceParams) ([]ListFollowsChangedSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowsChangedSince, arg.UserID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowsChangedSinceRow
+	for rows.Next() {
+		var i ListFollowsChangedSinceRow
+		if err := rows.Scan(&i.Name, &i.Url, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifyFeeds = `-- name: ListNotifyFeeds :many
+SELECT
+    f.name,
+    f.url
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+AND ff.notify
+ORDER BY f.name
+`
+
+type ListNotifyFeedsRow struct {
+	Name string
+	Url  string
+}
+
+// the feeds a user is notified about
+func (q *Queries) ListNotifyFeeds(ctx context.Context, userID uuid.UUID) ([]ListNotifyFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNotifyFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNotifyFeedsRow
+	for rows.Next() {
+		var i ListNotifyFeedsRow
+		if err := rows.Scan(&i.Name, &i.Url); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnoozedFeeds = `-- name: ListSnoozedFeeds :many
+SELECT
+    f.name,
+    f.url,
+    ff.snoozed_until
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+AND ff.snoozed_until > $2::timestamp
+ORDER BY ff.snoozed_until, f.name
+`
+
+type ListSnoozedFeedsParams struct {
+	UserID uuid.UUID
+	Now    time.Time
+}
+
+type ListSnoozedFeedsRow struct {
+	Name         string
+	Url          string
+	SnoozedUntil sql.NullTime
+}
+
+// a user's follows snoozed past a time, soonest to resume first
+func (q *Queries) ListSnoozedFeeds(ctx context.Context, arg ListSnoozedFeedsParams) ([]ListSnoozedFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSnoozedFeeds, arg.UserID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSnoozedFeedsRow
+	for rows.Next() {
+		var i ListSnoozedFeedsRow
+		if err := rows.Scan(&i.Name, &i.Url, &i.SnoozedUntil); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranslateFeeds = `-- name: ListTranslateFeeds :many
+SELECT
+    f.id,
+    f.name,
+    f.url,
+    ff.translate_to::text AS translate_to
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+AND ff.translate_to IS NOT NULL
+ORDER BY f.name
+`
+
+type ListTranslateFeedsRow struct {
+	ID          uuid.UUID
+	Name        string
+	Url         string
+	TranslateTo string
+}
+
+// the feeds a user has auto-translated, and the language of each
+func (q *Queries) ListTranslateFeeds(ctx context.Context, userID uuid.UUID) ([]ListTranslateFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTranslateFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTranslateFeedsRow
+	for rows.Next() {
+		var i ListTranslateFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.TranslateTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserDataFollows = `-- name: ListUserDataFollows :many
+SELECT
+    f.name,
+    f.url,
+    fo.name AS folder,
+    ff.created_at,
+    ff.notify,
+    ff.translate_to,
+    ff.weight,
+    ff.snoozed_until
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+ORDER BY ff.created_at, f.url
+`
+
+type ListUserDataFollowsRow struct {
+	Name         string
+	Url          string
+	Folder       sql.NullString
+	CreatedAt    time.Time
+	Notify       bool
+	TranslateTo  sql.NullString
+	Weight       float32
+	SnoozedUntil sql.NullTime
+}
+
+// a user's follows with their folder and settings, oldest first (export --all)
+func (q *Queries) ListUserDataFollows(ctx context.Context, userID uuid.UUID) ([]ListUserDataFollowsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserDataFollows, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserDataFollowsRow
+	for rows.Next() {
+		var i ListUserDataFollowsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Folder,
+			&i.CreatedAt,
+			&i.Notify,
+			&i.TranslateTo,
+			&i.Weight,
+			&i.SnoozedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowNotify = `-- name: SetFeedFollowNotify :execrows
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  notify = $1
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = $2
+AND ff.user_id = $3
+`
+
+type SetFeedFollowNotifyParams struct {
+	Notify bool
+	Url    string
+	UserID uuid.UUID
+}
+
+// flag (or unflag) a user's follow of a feed, so its new posts are pushed to them
+func (q *Queries) SetFeedFollowNotify(ctx context.Context, arg SetFeedFollowNotifyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowNotify, arg.Notify, arg.Url, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedFollowSnooze = `-- name: SetFeedFollowSnooze :execrows
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  snoozed_until = $1
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = $2
+AND ff.user_id = $3
+`
+
+type SetFeedFollowSnoozeParams struct {
+	SnoozedUntil sql.NullTime
+	Url          string
+	UserID       uuid.UUID
+}
+
+// snooze a user's follow of a feed until a time, or resume it (NULL)
+func (q *Queries) SetFeedFollowSnooze(ctx context.Context, arg SetFeedFollowSnoozeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowSnooze, arg.SnoozedUntil, arg.Url, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedFollowTranslate = `-- name: SetFeedFollowTranslate :execrows
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  translate_to = $1
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = $2
+AND ff.user_id = $3
+`
+
+type SetFeedFollowTranslateParams struct {
+	TranslateTo sql.NullString
+	Url         string
+	UserID      uuid.UUID
+}
+
+// set (or clear, NULL) the language a user's follow of a feed is auto-translated to
+func (q *Queries) SetFeedFollowTranslate(ctx context.Context, arg SetFeedFollowTranslateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowTranslate, arg.TranslateTo, arg.Url, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedFollowWeight = `-- name: SetFeedFollowWeight :execrows
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  weight = $1
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = $2
+AND ff.user_id = $3
+`
+
+type SetFeedFollowWeightParams struct {
+	Weight float32
+	Url    string
+	UserID uuid.UUID
+}
+
+// set how much a user's follow of a feed counts in browse --ranked
+func (q *Queries) SetFeedFollowWeight(ctx context.Context, arg SetFeedFollowWeightParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowWeight, arg.Weight, arg.Url, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
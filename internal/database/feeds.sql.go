@@ -7,32 +7,59 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countFeedDependents = `-- name: CountFeedDependents :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows ff WHERE ff.feed_id = f.id) AS feed_follows,
+    (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id) AS posts
+FROM feeds f
+WHERE f.url = $1
+`
+
+type CountFeedDependentsRow struct {
+	FeedFollows int64
+	Posts       int64
+}
+
+// count everything deleting a feed cascades to (for --dry-run)
+func (q *Queries) CountFeedDependents(ctx context.Context, url string) (CountFeedDependentsRow, error) {
+	row := q.db.QueryRowContext(ctx, countFeedDependents, url)
+	var i CountFeedDependentsRow
+	err := row.Scan(&i.FeedFollows, &i.Posts)
+	return i, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, refresh_interval_seconds, url_key)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, refresh_interval_seconds, last_error, last_error_at, consecutive_failures, deleted_at, fever_id, refresh_cron, next_fetch_at, next_attempt_at, url_key
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Name                   string
+	Url                    string
+	UserID                 uuid.UUID
+	RefreshIntervalSeconds sql.NullInt32
+	UrlKey                 sql.NullString
 }
 
 // feeds.sql
@@ -44,6 +71,8 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.RefreshIntervalSeconds,
+		arg.UrlKey,
 	)
 	var i Feed
 	err := row.Scan(
@@ -54,14 +83,106 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RefreshIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.DeletedAt,
+		&i.FeverID,
+		&i.RefreshCron,
+		&i.NextFetchAt,
+		&i.NextAttemptAt,
+		&i.UrlKey,
 	)
 	return i, err
 }
 
+const deleteFeedByURL = `-- name: DeleteFeedByURL :execrows
+DELETE FROM feeds
+WHERE url = $1
+`
+
+func (q *Queries) DeleteFeedByURL(ctx context.Context, url string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedByURL, url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const discoverFeeds = `-- name: DiscoverFeeds :many
+SELECT
+    f.name,
+    f.url,
+    COUNT(DISTINCT ff.user_id) AS followers,
+    (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id AND COALESCE(p.published_at, p.created_at) > $1) AS recent_posts
+FROM feeds f
+INNER JOIN feed_follows ff ON ff.feed_id = f.id
+INNER JOIN users u ON u.id = ff.user_id AND u.deleted_at IS NULL
+WHERE f.deleted_at IS NULL
+AND ff.user_id != $2
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows mine
+    WHERE mine.feed_id = f.id
+    AND mine.user_id = $2
+)
+GROUP BY f.id
+ORDER BY followers DESC,
+         recent_posts DESC,
+         f.name
+LIMIT $3
+`
+
+type DiscoverFeedsParams struct {
+	ActiveSince time.Time
+	UserID      uuid.UUID
+	FeedLimit   int32
+}
+
+type DiscoverFeedsRow struct {
+	Name        string
+	Url         string
+	Followers   int64
+	RecentPosts int64
+}
+
+// discover: feeds other users follow that this user doesn't, most followed first, then most active
+// inner join feed_follows (only followed feeds)
+// inner join users (soft deleted users don't count)
+// not the feeds the user already follows
+func (q *Queries) DiscoverFeeds(ctx context.Context, arg DiscoverFeedsParams) ([]DiscoverFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, discoverFeeds, arg.ActiveSince, arg.UserID, arg.FeedLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DiscoverFeedsRow
+	for rows.Next() {
+		var i DiscoverFeedsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Followers,
+			&i.RecentPosts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedByURL = `-- name: GetFeedByURL :one
 SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
 WHERE url = $1 -- url to match the inputy
+AND deleted_at IS NULL -- soft deleted feeds can't be followed
 LIMIT 1
 `
 
@@ -88,15 +209,153 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (GetFeedByURLRow
 	return i, err
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
+const getFeedByURLKey = `-- name: GetFeedByURLKey :one
+SELECT url, deleted_at FROM feeds
+WHERE url_key = $1
+`
 
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds          -- we return ALL cols for ScrapeFeeds
+type GetFeedByURLKeyRow struct {
+	Url       string
+	DeletedAt sql.NullTime
+}
+
+// the feed with a urlnorm.Key (soft deleted too, it still holds its key) for spotting equivalent feeds
+func (q *Queries) GetFeedByURLKey(ctx context.Context, urlKey sql.NullString) (GetFeedByURLKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURLKey, urlKey)
+	var i GetFeedByURLKeyRow
+	err := row.Scan(&i.Url, &i.DeletedAt)
+	return i, err
+}
+
+const getFeedOwner = `-- name: GetFeedOwner :one
+SELECT user_id
+FROM feeds
+WHERE url = $1
+`
+
+// who added a feed, soft deleted or not (removefeed's permission check)
+func (q *Queries) GetFeedOwner(ctx context.Context, url string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getFeedOwner, url)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getFeedsByIDs = `-- name: GetFeedsByIDs :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, refresh_interval_seconds, last_error, last_error_at, consecutive_failures, deleted_at, fever_id, refresh_cron, next_fetch_at, next_attempt_at, url_key FROM feeds
+WHERE id = ANY($1::uuid[])
+AND deleted_at IS NULL
+`
+
+// the feeds of the fetch jobs agg claimed
+func (q *Queries) GetFeedsByIDs(ctx context.Context, ids []uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.RefreshIntervalSeconds,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.DeletedAt,
+			&i.FeverID,
+			&i.RefreshCron,
+			&i.NextFetchAt,
+			&i.NextAttemptAt,
+			&i.UrlKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsToFetch = `-- name: GetFeedsToFetch :many
+
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, refresh_interval_seconds, last_error, last_error_at, consecutive_failures, deleted_at, fever_id, refresh_cron, next_fetch_at, next_attempt_at, url_key FROM feeds          -- we return ALL cols for ScrapeFeed
+WHERE deleted_at IS NULL     -- skip soft deleted feeds
+AND url NOT LIKE 'newsletter:%' -- skip newsletter feeds, their posts come by email
+AND NOT (                    -- skip feeds everyone following them snoozed
+    EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND ff.snoozed_until > NOW() AT TIME ZONE 'UTC')
+    AND NOT EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC'))
+)
+ORDER BY last_fetched_at ASC -- from oldest to newest
+NULLS FIRST
+`
+
+func (q *Queries) GetFeedsToFetch(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsToFetch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.RefreshIntervalSeconds,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.DeletedAt,
+			&i.FeverID,
+			&i.RefreshCron,
+			&i.NextFetchAt,
+			&i.NextAttemptAt,
+			&i.UrlKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, refresh_interval_seconds, last_error, last_error_at, consecutive_failures, deleted_at, fever_id, refresh_cron, next_fetch_at, next_attempt_at, url_key FROM feeds          -- we return ALL cols for ScrapeFeeds
+WHERE deleted_at IS NULL     -- skip soft deleted feeds
+AND url NOT LIKE 'newsletter:%' -- skip newsletter feeds, their posts come by email
+AND NOT (                    -- skip feeds everyone following them snoozed
+    EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND ff.snoozed_until > NOW() AT TIME ZONE 'UTC')
+    AND NOT EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC'))
+)
 ORDER BY last_fetched_at ASC -- from oldest to newest
 NULLS FIRST                   -- any null fetched at record first, these are EVEN older!
 LIMIT 1
 `
 
-// use feed_id (unique as it's a pk)
 func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
 	var i Feed
@@ -108,20 +367,118 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RefreshIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.DeletedAt,
+		&i.FeverID,
+		&i.RefreshCron,
+		&i.NextFetchAt,
+		&i.NextAttemptAt,
+		&i.UrlKey,
 	)
 	return i, err
 }
 
+const listAllFeeds = `-- name: ListAllFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, refresh_interval_seconds, last_error, last_error_at, consecutive_failures, deleted_at, fever_id, refresh_cron, next_fetch_at, next_attempt_at, url_key FROM feeds
+ORDER BY created_at
+`
+
+// full rows (bench)
+func (q *Queries) ListAllFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listAllFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.RefreshIntervalSeconds,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.DeletedAt,
+			&i.FeverID,
+			&i.RefreshCron,
+			&i.NextFetchAt,
+			&i.NextAttemptAt,
+			&i.UrlKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedURLs = `-- name: ListFeedURLs :many
+SELECT url, deleted_at FROM feeds
+WHERE url_key IS NULL
+ORDER BY created_at
+`
+
+type ListFeedURLsRow struct {
+	Url       string
+	DeletedAt sql.NullTime
+}
+
+// the stored urls without a url_key (soft deleted too, they still hold their url) for spotting equivalent feeds
+// feeds added before url keys, their key is worked out in go
+func (q *Queries) ListFeedURLs(ctx context.Context) ([]ListFeedURLsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedURLs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeedURLsRow
+	for rows.Next() {
+		var i ListFeedURLsRow
+		if err := rows.Scan(&i.Url, &i.DeletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFeedsWithCreator = `-- name: ListFeedsWithCreator :many
-SELECT f.name AS feedName, f.url AS feedURL, u.name AS userName
+SELECT f.name AS feedName, f.url AS feedURL, u.name AS userName,
+       f.consecutive_failures, f.last_error, f.last_error_at -- for feeds --broken
 FROM feeds f INNER JOIN users u
 ON u.id = f.user_id
+WHERE f.deleted_at IS NULL
 `
 
 type ListFeedsWithCreatorRow struct {
-	Feedname string
-	Feedurl  string
-	Username string
+	Feedname            string
+	Feedurl             string
+	Username            string
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
 }
 
 func (q *Queries) ListFeedsWithCreator(ctx context.Context) ([]ListFeedsWithCreatorRow, error) {
@@ -133,7 +490,14 @@ func (q *Queries) ListFeedsWithCreator(ctx context.Context) ([]ListFeedsWithCrea
 	var items []ListFeedsWithCreatorRow
 	for rows.Next() {
 		var i ListFeedsWithCreatorRow
-		if err := rows.Scan(&i.Feedname, &i.Feedurl, &i.Username); err != nil {
+		if err := rows.Scan(
+			&i.Feedname,
+			&i.Feedurl,
+			&i.Username,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastErrorAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -147,12 +511,130 @@ func (q *Queries) ListFeedsWithCreator(ctx context.Context) ([]ListFeedsWithCrea
 	return items, nil
 }
 
+const listNewsletterFeeds = `-- name: ListNewsletterFeeds :many
+SELECT id, created_at, name, url, last_fetched_at
+FROM feeds
+WHERE user_id = $1
+AND url LIKE 'newsletter:%'
+AND deleted_at IS NULL
+ORDER BY name
+`
+
+type ListNewsletterFeedsRow struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	Name          string
+	Url           string
+	LastFetchedAt sql.NullTime
+}
+
+// the newsletter feeds a user added (see newsletter), their url is newsletter:<address token>
+func (q *Queries) ListNewsletterFeeds(ctx context.Context, userID uuid.UUID) ([]ListNewsletterFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNewsletterFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNewsletterFeedsRow
+	for rows.Next() {
+		var i ListNewsletterFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Url,
+			&i.LastFetchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockFeedForFetch = `-- name: LockFeedForFetch :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, refresh_interval_seconds, last_error, last_error_at, consecutive_failures, deleted_at, fever_id, refresh_cron, next_fetch_at, next_attempt_at, url_key FROM feeds
+WHERE id = $1
+AND deleted_at IS NULL
+AND last_fetched_at IS NOT DISTINCT FROM $2
+FOR UPDATE SKIP LOCKED
+`
+
+type LockFeedForFetchParams struct {
+	ID            uuid.UUID
+	LastFetchedAt sql.NullTime
+}
+
+// claim a due feed inside the fetch's transaction, so two aggs never fetch it twice
+// we should only get 1 agg per feed, as there MIGHT be more than one agg running
+// SKIP LOCKED = no row if another agg is fetching it right now (instead of waiting for it)
+// and the last_fetched_at check = no row if another agg fetched it since we listed the feeds
+func (q *Queries) LockFeedForFetch(ctx context.Context, arg LockFeedForFetchParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, lockFeedForFetch, arg.ID, arg.LastFetchedAt)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.RefreshIntervalSeconds,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.DeletedAt,
+		&i.FeverID,
+		&i.RefreshCron,
+		&i.NextFetchAt,
+		&i.NextAttemptAt,
+		&i.UrlKey,
+	)
+	return i, err
+}
+
+const markFeedFailed = `-- name: MarkFeedFailed :exec
+
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  last_error = $2,
+  last_error_at = NOW() AT TIME ZONE 'UTC',
+  consecutive_failures = consecutive_failures + 1,
+  next_attempt_at = NOW() AT TIME ZONE 'UTC' + LEAST(60 * POWER(2, LEAST(consecutive_failures, 9)), 21600) * INTERVAL '1 second'
+WHERE id = $1
+`
+
+type MarkFeedFailedParams struct {
+	ID        uuid.UUID
+	LastError sql.NullString
+}
+
+// use feed_id (unique as it's a pk)
+// record a failed fetch, backing off before the next attempt: 1, 2, 4... minutes (the old streak), at most 6 hours
+// last_fetched_at stays, so without the backoff a broken feed would be due again right away
+func (q *Queries) MarkFeedFailed(ctx context.Context, arg MarkFeedFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFailed, arg.ID, arg.LastError)
+	return err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 
 UPDATE feeds
 SET
   updated_at = NOW(),
-  last_fetched_at = NOW()
+  last_fetched_at = NOW() AT TIME ZONE 'UTC', -- UTC so the scheduler can compare it with Go's clock
+  last_error = NULL, -- it works again, clear the failure streak
+  last_error_at = NULL,
+  consecutive_failures = 0,
+  next_attempt_at = NULL
 WHERE id = $1
 `
 
@@ -161,3 +643,102 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const setFeedNextFetch = `-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1
+`
+
+type SetFeedNextFetchParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+}
+
+// when a cron feed is due next, after it was fetched
+func (q *Queries) SetFeedNextFetch(ctx context.Context, arg SetFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetch, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const setFeedRefreshInterval = `-- name: SetFeedRefreshInterval :execrows
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  refresh_interval_seconds = $2 -- NULL resets to the agg interval
+WHERE url = $1
+AND deleted_at IS NULL
+`
+
+type SetFeedRefreshIntervalParams struct {
+	Url                    string
+	RefreshIntervalSeconds sql.NullInt32
+}
+
+func (q *Queries) SetFeedRefreshInterval(ctx context.Context, arg SetFeedRefreshIntervalParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedRefreshInterval, arg.Url, arg.RefreshIntervalSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedSchedule = `-- name: SetFeedSchedule :execrows
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  refresh_cron = $2, -- NULL = back to the refresh interval
+  next_fetch_at = $3
+WHERE url = $1
+AND deleted_at IS NULL
+`
+
+type SetFeedScheduleParams struct {
+	Url         string
+	RefreshCron sql.NullString
+	NextFetchAt sql.NullTime
+}
+
+// set or clear a feed's cron schedule (setschedule), with when it's first due
+func (q *Queries) SetFeedSchedule(ctx context.Context, arg SetFeedScheduleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedSchedule, arg.Url, arg.RefreshCron, arg.NextFetchAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteFeedByURL = `-- name: SoftDeleteFeedByURL :execrows
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  deleted_at = NOW()
+WHERE url = $1
+AND deleted_at IS NULL
+`
+
+// removefeed: mark the feed deleted, its follows and posts stay for undelete
+func (q *Queries) SoftDeleteFeedByURL(ctx context.Context, url string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteFeedByURL, url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const undeleteFeedByURL = `-- name: UndeleteFeedByURL :execrows
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  deleted_at = NULL
+WHERE url = $1
+AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) UndeleteFeedByURL(ctx context.Context, url string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, undeleteFeedByURL, url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
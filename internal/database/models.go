@@ -6,43 +6,301 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AggWorker struct {
+	ID          uuid.UUID
+	Hostname    string
+	Pid         int32
+	StartedAt   time.Time
+	HeartbeatAt time.Time
+}
+
+type ApiToken struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	LastUsedAt sql.NullTime
+}
+
+type DigestPreference struct {
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Cadence    string
+	Weekday    int32
+	SendMinute int32
+	Timezone   string
+	Channel    string
+	Folders    []string
+	Tags       []string
+	LastSentAt sql.NullTime
+}
+
+type DigestSubscription struct {
+	UserID           uuid.UUID
+	CreatedAt        time.Time
+	Email            string
+	LastSentAt       sql.NullTime
+	UnsubscribeToken string
+}
+
+type Enclosure struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	MimeType  sql.NullString
+	Length    sql.NullInt64
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Name                   string
+	Url                    string
+	UserID                 uuid.UUID
+	LastFetchedAt          sql.NullTime
+	RefreshIntervalSeconds sql.NullInt32
+	LastError              sql.NullString
+	LastErrorAt            sql.NullTime
+	ConsecutiveFailures    int32
+	DeletedAt              sql.NullTime
+	FeverID                int64
+	RefreshCron            sql.NullString
+	NextFetchAt            sql.NullTime
+	NextAttemptAt          sql.NullTime
+	UrlKey                 sql.NullString
 }
 
 type FeedFollow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	FolderID     uuid.NullUUID
+	Notify       bool
+	TranslateTo  sql.NullString
+	Weight       float32
+	SnoozedUntil sql.NullTime
+}
+
+type FeedSnapshot struct {
+	FeedID      uuid.UUID
+	FetchedAt   time.Time
+	ContentType string
+	Body        []byte
+	Size        int32
+	Truncated   bool
+}
+
+type FetchJob struct {
+	ID         int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FeedID     uuid.UUID
+	State      string
+	Priority   int32
+	Attempts   int32
+	RunAfter   time.Time
+	StartedAt  sql.NullTime
+	FinishedAt sql.NullTime
+	LastError  sql.NullString
+	WorkerID   uuid.NullUUID
+}
+
+type FeverAccount struct {
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	ApiKeyHash string
+}
+
+type Folder struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Name      string
+	UserID    uuid.UUID
+	FeverID   int64
+}
+
+type HostBreaker struct {
+	Host                string
+	ConsecutiveTimeouts int32
+	OpenUntil           sql.NullTime
+	LastError           sql.NullString
+	UpdatedAt           time.Time
+}
+
+type LanguagePreference struct {
+	UserID    uuid.UUID
+	UpdatedAt time.Time
+	Languages []string
+}
+
+type Mute struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
 	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	Pattern   string
+	IsRegex   bool
 }
 
 type Post struct {
-	ID          uuid.UUID
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	FeedID         uuid.UUID
+	FeverID        int64
+	Language       sql.NullString
+	ReadingMinutes sql.NullInt32
+	Guid           sql.NullString
+}
+
+type PostNote struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Body      string
+	Highlight sql.NullString
+}
+
+type PostTag struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type PostShare struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	FromUserID uuid.UUID
+	ToUserID   uuid.UUID
+	PostID     uuid.UUID
+	Message    sql.NullString
+	SeenAt     sql.NullTime
+}
+
+type PostState struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	UpdatedAt time.Time
+	ReadAt    sql.NullTime
+	SavedAt   sql.NullTime
+	MutedAt   sql.NullTime
+}
+
+type PostSummary struct {
+	PostID    uuid.UUID
+	CreatedAt time.Time
+	Summary   string
+}
+
+type PostTranslation struct {
+	PostID      uuid.UUID
+	Language    string
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
 	Title       string
-	Url         string
 	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
 }
 
-type User struct {
-	ID        uuid.UUID
+type ProfileShare struct {
+	UserID    uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+}
+
+type PushTarget struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	Service   string
+	Target    string
+	Token     sql.NullString
+	PushedAt  time.Time
+}
+
+type ReadLaterAccount struct {
+	UserID    uuid.UUID
+	Service   string
+	CreatedAt time.Time
+	Settings  json.RawMessage
+}
+
+type Rule struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	FeedID       uuid.NullUUID
+	TitlePattern sql.NullString
+	Category     sql.NullString
+	Author       sql.NullString
+	Action       string
+	Argument     sql.NullString
+}
+
+type Session struct {
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UserID    uuid.UUID
+	CsrfToken string
+}
+
+type ShortLink struct {
+	Url         string
+	CreatedAt   time.Time
+	ResolvedUrl string
+}
+
+type SyncAccount struct {
+	UserID    uuid.UUID
+	Service   string
+	CreatedAt time.Time
+	Settings  json.RawMessage
+	PushRead  bool
+	SyncedAt  sql.NullTime
+}
+
+type TelegramChat struct {
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	ChatID     sql.NullInt64
+	LinkCode   sql.NullString
+	PushedAt   time.Time
+	LinkCodeAt sql.NullTime
+}
+
+type TimelineShare struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type User struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Name               string
+	DeletedAt          sql.NullTime
+	BrowseCursorAt     sql.NullTime
+	BrowseCursorPostID uuid.NullUUID
+	IsAdmin            bool
+}
+
+type UserPassword struct {
+	UserID       uuid.UUID
+	UpdatedAt    time.Time
+	PasswordHash string
 }
@@ -11,8 +11,90 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const archivePostsOlderThan = `-- name: ArchivePostsOlderThan :many
+DELETE FROM posts p
+USING feeds f
+WHERE p.feed_id = f.id
+AND COALESCE(p.published_at, p.created_at) < $1
+AND NOT EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = p.id) -- posts with notes are kept
+RETURNING
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    f.name AS feed_name,
+    f.url AS feed_url
+`
+
+type ArchivePostsOlderThanRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	FeedName    string
+	FeedUrl     string
+}
+
+// prune --archive: delete the old posts and return them (with their feed) to be archived
+func (q *Queries) ArchivePostsOlderThan(ctx context.Context, cutoff time.Time) ([]ArchivePostsOlderThanRow, error) {
+	rows, err := q.db.QueryContext(ctx, archivePostsOlderThan, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ArchivePostsOlderThanRow
+	for rows.Next() {
+		var i ArchivePostsOlderThanRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countPostsOlderThan = `-- name: CountPostsOlderThan :one
+SELECT COUNT(*) FROM posts
+WHERE COALESCE(published_at, created_at) < $1
+AND NOT EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = posts.id) -- posts with notes are kept
+`
+
+// count what prune would delete (for --dry-run)
+func (q *Queries) CountPostsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsOlderThan, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPost = `-- name: CreatePost :one
 
 INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
@@ -26,7 +108,7 @@ VALUES (
     $7,
     $8
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, fever_id, language, reading_minutes, guid
 `
 
 type CreatePostParams struct {
@@ -62,10 +144,233 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.FeverID,
+		&i.Language,
+		&i.ReadingMinutes,
+		&i.Guid,
 	)
 	return i, err
 }
 
+const deletePostsOlderThan = `-- name: DeletePostsOlderThan :execrows
+DELETE FROM posts
+WHERE COALESCE(published_at, created_at) < $1
+AND NOT EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = posts.id) -- posts with notes are kept
+`
+
+// posts without a pubdate use their created_at instead
+func (q *Queries) DeletePostsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePostsOlderThan, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const findPostsForUser = `-- name: FindPostsForUser :many
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = $1
+AND (p.url = $2::text OR p.id::text LIKE $2::text || '%')
+ORDER BY p.created_at DESC
+LIMIT 2
+`
+
+type FindPostsForUserParams struct {
+	UserID uuid.UUID
+	Ref    string
+}
+
+// sendto: a followed post by its url or the start of its id (browse prints the first 8 characters)
+// limit 2 so callers can tell an ambiguous id prefix apart
+// inner join feed_follows (omit other feeds and users)
+// inner join feeds (omit soft deleted feeds)
+func (q *Queries) FindPostsForUser(ctx context.Context, arg FindPostsForUserParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, findPostsForUser, arg.UserID, arg.Ref)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNewPostsForUser = `-- name: GetNewPostsForUser :many
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (p.created_at, p.id) > ($2::timestamp, $3::uuid)
+AND ($4::text IS NULL OR fo.name = $4)
+AND ($5::text IS NULL OR p.language = $5) -- browse --lang
+AND ($6::int IS NULL OR p.reading_minutes <= $6) -- browse --max-minutes
+ORDER BY p.created_at,
+         p.id
+LIMIT $7
+`
+
+type GetNewPostsForUserParams struct {
+	UserID       uuid.UUID
+	CursorAt     time.Time
+	CursorPostID uuid.UUID
+	Folder       sql.NullString
+	Language     sql.NullString
+	MaxMinutes   sql.NullInt32
+	PostLimit    int32
+}
+
+// browse --new: posts that arrived after the user's cursor, oldest first so paging never skips any
+// the zero time and nil uuid mean no cursor yet, folder NULL means every followed feed
+// inner join feed_follows (omit other feeds and users)
+// inner join feeds (omit soft deleted feeds)
+// left join folders (--folder, follows without one still count otherwise)
+func (q *Queries) GetNewPostsForUser(ctx context.Context, arg GetNewPostsForUserParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getNewPostsForUser,
+		arg.UserID,
+		arg.CursorAt,
+		arg.CursorPostID,
+		arg.Folder,
+		arg.Language,
+		arg.MaxMinutes,
+		arg.PostLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForFeed = `-- name: GetPostsForFeed :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, fever_id, language, reading_minutes, guid FROM posts
+WHERE feed_id = $1
+ORDER BY created_at DESC,
+         published_at DESC NULLS LAST
+LIMIT $2
+`
+
+type GetPostsForFeedParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+// a feed's newest posts (watch prints the ones a notification announced)
+func (q *Queries) GetPostsForFeed(ctx context.Context, arg GetPostsForFeedParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForFeed, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
+			&i.Guid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
 SELECT 
     p.id,
@@ -75,26 +380,126 @@ SELECT
     p.url,
     p.description,
     p.published_at,
-    p.feed_id
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
 FROM posts p
 INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
 WHERE ff.user_id = $1
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND ($2::text IS NULL OR p.language = $2) -- browse --lang
+AND ($3::int IS NULL OR p.reading_minutes <= $3) -- browse --max-minutes
 ORDER BY p.published_at DESC NULLS LAST,
          p.created_at DESC
-LIMIT $2
+LIMIT $4
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID     uuid.UUID
+	Language   sql.NullString
+	MaxMinutes sql.NullInt32
+	PostLimit  int32
 }
 
 // inner join feed_follows (omit other feeds and users)
+// inner join feeds (omit soft deleted feeds)
 // match with current user
 // order by published_at descending, NULLS LAST (as they're older)
 // THEN order by updated_ desc, to prevent random NULL selection
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Language,
+		arg.MaxMinutes,
+		arg.PostLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserInFolder = `-- name: GetPostsForUserInFolder :many
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN folders fo ON fo.id = ff.folder_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = $1
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND fo.name = $2
+AND ($3::text IS NULL OR p.language = $3) -- browse --lang
+AND ($4::int IS NULL OR p.reading_minutes <= $4) -- browse --max-minutes
+ORDER BY p.published_at DESC NULLS LAST,
+         p.created_at DESC
+LIMIT $5
+`
+
+type GetPostsForUserInFolderParams struct {
+	UserID     uuid.UUID
+	Name       string
+	Language   sql.NullString
+	MaxMinutes sql.NullInt32
+	PostLimit  int32
+}
+
+// same as GetPostsForUser, but only feeds the user put in the folder (browse --folder)
+// inner join feed_follows (omit other feeds and users)
+// inner join folders (omit follows in other folders, or none)
+// inner join feeds (omit soft deleted feeds)
+// match with current user and folder name
+func (q *Queries) GetPostsForUserInFolder(ctx context.Context, arg GetPostsForUserInFolderParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserInFolder,
+		arg.UserID,
+		arg.Name,
+		arg.Language,
+		arg.MaxMinutes,
+		arg.PostLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +516,9 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
 		); err != nil {
 			return nil, err
 		}
@@ -124,3 +532,880 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getRankCandidatesForUser = `-- name: GetRankCandidatesForUser :many
+WITH feed_stats AS (
+    SELECT
+        p.feed_id,
+        COUNT(*) AS posts,
+        COUNT(ps.read_at) AS reads,
+        COUNT(ps.saved_at) AS stars
+    FROM posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = $1
+    LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+    WHERE p.created_at > $2::timestamp
+    GROUP BY p.feed_id
+)
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes,
+    ff.weight,
+    COALESCE(fs.posts, 0)::bigint AS feed_posts,
+    COALESCE(fs.reads, 0)::bigint AS feed_reads,
+    COALESCE(fs.stars, 0)::bigint AS feed_stars,
+    (SELECT COUNT(o.read_at) FROM post_states o WHERE o.post_id = p.id AND o.user_id <> ff.user_id)::bigint AS other_reads,
+    (SELECT COUNT(o.saved_at) FROM post_states o WHERE o.post_id = p.id AND o.user_id <> ff.user_id)::bigint AS other_stars
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+LEFT JOIN feed_stats fs ON fs.feed_id = p.feed_id
+WHERE ff.user_id = $1
+AND p.created_at > $3::timestamp
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND (ps.read_at IS NOT NULL OR ps.muted_at IS NOT NULL)) -- read, or muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND ($4::text IS NULL OR fo.name = $4)
+AND ($5::text IS NULL OR p.language = $5) -- browse --lang
+AND ($6::int IS NULL OR p.reading_minutes <= $6) -- browse --max-minutes
+ORDER BY p.created_at DESC
+LIMIT $7
+`
+
+type GetRankCandidatesForUserParams struct {
+	UserID         uuid.UUID
+	StatsSince     time.Time
+	Since          time.Time
+	Folder         sql.NullString
+	Language       sql.NullString
+	MaxMinutes     sql.NullInt32
+	CandidateLimit int32
+}
+
+type GetRankCandidatesForUserRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    sql.NullString
+	PublishedAt    sql.NullTime
+	FeedID         uuid.UUID
+	FeverID        int64
+	Language       sql.NullString
+	ReadingMinutes sql.NullInt32
+	Weight         float32
+	FeedPosts      int64
+	FeedReads      int64
+	FeedStars      int64
+	OtherReads     int64
+	OtherStars     int64
+}
+
+// browse --ranked: a user's unread posts since a time, with what the ranking scores them on
+// feed_posts, feed_reads and feed_stars: the user's interest in the post's feed, over its posts since stats_since
+// other_reads and other_stars: the post's popularity with the other users
+// inner join feed_follows (omit other feeds and users)
+// inner join feeds (omit soft deleted feeds)
+// left join folders (--folder, follows without one still count otherwise)
+func (q *Queries) GetRankCandidatesForUser(ctx context.Context, arg GetRankCandidatesForUserParams) ([]GetRankCandidatesForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRankCandidatesForUser,
+		arg.UserID,
+		arg.StatsSince,
+		arg.Since,
+		arg.Folder,
+		arg.Language,
+		arg.MaxMinutes,
+		arg.CandidateLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRankCandidatesForUserRow
+	for rows.Next() {
+		var i GetRankCandidatesForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
+			&i.Weight,
+			&i.FeedPosts,
+			&i.FeedReads,
+			&i.FeedStars,
+			&i.OtherReads,
+			&i.OtherStars,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importPostStates = `-- name: ImportPostStates :execrows
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at)
+SELECT
+    $1::uuid,
+    p.id,
+    NOW(),
+    CASE WHEN s.read THEN NOW() END,
+    CASE WHEN s.saved THEN NOW() END
+FROM UNNEST(
+    $2::text[],
+    $3::bool[],
+    $4::bool[]
+) AS s(url, read, saved)
+INNER JOIN posts p ON p.url = s.url
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at)
+`
+
+type ImportPostStatesParams struct {
+	UserID uuid.UUID
+	Urls   []string
+	Reads  []bool
+	Saves  []bool
+}
+
+// read and starred states brought over from another reader (see import), by post url
+// keeps the states already set, and skips urls without a post
+func (q *Queries) ImportPostStates(ctx context.Context, arg ImportPostStatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, importPostStates,
+		arg.UserID,
+		pq.Array(arg.Urls),
+		pq.Array(arg.Reads),
+		pq.Array(arg.Saves),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertPosts = `-- name: InsertPosts :many
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, language, reading_minutes, guid)
+SELECT
+    p.id,
+    $1::timestamp,
+    $1::timestamp,
+    p.title,
+    p.url,
+    NULLIF(p.description, ''),
+    NULLIF(p.published_at, '0001-01-01 00:00:00'::timestamp),
+    $2::uuid,
+    NULLIF(p.language, ''),
+    NULLIF(p.reading_minutes, 0),
+    NULLIF(p.guid, '')
+FROM UNNEST(
+    $3::uuid[],
+    $4::text[],
+    $5::text[],
+    $6::text[],
+    $7::timestamp[],
+    $8::text[],
+    $9::int[],
+    $10::text[]
+) AS p(id, title, url, description, published_at, language, reading_minutes, guid)
+ON CONFLICT (url) DO NOTHING
+RETURNING url
+`
+
+type InsertPostsParams struct {
+	CreatedAt      time.Time
+	FeedID         uuid.UUID
+	Ids            []uuid.UUID
+	Titles         []string
+	Urls           []string
+	Descriptions   []string
+	PublishedAts   []time.Time
+	Languages      []string
+	ReadingMinutes []int32
+	Guids          []string
+}
+
+// bulk insert a fetch's posts in one round trip, the arrays are zipped into rows by UNNEST
+// ” description, zero published_at, ” language, 0 reading_minutes and ” guid mean NULL (arrays can't hold sql.Null* types)
+// skips urls already stored, and returns the urls that were new
+func (q *Queries) InsertPosts(ctx context.Context, arg InsertPostsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, insertPosts,
+		arg.CreatedAt,
+		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAts),
+		pq.Array(arg.Languages),
+		pq.Array(arg.ReadingMinutes),
+		pq.Array(arg.Guids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		items = append(items, url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemStates = `-- name: ListItemStates :many
+SELECT
+    p.url,
+    p.guid,
+    f.url AS feed_url,
+    p.title,
+    ps.read_at,
+    ps.saved_at
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE ps.user_id = $1
+AND (ps.read_at IS NOT NULL OR ps.saved_at IS NOT NULL)
+ORDER BY p.created_at, p.id
+`
+
+type ListItemStatesRow struct {
+	Url     string
+	Guid    sql.NullString
+	FeedUrl string
+	Title   string
+	ReadAt  sql.NullTime
+	SavedAt sql.NullTime
+}
+
+// a user's read and starred posts with their guid and feed, oldest first (export --states)
+func (q *Queries) ListItemStates(ctx context.Context, userID uuid.UUID) ([]ListItemStatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listItemStates, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListItemStatesRow
+	for rows.Next() {
+		var i ListItemStatesRow
+		if err := rows.Scan(
+			&i.Url,
+			&i.Guid,
+			&i.FeedUrl,
+			&i.Title,
+			&i.ReadAt,
+			&i.SavedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostStatesChangedSince = `-- name: ListPostStatesChangedSince :many
+SELECT
+    p.url,
+    f.url AS feed_url,
+    p.title,
+    p.description,
+    p.published_at,
+    ps.read_at,
+    ps.saved_at,
+    ps.muted_at,
+    ps.updated_at
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE ps.user_id = $1
+AND ps.updated_at > $2::timestamp
+ORDER BY ps.updated_at, p.url
+`
+
+type ListPostStatesChangedSinceParams struct {
+	UserID uuid.UUID
+	Since  time.Time
+}
+
+type ListPostStatesChangedSinceRow struct {
+	Url         string
+	FeedUrl     string
+	Title       string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	ReadAt      sql.NullTime
+	SavedAt     sql.NullTime
+	MutedAt     sql.NullTime
+	UpdatedAt   time.Time
+}
+
+// a user's post states changed after a time, with their post and feed, oldest first (gator sync)
+func (q *Queries) ListPostStatesChangedSince(ctx context.Context, arg ListPostStatesChangedSinceParams) ([]ListPostStatesChangedSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostStatesChangedSince, arg.UserID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostStatesChangedSinceRow
+	for rows.Next() {
+		var i ListPostStatesChangedSinceRow
+		if err := rows.Scan(
+			&i.Url,
+			&i.FeedUrl,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.ReadAt,
+			&i.SavedAt,
+			&i.MutedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsForUser = `-- name: ListPostsForUser :many
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND ($2::text IS NULL OR fo.name = $2)
+AND ($3::text IS NULL
+     OR p.title ILIKE '%' || $3 || '%'
+     OR p.description ILIKE '%' || $3 || '%')
+ORDER BY p.published_at DESC NULLS LAST,
+         p.created_at DESC,
+         p.id
+LIMIT $4
+OFFSET $5
+`
+
+type ListPostsForUserParams struct {
+	UserID     uuid.UUID
+	Folder     sql.NullString
+	Query      sql.NullString
+	PostLimit  int32
+	PostOffset int32
+}
+
+// serve's posts endpoint: a page of a user's posts, newest first
+// folder NULL means every followed feed, query NULL means no search (else title or description ILIKE)
+// inner join feed_follows (omit other feeds and users)
+// inner join feeds (omit soft deleted feeds)
+// left join folders (folder filter, follows without one still count otherwise)
+func (q *Queries) ListPostsForUser(ctx context.Context, arg ListPostsForUserParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsForUser,
+		arg.UserID,
+		arg.Folder,
+		arg.Query,
+		arg.PostLimit,
+		arg.PostOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeverID,
+			&i.Language,
+			&i.ReadingMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReadPostURLs = `-- name: ListReadPostURLs :many
+SELECT p.url
+FROM posts p
+INNER JOIN post_states ps ON ps.post_id = p.id
+WHERE ps.user_id = $1
+AND ps.read_at IS NOT NULL
+AND p.url = ANY($2::text[])
+`
+
+type ListReadPostURLsParams struct {
+	UserID uuid.UUID
+	Urls   []string
+}
+
+// which of some post urls a user has read (sync pushes them to hosted readers)
+func (q *Queries) ListReadPostURLs(ctx context.Context, arg ListReadPostURLsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listReadPostURLs, arg.UserID, pq.Array(arg.Urls))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		items = append(items, url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrendingPosts = `-- name: ListTrendingPosts :many
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.published_at,
+    p.created_at,
+    f.name AS feed_name,
+    COUNT(DISTINCT ps.user_id)::bigint AS users,
+    COUNT(ps.read_at) FILTER (WHERE ps.read_at > $1::timestamp)::bigint AS reads,
+    COUNT(ps.saved_at) FILTER (WHERE ps.saved_at > $1::timestamp)::bigint AS stars
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ps.read_at > $1::timestamp OR ps.saved_at > $1::timestamp
+GROUP BY p.id, f.name
+ORDER BY COUNT(ps.read_at) FILTER (WHERE ps.read_at > $1::timestamp) + 3 * COUNT(ps.saved_at) FILTER (WHERE ps.saved_at > $1::timestamp) DESC, p.created_at DESC
+LIMIT $2
+`
+
+type ListTrendingPostsParams struct {
+	Since     time.Time
+	PostLimit int32
+}
+
+type ListTrendingPostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	FeedName    string
+	Users       int64
+	Reads       int64
+	Stars       int64
+}
+
+// trending: the posts most read and starred across all users since a time (read = opened in a fever app)
+// inner join feeds (omit soft deleted feeds)
+// stars count three reads, like browse --ranked
+func (q *Queries) ListTrendingPosts(ctx context.Context, arg ListTrendingPostsParams) ([]ListTrendingPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTrendingPosts, arg.Since, arg.PostLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrendingPostsRow
+	for rows.Next() {
+		var i ListTrendingPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.FeedName,
+			&i.Users,
+			&i.Reads,
+			&i.Stars,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnreadPostsForUser = `-- name: ListUnreadPostsForUser :many
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.language,
+    f.name AS feed_name
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND (ps.read_at IS NOT NULL OR ps.muted_at IS NOT NULL)) -- read, or muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND ($2::text IS NULL OR fo.name = $2)
+ORDER BY f.name,
+         p.published_at NULLS LAST,
+         p.created_at
+LIMIT $3
+`
+
+type ListUnreadPostsForUserParams struct {
+	UserID    uuid.UUID
+	Folder    sql.NullString
+	PostLimit int32
+}
+
+type ListUnreadPostsForUserRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	Language    sql.NullString
+	FeedName    string
+}
+
+// digest --epub: a user's unread posts (folder NULL means every followed feed), grouped by feed, oldest first
+// inner join feed_follows (omit other feeds and users)
+// inner join feeds (omit soft deleted feeds)
+// left join folders (--folder, follows without one still count otherwise)
+func (q *Queries) ListUnreadPostsForUser(ctx context.Context, arg ListUnreadPostsForUserParams) ([]ListUnreadPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnreadPostsForUser, arg.UserID, arg.Folder, arg.PostLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnreadPostsForUserRow
+	for rows.Next() {
+		var i ListUnreadPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Language,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserDataPosts = `-- name: ListUserDataPosts :many
+SELECT
+    p.url,
+    p.title,
+    p.description,
+    p.published_at,
+    f.url AS feed_url,
+    ps.read_at,
+    ps.saved_at,
+    ps.muted_at
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = $1
+WHERE ps.user_id IS NOT NULL
+OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.post_id = p.id AND pt.user_id = $1)
+OR EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = p.id AND n.user_id = $1)
+ORDER BY p.created_at, p.id
+`
+
+type ListUserDataPostsRow struct {
+	Url         string
+	Title       string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedUrl     string
+	ReadAt      sql.NullTime
+	SavedAt     sql.NullTime
+	MutedAt     sql.NullTime
+}
+
+// the posts a user has a state, tag or note on, with their feed's url (export --all)
+func (q *Queries) ListUserDataPosts(ctx context.Context, userID uuid.UUID) ([]ListUserDataPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserDataPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserDataPostsRow
+	for rows.Next() {
+		var i ListUserDataPostsRow
+		if err := rows.Scan(
+			&i.Url,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedUrl,
+			&i.ReadAt,
+			&i.SavedAt,
+			&i.MutedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notifyNewPosts = `-- name: NotifyNewPosts :exec
+SELECT pg_notify($1::text, $2::text)
+`
+
+type NotifyNewPostsParams struct {
+	Channel string
+	Payload string
+}
+
+// tell LISTENing processes about new posts, only delivered when the transaction commits
+func (q *Queries) NotifyNewPosts(ctx context.Context, arg NotifyNewPostsParams) error {
+	_, err := q.db.ExecContext(ctx, notifyNewPosts, arg.Channel, arg.Payload)
+	return err
+}
+
+const restoreItemStates = `-- name: RestoreItemStates :execrows
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at)
+SELECT DISTINCT ON (p.id) -- one item per post, the url match if both match
+    $1::uuid,
+    p.id,
+    NOW(),
+    NULLIF(s.read_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.saved_at, '0001-01-01 00:00:00'::timestamp)
+FROM UNNEST(
+    $2::text[],
+    $3::text[],
+    $4::text[],
+    $5::timestamp[],
+    $6::timestamp[]
+) AS s(url, guid, feed_url, read_at, saved_at)
+INNER JOIN posts p ON p.url = s.url
+OR (s.guid <> '' AND p.guid = s.guid AND p.feed_id = (SELECT f.id FROM feeds f WHERE f.url = s.feed_url))
+ORDER BY p.id, p.url = s.url DESC
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at)
+`
+
+type RestoreItemStatesParams struct {
+	UserID   uuid.UUID
+	Urls     []string
+	Guids    []string
+	FeedUrls []string
+	ReadAts  []time.Time
+	SavedAts []time.Time
+}
+
+// read and starred times from a state export (see import states), by post url, else by guid within the item's feed
+// ” guids and zero times mean NULL, keeps the states already set, and skips items without a post
+func (q *Queries) RestoreItemStates(ctx context.Context, arg RestoreItemStatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreItemStates,
+		arg.UserID,
+		pq.Array(arg.Urls),
+		pq.Array(arg.Guids),
+		pq.Array(arg.FeedUrls),
+		pq.Array(arg.ReadAts),
+		pq.Array(arg.SavedAts),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restorePostStates = `-- name: RestorePostStates :execrows
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at, muted_at)
+SELECT
+    $1::uuid,
+    p.id,
+    NOW(),
+    NULLIF(s.read_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.saved_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.muted_at, '0001-01-01 00:00:00'::timestamp)
+FROM UNNEST(
+    $2::text[],
+    $3::timestamp[],
+    $4::timestamp[],
+    $5::timestamp[]
+) AS s(url, read_at, saved_at, muted_at)
+INNER JOIN posts p ON p.url = s.url
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at),
+    muted_at = COALESCE(post_states.muted_at, EXCLUDED.muted_at)
+`
+
+type RestorePostStatesParams struct {
+	UserID   uuid.UUID
+	Urls     []string
+	ReadAts  []time.Time
+	SavedAts []time.Time
+	MutedAts []time.Time
+}
+
+// read, starred and muted times brought over from a user data export (see import), by post url
+// zero times mean NULL, keeps the states already set, and skips urls without a post
+func (q *Queries) RestorePostStates(ctx context.Context, arg RestorePostStatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restorePostStates,
+		arg.UserID,
+		pq.Array(arg.Urls),
+		pq.Array(arg.ReadAts),
+		pq.Array(arg.SavedAts),
+		pq.Array(arg.MutedAts),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const syncPostStates = `-- name: SyncPostStates :execrows
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at, muted_at)
+SELECT
+    $1::uuid,
+    p.id,
+    s.updated_at,
+    NULLIF(s.read_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.saved_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.muted_at, '0001-01-01 00:00:00'::timestamp)
+FROM UNNEST(
+    $2::text[],
+    $3::timestamp[],
+    $4::timestamp[],
+    $5::timestamp[],
+    $6::timestamp[]
+) AS s(url, read_at, saved_at, muted_at, updated_at)
+INNER JOIN posts p ON p.url = s.url
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = EXCLUDED.read_at,
+    saved_at = EXCLUDED.saved_at,
+    muted_at = EXCLUDED.muted_at
+WHERE post_states.updated_at < EXCLUDED.updated_at
+`
+
+type SyncPostStatesParams struct {
+	UserID     uuid.UUID
+	Urls       []string
+	ReadAts    []time.Time
+	SavedAts   []time.Time
+	MutedAts   []time.Time
+	UpdatedAts []time.Time
+}
+
+// post states from another gator instance, by post url, the newer change wins (whole rows, unread and unstarred too)
+// zero times mean NULL, skips urls without a post, and keeps the sender's updated_at so it isn't sent back as new
+func (q *Queries) SyncPostStates(ctx context.Context, arg SyncPostStatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, syncPostStates,
+		arg.UserID,
+		pq.Array(arg.Urls),
+		pq.Array(arg.ReadAts),
+		pq.Array(arg.SavedAts),
+		pq.Array(arg.MutedAts),
+		pq.Array(arg.UpdatedAts),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
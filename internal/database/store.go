@@ -0,0 +1,18 @@
+// store.go
+package database
+
+import (
+	// std go libraries
+	"database/sql" // transactions
+)
+
+// what the app needs of the database: *Queries, or a fake in tests
+// NOTE: Querier is generated by sqlc (emit_interface), WithTx isn't part of it so it's added here
+// NOTE: transactions only run on a real connection (State.Conn), so WithTx hands back the real Queries
+type Store interface {
+	Querier
+	WithTx(tx *sql.Tx) *Queries
+}
+
+// the generated queries are the real store
+var _ Store = (*Queries)(nil)
@@ -7,11 +7,105 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE is_admin
+AND deleted_at IS NULL
+`
+
+// how many admins there are, with none every user may do admin things
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countResetRows = `-- name: CountResetRows :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM feeds) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+    (SELECT COUNT(*) FROM posts) AS posts
+`
+
+type CountResetRowsRow struct {
+	Users       int64
+	Feeds       int64
+	FeedFollows int64
+	Posts       int64
+}
+
+// count everything reset would delete (for --dry-run)
+func (q *Queries) CountResetRows(ctx context.Context) (CountResetRowsRow, error) {
+	row := q.db.QueryRowContext(ctx, countResetRows)
+	var i CountResetRowsRow
+	err := row.Scan(
+		&i.Users,
+		&i.Feeds,
+		&i.FeedFollows,
+		&i.Posts,
+	)
+	return i, err
+}
+
+const countUserDependents = `-- name: CountUserDependents :one
+SELECT
+    (SELECT COUNT(*) FROM feeds f WHERE f.user_id = u.id) AS feeds,
+    -- their follows, and other users' follows of the feeds they added (those go with the feeds)
+    (SELECT COUNT(*) FROM feed_follows ff WHERE ff.user_id = u.id OR ff.feed_id IN (SELECT f.id FROM feeds f WHERE f.user_id = u.id)) AS feed_follows,
+    (SELECT COUNT(*) FROM feed_follows ff INNER JOIN feeds f ON f.id = ff.feed_id WHERE f.user_id = u.id AND ff.user_id <> u.id) AS other_follows,
+    (SELECT COUNT(*) FROM posts p INNER JOIN feeds f ON f.id = p.feed_id WHERE f.user_id = u.id) AS posts
+FROM users u
+WHERE u.name = $1
+`
+
+type CountUserDependentsRow struct {
+	Feeds        int64
+	FeedFollows  int64
+	OtherFollows int64
+	Posts        int64
+}
+
+// count everything deleting a user cascades to (for --dry-run)
+func (q *Queries) CountUserDependents(ctx context.Context, name string) (CountUserDependentsRow, error) {
+	row := q.db.QueryRowContext(ctx, countUserDependents, name)
+	var i CountUserDependentsRow
+	err := row.Scan(
+		&i.Feeds,
+		&i.FeedFollows,
+		&i.OtherFollows,
+		&i.Posts,
+	)
+	return i, err
+}
+
+const countUsersAndFeeds = `-- name: CountUsersAndFeeds :one
+SELECT
+    (SELECT COUNT(*) FROM users WHERE deleted_at IS NULL) AS users,
+    (SELECT COUNT(*) FROM feeds WHERE deleted_at IS NULL) AS feeds
+`
+
+type CountUsersAndFeedsRow struct {
+	Users int64
+	Feeds int64
+}
+
+// how many users and feeds there are, deleted ones aside (telemetry)
+func (q *Queries) CountUsersAndFeeds(ctx context.Context) (CountUsersAndFeedsRow, error) {
+	row := q.db.QueryRowContext(ctx, countUsersAndFeeds)
+	var i CountUsersAndFeedsRow
+	err := row.Scan(&i.Users, &i.Feeds)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 
 INSERT INTO users (id, created_at, updated_at, name)
@@ -21,7 +115,7 @@ VALUES (
     $3,
     $4
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, deleted_at, browse_cursor_at, browse_cursor_post_id, is_admin
 `
 
 type CreateUserParams struct {
@@ -45,13 +139,45 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.DeletedAt,
+		&i.BrowseCursorAt,
+		&i.BrowseCursorPostID,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE name = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, name string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserPassword = `-- name: DeleteUserPassword :execrows
+DELETE FROM user_passwords
+WHERE user_id = $1
+`
+
+// remove a user's password, they log in by name again (passwd --remove)
+func (q *Queries) DeleteUserPassword(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserPassword, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, deleted_at, browse_cursor_at, browse_cursor_post_id, is_admin FROM users
 WHERE name = $1
+AND deleted_at IS NULL -- soft deleted users can't log in
 LIMIT 1
 `
 
@@ -63,12 +189,31 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.DeletedAt,
+		&i.BrowseCursorAt,
+		&i.BrowseCursorPostID,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
+const getUserPasswordHash = `-- name: GetUserPasswordHash :one
+SELECT password_hash
+FROM user_passwords
+WHERE user_id = $1
+`
+
+// a user's password hash, no rows = the user has no password
+func (q *Queries) GetUserPasswordHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserPasswordHash, userID)
+	var password_hash string
+	err := row.Scan(&password_hash)
+	return password_hash, err
+}
+
 const getUsers = `-- name: GetUsers :many
 SELECT name FROM users
+WHERE deleted_at IS NULL
 ORDER BY name
 `
 
@@ -103,3 +248,133 @@ func (q *Queries) Reset(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, reset)
 	return err
 }
+
+const setBrowseCursor = `-- name: SetBrowseCursor :exec
+UPDATE users
+SET browse_cursor_at = $2, browse_cursor_post_id = $3
+WHERE id = $1
+`
+
+type SetBrowseCursorParams struct {
+	ID                 uuid.UUID
+	BrowseCursorAt     sql.NullTime
+	BrowseCursorPostID uuid.NullUUID
+}
+
+// move the user's browse --new cursor to the newest post it showed
+func (q *Queries) SetBrowseCursor(ctx context.Context, arg SetBrowseCursorParams) error {
+	_, err := q.db.ExecContext(ctx, setBrowseCursor, arg.ID, arg.BrowseCursorAt, arg.BrowseCursorPostID)
+	return err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :execrows
+UPDATE users
+SET is_admin = $2, updated_at = NOW()
+WHERE name = $1
+AND deleted_at IS NULL
+`
+
+type SetUserAdminParams struct {
+	Name    string
+	IsAdmin bool
+}
+
+// make a user an admin or not (admin promote/demote)
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserAdmin, arg.Name, arg.IsAdmin)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+INSERT INTO user_passwords (user_id, updated_at, password_hash)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, password_hash = EXCLUDED.password_hash
+`
+
+type SetUserPasswordParams struct {
+	UserID       uuid.UUID
+	UpdatedAt    time.Time
+	PasswordHash string
+}
+
+// set or change a user's password hash (passwd)
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.UserID, arg.UpdatedAt, arg.PasswordHash)
+	return err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :one
+WITH deleted_user AS (
+    UPDATE users
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE name = $1
+    AND deleted_at IS NULL
+    RETURNING id, deleted_at
+), deleted_feeds AS (
+    UPDATE feeds f
+    SET deleted_at = du.deleted_at, updated_at = NOW()
+    FROM deleted_user du
+    WHERE f.user_id = du.id
+    AND f.deleted_at IS NULL
+    RETURNING f.id
+)
+SELECT
+    (SELECT COUNT(*) FROM deleted_user) AS users,
+    (SELECT COUNT(*) FROM deleted_feeds) AS feeds
+`
+
+type SoftDeleteUserRow struct {
+	Users int64
+	Feeds int64
+}
+
+// deleteuser: mark the user and the feeds they added as deleted (same timestamp, so undelete finds them)
+func (q *Queries) SoftDeleteUser(ctx context.Context, name string) (SoftDeleteUserRow, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteUser, name)
+	var i SoftDeleteUserRow
+	err := row.Scan(&i.Users, &i.Feeds)
+	return i, err
+}
+
+const undeleteUser = `-- name: UndeleteUser :one
+WITH restored_user AS (
+    UPDATE users u
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM users old
+    WHERE u.id = old.id
+    AND old.name = $1
+    AND old.deleted_at IS NOT NULL
+    RETURNING u.id, old.deleted_at
+), restored_feeds AS (
+    UPDATE feeds f
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM restored_user ru
+    WHERE f.user_id = ru.id
+    AND f.deleted_at = ru.deleted_at -- not the feeds removed on their own before
+    RETURNING f.id
+)
+SELECT
+    (SELECT COUNT(*) FROM restored_user) AS users,
+    (SELECT COUNT(*) FROM restored_feeds) AS feeds
+`
+
+type UndeleteUserRow struct {
+	Users int64
+	Feeds int64
+}
+
+// undelete user: restore the user, and the feeds that were deleted along with them
+func (q *Queries) UndeleteUser(ctx context.Context, name string) (UndeleteUserRow, error) {
+	row := q.db.QueryRowContext(ctx, undeleteUser, name)
+	var i UndeleteUserRow
+	err := row.Scan(&i.Users, &i.Feeds)
+	return i, err
+}
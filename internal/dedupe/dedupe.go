@@ -0,0 +1,112 @@
+// dedupe.go
+package dedupe
+
+import (
+	// std go libraries
+	"strings" // url keys and title words
+	"unicode" // splitting titles into words
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/urlnorm" // canonical urls
+)
+
+// package-wide constants
+const (
+	minTitleWords = 4   // shorter titles ("Weekly update") are too generic to call duplicates
+	minSimilarity = 0.8 // share of words two titles must have in common (jaccard), to be the same story
+)
+
+// a post to compare
+type Post struct {
+	URL   string
+	Title string
+}
+
+// group duplicate posts: the same canonical url, or near identical titles
+// returns groups of indexes into posts, each in the posts' order, ordered by their first post
+func Group(posts []Post) [][]int {
+	// the comparison keys of each post
+	urls := make([]string, len(posts))
+	titles := make([]map[string]bool, len(posts))
+	for i, post := range posts {
+		urls[i] = URLKey(post.URL)
+		titles[i] = titleWords(post.Title)
+	}
+
+	// union the duplicates (union-find, so chains of near duplicates end up together)
+	parent := make([]int, len(posts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range posts {
+		for j := i + 1; j < len(posts); j++ {
+			if (urls[i] != "" && urls[i] == urls[j]) || similar(titles[i], titles[j]) {
+				// the earlier post stays the root, so groups keep the posts' order
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+
+	// collect the groups by root, in order of first post
+	groups := [][]int{}
+	index := map[int]int{}
+	for i := range posts {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// canonical url key helper: normalized, without scheme and www., "" if it's not a url
+func URLKey(rawURL string) string {
+	normalized, err := urlnorm.Normalize(rawURL)
+	if err != nil {
+		return ""
+	}
+	_, key, _ := strings.Cut(normalized, "://")
+	return strings.TrimPrefix(key, "www.")
+}
+
+// a title's words helper, lowercase letters and digits only
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// same story by title helper, jaccard similarity of the words
+func similar(a, b map[string]bool) bool {
+	// too short check
+	if len(a) < minTitleWords || len(b) < minTitleWords {
+		return false
+	}
+
+	// count the shared words
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared)/float64(len(a)+len(b)-shared) >= minSimilarity
+}
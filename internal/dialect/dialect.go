@@ -0,0 +1,109 @@
+// dialect.go
+package dialect
+
+import (
+	// std go libraries
+	"context"      // cancelling retries
+	"database/sql" // pinging the database
+	"errors"       // unwrapping driver errors
+	"fmt"          // printing errors
+	"log/slog"     // structured logging
+	"time"         // retry backoff
+
+	// external packages
+	"github.com/lib/pq" // postgres error codes
+)
+
+// dialect type, which postgres-compatible database gator talks to (set with db_dialect)
+type Dialect string
+
+// supported dialects
+const (
+	Postgres    Dialect = "postgres"    // plain postgres, everything works (default)
+	CockroachDB Dialect = "cockroachdb" // no LISTEN/NOTIFY, transactions may need retrying
+	Neon        Dialect = "neon"        // serverless postgres, the compute may be asleep
+)
+
+// connection retry backoff, long enough for a serverless compute to wake up
+const (
+	wakeAttempts = 5
+	wakeBackoff  = time.Second // doubles every attempt (1s, 2s, 4s, 8s)
+)
+
+// parse a db_dialect value into a Dialect ("" = postgres)
+func Parse(input string) (Dialect, error) {
+	// default check
+	if input == "" {
+		return Postgres, nil
+	}
+
+	// match input to a supported dialect
+	switch Dialect(input) {
+	case Postgres, CockroachDB, Neon:
+		return Dialect(input), nil
+	}
+
+	// unsupported dialect
+	return "", fmt.Errorf("error: unknown db_dialect %q (use postgres, cockroachdb or neon)", input)
+}
+
+// does the database support LISTEN/NOTIFY (agg's new posts notifications, watch)
+func (d Dialect) SupportsNotify() bool {
+	return d != CockroachDB
+}
+
+// does the database have advisory locks (agg's lock against a second agg)
+func (d Dialect) SupportsAdvisoryLocks() bool {
+	return d != CockroachDB // accepted, but they don't lock anything
+}
+
+// can the database be asleep, so the first connection needs retrying
+func (d Dialect) Serverless() bool {
+	return d == Neon || d == CockroachDB // cockroach serverless scales to zero too
+}
+
+// is err worth retrying a whole transaction (or connection) over: the database undid it or never ran it
+// (a serialization failure, deadlock or failover), or the connection dropped (serverless computes go to sleep)
+// NOTE: a transaction cut off by a dropped connection is rolled back, a single statement may not be (see RetryDB)
+func IsRetryable(err error) bool {
+	return notApplied(err) || connectionLost(err)
+}
+
+// wake the database up, pinging with backoff until it answers (for serverless dialects)
+func Wake(ctx context.Context, db *sql.DB) error {
+	// ping until it works, or we run out of attempts
+	backoff := wakeBackoff
+	var err error
+	for attempt := 1; attempt <= wakeAttempts; attempt++ {
+		// ping the database
+		err = db.PingContext(ctx)
+
+		// ping check
+		if err == nil {
+			return nil
+		}
+
+		// answered with an error check (bad password, no such database...), retrying won't help
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && !IsRetryable(err) {
+			return err
+		}
+
+		// last attempt check
+		if attempt == wakeAttempts {
+			break
+		}
+		slog.Debug("database not answering, retrying", "attempt", attempt, "wait", backoff, "err", err)
+
+		// wait before the next attempt (or ctrl+c!)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+
+	// return the last error
+	return fmt.Errorf("error: database did not answer after %d attempts: %w", wakeAttempts, err)
+}
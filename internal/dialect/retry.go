@@ -0,0 +1,266 @@
+// retry.go
+package dialect
+
+import (
+	// std go libraries
+	"context"             // cancelling retries
+	"database/sql"        // the wrapped database
+	"database/sql/driver" // bad connection errors
+	"errors"              // unwrapping errors
+	"io"                  // dropped connections
+	"log/slog"            // structured logging
+	"net"                 // dropped connections
+	"strings"             // reading statements
+	"time"                // retry backoff
+
+	// external packages
+	"github.com/lib/pq" // postgres error codes
+)
+
+// statement retry policy, a blip rides out in under 2s (a failover that takes longer fails like before)
+const (
+	statementAttempts = 4
+	statementBackoff  = 250 * time.Millisecond // doubled after every retry
+)
+
+// a database whose statements are retried on transient errors (serialization failures, dropped connections, failovers)
+// pass it to database.New, so one blip doesn't fail a whole agg tick or api request
+// NOTE: statements in a transaction (WithTx) aren't retried, a failed one aborts the transaction, retry it whole instead
+type RetryDB struct {
+	*sql.DB
+}
+
+// run a statement that doesn't return rows, retrying it on transient errors
+func (db RetryDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := retryStatement(ctx, query, readOnly(query), func() error {
+		var err error
+		result, err = db.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// prepare a statement, retrying on transient errors (preparing never changes anything)
+func (db RetryDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	var stmt *sql.Stmt
+	err := retryStatement(ctx, query, true, func() error {
+		var err error
+		stmt, err = db.DB.PrepareContext(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+// run a statement that returns rows, retrying it on transient errors
+// NOTE: only until the rows start coming, an error while reading them is the caller's
+func (db RetryDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := retryStatement(ctx, query, readOnly(query), func() error {
+		var err error
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// run a statement that returns one row, retrying it on transient errors
+func (db RetryDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	var row *sql.Row
+	err := retryStatement(ctx, query, readOnly(query), func() error {
+		row = db.DB.QueryRowContext(ctx, query, args...)
+		return row.Err() // the statement's error, the row's own error comes from Scan
+	})
+
+	// failed check, the last attempt's row holds the error and Scan returns it
+	if err != nil {
+		slog.Debug("database statement failed", "query", queryName(query), "err", err)
+	}
+	return row
+}
+
+// run a statement until it works, fails for good, or runs out of attempts helper
+// reads = running it twice can't change anything, so it's retried even if the connection dropped while it ran
+func retryStatement(ctx context.Context, query string, reads bool, run func() error) error {
+	backoff := statementBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		// run it
+		err = run()
+
+		// retry check (ctrl+c and --timeout end it right away)
+		if err == nil || attempt == statementAttempts || ctx.Err() != nil || !retryableStatement(err, reads) {
+			return err
+		}
+		slog.Debug("retrying database statement", "query", queryName(query), "attempt", attempt, "wait", backoff, "err", err)
+
+		// wait before the next attempt (or ctrl+c!)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// is a failed statement safe to run again helper
+// errors where it never ran are always retried, a connection lost while it ran only for reads (a write may have happened)
+func retryableStatement(err error, reads bool) bool {
+	return IsRetryable(err) && (reads || notApplied(err))
+}
+
+// the statement never ran, or the database undid it whole helper
+func notApplied(err error) bool {
+	// postgres error check
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", // serialization failure (cockroach asks clients to retry those)
+			"40P01", // deadlock detected, one side is rolled back
+			"57P03", // cannot connect now, the server is starting (a failover)
+			"08001", // can't establish the connection
+			"08004": // the server rejected the connection
+			return true
+		}
+		return false
+	}
+
+	// couldn't connect check (refused while the server restarts, eg a failover)
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	// bad connection check (database/sql already retried it on fresh connections)
+	return errors.Is(err, driver.ErrBadConn)
+}
+
+// the connection dropped or the server went away while the statement ran helper
+func connectionLost(err error) bool {
+	// postgres error check
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" || // connection exceptions
+			pqErr.Code == "57P01" || // admin shutdown, eg a managed failover
+			pqErr.Code == "57P02" // crash shutdown
+	}
+
+	// network check
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || (errors.As(err, &netErr) && !netErr.Timeout())
+}
+
+// a statement that only reads helper, running it twice can't change anything
+// NOTE: by sqlc name, not by its sql: a SELECT can still write (pg_notify, FOR UPDATE, a WITH ... UPDATE)
+func readOnly(query string) bool {
+	return readQueries[queryName(query)]
+}
+
+// a query's sqlc name helper, for the log ("" for queries of our own)
+func queryName(query string) string {
+	name, found := strings.CutPrefix(strings.TrimSpace(query), "-- name: ")
+	if !found {
+		return ""
+	}
+	name, _, _ = strings.Cut(name, " ")
+	return name
+}
+
+// the sqlc queries that only read, retried even when the connection dropped while they ran
+// NOTE: a query missing here is still retried when it never ran, add new read queries to retry them after a drop too
+var readQueries = map[string]bool{
+	"CheckMuteRegex":             true,
+	"CountAdmins":                true,
+	"CountFeedDependents":        true,
+	"CountFetchJobs":             true,
+	"CountFeverItems":            true,
+	"CountNewShares":             true,
+	"CountPostSummariesSince":    true,
+	"CountPostsOlderThan":        true,
+	"CountResetRows":             true,
+	"CountUserDependents":        true,
+	"CountUsersAndFeeds":         true,
+	"DiscoverFeeds":              true,
+	"FindPostNotes":              true,
+	"FindPostsForUser":           true,
+	"GetDigestPreference":        true,
+	"GetDigestSubscription":      true,
+	"GetEnclosuresForPosts":      true,
+	"GetFeedByURL":               true,
+	"GetFeedByURLKey":            true,
+	"GetFeedFollowsForUser":      true,
+	"GetFeedOwner":               true,
+	"GetFeedSnapshotByURL":       true,
+	"GetFeedsByIDs":              true,
+	"GetFeedsToFetch":            true,
+	"GetFolderByName":            true,
+	"GetLanguagePreference":      true,
+	"GetNewPostsForUser":         true,
+	"GetPostSummaries":           true,
+	"GetPostTranslations":        true,
+	"GetPostsForFeed":            true,
+	"GetPostsForUser":            true,
+	"GetPostsForUserInFolder":    true,
+	"GetProfileShare":            true,
+	"GetPushTarget":              true,
+	"GetRankCandidatesForUser":   true,
+	"GetReadLaterAccount":        true,
+	"GetSessionUser":             true,
+	"GetSharedProfileUser":       true,
+	"GetSharedTimelineUser":      true,
+	"GetShortLinks":              true,
+	"GetSyncAccount":             true,
+	"GetTelegramChatUser":        true,
+	"GetTelegramLink":            true,
+	"GetTelegramLinkCodeUser":    true,
+	"GetTimelineShare":           true,
+	"GetUser":                    true,
+	"GetUserByFeverAPIKey":       true,
+	"GetUserPasswordHash":        true,
+	"GetUsers":                   true,
+	"ListAPITokens":              true,
+	"ListAggWorkers":             true,
+	"ListAllFeeds":               true,
+	"ListDigestPosts":            true,
+	"ListDigestPreferences":      true,
+	"ListDueDigests":             true,
+	"ListFeedURLs":               true,
+	"ListFeedWeights":            true,
+	"ListFeedsWithCreator":       true,
+	"ListFetchJobWakes":          true,
+	"ListFetchJobs":              true,
+	"ListFeverFeeds":             true,
+	"ListFeverGroups":            true,
+	"ListFeverItems":             true,
+	"ListFoldersForUser":         true,
+	"ListFollowsChangedSince":    true,
+	"ListHostBreakers":           true,
+	"ListInbox":                  true,
+	"ListItemStates":             true,
+	"ListMutes":                  true,
+	"ListNewsletterFeeds":        true,
+	"ListNotifyFeeds":            true,
+	"ListNotifyPosts":            true,
+	"ListPostNotes":              true,
+	"ListPostStatesChangedSince": true,
+	"ListPostsForUser":           true,
+	"ListPostsToSummarize":       true,
+	"ListProfileFeeds":           true,
+	"ListPushTargets":            true,
+	"ListReadLaterAccounts":      true,
+	"ListReadPostURLs":           true,
+	"ListRules":                  true,
+	"ListRulesForFeed":           true,
+	"ListSavedFeverItemIDs":      true,
+	"ListSnoozedFeeds":           true,
+	"ListSyncAccounts":           true,
+	"ListTelegramChats":          true,
+	"ListTranslateFeeds":         true,
+	"ListTrendingPosts":          true,
+	"ListUnreadFeverItemIDs":     true,
+	"ListUnreadPostsForUser":     true,
+	"ListUserDataFollows":        true,
+	"ListUserDataPosts":          true,
+	"ListUserPostTags":           true,
+}
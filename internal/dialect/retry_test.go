@@ -0,0 +1,55 @@
+// retry_test.go
+package dialect
+
+import (
+	// std go libraries
+	"database/sql/driver" // bad connection errors
+	"errors"              // plain errors
+	"io"                  // dropped connections
+	"reflect"             // the querier's methods
+	"testing"             // go tests
+
+	// external packages
+	"github.com/lib/pq" // postgres error codes
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // the sqlc queries
+)
+
+// only allowlisted sqlc reads are retried after a dropped connection, everything is retried when it never ran
+func TestRetryableStatement(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  bool
+	}{
+		{"read, connection dropped", "-- name: GetUser :one\nSELECT * FROM users", io.ErrUnexpectedEOF, true},
+		{"write, connection dropped", "-- name: MarkFeedFetched :exec\nUPDATE feeds", io.ErrUnexpectedEOF, false},
+		{"select that notifies, connection dropped", "-- name: NotifyNewPosts :exec\nSELECT pg_notify('posts', '')", io.ErrUnexpectedEOF, false},
+		{"select for update, connection dropped", "-- name: LockFeedForFetch :one\nSELECT * FROM feeds FOR UPDATE", io.ErrUnexpectedEOF, false},
+		{"query of our own, connection dropped", "SELECT pg_terminate_backend($1)", io.EOF, false},
+		{"write, server went away", "-- name: MarkFeedFetched :exec\nUPDATE feeds", &pq.Error{Code: "57P01"}, false},
+		{"write, serialization failure", "-- name: MarkFeedFetched :exec\nUPDATE feeds", &pq.Error{Code: "40001"}, true},
+		{"write, bad connection", "-- name: MarkFeedFetched :exec\nUPDATE feeds", driver.ErrBadConn, true},
+		{"read, unique violation", "-- name: GetUser :one\nSELECT * FROM users", &pq.Error{Code: "23505"}, false},
+		{"read, other error", "-- name: GetUser :one\nSELECT * FROM users", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryableStatement(tt.err, readOnly(tt.query)); got != tt.want {
+				t.Errorf("retryableStatement = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// every allowlisted read is a real sqlc query, so a renamed one isn't silently dropped
+func TestReadQueriesExist(t *testing.T) {
+	querier := reflect.TypeOf((*database.Querier)(nil)).Elem()
+	for name := range readQueries {
+		if _, ok := querier.MethodByName(name); !ok {
+			t.Errorf("read query %s isn't a sqlc query", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// digest.go
+package digest
+
+import (
+	// std go libraries
+	"bytes"         // rendering the bodies
+	"fmt"           // subject and text lines
+	"html"          // unescaping descriptions
+	"html/template" // the html body
+	"strings"       // the text body
+	"time"          // post dates
+)
+
+// how much of a post's description a digest shows
+const summaryRunes = 280
+
+// a post in a digest
+type Post struct {
+	Feed      string
+	Title     string
+	URL       string
+	Summary   string // the post's description, html is stripped
+	Abstract  string // written by the summarizer ("" = none), shown whole in place of Summary
+	Published time.Time
+}
+
+// a feed's posts in a digest
+type Group struct {
+	Feed  string
+	Posts []Post
+}
+
+// a digest ready to send
+type Digest struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// the html body, posts grouped by feed
+var page = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 40em;">
+<h1 style="font-size: 1.3em;">{{.Total}} new post{{if ne .Total 1}}s{{end}} for {{.User}}</h1>
+{{range .Groups}}<h2 style="font-size: 1.1em; border-bottom: 1px solid #ccc;">{{.Feed}}</h2>
+<ul>
+{{range .Posts}}<li style="margin-bottom: 0.8em;"><a href="{{.URL}}">{{.Title}}</a>{{if not .Published.IsZero}} <small>{{.Published.Format "Jan 2, 15:04"}}</small>{{end}}{{if .Summary}}<br><span style="color: #555;">{{.Summary}}</span>{{end}}</li>
+{{end}}</ul>
+{{end}}{{if .Unsubscribe}}<p style="color: #888; font-size: 0.8em;">You get this because you subscribed to gator digests. <a href="{{.Unsubscribe}}">Unsubscribe</a></p>
+{{end}}</body>
+</html>
+`))
+
+// build a user's digest from their new posts (already ordered by feed), "" unsubscribe leaves the link out
+func Build(user string, posts []Post, unsubscribe string) Digest {
+	// group the posts by feed, keeping their order
+	groups := []Group{}
+	for _, post := range posts {
+		if len(groups) == 0 || groups[len(groups)-1].Feed != post.Feed {
+			groups = append(groups, Group{Feed: post.Feed})
+		}
+		post.Summary = summarize(post.Summary)
+		if post.Abstract != "" {
+			post.Summary = post.Abstract
+		}
+		groups[len(groups)-1].Posts = append(groups[len(groups)-1].Posts, post)
+	}
+
+	// render the html body (the template is fixed, and writing to a buffer can't fail)
+	var body bytes.Buffer
+	_ = page.Execute(&body, struct {
+		User        string
+		Total       int
+		Groups      []Group
+		Unsubscribe string
+	}{user, len(posts), groups, unsubscribe})
+
+	// render the text body
+	var text strings.Builder
+	fmt.Fprintf(&text, "%d new post%s for %s\n", len(posts), plural(len(posts)), user)
+	for _, group := range groups {
+		fmt.Fprintf(&text, "\n== %s ==\n", group.Feed)
+		for _, post := range group.Posts {
+			fmt.Fprintf(&text, "\n* %s\n  %s\n", post.Title, post.URL)
+			if post.Abstract != "" {
+				fmt.Fprintf(&text, "  %s\n", post.Abstract)
+			}
+		}
+	}
+	if unsubscribe != "" {
+		fmt.Fprintf(&text, "\n--\nUnsubscribe: %s\n", unsubscribe)
+	}
+
+	// return the digest
+	return Digest{
+		Subject: fmt.Sprintf("%d new post%s from %d feed%s", len(posts), plural(len(posts)), len(groups), plural(len(groups))),
+		HTML:    body.String(),
+		Text:    text.String(),
+	}
+}
+
+// plural s helper
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// strip a description down to a short plain text summary helper
+func summarize(description string) string {
+	// drop the tags, descriptions are often html
+	var out strings.Builder
+	inTag := false
+	for _, r := range description {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+			out.WriteRune(' ')
+		case !inTag:
+			out.WriteRune(r)
+		}
+	}
+
+	// unescape entities, collapse whitespace, and cut it short
+	summary := []rune(strings.Join(strings.Fields(html.UnescapeString(out.String())), " "))
+	if len(summary) > summaryRunes {
+		return strings.TrimSpace(string(summary[:summaryRunes])) + "…"
+	}
+	return string(summary)
+}
@@ -0,0 +1,88 @@
+// schedule.go
+package digest
+
+import (
+	// std go libraries
+	"fmt"     // describing schedules and errors
+	"strings" // weekday names
+	"time"    // due times
+)
+
+// how often a scheduled digest goes out
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// when a user's digests go out (see digest prefs)
+type Schedule struct {
+	Cadence  string         // Daily or Weekly
+	Weekday  time.Weekday   // weekly digests' day
+	Minute   int            // the time of day, in minutes after midnight
+	Location *time.Location // the time zone Minute is in
+}
+
+// get how long a digest's cadence is, ie what the first one covers
+func (s Schedule) Period() time.Duration {
+	if s.Cadence == Weekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// get the last time a digest was due, at or before now
+func (s Schedule) Last(now time.Time) time.Time {
+	// today at the time (time.Date rolls the minutes over into hours)
+	local := now.In(s.Location)
+	due := time.Date(local.Year(), local.Month(), local.Day(), 0, s.Minute, 0, 0, s.Location)
+	days := 1
+
+	// weekly check, back to the weekday
+	if s.Cadence == Weekly {
+		due = due.AddDate(0, 0, -((int(local.Weekday()) - int(s.Weekday) + 7) % 7))
+		days = 7
+	}
+
+	// not yet check, the one before
+	if due.After(now) {
+		due = due.AddDate(0, 0, -days)
+	}
+	return due
+}
+
+// describe a schedule, like "weekly on Monday at 07:00"
+func (s Schedule) String() string {
+	at := FormatMinute(s.Minute)
+	if s.Location != nil && s.Location != time.Local {
+		at += " " + s.Location.String()
+	}
+	if s.Cadence == Weekly {
+		return fmt.Sprintf("weekly on %s at %s", s.Weekday, at)
+	}
+	return "daily at " + at
+}
+
+// parse a time of day like 07:00 into minutes after midnight
+func ParseMinute(value string) (int, error) {
+	at, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("error: invalid time %q (use 24 hour HH:MM, like 07:00)", value)
+	}
+	return at.Hour()*60 + at.Minute(), nil
+}
+
+// format minutes after midnight as a time of day like 07:00
+func FormatMinute(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// parse a weekday's name, or its first three letters (any case)
+func ParseWeekday(value string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if strings.EqualFold(value, name) || strings.EqualFold(value, name[:3]) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("error: invalid day %q (use e.g. mon or monday)", value)
+}
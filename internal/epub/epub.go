@@ -0,0 +1,246 @@
+// epub.go
+package epub
+
+import (
+	// std go libraries
+	"archive/zip"   // an epub is a zip
+	"encoding/xml"  // the xml declaration
+	"fmt"           // file names and errors
+	"html/template" // the xhtml files (escaping text and urls)
+	"io"            // writing the book
+	"strings"       // cleaning text
+	"time"          // modified date
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/extract" // article text blocks
+)
+
+// an e-book, its chapters in reading order
+type Book struct {
+	ID       string // unique id, e.g. urn:uuid:...
+	Title    string
+	Author   string
+	Language string // e.g. en
+	Modified time.Time
+	Chapters []Chapter
+}
+
+// a chapter of articles (a digest has one per feed)
+type Chapter struct {
+	Title    string
+	Articles []Article
+}
+
+// an article in a chapter
+type Article struct {
+	Title  string
+	URL    string // the original, linked under the title
+	Byline string // a line under the title, e.g. the date
+	Blocks []extract.Block
+	Notes  []Note // the reader's own, shown before the text
+}
+
+// a reader's note on an article
+type Note struct {
+	Highlight string // a quoted passage ("" = none)
+	Text      string
+}
+
+// the container file, pointing readers at the package file
+const container = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+// the package file, the book's metadata and files (epub 3, with an epub 2 toc for older readers)
+var opf = template.Must(template.New("opf").Parse(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">{{.ID}}</dc:identifier>
+<dc:title>{{.Title}}</dc:title>
+<dc:creator>{{.Author}}</dc:creator>
+<dc:language>{{.Language}}</dc:language>
+<meta property="dcterms:modified">{{.Modified.UTC.Format "2006-01-02T15:04:05Z"}}</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="style" href="style.css" media-type="text/css"/>
+{{range $i, $c := .Chapters}}<item id="chapter-{{$i}}" href="chapter-{{$i}}.xhtml" media-type="application/xhtml+xml"/>
+{{end}}</manifest>
+<spine toc="ncx">
+{{range $i, $c := .Chapters}}<itemref idref="chapter-{{$i}}"/>
+{{end}}</spine>
+</package>
+`))
+
+// the epub 3 table of contents
+var nav = template.Must(template.New("nav").Parse(`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>{{.Title}}</title></head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>{{.Title}}</h1>
+<ol>
+{{range $i, $c := .Chapters}}<li><a href="chapter-{{$i}}.xhtml">{{$c.Title}}</a><ol>
+{{range $j, $a := $c.Articles}}<li><a href="chapter-{{$i}}.xhtml#article-{{$j}}">{{$a.Title}}</a></li>
+{{end}}</ol></li>
+{{end}}</ol>
+</nav>
+</body>
+</html>
+`))
+
+// the epub 2 table of contents
+var ncx = template.Must(template.New("ncx").Funcs(template.FuncMap{"inc": func(i int) int { return i + 1 }}).Parse(`<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head><meta name="dtb:uid" content="{{.ID}}"/></head>
+<docTitle><text>{{.Title}}</text></docTitle>
+<navMap>
+{{range $i, $c := .Chapters}}<navPoint id="chapter-{{$i}}" playOrder="{{inc $i}}"><navLabel><text>{{$c.Title}}</text></navLabel><content src="chapter-{{$i}}.xhtml"/></navPoint>
+{{end}}</navMap>
+</ncx>
+`))
+
+// a chapter's page
+var chapter = template.Must(template.New("chapter").Parse(`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>{{.Title}}</title><link rel="stylesheet" type="text/css" href="style.css"/></head>
+<body>
+<h1>{{.Title}}</h1>
+{{range $j, $a := .Articles}}<section id="article-{{$j}}">
+<h2>{{$a.Title}}</h2>
+<p class="byline">{{if $a.Byline}}{{$a.Byline}} · {{end}}<a href="{{$a.URL}}">original</a></p>
+{{if $a.Notes}}<div class="notes">{{range $a.Notes}}{{if .Highlight}}<blockquote>{{.Highlight}}</blockquote>{{end}}{{if .Text}}<p>{{.Text}}</p>{{end}}{{end}}</div>
+{{end}}{{range $a.Blocks}}{{if eq .Tag "h"}}<h3>{{.Text}}</h3>{{else if eq .Tag "pre"}}<pre>{{.Text}}</pre>{{else if eq .Tag "li"}}<p class="item">• {{.Text}}</p>{{else}}<p>{{.Text}}</p>{{end}}
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// the book's style, kept plain so readers' own settings win
+const style = `h1 { font-size: 1.4em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+h3 { font-size: 1em; }
+.byline { color: #666; font-size: 0.85em; }
+.item { margin-left: 1em; }
+.notes { border-left: 3px solid #999; padding-left: 0.8em; font-style: italic; }
+pre { white-space: pre-wrap; font-size: 0.85em; }
+`
+
+// write a book as an epub
+func Write(w io.Writer, book Book) error {
+	// chapters check, readers reject an empty spine
+	if len(book.Chapters) == 0 {
+		return fmt.Errorf("error: the book has no chapters")
+	}
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	book = clean(book)
+
+	// the mimetype comes first and uncompressed, readers sniff it
+	archive := zip.NewWriter(w)
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("error writing epub: %w", err)
+	}
+	_, err = io.WriteString(file, "application/epub+zip")
+	if err != nil {
+		return fmt.Errorf("error writing epub: %w", err)
+	}
+
+	// the fixed files
+	err = writeFile(archive, "META-INF/container.xml", container)
+	if err != nil {
+		return err
+	}
+	err = writeFile(archive, "OEBPS/style.css", style)
+	if err != nil {
+		return err
+	}
+
+	// the package file and tables of contents
+	for name, page := range map[string]*template.Template{"OEBPS/content.opf": opf, "OEBPS/nav.xhtml": nav, "OEBPS/toc.ncx": ncx} {
+		err = writeTemplate(archive, name, page, book)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the chapters
+	for i, c := range book.Chapters {
+		err = writeTemplate(archive, fmt.Sprintf("OEBPS/chapter-%d.xhtml", i), chapter, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	// finish the zip
+	err = archive.Close()
+	if err != nil {
+		return fmt.Errorf("error writing epub: %w", err)
+	}
+	return nil
+}
+
+// drop the characters xml doesn't allow helper (feeds and pages carry control characters now and then)
+func clean(book Book) Book {
+	strip := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r < 0x20 && r != '\n' && r != '\t' || r == 0xFFFE || r == 0xFFFF {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	book.Title, book.Author = strip(book.Title), strip(book.Author)
+	chapters := make([]Chapter, len(book.Chapters))
+	for i, c := range book.Chapters {
+		chapters[i] = Chapter{Title: strip(c.Title), Articles: make([]Article, len(c.Articles))}
+		for j, a := range c.Articles {
+			blocks := make([]extract.Block, len(a.Blocks))
+			for k, block := range a.Blocks {
+				blocks[k] = extract.Block{Tag: block.Tag, Text: strip(block.Text)}
+			}
+			notes := make([]Note, len(a.Notes))
+			for k, note := range a.Notes {
+				notes[k] = Note{Highlight: strip(note.Highlight), Text: strip(note.Text)}
+			}
+			chapters[i].Articles[j] = Article{Title: strip(a.Title), URL: strip(a.URL), Byline: strip(a.Byline), Blocks: blocks, Notes: notes}
+		}
+	}
+	book.Chapters = chapters
+	return book
+}
+
+// write a fixed file into the epub helper
+func writeFile(archive *zip.Writer, name, content string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("error writing epub %s: %w", name, err)
+	}
+	_, err = io.WriteString(file, content)
+	if err != nil {
+		return fmt.Errorf("error writing epub %s: %w", name, err)
+	}
+	return nil
+}
+
+// render a template into the epub helper
+func writeTemplate(archive *zip.Writer, name string, page *template.Template, data any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("error writing epub %s: %w", name, err)
+	}
+	_, err = io.WriteString(file, xml.Header) // html/template would escape it
+	if err != nil {
+		return fmt.Errorf("error writing epub %s: %w", name, err)
+	}
+	err = page.Execute(file, data)
+	if err != nil {
+		return fmt.Errorf("error writing epub %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// extract.go
+package extract
+
+import (
+	// std go libraries
+	"html"    // unescaping entities
+	"regexp"  // finding tags
+	"slices"  // rel values
+	"strings" // building text
+)
+
+// an article with less text than this wasn't found (a paywall, a js-only page...)
+const minWords = 80
+
+// a block of an article's text
+type Block struct {
+	Tag  string // p, h (a heading), li or pre
+	Text string // plain text, unescaped
+}
+
+// page parts that are never the article, removed with their contents
+var junk = func() []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, tag := range []string{"script", "style", "noscript", "template", "svg", "nav", "header", "footer", "aside", "form", "figcaption", "button", "select"} {
+		res = append(res, regexp.MustCompile(`(?is)<`+tag+`\b[^>]*>.*?</`+tag+`\s*>`))
+	}
+	return res
+}()
+
+// package-wide patterns
+var (
+	comment    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	openBlock  = regexp.MustCompile(`(?i)<(p|h[1-6]|li|pre)\b[^>]*>`)
+	anyTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+	breakTag   = regexp.MustCompile(`(?i)<br\s*/?>`)
+	imgTag     = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	imgSrc     = regexp.MustCompile(`(?is)\ssrc\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	pixelSize  = regexp.MustCompile(`(?is)\s(?:width|height)\s*=\s*["']?[01](?:px)?["'\s/>]`)
+	linkTag    = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	anyAttr    = regexp.MustCompile(`(?is)\s([a-z][a-z0-9_:-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	titleTag   = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	containers = []*regexp.Regexp{
+		regexp.MustCompile(`(?is)<article\b[^>]*>(.*?)</article\s*>`),
+		regexp.MustCompile(`(?is)<main\b[^>]*>(.*?)</main\s*>`),
+		regexp.MustCompile(`(?is)<body\b[^>]*>(.*)</body\s*>`),
+	}
+)
+
+// extract an article's text from its web page, nil when there isn't enough of it
+// NOTE: the article is the biggest <article>, else <main>, else the whole <body>, with nav, footers, scripts... dropped
+func Article(page string) []Block {
+	// drop the parts that are never the article
+	page = comment.ReplaceAllString(page, "")
+	for _, re := range junk {
+		page = re.ReplaceAllString(page, "")
+	}
+
+	// find the container, the first kind that's there with enough text
+	for _, re := range containers {
+		var best []Block
+		for _, match := range re.FindAllStringSubmatch(page, -1) {
+			if blocks := Blocks(match[1]); words(blocks) > words(best) {
+				best = blocks
+			}
+		}
+		if words(best) >= minWords {
+			return best
+		}
+	}
+	return nil
+}
+
+// split html into its text blocks (a description, or an article's container)
+// NOTE: text outside of p, headings, li and pre is lost, html with none of them is one paragraph
+func Blocks(fragment string) []Block {
+	var blocks []Block
+	for _, loc := range openBlock.FindAllStringSubmatchIndex(fragment, -1) {
+		tag := strings.ToLower(fragment[loc[2]:loc[3]])
+
+		// the block ends at its closing tag, or the next block when it's left open
+		rest := fragment[loc[1]:]
+		end := closing(rest, tag)
+		if next := openBlock.FindStringIndex(rest); next != nil && (end < 0 || next[0] < end) && tag != "pre" {
+			end = next[0]
+		}
+		if end < 0 {
+			end = len(rest)
+		}
+
+		// the block's text
+		text := Text(rest[:end], tag == "pre")
+		if text == "" {
+			continue
+		}
+		if tag[0] == 'h' {
+			tag = "h"
+		}
+		blocks = append(blocks, Block{Tag: tag, Text: text})
+	}
+
+	// no blocks check, plain text or bare html
+	if len(blocks) == 0 {
+		if text := Text(fragment, false); text != "" {
+			blocks = append(blocks, Block{Tag: "p", Text: text})
+		}
+	}
+	return blocks
+}
+
+// find a tag's closing tag helper, -1 = none (</p doesn't match </pre>)
+func closing(fragment, tag string) int {
+	lower := strings.ToLower(fragment)
+	for offset := 0; ; {
+		i := strings.Index(lower[offset:], "</"+tag)
+		if i < 0 {
+			return -1
+		}
+		i += offset
+		if after := i + 2 + len(tag); after < len(lower) && strings.ContainsRune("> \t\r\n", rune(lower[after])) {
+			return i
+		}
+		offset = i + 2
+	}
+}
+
+// html to plain text helper, whitespace collapses unless it's preformatted
+func Text(fragment string, pre bool) string {
+	fragment = breakTag.ReplaceAllString(fragment, "\n")
+	fragment = anyTag.ReplaceAllString(fragment, "")
+	text := html.UnescapeString(fragment)
+	if pre {
+		return strings.Trim(text, "\n")
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// the images in html (a description), in order and without repeats
+// NOTE: tracking pixels (1x1 or 0x0 images) and data: urls are left out
+func Images(fragment string) []string {
+	var images []string
+	seen := map[string]bool{}
+	for _, tag := range imgTag.FindAllString(fragment, -1) {
+		// tracking pixel check
+		if pixelSize.MatchString(tag) {
+			continue
+		}
+
+		// the image's url
+		match := imgSrc.FindStringSubmatch(tag)
+		if match == nil {
+			continue
+		}
+		src := html.UnescapeString(strings.TrimSpace(match[1] + match[2] + match[3]))
+		if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") || seen[src] {
+			continue
+		}
+		seen[src] = true
+		images = append(images, src)
+	}
+	return images
+}
+
+// a feed a web page links to (autodiscovery)
+type FeedLink struct {
+	URL   string // as written, maybe relative to the page
+	Title string // the link's title, often "<site> RSS" ("" = none)
+}
+
+// the rss feeds a web page links to, its <link rel="alternate" type="application/rss+xml"> tags, in order and without repeats
+func FeedLinks(page string) []FeedLink {
+	var links []FeedLink
+	seen := map[string]bool{}
+	for _, tag := range linkTag.FindAllString(comment.ReplaceAllString(page, ""), -1) {
+		// feed check, rel can hold several words
+		attrs := attributes(tag)
+		rel := strings.Fields(strings.ToLower(attrs["rel"]))
+		href := attrs["href"]
+		if !slices.Contains(rel, "alternate") || !strings.HasPrefix(strings.ToLower(attrs["type"]), "application/rss+xml") || href == "" || seen[href] {
+			continue
+		}
+		seen[href] = true
+		links = append(links, FeedLink{URL: href, Title: attrs["title"]})
+	}
+	return links
+}
+
+// a web page's <title>, "" = none
+func Title(page string) string {
+	match := titleTag.FindStringSubmatch(page)
+	if match == nil {
+		return ""
+	}
+	return Text(match[1], false)
+}
+
+// get a tag's attributes helper, lower-case names to unescaped values
+func attributes(tag string) map[string]string {
+	attrs := map[string]string{}
+	for _, match := range anyAttr.FindAllStringSubmatch(tag, -1) {
+		name := strings.ToLower(match[1])
+		if _, ok := attrs[name]; !ok {
+			attrs[name] = html.UnescapeString(strings.TrimSpace(match[2] + match[3] + match[4]))
+		}
+	}
+	return attrs
+}
+
+// drop tracking pixels (1x1 or 0x0 images) from html helper, they only tell their host who read the post
+func RemoveTrackingPixels(fragment string) string {
+	return imgTag.ReplaceAllStringFunc(fragment, func(tag string) string {
+		if pixelSize.MatchString(tag) {
+			return ""
+		}
+		return tag
+	})
+}
+
+// replace the images' urls in html helper, replace returns the new url ("" keeps the old one)
+func ReplaceImages(fragment string, replace func(src string) string) string {
+	return imgTag.ReplaceAllStringFunc(fragment, func(tag string) string {
+		loc := imgSrc.FindStringSubmatchIndex(tag)
+		if loc == nil {
+			return tag
+		}
+		for group := 2; group < len(loc); group += 2 {
+			if loc[group] < 0 {
+				continue
+			}
+			next := replace(html.UnescapeString(tag[loc[group]:loc[group+1]]))
+			if next == "" {
+				return tag
+			}
+			return tag[:loc[group]] + html.EscapeString(next) + tag[loc[group+1]:]
+		}
+		return tag
+	})
+}
+
+// count blocks' words helper
+func words(blocks []Block) int {
+	n := 0
+	for _, block := range blocks {
+		n += len(strings.Fields(block.Text))
+	}
+	return n
+}
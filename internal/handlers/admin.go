@@ -0,0 +1,124 @@
+// admin.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// admin handler logic
+// NOTE: cmd will be admin promote|demote <name>, only admins may make or unmake admins
+func HandlerAdmin(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) != 2 || (cmd.Args[0] != "promote" && cmd.Args[0] != "demote") {
+		return fmt.Errorf("error: usage: admin promote <name> | admin demote <name>")
+	}
+	promote, name := cmd.Args[0] == "promote", cmd.Args[1]
+
+	// admin check
+	err := requireAdmin(ctx, s, "admin "+cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	// get the user
+	user, err := s.DB.GetUser(ctx, name)
+
+	// user exists check
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: user '%s' doesn't exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting user from db: %w", err)
+	}
+
+	// nothing to do check
+	if user.IsAdmin == promote {
+		fmt.Fprintf(s.Stdout, "User '%s' already %s.\n", name, map[bool]string{true: "is an admin", false: "isn't an admin"}[promote])
+		return nil
+	}
+
+	// last admin check, someone must be left who can promote
+	if !promote {
+		admins, err := s.DB.CountAdmins(ctx)
+		if err != nil {
+			return fmt.Errorf("error counting admins: %w", err)
+		}
+		if admins <= 1 {
+			return fmt.Errorf("error: '%s' is the last admin, promote someone else first", name)
+		}
+	}
+
+	// set the flag
+	_, err = s.DB.SetUserAdmin(ctx, database.SetUserAdminParams{Name: name, IsAdmin: promote})
+
+	// set check
+	if err != nil {
+		return fmt.Errorf("error updating user: %w", err)
+	}
+
+	// log a demoted admin's serve sessions out, so they log in again as who they are now
+	if !promote {
+		_, err = s.DB.DeleteSessionsForUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error logging '%s' out of serve: %w", name, err)
+		}
+	}
+
+	// print confirmation msg to user
+	if promote {
+		fmt.Fprintf(s.Stdout, "User '%s' is now an admin.\n", name)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "User '%s' is no longer an admin.\n", name)
+	return nil
+}
+
+// require the current user to be an admin helper, action says what needed it
+// NOTE: with no admins at all (an empty database) anyone may, so the first user can get going
+// NOTE: the current user comes from the config, which whoever runs the cli controls (config set current_user_name,
+// GATOR_CURRENT_USER), so this keeps honest users from mistakes, it's no security boundary: the cli is fully trusted,
+// like anyone holding db_url is. Only serve authenticates users
+func requireAdmin(ctx context.Context, s *app.State, action string) error {
+	// any admins check
+	admins, err := s.DB.CountAdmins(ctx)
+
+	// count check
+	if err != nil {
+		return fmt.Errorf("error counting admins: %w", err)
+	}
+	if admins == 0 {
+		return nil
+	}
+
+	// logged in check
+	if s.Config == nil || s.Config.Name == nil || *s.Config.Name == "" {
+		return fmt.Errorf("error: %s needs an admin, log in as one", action)
+	}
+
+	// get the current user
+	user, err := s.DB.GetUser(ctx, *s.Config.Name)
+
+	// get user check
+	if err != nil {
+		return fmt.Errorf("error getting user from db: %w", err)
+	}
+
+	// admin check
+	if !user.IsAdmin {
+		return fmt.Errorf("error: %s needs an admin, and '%s' isn't one (an admin can run: admin promote %s)", action, user.Name, user.Name)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// agglock.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // dedicated lock connection
+	"fmt"          // print errors
+	"hash/fnv"     // lock keys
+	"log/slog"     // structured logging
+	"time"         // waiting for a stolen lock
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app" // for State
+)
+
+// package-wide constants
+const (
+	aggLockName    = "gator_agg" // advisory lock name, per schema (see aggLockKey)
+	stealAttempts  = 10          // tries to take a stolen lock, the old session takes a moment to go
+	stealRetryWait = 500 * time.Millisecond
+)
+
+// a held agg lock, a postgres advisory lock on its own connection
+// NOTE: session locks belong to a connection, so it can't come from the shared pool
+type aggLock struct {
+	conn   *sql.Conn // nil when the dialect has no advisory locks
+	key    int64
+	shared bool // held by one of several agg --worker, they only keep a single agg out
+}
+
+// the agg lock's key helper, one per schema so instances sharing a server don't block each other
+func aggLockKey(schema string) int64 {
+	// hash the lock name with the schema
+	hash := fnv.New64a()
+	hash.Write([]byte(aggLockName + ":" + schema))
+	return int64(hash.Sum64())
+}
+
+// take the agg lock, failing fast if another agg holds it
+// takeOver terminates the holder's lock session instead, so a stuck agg can be replaced, but only if that's
+// the holder's backend pid (as the failed agg printed it), so a different (or unknown) holder is never ended blindly
+// shared takes it alongside other agg --worker instead, a single agg and workers still keep each other out
+func acquireAggLock(ctx context.Context, s *app.State, takeOver int, shared bool) (*aggLock, error) {
+	// advisory locks check (cockroachdb accepts them, but doesn't lock anything)
+	if !s.Dialect.SupportsAdvisoryLocks() {
+		slog.Warn("no advisory locks on this database, not checking for other aggs", "dialect", s.Dialect)
+		return &aggLock{}, nil
+	}
+
+	// get a dedicated connection
+	conn, err := s.Conn.Conn(ctx)
+
+	// connection check
+	if err != nil {
+		return nil, fmt.Errorf("error getting a connection for the agg lock: %w", err)
+	}
+	lock := &aggLock{conn: conn, key: aggLockKey(s.Config.SchemaName()), shared: shared}
+
+	// the lock's functions, shared or not
+	try := `SELECT pg_try_advisory_lock($1)`
+	if shared {
+		try = `SELECT pg_try_advisory_lock_shared($1)`
+	}
+
+	// try to take the lock
+	terminated := 0 // the last holder we ended, workers may hold it several times over
+	for attempt := 1; ; attempt++ {
+		// take it, without waiting
+		var acquired bool
+		err = conn.QueryRowContext(ctx, try, lock.key).Scan(&acquired)
+
+		// lock check
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error taking the agg lock: %w", err)
+		}
+
+		// acquired check
+		if acquired {
+			return lock, nil
+		}
+
+		// who holds it
+		holder, pid := lock.holder(ctx)
+
+		// take over check, fail fast with who's holding it
+		if takeOver == 0 {
+			conn.Close()
+			if shared {
+				return nil, fmt.Errorf("error: a single agg is already running (%s), stop it first or start it with --worker too", holder)
+			}
+			return nil, fmt.Errorf("error: another agg is already running (%s), stop it first, pass --take-over <pid> to take over, or start every agg with --worker to share the feeds", holder)
+		}
+
+		// give up check, the holder won't go away
+		if attempt == stealAttempts {
+			conn.Close()
+			return nil, fmt.Errorf("error: couldn't take over the agg lock from %s", holder)
+		}
+
+		// confirmed holder check, only the pid the user named is ended (unknown = it may have just let go, once we ended it)
+		if pid == 0 && terminated == 0 {
+			conn.Close()
+			return nil, fmt.Errorf("error: can't tell which session holds the agg lock (pg_stat_activity restricted?), not taking it over")
+		}
+		if pid != 0 && pid != terminated && pid != takeOver {
+			conn.Close()
+			return nil, fmt.Errorf("error: the agg lock is held by %s, not pid %d, check who that is before taking over", holder, takeOver)
+		}
+
+		// terminate the holder's session (once), it notices and stops on its next tick
+		if pid != 0 && pid != terminated {
+			terminated = pid
+			slog.Warn("taking over the agg lock", "from", holder)
+			_, err = conn.ExecContext(ctx, `SELECT pg_terminate_backend($1)`, pid)
+
+			// terminate check (needs the same role, or pg_signal_backend)
+			if err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("error taking over the agg lock from %s: %w", holder, err)
+			}
+		}
+
+		// wait for the session to go (or ctrl+c!)
+		select {
+		case <-time.After(stealRetryWait):
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// describe who holds the agg lock helper, and their backend pid (0 if unknown)
+func (l *aggLock) holder(ctx context.Context) (string, int) {
+	// find the lock's session (bigint keys are split into classid and objid, objsubid 1)
+	var pid int
+	var clientAddr sql.NullString
+	var backendStart time.Time
+	err := l.conn.QueryRowContext(ctx, `
+		SELECT l.pid, host(a.client_addr), a.backend_start
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+		AND l.classid = (($1::bigint >> 32) & 4294967295)::oid
+		AND l.objid = ($1::bigint & 4294967295)::oid`, l.key).Scan(&pid, &clientAddr, &backendStart)
+
+	// holder check (it may have just let go, or pg_stat_activity is restricted)
+	if err != nil {
+		return "holder unknown", 0
+	}
+
+	// describe them
+	from := "local socket"
+	if clientAddr.Valid {
+		from = clientAddr.String
+	}
+	return fmt.Sprintf("postgres pid %d from %s, running since %s", pid, from, backendStart.Local().Format(time.DateTime)), pid
+}
+
+// still holding the lock check, the session dies if another agg took over with --take-over
+func (l *aggLock) Held(ctx context.Context) error {
+	// no lock check
+	if l.conn == nil {
+		return nil
+	}
+
+	// ping the lock's session
+	err := l.conn.PingContext(ctx)
+
+	// lost check
+	if err != nil {
+		return fmt.Errorf("error: lost the agg lock (another agg took over with --take-over?): %w", err)
+	}
+	return nil
+}
+
+// let go of the lock
+func (l *aggLock) Release() {
+	// no lock check
+	if l.conn == nil {
+		return
+	}
+
+	// unlock (closing the connection would too, but it goes back to the pool)
+	unlock := `SELECT pg_advisory_unlock($1)`
+	if l.shared {
+		unlock = `SELECT pg_advisory_unlock_shared($1)`
+	}
+	_, err := l.conn.ExecContext(context.Background(), unlock, l.key)
+	if err != nil {
+		slog.Debug("error releasing the agg lock", "err", err)
+	}
+	l.conn.Close()
+}
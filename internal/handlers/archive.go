@@ -0,0 +1,89 @@
+// archive.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"regexp"  // search patterns
+	"time"    // printing dates
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"     // for State and Command
+	"github.com/PietPadda/aggregator/internal/archive" // pruned post archives
+	"github.com/PietPadda/aggregator/internal/output"  // for --output formats
+)
+
+// archive handler logic
+// NOTE: cmd will be archive search <pattern>, and state holds the config with the archive dir
+// greps the archives prune --archive wrote, no db needed!
+func HandlerArchive(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) < 2 || cmd.Args[0] != "search" {
+		return fmt.Errorf("error: usage: archive search <pattern>")
+	} // archive handler expects TWO args: search and the pattern!
+
+	// compile the pattern, case insensitive like grep -i
+	re, err := regexp.Compile("(?i)" + cmd.Args[1])
+
+	// pattern check
+	if err != nil {
+		return fmt.Errorf("error: invalid search pattern: %w", err)
+	}
+
+	// get the archive dir
+	dir, err := s.Config.ArchivePath()
+
+	// archive dir check
+	if err != nil {
+		return err
+	}
+
+	// search the archives
+	matches, err := archive.Search(dir, re)
+
+	// search check
+	if err != nil {
+		return err
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"title", "url", "published_at", "feed_name", "feed_url", "archive"}}
+		for _, match := range matches {
+			table.Add(match.Post.Title, match.Post.URL, archivedDate(match.Post), match.Post.FeedName, match.Post.FeedURL, match.File)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no matches check
+	if len(matches) == 0 {
+		fmt.Fprintf(s.Stdout, "No archived posts match %q in %s\n", cmd.Args[1], dir)
+		return nil
+	}
+
+	// print the matches
+	for _, match := range matches {
+		fmt.Fprintf(s.Stdout, "%s  %s (%s)\n", archivedDate(match.Post).Format(time.DateOnly), match.Post.Title, match.Post.FeedName)
+		fmt.Fprintf(s.Stdout, "  %s\n", match.Post.URL)
+	}
+	fmt.Fprintf(s.Stdout, "%d archived posts found\n", len(matches))
+
+	// return success
+	return nil
+}
+
+// archived post date helper, the pubdate or when it was stored
+// NOTE: same fallback prune uses to decide a post's age
+func archivedDate(post archive.Post) time.Time {
+	// pubdate check
+	if post.PublishedAt != nil {
+		return *post.PublishedAt
+	}
+	return post.CreatedAt
+}
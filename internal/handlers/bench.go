@@ -0,0 +1,391 @@
+// bench.go
+package handlers
+
+import (
+	// std go libs
+	"bytes"         // snapshot bodies
+	"compress/gzip" // snapshot bodies
+	"context"       // for context
+	"database/sql"  // no snapshot
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // print errors
+	"io"            // reading snapshots
+	"log/slog"      // structured logging
+	"runtime"       // allocation stats
+	"slices"        // picking feeds
+	"strconv"       // parsing --rounds
+	"sync"          // parallel fetches
+	"time"          // timings
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+	"github.com/PietPadda/aggregator/internal/progress" // fetch progress
+	"github.com/PietPadda/aggregator/internal/rssfeed"  // raw feeds
+)
+
+// a feed's raw body, fetched or replayed, ready to parse
+type benchFeed struct {
+	feed database.Feed
+	raw  *rssfeed.Raw
+}
+
+// a parsed feed, ready to write
+type benchParsed struct {
+	feed   database.Feed
+	parsed *parsedFeed
+}
+
+// one bench phase's totals
+type benchPhase struct {
+	name       string
+	feeds      int
+	items      int   // posts parsed or written
+	bytes      int   // feed bodies read
+	failed     int   // feeds that failed the phase (left out of the later ones)
+	allocs     int64 // heap allocations (parse only)
+	allocBytes int64 // heap bytes allocated (parse only)
+	elapsed    time.Duration
+}
+
+// the phase's rate per second helper
+func (p benchPhase) perSecond(n int) float64 {
+	// too quick to time check
+	if p.elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / p.elapsed.Seconds()
+}
+
+// bench handler logic
+// NOTE: cmd will be bench [<feed_url>...] [--snapshots] [--rounds <n>] [--no-store]
+// fetches the feeds (or replays their snapshots), then times parsing and writing them like agg does
+// nothing is kept: the post writes are rolled back, and parsing never stores a snapshot
+func HandlerBench(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get the flags
+	args, snapshots := popFlag(cmd.Args, "--snapshots")
+	args, noStore := popFlag(args, "--no-store")
+	args, roundsInput, err := popFlagValue(args, "--rounds")
+	if err != nil {
+		return err
+	}
+
+	// rounds check (parsing the same bodies again evens out the timings)
+	rounds := 1
+	if roundsInput != "" {
+		rounds, err = strconv.Atoi(roundsInput)
+		if err != nil || rounds < 1 {
+			return fmt.Errorf("error: --rounds must be a whole number of at least 1")
+		}
+	}
+
+	// get the feeds, the given ones or every feed
+	feeds, err := benchFeeds(ctx, s, args)
+	if err != nil {
+		return err
+	}
+
+	// nothing to bench check
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "No feeds to bench, add some with addfeed (or seed).")
+		return nil
+	}
+
+	// fetch or replay the bodies
+	var bodies []benchFeed
+	var fetch benchPhase
+	if snapshots {
+		bodies, fetch, err = benchReplay(ctx, s, feeds)
+		if err != nil {
+			return err
+		}
+	} else {
+		bodies, fetch = benchFetch(ctx, s, feeds)
+	}
+
+	// interrupted check
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// parse them
+	parsed, parse := benchParse(ctx, s, bodies, rounds)
+	phases := []benchPhase{fetch, parse}
+
+	// write them
+	if !noStore {
+		store, err := benchStore(ctx, s, parsed)
+		if err != nil {
+			return err
+		}
+		phases = append(phases, store)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"phase", "feeds", "items", "bytes", "failed", "seconds", "feeds_per_sec", "items_per_sec", "mb_per_sec", "allocs", "alloc_bytes"}}
+		for _, phase := range phases {
+			table.Add(phase.name, phase.feeds, phase.items, phase.bytes, phase.failed, phase.elapsed.Seconds(), phase.perSecond(phase.feeds), phase.perSecond(phase.items), phase.perSecond(phase.bytes)/(1<<20), phase.allocs, phase.allocBytes)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// print the results, one phase after the other
+	for _, phase := range phases {
+		fmt.Fprintf(s.Stdout, "%-8s %d feeds in %s", phase.name+":", phase.feeds, phase.elapsed.Round(time.Millisecond))
+		if phase.failed > 0 {
+			fmt.Fprintf(s.Stdout, " (%d failed)", phase.failed)
+		}
+		fmt.Fprintf(s.Stdout, ", %.1f feeds/s", phase.perSecond(phase.feeds))
+		if phase.bytes > 0 {
+			fmt.Fprintf(s.Stdout, ", %.2f MB/s", phase.perSecond(phase.bytes)/(1<<20))
+		}
+		if phase.items > 0 {
+			fmt.Fprintf(s.Stdout, ", %d posts, %.0f posts/s", phase.items, phase.perSecond(phase.items))
+		}
+		fmt.Fprintln(s.Stdout)
+
+		// allocations check (parse only)
+		if phase.allocs > 0 && phase.items > 0 {
+			fmt.Fprintf(s.Stdout, "%-8s %d allocs (%d per post), %.1f MB allocated (%.1f KB per post)\n", "", phase.allocs, phase.allocs/int64(phase.items), float64(phase.allocBytes)/(1<<20), float64(phase.allocBytes)/1024/float64(phase.items))
+		}
+	}
+	if rounds > 1 {
+		fmt.Fprintf(s.Stdout, "Parsed every feed %d times.\n", rounds)
+	}
+	if !noStore {
+		fmt.Fprintln(s.Stdout, "The writes were rolled back, nothing was stored.")
+	}
+	return nil
+}
+
+// the feeds to bench helper, the given urls (stored feeds only, the writes need their ids) or every feed
+func benchFeeds(ctx context.Context, s *app.State, urls []string) ([]database.Feed, error) {
+	// get them all
+	all, err := s.DB.ListAllFeeds(ctx)
+
+	// list check
+	if err != nil {
+		return nil, fmt.Errorf("error getting feeds: %w", err)
+	}
+
+	// keep the live ones that were asked for
+	var feeds []database.Feed
+	for _, feed := range all {
+		if feed.DeletedAt.Valid || (len(urls) > 0 && !slices.Contains(urls, feed.Url)) {
+			continue
+		}
+		feeds = append(feeds, feed)
+	}
+
+	// unknown url check
+	for _, url := range urls {
+		found := slices.ContainsFunc(feeds, func(feed database.Feed) bool { return feed.Url == url })
+		if !found {
+			return nil, fmt.Errorf("error: %s is not a stored feed (add it with addfeed first)", url)
+		}
+	}
+	return feeds, nil
+}
+
+// fetch phase helper, gets the feeds' bodies over the network (fetch_concurrency at a time, like agg)
+// NOTE: the timing is wall time, so it says as much about the feed hosts as about gator
+func benchFetch(ctx context.Context, s *app.State, feeds []database.Feed) ([]benchFeed, benchPhase) {
+	phase := benchPhase{name: "fetch"}
+	raws := make([]*rssfeed.Raw, len(feeds))
+	bar := progress.New(s.Stderr, len(feeds), "feeds")
+
+	// workers take feeds off the queue
+	started := time.Now()
+	queue := make(chan int)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range max(s.Config.FetchConcurrency(), 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				raw, err := httpClient(s).FetchRaw(ctx, feeds[i].Url)
+				if err != nil {
+					slog.Warn("error fetching feed, left out", "feed", feeds[i].Name, "err", err)
+				}
+				raws[i] = raw
+				done <- struct{}{}
+			}
+		}()
+	}
+
+	// queue every feed, then wait for the workers
+	go func() {
+		defer close(queue)
+		for i := range feeds {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for range done {
+		bar.Step()
+	}
+	bar.Done()
+	phase.elapsed = time.Since(started)
+
+	// keep the fetched ones
+	var bodies []benchFeed
+	for i, raw := range raws {
+		if raw == nil {
+			phase.failed++
+			continue
+		}
+		bodies = append(bodies, benchFeed{feed: feeds[i], raw: raw})
+		phase.feeds++
+		phase.bytes += len(raw.Body)
+	}
+	return bodies, phase
+}
+
+// replay phase helper, reads the feeds' last stored snapshots instead of fetching them
+// NOTE: the same bodies every run, so only gator's timings change (agg stores them with snapshot_feeds on)
+func benchReplay(ctx context.Context, s *app.State, feeds []database.Feed) ([]benchFeed, benchPhase, error) {
+	phase := benchPhase{name: "replay"}
+	started := time.Now()
+	var bodies []benchFeed
+	for _, feed := range feeds {
+		// get the snapshot
+		snapshot, err := s.DB.GetFeedSnapshotByURL(ctx, feed.Url)
+
+		// no snapshot check
+		if errors.Is(err, sql.ErrNoRows) {
+			phase.failed++
+			continue
+		}
+
+		// get check
+		if err != nil {
+			return nil, phase, fmt.Errorf("error getting snapshot of %s: %w", feed.Url, err)
+		}
+
+		// decompress the body
+		zr, err := gzip.NewReader(bytes.NewReader(snapshot.Body))
+		if err != nil {
+			return nil, phase, fmt.Errorf("error reading snapshot of %s: %w", feed.Url, err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, phase, fmt.Errorf("error reading snapshot of %s: %w", feed.Url, err)
+		}
+		bodies = append(bodies, benchFeed{feed: feed, raw: &rssfeed.Raw{Body: body, ContentType: snapshot.ContentType, StatusCode: 200}})
+		phase.feeds++
+		phase.bytes += len(body)
+	}
+	phase.elapsed = time.Since(started)
+
+	// no snapshots at all check
+	if len(bodies) == 0 {
+		return nil, phase, fmt.Errorf("error: no snapshots to replay (set \"snapshot_feeds\": true in ~/.gatorconfig.json and run agg)")
+	}
+	return bodies, phase, nil
+}
+
+// parse phase helper, runs agg's parse stage over the bodies, rounds times, one feed at a time
+// one at a time so the timings and allocations are gator's own, not the scheduler's
+func benchParse(ctx context.Context, s *app.State, bodies []benchFeed, rounds int) ([]benchParsed, benchPhase) {
+	phase := benchPhase{name: "parse"}
+
+	// a state that never stores snapshots, parsing mustn't write
+	cfg := *s.Config
+	cfg.SnapshotFeeds = false
+	parseState := *s
+	parseState.Config = &cfg
+
+	// start from a clean heap, so the stats are the parse's
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	started := time.Now()
+
+	// parse every body, every round (the last round's posts are the ones written)
+	var parsed []benchParsed
+	failed := map[int]bool{}
+	for round := range rounds {
+		parsed = parsed[:0]
+		for i, body := range bodies {
+			feed, err := parseFeed(ctx, &parseState, body.feed, body.raw)
+			phase.feeds++
+			phase.bytes += len(body.raw.Body)
+
+			// parse check, left out of the writes
+			if err != nil {
+				if round == 0 {
+					slog.Warn("error parsing feed, left out", "feed", body.feed.Name, "err", err)
+				}
+				failed[i] = true
+				continue
+			}
+			phase.items += len(feed.posts.Ids)
+			parsed = append(parsed, benchParsed{feed: body.feed, parsed: feed})
+		}
+	}
+	phase.elapsed = time.Since(started)
+	runtime.ReadMemStats(&after)
+	phase.failed = len(failed)
+	phase.allocs = int64(after.Mallocs - before.Mallocs)
+	phase.allocBytes = int64(after.TotalAlloc - before.TotalAlloc)
+	return parsed, phase
+}
+
+// write phase helper, inserts the parsed posts and enclosures like agg's store stage, one transaction per feed
+// every transaction is rolled back, so nothing is kept (the unique urls are still checked, so rerunning is fair)
+// NOTE: agg also runs the rules and marks the feed fetched, those depend on the followers, not the feed
+func benchStore(ctx context.Context, s *app.State, parsed []benchParsed) (benchPhase, error) {
+	phase := benchPhase{name: "store"}
+	started := time.Now()
+	for _, feed := range parsed {
+		// begin the transaction
+		tx, err := s.Conn.BeginTx(ctx, nil)
+
+		// begin check
+		if err != nil {
+			return phase, fmt.Errorf("error beginning transaction: %w", err)
+		}
+		queries := s.DB.WithTx(tx)
+
+		// write the posts, then their enclosures
+		posts, enclosures := feed.parsed.posts, feed.parsed.enclosures
+		if len(posts.Ids) > 0 {
+			_, err = queries.InsertPosts(ctx, posts)
+		}
+		if err == nil && len(enclosures.Ids) > 0 {
+			err = queries.InsertEnclosures(ctx, enclosures)
+		}
+
+		// undo it all
+		tx.Rollback()
+
+		// write check (a feed the database won't take is left out, like a failed fetch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return phase, ctx.Err()
+			}
+			slog.Warn("error writing feed, left out", "feed", feed.feed.Name, "err", err)
+			phase.failed++
+			continue
+		}
+		phase.feeds++
+		phase.items += len(posts.Ids)
+	}
+	phase.elapsed = time.Since(started)
+	return phase, nil
+}
@@ -0,0 +1,155 @@
+// breaker.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // nullable breaker columns
+	"errors"       // matching timeouts
+	"fmt"          // print errors
+	"log/slog"     // structured logging
+	"net"          // timeout errors
+	"net/url"      // feed hosts
+	"strings"      // lowercasing hosts
+	"sync"         // fetch workers record in parallel
+	"time"         // cooldowns
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// host circuit breaker settings
+const hostBreakerThreshold = 3               // timeouts in a row that open a host's circuit
+const hostBreakerCooldown = 10 * time.Minute // how long an open circuit keeps the host's feeds from being fetched
+
+// a fetch skipped as its host's circuit is open, its job waits until it closes
+type hostOpenError struct {
+	host  string
+	until time.Time
+}
+
+func (e hostOpenError) Error() string {
+	return fmt.Sprintf("%s timed out %d times in a row, its feeds are skipped until %s", e.host, hostBreakerThreshold, e.until.Local().Format(time.DateTime))
+}
+
+// agg's view of the host circuit breakers, reloaded every tick (other aggs share them) and kept in step as fetches finish
+type hostBreakers struct {
+	mu    sync.Mutex
+	hosts map[string]database.HostBreaker // hosts with timeouts in a row, their circuit open or not
+}
+
+// create empty host breakers
+func newHostBreakers() *hostBreakers {
+	return &hostBreakers{hosts: map[string]database.HostBreaker{}}
+}
+
+// reload the breakers from the database, keeping the old ones if that fails (a fetch is still better than none)
+func (b *hostBreakers) reload(ctx context.Context, s *app.State) {
+	// get them
+	rows, err := s.DB.ListHostBreakers(ctx)
+
+	// list check
+	if err != nil {
+		slog.Warn("error getting host circuit breakers", "err", err)
+		return
+	}
+	hosts := make(map[string]database.HostBreaker, len(rows))
+	for _, row := range rows {
+		hosts[row.Host] = row
+	}
+	b.mu.Lock()
+	b.hosts = hosts
+	b.mu.Unlock()
+}
+
+// check a host's circuit before fetching from it, a hostOpenError if it's open
+// NOTE: once the cooldown is up, fetches go through again (half open), the first timeout opens it right back
+func (b *hostBreakers) check(host string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker, ok := b.hosts[host]
+	if ok && breaker.OpenUntil.Valid && now.UTC().Before(breaker.OpenUntil.Time) {
+		return hostOpenError{host: host, until: breaker.OpenUntil.Time}
+	}
+	return nil
+}
+
+// record how a fetch from a host went: a timeout counts towards opening its circuit, any answer closes it
+// NOTE: only database trouble is logged, the fetch's own error is logged by agg
+func (b *hostBreakers) record(ctx context.Context, s *app.State, host string, fetchErr error) {
+	// no host check (a url that doesn't parse never got a connection either)
+	if host == "" {
+		return
+	}
+
+	// answered check, forget the host's timeouts (if it had any, most hosts never time out)
+	if !isTimeout(fetchErr) {
+		b.mu.Lock()
+		_, had := b.hosts[host]
+		delete(b.hosts, host)
+		b.mu.Unlock()
+		if !had {
+			return
+		}
+		err := s.DB.CloseHostBreaker(ctx, host)
+		if err != nil {
+			slog.Warn("error closing host circuit", "host", host, "err", err)
+			return
+		}
+		slog.Info("host answers again, circuit closed", "host", host)
+		return
+	}
+
+	// timed out, count it (the database decides if that opens the circuit, other aggs count too)
+	now := time.Now().UTC()
+	breaker, err := s.DB.RecordHostTimeout(ctx, database.RecordHostTimeoutParams{
+		Host:      host,
+		LastError: sql.NullString{String: fetchErr.Error(), Valid: true},
+		Now:       now,
+		Threshold: hostBreakerThreshold,
+		OpenUntil: now.Add(hostBreakerCooldown),
+	})
+	if err != nil {
+		slog.Warn("error recording host timeout", "host", host, "err", err)
+		return
+	}
+
+	// opened check
+	b.mu.Lock()
+	old := b.hosts[host]
+	b.hosts[host] = breaker
+	b.mu.Unlock()
+	if breaker.OpenUntil.Valid && breaker.OpenUntil != old.OpenUntil {
+		slog.Warn("host keeps timing out, circuit opened", "host", host, "timeouts", breaker.ConsecutiveTimeouts, "until", breaker.OpenUntil.Time.Local())
+	}
+}
+
+// how many hosts have an open circuit right now (agg status)
+func (b *hostBreakers) openCount(now time.Time) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	open := 0
+	for _, breaker := range b.hosts {
+		if breaker.OpenUntil.Valid && now.UTC().Before(breaker.OpenUntil.Time) {
+			open++
+		}
+	}
+	return open
+}
+
+// a feed url's host helper, lowercased and without the port ("" if it doesn't parse)
+func feedHost(feedURL string) string {
+	parsed, err := url.Parse(feedURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// a timed out request check helper (the client's timeout, a dial or tls handshake timeout...)
+// NOTE: ctrl+c and --timeout cancel ctx, they never get here (see the fetch stage)
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,140 @@
+// collapse.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"strings" // csv cells
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State
+	"github.com/PietPadda/aggregator/internal/cluster"  // grouping related stories
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/dedupe"   // finding duplicates
+	"github.com/google/uuid"                            // feed ids
+)
+
+// a feed a collapsed story appeared in, for browse
+type postSource struct {
+	Feed string `json:"feed"`
+	URL  string `json:"url"`
+}
+
+// the feeds a collapsed story appeared in (empty if only in one)
+type sourceList []postSource
+
+// csv/tsv cell form, the urls separated by spaces
+func (l sourceList) String() string {
+	urls := make([]string, len(l))
+	for i, source := range l {
+		urls[i] = source.URL
+	}
+	return strings.Join(urls, " ")
+}
+
+// a post related to a clustered story, for browse --clustered
+type relatedPost struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// the other posts covering a clustered story (empty if none)
+type relatedList []relatedPost
+
+// csv/tsv cell form, the urls separated by spaces
+func (l relatedList) String() string {
+	urls := make([]string, len(l))
+	for i, related := range l {
+		urls[i] = related.URL
+	}
+	return strings.Join(urls, " ")
+}
+
+// a post browse shows, with the duplicates collapsed into it
+type browseEntry struct {
+	Post    database.Post
+	Sources sourceList
+	Related relatedList // only filled by browse --clustered
+}
+
+// collapse the same story from several feeds helper, for browse
+// the first post of each group stands for it (browse's order), listing every feed it came from
+// collapse false keeps every post as its own entry
+func collapsePosts(ctx context.Context, s *app.State, posts []database.Post, collapse bool) ([]browseEntry, error) {
+	// no collapsing check
+	entries := []browseEntry{}
+	if !collapse {
+		for _, post := range posts {
+			entries = append(entries, browseEntry{Post: post, Sources: sourceList{}, Related: relatedList{}})
+		}
+		return entries, nil
+	}
+
+	// find the duplicates
+	items := make([]dedupe.Post, len(posts))
+	for i, post := range posts {
+		items[i] = dedupe.Post{URL: post.Url, Title: post.Title}
+	}
+	groups := dedupe.Group(items)
+
+	// get the names of the feeds in groups, only those need them
+	var feedIDs []uuid.UUID
+	for _, group := range groups {
+		if len(group) > 1 {
+			for _, i := range group {
+				feedIDs = append(feedIDs, posts[i].FeedID)
+			}
+		}
+	}
+	feedNames := map[uuid.UUID]string{}
+	if len(feedIDs) > 0 {
+		feeds, err := s.DB.GetFeedsByIDs(ctx, feedIDs)
+
+		// get feeds check
+		if err != nil {
+			return nil, fmt.Errorf("error getting feeds: %w", err)
+		}
+		for _, feed := range feeds {
+			feedNames[feed.ID] = feed.Name
+		}
+	}
+
+	// one entry per group
+	for _, group := range groups {
+		entry := browseEntry{Post: posts[group[0]], Sources: sourceList{}, Related: relatedList{}}
+		if len(group) > 1 {
+			for _, i := range group {
+				entry.Sources = append(entry.Sources, postSource{Feed: feedNames[posts[i].FeedID], URL: posts[i].Url})
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// cluster posts covering the same story helper, for browse --clustered
+// looser than collapsing: different articles with similar titles published close together
+// the first entry of each cluster leads it (browse's order), the rest become its related posts
+func clusterEntries(entries []browseEntry) []browseEntry {
+	// when each post came out (published date if the feed gave one, else when we found it)
+	items := make([]cluster.Post, len(entries))
+	for i, entry := range entries {
+		postTime := entry.Post.CreatedAt
+		if entry.Post.PublishedAt.Valid {
+			postTime = entry.Post.PublishedAt.Time
+		}
+		items[i] = cluster.Post{Title: entry.Post.Title, Time: postTime}
+	}
+
+	// one entry per cluster
+	clustered := []browseEntry{}
+	for _, group := range cluster.Group(items) {
+		lead := entries[group[0]]
+		for _, i := range group[1:] {
+			lead.Related = append(lead.Related, relatedPost{Title: entries[i].Post.Title, URL: entries[i].Post.Url})
+		}
+		clustered = append(clustered, lead)
+	}
+	return clustered
+}
@@ -0,0 +1,251 @@
+// config.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"net/url" // masking passwords
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"     // for State and Command
+	"github.com/PietPadda/aggregator/internal/config"  // which keys are secret
+	"github.com/PietPadda/aggregator/internal/keyring" // storing db passwords
+	"github.com/PietPadda/aggregator/internal/output"  // for --output formats
+)
+
+// config handler logic
+// NOTE: cmd will be config get <key> [--reveal] | config set <key> <value> | config unset <key> | config list | config validate | config path | config set-password <alias>
+// values are validated before they're saved, so a typo can't break the config file
+// works without a database (main skips it for config), so db_url can be set on a fresh install
+func HandlerConfig(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("error: usage: config get <key> [--reveal] | config set <key> <value> | config unset <key> | config list | config validate | config path | config set-password <alias>")
+	} // config handler expects a subcommand, and its args!
+
+	// run the subcommand
+	switch cmd.Args[0] {
+	case "get":
+		// strip the optional reveal flag from the args (prints passwords and tokens as they are, eg for scripts)
+		args, reveal := popFlag(cmd.Args, "--reveal")
+
+		// key input check
+		if len(args) < 2 {
+			return fmt.Errorf("error: config key required")
+		}
+
+		// get the value
+		value, set, err := s.Config.Get(args[1])
+
+		// get check
+		if err != nil {
+			return err
+		}
+
+		// not set check (nothing on stdout, so scripts get "")
+		if !set {
+			fmt.Fprintf(s.Stderr, "%s is not set\n", args[1])
+			return nil
+		}
+
+		// secret check, masked unless revealed
+		if config.IsSecret(args[1]) && !reveal {
+			value = maskSecret(args[1], value)
+		}
+		fmt.Fprintln(s.Stdout, value)
+		return nil
+	case "set":
+		// key and value input check
+		if len(cmd.Args) < 3 {
+			return fmt.Errorf("error: config key and value required")
+		}
+
+		// validate and save
+		err := s.Config.Set(cmd.Args[1], cmd.Args[2])
+
+		// set check
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "%s saved\n", cmd.Args[1])
+		return nil
+	case "unset":
+		// key input check
+		if len(cmd.Args) < 2 {
+			return fmt.Errorf("error: config key required")
+		}
+
+		// "" removes it from the file (db_url is required, so its validation refuses)
+		err := s.Config.Set(cmd.Args[1], "")
+
+		// unset check
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "%s removed\n", cmd.Args[1])
+		return nil
+	case "list":
+		return listConfig(s)
+	case "validate":
+		return validateConfig(s)
+	case "path":
+		// get the file in use (--config, GATOR_CONFIG or the default)
+		path, err := s.Config.Path()
+
+		// path check
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(s.Stdout, path)
+		return nil
+	case "set-password":
+		// alias input check
+		if len(cmd.Args) < 2 {
+			return fmt.Errorf("error: keyring alias required")
+		}
+		return setKeyringPassword(s, cmd.Args[1])
+	}
+
+	// unknown subcommand
+	return fmt.Errorf("error: unknown config subcommand %q (use get, set, unset, list, validate, path or set-password)", cmd.Args[0])
+}
+
+// config list helper, every key with its value (passwords and tokens masked)
+func listConfig(s *app.State) error {
+	// get the keys
+	keys := s.Config.List()
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"key", "value", "set", "env_override", "description"}}
+		for _, key := range keys {
+			value := key.Value
+			if key.Secret {
+				value = maskSecret(key.Key, value)
+			}
+			table.Add(key.Key, value, key.Set, key.EnvOverride, key.Description)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// print the keys
+	for _, key := range keys {
+		// value, or why there's none
+		value := key.Value
+		if key.Secret {
+			value = maskSecret(key.Key, value)
+		}
+		if !key.Set {
+			value = "(not set)"
+		}
+
+		// env override note
+		if key.EnvOverride != "" {
+			value += " (from " + key.EnvOverride + ")"
+		}
+		fmt.Fprintf(s.Stdout, "%-20s = %s\n", key.Key, value)
+		fmt.Fprintf(s.Stdout, "%-20s   %s\n", "", key.Description)
+	}
+
+	// return success
+	return nil
+}
+
+// config validate helper, explains every problem in the config file and GATOR_* env vars
+func validateConfig(s *app.State) error {
+	// validate the config
+	problems, err := s.Config.Validate()
+
+	// validate check
+	if err != nil {
+		return err
+	}
+
+	// valid check
+	if len(problems) == 0 {
+		fmt.Fprintln(s.Stdout, "Config is valid")
+		return nil
+	}
+
+	// print the problems
+	for _, problem := range problems {
+		fmt.Fprintln(s.Stdout, problem)
+	}
+	return fmt.Errorf("error: config has %d problem(s)", len(problems))
+}
+
+// config set-password helper, stores the db password in the os keyring and points db_password_keyring at it
+func setKeyringPassword(s *app.State, alias string) error {
+	// alias check, before asking for the password
+	err := keyring.CheckAlias(alias)
+	if err != nil {
+		return err
+	}
+
+	// read the password (not echoed)
+	password, err := keyring.ReadPassword(fmt.Sprintf("Database password for %s: ", alias))
+
+	// read check
+	if err != nil {
+		return err
+	}
+
+	// store it in the keyring
+	err = keyring.Set(alias, password)
+
+	// keyring check
+	if err != nil {
+		return err
+	}
+
+	// reference it from the config
+	err = s.Config.Set("db_password_keyring", alias)
+
+	// set check
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Password stored in the keyring as %s, db_password_keyring saved\n", alias)
+	fmt.Fprintln(s.Stdout, "Remove the password from db_url (eg postgres://gator@localhost:5432/gator) to keep it out of the config file")
+
+	// return success
+	return nil
+}
+
+// hide a secret config value helper, db_url only has its password hidden (the rest says where it connects)
+func maskSecret(key, value string) string {
+	// db_url check
+	if key == "db_url" {
+		return maskPassword(value)
+	}
+
+	// nothing to hide check
+	if value == "" {
+		return value
+	}
+	return "xxxxx"
+}
+
+// hide the password of a postgres:// url helper, key=value strings are returned as is
+func maskPassword(dbURL string) string {
+	// parse the url
+	u, err := url.Parse(dbURL)
+
+	// no password check
+	if err != nil || u.User == nil {
+		return dbURL
+	}
+	if _, ok := u.User.Password(); !ok {
+		return dbURL
+	}
+
+	// mask it
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return u.String()
+}
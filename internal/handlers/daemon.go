@@ -0,0 +1,479 @@
+// daemon.go
+package handlers
+
+import (
+	// std go libs
+	"bufio"         // reading control commands
+	"context"       // for context
+	"encoding/json" // control responses
+	"errors"        // matching not running
+	"fmt"           // print errors
+	"log/slog"      // structured logging
+	"net"           // the control socket
+	"os"            // pid file, re-running ourselves
+	"os/exec"       // starting the daemon
+	"path/filepath" // run files
+	"strconv"       // pid file
+	"strings"       // control commands
+	"sync"          // guarding the status
+	"time"          // timestamps and polling
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"    // for State
+	"github.com/PietPadda/aggregator/internal/output" // for --output formats
+)
+
+// package-wide constants
+const (
+	aggControlTimeout = 5 * time.Second  // a control request must be answered within this
+	aggStartTimeout   = 10 * time.Second // agg --daemon waits this long for the daemon to answer
+	aggStopTimeout    = 30 * time.Second // agg stop waits this long for in-flight fetches to finish
+)
+
+// agg status/stop's error when no agg answers
+var errAggNotRunning = errors.New("error: agg is not running")
+
+// files a running agg announces itself with, one set per schema (like the agg lock)
+type aggRunFiles struct {
+	pid    string // the pid, for humans and process managers
+	socket string // control socket, agg status and agg stop talk to it
+	log    string // agg --daemon's output
+}
+
+// what a running agg is doing, the control socket's answer
+type aggStatus struct {
+	PID         int            `json:"pid"`
+	Started     time.Time      `json:"started"`
+	Interval    string         `json:"interval"`
+	Batch       int32          `json:"batch"`
+	Concurrency int            `json:"concurrency"` // feeds stored at once right now, lower while the database is slow
+	State       string         `json:"state"`       // fetching, waiting or stopping
+	Fetched     int            `json:"fetched"`
+	Failed      int            `json:"failed"`
+	LastRun     time.Time      `json:"last_run"`         // zero until the first tick finished
+	NextRun     time.Time      `json:"next_run"`         // zero while fetching
+	Stages      []stageMetrics `json:"stages,omitempty"` // the fetch pipeline's totals since it started
+	OpenHosts   int            `json:"open_hosts"`       // hosts whose circuit is open, their feeds are skipped (see feeds --broken)
+	Error       string         `json:"error,omitempty"`
+}
+
+// a running agg's control socket
+type aggControl struct {
+	mu       sync.Mutex         // guards status
+	status   aggStatus          // what the agg is doing
+	files    aggRunFiles        // pid file and socket, removed on Close
+	listener net.Listener       // the control socket
+	stop     context.CancelFunc // ends the agg loop, like ctrl+c
+	reloads  chan struct{}      // agg reload asks, like SIGHUP (see watchConfig)
+}
+
+// get the run files of this config's agg helper
+func aggRunPaths(s *app.State) (aggRunFiles, error) {
+	// get the run dir
+	dir, err := s.Config.RunDir()
+
+	// run dir check
+	if err != nil {
+		return aggRunFiles{}, err
+	}
+
+	// one agg per schema, so name the files after it
+	name := "agg"
+	if schema := s.Config.SchemaName(); schema != "" {
+		name += "-" + schema
+	}
+	return aggRunFiles{
+		pid:    filepath.Join(dir, name+".pid"),
+		socket: filepath.Join(dir, name+".sock"),
+		log:    filepath.Join(dir, name+".log"),
+	}, nil
+}
+
+// start answering agg status and agg stop, writing the pid file
+// stop is called on agg stop, it should end the agg loop like ctrl+c does
+func startAggControl(s *app.State, status aggStatus, stop context.CancelFunc) (*aggControl, error) {
+	// get the run files
+	files, err := aggRunPaths(s)
+
+	// run files check
+	if err != nil {
+		return nil, err
+	}
+
+	// create the run dir
+	err = os.MkdirAll(filepath.Dir(files.socket), 0o700)
+
+	// mkdir check
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s: %w", filepath.Dir(files.socket), err)
+	}
+
+	// another agg check (one on another database can share the home dir and schema name)
+	if running, err := requestAgg(files, "status"); err == nil {
+		return nil, fmt.Errorf("error: another agg (pid %d) is already using %s, give this one its own schema", running.PID, files.socket)
+	}
+
+	// listen on the control socket (a leftover one is stale, nobody answered)
+	os.Remove(files.socket)
+	listener, err := net.Listen("unix", files.socket)
+
+	// listen check
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %w", files.socket, err)
+	}
+
+	// write the pid file
+	err = os.WriteFile(files.pid, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
+
+	// pid file check
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("error writing %s: %w", files.pid, err)
+	}
+
+	// answer requests in the background
+	control := &aggControl{status: status, files: files, listener: listener, stop: stop, reloads: make(chan struct{}, 1)}
+	go control.serve()
+
+	// return the control
+	return control, nil
+}
+
+// change the status method (once stopping, it stays stopping)
+func (c *aggControl) update(change func(status *aggStatus)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stopping := c.status.State == "stopping"
+	change(&c.status)
+	if stopping {
+		c.status.State = "stopping"
+	}
+}
+
+// stop answering method, removes the socket and pid file
+func (c *aggControl) Close() {
+	c.listener.Close()
+	os.Remove(c.files.socket)
+	os.Remove(c.files.pid)
+}
+
+// answer control requests method, until Close
+func (c *aggControl) serve() {
+	for {
+		// wait for a request
+		conn, err := c.listener.Accept()
+
+		// closed check
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			slog.Debug("error accepting control connection", "err", err)
+			continue
+		}
+		go c.answer(conn)
+	}
+}
+
+// answer a single control request method: a command line in, the status as json out
+func (c *aggControl) answer(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(aggControlTimeout))
+
+	// read the command
+	line, err := bufio.NewReader(conn).ReadString('\n')
+
+	// read check
+	if err != nil {
+		slog.Debug("error reading control request", "err", err)
+		return
+	}
+
+	// run the command
+	c.mu.Lock()
+	switch command := strings.TrimSpace(line); command {
+	case "status":
+	case "stop":
+		slog.Info("stopping agg, asked by agg stop")
+		c.status.State = "stopping"
+		c.stop()
+	case "reload":
+		slog.Info("reloading config and feeds, asked by agg reload")
+		select {
+		case c.reloads <- struct{}{}:
+		default: // one is pending already
+		}
+	default:
+		c.status.Error = fmt.Sprintf("unknown command %q", command)
+	}
+	status := c.status
+	c.status.Error = ""
+	c.mu.Unlock()
+
+	// answer with the status
+	err = json.NewEncoder(conn).Encode(status)
+
+	// write check
+	if err != nil {
+		slog.Debug("error answering control request", "err", err)
+	}
+}
+
+// send a command to the running agg helper, returning its status
+func requestAgg(files aggRunFiles, command string) (aggStatus, error) {
+	// connect to the control socket
+	conn, err := net.DialTimeout("unix", files.socket, aggControlTimeout)
+
+	// not running check
+	if err != nil {
+		return aggStatus{}, errAggNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(aggControlTimeout))
+
+	// send the command
+	_, err = fmt.Fprintln(conn, command)
+
+	// send check
+	if err != nil {
+		return aggStatus{}, fmt.Errorf("error talking to agg: %w", err)
+	}
+
+	// read the status
+	var status aggStatus
+	err = json.NewDecoder(conn).Decode(&status)
+
+	// decode check
+	if err != nil {
+		return aggStatus{}, fmt.Errorf("error reading agg's answer: %w", err)
+	}
+
+	// command error check
+	if status.Error != "" {
+		return status, fmt.Errorf("error: agg says: %s", status.Error)
+	}
+	return status, nil
+}
+
+// agg status helper, prints what the running agg is doing
+func aggStatusCmd(s *app.State) error {
+	// get the run files
+	files, err := aggRunPaths(s)
+
+	// run files check
+	if err != nil {
+		return err
+	}
+
+	// ask the running agg
+	status, err := requestAgg(files, "status")
+
+	// request check
+	if err != nil {
+		return err
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"pid", "started", "state", "interval", "batch", "concurrency", "fetched", "failed", "last_run", "next_run", "fetch_avg", "parse_avg", "store_avg", "open_hosts"}}
+		table.Add(status.PID, status.Started, status.State, status.Interval, status.Batch, status.Concurrency, status.Fetched, status.Failed, optionalTime(status.LastRun), optionalTime(status.NextRun),
+			stageAverage(status.Stages, stageFetch), stageAverage(status.Stages, stageParse), stageAverage(status.Stages, stageStore), status.OpenHosts)
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// print the status
+	fmt.Fprintf(s.Stdout, "agg is running (pid %d, since %s)\n", status.PID, status.Started.Format(time.DateTime))
+	fmt.Fprintf(s.Stdout, "State: %s", status.State)
+	if status.State == "waiting" && !status.NextRun.IsZero() {
+		fmt.Fprintf(s.Stdout, ", next run at %s", status.NextRun.Format(time.DateTime))
+	}
+	fmt.Fprintln(s.Stdout)
+	fmt.Fprintf(s.Stdout, "Interval: %s, batch: %d (0 = all due feeds), concurrency: %d\n", status.Interval, status.Batch, status.Concurrency)
+	fmt.Fprintf(s.Stdout, "Fetched: %d feeds, %d failed\n", status.Fetched, status.Failed)
+	for _, stage := range status.Stages {
+		if stage.Feeds > 0 {
+			fmt.Fprintf(s.Stdout, "  %s: %d feeds, %d failed, avg %s\n", stage.Stage, stage.Feeds, stage.Failed, stage.Average().Round(time.Millisecond))
+		}
+		if stage.Skip > 0 {
+			fmt.Fprintf(s.Stdout, "  %s: %d feeds skipped, their host's circuit was open\n", stage.Stage, stage.Skip)
+		}
+	}
+	if status.OpenHosts > 0 {
+		fmt.Fprintf(s.Stdout, "Open host circuits: %d (see feeds --broken)\n", status.OpenHosts)
+	}
+	if !status.LastRun.IsZero() {
+		fmt.Fprintf(s.Stdout, "Last run: %s\n", status.LastRun.Format(time.DateTime))
+	}
+	fmt.Fprintf(s.Stdout, "Log and pid file: %s, %s\n", files.log, files.pid)
+
+	// return success
+	return nil
+}
+
+// a stage's average time per feed helper, "" if it hasn't had any
+func stageAverage(stages []stageMetrics, stage int) string {
+	// no feeds check
+	if stage >= len(stages) || stages[stage].Feeds == 0 {
+		return ""
+	}
+	return stages[stage].Average().Round(time.Millisecond).String()
+}
+
+// agg stop helper, asks the running agg to stop and waits until it has
+func aggStopCmd(ctx context.Context, s *app.State) error {
+	// get the run files
+	files, err := aggRunPaths(s)
+
+	// run files check
+	if err != nil {
+		return err
+	}
+
+	// ask the running agg to stop
+	status, err := requestAgg(files, "stop")
+
+	// request check
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Stopping agg (pid %d), waiting for in-flight fetches...\n", status.PID)
+
+	// wait until its socket is gone (it removes it when it exits)
+	deadline := time.Now().Add(aggStopTimeout)
+	for time.Now().Before(deadline) {
+		// stopped check
+		if _, err := requestAgg(files, "status"); errors.Is(err, errAggNotRunning) {
+			fmt.Fprintln(s.Stdout, "agg stopped.")
+			return nil
+		}
+
+		// wait a bit (or give up on ctrl+c)
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// still running
+	return fmt.Errorf("error: agg (pid %d) is still stopping after %s, see %s", status.PID, aggStopTimeout, files.log)
+}
+
+// agg reload helper, asks the running agg to reload its config and check for due feeds right away
+func aggReloadCmd(s *app.State) error {
+	// get the run files
+	files, err := aggRunPaths(s)
+
+	// run files check
+	if err != nil {
+		return err
+	}
+
+	// ask the running agg to reload
+	status, err := requestAgg(files, "reload")
+
+	// request check
+	if err != nil {
+		return err
+	}
+
+	// it reloads in the background, a bad config only shows in its log
+	fmt.Fprintf(s.Stdout, "Asked agg (pid %d) to reload its config and feeds, see %s for the outcome.\n", status.PID, files.log)
+	return nil
+}
+
+// agg --daemon helper, runs agg again in the background and waits until it answers
+// the daemon gets this process' args minus --daemon
+func startAggDaemon(ctx context.Context, s *app.State) error {
+	// get the run files
+	files, err := aggRunPaths(s)
+
+	// run files check
+	if err != nil {
+		return err
+	}
+
+	// already running check
+	if status, err := requestAgg(files, "status"); err == nil {
+		return fmt.Errorf("error: agg is already running (pid %d), stop it with: aggregator agg stop", status.PID)
+	}
+
+	// get our own binary
+	executable, err := os.Executable()
+
+	// executable check
+	if err != nil {
+		return fmt.Errorf("error finding the aggregator binary: %w", err)
+	}
+
+	// the same args minus --daemon (global flags like --config and -v are kept)
+	var args []string
+	for _, arg := range os.Args[1:] {
+		if arg != "--daemon" {
+			args = append(args, arg)
+		}
+	}
+
+	// open the log, the daemon's stdout and stderr
+	err = os.MkdirAll(filepath.Dir(files.log), 0o700)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(files.log), err)
+	}
+	logFile, err := os.OpenFile(files.log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+
+	// open log check
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", files.log, err)
+	}
+	defer logFile.Close() // the daemon has its own copy
+
+	// start the daemon in its own session, so it outlives this terminal
+	daemon := exec.Command(executable, args...)
+	daemon.Stdout = logFile
+	daemon.Stderr = logFile
+	daemon.SysProcAttr = detachedProcess()
+	err = daemon.Start()
+
+	// start check
+	if err != nil {
+		return fmt.Errorf("error starting agg in the background: %w", err)
+	}
+
+	// notice if it exits early (bad interval, lock held, no database...)
+	exited := make(chan error, 1)
+	go func() {
+		exited <- daemon.Wait()
+	}()
+
+	// wait until it answers on its control socket
+	deadline := time.Now().Add(aggStartTimeout)
+	for time.Now().Before(deadline) {
+		// answering check
+		if status, err := requestAgg(files, "status"); err == nil {
+			fmt.Fprintf(s.Stdout, "agg is running in the background (pid %d), logging to %s\n", status.PID, files.log)
+			fmt.Fprintln(s.Stdout, "Check on it with: aggregator agg status, stop it with: aggregator agg stop")
+			return nil
+		}
+
+		// wait a bit (or until it exits)
+		select {
+		case err := <-exited:
+			return fmt.Errorf("error: agg exited right after starting (%v), see %s", err, files.log)
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// started, but not answering yet
+	return fmt.Errorf("error: agg (pid %d) started but isn't answering yet, see %s", daemon.Process.Pid, files.log)
+}
+
+// zero time as null helper, for machine-readable output
+func optionalTime(t time.Time) any {
+	// zero check
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
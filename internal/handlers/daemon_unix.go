@@ -0,0 +1,14 @@
+// daemon_unix.go
+//go:build !windows
+
+package handlers
+
+import (
+	// std go libs
+	"syscall" // process attributes
+)
+
+// process attributes of agg --daemon, a new session so it outlives the terminal (and its SIGHUP)
+func detachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,17 @@
+// daemon_windows.go
+//go:build windows
+
+package handlers
+
+import (
+	// std go libs
+	"syscall" // process attributes
+)
+
+// DETACHED_PROCESS, no console (syscall doesn't define it)
+const detachedProcessFlag = 0x00000008
+
+// process attributes of agg --daemon, no console and its own process group so it outlives the terminal (and its ctrl+c)
+func detachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: detachedProcessFlag | syscall.CREATE_NEW_PROCESS_GROUP, HideWindow: true}
+}
@@ -0,0 +1,528 @@
+// delete.go
+package handlers
+
+import (
+	// std go libs
+	"context"       // for context
+	"database/sql"  // for sql errors
+	"encoding/json" // reset backups
+	"errors"        // for error handling
+	"fmt"           // print errors
+	"os"            // writing backups
+	"time"          // prune cutoff
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/archive"  // for prune --archive
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// removefeed handler logic
+// NOTE: cmd will be removefeed, and state holds the config file to delete a feed
+// soft deletes by default (undelete feed restores it), --purge deletes it for good (with its follows and posts!)
+// supports --dry-run to print what would be deleted without deleting it
+func HandlerRemoveFeed(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// strip the dry-run and purge flags from the args
+	args, dryRun := popFlag(cmd.Args, "--dry-run")
+	args, purge := popFlag(args, "--purge")
+
+	// cmd input check
+	if len(args) < 1 {
+		return fmt.Errorf("error: feed url required")
+	} // removefeed handler expects ONE arg: the feed URL!
+
+	// get arguments input
+	feedURL := args[0] // not needed, but nicely readable!
+
+	// dry-run check
+	if dryRun {
+		// count everything the feed delete cascades to
+		counts, err := s.DB.CountFeedDependents(ctx, feedURL)
+
+		// feed exists check
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: no feed found with url %s", feedURL)
+		}
+
+		// count check
+		if err != nil {
+			return fmt.Errorf("error counting feed rows for %s: %w", feedURL, err)
+		}
+
+		// soft delete check, nothing is actually deleted
+		if !purge {
+			fmt.Fprintf(s.Stdout, "Dry run: removefeed would soft delete feed %s, keeping its\n", feedURL)
+			fmt.Fprintf(s.Stdout, "  %d feed follows\n", counts.FeedFollows)
+			fmt.Fprintf(s.Stdout, "  %d posts\n", counts.Posts)
+			fmt.Fprintln(s.Stdout, "(restore it with undelete feed, or use --purge to delete it for good)")
+			return nil
+		}
+
+		// print what would be deleted
+		fmt.Fprintf(s.Stdout, "Dry run: removefeed would delete feed %s and\n", feedURL)
+		fmt.Fprintf(s.Stdout, "  %d feed follows\n", counts.FeedFollows)
+		fmt.Fprintf(s.Stdout, "  %d posts\n", counts.Posts)
+
+		// return success
+		return nil
+	}
+
+	// owner check, only admins may remove feeds someone else added
+	owner, err := s.DB.GetFeedOwner(ctx, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: no feed found with url %s", feedURL)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+	if owner != user.ID {
+		err = requireAdmin(ctx, s, "removing a feed someone else added")
+		if err != nil {
+			return err
+		}
+	}
+
+	// soft delete check (the default, recoverable)
+	if !purge {
+		// mark the feed deleted, its follows and posts are kept
+		rows, err := s.DB.SoftDeleteFeedByURL(ctx, feedURL)
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error removing feed: %w", err)
+		}
+
+		// feed exists check (0 rows = no such feed, or already removed)
+		if rows == 0 {
+			return fmt.Errorf("error: no feed found with url %s", feedURL)
+		}
+
+		// print confirmation msg to user
+		fmt.Fprintf(s.Stdout, "Feed %s successfully removed! (undo with: undelete feed %s)\n", feedURL, feedURL)
+		return nil
+	}
+
+	// purge the feed (follows and posts cascade!)
+	rows, err := s.DB.DeleteFeedByURL(ctx, feedURL)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
+
+	// delete check
+	if err != nil {
+		return fmt.Errorf("error removing feed: %w", err)
+	}
+
+	// feed exists check
+	if rows == 0 {
+		return fmt.Errorf("error: no feed found with url %s", feedURL)
+	}
+
+	// print confirmation msg to user
+	fmt.Fprintf(s.Stdout, "Feed %s successfully purged!\n", feedURL)
+
+	// return success
+	return nil
+}
+
+// deleteuser handler logic
+// NOTE: cmd will be deleteuser, and state holds the config file to delete a user (and their feeds!)
+// soft deletes by default (undelete user restores them), --purge deletes them for good (with their follows!)
+// supports --dry-run to print what would be deleted without deleting it
+func HandlerDeleteUser(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// strip the dry-run and purge flags from the args
+	args, dryRun := popFlag(cmd.Args, "--dry-run")
+	args, purge := popFlag(args, "--purge")
+
+	// cmd input check
+	if len(args) < 1 {
+		return fmt.Errorf("error: username required")
+	} // deleteuser handler expects ONE arg: the username!
+
+	// get username input (first arg!)
+	username := args[0] // not needed, but nicely readable!
+
+	// dry-run check
+	if dryRun {
+		// count everything the user delete cascades to
+		counts, err := s.DB.CountUserDependents(ctx, username)
+
+		// user exists check
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: user '%s' doesn't exist", username)
+		}
+
+		// count check
+		if err != nil {
+			return fmt.Errorf("error counting user rows for '%s': %w", username, err)
+		}
+
+		// soft delete check, nothing is actually deleted
+		if !purge {
+			fmt.Fprintf(s.Stdout, "Dry run: deleteuser would soft delete user '%s' and their\n", username)
+			fmt.Fprintf(s.Stdout, "  %d feeds\n", counts.Feeds)
+			fmt.Fprintln(s.Stdout, "(restore them with undelete user, or use --purge to delete them for good)")
+			return nil
+		}
+
+		// print what would be deleted
+		fmt.Fprintf(s.Stdout, "Dry run: deleteuser would delete user '%s' and\n", username)
+		fmt.Fprintf(s.Stdout, "  %d feeds\n", counts.Feeds)
+		fmt.Fprintf(s.Stdout, "  %d feed follows (%d of them other users' follows of their feeds)\n", counts.FeedFollows, counts.OtherFollows)
+		fmt.Fprintf(s.Stdout, "  %d posts\n", counts.Posts)
+
+		// return success
+		return nil
+	}
+
+	// other user check, only admins may delete someone else
+	if s.Config == nil || s.Config.Name == nil || *s.Config.Name != username {
+		err := requireAdmin(ctx, s, "deleting another user")
+		if err != nil {
+			return err
+		}
+	}
+
+	// soft delete check (the default, recoverable)
+	if !purge {
+		// delete and log out in one transaction, so undelete can't bring their serve sessions back
+		tx, err := s.Conn.BeginTx(ctx, nil)
+
+		// begin check
+		if err != nil {
+			return fmt.Errorf("error beginning transaction: %w", err)
+		}
+		defer tx.Rollback() // no-op after commit
+		queries := s.DB.WithTx(tx)
+
+		// get the user (soft deleted users aren't found, so deleting them twice says so)
+		user, err := queries.GetUser(ctx, username)
+
+		// user exists check
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: user '%s' doesn't exist", username)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting user from db: %w", err)
+		}
+
+		// mark the user and their feeds deleted
+		deleted, err := queries.SoftDeleteUser(ctx, username)
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error deleting user: %w", err)
+		}
+
+		// log their sessions out
+		_, err = queries.DeleteSessionsForUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error logging '%s' out of serve: %w", username, err)
+		}
+
+		// commit check
+		err = tx.Commit()
+		if err != nil {
+			return fmt.Errorf("error committing user delete: %w", err)
+		}
+
+		// print confirmation msg to user
+		fmt.Fprintf(s.Stdout, "User '%s' and %d of their feeds successfully deleted! (undo with: undelete user %s)\n", username, deleted.Feeds, username)
+		return nil
+	}
+
+	// purge the user (feeds, follows, posts and sessions cascade!)
+	rows, err := s.DB.DeleteUser(ctx, username)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
+
+	// delete check
+	if err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	// user exists check
+	if rows == 0 {
+		return fmt.Errorf("error: user '%s' doesn't exist", username)
+	}
+
+	// print confirmation msg to user
+	fmt.Fprintf(s.Stdout, "User '%s' successfully purged!\n", username)
+
+	// return success
+	return nil
+}
+
+// undelete handler logic
+// NOTE: cmd will be undelete user <name> | undelete feed <url>, restores what deleteuser/removefeed soft deleted
+func HandlerUndelete(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// restore by kind (Run checked there are TWO args: what to restore, and its name/url)
+	switch cmd.Args[0] {
+	case "user":
+		// restore the user (and the feeds deleted with them)
+		username := cmd.Args[1]
+		restored, err := s.DB.UndeleteUser(ctx, username)
+
+		// undelete check
+		if err != nil {
+			return fmt.Errorf("error restoring user: %w", err)
+		}
+
+		// deleted user check
+		if restored.Users == 0 {
+			return fmt.Errorf("error: no deleted user named '%s'", username)
+		}
+
+		// print confirmation msg to user
+		fmt.Fprintf(s.Stdout, "User '%s' and %d of their feeds restored!\n", username, restored.Feeds)
+	case "feed":
+		// restore the feed (its follows and posts were kept)
+		feedURL := cmd.Args[1]
+		rows, err := s.DB.UndeleteFeedByURL(ctx, feedURL)
+
+		// undelete check
+		if err != nil {
+			return fmt.Errorf("error restoring feed: %w", err)
+		}
+
+		// deleted feed check
+		if rows == 0 {
+			return fmt.Errorf("error: no deleted feed with url %s", feedURL)
+		}
+
+		// print confirmation msg to user
+		fmt.Fprintf(s.Stdout, "Feed %s restored!\n", feedURL)
+	default:
+		return fmt.Errorf("error: can only undelete a user or a feed, not %q", cmd.Args[0])
+	}
+
+	// return success
+	return nil
+}
+
+// prune handler logic
+// NOTE: cmd will be prune, and state holds the config file to delete posts older than a given age
+// supports --dry-run to print what would be deleted without deleting it
+func HandlerPrune(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// strip the dry-run and archive flags from the args
+	args, dryRun := popFlag(cmd.Args, "--dry-run")
+	args, archivePosts := popFlag(args, "--archive")
+
+	// cmd input check
+	if len(args) < 1 {
+		return fmt.Errorf("error: max post age required")
+	} // prune handler expects ONE arg: the max age, eg 720h!
+
+	// parse the age duration string
+	maxAge, err := time.ParseDuration(args[0])
+
+	// max age check
+	if err != nil {
+		return fmt.Errorf("error: invalid duration format: %w", err)
+	}
+
+	// posts published before the cutoff get pruned
+	cutoff := time.Now().Add(-maxAge)
+
+	// dry-run check
+	if dryRun {
+		// count the posts prune would delete
+		count, err := s.DB.CountPostsOlderThan(ctx, cutoff)
+
+		// count check
+		if err != nil {
+			return fmt.Errorf("error counting posts to prune: %w", err)
+		}
+
+		// print what would be deleted
+		fmt.Fprintf(s.Stdout, "Dry run: prune would delete %d posts older than %v\n", count, maxAge)
+
+		// archive check
+		if archivePosts {
+			// get the archive dir
+			dir, err := s.Config.ArchivePath()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(s.Stdout, "  and archive them to %s\n", dir)
+		}
+
+		// return success
+		return nil
+	}
+
+	// archive check, pruned posts get cold-stored instead of lost
+	if archivePosts {
+		return pruneWithArchive(ctx, s, cutoff, maxAge)
+	}
+
+	// delete the old posts
+	rows, err := s.DB.DeletePostsOlderThan(ctx, cutoff)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
+
+	// delete check
+	if err != nil {
+		return fmt.Errorf("error pruning posts: %w", err)
+	}
+
+	// print confirmation msg to user
+	fmt.Fprintf(s.Stdout, "Pruned %d posts older than %v\n", rows, maxAge)
+
+	// return success
+	return nil
+}
+
+// prune --archive helper, deletes the old posts and writes them to a gzipped JSONL archive
+// NOTE: the delete is only committed once the archive is safely written
+func pruneWithArchive(ctx context.Context, s *app.State, cutoff time.Time, maxAge time.Duration) error {
+	// get the archive dir
+	dir, err := s.Config.ArchivePath()
+
+	// archive dir check
+	if err != nil {
+		return err
+	}
+
+	// begin a transaction, so the posts stay if the archive can't be written
+	tx, err := s.Conn.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit
+
+	// delete the old posts, getting them back to archive
+	deleted, err := s.DB.WithTx(tx).ArchivePostsOlderThan(ctx, cutoff)
+
+	// delete check
+	if err != nil {
+		return fmt.Errorf("error pruning posts: %w", err)
+	}
+
+	// nothing to prune check (no empty archives)
+	if len(deleted) == 0 {
+		fmt.Fprintf(s.Stdout, "Pruned 0 posts older than %v\n", maxAge)
+		return nil
+	}
+
+	// convert to archive posts (plain values, not sql.Null types)
+	posts := make([]archive.Post, 0, len(deleted))
+	for _, post := range deleted {
+		archived := archive.Post{
+			Title:       post.Title,
+			URL:         post.Url,
+			Description: post.Description.String, // "" if NULL
+			CreatedAt:   post.CreatedAt,
+			FeedName:    post.FeedName,
+			FeedURL:     post.FeedUrl,
+		}
+
+		// pubdate check
+		if post.PublishedAt.Valid {
+			archived.PublishedAt = &post.PublishedAt.Time
+		}
+		posts = append(posts, archived)
+	}
+
+	// write the archive
+	path, err := archive.Write(dir, posts)
+
+	// write check (rolls back the delete)
+	if err != nil {
+		return err
+	}
+
+	// commit the delete
+	err = tx.Commit()
+
+	// commit check
+	if err != nil {
+		return fmt.Errorf("error committing prune (posts were archived to %s but not deleted): %w", path, err)
+	}
+
+	// print confirmation msg to user
+	fmt.Fprintf(s.Stdout, "Pruned %d posts older than %v, archived to %s\n", len(deleted), maxAge, path)
+
+	// return success
+	return nil
+}
+
+// every table reset deletes: users, and every table whose rows cascade from them (see TestResetTablesCascade)
+// NOTE: a new table with an ON DELETE CASCADE key into one of these must be added here, or --backup misses it
+// parents come before the tables referencing them, the order they restore in
+var resetTables = []string{
+	"users", "folders", "feeds", "feed_follows", "posts", "feed_snapshots", "enclosures",
+	"post_states", "fever_accounts", "api_tokens", "user_passwords", "sessions", "fetch_jobs",
+	"timeline_shares", "digest_subscriptions", "telegram_chats", "push_targets", "rules", "post_tags",
+	"mutes", "language_preferences", "post_translations", "post_summaries", "read_later_accounts",
+	"digest_preferences", "post_notes", "post_shares", "profile_shares", "sync_accounts",
+}
+
+// reset --backup file contents, each table's rows as json objects of their columns
+// NOTE: a table restores with INSERT INTO <table> SELECT * FROM json_populate_recordset(NULL::<table>, <rows>)
+type resetBackup struct {
+	CreatedAt time.Time                  `json:"created_at"`
+	Tables    map[string]json.RawMessage `json:"tables"`
+}
+
+// backup helper, dumps ALL tables reset deletes to a JSON file
+// the tables are read in one read-only snapshot, so the rows agree with each other
+func writeResetBackup(ctx context.Context, conn *sql.DB, path string) error {
+	// begin the snapshot
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	// begin check
+	if err != nil {
+		return fmt.Errorf("error starting backup: %w", err)
+	}
+	defer tx.Rollback() // read only, nothing to commit
+
+	// dump each table (the names are ours, not user input)
+	backup := resetBackup{CreatedAt: time.Now(), Tables: map[string]json.RawMessage{}}
+	for _, table := range resetTables {
+		var rows []byte
+		err = tx.QueryRowContext(ctx, `SELECT COALESCE(json_agg(t), '[]') FROM `+table+` t`).Scan(&rows)
+		if err != nil {
+			return fmt.Errorf("error listing %s: %w", table, err)
+		}
+		backup.Tables[table] = rows
+	}
+
+	// marshal the backup to json (marshalindent prettifies it with newlines!)
+	jsonData, err := json.MarshalIndent(backup, "", "  ")
+
+	// marshalindent check
+	if err != nil {
+		return fmt.Errorf("error marshalling backup to json: %w", err)
+	}
+
+	// write the backup file
+	err = os.WriteFile(path, jsonData, 0600)
+	// 0600 = owner read & write ONLY, the backup holds all the user data (password and token hashes too)!
+
+	// writefile check
+	if err != nil {
+		return fmt.Errorf("error writing backup file: %w", err)
+	}
+
+	// return success
+	return nil
+}
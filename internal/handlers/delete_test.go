@@ -0,0 +1,64 @@
+// delete_test.go
+package handlers
+
+import (
+	// std go libs
+	"os"            // reading the migrations
+	"path/filepath" // the schema dir
+	"regexp"        // finding tables and foreign keys
+	"slices"        // comparing table lists
+	"strings"       // splitting statements
+	"testing"       // go tests
+)
+
+// statements that create or change a table, and foreign keys that delete their rows with the row they point at
+var (
+	tableStatement = regexp.MustCompile(`(?is)\b(?:CREATE|ALTER)\s+TABLE\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?(?:ONLY\s+)?(\w+)`)
+	cascadeKey     = regexp.MustCompile(`(?is)REFERENCES\s+(\w+)\s*\([^)]*\)\s*ON\s+DELETE\s+CASCADE`)
+	sqlComment     = regexp.MustCompile(`--[^\n]*`)
+)
+
+// reset --backup dumps every table DELETE FROM users empties, read off the migrations' ON DELETE CASCADE keys
+func TestResetTablesCascade(t *testing.T) {
+	// get the migrations
+	files, err := filepath.Glob(filepath.Join("..", "..", "sql", "schema", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+
+	// collect the cascading keys, parent table to the tables whose rows go with it
+	children := map[string][]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+		up, _, _ := strings.Cut(string(data), "-- +goose Down")
+		for _, statement := range strings.Split(sqlComment.ReplaceAllString(up, ""), ";") {
+			table := tableStatement.FindStringSubmatch(statement)
+			if table == nil {
+				continue
+			}
+			for _, key := range cascadeKey.FindAllStringSubmatch(statement, -1) {
+				children[key[1]] = append(children[key[1]], table[1])
+			}
+		}
+	}
+
+	// follow them from users
+	deleted := []string{"users"}
+	for i := 0; i < len(deleted); i++ {
+		for _, child := range children[deleted[i]] {
+			if !slices.Contains(deleted, child) {
+				deleted = append(deleted, child)
+			}
+		}
+	}
+
+	// compare with what the backup dumps
+	slices.Sort(deleted)
+	backedUp := slices.Sorted(slices.Values(resetTables))
+	if !slices.Equal(deleted, backedUp) {
+		t.Errorf("reset deletes %v\nbut --backup dumps %v", deleted, backedUp)
+	}
+}
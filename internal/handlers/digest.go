@@ -0,0 +1,348 @@
+// digest.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows and NullTime
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"log/slog"     // logging sends
+	"net/url"      // escaping the token
+	"time"         // digest windows
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/api"      // unsubscribe tokens
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/digest"   // building the emails
+	"github.com/PietPadda/aggregator/internal/mail"     // sending them
+)
+
+// most posts in one digest, the newest of each feed make it in
+const digestPostLimit = 200
+
+// digest handler logic
+// NOTE: cmd will be digest subscribe <email> | unsubscribe | status | prefs [set [flags]|clear] | send [--dry-run] | --epub <file> [--folder <name>]
+// NOTE: subscribe, unsubscribe, status, prefs and --epub are the current user's, send sends every digest that's due
+func HandlerDigest(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: digest subscribe <email> | digest unsubscribe | digest status | digest prefs [set [flags]|clear] | digest send [--dry-run] | digest --epub <file> [--folder <name>]")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// e-book check, the unread posts go in a file instead of an email
+	rest, path, err := popFlagValue(cmd.Args, "--epub")
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		rest, folder, err := popFlagValue(rest, "--folder")
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return fmt.Errorf("error: usage: digest --epub <file> [--folder <name>]")
+		}
+		return MiddlewareLoggedIn(func(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+			return digestEPUB(ctx, s, user, path, folder)
+		})(ctx, s, cmd)
+	}
+
+	// subcommand check
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "subscribe":
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: digest subscribe <email>")
+		}
+		return MiddlewareLoggedIn(func(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+			return subscribeDigest(ctx, s, user, args[0])
+		})(ctx, s, cmd)
+	case "unsubscribe":
+		return MiddlewareLoggedIn(unsubscribeDigest)(ctx, s, cmd)
+	case "status":
+		return MiddlewareLoggedIn(digestStatus)(ctx, s, cmd)
+	case "prefs":
+		return MiddlewareLoggedIn(func(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+			return digestPrefs(ctx, s, user, args)
+		})(ctx, s, cmd)
+	case "send":
+		args, dryRun := popFlag(args, "--dry-run")
+		if len(args) != 0 {
+			return fmt.Errorf("error: usage: digest send [--dry-run]")
+		}
+
+		// send the due digests, then the scheduled ones
+		sent, failed, err := sendDueDigests(ctx, s, dryRun)
+		if err != nil {
+			return err
+		}
+		scheduledSent, scheduledFailed, err := sendScheduledDigests(ctx, s, dryRun)
+		if err != nil {
+			return err
+		}
+		sent, failed = sent+scheduledSent, failed+scheduledFailed
+		if dryRun {
+			return nil
+		}
+		fmt.Fprintf(s.Stdout, "Sent %d digests.\n", sent)
+
+		// any failures check (non-zero exit status for cron!)
+		if failed > 0 {
+			return fmt.Errorf("error: %d digests failed to send, they're retried next time", failed)
+		}
+		return nil
+	}
+	return usage
+}
+
+// digest subscribe helper, (re)subscribes the user at an address
+func subscribeDigest(ctx context.Context, s *app.State, user database.User, address string) error {
+	// address check
+	email, err := mail.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	// a token for the unsubscribe links (kept if already subscribed)
+	token, err := api.NewUnsubscribeToken()
+
+	// token check
+	if err != nil {
+		return err
+	}
+
+	// subscribe
+	err = s.DB.SubscribeDigest(ctx, database.SubscribeDigestParams{
+		UserID:           user.ID,
+		CreatedAt:        time.Now().UTC(),
+		Email:            email,
+		UnsubscribeToken: token,
+	})
+
+	// subscribe check
+	if err != nil {
+		return fmt.Errorf("error subscribing to digests: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Digests of your new posts will be emailed to %s.\n", email)
+
+	// not sending check, so nobody waits for mail that never comes
+	if s.Config.DigestEvery() == 0 {
+		fmt.Fprintln(s.Stdout, "Note: digest_interval isn't set, so agg won't send them (set it with: config set digest_interval 24h, or run: digest send)")
+	}
+	if _, err := s.Config.SMTPOptions(); err != nil {
+		fmt.Fprintln(s.Stdout, "Note: no smtp server is configured yet (config set smtp.addr and smtp.from)")
+	}
+	return nil
+}
+
+// digest unsubscribe helper
+func unsubscribeDigest(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	rows, err := s.DB.UnsubscribeDigest(ctx, user.ID)
+
+	// unsubscribe check
+	if err != nil {
+		return fmt.Errorf("error unsubscribing from digests: %w", err)
+	}
+
+	// wasn't subscribed check
+	if rows == 0 {
+		fmt.Fprintln(s.Stdout, "You weren't subscribed to digests.")
+		return nil
+	}
+	fmt.Fprintln(s.Stdout, "You're unsubscribed from digests.")
+	return nil
+}
+
+// digest status helper, where and when the user's digests go
+func digestStatus(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// own schedule check, its digests may not be emails at all
+	prefs, err := s.DB.GetDigestPreference(ctx, user.ID)
+	if err == nil {
+		return showDigestPrefs(ctx, s, user, prefs)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error getting digest preferences: %w", err)
+	}
+
+	// get the subscription
+	sub, err := s.DB.GetDigestSubscription(ctx, user.ID)
+
+	// not subscribed check
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Fprintln(s.Stdout, "You're not subscribed to digests, subscribe with: digest subscribe <email>")
+		return nil
+	}
+
+	// get subscription check
+	if err != nil {
+		return fmt.Errorf("error getting digest subscription: %w", err)
+	}
+
+	// print it
+	fmt.Fprintf(s.Stdout, "Digests go to %s, subscribed since %s\n", sub.Email, sub.CreatedAt.Local().Format(time.DateTime))
+	if sub.LastSentAt.Valid {
+		fmt.Fprintf(s.Stdout, "Last sent: %s\n", sub.LastSentAt.Time.Local().Format(time.DateTime))
+	} else {
+		fmt.Fprintln(s.Stdout, "Last sent: never")
+	}
+	if every := s.Config.DigestEvery(); every > 0 {
+		fmt.Fprintf(s.Stdout, "Sent every %s by agg, when there are new posts\n", every)
+	} else {
+		fmt.Fprintln(s.Stdout, "digest_interval isn't set, so only digest send sends them")
+	}
+	return nil
+}
+
+// send the due digests helper, for digest send and agg
+// due = last sent at least digest_interval ago (any time if it's not set), subscribers without new posts are skipped
+// NOTE: each digest is claimed before it's sent, so two aggs never send the same one
+// returns how many digests were sent and how many failed
+func sendDueDigests(ctx context.Context, s *app.State, dryRun bool) (int, int, error) {
+	// get the due subscriptions
+	now := time.Now().UTC()
+	subs, err := s.DB.ListDueDigests(ctx, now.Add(-s.Config.DigestEvery()))
+
+	// due check
+	if err != nil {
+		return 0, 0, fmt.Errorf("error listing due digests: %w", err)
+	}
+
+	// smtp check (dry runs don't send, and nothing due needs no server)
+	opts, err := s.Config.SMTPOptions()
+	if err != nil && !dryRun && len(subs) > 0 {
+		return 0, 0, err
+	}
+
+	// send each
+	sent, failed := 0, 0
+	for _, sub := range subs {
+		// stopping check
+		if ctx.Err() != nil {
+			return sent, failed, ctx.Err()
+		}
+
+		// get the posts since the last one (or since subscribing)
+		since := sub.CreatedAt
+		if sub.LastSentAt.Valid {
+			since = sub.LastSentAt.Time
+		}
+		posts, err := digestPosts(ctx, s, database.ListDigestPostsParams{
+			UserID: sub.UserID,
+			Since:  since,
+		})
+
+		// posts check
+		if err != nil {
+			return sent, failed, err
+		}
+
+		// nothing new check, the next one covers these hours too
+		if len(posts) == 0 {
+			continue
+		}
+
+		// build the digest
+		unsubscribe := serveBaseURL(s) + "/digest/unsubscribe?token=" + url.QueryEscape(sub.UnsubscribeToken)
+		message := digest.Build(sub.UserName, posts, unsubscribe)
+
+		// dry run check
+		if dryRun {
+			fmt.Fprintf(s.Stdout, "Would send %q to %s (%s)\n", message.Subject, sub.UserName, sub.Email)
+			continue
+		}
+
+		// claim it, unless another agg just did
+		claimed, err := s.DB.ClaimDigest(ctx, database.ClaimDigestParams{
+			SentAt:   now,
+			UserID:   sub.UserID,
+			Previous: sub.LastSentAt,
+		})
+
+		// claim check
+		if err != nil {
+			return sent, failed, fmt.Errorf("error claiming digest: %w", err)
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		// send it
+		err = mail.Send(opts, mail.Message{
+			To:          sub.Email,
+			Subject:     message.Subject,
+			HTML:        message.HTML,
+			Text:        message.Text,
+			Unsubscribe: unsubscribe,
+		})
+
+		// send check, unclaim so the next try covers the same posts
+		if err != nil {
+			failed++
+			slog.Error("error sending digest", "user", sub.UserName, "err", err)
+			unclaimErr := s.DB.UnclaimDigest(context.WithoutCancel(ctx), database.UnclaimDigestParams{
+				Previous: sub.LastSentAt,
+				UserID:   sub.UserID,
+				SentAt:   now,
+			})
+			if unclaimErr != nil {
+				slog.Error("error unclaiming digest", "user", sub.UserName, "err", unclaimErr)
+			}
+			continue
+		}
+		sent++
+		slog.Info("sent digest", "user", sub.UserName, "posts", len(posts))
+	}
+
+	// return the totals
+	return sent, failed, nil
+}
+
+// get a digest's posts helper, at most digestPostLimit (params' PostLimit is set here)
+func digestPosts(ctx context.Context, s *app.State, params database.ListDigestPostsParams) ([]digest.Post, error) {
+	params.PostLimit = digestPostLimit
+	rows, err := s.DB.ListDigestPosts(ctx, params)
+
+	// posts check
+	if err != nil {
+		return nil, fmt.Errorf("error getting digest posts: %w", err)
+	}
+
+	// convert them
+	posts := make([]digest.Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, digest.Post{
+			Feed:      row.FeedName,
+			Title:     row.Title,
+			URL:       row.Url,
+			Summary:   row.Description.String,
+			Abstract:  row.Summary.String,
+			Published: row.PublishedAt.Time.Local(),
+		})
+	}
+	return posts, nil
+}
+
+// send the due digests from agg helper, every digest_interval if it's set and on users' own schedules (errors are logged, agg keeps going)
+func aggDigests(ctx context.Context, s *app.State) {
+	// interval digests check
+	if s.Config.DigestEvery() > 0 {
+		_, _, err := sendDueDigests(ctx, s, false)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error sending digests", "err", err)
+		}
+	}
+
+	// scheduled digests (digest prefs)
+	_, _, err := sendScheduledDigests(ctx, s, false)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("error sending scheduled digests", "err", err)
+	}
+}
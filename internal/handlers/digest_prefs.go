@@ -0,0 +1,456 @@
+// digest_prefs.go
+package handlers
+
+import (
+	// std go libs
+	"context"       // for context
+	"database/sql"  // for no rows
+	"errors"        // for error handling
+	"fmt"           // print errors
+	"log/slog"      // logging sends
+	"net/url"       // escaping tokens and user names
+	"os"            // writing digest files
+	"path/filepath" // digest file paths
+	"slices"        // channel and list checks
+	"strings"       // folder and tag lists
+	"time"          // schedules
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/digest"   // schedules and building digests
+	"github.com/PietPadda/aggregator/internal/mail"     // the email channel
+	"github.com/PietPadda/aggregator/internal/telegram" // the telegram channel
+)
+
+// where scheduled digests can go
+var digestChannels = []string{"email", "telegram", "file"}
+
+// digest prefs helper
+// NOTE: args will be [] | set [--every daily|weekly] [--day <weekday>] [--at HH:MM] [--tz <zone>] [--via email|telegram|file] [--folders <a,b>|all] [--tags <a,b>|all] | clear
+func digestPrefs(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// show check
+	if len(args) == 0 {
+		prefs, err := s.DB.GetDigestPreference(ctx, user.ID)
+
+		// no preferences check
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintln(s.Stdout, "You have no digest preferences, digests are emailed every digest_interval.")
+			fmt.Fprintln(s.Stdout, "Set a schedule with e.g.: digest prefs set --every weekly --day mon --at 07:00 --via telegram")
+			return nil
+		}
+
+		// get preferences check
+		if err != nil {
+			return fmt.Errorf("error getting digest preferences: %w", err)
+		}
+		return showDigestPrefs(ctx, s, user, prefs)
+	}
+
+	// subcommand check
+	switch args[0] {
+	case "set":
+		return setDigestPrefs(ctx, s, user, args[1:])
+	case "clear":
+		rows, err := s.DB.DeleteDigestPreference(ctx, user.ID)
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error clearing digest preferences: %w", err)
+		}
+		if rows == 0 {
+			fmt.Fprintln(s.Stdout, "You had no digest preferences.")
+			return nil
+		}
+		fmt.Fprintln(s.Stdout, "Cleared your digest preferences, digests are emailed every digest_interval again (if you're subscribed).")
+		return nil
+	}
+	return fmt.Errorf("error: usage: digest prefs [set [--every daily|weekly] [--day <weekday>] [--at HH:MM] [--tz <zone>] [--via email|telegram|file] [--folders <a,b>|all] [--tags <a,b>|all] | clear]")
+}
+
+// digest prefs set helper, changes the given settings (the rest keep their values, or start as daily at 07:00 by email)
+func setDigestPrefs(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// start from the current preferences, or the defaults
+	prefs, err := s.DB.GetDigestPreference(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		prefs = database.DigestPreference{
+			Cadence:    digest.Daily,
+			Weekday:    int32(time.Monday),
+			SendMinute: 7 * 60,
+			Channel:    "email",
+		}
+	} else if err != nil {
+		return fmt.Errorf("error getting digest preferences: %w", err)
+	}
+
+	// strip the flags from the args, in order
+	values := map[string]string{}
+	anySet := false
+	for _, flag := range []string{"--every", "--day", "--at", "--tz", "--via", "--folders", "--tags"} {
+		var value string
+		args, value, err = popFlagValue(args, flag)
+		if err != nil {
+			return err
+		}
+		values[flag] = value
+		anySet = anySet || value != ""
+	}
+
+	// leftover args and nothing to set check
+	if len(args) != 0 || !anySet {
+		return fmt.Errorf("error: usage: digest prefs set [--every daily|weekly] [--day <weekday>] [--at HH:MM] [--tz <zone>] [--via email|telegram|file] [--folders <a,b>|all] [--tags <a,b>|all]")
+	}
+
+	// cadence check
+	if every := values["--every"]; every != "" {
+		if every != digest.Daily && every != digest.Weekly {
+			return fmt.Errorf("error: invalid --every %q (use daily or weekly)", every)
+		}
+		prefs.Cadence = every
+	}
+
+	// weekday check
+	if day := values["--day"]; day != "" {
+		weekday, err := digest.ParseWeekday(day)
+		if err != nil {
+			return err
+		}
+		prefs.Weekday = int32(weekday)
+	}
+
+	// time of day check
+	if at := values["--at"]; at != "" {
+		minute, err := digest.ParseMinute(at)
+		if err != nil {
+			return err
+		}
+		prefs.SendMinute = int32(minute)
+	}
+
+	// time zone check ("local" = agg's)
+	if zone := values["--tz"]; zone != "" {
+		if strings.EqualFold(zone, "local") {
+			zone = ""
+		}
+		if _, err := time.LoadLocation(zone); err != nil {
+			return fmt.Errorf("error: unknown time zone %q (use e.g. Europe/Berlin, UTC or local)", zone)
+		}
+		prefs.Timezone = zone
+	}
+
+	// channel check
+	if via := values["--via"]; via != "" {
+		if !slices.Contains(digestChannels, via) {
+			return fmt.Errorf("error: invalid --via %q (use email, telegram or file)", via)
+		}
+		prefs.Channel = via
+	}
+
+	// folders check (otherwise a typo looks like an empty digest)
+	if folders := values["--folders"]; folders != "" {
+		prefs.Folders = splitList(folders)
+		for _, folder := range prefs.Folders {
+			_, err := s.DB.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: folder})
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("error: no folder named %s (see folder list)", folder)
+			}
+			if err != nil {
+				return fmt.Errorf("error getting folder %s: %w", folder, err)
+			}
+		}
+	}
+
+	// tags check
+	if tags := values["--tags"]; tags != "" {
+		prefs.Tags = splitList(tags)
+	}
+
+	// save them
+	now := time.Now().UTC()
+	err = s.DB.SetDigestPreference(ctx, database.SetDigestPreferenceParams{
+		UserID:     user.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Cadence:    prefs.Cadence,
+		Weekday:    prefs.Weekday,
+		SendMinute: prefs.SendMinute,
+		Timezone:   prefs.Timezone,
+		Channel:    prefs.Channel,
+		Folders:    prefs.Folders,
+		Tags:       prefs.Tags,
+	})
+
+	// save check
+	if err != nil {
+		return fmt.Errorf("error saving digest preferences: %w", err)
+	}
+	return showDigestPrefs(ctx, s, user, prefs)
+}
+
+// show the digest preferences helper, with a note when their channel can't deliver yet
+func showDigestPrefs(ctx context.Context, s *app.State, user database.User, prefs database.DigestPreference) error {
+	// the schedule
+	schedule, err := digestSchedule(prefs.Cadence, prefs.Weekday, prefs.SendMinute, prefs.Timezone)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Digests go out %s, when there are new posts\n", schedule)
+
+	// the channel, and whether it can deliver
+	switch prefs.Channel {
+	case "email":
+		sub, err := s.DB.GetDigestSubscription(ctx, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintln(s.Stdout, "Via: email, but you're not subscribed yet (digest subscribe <email>)")
+		} else if err != nil {
+			return fmt.Errorf("error getting digest subscription: %w", err)
+		} else {
+			fmt.Fprintf(s.Stdout, "Via: email to %s\n", sub.Email)
+		}
+		if _, err := s.Config.SMTPOptions(); err != nil {
+			fmt.Fprintln(s.Stdout, "Note: no smtp server is configured yet (config set smtp.addr and smtp.from)")
+		}
+	case "telegram":
+		chat, err := s.DB.GetTelegramLink(ctx, user.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error getting telegram link: %w", err)
+		}
+		if !chat.ChatID.Valid {
+			fmt.Fprintln(s.Stdout, "Via: telegram, but no chat is linked yet (telegram link)")
+		} else {
+			fmt.Fprintln(s.Stdout, "Via: telegram, to your linked chat")
+		}
+	case "file":
+		dir, err := digestFileDir(s, user.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "Via: html files in %s (on the machine agg runs on)\n", dir)
+	}
+
+	// the scope
+	switch {
+	case prefs.Folders == nil && prefs.Tags == nil:
+		fmt.Fprintln(s.Stdout, "Scope: every followed feed")
+	case prefs.Tags == nil:
+		fmt.Fprintf(s.Stdout, "Scope: folders %s\n", strings.Join(prefs.Folders, ", "))
+	case prefs.Folders == nil:
+		fmt.Fprintf(s.Stdout, "Scope: tags %s\n", strings.Join(prefs.Tags, ", "))
+	default:
+		fmt.Fprintf(s.Stdout, "Scope: folders %s, or tags %s\n", strings.Join(prefs.Folders, ", "), strings.Join(prefs.Tags, ", "))
+	}
+
+	// last sent
+	if prefs.LastSentAt.Valid {
+		fmt.Fprintf(s.Stdout, "Last sent: %s\n", prefs.LastSentAt.Time.Local().Format(time.DateTime))
+	} else {
+		fmt.Fprintln(s.Stdout, "Last sent: never")
+	}
+	return nil
+}
+
+// send the scheduled digests helper, for digest send and agg
+// due = a scheduled time passed since the last one was sent (or since the preferences were set), users without new posts are skipped
+// NOTE: each digest is claimed before it's sent, so two aggs never send the same one
+// returns how many digests were sent and how many failed
+func sendScheduledDigests(ctx context.Context, s *app.State, dryRun bool) (int, int, error) {
+	// get everyone's preferences
+	prefs, err := s.DB.ListDigestPreferences(ctx)
+
+	// preferences check
+	if err != nil {
+		return 0, 0, fmt.Errorf("error listing digest preferences: %w", err)
+	}
+
+	// send each that's due
+	now := time.Now().UTC()
+	sent, failed := 0, 0
+	for _, pref := range prefs {
+		// stopping check
+		if ctx.Err() != nil {
+			return sent, failed, ctx.Err()
+		}
+
+		// schedule check (a time zone may have gone from the system since it was set)
+		schedule, err := digestSchedule(pref.Cadence, pref.Weekday, pref.SendMinute, pref.Timezone)
+		if err != nil {
+			slog.Error("error scheduling digest", "user", pref.UserName, "err", err)
+			continue
+		}
+
+		// unsubscribed check, the unsubscribe link stops email digests on a schedule too
+		if pref.Channel == "email" && !pref.Email.Valid {
+			continue
+		}
+
+		// due check, the first one covers the cadence before it
+		due := schedule.Last(now)
+		since := due.Add(-schedule.Period()).UTC()
+		if pref.LastSentAt.Valid {
+			since = pref.LastSentAt.Time
+		}
+		if !since.Before(due) || (!pref.LastSentAt.Valid && !pref.CreatedAt.Before(due)) {
+			continue
+		}
+
+		// get the posts in scope
+		posts, err := digestPosts(ctx, s, database.ListDigestPostsParams{
+			UserID:  pref.UserID,
+			Since:   since,
+			Folders: pref.Folders,
+			Tags:    pref.Tags,
+		})
+
+		// posts check
+		if err != nil {
+			return sent, failed, err
+		}
+
+		// nothing new check, the next one covers these posts too
+		if len(posts) == 0 {
+			continue
+		}
+
+		// build the digest (emails get an unsubscribe link)
+		unsubscribe := ""
+		if pref.Channel == "email" && pref.UnsubscribeToken.Valid {
+			unsubscribe = serveBaseURL(s) + "/digest/unsubscribe?token=" + url.QueryEscape(pref.UnsubscribeToken.String)
+		}
+		message := digest.Build(pref.UserName, posts, unsubscribe)
+
+		// dry run check
+		if dryRun {
+			fmt.Fprintf(s.Stdout, "Would send %q to %s (via %s)\n", message.Subject, pref.UserName, pref.Channel)
+			continue
+		}
+
+		// claim it, unless another agg just did
+		claimed, err := s.DB.ClaimScheduledDigest(ctx, database.ClaimScheduledDigestParams{
+			SentAt:   now,
+			UserID:   pref.UserID,
+			Previous: pref.LastSentAt,
+		})
+
+		// claim check
+		if err != nil {
+			return sent, failed, fmt.Errorf("error claiming digest: %w", err)
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		// deliver it
+		err = deliverDigest(ctx, s, pref, message, unsubscribe, now)
+
+		// deliver check, unclaim so the next try covers the same posts
+		if err != nil {
+			failed++
+			slog.Error("error sending scheduled digest", "user", pref.UserName, "via", pref.Channel, "err", err)
+			unclaimErr := s.DB.UnclaimScheduledDigest(context.WithoutCancel(ctx), database.UnclaimScheduledDigestParams{
+				Previous: pref.LastSentAt,
+				UserID:   pref.UserID,
+				SentAt:   now,
+			})
+			if unclaimErr != nil {
+				slog.Error("error unclaiming scheduled digest", "user", pref.UserName, "err", unclaimErr)
+			}
+			continue
+		}
+		sent++
+		slog.Info("sent scheduled digest", "user", pref.UserName, "via", pref.Channel, "posts", len(posts))
+	}
+
+	// return the totals
+	return sent, failed, nil
+}
+
+// deliver a scheduled digest through its channel helper
+func deliverDigest(ctx context.Context, s *app.State, pref database.ListDigestPreferencesRow, message digest.Digest, unsubscribe string, now time.Time) error {
+	switch pref.Channel {
+	case "email":
+		// subscribed check, the address comes from digest subscribe
+		if !pref.Email.Valid {
+			return fmt.Errorf("error: not subscribed, there's no address to email (digest subscribe <email>)")
+		}
+		opts, err := s.Config.SMTPOptions()
+		if err != nil {
+			return err
+		}
+		return mail.Send(opts, mail.Message{
+			To:          pref.Email.String,
+			Subject:     message.Subject,
+			HTML:        message.HTML,
+			Text:        message.Text,
+			Unsubscribe: unsubscribe,
+		})
+	case "telegram":
+		// bot and chat check
+		if s.Config.TelegramToken == nil || *s.Config.TelegramToken == "" {
+			return fmt.Errorf("error: no telegram_token configured")
+		}
+		if !pref.ChatID.Valid {
+			return fmt.Errorf("error: no telegram chat linked (telegram link)")
+		}
+		return telegram.New(*s.Config.TelegramToken).SendMessage(ctx, pref.ChatID.Int64, message.Text)
+	case "file":
+		dir, err := digestFileDir(s, pref.UserName)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(dir, 0o700)
+		if err != nil {
+			return fmt.Errorf("error creating digest dir: %w", err)
+		}
+		path := filepath.Join(dir, "digest-"+now.Local().Format("2006-01-02-1504")+".html")
+		err = os.WriteFile(path, []byte(message.HTML), 0o600)
+		if err != nil {
+			return fmt.Errorf("error writing digest: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("error: unknown digest channel %q", pref.Channel)
+}
+
+// get a user's digest schedule helper, "" time zone = agg's local one
+func digestSchedule(cadence string, weekday, minute int32, zone string) (digest.Schedule, error) {
+	location := time.Local
+	if zone != "" {
+		loaded, err := time.LoadLocation(zone)
+		if err != nil {
+			return digest.Schedule{}, fmt.Errorf("error loading time zone %s: %w", zone, err)
+		}
+		location = loaded
+	}
+	return digest.Schedule{
+		Cadence:  cadence,
+		Weekday:  time.Weekday(weekday),
+		Minute:   int(minute),
+		Location: location,
+	}, nil
+}
+
+// get the dir a user's digest files go in helper (~/.gator/digests/<user>)
+func digestFileDir(s *app.State, userName string) (string, error) {
+	dir, err := s.Config.DigestPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, url.PathEscape(userName)), nil
+}
+
+// split a comma separated list helper, "all" (or nothing in it) = no list (nil)
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		// all check
+		if item == "all" {
+			return nil
+		}
+		if item != "" && !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
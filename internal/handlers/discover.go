@@ -0,0 +1,81 @@
+// discover.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"strconv" // parsing the limit
+	"time"    // activity window
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+)
+
+// package-wide constants
+const discoverActivityWindow = 30 * 24 * time.Hour // posts newer than this count as recent activity
+
+// discover handler logic
+// NOTE: cmd will be discover [limit], lists feeds other users follow (that the current user doesn't)
+// ranked by followers, then recent posts, so new users find feeds without knowing urls
+func HandlerDiscover(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// optional limit input, default 10
+	var feedLimit int32 = 10
+	if len(cmd.Args) > 0 {
+		limit, err := strconv.Atoi(cmd.Args[0])
+
+		// limit check
+		if err != nil || limit < 1 {
+			return fmt.Errorf("error: invalid limit %q", cmd.Args[0])
+		}
+		feedLimit = int32(limit)
+	}
+
+	// get the ranked feeds
+	feeds, err := s.DB.DiscoverFeeds(ctx, database.DiscoverFeedsParams{
+		ActiveSince: time.Now().Add(-discoverActivityWindow),
+		UserID:      user.ID,
+		FeedLimit:   feedLimit,
+	})
+
+	// discover check
+	if err != nil {
+		return fmt.Errorf("error discovering feeds: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "url", "followers", "recent_posts"}}
+		for _, feed := range feeds {
+			table.Add(feed.Name, feed.Url, feed.Followers, feed.RecentPosts)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// nothing to discover check
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "Nothing to discover, you already follow every feed other users follow!")
+		return nil
+	}
+
+	// print the feeds
+	fmt.Fprintln(s.Stdout, "Feeds followed by other users:")
+	fmt.Fprintln(s.Stdout) // newline
+	for _, feed := range feeds {
+		fmt.Fprintf(s.Stdout, "Feed name: %s\n", feed.Name)
+		fmt.Fprintf(s.Stdout, "Feed URL: %s\n", feed.Url)
+		fmt.Fprintf(s.Stdout, "Followers: %d, posts in the last 30 days: %d\n", feed.Followers, feed.RecentPosts)
+		fmt.Fprintln(s.Stdout) // newline
+	}
+	fmt.Fprintln(s.Stdout, "Follow one with: follow <feed_url>")
+
+	// return success
+	return nil
+}
@@ -0,0 +1,90 @@
+// enclosures.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"strings" // joining urls
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/google/uuid"                            // for UUID generation
+)
+
+// a post's attachment, as shown by browse --with-enclosures
+type enclosureInfo struct {
+	URL      string `json:"url"`
+	MimeType any    `json:"mime_type"` // null if the feed left it out
+	Length   any    `json:"length"`    // bytes, null if the feed left it out
+}
+
+// text form, eg https://example.com/ep1.mp3 (audio/mpeg, 1234 bytes)
+func (e enclosureInfo) String() string {
+	// collect the known details
+	var details []string
+	if e.MimeType != nil {
+		details = append(details, fmt.Sprint(e.MimeType))
+	}
+	if e.Length != nil {
+		details = append(details, fmt.Sprintf("%d bytes", e.Length))
+	}
+
+	// no details check
+	if len(details) == 0 {
+		return e.URL
+	}
+	return fmt.Sprintf("%s (%s)", e.URL, strings.Join(details, ", "))
+}
+
+// a post's attachments, json encodes as an array of objects
+type enclosureList []enclosureInfo
+
+// csv/tsv cell form, the urls separated by spaces
+func (l enclosureList) String() string {
+	urls := make([]string, len(l))
+	for i, enclosure := range l {
+		urls[i] = enclosure.URL
+	}
+	return strings.Join(urls, " ")
+}
+
+// get the enclosures of a page of posts helper, keyed by post id
+func getEnclosures(ctx context.Context, s *app.State, posts []database.Post) (map[uuid.UUID]enclosureList, error) {
+	// collect the post ids
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	// get their enclosures in one query
+	enclosures, err := s.DB.GetEnclosuresForPosts(ctx, postIDs)
+
+	// get check
+	if err != nil {
+		return nil, fmt.Errorf("error getting enclosures: %w", err)
+	}
+
+	// group them by post (empty, not nil, so json prints [] for posts without any)
+	byPost := make(map[uuid.UUID]enclosureList, len(posts))
+	for _, post := range posts {
+		byPost[post.ID] = enclosureList{}
+	}
+	for _, enclosure := range enclosures {
+		// length NULL check
+		var length any
+		if enclosure.Length.Valid {
+			length = enclosure.Length.Int64
+		}
+
+		byPost[enclosure.PostID] = append(byPost[enclosure.PostID], enclosureInfo{
+			URL:      enclosure.Url,
+			MimeType: nullString(enclosure.MimeType),
+			Length:   length,
+		})
+	}
+
+	// return the enclosures
+	return byPost, nil
+}
@@ -0,0 +1,168 @@
+// epub.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // null folder
+	"fmt"          // print errors
+	"log/slog"     // logging failed extractions
+	"os"           // writing the book
+	"sync"         // fetching articles in parallel
+	"time"         // the book's date
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/epub"     // writing the book
+	"github.com/PietPadda/aggregator/internal/extract"  // full article text
+	"github.com/PietPadda/aggregator/internal/progress" // fetch progress
+	"github.com/google/uuid"                            // the book's id
+)
+
+// digest --epub helper, compiles the user's unread posts into an e-book with a chapter per feed
+// NOTE: each post's full article is fetched and extracted, posts it fails for fall back to their description
+func digestEPUB(ctx context.Context, s *app.State, user database.User, path, folder string) error {
+	// get the unread posts
+	posts, err := s.DB.ListUnreadPostsForUser(ctx, database.ListUnreadPostsForUserParams{
+		UserID:    user.ID,
+		Folder:    sql.NullString{String: folder, Valid: folder != ""},
+		PostLimit: digestPostLimit,
+	})
+
+	// posts check
+	if err != nil {
+		return fmt.Errorf("error getting unread posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Fprintln(s.Stdout, "No unread posts, nothing to put in a book!")
+		return nil
+	}
+
+	// get the user's notes, they go with their posts
+	notes, err := s.DB.ListPostNotes(ctx, database.ListPostNotesParams{UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("error listing notes: %w", err)
+	}
+	postNotes := map[uuid.UUID][]epub.Note{}
+	for _, note := range notes {
+		postNotes[note.PostID] = append(postNotes[note.PostID], epub.Note{Highlight: note.Highlight.String, Text: note.Body})
+	}
+
+	// fetch the full articles
+	articles := extractArticles(ctx, s, posts)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// build the book, a chapter per feed (the posts are ordered by feed)
+	now := time.Now()
+	book := epub.Book{
+		ID:       "urn:uuid:" + uuid.New().String(),
+		Title:    fmt.Sprintf("Gator digest for %s, %s", user.Name, now.Format("Jan 2, 2006")),
+		Author:   "Gator",
+		Language: bookLanguage(posts),
+		Modified: now,
+	}
+	for i, post := range posts {
+		if len(book.Chapters) == 0 || book.Chapters[len(book.Chapters)-1].Title != post.FeedName {
+			book.Chapters = append(book.Chapters, epub.Chapter{Title: post.FeedName})
+		}
+		article := epub.Article{Title: post.Title, URL: post.Url, Blocks: articles[i], Notes: postNotes[post.ID]}
+		if post.PublishedAt.Valid {
+			article.Byline = post.PublishedAt.Time.Local().Format("Jan 2, 2006 15:04")
+		}
+		chapter := &book.Chapters[len(book.Chapters)-1]
+		chapter.Articles = append(chapter.Articles, article)
+	}
+
+	// write it
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", path, err)
+	}
+	err = epub.Write(file, book)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	// write check, no half-written books left behind
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	fmt.Fprintf(s.Stdout, "Wrote %d posts from %d feeds to %s.\n", len(posts), len(book.Chapters), path)
+	return nil
+}
+
+// fetch and extract the posts' articles helper, in parallel (fetch_concurrency at a time)
+// returns each post's text blocks, in the posts' order
+func extractArticles(ctx context.Context, s *app.State, posts []database.ListUnreadPostsForUserRow) [][]extract.Block {
+	articles := make([][]extract.Block, len(posts))
+	bar := progress.New(s.Stderr, len(posts), "articles")
+
+	// workers take posts off the queue
+	queue := make(chan int)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range max(s.Config.FetchConcurrency(), 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				articles[i] = extractArticle(ctx, s, posts[i])
+				done <- struct{}{}
+			}
+		}()
+	}
+
+	// queue every post, then wait for the workers
+	go func() {
+		defer close(queue)
+		for i := range posts {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for range done {
+		bar.Step()
+	}
+	bar.Done()
+	return articles
+}
+
+// one post's text helper, its article, or its description when that can't be had
+func extractArticle(ctx context.Context, s *app.State, post database.ListUnreadPostsForUserRow) []extract.Block {
+	page, err := httpClient(s).FetchPage(ctx, post.Url)
+	if err == nil {
+		if blocks := extract.Article(string(page)); blocks != nil {
+			return blocks
+		}
+		err = fmt.Errorf("no article found in the page")
+	}
+	slog.Warn("using post description, full article unavailable", "url", post.Url, "err", err)
+	return extract.Blocks(post.Description.String)
+}
+
+// the book's language helper, the most common of its posts' (en if none were detected)
+func bookLanguage(posts []database.ListUnreadPostsForUserRow) string {
+	counts := map[string]int{}
+	best := "en"
+	for _, post := range posts {
+		if !post.Language.Valid {
+			continue
+		}
+		counts[post.Language.String]++
+		if counts[post.Language.String] > counts[best] {
+			best = post.Language.String
+		}
+	}
+	return best
+}
@@ -0,0 +1,94 @@
+// fever.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"time"    // created at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/api"      // fever api keys
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/keyring"  // reading passwords without echo
+)
+
+// fever handler logic
+// NOTE: cmd will be fever set-password|disable, manages the current user's access to serve's fever api
+func HandlerFever(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("error: usage: fever set-password | fever disable")
+	}
+
+	// run the subcommand
+	switch cmd.Args[0] {
+	case "set-password":
+		return setFeverPassword(ctx, s, user)
+	case "disable":
+		return disableFever(ctx, s, user)
+	}
+
+	// unknown subcommand
+	return fmt.Errorf("error: unknown fever subcommand %q (use set-password or disable)", cmd.Args[0])
+}
+
+// fever set-password helper
+func setFeverPassword(ctx context.Context, s *app.State, user database.User) error {
+	// ask for the password (fever clients log in with the user name and it)
+	password, err := keyring.ReadPassword(fmt.Sprintf("Fever password for %s: ", user.Name))
+
+	// read check
+	if err != nil {
+		return err
+	}
+
+	// empty password check
+	if password == "" {
+		return fmt.Errorf("error: password is empty")
+	}
+
+	// store the hashed api key
+	err = s.DB.UpsertFeverAccount(ctx, database.UpsertFeverAccountParams{
+		UserID:     user.ID,
+		CreatedAt:  time.Now(),
+		ApiKeyHash: api.HashFeverAPIKey(api.FeverAPIKey(user.Name, password)),
+	})
+
+	// upsert check
+	if err != nil {
+		return fmt.Errorf("error saving fever password: %w", err)
+	}
+
+	// tell the user how to connect
+	fmt.Fprintf(s.Stdout, "Fever access enabled for %s.\n", user.Name)
+	fmt.Fprintf(s.Stdout, "Run serve, then log in from your reader with the url http://<host>:<port>/fever/, user %s and this password.\n", user.Name)
+	return nil
+}
+
+// fever disable helper
+func disableFever(ctx context.Context, s *app.State, user database.User) error {
+	// delete the api key
+	deleted, err := s.DB.DeleteFeverAccount(ctx, user.ID)
+
+	// delete check
+	if err != nil {
+		return fmt.Errorf("error disabling fever access: %w", err)
+	}
+
+	// not enabled check
+	if deleted == 0 {
+		fmt.Fprintf(s.Stdout, "Fever access wasn't enabled for %s.\n", user.Name)
+		return nil
+	}
+
+	// success
+	fmt.Fprintf(s.Stdout, "Fever access disabled for %s.\n", user.Name)
+	return nil
+}
@@ -0,0 +1,171 @@
+// folder.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // for sql errors
+	"errors"       // for error handling
+	"fmt"          // print errors
+	"strings"      // filter text in strs
+	"time"         // created/updated at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+	"github.com/google/uuid"                            // for UUID generation
+)
+
+// folder handler logic
+// NOTE: cmd will be folder create|move|list, folders group the current user's follows (News, Go, Podcasts)
+func HandlerFolder(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("error: usage: folder create <name> | folder move <feed_url> <name|none> | folder list")
+	} // folder handler expects a subcommand, and its args!
+
+	// run the subcommand
+	switch cmd.Args[0] {
+	case "create":
+		// name input check
+		if len(cmd.Args) < 2 {
+			return fmt.Errorf("error: folder name required")
+		}
+		return createFolder(ctx, s, user, cmd.Args[1])
+	case "move":
+		// url and name input check
+		if len(cmd.Args) < 3 {
+			return fmt.Errorf("error: feed url and folder name required")
+		}
+		return moveToFolder(ctx, s, user, cmd.Args[1], cmd.Args[2])
+	case "list":
+		return listFolders(ctx, s, user)
+	}
+
+	// unknown subcommand
+	return fmt.Errorf("error: unknown folder subcommand %q (use create, move or list)", cmd.Args[0])
+}
+
+// folder create helper
+func createFolder(ctx context.Context, s *app.State, user database.User, name string) error {
+	// reserved name check, "none" takes a feed out of its folder
+	if name == "none" {
+		return fmt.Errorf("error: folder name none is reserved")
+	}
+
+	// create new folder in database
+	currentTime := time.Now()
+	folder, err := s.DB.CreateFolder(ctx, database.CreateFolderParams{
+		ID:        uuid.New(),  // set id to new UUID
+		CreatedAt: currentTime, // set created at to current time
+		UpdatedAt: currentTime, // set updated at to current time
+		Name:      name,        // set name to folder name
+		UserID:    user.ID,     // set user id to current user
+	})
+
+	// create check
+	if err != nil {
+		// check if unique (per user)
+		if strings.Contains(err.Error(), "unique constraint") {
+			return fmt.Errorf("error: you already have a folder named %s", name)
+		}
+		return fmt.Errorf("error creating folder: %w", err)
+	}
+
+	// print confirmation msg to user
+	fmt.Fprintf(s.Stdout, "Folder '%s' created!\n", folder.Name)
+
+	// return success
+	return nil
+}
+
+// folder move helper, "none" takes the feed out of its folder
+func moveToFolder(ctx context.Context, s *app.State, user database.User, feedURL, name string) error {
+	// NULL folder = no folder
+	var folderID uuid.NullUUID
+
+	// folder name check
+	if name != "none" {
+		// get the folder by name
+		folder, err := s.DB.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: name})
+
+		// folder exists check
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: no folder named %s (create it with folder create)", name)
+		}
+
+		// get check
+		if err != nil {
+			return fmt.Errorf("error getting folder %s: %w", name, err)
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	// move the follow
+	rows, err := s.DB.MoveFeedFollowToFolder(ctx, database.MoveFeedFollowToFolderParams{
+		FolderID: folderID,
+		Url:      feedURL,
+		UserID:   user.ID,
+	})
+
+	// move check
+	if err != nil {
+		return fmt.Errorf("error moving feed: %w", err)
+	}
+
+	// following check (0 rows = not following that url)
+	if rows == 0 {
+		return fmt.Errorf("error: you are not following %s", feedURL)
+	}
+
+	// print confirmation msg to user
+	if !folderID.Valid {
+		fmt.Fprintf(s.Stdout, "%s removed from its folder\n", feedURL)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "%s moved to folder '%s'\n", feedURL, name)
+
+	// return success
+	return nil
+}
+
+// folder list helper
+func listFolders(ctx context.Context, s *app.State, user database.User) error {
+	// get the user's folders
+	folders, err := s.DB.ListFoldersForUser(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing folders: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "feeds"}}
+		for _, folder := range folders {
+			table.Add(folder.Name, folder.Feeds)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no folders check
+	if len(folders) == 0 {
+		fmt.Fprintln(s.Stdout, "No folders yet, create one with: folder create <name>")
+		return nil
+	}
+
+	// print the folders
+	fmt.Fprintf(s.Stdout, "Folders of %s:\n", user.Name)
+	for _, folder := range folders {
+		fmt.Fprintf(s.Stdout, "* %s (%d feeds)\n", folder.Name, folder.Feeds)
+	}
+
+	// return success
+	return nil
+}
@@ -3,32 +3,51 @@ package handlers
 
 import (
 	// std go libs
+	"bufio"        // reading confirmations
 	"context"      // for context
 	"database/sql" // for sql errors
 	"errors"       // for error handling
 	"fmt"          // print errors
 	"html"
-	"os" // for file reading/writing
+	"io"       // for EOF on confirmations
+	"log/slog" // structured logging
+	"math"     // int32 interval bounds
+	"os"       // for file reading/writing
 	"strconv"
 	"strings" // filter text in strs
+	"sync"    // parallel feed fetches
 	"time"    // context timeout
 
 	// internal packages
-	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
-	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
-	"github.com/PietPadda/aggregator/internal/rssfeed"  // for RSS feed fetching
-	"github.com/araddon/dateparse"                      // for publication date of post parsing
-	"github.com/google/uuid"                            // for UUID generation
-	"github.com/lib/pq"
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/database"  // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/dialect"   // for retryable db errors
+	"github.com/PietPadda/aggregator/internal/lang"      // for post language detection
+	"github.com/PietPadda/aggregator/internal/logging"   // for the trace log level
+	"github.com/PietPadda/aggregator/internal/notify"    // for new posts notifications
+	"github.com/PietPadda/aggregator/internal/output"    // for --output formats
+	"github.com/PietPadda/aggregator/internal/progress"  // for bulk progress bars
+	"github.com/PietPadda/aggregator/internal/readtime"  // for post reading times
+	"github.com/PietPadda/aggregator/internal/rssfeed"   // for RSS feed fetching
+	"github.com/PietPadda/aggregator/internal/rules"     // for the users' post rules
+	"github.com/PietPadda/aggregator/internal/scheduler" // for per-feed due times
+	"github.com/PietPadda/aggregator/internal/systemd"   // for systemd readiness and watchdog
+	"github.com/PietPadda/aggregator/internal/urlnorm"   // for feed url normalization
+	"github.com/araddon/dateparse"                       // for publication date of post parsing
+	"github.com/google/uuid"                             // for UUID generation
 )
 
+// package-wide constants
+const progressThreshold = 5 // min due feeds before agg shows catch-up progress
+const scrapeAttempts = 3    // tries per feed when the database asks for a retry
+
 // MIDDLEWARE
 
 // used to replace GetUser() and error checking inside handler command functions
 // PascalCase to export to main.go (as opposed to camelCase for package only)
 // NOTE: database.User --> database package has file models.go with User struct
-func MiddlewareLoggedIn(handler func(s *app.State, cmd app.Command, user database.User) error) func(*app.State, app.Command) error {
-	return func(s *app.State, cmd app.Command) error {
+func MiddlewareLoggedIn(handler func(ctx context.Context, s *app.State, cmd app.Command, user database.User) error) func(context.Context, *app.State, app.Command) error {
+	return func(ctx context.Context, s *app.State, cmd app.Command) error {
 		// state check
 		if s == nil {
 			return fmt.Errorf("error: State is nil")
@@ -52,7 +71,7 @@ func MiddlewareLoggedIn(handler func(s *app.State, cmd app.Command, user databas
 		// currentUser := *s.Config.Name
 
 		// get current user struct
-		user, err := s.DB.GetUser(context.Background(), *s.Config.Name)
+		user, err := s.DB.GetUser(ctx, *s.Config.Name)
 
 		// getuser check
 		if err != nil {
@@ -60,7 +79,7 @@ func MiddlewareLoggedIn(handler func(s *app.State, cmd app.Command, user databas
 		}
 
 		// return the handler command from Handler map in config, in state
-		return handler(s, cmd, user)
+		return handler(ctx, s, cmd, user)
 		// NOTE: we choose the name as "handler"
 	}
 }
@@ -69,29 +88,22 @@ func MiddlewareLoggedIn(handler func(s *app.State, cmd app.Command, user databas
 
 // login handler logic
 // NOTE: cmd will be login, and state holds the config file to "sign in" the user
-func HandlerLogin(s *app.State, cmd app.Command) error {
+func HandlerLogin(ctx context.Context, s *app.State, cmd app.Command) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
 	}
 
-	// cmd input check
-	// command is a struct, get its field for length check
-	if len(cmd.Args) == 0 {
-		return fmt.Errorf("error: no command input")
-	} // login handler expects ONE arg: the username!
-
-	// get username input (first arg!)
+	// get username input (first arg! Run checked there's exactly one)
 	username := cmd.Args[0] // not needed, but nicely readable!
 
 	// check if user already exists in database
-	_, err := s.DB.GetUser(context.Background(), username)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	user, err := s.DB.GetUser(ctx, username)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// user exists check
 	if errors.Is(err, sql.ErrNoRows) {
-		fmt.Printf("error: user '%s' doesn't exist\n", username)
-		os.Exit(1) // clean exit
+		return fmt.Errorf("error: user '%s' doesn't exist", username)
 	}
 	// errors.Is sql.ErrNoRows > err = sql.ErrNoRows
 	// why? it includes wrapped errors, the error returned may not match exactly!
@@ -102,6 +114,12 @@ func HandlerLogin(s *app.State, cmd app.Command) error {
 	}
 	// this is a generic error check, if it isn't sql.ErrNoRows, then it's something else
 
+	// password check, users with one (see passwd) must give it
+	_, err = checkPassword(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
 	// use state to access config and set username
 	err = s.Config.SetUser(username)
 	// apply method to config file, which is contained in state
@@ -112,7 +130,7 @@ func HandlerLogin(s *app.State, cmd app.Command) error {
 	}
 
 	// print confirmation msg to user
-	fmt.Printf("User '%s' has successfully logged in!\n", username)
+	fmt.Fprintf(s.Stdout, "User '%s' has successfully logged in!\n", username)
 
 	// return success
 	return nil
@@ -120,19 +138,13 @@ func HandlerLogin(s *app.State, cmd app.Command) error {
 
 // register handler logic
 // NOTE: cmd will be register, and state holds the config file to "register" new user if he doesn't exist
-func HandlerRegister(s *app.State, cmd app.Command) error {
+func HandlerRegister(ctx context.Context, s *app.State, cmd app.Command) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
 	}
 
-	// cmd input check
-	// command is a struct, get its field for length check
-	if len(cmd.Args) == 0 {
-		return fmt.Errorf("error: no command input")
-	} // login handler expects ONE arg: the username!
-
-	// get username input (first arg!)
+	// get username input (first arg! Run checked there's exactly one)
 	username := cmd.Args[0] // not needed, but nicely readable!
 
 	// get user id as UUID and timestamp for created/updated at fields
@@ -183,8 +195,8 @@ func HandlerRegister(s *app.State, cmd app.Command) error {
 	} */
 
 	// check if user already exists in database
-	_, err := s.DB.GetUser(context.Background(), username)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	_, err := s.DB.GetUser(ctx, username)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// general check that's NOT checking if users exists
 	if err != nil && !errors.Is(err, sql.ErrNoRows) { // check if err is NOT sql.ErrNoRows
@@ -196,14 +208,13 @@ func HandlerRegister(s *app.State, cmd app.Command) error {
 
 	// user exits check
 	if err == nil {
-		fmt.Printf("error: user '%s' exists\n", username)
-		os.Exit(1) // clean exit
+		return fmt.Errorf("error: user '%s' exists", username)
 	}
 
 	// user doesn't exist, so we can make a new user
 	// create/register new user in database
 
-	user, err := s.DB.CreateUser(context.Background(), database.CreateUserParams{
+	user, err := s.DB.CreateUser(ctx, database.CreateUserParams{
 		ID:        id,          // set id to UUID
 		CreatedAt: currentTime, // set created at to current time
 		UpdatedAt: currentTime, // set updated at to current time
@@ -212,13 +223,30 @@ func HandlerRegister(s *app.State, cmd app.Command) error {
 	// CreateUser is a method from DB pass through state s (we made using users.sql)
 	// CreateUserParams is a struct that was genned in database package
 	// could do "_, err := ..." but we need user for printing confirmation msg
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// user registration check
 	if err != nil {
+		// unique name check, GetUser above skips soft deleted users, so the name belongs to one
+		if strings.Contains(err.Error(), "unique constraint") {
+			return fmt.Errorf("error: user '%s' was deleted, restore them with: undelete user %s", username, username)
+		}
 		return fmt.Errorf("error registering user: %w", err)
 	}
 
+	// first user check, with no admins yet they become the first
+	admins, err := s.DB.CountAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("error counting admins: %w", err)
+	}
+	if admins == 0 {
+		_, err = s.DB.SetUserAdmin(ctx, database.SetUserAdminParams{Name: username, IsAdmin: true})
+		if err != nil {
+			return fmt.Errorf("error making the first user an admin: %w", err)
+		}
+		user.IsAdmin = true
+	}
+
 	// use state to access config and set username
 	err = s.Config.SetUser(username)
 	// apply method to config file, which is contained in state
@@ -229,9 +257,12 @@ func HandlerRegister(s *app.State, cmd app.Command) error {
 	}
 
 	// print confirmation msg to user + log user details
-	fmt.Printf("User '%s' has successfully been registered!\n", username)                     // confirmation msg
-	fmt.Printf("User details:\n  ID = %s\n  CreatedAt = %s\n  UpdatedAt = %s\n  Name = %s\n", // log user details
+	fmt.Fprintf(s.Stdout, "User '%s' has successfully been registered!\n", username)                     // confirmation msg
+	fmt.Fprintf(s.Stdout, "User details:\n  ID = %s\n  CreatedAt = %s\n  UpdatedAt = %s\n  Name = %s\n", // log user details
 		user.ID, user.CreatedAt, user.UpdatedAt, user.Name)
+	if user.IsAdmin {
+		fmt.Fprintln(s.Stdout, "As the first user, they're an admin (see admin promote).")
+	}
 
 	// return success
 	return nil
@@ -240,11 +271,10 @@ func HandlerRegister(s *app.State, cmd app.Command) error {
 // reset handler logic
 // NOTE: cmd will be reset, and state holds the config file to "reset" the users table
 // NOTE: this is a dangerous command, so be careful with it! (for production code! but for our little app, it's fine)
-func HandlerReset(s *app.State, cmd app.Command) error {
+func HandlerReset(ctx context.Context, s *app.State, cmd app.Command) error {
 	// state ptr check
 	if s == nil {
-		fmt.Printf("error: State is nil")
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error: State is nil")
 	}
 
 	/* Note: the method that SQLC generated
@@ -255,33 +285,92 @@ func HandlerReset(s *app.State, cmd app.Command) error {
 		return err
 	} */
 
+	// strip the reset flags from the args
+	args, dryRun := popFlag(cmd.Args, "--dry-run")
+	args, force := popFlag(args, "--force")
+	_, backupPath, err := popFlagValue(args, "--backup")
+
+	// flags check
+	if err != nil {
+		return err
+	}
+
+	// dry-run check (print what would be deleted, but don't delete it!)
+	if dryRun {
+		// count everything the reset would delete
+		counts, err := s.DB.CountResetRows(ctx)
+
+		// count check
+		if err != nil {
+			return fmt.Errorf("error counting rows to reset: %w", err)
+		}
+
+		// print what would be deleted
+		fmt.Fprintln(s.Stdout, "Dry run: reset would delete")
+		fmt.Fprintf(s.Stdout, "  %d users\n", counts.Users)
+		fmt.Fprintf(s.Stdout, "  %d feeds\n", counts.Feeds)
+		fmt.Fprintf(s.Stdout, "  %d feed follows\n", counts.FeedFollows)
+		fmt.Fprintf(s.Stdout, "  %d posts\n", counts.Posts)
+
+		// return success
+		return nil
+	}
+
+	// admin check, reset deletes everyone's data
+	err = requireAdmin(ctx, s, "reset")
+	if err != nil {
+		return err
+	}
+
+	// confirmation check (unless forced, eg in scripts)
+	if !force {
+		// ask the user to confirm
+		confirmed, err := confirm(s, "This will permanently delete ALL users, feeds, follows and posts. Type 'yes' to continue: ")
+
+		// confirm check
+		if err != nil {
+			return fmt.Errorf("error reading confirmation: %w", err)
+		}
+
+		// not confirmed? don't touch anything!
+		if !confirmed {
+			fmt.Fprintln(s.Stdout, "Reset cancelled.")
+			return nil
+		}
+	}
+
+	// backup check (dump the tables BEFORE we delete them!)
+	if backupPath != "" {
+		err := writeResetBackup(ctx, s.Conn, backupPath)
+
+		// backup check (no backup = no reset!)
+		if err != nil {
+			return fmt.Errorf("error backing up database, nothing was reset: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Database backed up to %s\n", backupPath)
+	}
+
 	// run the reset command
-	err := s.DB.Reset(context.Background())
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	err = s.DB.Reset(ctx)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// reset check
 	if err != nil {
-		fmt.Printf("error resetting database: %s\n", err)
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error resetting database: %w", err)
 	}
 
-	// success with code 0
-	fmt.Printf("Database successfully reset!\n")
-	os.Exit(0) // clean exit code 0
-
-	// return success
+	// success
+	fmt.Fprintf(s.Stdout, "Database successfully reset!\n")
 	return nil
-	// this will never be reached, but it's here for the requirement of the Register function
 	// and to make the function complete
 }
 
 // getusers handler logic
 // NOTE: cmd will be users, and state holds the config file to "users" from users table, also showing "current" user
-func HandlerGetUsers(s *app.State, cmd app.Command) error {
+func HandlerGetUsers(ctx context.Context, s *app.State, cmd app.Command) error {
 	// state ptr check
 	if s == nil {
-		fmt.Printf("error: State is nil")
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error: State is nil")
 	}
 
 	/* Note: the method that SQLC generated
@@ -311,25 +400,39 @@ func HandlerGetUsers(s *app.State, cmd app.Command) error {
 	} */
 
 	// run the getusers command
-	users, err := s.DB.GetUsers(context.Background())
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	users, err := s.DB.GetUsers(ctx)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// getusers check
 	if err != nil {
-		fmt.Printf("error returning registered users from database: %s\n", err)
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error returning registered users from database: %w", err)
+	}
+
+	// get current user for marking (nil = nobody logged in)
+	currentName := ""
+	if s.Config.Name != nil {
+		currentName = *s.Config.Name
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		// build table of users
+		table := output.Table{Columns: []string{"name", "current"}}
+		for _, user := range users {
+			table.Add(user, user == currentName)
+		}
+		return output.Write(s.Stdout, s.Output, table)
 	}
 
 	// no users check
 	if len(users) == 0 {
-		fmt.Printf("No users registered in database!\n")
-		os.Exit(0) // clean exit code 0
+		fmt.Fprintf(s.Stdout, "No users registered in database!\n")
+		return nil
 	}
 
 	// nil current user check
 	if s.Config.Name == nil {
-		fmt.Printf("error: current user is nil\n")
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error: current user is nil")
 	}
 
 	// get current user (safely deref after checking nil ptr)
@@ -339,37 +442,147 @@ func HandlerGetUsers(s *app.State, cmd app.Command) error {
 	for _, user := range users {
 		// check if current user
 		if user == currentUser {
-			fmt.Printf("* %s (current)\n", user)
+			fmt.Fprintf(s.Stdout, "* %s (current)\n", user)
 			continue // skip to next user (else we print it twice)
 		}
-		fmt.Printf("* %s\n", user)
+		fmt.Fprintf(s.Stdout, "* %s\n", user)
 	}
 	// succesfully printed users
-	os.Exit(0) // clean exit code 0
-
-	// return success
 	return nil
-	// this will never be reached, but it's here for the requirement of the Register function
 	// and to make the function complete
 }
 
 // agg handler logic
 // NOTE: cmd will be agg, and state holds the config file, it will "agg"regate the RSSFeed
 // FetchFeed handles the error checking and parsing of the RSS feed
-func HandlerAgg(s *app.State, cmd app.Command) error {
+func HandlerAgg(ctx context.Context, s *app.State, cmd app.Command) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
 	}
 
+	// control subcommands check, they talk to the running agg instead of being one
+	if len(cmd.Args) == 1 && cmd.Args[0] == "status" {
+		return aggStatusCmd(s)
+	}
+	if len(cmd.Args) == 1 && cmd.Args[0] == "stop" {
+		return aggStopCmd(ctx, s)
+	}
+	if len(cmd.Args) == 1 && cmd.Args[0] == "reload" {
+		return aggReloadCmd(s)
+	}
+	if len(cmd.Args) == 1 && cmd.Args[0] == "workers" {
+		return aggWorkersCmd(ctx, s)
+	}
+
+	// strip the optional force flag from the args (allows intervals below agg_min_interval)
+	args, force := popFlag(cmd.Args, "--force")
+
+	// strip the optional take over flag from the args (the pid of the agg lock's holder to end, as agg printed it)
+	args, takeOverInput, err := popFlagValue(args, "--take-over")
+
+	// take over flag check
+	if err != nil {
+		return err
+	}
+	takeOver := 0
+	if takeOverInput != "" {
+		takeOver, err = strconv.Atoi(takeOverInput)
+		if err != nil || takeOver < 1 {
+			return fmt.Errorf("error: invalid --take-over %q (use the postgres pid of the agg holding the lock)", takeOverInput)
+		}
+	}
+
+	// strip the optional daemon flag from the args
+	args, daemon := popFlag(args, "--daemon")
+
+	// strip the optional worker flag from the args (share the feeds with other agg --worker, eg on other machines)
+	args, shared := popFlag(args, "--worker")
+
+	// strip the optional one-shot flag from the args (anywhere, eg agg --force --once)
+	args, once := popFlag(args, "--once")
+
+	// strip the optional batch flag from the args
+	args, batchInput, err := popFlagValue(args, "--batch")
+
+	// batch flag check
+	if err != nil {
+		return err
+	}
+
+	// strip the optional profiling flags from the args (written when agg stops)
+	args, profiles, err := popProfileFlags(args)
+
+	// profiling flags check
+	if err != nil {
+		return err
+	}
+
+	// feeds per tick, --batch wins over agg_batch_size from the config (0 = all due feeds)
+	batch, err := aggBatchSize(s, batchInput)
+
+	// batch size check
+	if err != nil {
+		return err
+	}
+
+	// one-shot mode check (for cron jobs instead of a long-lived process)
+	if once {
+		// args check, --once fetches what's due, there's no interval to use
+		if len(args) > 0 {
+			return fmt.Errorf("error: --once takes no duration, got %q (usage: agg --once or agg <duration>)", strings.Join(args, " "))
+		}
+
+		// daemon check, there's nothing to keep running
+		if daemon {
+			return fmt.Errorf("error: --daemon can't be combined with --once")
+		}
+
+		// take the agg lock, so an overlapping cron run (or a running agg) fails fast
+		lock, err := acquireAggLock(ctx, s, takeOver, shared)
+
+		// lock check
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		// register as a worker, so our jobs are reassigned if we die
+		worker, err := startAggWorker(ctx, s)
+
+		// worker check
+		if err != nil {
+			return err
+		}
+		defer worker.Stop(ctx, s)
+
+		// profiles check, if asked for
+		err = profiles.start()
+		if err != nil {
+			return err
+		}
+		defer profiles.stop()
+
+		return aggOnce(ctx, s, worker, batch)
+	}
+
+	// default interval check, agg_interval from the config lets agg run without args
+	intervalFromConfig := len(args) < 1 && s.Config.AggInterval != nil
+	if intervalFromConfig {
+		args = []string{*s.Config.AggInterval} // follows config reloads too
+	}
+
 	// cmd input check
 	// command is a struct, get its field for length check
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("error: time between requests required")
-	} // agg handler expects ONE arg: time_between_reqs!!
+	if len(args) < 1 {
+		return fmt.Errorf("error: time between requests or --once required (or set agg_interval with: config set agg_interval 10m)")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("error: agg takes one duration, got %q (usage: agg <duration> or agg --once)", strings.Join(args, " "))
+	} // agg handler expects ONE arg: time_between_reqs OR --once!!
 
 	// get arguments input
-	timeInput := cmd.Args[0] // not needed, but nicely readable!
+	timeInput := args[0] // not needed, but nicely readable!
 
 	// parse the time duration string
 	timeBetweenRequests, err := time.ParseDuration(timeInput)
@@ -379,41 +592,184 @@ func HandlerAgg(s *app.State, cmd app.Command) error {
 		return fmt.Errorf("error: invalid duration format: %w", err)
 	}
 
-	// init the infinite loop time.Ticker()
-	timeTicker := time.NewTicker(timeBetweenRequests)
-	defer timeTicker.Stop() // stop the ticker when command ends
-	// CORE: it will NEVER end because it's an infinite loop!
-	// but good practice to add in anyway
+	// get the safety floor
+	minInterval, err := s.Config.MinAggInterval()
+
+	// min interval check
+	if err != nil {
+		return err
+	}
+
+	// safety floor check, a tiny interval hammers every feed host (--force if you really mean it)
+	if timeBetweenRequests < minInterval && !force {
+		return fmt.Errorf("error: interval %s is below the %s minimum (agg_min_interval), fetching that often can hammer feed hosts; pass --force to run anyway", timeBetweenRequests, minInterval)
+	}
+
+	// daemon check, run this same agg in the background instead (it checks the lock itself)
+	if daemon {
+		return startAggDaemon(ctx, s)
+	}
+
+	// take the agg lock, so a second agg on this database fails fast (unless they're all workers)
+	lock, err := acquireAggLock(ctx, s, takeOver, shared)
+
+	// lock check
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// register as a worker, heartbeating so our jobs are reassigned if we die
+	worker, err := startAggWorker(ctx, s)
+
+	// worker check
+	if err != nil {
+		return err
+	}
+	defer worker.Stop(ctx, s)
+
+	// a cancel of our own, so agg stop ends the loop just like ctrl+c
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	// answer agg status and agg stop, and write the pid file
+	started := time.Now()
+	control, err := startAggControl(s, aggStatus{
+		PID:         os.Getpid(),
+		Started:     started,
+		Interval:    timeBetweenRequests.String(),
+		Batch:       batch,
+		Concurrency: worker.limiter.Limit(),
+		State:       "fetching",
+	}, stop)
+
+	// control check
+	if err != nil {
+		return err
+	}
+	defer control.Close()
+
+	// watch the config, so interval, http settings and log level changes apply without a restart
+	// agg reload (and SIGHUP) reload it right away, and check for new feeds and intervals too
+	reloads := watchConfig(ctx, s, control.reloads)
+
+	// pprof check, if pprof_addr is set
+	err = startPprof(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	// profiles check, if asked for (the daemon's child profiles, not the parent)
+	err = profiles.start()
+	if err != nil {
+		return err
+	}
+	defer profiles.stop()
+
+	// make sure the database answers before telling systemd we're up
+	err = s.Conn.PingContext(ctx)
+
+	// ping check
+	if err != nil {
+		return fmt.Errorf("error connecting to the database: %w", err)
+	}
+
+	// under systemd (Type=notify), say we're ready and ping the watchdog while feeds get fetched
+	watchdog := startAggWatchdog(ctx)
+	sdNotify(systemd.Ready, systemd.Status(fmt.Sprintf("collecting feeds every %s", timeBetweenRequests)))
 
 	// inform user of the time interval
-	fmt.Printf("Collecting feeds every %v\n", timeBetweenRequests)
+	slog.Info("collecting feeds", "interval", timeBetweenRequests, "batch", batch, "note", "feeds with their own interval use it instead")
+
+	// session totals, for the summary when agg stops
+	fetched, failed := 0, 0
 
-	// start an infinite loop with a time.Ticker()
+	// start an infinite loop driven by the scheduler
 	for {
-		// scrape the feeds immediately!
-		err = scrapeFeeds(s.DB)
+		// stopping check (ctrl+c, SIGTERM or --timeout), interrupted fetches were rolled back and stay due
+		if ctx.Err() != nil {
+			sdNotify(systemd.Stopping)
+			slog.Info("agg stopped", "fetched", fetched, "failed", failed, "ran", time.Since(started).Round(time.Second))
+
+			// a signal is how agg is meant to stop, a --timeout still says so
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		}
 
-		// scrape feeds check
+		// still ours check, stop if another agg took over with --take-over
+		err = lock.Held(ctx)
+		if ctx.Err() != nil {
+			continue // stopping meanwhile, not a lost lock
+		}
 		if err != nil {
-			fmt.Printf("error scraping the feeds: %s\n", err)
+			return err
 		}
 
-		// block the loop and wait until ticker TICKS!
-		<-timeTicker.C // ticker runs on it's own channel called C
-		// <- = reads from the ticker channel, which sends the current time at regular intervals
-		// we set the "tick" to be timeBetweenRequests
-	}
+		// scrape whatever feeds are due immediately!
+		control.update(func(status *aggStatus) { status.State, status.NextRun = "fetching", time.Time{} })
+		watchdog.Busy()
+		due, dueFailed, err := scrapeDueFeeds(ctx, s, worker, timeBetweenRequests, batch, watchdog.Progress)
+		watchdog.Idle()
+		fetched += due - dueFailed
+		failed += dueFailed
+		control.update(func(status *aggStatus) {
+			status.Fetched, status.Failed, status.LastRun = fetched, failed, time.Now()
+			status.Concurrency = worker.limiter.Limit()
+			status.Stages = worker.stages.Snapshot()
+			status.OpenHosts = worker.breakers.openCount(time.Now())
+		})
 
-	// return success
-	return nil
-	// this UNREACHABLE code, as we have an infinite loop that only ends on ctrl+c!!!!
-	// just formality as function returns an error value
+		// scrape feeds check
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error scraping the feeds", "err", err)
+		}
+
+		// summarize the new posts, if summarize.provider is set (before the digests, so they have them)
+		aggSummaries(ctx, s)
+
+		// send the digests that are due, if digest_interval is set
+		aggDigests(ctx, s)
+
+		// push the new posts of flagged follows, if anyone set up notifications
+		aggNotifications(ctx, s)
+
+		// send the usage report when it's due, if telemetry is on
+		aggTelemetry(ctx, s)
+
+		// ask the scheduler how long until the next feed is due
+		wait, err := nextWake(ctx, s.DB, timeBetweenRequests)
+
+		// next wake check
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error scheduling the next fetch", "err", err)
+			wait = timeBetweenRequests // fall back to the global interval
+		}
+
+		// log when we'll wake up next
+		slog.Debug("waiting for next due feed", "wait", wait)
+		sdNotify(systemd.Status(fmt.Sprintf("fetched %d feeds (%d failed), next run in %s", fetched, failed, wait.Round(time.Second))))
+		control.update(func(status *aggStatus) {
+			status.State, status.NextRun = "waiting", time.Now().Add(wait)
+		})
+
+		// block the loop and wait until the next feed is due (or ctrl+c!)
+		select {
+		case <-time.After(wait): // After runs on it's own channel, and sends once the wait is over
+		case cfg := <-reloads: // config changed or reload asked, apply it and check what's due again (new feeds too)
+			timeBetweenRequests = applyConfig(s, cfg, timeBetweenRequests, intervalFromConfig, force)
+			control.update(func(status *aggStatus) { status.Interval = timeBetweenRequests.String() })
+		case <-ctx.Done(): // ctx is cancelled on ctrl+c, SIGTERM or --timeout, the loop top stops
+		}
+	}
+	// NOTE: no return needed, we have an infinite loop that only ends on ctrl+c!!!!
 }
 
 // addfeed handler logic
 // NOTE: cmd will be addfeed, and state holds the config file, it will add a new feed to the database
 // now use middleware to provide user as input! not more GetUser()!
-func HandlerAddFeed(s *app.State, cmd app.Command, user database.User) error {
+func HandlerAddFeed(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
@@ -427,17 +783,67 @@ func HandlerAddFeed(s *app.State, cmd app.Command, user database.User) error {
 
 	// get arguments input
 	feedName := cmd.Args[0] // not needed, but nicely readable!
-	feedURL := cmd.Args[1]  // not needed, but nicely readable!
+
+	// check the url, it's fetched as given (its normalized form is only the url_key, for spotting duplicates)
+	feedURL, err := urlnorm.Clean(cmd.Args[1])
+
+	// url check
+	if err != nil {
+		return err
+	}
+
+	// optional refresh interval (NULL = use the agg interval)
+	var refreshInterval sql.NullInt32
+
+	// interval input check
+	if len(cmd.Args) > 2 {
+		// parse the interval using helper
+		interval, err := parseRefreshInterval(cmd.Args[2])
+
+		// parse check
+		if err != nil {
+			return err
+		}
+		refreshInterval = interval
+	}
 
 	// nil current user check
 	if s.Config.Name == nil {
 		return fmt.Errorf("error: current user is nil/not logged in")
 	}
 
+	// duplicate feed check, eg http:// vs https:// or a trailing slash
+	existingURL, deleted, err := findEquivalentFeed(ctx, s, feedURL)
+
+	// equivalent check
+	if err != nil {
+		return err
+	}
+
+	// already stored? don't add it twice
+	if existingURL != "" {
+		// removed feed check, it still holds its url
+		if deleted {
+			return fmt.Errorf("error: feed %s was removed, restore it with: undelete feed %s", existingURL, existingURL)
+		}
+
+		// offer to follow the existing feed instead
+		followIt, err := confirm(s, fmt.Sprintf("Feed %s already exists, follow it instead? (yes/no): ", existingURL))
+
+		// confirm check
+		if err != nil {
+			return fmt.Errorf("error reading confirmation: %w", err)
+		}
+		if !followIt {
+			return fmt.Errorf("error: feed %s already exists", existingURL)
+		}
+		return followFeed(ctx, s, user, existingURL)
+	}
+
 	// THIS PART IS NOW HANDLED BY MIDDLEWARELOGIN!
 	// get user by currentUser from database to set the feed follow's fk user_id
 	// user, err := s.DB.GetUser(context.Background(), currentUser)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// get feed id as UUID and timestamp for created/updated at fields
 	id := uuid.New()          // generate new UUID
@@ -478,63 +884,80 @@ func HandlerAddFeed(s *app.State, cmd app.Command, user database.User) error {
 		UserID    uuid.UUID
 	} */
 
+	// create the feed and its follow in one transaction, so a failed follow doesn't leave an orphaned feed
+	tx, err := s.Conn.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit, undoes the feed on any error return
+
+	// run the queries inside the transaction
+	queries := s.DB.WithTx(tx)
+
 	// create new feed in database
-	feed, err := s.DB.CreateFeed(context.Background(), database.CreateFeedParams{
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
 		ID:        id,          // set id to UUID
 		CreatedAt: currentTime, // set created at to current time
 		UpdatedAt: currentTime, // set updated at to current time
 		Name:      feedName,    // set name to feedname, arg 0
 		Url:       feedURL,     // set url to feedURL, arg 1
 		UserID:    userID,      // set user id to current user
+		// set refresh interval, arg 2 (optional)
+		RefreshIntervalSeconds: refreshInterval,
+		UrlKey:                 feedURLKey(feedURL), // one feed per normalized url
 	})
 	// CreateFeed is a method from DB pass through state s (we made using users.sql)
 	// CreateFeedParams is a struct that was genned in database package
 	// could do "_, err := ..." but we need feed for printing confirmation msg
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// user registration check
 	if err != nil {
 		return fmt.Errorf("error adding new feed to database: %w", err)
 	}
 
-	// print confirmation msg to user + log feed details
-	fmt.Printf("RSS Feed '%s' has successfully been added to database!\n", feedName)                                    // confirmation msg
-	fmt.Printf("Feed details:\n  ID = %s\n  CreatedAt = %s\n  UpdatedAt = %s\n  Name = %s\n  URL: %s\n  UserID = %s\n", // log user details
-		feed.ID, feed.CreatedAt, feed.UpdatedAt, feed.Name, feed.Url, feed.UserID)
-
 	// before finishing, we also follow the feed for the current user :)
-	// we COULD do all the logic, but HandlerFollow already exists
-	// GO: function order doesn't matter in go, as long it's in the same package, we can just call it!
+	// NOTE: no more HandlerFollow hack, the follow is part of the same transaction!
+	feedFollow, err := queries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		ID:        uuid.New(),  // set id to new UUID
+		CreatedAt: currentTime, // set created at to current time
+		UpdatedAt: currentTime, // set updated at to current time
+		UserID:    userID,      // set user id to current user
+		FeedID:    feed.ID,     // set feed id to the new feed
+	})
 
-	// first let's create a new command for the follow handler
-	// NOTE: this is a bit of a hack, but it works!
-	followCmd := app.Command{
-		Name: "follow",
-		Args: []string{feedURL}, // we need the URL to follow the feed]
+	// feed follow check (rolls back the feed too)
+	if err != nil {
+		return fmt.Errorf("error following new feed: %w", err)
 	}
 
-	// Call the follow handler to follow the feed!
-	// we'll use our "hacky" followCmd command to do this!
-	err = HandlerFollow(s, followCmd, user)
-	// NOTE: added user as it's required for our middleware :)
+	// commit the feed and follow together
+	err = tx.Commit()
 
-	// follow handler check
+	// commit check
 	if err != nil {
-		// nice to have but NOT critical, so we just print a warning INSTEAD OF EXITING!
-		fmt.Printf("Warning: error following feed: %s", err)
+		return fmt.Errorf("error committing new feed: %w", err)
 	}
 
+	// print confirmation msg to user + log feed details
+	fmt.Fprintf(s.Stdout, "RSS Feed '%s' has successfully been added to database!\n", feedName)                                    // confirmation msg
+	fmt.Fprintf(s.Stdout, "Feed details:\n  ID = %s\n  CreatedAt = %s\n  UpdatedAt = %s\n  Name = %s\n  URL: %s\n  UserID = %s\n", // log user details
+		feed.ID, feed.CreatedAt, feed.UpdatedAt, feed.Name, feed.Url, feed.UserID)
+	fmt.Fprintf(s.Stdout, "%s is now following: %s\n", // confirmation msg
+		feedFollow.Username, feedFollow.Feedname)
+
 	// return success
 	return nil
 }
 
 // feeds handler logic
 // NOTE: cmd will be feeds, and state holds the config file to db, feedname, feedurl and username that CREATED it
-func HandlerFeeds(s *app.State, cmd app.Command) error {
+func HandlerFeeds(ctx context.Context, s *app.State, cmd app.Command) error {
 	// state ptr check
 	if s == nil {
-		fmt.Printf("error: State is nil")
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error: State is nil")
 	}
 
 	/* Note: the method that SQLC generated
@@ -571,39 +994,88 @@ func HandlerFeeds(s *app.State, cmd app.Command) error {
 		Username string
 	}*/
 
+	// strip the broken flag from the args (only list currently failing feeds)
+	_, brokenOnly := popFlag(cmd.Args, "--broken")
+
 	// run the listfeedswithcreator sql query
-	feeds, err := s.DB.ListFeedsWithCreator(context.Background())
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	feeds, err := s.DB.ListFeedsWithCreator(ctx)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// listfeed check
 	if err != nil {
-		fmt.Printf("error returning feeds from database: %s\n", err)
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error returning feeds from database: %w", err)
+	}
+
+	// get the open host circuits, the feeds of a host that keeps timing out aren't fetched for now
+	breakers, err := s.DB.ListHostBreakers(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting host circuits: %w", err)
+	}
+	openHosts := map[string]database.HostBreaker{}
+	for _, breaker := range breakers {
+		if breaker.OpenUntil.Valid && time.Now().UTC().Before(breaker.OpenUntil.Time) {
+			openHosts[breaker.Host] = breaker
+		}
+	}
+
+	// broken check, keep only the feeds whose last fetch(es) failed, or whose host's circuit is open
+	if brokenOnly {
+		var broken []database.ListFeedsWithCreatorRow
+		for _, feed := range feeds {
+			if _, open := openHosts[feedHost(feed.Feedurl)]; open || feed.ConsecutiveFailures > 0 {
+				broken = append(broken, feed)
+			}
+		}
+		feeds = broken
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		// build table of feeds
+		table := output.Table{Columns: []string{"name", "url", "created_by", "consecutive_failures", "last_error", "last_error_at", "host_circuit_open_until"}}
+		for _, feed := range feeds {
+			circuit := openHosts[feedHost(feed.Feedurl)] // zero = closed = null
+			table.Add(feed.Feedname, feed.Feedurl, feed.Username, feed.ConsecutiveFailures, nullString(feed.LastError), nullTime(feed.LastErrorAt), nullTime(circuit.OpenUntil))
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no broken feeds check
+	if brokenOnly && len(feeds) == 0 {
+		fmt.Fprintf(s.Stdout, "No broken feeds, all fetches are working!\n")
+		return nil
 	}
 
 	// no feeds check
 	if len(feeds) == 0 {
-		fmt.Printf("No feeds logged in database!\n")
-		os.Exit(0) // clean exit code 0
+		fmt.Fprintf(s.Stdout, "No feeds logged in database!\n")
+		return nil
 	}
 
 	// print feeds header
-	fmt.Println("Feeds list based on creator:")
-	fmt.Println() // newline
+	fmt.Fprintln(s.Stdout, "Feeds list based on creator:")
+	fmt.Fprintln(s.Stdout) // newline
 
 	// print feeds from database
 	for _, feed := range feeds {
-		fmt.Printf("Feed name: %s\n", feed.Feedname)
-		fmt.Printf("Feed URL: %s\n", feed.Feedurl)
-		fmt.Printf("Created by: %s\n", feed.Username)
-		fmt.Println() // newline
+		fmt.Fprintf(s.Stdout, "Feed name: %s\n", feed.Feedname)
+		fmt.Fprintf(s.Stdout, "Feed URL: %s\n", feed.Feedurl)
+		fmt.Fprintf(s.Stdout, "Created by: %s\n", feed.Username)
+
+		// failing feed check, so dead subscriptions get noticed
+		if feed.ConsecutiveFailures > 0 {
+			fmt.Fprintf(s.Stdout, "Status: FAILING (%d fetches in a row, last at %s)\n", feed.ConsecutiveFailures, feed.LastErrorAt.Time.Format(time.DateTime))
+			fmt.Fprintf(s.Stdout, "Last error: %s\n", feed.LastError.String)
+		}
+
+		// open host circuit check, agg skips the feed until it closes
+		if circuit, open := openHosts[feedHost(feed.Feedurl)]; open {
+			fmt.Fprintf(s.Stdout, "Host circuit: OPEN until %s (%s timed out %d times in a row, its feeds are skipped meanwhile)\n", circuit.OpenUntil.Time.Local().Format(time.DateTime), circuit.Host, circuit.ConsecutiveTimeouts)
+		}
+		fmt.Fprintln(s.Stdout) // newline
 	}
 	// succesfully printed users
-	os.Exit(0) // clean exit code 0
-
-	// return success
 	return nil
-	// this will never be reached, but it's here for the requirement of the Register function
 	// and to make the function complete
 }
 
@@ -612,7 +1084,7 @@ func HandlerFeeds(s *app.State, cmd app.Command) error {
 // NOTE: cmd will be follow, and state holds the config file to allow current user to "follow" a feed
 // essentially adds a feed follow record to the database
 // now use middleware to provide user as input! not more GetUser()!
-func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
+func HandlerFollow(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
@@ -622,11 +1094,41 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 	// command is a struct, get its field for length check
 	if len(cmd.Args) == 0 {
 		return fmt.Errorf("error: no command input")
-	} // login handler expects ONE arg: the url!
+	} // follow handler expects ONE OR MORE args: the url(s)!
+
+	// single url? just follow it
+	if len(cmd.Args) == 1 {
+		return followFeed(ctx, s, user, cmd.Args[0])
+	}
+
+	// bulk follow, with progress as there can be many
+	bar := progress.New(s.Stderr, len(cmd.Args), "feeds")
+	failed := 0 // track the failed follows, we don't stop on the first one!
+
+	// follow each url
+	for _, urlArg := range cmd.Args {
+		err := followFeed(ctx, s, user, urlArg)
 
-	// get url input (first arg!)
-	urlArg := cmd.Args[0] // not needed, but nicely readable!
+		// follow check
+		if err != nil {
+			slog.Warn("error following feed", "url", urlArg, "err", err)
+			failed++ // count it, but move on to the next url
+		}
+		bar.Step()
+	}
+	bar.Done()
+
+	// any failures check
+	if failed > 0 {
+		return fmt.Errorf("error: %d of %d feeds could not be followed", failed, len(cmd.Args))
+	}
+
+	// return success
+	return nil
+}
 
+// follow a single feed helper, adds a feed follow record for the user
+func followFeed(ctx context.Context, s *app.State, user database.User, urlArg string) error {
 	// get feed follow id as UUID and timestamp for created/updated at fields
 	id := uuid.New()          // generate new UUID
 	currentTime := time.Now() // get current time
@@ -689,11 +1191,21 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 	// THIS PART IS NOW HANDLED BY MIDDLEWARELOGIN!
 	// get user by currentUser from database to set the feed follow's fk user_id
 	// user, err := s.DB.GetUser(context.Background(), currentUser)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// get feed by url from database to set the feed follow's fk feed_id
-	feed, err := s.DB.GetFeedByURL(context.Background(), urlArg)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	feed, err := s.DB.GetFeedByURL(ctx, urlArg)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
+
+	// no exact match? the feed may be stored with an equivalent url (https, trailing slash...)
+	if errors.Is(err, sql.ErrNoRows) {
+		existingURL, deleted, findErr := findEquivalentFeed(ctx, s, urlArg)
+
+		// equivalent found check (deleted feeds can't be followed)
+		if findErr == nil && existingURL != "" && !deleted {
+			feed, err = s.DB.GetFeedByURL(ctx, existingURL)
+		}
+	}
 
 	// user check
 	if err != nil {
@@ -702,7 +1214,7 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 
 	// create new feed follow in database
 
-	feedFollow, err := s.DB.CreateFeedFollows(context.Background(), database.CreateFeedFollowsParams{
+	feedFollow, err := s.DB.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
 		ID:        id,          // set id to UUID
 		CreatedAt: currentTime, // set created at to current time
 		UpdatedAt: currentTime, // set updated at to current time
@@ -712,7 +1224,7 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 	// CreateFeedFollows is a method from DB pass through state s (we made using users.sql)
 	// CreateFeedFollowsParams is a struct that was genned in database package
 	// could do "_, err := ..." but we need feed for printing confirmation msg
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// feed follow check
 	if err != nil {
@@ -725,17 +1237,66 @@ func HandlerFollow(s *app.State, cmd app.Command, user database.User) error {
 	}
 
 	// print confirmation msg to user + log user details
-	fmt.Printf("%s is now following: %s\n", // confirmation msg
+	fmt.Fprintf(s.Stdout, "%s is now following: %s\n", // confirmation msg
 		feedFollow.Username, feedFollow.Feedname)
 
 	// return success
 	return nil
 }
 
-// following handler logic
-// NOTE: cmd will be following, and state holds the config file to print all feeds the current user is "following"
+// find a stored feed equivalent to feedURL helper (same urlnorm.Key)
+// returns the stored url and whether it's soft deleted, or "" if there's none
+func findEquivalentFeed(ctx context.Context, s *app.State, feedURL string) (string, bool, error) {
+	// get the key of the new url
+	key, err := urlnorm.Key(feedURL)
+
+	// key check (unparseable urls can't match anything)
+	if err != nil {
+		return "", false, nil
+	}
+
+	// stored key check
+	feed, err := s.DB.GetFeedByURLKey(ctx, sql.NullString{String: key, Valid: true})
+	if err == nil {
+		return feed.Url, feed.DeletedAt.Valid, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", false, fmt.Errorf("error looking up feed url: %w", err)
+	}
+
+	// get the stored urls without a key, feeds added before keys were stored
+	feeds, err := s.DB.ListFeedURLs(ctx)
+
+	// list check
+	if err != nil {
+		return "", false, fmt.Errorf("error listing feed urls: %w", err)
+	}
+
+	// compare keys
+	for _, feed := range feeds {
+		storedKey, err := urlnorm.Key(feed.Url)
+		if err == nil && storedKey == key {
+			return feed.Url, feed.DeletedAt.Valid, nil
+		}
+	}
+
+	// no equivalent feed
+	return "", false, nil
+}
+
+// a feed url's url_key helper, NULL if it has none (it's checked by urlnorm.Clean before it's stored)
+func feedURLKey(feedURL string) sql.NullString {
+	key, err := urlnorm.Key(feedURL)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: key, Valid: true}
+}
+
+// following handler logic
+// NOTE: cmd will be following, and state holds the config file to print all feeds the current user is "following"
 // now use middleware to provide user as input! not more GetUser()!
-func HandlerFollowing(s *app.State, cmd app.Command, user database.User) error {
+func HandlerFollowing(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
@@ -786,49 +1347,53 @@ func HandlerFollowing(s *app.State, cmd app.Command, user database.User) error {
 	// THIS PART IS NOW HANDLED BY MIDDLEWARELOGIN!
 	// get user by currentUser from database to set the feed follow's fk user_id
 	// user, err := s.DB.GetUser(context.Background(), currentUser)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// run the getfeedfollowsfor user command
-	feedFollows, err := s.DB.GetFeedFollowsForUser(context.Background(), user.ID)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	feedFollows, err := s.DB.GetFeedFollowsForUser(ctx, user.ID)
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// getfeedfollowsofruser check
 	if err != nil {
-		fmt.Printf("error returning feed follows from database: %s\n", err)
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error returning feed follows from database: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		// build table of feed follows
+		table := output.Table{Columns: []string{"feed_name", "followed_at"}}
+		for _, feedFollow := range feedFollows {
+			table.Add(feedFollow.Feedname, feedFollow.CreatedAt)
+		}
+		return output.Write(s.Stdout, s.Output, table)
 	}
 
 	// no feed follows check
 	if len(feedFollows) == 0 {
-		fmt.Printf("No feed follows in database!\n")
-		os.Exit(0) // clean exit code 0
+		fmt.Fprintf(s.Stdout, "No feed follows in database!\n")
+		return nil
 	}
 
 	// print feeds follows header
-	fmt.Printf("Feeds followed by %s:\n", currentUser)
-	fmt.Println() // newline
+	fmt.Fprintf(s.Stdout, "Feeds followed by %s:\n", currentUser)
+	fmt.Fprintln(s.Stdout) // newline
 
 	// print names of feed follows from database for current user
 	for _, feedFollow := range feedFollows {
-		fmt.Printf("Feed name: %s\n", feedFollow.Feedname)
-		fmt.Println() // newline
+		fmt.Fprintf(s.Stdout, "Feed name: %s\n", feedFollow.Feedname)
+		fmt.Fprintln(s.Stdout) // newline
 	}
 	// succesfully printed users
-	os.Exit(0) // clean exit code 0
-
-	// return success
 	return nil
-	// this will never be reached, but it's here for the requirement of the Register function
 	// and to make the function complete
 }
 
 // unfollow handler logic
 // NOTE: cmd will be unfollow, and state holds the config file to "unfollow" a feed follow from current user
-func HandlerUnfollow(s *app.State, cmd app.Command, user database.User) error {
+func HandlerUnfollow(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
 	// state ptr check
 	if s == nil {
-		fmt.Printf("error: State is nil")
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error: State is nil")
 	}
 
 	/* Note: the method and struct that SQLC generated
@@ -876,43 +1441,38 @@ func HandlerUnfollow(s *app.State, cmd app.Command, user database.User) error {
 	// create SQL struct
 
 	// run the unfollow command
-	_, err := s.DB.DeleteFeedFollowByUserAndFeed(context.Background(), database.DeleteFeedFollowByUserAndFeedParams{
+	_, err := s.DB.DeleteFeedFollowByUserAndFeed(ctx, database.DeleteFeedFollowByUserAndFeedParams{
 		Url:    feedURL,       // set feed url from arg
 		UserID: currentUserID, // set user id from middleware
 	})
 	// DeleteFeedFollowByUserAndFeed is a method from DB pass through state s (we made using feed_follows.sql)
 	// DeleteFeedFollowByUserAndFeedParams is a struct that was genned in database package
 	// doing "_, err := ..." because we're not using the feed follow!
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// feed follow exists check
 	if errors.Is(err, sql.ErrNoRows) {
-		fmt.Printf("%s is not following this feed!\n", currentUser)
-		os.Exit(0) // clean exit
+		fmt.Fprintf(s.Stdout, "%s is not following this feed!\n", currentUser)
+		return nil
 	}
 	// errors.Is sql.ErrNoRows > err = sql.ErrNoRows
 	// why? it includes wrapped errors, the error returned may not match exactly!
 
 	// unfollow check
 	if err != nil {
-		fmt.Printf("error unfollowing feed: %s\n", err)
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error unfollowing feed: %w", err)
 	}
 
-	// success with code 0
-	fmt.Printf("Feed successfully unfollowed!\n")
-	os.Exit(0) // clean exit code 0
-
-	// return success
+	// success
+	fmt.Fprintf(s.Stdout, "Feed successfully unfollowed!\n")
 	return nil
-	// this will never be reached, but it's here for the requirement of the Register function
 	// and to make the function complete
 }
 
 // browse handler logic
 // NOTE: cmd will be browse, and state holds the config file to print or "browse" all posts of feeds the current user is "following"
 // now use middleware to provide user as input! not more GetUser()!
-func HandlerBrowse(s *app.State, cmd app.Command, user database.User) error {
+func HandlerBrowse(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
 	// state ptr check
 	if s == nil {
 		return fmt.Errorf("error: State is nil")
@@ -923,19 +1483,87 @@ func HandlerBrowse(s *app.State, cmd app.Command, user database.User) error {
 		return fmt.Errorf("error: current user is nil/not logged in")
 	}
 
+	// strip the optional folder flag from the args
+	args, folder, err := popFlagValue(cmd.Args, "--folder")
+
+	// folder flag check
+	if err != nil {
+		return err
+	}
+
+	// strip the optional enclosures flag from the args
+	args, withEnclosures := popFlag(args, "--with-enclosures")
+
+	// strip the optional new posts flag from the args
+	args, newOnly := popFlag(args, "--new")
+
+	// strip the optional ranked flag from the args (likely-interesting posts first)
+	args, ranked := popFlag(args, "--ranked")
+
+	// ranked with new check (--new walks a chronological cursor)
+	if ranked && newOnly {
+		return fmt.Errorf("error: --ranked and --new can't be combined")
+	}
+
+	// strip the optional no collapse flag from the args (shows the same story from several feeds separately)
+	args, noCollapse := popFlag(args, "--no-collapse")
+
+	// strip the optional clustered flag from the args (groups posts covering the same story)
+	args, clustered := popFlag(args, "--clustered")
+
+	// strip the optional language flag from the args
+	args, language, err := popFlagValue(args, "--lang")
+
+	// language flag check
+	if err != nil {
+		return err
+	}
+	if language != "" && lang.Normalize(language) == "" {
+		return fmt.Errorf("error: %s is not a language code (e.g. en, de, pt-BR)", language)
+	}
+	language = lang.Normalize(language)
+
+	// strip the optional max minutes flag from the args (quick reads only)
+	args, maxMinutesFlag, err := popFlagValue(args, "--max-minutes")
+
+	// max minutes flag check
+	if err != nil {
+		return err
+	}
+	var maxMinutes sql.NullInt32
+	if maxMinutesFlag != "" {
+		minutes, err := strconv.Atoi(maxMinutesFlag)
+		if err != nil || minutes < 1 {
+			return fmt.Errorf("error: invalid --max-minutes %q (use a number of minutes)", maxMinutesFlag)
+		}
+		maxMinutes = sql.NullInt32{Int32: int32(minutes), Valid: true}
+	}
+
+	// strip the optional translate flag from the args (shows every post in that language)
+	args, translateTo, err := popFlagValue(args, "--translate")
+
+	// translate flag check
+	if err != nil {
+		return err
+	}
+	if translateTo != "" && lang.Normalize(translateTo) == "" {
+		return fmt.Errorf("error: %s is not a language code (e.g. en, de, pt-BR)", translateTo)
+	}
+	translateTo = lang.Normalize(translateTo)
+
 	// Go requires var BEFORE if blocks to update it within function scope
 	var postLimit int32 = 2 // Default value
 	// why int32? because thats' what PostgreSQL uses!
 
 	// cmd input check
 	// command is a struct, get its field for length check
-	if len(cmd.Args) > 0 { // IF an arg was input
-		// first convert STRING to INT
-		limit, err := strconv.Atoi(cmd.Args[0]) // conv input str to int
+	if len(args) > 0 { // IF an arg was input
+		// first convert STRING to INT (32 bits, or it overflows below)
+		limit, err := strconv.ParseInt(args[0], 10, 32) // conv input str to int
 
-		// conversion check
-		if err != nil {
-			fmt.Println("Invalid limit input! Using defaut of 2.")
+		// conversion check (an error, so --quiet doesn't hide it)
+		if err != nil || limit < 1 {
+			return fmt.Errorf("error: invalid limit %q, it should be a number of posts (default 2)", args[0])
 		}
 
 		// pass error, update the postLimit
@@ -951,115 +1579,633 @@ func HandlerBrowse(s *app.State, cmd app.Command, user database.User) error {
 	// THIS PART IS NOW HANDLED BY MIDDLEWARELOGIN!
 	// get user by currentUser from database to set the feed follow's fk user_id
 	// user, err := s.DB.GetUser(context.Background(), currentUser)
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
+
+	// folder exists check (otherwise a typo looks like an empty folder)
+	if folder != "" {
+		_, err = s.DB.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: folder})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: no folder named %s (see folder list)", folder)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting folder %s: %w", folder, err)
+		}
+	}
 
 	// run the getpostsforuser user command
-	userPosts, err := s.DB.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,   // set user id from middleware
-		Limit:  postLimit, // set limit to 10
-	})
+	var userPosts []database.Post
+	switch {
+	case ranked: // --ranked, the last week's unread posts by score (see weight)
+		userPosts, err = rankedPosts(ctx, s, user, folder, language, maxMinutes, postLimit)
+	case newOnly: // --new, only posts that arrived since the last browse --new (NULL cursor = zero values = all)
+		userPosts, err = s.DB.GetNewPostsForUser(ctx, database.GetNewPostsForUserParams{
+			UserID:       user.ID,
+			CursorAt:     user.BrowseCursorAt.Time,
+			CursorPostID: user.BrowseCursorPostID.UUID,
+			Folder:       sql.NullString{String: folder, Valid: folder != ""},
+			Language:     sql.NullString{String: language, Valid: language != ""},
+			MaxMinutes:   maxMinutes,
+			PostLimit:    postLimit,
+		})
+	case folder == "":
+		userPosts, err = s.DB.GetPostsForUser(ctx, database.GetPostsForUserParams{
+			UserID:     user.ID, // set user id from middleware
+			Language:   sql.NullString{String: language, Valid: language != ""},
+			MaxMinutes: maxMinutes,
+			PostLimit:  postLimit, // set limit to 10
+		})
+	default: // --folder, only posts from the feeds in that folder
+		userPosts, err = s.DB.GetPostsForUserInFolder(ctx, database.GetPostsForUserInFolderParams{
+			UserID:     user.ID,
+			Name:       folder,
+			Language:   sql.NullString{String: language, Valid: language != ""},
+			MaxMinutes: maxMinutes,
+			PostLimit:  postLimit,
+		})
+	}
 
-	// context.Background() provides root empty context with no deadlines or cancellation - required by DB API
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
 
 	// getpostsforuser check
 	if err != nil {
-		fmt.Printf("error returning posts from database: %s\n", err)
-		os.Exit(1) // clean exit code 1
+		return fmt.Errorf("error returning posts from database: %w", err)
+	}
+
+	// move the --new cursor past the posts shown (oldest first, so the last one is the newest)
+	if newOnly && len(userPosts) > 0 {
+		lastPost := userPosts[len(userPosts)-1]
+		err = s.DB.SetBrowseCursor(ctx, database.SetBrowseCursorParams{
+			ID:                 user.ID,
+			BrowseCursorAt:     sql.NullTime{Time: lastPost.CreatedAt, Valid: true},
+			BrowseCursorPostID: uuid.NullUUID{UUID: lastPost.ID, Valid: true},
+		})
+
+		// set cursor check
+		if err != nil {
+			return fmt.Errorf("error saving browse cursor: %w", err)
+		}
+	}
+
+	// translate the posts of auto-translated follows, or all of them with --translate
+	userPosts, err = translatePosts(ctx, s, user, userPosts, translateTo)
+
+	// translate check
+	if err != nil {
+		return err
+	}
+
+	// get the posts' attachments (--with-enclosures only, it's another query)
+	var postEnclosures map[uuid.UUID]enclosureList
+	if withEnclosures {
+		postEnclosures, err = getEnclosures(ctx, s, userPosts)
+
+		// get enclosures check
+		if err != nil {
+			return err
+		}
+	}
+
+	// collapse the same story from several feeds into one entry
+	entries, err := collapsePosts(ctx, s, userPosts, !noCollapse)
+
+	// collapse check
+	if err != nil {
+		return err
+	}
+
+	// get the posts' summaries, if the summarizer wrote any
+	postSummaries, err := getSummaries(ctx, s, userPosts)
+
+	// get summaries check
+	if err != nil {
+		return err
+	}
+
+	// group related posts under their story (--clustered only)
+	if clustered {
+		entries = clusterEntries(entries)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		// build table of posts
+		table := output.Table{Columns: []string{"title", "url", "published_at", "description"}}
+		if withEnclosures {
+			table.Columns = append(table.Columns, "enclosures")
+		}
+		table.Columns = append(table.Columns, "sources", "summary", "reading_minutes", "id")
+		if clustered {
+			table.Columns = append(table.Columns, "related")
+		}
+		for _, entry := range entries {
+			userPost := entry.Post
+			row := []any{userPost.Title, userPost.Url, nullTime(userPost.PublishedAt), nullString(userPost.Description)}
+			if withEnclosures {
+				row = append(row, postEnclosures[userPost.ID])
+			}
+			row = append(row, entry.Sources, postSummaries[userPost.ID], nullInt32(userPost.ReadingMinutes), userPost.ID)
+			if clustered {
+				row = append(row, entry.Related)
+			}
+			table.Add(row...)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// new shares check, so they don't go unnoticed
+	if shares, err := s.DB.CountNewShares(ctx, user.ID); err == nil && shares > 0 {
+		fmt.Fprintf(s.Stdout, "%d new post(s) shared with you, see: inbox\n\n", shares)
 	}
 
 	// no feed follows check
+	if len(userPosts) == 0 && newOnly {
+		fmt.Fprintf(s.Stdout, "No new posts since your last browse --new!\n")
+		return nil
+	}
 	if len(userPosts) == 0 {
-		fmt.Printf("No posts from feeds followed in database!\n")
-		os.Exit(0) // clean exit code 0
+		fmt.Fprintf(s.Stdout, "No posts from feeds followed in database!\n")
+		return nil
 	}
 
 	// print feeds follows header
-	fmt.Printf("Posts from feeds followed by %s:\n", currentUser)
-	fmt.Println() // newline
+	fmt.Fprintf(s.Stdout, "Posts from feeds followed by %s:\n", currentUser)
+	fmt.Fprintln(s.Stdout) // newline
 
 	// print names of posts from database for current user
-	for _, userPost := range userPosts {
-		fmt.Printf("Post name: %s\n", userPost.Title)
-		fmt.Printf("Post url: %s\n", userPost.Url)
-		fmt.Printf("Post pubdate: %s\n", userPost.PublishedAt.Time)   // was nullable, need to call .Time!
-		fmt.Printf("Post content: %s\n", userPost.Description.String) // was nullable, need to call .String!
-		fmt.Println()                                                 // newline
+	for _, entry := range entries {
+		userPost := entry.Post
+		fmt.Fprintf(s.Stdout, "Post name: %s\n", userPost.Title)
+		fmt.Fprintf(s.Stdout, "Post id: %s\n", shortID(userPost.ID)) // sendto takes it
+		fmt.Fprintf(s.Stdout, "Post url: %s\n", userPost.Url)
+		fmt.Fprintf(s.Stdout, "Post pubdate: %s\n", userPost.PublishedAt.Time)   // was nullable, need to call .Time!
+		fmt.Fprintf(s.Stdout, "Post content: %s\n", userPost.Description.String) // was nullable, need to call .String!
+		if userPost.ReadingMinutes.Valid {
+			fmt.Fprintf(s.Stdout, "Post reading time: %d min\n", userPost.ReadingMinutes.Int32)
+		}
+		if summary := postSummaries[userPost.ID]; summary != "" {
+			fmt.Fprintf(s.Stdout, "Post summary: %s\n", summary)
+		}
+		for _, enclosure := range postEnclosures[userPost.ID] {
+			fmt.Fprintf(s.Stdout, "Post enclosure: %s\n", enclosure)
+		}
+		for _, source := range entry.Sources {
+			fmt.Fprintf(s.Stdout, "Post source: %s (%s)\n", source.Feed, source.URL)
+		}
+		for _, related := range entry.Related {
+			fmt.Fprintf(s.Stdout, "Post related: %s (%s)\n", related.Title, related.URL)
+		}
+		fmt.Fprintln(s.Stdout) // newline
 	}
 	// succesfully printed users
-	os.Exit(0) // clean exit code 0
+	return nil
+	// and to make the function complete
+}
+
+// setinterval handler logic
+// NOTE: cmd will be setinterval, and state holds the config file to set a feed's own refresh interval
+func HandlerSetInterval(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get arguments input (Run checked there are TWO: feed URL and interval, or "default")
+	feedURL := cmd.Args[0] // not needed, but nicely readable!
+
+	// parse the interval using helper
+	refreshInterval, err := parseRefreshInterval(cmd.Args[1])
+
+	// parse check
+	if err != nil {
+		return err
+	}
+
+	// owner check, only admins may change feeds someone else added (like removefeed)
+	owner, err := s.DB.GetFeedOwner(ctx, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: no feed found with url %s", feedURL)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+	if owner != user.ID {
+		err = requireAdmin(ctx, s, "changing the interval of a feed someone else added")
+		if err != nil {
+			return err
+		}
+	}
+
+	// update the feed's refresh interval
+	rows, err := s.DB.SetFeedRefreshInterval(ctx, database.SetFeedRefreshIntervalParams{
+		Url:                    feedURL,         // set feed url from arg
+		RefreshIntervalSeconds: refreshInterval, // set interval from arg
+	})
+	// ctx is passed down from main, so --timeout and ctrl+c cancel the query
+
+	// update check
+	if err != nil {
+		return fmt.Errorf("error setting feed refresh interval: %w", err)
+	}
+
+	// feed exists check
+	if rows == 0 {
+		return fmt.Errorf("error: no feed found with url %s", feedURL)
+	}
+
+	// print confirmation msg to user
+	if !refreshInterval.Valid {
+		fmt.Fprintf(s.Stdout, "Feed %s now uses the agg interval\n", feedURL)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Feed %s is now fetched every %v\n", feedURL, time.Duration(refreshInterval.Int32)*time.Second)
 
 	// return success
 	return nil
-	// this will never be reached, but it's here for the requirement of the Register function
-	// and to make the function complete
 }
 
 // HELPER FUNCTIONS
 
-// aggregation function helper to get feeds for agg command
-func scrapeFeeds(queries *database.Queries) error {
+// one-shot aggregation helper for agg --once
+// fetches ALL due feeds exactly once, and errors if any of the fetches failed
+func aggOnce(ctx context.Context, s *app.State, worker *aggWorker, batch int32) error {
+	// scrape the due feeds (no global interval, so feeds without their own are always due)
+	scraped, failed, err := scrapeDueFeeds(ctx, s, worker, 0, batch, nil)
+
+	// scrape due feeds check
+	if err != nil {
+		return err
+	}
+
+	// interrupted check (ctrl+c or SIGTERM), the unfinished feeds were rolled back and stay due
+	if ctx.Err() != nil {
+		slog.Info("aggregation interrupted", "fetched", scraped-failed, "failed", failed)
+		return fmt.Errorf("error: aggregation interrupted after fetching %d feeds (%d failed): %w", scraped-failed, failed, ctx.Err())
+	}
+
+	// print summary
+	slog.Info("aggregation finished", "fetched", scraped-failed, "failed", failed, "due", scraped)
+
+	// summarize the new posts, if summarize.provider is set (before the digests, so they have them)
+	aggSummaries(ctx, s)
+
+	// send the digests that are due, if digest_interval is set
+	aggDigests(ctx, s)
+
+	// push the new posts of flagged follows, if anyone set up notifications
+	aggNotifications(ctx, s)
+
+	// send the usage report when it's due, if telemetry is on
+	aggTelemetry(ctx, s)
+
+	// any failures check (non-zero exit status for cron!)
+	if failed > 0 {
+		return fmt.Errorf("error: %d of %d feeds failed to fetch", failed, scraped)
+	}
+
+	// return success
+	return nil
+}
+
+// get the agg batch size helper, the --batch value if given, else agg_batch_size (0 = all due feeds)
+func aggBatchSize(s *app.State, input string) (int32, error) {
+	// no flag check, use the config
+	if input == "" {
+		// config check (negative makes no sense)
+		if s.Config.AggBatchSize < 0 {
+			return 0, fmt.Errorf("error: invalid agg_batch_size %d in config", s.Config.AggBatchSize)
+		}
+		return int32(s.Config.AggBatchSize), nil
+	}
+
+	// parse the flag value
+	batch, err := strconv.ParseInt(input, 10, 32)
+
+	// batch check
+	if err != nil || batch < 0 {
+		return 0, fmt.Errorf("error: invalid --batch %q (use a number of feeds, 0 = all)", input)
+	}
+	return int32(batch), nil
+}
+
+// scrapeFeed's error when another agg claimed the feed first
+var errFeedClaimed = errors.New("feed claimed by another agg")
+
+// scheduler helper that scrapes all feeds that are due
+// fallback is the global interval, used for feeds without their own
+// batch caps the feeds scraped per call (0 = all due feeds), the rest are due again right away
+// onFeed is called as each feed finishes, however it went (nil = not needed)
+// worker is the agg claiming the jobs, so they're reassigned if it dies
+// NOTE: due feeds are queued as fetch jobs first, so failures are retried with backoff and new feeds go first
+// returns how many due feeds were scraped (not claimed by another agg, put off by an open host circuit or interrupted) and how many of them failed
+func scrapeDueFeeds(ctx context.Context, s *app.State, worker *aggWorker, fallback time.Duration, batch int32, onFeed func()) (int, int, error) {
 	// database queries check
-	if queries == nil {
-		return fmt.Errorf("error: database queries is nil")
+	if s.DB == nil {
+		return 0, 0, fmt.Errorf("error: database queries is nil")
 	}
 
-	// use GetNextFeedToFetch to... get the next feed to fetch!
-	nextFeed, err := queries.GetNextFeedToFetch(context.Background())
+	// queue the due feeds and claim the next jobs (at most batch of them, 0 = all)
+	jobs, feeds, err := claimFetchJobs(ctx, s, worker, fallback, batch)
 
-	// get next feed to fetch check
+	// claim jobs check
 	if err != nil {
-		// check if no needs available in DB
-		if errors.Is(err, sql.ErrNoRows) {
-			fmt.Println("No feeds found in database. Add some using the 'addfeed' command.")
-			return nil
+		return 0, 0, err
+	}
+
+	// nothing due check
+	if len(jobs) == 0 {
+		slog.Debug("no feeds due")
+		return 0, 0, nil
+	}
+
+	// catching up on lots of feeds? show progress (nil = no progress)
+	var bar *progress.Reporter
+	if len(jobs) >= progressThreshold {
+		bar = progress.New(s.Stderr, len(jobs), "feeds")
+	}
+
+	// track the failed fetches, we don't stop on the first one!
+	failed := 0
+	skipped := 0      // fetched by another agg instead, host circuit open, or interrupted by ctrl+c
+	var mu sync.Mutex // guards the counts and the bar, workers finish in any order
+
+	// apply the current concurrency limits (they may have been reloaded), the limiter keeps its place within them
+	fewest, most, target, err := s.Config.FetchLimits()
+
+	// limits check
+	if err != nil {
+		return 0, 0, err
+	}
+	limiter := worker.limiter
+	limiter.SetBounds(fewest, most, target)
+
+	// tell the limiter how the database is doing after each feed, it backs off when it's slow or failing
+	observe := func(started time.Time, dbErr error) {
+		latency := time.Since(started)
+		old, limit := limiter.Observe(latency, dbErr)
+		if limit < old {
+			slog.Warn("database is slow, storing fewer feeds at once", "concurrency", limit, "latency", latency, "err", dbErr)
+		} else if limit > old {
+			slog.Debug("database is healthy, storing more feeds at once", "concurrency", limit)
 		}
-		return fmt.Errorf("error getting next feed to fetch: %w", err)
 	}
 
+	// run the jobs through the fetch, parse and store stages, each with its own concurrency
+	// fetches: http.concurrency at a time, stores: the limiter's (1 = one after the other)
+	// and the hosts that keep timing out skipped, other aggs may have opened or closed circuits since the last tick
+	metrics := newPipelineMetrics()
+	worker.breakers.reload(ctx, s)
+	runFetchPipeline(ctx, s, jobs, feeds, most, limiter, worker.breakers, metrics, func(item pipelineFeed) {
+		job, feed, err := item.job, item.feed, item.err
+
+		// removed since it was queued check (nothing to fetch, the job is done)
+		if !item.found {
+			finishFetchJob(ctx, s, job, nil)
+			mu.Lock()
+			skipped++
+			bar.Step()
+			mu.Unlock()
+			return
+		}
+
+		// progress callback check
+		if onFeed != nil {
+			onFeed()
+		}
+
+		// host circuit open check (not the feed's failure, it's fetched once the circuit closes)
+		var open hostOpenError
+		if errors.As(err, &open) {
+			slog.Debug("skipping feed, its host's circuit is open", "feed", feed.Name, "host", open.host, "until", open.until)
+			deferFetchJob(ctx, s, job, open.until, err)
+			mu.Lock()
+			skipped++
+			bar.Step()
+			mu.Unlock()
+			return
+		}
+
+		// claimed by another agg check (not a failure, it's being fetched)
+		// interrupted check (ctrl+c isn't the feed's fault, its transaction was rolled back)
+		if errors.Is(err, errFeedClaimed) || (err != nil && ctx.Err() != nil) {
+			if ctx.Err() == nil {
+				slog.Debug("skipping feed, another agg is fetching it", "feed", feed.Name)
+				finishFetchJob(ctx, s, job, nil)
+			} else {
+				releaseFetchJob(ctx, s, job)
+			}
+			mu.Lock()
+			skipped++
+			bar.Step()
+			mu.Unlock()
+			return
+		}
+
+		// scrape feed check
+		if err != nil {
+			slog.Error("error scraping the feed", "feed", feed.Name, "attempt", job.Attempts, "err", err)
+			recordFeedFailure(ctx, s, feed, err)
+		}
+		started := time.Now()
+		dbErr := finishFetchJob(ctx, s, job, err) // retried later if it has attempts left
+
+		// database trouble check, a dropped connection or conflict counts like a slow call
+		if dbErr == nil && item.stored && dialect.IsRetryable(err) {
+			dbErr = err
+		}
+		observe(started, dbErr)
+		mu.Lock()
+		if err != nil {
+			failed++ // count it, but move on to the next feed
+		}
+		bar.Step()
+		mu.Unlock()
+	})
+	bar.Done()
+
+	// log how long the stages took, and add them to the session's totals for agg status
+	logPipelineMetrics(metrics)
+	worker.stages.add(metrics)
+
+	// return the counts
+	return len(jobs) - skipped, failed, nil
+}
+
+// the shared http client helper, the default one if State has none
+func httpClient(s *app.State) *rssfeed.Client {
+	// configured check
+	if s.HTTP == nil {
+		return rssfeed.Default
+	}
+	return s.HTTP
+}
+
+// store a parsed feed, retrying when the database asks for it helper
+// cockroachdb aborts transactions that conflict (40001), and serverless connections can drop
+// NOTE: only the transaction is retried, the feed isn't fetched or parsed again
+func storeFeedWithRetry(ctx context.Context, s *app.State, feed database.Feed, parsed *parsedFeed) error {
+	var err error
+	for attempt := 1; attempt <= scrapeAttempts; attempt++ {
+		// store the feed
+		err = storeFeed(ctx, s, feed, parsed)
+
+		// retryable check (claimed feeds and the like are returned as is)
+		if err == nil || !dialect.IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		slog.Debug("retrying feed", "feed", feed.Name, "attempt", attempt, "err", err)
+	}
+
+	// return the last error
+	return err
+}
+
+// record a failed fetch on the feed helper, so feeds --broken can show it
+func recordFeedFailure(ctx context.Context, s *app.State, feed database.Feed, fetchErr error) {
+	// cancelled check (ctrl+c or --timeout isn't the feed's fault!)
+	if ctx.Err() != nil {
+		return
+	}
+
+	// store the error and bump the failure streak
+	err := s.DB.MarkFeedFailed(ctx, database.MarkFeedFailedParams{
+		ID:        feed.ID,
+		LastError: sql.NullString{String: fetchErr.Error(), Valid: true},
+	})
+
+	// mark failed check (just log it, the fetch error is already logged)
+	if err != nil {
+		slog.Warn("error recording feed failure", "feed", feed.Name, "err", err)
+	}
+}
+
+// scheduler helper to get the wait until the next feed is due
+func nextWake(ctx context.Context, queries database.Store, fallback time.Duration) (time.Duration, error) {
+	// get all feeds with their (updated) last fetched times
+	feeds, err := queries.GetFeedsToFetch(ctx)
+
+	// get feeds to fetch check
+	if err != nil {
+		return 0, fmt.Errorf("error getting feeds to fetch: %w", err)
+	}
+
+	// get the feeds their fetch jobs hold back (retrying, cooling down after failing, or being fetched)
+	wakes, err := queries.ListFetchJobWakes(ctx, database.ListFetchJobWakesParams{
+		FallbackSeconds: int32(fallback / time.Second),
+		Now:             time.Now().UTC(),
+	})
+
+	// get job wakes check
+	if err != nil {
+		return 0, fmt.Errorf("error getting fetch jobs: %w", err)
+	}
+	held := make(map[uuid.UUID]time.Time, len(wakes))
+	for _, wake := range wakes {
+		held[wake.FeedID] = wake.WakeAt.Time // zero = running, not due until it finishes
+	}
+
+	// no feeds check
+	if len(feeds) == 0 {
+		slog.Warn("no feeds found in database, add some using the 'addfeed' command")
+	}
+
+	// return the wait from the scheduler
+	return scheduler.NextWake(feeds, held, time.Now(), fallback), nil
+}
+
+// scrape a single feed helper, fetches, parses and stores it one stage after the other
+// NOTE: agg runs the stages as a pipeline instead (see pipeline.go), this is for one-off fetches like seed
+func scrapeFeed(ctx context.Context, s *app.State, nextFeed database.Feed) error {
+	// fetch it
+	raw, err := fetchFeed(ctx, s, nextFeed)
+	if err != nil {
+		return err
+	}
+
+	// parse it
+	parsed, err := parseFeed(ctx, s, nextFeed, raw)
+	if err != nil {
+		return err
+	}
+
+	// store it, retrying just the store when the database asks for it (not another fetch)
+	return storeFeedWithRetry(ctx, s, nextFeed, parsed)
+}
+
+// a fetched feed's posts, parsed and ready to store in one go
+type parsedFeed struct {
+	items      int                             // items in the feed, stored or not (for the log)
+	posts      database.InsertPostsParams      // the posts as columns, for ONE bulk insert
+	enclosures database.InsertEnclosuresParams // their attachments, linked to the posts by url
+	rulePosts  map[string]rulePost             // what the followers' rules see of each post, by url
+	postImages map[string][]string             // each post's images by url, for the image cache
+}
+
+// fetch stage helper, gets a feed's raw body over the network
+func fetchFeed(ctx context.Context, s *app.State, nextFeed database.Feed) (*rssfeed.Raw, error) {
+	// tell user that fetching has started!
+	slog.Info("fetching feed", "feed", nextFeed.Name, "url", nextFeed.Url)
+
+	// fetch the raw feed using url, with the shared client (timeout, proxy etc from the http config)
+	raw, err := httpClient(s).FetchRaw(ctx, nextFeed.Url)
+
+	// fetch feed check
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the feed %s: %w", nextFeed.Name, err)
+	}
+	return raw, nil
+}
+
+// parse stage helper, turns a raw feed into the posts to store
+// NOTE: with unshorten_links, cleaning the links may make requests of its own (once per short link)
+func parseFeed(ctx context.Context, s *app.State, nextFeed database.Feed, raw *rssfeed.Raw) (*parsedFeed, error) {
 	// get feed data (not needed, but nice and readable!)
 	feedID := nextFeed.ID
 	feedName := nextFeed.Name
 	feedURL := nextFeed.Url
 
-	// mark the feed as fetched
-	err = queries.MarkFeedFetched(context.Background(), feedID)
+	// debug mode check, keep the raw body BEFORE parsing, so parse bugs can be reproduced
+	if s.Config.SnapshotFeeds {
+		storeSnapshot(ctx, s, nextFeed, raw)
+	}
 
-	// mark feed fetched check
+	// parse the raw feed
+	feed, err := rssfeed.Parse(feedURL, raw)
+
+	// parse feed check
 	if err != nil {
-		return fmt.Errorf("error marking feed as fetched: %w", err)
+		return nil, fmt.Errorf("error parsing the feed %s: %w", feedName, err)
 	}
 
-	// tell user that fetching has started!
-	fmt.Printf("Fetching feed: %s (%s)\n", feedName, feedURL)
+	// clean the post links first, so the same post is always stored under the same url
+	err = cleanPostLinks(ctx, s, s.DB, feed.Channel.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect the posts as columns, so they're stored with ONE bulk insert (one round trip!)
+	posts := database.InsertPostsParams{
+		CreatedAt: time.Now(), // same created/updated at for the whole fetch
+		FeedID:    feedID,
+	}
 
-	// create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel() // Don't forget to cancel to prevent resource leaks
-	// context.WithTimeout creates a new context with a timeout of 10 seconds
-	// this is used to limit the time the function can run, in case of a slow network or server
-	// cancel is a function that cancels the context, and should be called when done
+	// collect their attachments the same way (linked to the posts by url)
+	enclosures := database.InsertEnclosuresParams{CreatedAt: posts.CreatedAt}
 
-	// fetch the feed using url (from rssfeed.go)
-	feed, err := rssfeed.FetchFeed(ctx, feedURL)
+	// and what the followers' rules see of each post, by url (the first of a url is the one stored)
+	rulePosts := map[string]rulePost{}
 
-	// fetch feed check
-	if err != nil {
-		return fmt.Errorf("error fetching the marked feed %s: %w", feedName, err)
-	}
+	// and each post's images by url, for the image cache
+	postImages := map[string][]string{}
 
-	// print the feed info
-	fmt.Printf("Feed: %s\n", feedName)
+	// the feed's declared language, for posts too short to detect their own
+	feedLanguage := lang.Normalize(feed.Channel.Language)
 
-	// loop over rssfeed and print title of each item in feed
+	// loop over rssfeed and collect each item in feed
 	for _, item := range feed.Channel.Items {
-		// we still print the feed title
-		fmt.Printf(" - %s\n", item.Title)
-
-		// CREATE POST after scraping feeds
+		// we still log the post title
+		slog.Log(ctx, logging.LevelTrace, "found post", "feed", feedName, "title", item.Title)
 
-		// get post id as UUID and timestamp for created/updated at fields
-		id := uuid.New()          // generate new UUID
-		currentTime := time.Now() // get current time
+		// COLLECT POST after scraping feeds
 
 		// PUBLICATION DATE - SQL.NULLTIME & PARSING
 		// let's parse the published_at using dateparse.ParseAny()
@@ -1080,7 +2226,7 @@ func scrapeFeeds(queries *database.Queries) error {
 			// date parse check
 			if err != nil {
 				// let's not crash the agg, just give warning as graceful degradation
-				fmt.Printf("Warning: could not parse date '%s': %v\n", item.PubDate, err)
+				slog.Warn("could not parse post date", "feed", feedName, "date", item.PubDate, "err", err)
 			} else { // else, parsing worked
 				publishedAt.Time = parsedDate // set to parsed date
 				publishedAt.Valid = true      // set parsing as success
@@ -1113,7 +2259,7 @@ func scrapeFeeds(queries *database.Queries) error {
 		// empty title check (may not be null!)
 		if unescapeTitle == "" {
 			// graceful degradation
-			fmt.Println("Post has no title, skipping...")
+			slog.Warn("post has no title, skipping", "feed", feedName, "url", item.Link)
 			continue // skip to next post
 		}
 
@@ -1123,62 +2269,368 @@ func scrapeFeeds(queries *database.Queries) error {
 		// empty link check (may not be null!)
 		if unescapeLink == "" {
 			// graceful degradation
-			fmt.Println("Post has no url, skipping...")
+			slog.Warn("post has no url, skipping", "feed", feedName, "title", unescapeTitle)
 			continue // skip to next post
 		}
 
-		/* CREATEPOSTPARAMS struct from posts.sql.go
-
-		type CreatePostParams struct {
-			ID          uuid.UUID
-			CreatedAt   time.Time
-			UpdatedAt   time.Time
-			Title       string
-			Url         string
-			Description sql.NullString
-			PublishedAt sql.NullTime
-			FeedID      uuid.UUID
-		} */
-
-		_, err := queries.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          id,
-			CreatedAt:   currentTime,
-			UpdatedAt:   currentTime,
-			Title:       unescapeTitle,
-			Url:         item.Link,       // item has Link, not url, samesame!
-			Description: postDescription, // nullable string
-			PublishedAt: publishedAt,     // nullable time and parsed
-			FeedID:      feedID,
-		})
-		// CreatePost is a method from DB pass through state s (we made using posts.sql)
-		// CreatePostParams is a struct that was genned in database package
-		// do "_, err := ..." as we don't need post (not logging ALL details)
+		// add the post to the batch
+		// NOTE: arrays can't hold NULLs, so "" and the zero time stand in for them (see InsertPosts)
+		posts.Ids = append(posts.Ids, uuid.New())
+		posts.Titles = append(posts.Titles, unescapeTitle)
+		posts.Urls = append(posts.Urls, item.Link) // item has Link, not url, samesame!
+		posts.Descriptions = append(posts.Descriptions, postDescription.String)
+		posts.PublishedAts = append(posts.PublishedAts, publishedAt.Time) // zero if not Valid
+
+		// detect the post's language, falling back to the feed's ("" if neither = NULL)
+		language := lang.Detect(unescapeTitle + "\n" + postDescription.String)
+		if language == "" {
+			language = feedLanguage
+		}
+		posts.Languages = append(posts.Languages, language)
 
-		// ENSURE URL IS UNIQUE (to handle error gracefully)
-		pqErr, isPQError := err.(*pq.Error)
+		// estimate the reading time from the fullest content the feed gave (0 = nothing to read = NULL)
+		content := postDescription.String
+		if len(item.Content) > len(content) {
+			content = item.Content
+		}
+		posts.ReadingMinutes = append(posts.ReadingMinutes, int32(readtime.Minutes(content)))
 
-		// handle specific error first
-		// check if url duplication occured
-		if isPQError && pqErr.Code == "23505" {
-			// the error exists and it matches the PostgreSQL code for unique duplication
-			// graceful degradation
-			fmt.Printf("Warning: Post with URL %s already exists in database: %v\n", item.Link, pqErr)
-			fmt.Println("Skipping this post...")
-			continue // skip to next post
+		// and its guid, what its read and starred state is matched by when its link changes ("" = none = NULL)
+		posts.Guids = append(posts.Guids, strings.TrimSpace(item.GUID))
+
+		// note the post's images, cached once it's stored (cache_images only)
+		if s.Config.CacheImages {
+			postImages[item.Link] = postImageURLs(postDescription.String, item.Enclosures)
 		}
 
-		// now do general error check
+		// add the post for the rules
+		if _, seen := rulePosts[item.Link]; !seen {
+			hook := rules.WebhookPost{
+				Feed:        feedName,
+				FeedURL:     feedURL,
+				Title:       unescapeTitle,
+				URL:         item.Link,
+				Description: postDescription.String,
+				Categories:  item.Categories,
+			}
+			if publishedAt.Valid {
+				hook.PublishedAt = &publishedAt.Time
+			}
+			rulePosts[item.Link] = rulePost{
+				ID: posts.Ids[len(posts.Ids)-1],
+				Match: rules.Post{
+					FeedID:     feedID,
+					Title:      unescapeTitle,
+					Categories: item.Categories,
+					Authors:    []string{item.Author, item.Creator},
+				},
+				Hook: hook,
+			}
+		}
+
+		// add the post's enclosures to their batch
+		for _, enclosure := range item.Enclosures {
+			// empty url check (nothing to attach)
+			if enclosure.URL == "" {
+				continue
+			}
+
+			// length is optional, junk or missing = 0 = NULL
+			length, _ := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+
+			enclosures.Ids = append(enclosures.Ids, uuid.New())
+			enclosures.PostUrls = append(enclosures.PostUrls, item.Link)
+			enclosures.Urls = append(enclosures.Urls, enclosure.URL)
+			enclosures.MimeTypes = append(enclosures.MimeTypes, enclosure.Type)
+			enclosures.Lengths = append(enclosures.Lengths, max(length, 0))
+		}
+	}
+
+	// return the parsed feed
+	return &parsedFeed{
+		items:      len(feed.Channel.Items),
+		posts:      posts,
+		enclosures: enclosures,
+		rulePosts:  rulePosts,
+		postImages: postImages,
+	}, nil
+}
+
+// store stage helper, stores a parsed feed's posts and marks it fetched, in one transaction
+// NOTE: the feed is only marked as fetched if its posts were stored, so failures get retried
+func storeFeed(ctx context.Context, s *app.State, nextFeed database.Feed, parsed *parsedFeed) error {
+	// get feed data (not needed, but nice and readable!)
+	feedID := nextFeed.ID
+	feedName := nextFeed.Name
+	feedURL := nextFeed.Url
+	posts, enclosures := parsed.posts, parsed.enclosures
+
+	// begin a transaction, so the posts and last_fetched_at are stored together (or not at all)
+	tx, err := s.Conn.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit, undoes everything on any error return
+
+	// queries that run inside the transaction
+	queries := s.DB.WithTx(tx)
+
+	// claim the feed (another agg may be storing it, or may have stored it since we listed it)
+	// NOTE: fetch jobs already keep aggs from fetching the same feed, this makes sure it's stored once either way
+	_, err = queries.LockFeedForFetch(ctx, database.LockFeedForFetchParams{
+		ID:            feedID,
+		LastFetchedAt: nextFeed.LastFetchedAt,
+	})
+
+	// claimed check
+	if errors.Is(err, sql.ErrNoRows) {
+		return errFeedClaimed
+	}
+
+	// lock check
+	if err != nil {
+		return fmt.Errorf("error claiming feed %s: %w", feedName, err)
+	}
+
+	// store the whole batch (ON CONFLICT DO NOTHING skips urls we already have)
+	var stored []string
+	if len(posts.Ids) > 0 {
+		stored, err = queries.InsertPosts(ctx, posts)
+
+		// insert check (rolls back the whole feed)
+		if err != nil {
+			return fmt.Errorf("error storing posts: %w", err)
+		}
+	}
+
+	// store the enclosures, after the posts they belong to (existing ones are skipped)
+	if len(enclosures.Ids) > 0 {
+		err = queries.InsertEnclosures(ctx, enclosures)
+
+		// insert check (rolls back the whole feed)
+		if err != nil {
+			return fmt.Errorf("error storing enclosures: %w", err)
+		}
+	}
+
+	// run the followers' rules on the new posts (in the transaction, so mutes, stars and tags are stored with them)
+	var deliveries []ruleDelivery
+	if len(stored) > 0 {
+		newPosts := make([]rulePost, 0, len(stored))
+		for _, url := range stored {
+			newPosts = append(newPosts, parsed.rulePosts[url])
+		}
+		deliveries, err = applyRules(ctx, queries, feedID, newPosts)
+
+		// rules check (rolls back the whole feed)
+		if err != nil {
+			return err
+		}
+	}
+
+	// log the new posts (debug only, info would be too verbose)
+	for _, url := range stored {
+		slog.Debug("post added to database", "feed", feedName, "url", url)
+	}
+
+	// tell listeners about the new posts (watch, ...), if the database supports it
+	// NOTE: postgres only delivers it on commit, so listeners never see uncommitted posts
+	if len(stored) > 0 && s.Dialect.SupportsNotify() {
+		payload, err := notify.Payload(notify.NewPosts{FeedID: feedID, FeedName: feedName, FeedURL: feedURL, Count: len(stored)})
+
+		// payload check
 		if err != nil {
-			return fmt.Errorf("error creating post: %w", err)
+			return err
 		}
 
-		// print confirmation msg to user (full logging would be too verbose)
-		fmt.Printf("Post '%s' has successfully been added to database!\n", unescapeTitle) // confirmation msg
+		// notify inside the transaction
+		err = queries.NotifyNewPosts(ctx, database.NotifyNewPostsParams{Channel: notify.Channel(s.Config.SchemaName()), Payload: payload})
+
+		// notify check
+		if err != nil {
+			return fmt.Errorf("error notifying new posts: %w", err)
+		}
 	}
 
-	// print newline for visual clairty
-	fmt.Println()
+	// ONLY now mark the feed as fetched (inside the same transaction)
+	err = queries.MarkFeedFetched(ctx, feedID)
+
+	// mark feed fetched check
+	if err != nil {
+		return fmt.Errorf("error marking feed as fetched: %w", err)
+	}
+
+	// cron feed check, store when its schedule is due next
+	if nextFeed.RefreshCron.Valid {
+		next, err := scheduler.NextCronFetch(nextFeed.RefreshCron.String, time.Now())
+
+		// schedule check (setschedule validates it, so only a hand edited one fails)
+		if err != nil {
+			return fmt.Errorf("error scheduling feed %s: %w", feedName, err)
+		}
+
+		// store it
+		err = queries.SetFeedNextFetch(ctx, database.SetFeedNextFetchParams{
+			ID:          feedID,
+			NextFetchAt: sql.NullTime{Time: next, Valid: true},
+		})
+
+		// store check
+		if err != nil {
+			return fmt.Errorf("error storing the next fetch of feed %s: %w", feedName, err)
+		}
+	}
+
+	// commit the posts and the fetch together
+	err = tx.Commit()
+
+	// commit check
+	if err != nil {
+		return fmt.Errorf("error committing feed %s: %w", feedName, err)
+	}
+
+	// notify and webhook rules, now the posts they point at are stored
+	deliverRules(ctx, s, deliveries)
+
+	// cache the new posts' images, now they're stored (cache_images only)
+	if len(stored) > 0 && s.Config.CacheImages {
+		var images []string
+		for _, url := range stored {
+			images = append(images, parsed.postImages[url]...)
+		}
+		cacheImages(ctx, s, images)
+	}
+
+	// log the feed summary
+	slog.Info("fetched feed", "feed", feedName, "posts", parsed.items, "new", len(stored))
 
 	// return success
 	return nil
 }
+
+// parse a refresh interval arg helper
+// "default" (or "0") resets the feed to the agg interval, ie NULL
+func parseRefreshInterval(input string) (sql.NullInt32, error) {
+	// reset check
+	if input == "default" || input == "0" {
+		return sql.NullInt32{}, nil // Valid = false, ie NULL
+	}
+
+	// parse the time duration string
+	interval, err := time.ParseDuration(input)
+
+	// interval check
+	if err != nil {
+		return sql.NullInt32{}, fmt.Errorf("error: invalid interval format: %w", err)
+	}
+
+	// at least one second check (we store whole seconds)
+	if interval < time.Second {
+		return sql.NullInt32{}, fmt.Errorf("error: interval must be at least 1s")
+	}
+
+	// fits the int32 column check (about 68 years), a bigger one would wrap around to a negative interval
+	if interval/time.Second > math.MaxInt32 {
+		return sql.NullInt32{}, fmt.Errorf("error: interval must be at most %v", time.Duration(math.MaxInt32)*time.Second)
+	}
+
+	// return the interval in seconds
+	return sql.NullInt32{Int32: int32(interval / time.Second), Valid: true}, nil
+}
+
+// flag helper, checks if a flag is in the args and strips it out
+// returns the remaining args, and whether the flag was found
+func popFlag(args []string, flag string) ([]string, bool) {
+	// create nil slice for the remaining args
+	var rest []string
+	found := false // default
+
+	// look for the flag in each arg
+	for _, arg := range args {
+		// flag check
+		if arg == flag {
+			found = true
+			continue // skip it, so handlers only see their positional args
+		}
+		rest = append(rest, arg)
+	}
+
+	// return remaining args and flag found
+	return rest, found
+}
+
+// valued flag helper, checks if a flag is in the args and strips it AND its value out
+// eg --backup out.json, returns the remaining args, and the value ("" if not found)
+func popFlagValue(args []string, flag string) ([]string, string, error) {
+	// create nil slice for the remaining args
+	var rest []string
+	value := "" // default
+
+	// look for the flag in each arg
+	for i := 0; i < len(args); i++ {
+		// not our flag? keep it
+		if args[i] != flag {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		// missing value check
+		if i+1 >= len(args) {
+			return nil, "", fmt.Errorf("error: %s requires a value", flag)
+		}
+
+		// get the value, and skip over it
+		value = args[i+1]
+		i++
+	}
+
+	// return remaining args and flag value
+	return rest, value, nil
+}
+
+// confirmation prompt helper, reads a "yes" from stdin
+func confirm(s *app.State, prompt string) (bool, error) {
+	// print the prompt (no newline, user types on the same line)
+	fmt.Fprint(s.Stdout, prompt)
+
+	// read the user's answer
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	// read check (EOF = no answer, ie NOT confirmed)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	// only an explicit yes confirms
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == "y", nil
+}
+
+// nullable time helper for output tables, NULL = nil
+func nullTime(t sql.NullTime) any {
+	// valid check
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+// nullable string helper for output tables, NULL = nil
+func nullString(str sql.NullString) any {
+	// valid check
+	if !str.Valid {
+		return nil
+	}
+	return str.String
+}
+
+// nullable int helper for output tables, NULL = nil
+func nullInt32(n sql.NullInt32) any {
+	// valid check
+	if !n.Valid {
+		return nil
+	}
+	return n.Int32
+}
@@ -0,0 +1,267 @@
+// handlers_test.go
+package handlers
+
+import (
+	// std go libs
+	"bytes"        // capturing handler output
+	"context"      // for context
+	"database/sql" // for sql errors
+	"strings"      // matching output
+	"testing"      // go tests
+	"time"         // post times
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/config"   // the logged in user
+	"github.com/PietPadda/aggregator/internal/database" // for the fake store
+	"github.com/PietPadda/aggregator/internal/output"   // for text output
+	"github.com/google/uuid"                            // for user and post ids
+)
+
+// a fake database, only what the tested handlers query
+// NOTE: the embedded Store is nil, so a query that isn't faked here panics and shows up in the test
+type fakeStore struct {
+	database.Store
+	users   []database.User
+	follows map[string]string // feed url to name
+	posts   []database.Post
+	owners  map[string]uuid.UUID // feed url to the user who added it
+}
+
+func (f *fakeStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	for _, user := range f.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) GetUsers(ctx context.Context) ([]string, error) {
+	var names []string
+	for _, user := range f.users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+func (f *fakeStore) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	var rows []database.GetFeedFollowsForUserRow
+	for _, name := range f.follows {
+		rows = append(rows, database.GetFeedFollowsForUserRow{UserID: userID, Feedname: name})
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) DeleteFeedFollowByUserAndFeed(ctx context.Context, arg database.DeleteFeedFollowByUserAndFeedParams) (database.FeedFollow, error) {
+	if _, ok := f.follows[arg.Url]; !ok {
+		return database.FeedFollow{}, sql.ErrNoRows
+	}
+	delete(f.follows, arg.Url)
+	return database.FeedFollow{UserID: arg.UserID}, nil
+}
+
+func (f *fakeStore) ListFeedsWithCreator(ctx context.Context) ([]database.ListFeedsWithCreatorRow, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) ListHostBreakers(ctx context.Context) ([]database.HostBreaker, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.Post, error) {
+	if int(arg.PostLimit) < len(f.posts) {
+		return f.posts[:arg.PostLimit], nil
+	}
+	return f.posts, nil
+}
+
+func (f *fakeStore) ListTranslateFeeds(ctx context.Context, userID uuid.UUID) ([]database.ListTranslateFeedsRow, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) GetPostSummaries(ctx context.Context, postIDs []uuid.UUID) ([]database.PostSummary, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) CountNewShares(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeStore) GetFeedOwner(ctx context.Context, url string) (uuid.UUID, error) {
+	owner, ok := f.owners[url]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	return owner, nil
+}
+
+func (f *fakeStore) CountAdmins(ctx context.Context) (int64, error) {
+	var admins int64
+	for _, user := range f.users {
+		if user.IsAdmin {
+			admins++
+		}
+	}
+	return admins, nil
+}
+
+func (f *fakeStore) SetFeedSchedule(ctx context.Context, arg database.SetFeedScheduleParams) (int64, error) {
+	return 1, nil
+}
+
+func (f *fakeStore) SetFeedRefreshInterval(ctx context.Context, arg database.SetFeedRefreshIntervalParams) (int64, error) {
+	return 1, nil
+}
+
+// a state with a fake database and the output in a buffer, logged in as the first user
+func newTestState(t *testing.T, store *fakeStore) (*app.State, *bytes.Buffer) {
+	t.Helper()
+	name := store.users[0].Name
+	var out bytes.Buffer
+	return &app.State{
+		Config: &config.Config{Name: &name},
+		DB:     store,
+		Output: output.Text,
+		Stdout: &out,
+		Stderr: &bytes.Buffer{},
+	}, &out
+}
+
+// a fake database with one user following one feed with a post
+func newTestStore() *fakeStore {
+	return &fakeStore{
+		users:   []database.User{{ID: uuid.New(), Name: "kahya"}, {ID: uuid.New(), Name: "holgith"}},
+		follows: map[string]string{"https://blog.boot.dev/index.xml": "Boot.dev Blog"},
+		posts: []database.Post{
+			{ID: uuid.New(), Title: "Learn Go", Url: "https://blog.boot.dev/go", CreatedAt: time.Now()},
+		},
+	}
+}
+
+// the handlers return to their caller (main, the bot, the daemon) instead of exiting, with their output in Stdout
+func TestHandlersWriteToStdout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(context.Context, *app.State, app.Command) error
+		args    []string
+		want    string
+	}{
+		{"users", HandlerGetUsers, nil, "* kahya (current)\n* holgith\n"},
+		{"feeds", HandlerFeeds, nil, "No feeds logged in database!\n"},
+		{"following", MiddlewareLoggedIn(HandlerFollowing), nil, "Feed name: Boot.dev Blog"},
+		{"unfollow", MiddlewareLoggedIn(HandlerUnfollow), []string{"https://blog.boot.dev/index.xml"}, "Feed successfully unfollowed!\n"},
+		{"unfollow not followed", MiddlewareLoggedIn(HandlerUnfollow), []string{"https://example.com/feed"}, "kahya is not following this feed!\n"},
+		{"browse", MiddlewareLoggedIn(HandlerBrowse), []string{"5"}, "Post name: Learn Go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, out := newTestState(t, newTestStore())
+			err := tt.handler(context.Background(), s, app.Command{Name: tt.name, Args: tt.args})
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output %q, want it to contain %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+// failures come back as errors, so the caller decides the exit code
+func TestHandlersReturnErrors(t *testing.T) {
+	s, out := newTestState(t, newTestStore())
+
+	// login as a user that doesn't exist
+	err := HandlerLogin(context.Background(), s, app.Command{Name: "login", Args: []string{"nobody"}})
+	if err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("login error %v, want user doesn't exist", err)
+	}
+
+	// browse with a limit that isn't a number (an error, not a log line --quiet would hide)
+	err = MiddlewareLoggedIn(HandlerBrowse)(context.Background(), s, app.Command{Name: "browse", Args: []string{"ten"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid limit") {
+		t.Errorf("browse error %v, want invalid limit", err)
+	}
+
+	// agg with --once after a duration, or extra args (both were silently ignored)
+	for _, args := range [][]string{{"1m", "--once"}, {"--once", "1m"}, {"1m", "2m"}, {"1m", "status"}} {
+		err = HandlerAgg(context.Background(), s, app.Command{Name: "agg", Args: args})
+		if err == nil || !strings.Contains(err.Error(), "usage: agg") {
+			t.Errorf("agg %v error %v, want a usage error", args, err)
+		}
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("errors printed %q to Stdout, want nothing", out.String())
+	}
+}
+
+// refresh intervals are whole seconds that fit the int32 column
+func TestParseRefreshInterval(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    sql.NullInt32
+		wantErr bool
+	}{
+		{"default", sql.NullInt32{}, false},
+		{"0", sql.NullInt32{}, false},
+		{"1h", sql.NullInt32{Int32: 3600, Valid: true}, false},
+		{"500ms", sql.NullInt32{}, true},
+		{"soon", sql.NullInt32{}, true},
+		{"596523h", sql.NullInt32{Int32: 596523 * 3600, Valid: true}, false},
+		{"596524h", sql.NullInt32{}, true}, // would wrap around to a negative interval
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseRefreshInterval(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRefreshInterval(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRefreshInterval(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// only the user who added a feed, or an admin, changes when it's fetched
+func TestFeedSchedulingNeedsOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(context.Context, *app.State, app.Command, database.User) error
+		args    []string
+	}{
+		{"setschedule", HandlerSetSchedule, []string{"0 7 * * *"}},
+		{"setschedule none", HandlerSetSchedule, []string{"none"}},
+		{"setinterval", HandlerSetInterval, []string{"1h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore()
+			store.users[1].IsAdmin = true // holgith
+			feedURL := "https://blog.boot.dev/index.xml"
+			store.owners = map[string]uuid.UUID{feedURL: store.users[1].ID}
+			args := append([]string{feedURL}, tt.args...)
+
+			// someone else's feed, refused (kahya isn't an admin)
+			s, _ := newTestState(t, store)
+			err := MiddlewareLoggedIn(tt.handler)(context.Background(), s, app.Command{Name: tt.name, Args: args})
+			if err == nil || !strings.Contains(err.Error(), "needs an admin") {
+				t.Errorf("non-owner error = %v, want it refused", err)
+			}
+
+			// own feed
+			err = tt.handler(context.Background(), s, app.Command{Name: tt.name, Args: args}, store.users[1])
+			if err != nil {
+				t.Errorf("owner error = %v", err)
+			}
+
+			// unknown feed
+			err = MiddlewareLoggedIn(tt.handler)(context.Background(), s, app.Command{Name: tt.name, Args: append([]string{"https://example.com/feed"}, tt.args...)})
+			if err == nil || !strings.Contains(err.Error(), "no feed found") {
+				t.Errorf("unknown feed error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// images.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"log/slog" // logging failed downloads
+	"strings"  // image enclosures
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"        // for State
+	"github.com/PietPadda/aggregator/internal/extract"    // images in descriptions
+	"github.com/PietPadda/aggregator/internal/imagecache" // the cache
+	"github.com/PietPadda/aggregator/internal/rssfeed"    // enclosures
+)
+
+// most images cached per post, the first ones are the thumbnail and what's above the fold
+const maxPostImages = 3
+
+// a post's images helper, its image enclosures then the images in its description (tracking pixels left out)
+func postImageURLs(description string, enclosures []rssfeed.Enclosure) []string {
+	var images []string
+	for _, enclosure := range enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") && enclosure.URL != "" {
+			images = append(images, enclosure.URL)
+		}
+	}
+	images = append(images, extract.Images(description)...)
+	return images[:min(len(images), maxPostImages)]
+}
+
+// the image cache helper, nil when cache_images is off (or there's no home dir to keep it in)
+func imageCache(s *app.State) *imagecache.Cache {
+	// enabled check
+	if !s.Config.CacheImages {
+		return nil
+	}
+
+	// get the dir
+	dir, err := s.Config.ImageCachePath()
+	if err != nil {
+		slog.Warn("image cache disabled", "err", err)
+		return nil
+	}
+	return imagecache.New(dir)
+}
+
+// download new posts' images into the cache helper (cache_images, errors are logged, the posts are stored already)
+func cacheImages(ctx context.Context, s *app.State, images []string) {
+	// cache check
+	cache := imageCache(s)
+	if cache == nil {
+		return
+	}
+
+	// download each, unless a post of another feed had it already
+	cached := 0
+	for _, imageURL := range images {
+		// stopping check
+		if ctx.Err() != nil {
+			return
+		}
+		if cache.Has(imageURL) {
+			continue
+		}
+
+		// download it
+		data, err := httpClient(s).FetchImage(ctx, imageURL)
+		if err == nil {
+			err = cache.Store(imageURL, data)
+		}
+
+		// download check
+		if err != nil {
+			slog.Debug("error caching image", "url", imageURL, "err", err)
+			continue
+		}
+		cached++
+	}
+	if cached > 0 {
+		slog.Debug("cached images", "count", cached)
+	}
+}
@@ -0,0 +1,349 @@
+// import.go
+package handlers
+
+import (
+	// std go libs
+	"bufio"    // reading the answers
+	"context"  // for context
+	"errors"   // matching io.EOF
+	"fmt"      // print errors
+	"io"       // end of the answers
+	"log/slog" // sites without feeds
+	"net/url"  // resolving feed links
+	"os"       // opening the export, answers from stdin
+	"strings"  // answers and hosts
+	"sync"     // discovering in parallel
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/bookmarks" // parsing browser exports
+	"github.com/PietPadda/aggregator/internal/database"  // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/extract"   // finding feed links
+	"github.com/PietPadda/aggregator/internal/progress"  // discovery progress
+	"github.com/PietPadda/aggregator/internal/readers"   // other readers' data
+	"github.com/PietPadda/aggregator/internal/urlnorm"   // comparing feed urls
+)
+
+// sites discovered at once, each is a page fetch (or two)
+const discoverConcurrency = 8
+
+// a site to look for feeds on, from its bookmarks
+type bookmarkedSite struct {
+	host     string             // without www.
+	bookmark bookmarks.Bookmark // its first bookmark, where discovery starts
+}
+
+// a feed found on a bookmarked site, to offer
+type feedCandidate struct {
+	name     string             // the page's title, or the link's
+	url      string             // absolute and normalized
+	bookmark bookmarks.Bookmark // where it was found
+}
+
+// import handler logic
+// NOTE: cmd will be import bookmarks <file> [--yes], finds the feeds of the bookmarked sites and offers to follow them
+// NOTE: or import miniflux <url> <api key> | import freshrss <url> <user> <api password>, brings over another reader's data
+// NOTE: or import csv <file> [--dry-run], follows the feeds of a name,url,folder list
+// NOTE: or import states <file>, brings back the read and starred items of a state export (see export --states)
+// NOTE: or import <file>, brings back a user data export (see export --all)
+func HandlerImport(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// strip the optional yes flag from the args (follow all, no questions), and csv's dry-run flag
+	args, yes := popFlag(cmd.Args, "--yes")
+	args, dryRun := popFlag(args, "--dry-run")
+
+	// usage check
+	usage := fmt.Errorf("error: usage: import <file> | import bookmarks <file> [--yes] | import csv <file> [--dry-run] | import states <file> | import miniflux <url> <api key> | import freshrss <url> <user> <api password>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	// kind check
+	switch args[0] {
+	case "bookmarks":
+		if len(args) != 2 || dryRun {
+			return fmt.Errorf("error: usage: import bookmarks <file> [--yes]")
+		}
+		return importBookmarks(ctx, s, user, args[1], yes)
+	case "csv":
+		if len(args) != 2 || yes {
+			return fmt.Errorf("error: usage: import csv <file> [--dry-run]")
+		}
+		return importCSV(ctx, s, user, args[1], dryRun)
+	case "states":
+		if len(args) != 2 || yes || dryRun {
+			return fmt.Errorf("error: usage: import states <file>")
+		}
+		return importStates(ctx, s, user, args[1])
+	case readers.Miniflux:
+		if len(args) != 3 {
+			return fmt.Errorf("error: usage: import miniflux <url> <api key>")
+		}
+		return importReader(ctx, s, user, readers.Miniflux, readers.Account{URL: args[1], Secret: args[2]})
+	case readers.FreshRSS:
+		if len(args) != 4 {
+			return fmt.Errorf("error: usage: import freshrss <url> <user> <api password>")
+		}
+		return importReader(ctx, s, user, readers.FreshRSS, readers.Account{URL: args[1], Username: args[2], Secret: args[3]})
+	}
+
+	// user data export check
+	if len(args) == 1 && !yes && !dryRun {
+		return importUserData(ctx, s, user, args[0])
+	}
+	return usage
+}
+
+// import bookmarks helper, discovers the sites' feeds and follows the ones the user picks (all with yes)
+func importBookmarks(ctx context.Context, s *app.State, user database.User, path string, yes bool) error {
+	// open the export
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening bookmarks: %w", err)
+	}
+	defer file.Close()
+
+	// parse it
+	marks, err := bookmarks.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	// one discovery per site, the first bookmark of each
+	var sites []bookmarkedSite
+	seen := map[string]bool{}
+	for _, mark := range marks {
+		parsed, err := url.Parse(mark.URL)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+		host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+		sites = append(sites, bookmarkedSite{host: host, bookmark: mark})
+	}
+	if len(sites) == 0 {
+		fmt.Fprintln(s.Stdout, "No web bookmarks in the file.")
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Looking for feeds on %d bookmarked sites (%d bookmarks)...\n", len(sites), len(marks))
+
+	// discover their feeds
+	candidates, err := discoverBookmarkFeeds(ctx, s, sites)
+	if err != nil {
+		return err
+	}
+
+	// drop the feeds the user already follows
+	followed, err := s.DB.ListProfileFeeds(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting followed feeds: %w", err)
+	}
+	following := map[string]bool{}
+	for _, feed := range followed {
+		if key, err := urlnorm.Key(feed.Url); err == nil {
+			following[key] = true
+		}
+	}
+	fresh := candidates[:0]
+	for _, candidate := range candidates {
+		if key, err := urlnorm.Key(candidate.url); err != nil || !following[key] {
+			fresh = append(fresh, candidate)
+		}
+	}
+	candidates = fresh
+
+	// nothing found check
+	if len(candidates) == 0 {
+		fmt.Fprintln(s.Stdout, "No new feeds found on your bookmarked sites.")
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Found %d feeds you don't follow yet.\n\n", len(candidates))
+
+	// offer each (one reader for all the answers, a piped stdin may hold them all)
+	answers := bufio.NewReader(os.Stdin)
+	added := 0
+	for i, candidate := range candidates {
+		// stopping check
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// show it
+		fmt.Fprintf(s.Stdout, "[%d/%d] %s\n", i+1, len(candidates), candidate.name)
+		fmt.Fprintf(s.Stdout, "  Feed: %s\n", candidate.url)
+		if candidate.bookmark.Folder != "" {
+			fmt.Fprintf(s.Stdout, "  From: %s (in %s)\n", candidate.bookmark.Title, candidate.bookmark.Folder)
+		} else {
+			fmt.Fprintf(s.Stdout, "  From: %s\n", candidate.bookmark.Title)
+		}
+
+		// ask, unless it's all of them
+		if !yes {
+			fmt.Fprint(s.Stdout, "Follow it? (yes/no/quit): ")
+			answer, err := answers.ReadString('\n')
+
+			// read check (EOF = no more answers, stop)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("error reading answer: %w", err)
+			}
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer == "quit" || answer == "q" || (answer == "" && errors.Is(err, io.EOF)) {
+				fmt.Fprintln(s.Stdout)
+				break
+			}
+			if answer != "yes" && answer != "y" {
+				fmt.Fprintln(s.Stdout)
+				continue
+			}
+		}
+
+		// follow it, adding it first if it's new
+		err := followCandidate(ctx, s, user, candidate)
+		if err != nil {
+			fmt.Fprintf(s.Stdout, "  Not followed: %s\n\n", err)
+			continue
+		}
+		added++
+		fmt.Fprintln(s.Stdout)
+	}
+
+	// print the total
+	fmt.Fprintf(s.Stdout, "Followed %d of %d feeds found.\n", added, len(candidates))
+	return nil
+}
+
+// discover the bookmarked sites' feeds helper, in parallel (sites without any are skipped)
+// NOTE: the bookmarked page first, then the site's home page if it links none
+func discoverBookmarkFeeds(ctx context.Context, s *app.State, sites []bookmarkedSite) ([]feedCandidate, error) {
+	// discover them in parallel
+	results := make([][]feedCandidate, len(sites))
+	bar := progress.New(s.Stderr, len(sites), "sites")
+	var mu sync.Mutex // guards the bar
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for range discoverConcurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = discoverSiteFeeds(ctx, s, sites[i])
+				mu.Lock()
+				bar.Step()
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range sites {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	bar.Done()
+
+	// stopping check, sites that failed because of it would look feedless
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// all of them, without repeats (sites can share a feed)
+	var candidates []feedCandidate
+	seen := map[string]bool{}
+	for _, found := range results {
+		for _, candidate := range found {
+			if seen[candidate.url] {
+				continue
+			}
+			seen[candidate.url] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+// discover one bookmarked site's feeds helper
+func discoverSiteFeeds(ctx context.Context, s *app.State, site bookmarkedSite) []feedCandidate {
+	// the pages to try
+	pages := []string{site.bookmark.URL}
+	if home, err := url.Parse(site.bookmark.URL); err == nil {
+		home.Path, home.RawPath, home.RawQuery, home.Fragment = "/", "", "", ""
+		if home.String() != site.bookmark.URL {
+			pages = append(pages, home.String())
+		}
+	}
+
+	// the first page linking feeds wins
+	for _, pageURL := range pages {
+		page, err := httpClient(s).FetchPage(ctx, pageURL)
+
+		// fetch check
+		if err != nil {
+			slog.Debug("error discovering feeds", "url", pageURL, "err", err)
+			continue
+		}
+
+		// the page's feeds
+		links := extract.FeedLinks(string(page))
+		if len(links) == 0 {
+			continue
+		}
+		base, err := url.Parse(pageURL)
+		if err != nil {
+			return nil
+		}
+		title := extract.Title(string(page))
+		var candidates []feedCandidate
+		for _, link := range links {
+			// absolute url, checked like addfeed
+			ref, err := url.Parse(link.URL)
+			if err != nil {
+				continue
+			}
+			feedURL, err := urlnorm.Clean(base.ResolveReference(ref).String())
+			if err != nil {
+				continue
+			}
+
+			// a name, the page's title unless the links tell several feeds apart (alone they're often just "RSS")
+			name := title
+			if name == "" || len(links) > 1 && link.Title != "" {
+				name = link.Title
+			}
+			if name == "" {
+				name = site.host
+			}
+			candidates = append(candidates, feedCandidate{name: name, url: feedURL, bookmark: site.bookmark})
+		}
+		return candidates
+	}
+	return nil
+}
+
+// follow a discovered feed helper, adding it first if nobody has yet
+func followCandidate(ctx context.Context, s *app.State, user database.User, candidate feedCandidate) error {
+	// stored check, maybe with an equivalent url
+	existingURL, deleted, err := findEquivalentFeed(ctx, s, candidate.url)
+	if err != nil {
+		return err
+	}
+
+	// removed feed check, it still holds its url
+	if deleted {
+		return fmt.Errorf("feed %s was removed, restore it with: undelete feed %s", existingURL, existingURL)
+	}
+
+	// stored, just follow it
+	if existingURL != "" {
+		return followFeed(ctx, s, user, existingURL)
+	}
+
+	// add it and follow it (like seed)
+	_, _, err = seedFollow(ctx, s, user, seedFeed{name: candidate.name, url: candidate.url})
+	return err
+}
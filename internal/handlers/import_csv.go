@@ -0,0 +1,226 @@
+// import_csv.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"encoding/csv" // reading the feed list
+	"errors"       // matching io.EOF
+	"fmt"          // print errors
+	"io"           // end of the feed list
+	"os"           // opening the feed list
+	"strings"      // header and error text
+
+	// external packages
+	"github.com/google/uuid" // folder ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/readers"  // following like the reader imports
+	"github.com/PietPadda/aggregator/internal/urlnorm"  // checking and comparing feed urls
+)
+
+// a valid row of a csv feed list
+type csvFeed struct {
+	line   int    // in the file, for messages
+	name   string // the url if the row has none
+	url    string // normalized
+	folder string // "" = not in a folder
+}
+
+// import a csv feed list helper (name,url,folder per row, the folder is optional)
+// every row is checked first, bad ones are listed and skipped, dryRun only says what would be done
+func importCSV(ctx context.Context, s *app.State, user database.User, path string, dryRun bool) error {
+	// open the list
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening feed list: %w", err)
+	}
+	defer file.Close()
+
+	// check its rows
+	feeds, invalid, err := readCSVFeeds(file)
+	if err != nil {
+		return fmt.Errorf("error reading feed list %s: %w", path, err)
+	}
+	for _, problem := range invalid {
+		fmt.Fprintf(s.Stdout, "  %s\n", problem)
+	}
+	if len(feeds) == 0 {
+		fmt.Fprintf(s.Stdout, "No valid rows in %s, expected name,url,folder per row.\n", path)
+		return nil
+	}
+
+	// dry-run check
+	if dryRun {
+		return previewCSVFeeds(ctx, s, user, feeds, len(invalid))
+	}
+
+	// follow each, putting new follows in their folder (created if needed)
+	followed, already, failed := 0, 0, 0
+	folders := map[string]uuid.UUID{}
+	for _, feed := range feeds {
+		// stopping check
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// follow it
+		row, isNew, err := importFollow(ctx, s, user, readers.Feed{Title: feed.name, URL: feed.url})
+		if err != nil {
+			fmt.Fprintf(s.Stdout, "  line %d: not followed: %s (%s)\n", feed.line, feed.url, strings.TrimPrefix(err.Error(), "error: "))
+			failed++
+			continue
+		}
+		if !isNew {
+			already++
+			continue
+		}
+		followed++
+
+		// folder check
+		if feed.folder == "" {
+			continue
+		}
+		folderID, ok := folders[feed.folder]
+		if !ok {
+			folderID, err = importFolder(ctx, s, user, feed.folder)
+			if err != nil {
+				return err
+			}
+			folders[feed.folder] = folderID
+		}
+		_, err = s.DB.MoveFeedFollowToFolder(ctx, database.MoveFeedFollowToFolderParams{
+			FolderID: uuid.NullUUID{UUID: folderID, Valid: true},
+			Url:      row.Url,
+			UserID:   user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error moving feed %s to folder %s: %w", row.Url, feed.folder, err)
+		}
+	}
+
+	// print the totals
+	fmt.Fprintf(s.Stdout, "\nFollowed %d new feeds (%d already followed, %d failed, %d invalid rows) in %d folders.\n", followed, already, failed, len(invalid), len(folders))
+	return nil
+}
+
+// read and check a csv feed list helper, returns the valid rows and what's wrong with the others
+// NOTE: a first row with url in its second column is a header, and is skipped
+func readCSVFeeds(r io.Reader) ([]csvFeed, []string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // counted per row below, so one bad row doesn't stop the rest
+	reader.TrimLeadingSpace = true
+
+	var feeds []csvFeed
+	var invalid []string
+	seen := map[string]int{} // url key -> line
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err // a quoting error, the rest of the file can't be trusted
+		}
+		line, _ := reader.FieldPos(0)
+
+		// header check
+		if first && len(record) >= 2 && strings.EqualFold(strings.TrimSpace(record[1]), "url") {
+			continue
+		}
+
+		// columns check
+		if len(record) < 2 || len(record) > 3 {
+			invalid = append(invalid, fmt.Sprintf("line %d: expected name,url[,folder], got %d columns", line, len(record)))
+			continue
+		}
+		feed := csvFeed{line: line, name: strings.TrimSpace(record[0])}
+		if len(record) == 3 {
+			feed.folder = strings.TrimSpace(record[2])
+		}
+
+		// url check
+		rawURL := strings.TrimSpace(record[1])
+		if rawURL == "" {
+			invalid = append(invalid, fmt.Sprintf("line %d: no url", line))
+			continue
+		}
+		feed.url, err = urlnorm.Clean(rawURL) // fetched as given, repeats are spotted by key
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("line %d: %s", line, strings.TrimPrefix(err.Error(), "error: ")))
+			continue
+		}
+
+		// repeated feed check
+		key, err := urlnorm.Key(feed.url)
+		if err != nil {
+			key = feed.url
+		}
+		if earlier, ok := seen[key]; ok {
+			invalid = append(invalid, fmt.Sprintf("line %d: %s is already on line %d", line, feed.url, earlier))
+			continue
+		}
+		seen[key] = line
+
+		// name check
+		if feed.name == "" {
+			feed.name = feed.url
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, invalid, nil
+}
+
+// import csv --dry-run helper, says what importing each valid row would do
+func previewCSVFeeds(ctx context.Context, s *app.State, user database.User, feeds []csvFeed, invalid int) error {
+	// the feeds the user follows
+	followed, err := s.DB.ListProfileFeeds(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting followed feeds: %w", err)
+	}
+	following := map[string]bool{}
+	for _, feed := range followed {
+		if key, err := urlnorm.Key(feed.Url); err == nil {
+			following[key] = true
+		}
+	}
+
+	// each row
+	follow, add, already, removed := 0, 0, 0, 0
+	for _, feed := range feeds {
+		where := ""
+		if feed.folder != "" {
+			where = " in " + feed.folder
+		}
+
+		// followed check
+		if key, err := urlnorm.Key(feed.url); err == nil && following[key] {
+			fmt.Fprintf(s.Stdout, "  line %d: already following %s\n", feed.line, feed.url)
+			already++
+			continue
+		}
+
+		// stored check
+		storedURL, deleted, err := findEquivalentFeed(ctx, s, feed.url)
+		if err != nil {
+			return err
+		}
+		switch {
+		case deleted:
+			fmt.Fprintf(s.Stdout, "  line %d: can't follow %s, it was removed (undelete feed %s)\n", feed.line, feed.url, storedURL)
+			removed++
+		case storedURL != "":
+			fmt.Fprintf(s.Stdout, "  line %d: would follow %s%s\n", feed.line, storedURL, where)
+			follow++
+		default:
+			fmt.Fprintf(s.Stdout, "  line %d: would add and follow %s as %q%s\n", feed.line, feed.url, feed.name, where)
+			add++
+		}
+	}
+
+	// print the totals
+	fmt.Fprintf(s.Stdout, "\nDry run: would follow %d feeds and add %d new ones (%d already followed, %d removed, %d invalid rows). Nothing was changed.\n", follow, add, already, removed, invalid)
+	return nil
+}
@@ -0,0 +1,199 @@
+// import_readers.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"strings"      // already following errors
+	"time"         // created at
+
+	// external packages
+	"github.com/google/uuid" // post and folder ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/lang"     // post languages
+	"github.com/PietPadda/aggregator/internal/readers"  // the other readers' apis
+	"github.com/PietPadda/aggregator/internal/readtime" // post reading times
+	"github.com/PietPadda/aggregator/internal/urlnorm"  // comparing feed urls, cleaning post links
+)
+
+// import from another reader helper, follows its feeds (in folders named after its categories)
+// and brings over its starred and newest read entries as posts with their state
+func importReader(ctx context.Context, s *app.State, user database.User, reader string, account readers.Account) error {
+	// get the reader's data
+	fmt.Fprintf(s.Stdout, "Reading %s at %s...\n", reader, account.URL)
+	export, err := readers.Fetch(ctx, reader, account)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Found %d feeds and %d starred or read entries.\n\n", len(export.Feeds), len(export.Entries))
+	return applyReaderExport(ctx, s, user, export)
+}
+
+// bring another reader's data into gator helper (import and sync), only ever adding follows, posts and states
+func applyReaderExport(ctx context.Context, s *app.State, user database.User, export readers.Export) error {
+	// follow the feeds, noting where each is stored
+	stored := map[string]database.GetFeedByURLRow{}
+	followed, failed := 0, 0
+	folders := map[string]uuid.UUID{}
+	for _, feed := range export.Feeds {
+		// stopping check
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// follow it
+		row, isNew, err := importFollow(ctx, s, user, feed)
+		if err != nil {
+			fmt.Fprintf(s.Stdout, "  Not followed: %s (%s)\n", feed.URL, err)
+			failed++
+			continue
+		}
+		stored[feed.URL] = row
+		if !isNew {
+			continue
+		}
+		followed++
+
+		// put new follows in their category's folder (created if needed)
+		if feed.Category == "" {
+			continue
+		}
+		folderID, ok := folders[feed.Category]
+		if !ok {
+			folderID, err = importFolder(ctx, s, user, feed.Category)
+			if err != nil {
+				return err
+			}
+			folders[feed.Category] = folderID
+		}
+		_, err = s.DB.MoveFeedFollowToFolder(ctx, database.MoveFeedFollowToFolderParams{
+			FolderID: uuid.NullUUID{UUID: folderID, Valid: true},
+			Url:      row.Url,
+			UserID:   user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error moving feed %s to folder %s: %w", row.Url, feed.Category, err)
+		}
+	}
+
+	// store the entries as posts of their feeds (posts already stored are kept as they are)
+	batches := map[string]*database.InsertPostsParams{}
+	states := database.ImportPostStatesParams{UserID: user.ID}
+	createdAt := time.Now()
+	for _, entry := range export.Entries {
+		// the post's url, cleaned like agg cleans links
+		postURL := urlnorm.StripTracking(entry.URL)
+		states.Urls = append(states.Urls, postURL)
+		states.Reads = append(states.Reads, entry.Read)
+		states.Saves = append(states.Saves, entry.Starred)
+
+		// feed not followed check, its state still applies if the post is stored
+		feed, ok := stored[entry.FeedURL]
+		if !ok || entry.Title == "" {
+			continue
+		}
+		batch := batches[feed.Url]
+		if batch == nil {
+			batch = &database.InsertPostsParams{CreatedAt: createdAt, FeedID: feed.ID}
+			batches[feed.Url] = batch
+		}
+		batch.Ids = append(batch.Ids, uuid.New())
+		batch.Titles = append(batch.Titles, entry.Title)
+		batch.Urls = append(batch.Urls, postURL)
+		batch.Descriptions = append(batch.Descriptions, entry.Content)
+		batch.PublishedAts = append(batch.PublishedAts, entry.PublishedAt)
+		batch.Languages = append(batch.Languages, lang.Detect(entry.Title+"\n"+entry.Content))
+		batch.ReadingMinutes = append(batch.ReadingMinutes, int32(readtime.Minutes(entry.Content)))
+		batch.Guids = append(batch.Guids, "") // the reader's entry ids aren't the feed's guids
+	}
+	posts := 0
+	for _, batch := range batches {
+		added, err := s.DB.InsertPosts(ctx, *batch)
+		if err != nil {
+			return fmt.Errorf("error storing imported posts: %w", err)
+		}
+		posts += len(added)
+	}
+
+	// then their states
+	var marked int64
+	if len(states.Urls) > 0 {
+		rows, err := s.DB.ImportPostStates(ctx, states)
+		if err != nil {
+			return fmt.Errorf("error storing read and starred states: %w", err)
+		}
+		marked = rows
+	}
+
+	// print the totals
+	fmt.Fprintf(s.Stdout, "\nFollowed %d new feeds (%d already followed, %d failed) in %d folders.\n", followed, len(stored)-followed, failed, len(folders))
+	fmt.Fprintf(s.Stdout, "Imported %d posts, and the read or starred state of %d.\n", posts, marked)
+	return nil
+}
+
+// follow an imported feed helper, adding it first if nobody has yet
+// returns the stored feed, and whether the follow is new
+func importFollow(ctx context.Context, s *app.State, user database.User, feed readers.Feed) (database.GetFeedByURLRow, bool, error) {
+	// check it like addfeed, it's fetched as given
+	feedURL, err := urlnorm.Clean(feed.URL)
+	if err != nil {
+		return database.GetFeedByURLRow{}, false, err
+	}
+	name := feed.Title
+	if name == "" {
+		name = feedURL
+	}
+
+	// follow it (an existing follow is fine)
+	isNew := true
+	err = followCandidate(ctx, s, user, feedCandidate{name: name, url: feedURL})
+	if err != nil && strings.Contains(err.Error(), "already following") {
+		isNew, err = false, nil
+	}
+	if err != nil {
+		return database.GetFeedByURLRow{}, false, err
+	}
+
+	// where it's stored, maybe under an equivalent url
+	storedURL, _, err := findEquivalentFeed(ctx, s, feedURL)
+	if err != nil {
+		return database.GetFeedByURLRow{}, false, err
+	}
+	row, err := s.DB.GetFeedByURL(ctx, storedURL)
+	if err != nil {
+		return database.GetFeedByURLRow{}, false, fmt.Errorf("error getting feed %s: %w", storedURL, err)
+	}
+	return row, isNew, nil
+}
+
+// get or create a folder of the user's helper
+func importFolder(ctx context.Context, s *app.State, user database.User, name string) (uuid.UUID, error) {
+	// existing check
+	folder, err := s.DB.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: name})
+	if err == nil {
+		return folder.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("error getting folder %s: %w", name, err)
+	}
+
+	// create it
+	now := time.Now()
+	folder, err = s.DB.CreateFolder(ctx, database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error creating folder %s: %w", name, err)
+	}
+	return folder.ID, nil
+}
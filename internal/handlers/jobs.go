@@ -0,0 +1,305 @@
+// jobs.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // nullable job columns
+	"fmt"          // print errors
+	"log/slog"     // structured logging
+	"sort"         // jobs by priority
+	"strconv"      // parse the limit
+	"time"         // run after and ages
+
+	// external packages
+	"github.com/google/uuid" // feed ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+)
+
+// fetch job queue settings
+const fetchJobAttempts = 3                  // tries per job before it's marked failed
+const fetchJobBackoff = time.Minute         // wait before the first retry, x4 for each one after
+const fetchJobStaleAfter = 30 * time.Minute // running jobs without a worker older than this were left behind
+const fetchJobHistory = 7 * 24 * time.Hour  // how long finished jobs are kept for jobs
+
+// queue the due feeds and claim the next fetch jobs for a worker helper
+// returns the claimed jobs (new feeds first) and their feeds, removed feeds are missing from the map
+func claimFetchJobs(ctx context.Context, s *app.State, worker *aggWorker, fallback time.Duration, batch int32) ([]database.FetchJob, map[uuid.UUID]database.Feed, error) {
+	now := time.Now().UTC() // job times are stored in UTC, like last_fetched_at
+
+	// hand jobs dead workers left running back to the queue, the live ones pick them up
+	requeued, err := s.DB.RequeueOrphanedFetchJobs(ctx, database.RequeueOrphanedFetchJobsParams{
+		StartedBefore:   now.Add(-fetchJobStaleAfter),
+		HeartbeatBefore: now.Add(-workerDeadAfter),
+	})
+
+	// requeue check
+	if err != nil {
+		return nil, nil, fmt.Errorf("error requeueing orphaned fetch jobs: %w", err)
+	}
+	if requeued > 0 {
+		slog.Warn("requeued fetch jobs of dead agg workers", "jobs", requeued)
+	}
+
+	// forget the dead workers
+	_, err = s.DB.DeleteDeadAggWorkers(ctx, now.Add(-workerDeadAfter))
+
+	// delete workers check
+	if err != nil {
+		return nil, nil, fmt.Errorf("error deleting dead agg workers: %w", err)
+	}
+
+	// forget old finished jobs
+	_, err = s.DB.DeleteOldFetchJobs(ctx, sql.NullTime{Time: now.Add(-fetchJobHistory), Valid: true})
+
+	// delete check
+	if err != nil {
+		return nil, nil, fmt.Errorf("error deleting old fetch jobs: %w", err)
+	}
+
+	// queue a job for each due feed without one
+	queued, err := s.DB.EnqueueDueFeeds(ctx, database.EnqueueDueFeedsParams{
+		Now:             now,
+		FallbackSeconds: int32(fallback / time.Second),
+	})
+
+	// enqueue check
+	if err != nil {
+		return nil, nil, fmt.Errorf("error queueing due feeds: %w", err)
+	}
+	slog.Debug("queued due feeds", "jobs", queued)
+
+	// claim the next pending jobs (at most batch of them, 0 = all)
+	jobs, err := s.DB.ClaimFetchJobs(ctx, database.ClaimFetchJobsParams{
+		WorkerID:  worker.ID(),
+		Now:       now,
+		BatchSize: sql.NullInt32{Int32: batch, Valid: batch > 0},
+	})
+
+	// claim check
+	if err != nil {
+		return nil, nil, fmt.Errorf("error claiming fetch jobs: %w", err)
+	}
+
+	// nothing to do check
+	if len(jobs) == 0 {
+		return nil, nil, nil
+	}
+
+	// RETURNING has no order, so start the new feeds first again
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		return jobs[i].RunAfter.Before(jobs[j].RunAfter)
+	})
+
+	// get the jobs' feeds
+	ids := make([]uuid.UUID, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.FeedID
+	}
+	feeds, err := s.DB.GetFeedsByIDs(ctx, ids)
+
+	// get feeds check (hand the jobs back, they weren't started)
+	if err != nil {
+		for _, job := range jobs {
+			releaseFetchJob(ctx, s, job)
+		}
+		return nil, nil, fmt.Errorf("error getting feeds to fetch: %w", err)
+	}
+	byID := make(map[uuid.UUID]database.Feed, len(feeds))
+	for _, feed := range feeds {
+		byID[feed.ID] = feed
+	}
+
+	// return the jobs and their feeds
+	return jobs, byID, nil
+}
+
+// finish a fetch job helper, done on success, else retried with backoff until it runs out of attempts
+// returns the database error, if any (it's logged already)
+func finishFetchJob(ctx context.Context, s *app.State, job database.FetchJob, fetchErr error) error {
+	now := time.Now().UTC()
+	var err error
+
+	// outcome check
+	switch {
+	case fetchErr == nil:
+		err = s.DB.FinishFetchJob(ctx, database.FinishFetchJobParams{
+			ID:         job.ID,
+			State:      "done",
+			FinishedAt: sql.NullTime{Time: now, Valid: true},
+			WorkerID:   job.WorkerID,
+		})
+	case job.Attempts < fetchJobAttempts:
+		// retry after 1m, 4m, 16m...
+		backoff := fetchJobBackoff << (2 * (job.Attempts - 1))
+		err = s.DB.RetryFetchJob(ctx, database.RetryFetchJobParams{
+			ID:        job.ID,
+			RunAfter:  now.Add(backoff),
+			LastError: sql.NullString{String: fetchErr.Error(), Valid: true},
+			WorkerID:  job.WorkerID,
+		})
+		slog.Info("fetch job will be retried", "job", job.ID, "attempt", job.Attempts, "in", backoff)
+	default:
+		// out of attempts, the feed waits for its next turn
+		err = s.DB.FinishFetchJob(ctx, database.FinishFetchJobParams{
+			ID:         job.ID,
+			State:      "failed",
+			FinishedAt: sql.NullTime{Time: now, Valid: true},
+			LastError:  sql.NullString{String: fetchErr.Error(), Valid: true},
+			WorkerID:   job.WorkerID,
+		})
+	}
+
+	// update check (just log it, an orphaned job is requeued eventually)
+	if err != nil {
+		slog.Warn("error updating fetch job", "job", job.ID, "err", err)
+	}
+	return err
+}
+
+// put a fetch job off until its host's circuit closes helper, the attempt doesn't count
+func deferFetchJob(ctx context.Context, s *app.State, job database.FetchJob, until time.Time, reason error) {
+	err := s.DB.DeferFetchJob(ctx, database.DeferFetchJobParams{
+		ID:        job.ID,
+		RunAfter:  until,
+		LastError: sql.NullString{String: reason.Error(), Valid: true},
+		WorkerID:  job.WorkerID,
+	})
+
+	// defer check (just log it, an orphaned job is requeued eventually)
+	if err != nil {
+		slog.Warn("error deferring fetch job", "job", job.ID, "err", err)
+	}
+}
+
+// hand an unstarted or interrupted fetch job back to the queue helper, the attempt doesn't count
+func releaseFetchJob(ctx context.Context, s *app.State, job database.FetchJob) {
+	// release the job, even on ctrl+c (that's usually why!)
+	err := s.DB.ReleaseFetchJob(context.WithoutCancel(ctx), database.ReleaseFetchJobParams{ID: job.ID, WorkerID: job.WorkerID})
+
+	// release check (just log it, an orphaned job is requeued eventually)
+	if err != nil {
+		slog.Warn("error releasing fetch job", "job", job.ID, "err", err)
+	}
+}
+
+// jobs handler logic
+// NOTE: cmd will be jobs [pending|running|failed|done] [limit], shows agg's fetch job queue
+func HandlerJobs(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// no state check, show the backlog
+	if len(cmd.Args) == 0 {
+		return jobBacklog(ctx, s)
+	}
+
+	// state arg check
+	state := cmd.Args[0]
+	switch state {
+	case "pending", "running", "failed", "done":
+	default:
+		return fmt.Errorf("error: unknown job state %q (use pending, running, failed or done)", state)
+	}
+
+	// limit check (defaults to 20)
+	limit := 20
+	if len(cmd.Args) > 1 {
+		parsed, err := strconv.Atoi(cmd.Args[1])
+
+		// parse check
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("error: invalid limit %q (use a positive number)", cmd.Args[1])
+		}
+		limit = parsed
+	}
+	return listJobs(ctx, s, state, int32(limit))
+}
+
+// jobs helper, the jobs per state
+func jobBacklog(ctx context.Context, s *app.State) error {
+	// count the jobs
+	counts, err := s.DB.CountFetchJobs(ctx)
+
+	// count check
+	if err != nil {
+		return fmt.Errorf("error counting fetch jobs: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"state", "jobs", "oldest"}}
+		for _, count := range counts {
+			table.Add(count.State, count.Jobs, count.Oldest)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// empty queue check
+	if len(counts) == 0 {
+		fmt.Fprintln(s.Stdout, "No fetch jobs yet, agg queues them as feeds fall due.")
+		return nil
+	}
+
+	// print the counts
+	now := time.Now().UTC()
+	fmt.Fprintln(s.Stdout, "Fetch jobs:")
+	for _, count := range counts {
+		fmt.Fprintf(s.Stdout, "* %-8s %d (oldest %v ago)\n", count.State, count.Jobs, now.Sub(count.Oldest).Round(time.Second))
+	}
+
+	// return success
+	return nil
+}
+
+// jobs <state> helper, the latest jobs in a state
+func listJobs(ctx context.Context, s *app.State, state string, limit int32) error {
+	// get the jobs
+	jobs, err := s.DB.ListFetchJobs(ctx, database.ListFetchJobsParams{State: state, Limit: limit})
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing fetch jobs: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"id", "feed", "url", "state", "priority", "attempts", "run_after", "started_at", "finished_at", "last_error"}}
+		for _, job := range jobs {
+			var lastError any
+			if job.LastError.Valid {
+				lastError = job.LastError.String
+			}
+			table.Add(job.ID, job.FeedName, job.FeedUrl, job.State, job.Priority, job.Attempts, job.RunAfter,
+				optionalTime(job.StartedAt.Time), optionalTime(job.FinishedAt.Time), lastError)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no jobs check
+	if len(jobs) == 0 {
+		fmt.Fprintf(s.Stdout, "No %s fetch jobs.\n", state)
+		return nil
+	}
+
+	// print the jobs
+	fmt.Fprintf(s.Stdout, "Latest %s fetch jobs:\n", state)
+	for _, job := range jobs {
+		fmt.Fprintf(s.Stdout, "* #%d %s (%s), attempt %d, run after %s\n", job.ID, job.FeedName, job.FeedUrl, job.Attempts, job.RunAfter.Format(time.DateTime))
+		if job.LastError.Valid {
+			fmt.Fprintf(s.Stdout, "  last error: %s\n", job.LastError.String)
+		}
+	}
+
+	// return success
+	return nil
+}
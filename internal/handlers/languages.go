@@ -0,0 +1,125 @@
+// languages.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // for no rows
+	"errors"       // for error handling
+	"fmt"          // print errors
+	"strings"      // joining codes
+	"time"         // updated at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/lang"     // language codes
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+)
+
+// languages handler logic
+// NOTE: cmd will be languages [list] | set <code>... | clear
+// NOTE: digests and notifications skip posts detected in other languages (posts of unknown language still come through)
+func HandlerLanguages(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: languages [list] | languages set <code>... | languages clear")
+	if len(cmd.Args) == 0 {
+		return listLanguages(ctx, s, user)
+	}
+
+	// subcommand check
+	switch cmd.Args[0] {
+	case "list":
+		return listLanguages(ctx, s, user)
+	case "set":
+		if len(cmd.Args) < 2 {
+			return usage
+		}
+		return setLanguages(ctx, s, user, cmd.Args[1:])
+	case "clear":
+		_, err := s.DB.DeleteLanguagePreference(ctx, user.ID)
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error clearing languages: %w", err)
+		}
+		fmt.Fprintln(s.Stdout, "Cleared your languages, digests and notifications include every language.")
+		return nil
+	}
+	return usage
+}
+
+// languages set helper, replaces the list (codes may be space or comma separated)
+func setLanguages(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// normalize the codes, once each
+	codes := []string{}
+	seen := map[string]bool{}
+	for _, arg := range args {
+		for _, code := range strings.Split(arg, ",") {
+			if strings.TrimSpace(code) == "" {
+				continue
+			}
+			normalized := lang.Normalize(code)
+
+			// code check
+			if normalized == "" {
+				return fmt.Errorf("error: %s is not a language code (e.g. en, de, pt-BR)", code)
+			}
+			if !seen[normalized] {
+				seen[normalized] = true
+				codes = append(codes, normalized)
+			}
+		}
+	}
+
+	// empty check
+	if len(codes) == 0 {
+		return fmt.Errorf("error: no languages given, use languages clear to allow every language")
+	}
+
+	// save them
+	err := s.DB.SetLanguagePreference(ctx, database.SetLanguagePreferenceParams{
+		UserID:    user.ID,
+		UpdatedAt: time.Now().UTC(),
+		Languages: codes,
+	})
+
+	// set check
+	if err != nil {
+		return fmt.Errorf("error saving languages: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Digests and notifications now only include posts in: %s\n", strings.Join(codes, ", "))
+	return nil
+}
+
+// languages list helper
+func listLanguages(ctx context.Context, s *app.State, user database.User) error {
+	preference, err := s.DB.GetLanguagePreference(ctx, user.ID)
+
+	// get check (no rows = no preference)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error getting languages: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"language"}}
+		for _, code := range preference.Languages {
+			table.Add(code)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// empty check
+	if len(preference.Languages) == 0 {
+		fmt.Fprintln(s.Stdout, "No preferred languages, digests and notifications include every language. Set some with: languages set en de")
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Digests and notifications only include posts in: %s\n", strings.Join(preference.Languages, ", "))
+	return nil
+}
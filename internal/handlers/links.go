@@ -0,0 +1,124 @@
+// links.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"fmt"      // print errors
+	"log/slog" // logging unresolved links
+	"sync"     // resolving in parallel
+	"time"     // when links were resolved
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/rssfeed"  // feed items
+	"github.com/PietPadda/aggregator/internal/urlnorm"  // tracking params and shorteners
+)
+
+// how many shortened links are resolved at once
+const unshortenConcurrency = 4
+
+// clean a fetch's post links before they're stored helper, so the same post always gets the same url
+// feedburner's origLink replaces its redirect, tracking params are stripped,
+// and with unshorten_links known shorteners' links are resolved (once, the result is kept in short_links)
+func cleanPostLinks(ctx context.Context, s *app.State, queries database.Store, items []rssfeed.RSSItem) error {
+	// the real links, and the shortened ones left
+	var short []string
+	for i := range items {
+		if items[i].OrigLink != "" {
+			items[i].Link = items[i].OrigLink
+		}
+		if s.Config.UnshortenLinks && urlnorm.IsShortener(items[i].Link) {
+			short = append(short, items[i].Link)
+			continue
+		}
+		items[i].Link = urlnorm.StripTracking(items[i].Link)
+	}
+
+	// nothing to unshorten check
+	if len(short) == 0 {
+		return nil
+	}
+
+	// resolve the links, the known ones from the table
+	resolved, err := resolveLinks(ctx, s, queries, short)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if link, ok := resolved[items[i].Link]; ok {
+			items[i].Link = urlnorm.StripTracking(link)
+		}
+	}
+	return nil
+}
+
+// resolve shortened links helper, the ones seen before from short_links, the rest over http (then saved)
+// a link that can't be resolved resolves to itself, so its post doesn't get a second url on a later fetch
+func resolveLinks(ctx context.Context, s *app.State, queries database.Store, links []string) (map[string]string, error) {
+	// the known ones
+	known, err := queries.GetShortLinks(ctx, links)
+	if err != nil {
+		return nil, fmt.Errorf("error getting short links: %w", err)
+	}
+	resolved := make(map[string]string, len(links))
+	for _, link := range known {
+		resolved[link.Url] = link.ResolvedUrl
+	}
+
+	// the new ones, without repeats
+	var fresh []string
+	for _, link := range links {
+		if _, ok := resolved[link]; !ok {
+			resolved[link] = link
+			fresh = append(fresh, link)
+		}
+	}
+	if len(fresh) == 0 {
+		return resolved, nil
+	}
+
+	// resolve them in parallel
+	results := make([]string, len(fresh))
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for range unshortenConcurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				link, err := httpClient(s).Resolve(ctx, fresh[i])
+				if err != nil {
+					slog.Debug("could not unshorten link, keeping it", "url", fresh[i], "err", err)
+					link = fresh[i]
+				}
+				results[i] = link
+			}
+		}()
+	}
+	for i := range fresh {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	// stopping check, links that failed because of it aren't worth remembering
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// remember them
+	for i, link := range fresh {
+		resolved[link] = results[i]
+	}
+	err = queries.SaveShortLinks(ctx, database.SaveShortLinksParams{
+		CreatedAt:    time.Now(),
+		Urls:         fresh,
+		ResolvedUrls: results,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving short links: %w", err)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,106 @@
+// migrate.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"     // for State and Command
+	"github.com/PietPadda/aggregator/internal/migrate" // embedded schema migrations
+	"github.com/PietPadda/aggregator/internal/output"  // for --output formats
+)
+
+// migrate handler logic
+// NOTE: cmd will be migrate up|down|status, and state holds the embedded migrations and db connection
+// lets a fresh install create/upgrade its schema without goose or the repo
+func HandlerMigrate(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// load the embedded migrations
+	migrations, err := migrate.Load(s.Migrations)
+
+	// load check
+	if err != nil {
+		return fmt.Errorf("error loading migrations: %w", err)
+	}
+
+	// run the subcommand
+	switch cmd.Args[0] {
+	case "up":
+		// schema check, a fresh instance's schema has to exist before anything can go in it
+		if schema := s.Config.SchemaName(); schema != "" {
+			err := migrate.EnsureSchema(ctx, s.Conn, schema)
+
+			// ensure schema check
+			if err != nil {
+				return err
+			}
+		}
+
+		// apply all pending migrations
+		applied, err := migrate.Up(ctx, s.Conn, migrations)
+
+		// print the ones that did apply, even if a later one failed
+		for _, migration := range applied {
+			fmt.Fprintf(s.Stdout, "Applied %s\n", migration.Name)
+		}
+
+		// up check
+		if err != nil {
+			return err
+		}
+
+		// nothing to do check
+		if len(applied) == 0 {
+			fmt.Fprintln(s.Stdout, "Database is up to date, no migrations to apply.")
+		}
+	case "down":
+		// roll back the latest migration
+		rolledBack, err := migrate.Down(ctx, s.Conn, migrations)
+
+		// down check
+		if err != nil {
+			return err
+		}
+
+		// nothing to do check
+		if rolledBack == nil {
+			fmt.Fprintln(s.Stdout, "No migrations to roll back.")
+			return nil
+		}
+		fmt.Fprintf(s.Stdout, "Rolled back %s\n", rolledBack.Name)
+	case "status":
+		// get each migration's status
+		statuses, err := migrate.GetStatus(ctx, s.Conn, migrations)
+
+		// status check
+		if err != nil {
+			return err
+		}
+
+		// machine-readable output check
+		if s.Output != output.Text {
+			table := output.Table{Columns: []string{"version", "name", "applied", "applied_at"}}
+			for _, status := range statuses {
+				table.Add(status.Migration.Version, status.Migration.Name, status.Applied, nullTime(status.AppliedAt))
+			}
+			return output.Write(s.Stdout, s.Output, table)
+		}
+
+		// print each migration's status
+		fmt.Fprintf(s.Stdout, "%-20s %s\n", "Applied At", "Migration")
+		for _, status := range statuses {
+			fmt.Fprintf(s.Stdout, "%-20s %s\n", status.AppliedAtString(), status.Migration.Name)
+		}
+	default:
+		return fmt.Errorf("error: unknown migrate subcommand %q (use up, down or status)", cmd.Args[0])
+	}
+
+	// return success
+	return nil
+}
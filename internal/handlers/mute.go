@@ -0,0 +1,138 @@
+// mute.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"regexp"  // checking regexes
+	"strings" // joining keywords
+	"time"    // created at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+	"github.com/google/uuid"                            // mute ids
+)
+
+// mute handler logic
+// NOTE: cmd will be mute add [--regex] <keyword or regex> | list | remove <keyword or regex>
+// NOTE: posts with a matching title are hidden from browse, search, serve, digests and notifications
+func HandlerMute(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: mute add [--regex] <keyword or regex> | mute list | mute remove <keyword or regex>")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// subcommand check
+	switch cmd.Args[0] {
+	case "add":
+		args, isRegex := popFlag(cmd.Args[1:], "--regex")
+		if len(args) == 0 {
+			return usage
+		}
+		return addMute(ctx, s, user, strings.Join(args, " "), isRegex)
+	case "list":
+		return listMutes(ctx, s, user)
+	case "remove":
+		if len(cmd.Args) < 2 {
+			return usage
+		}
+		pattern := strings.Join(cmd.Args[1:], " ")
+		rows, err := s.DB.RemoveMute(ctx, database.RemoveMuteParams{UserID: user.ID, Pattern: pattern})
+
+		// remove check
+		if err != nil {
+			return fmt.Errorf("error removing mute: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("error: %q isn't on your mute list (see mute list)", pattern)
+		}
+		fmt.Fprintf(s.Stdout, "Unmuted %q.\n", pattern)
+		return nil
+	}
+	return usage
+}
+
+// mute add helper
+func addMute(ctx context.Context, s *app.State, user database.User, pattern string, isRegex bool) error {
+	// empty check
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return fmt.Errorf("error: nothing to mute")
+	}
+
+	// regex check, in go for a readable error, then in the database that runs it
+	if isRegex {
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("error: invalid regex %q: %w", pattern, err)
+		}
+		err = s.DB.CheckMuteRegex(ctx, pattern)
+		if err != nil {
+			return fmt.Errorf("error: the database can't use regex %q: %w", pattern, err)
+		}
+	}
+
+	// add it
+	rows, err := s.DB.AddMute(ctx, database.AddMuteParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Pattern:   pattern,
+		IsRegex:   isRegex,
+	})
+
+	// add check
+	if err != nil {
+		return fmt.Errorf("error adding mute: %w", err)
+	}
+	if rows == 0 {
+		fmt.Fprintf(s.Stdout, "%q is already muted.\n", pattern)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Muted %q, posts with it in their title are hidden.\n", pattern)
+	return nil
+}
+
+// mute list helper
+func listMutes(ctx context.Context, s *app.State, user database.User) error {
+	mutes, err := s.DB.ListMutes(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing mutes: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"pattern", "regex", "created_at"}}
+		for _, mute := range mutes {
+			table.Add(mute.Pattern, mute.IsRegex, mute.CreatedAt)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// empty check
+	if len(mutes) == 0 {
+		fmt.Fprintln(s.Stdout, "Nothing is muted, mute a keyword with: mute add <keyword>")
+		return nil
+	}
+
+	// print them
+	for _, mute := range mutes {
+		if mute.IsRegex {
+			fmt.Fprintf(s.Stdout, "* /%s/ (regex)\n", mute.Pattern)
+		} else {
+			fmt.Fprintf(s.Stdout, "* %s\n", mute.Pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+// newsletter.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"crypto/rand"  // address tokens
+	"database/sql" // no rows
+	"encoding/hex" // address tokens
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"html"         // plain text bodies as html
+	"log/slog"     // logging received mail
+	"net"          // the smtp listener
+	"strings"      // addresses and paragraphs
+	"time"         // created and published at
+
+	// external packages
+	"github.com/google/uuid" // post ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/lang"     // post languages
+	"github.com/PietPadda/aggregator/internal/mail"     // receiving and parsing mail
+	"github.com/PietPadda/aggregator/internal/notify"   // telling watch about new posts
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+	"github.com/PietPadda/aggregator/internal/readtime" // post reading times
+	"github.com/PietPadda/aggregator/internal/rules"    // the followers' rules
+)
+
+// newsletter feeds' urls are newsletter:<token>, mail to <token>@<newsletter_domain> becomes their posts
+const newsletterScheme = "newsletter:"
+
+// newsletter handler logic
+// NOTE: cmd will be newsletter add <name> | list | receive
+// NOTE: add and list are the current user's, receive takes mail for every newsletter feed until ctrl+c
+func HandlerNewsletter(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// subcommand check
+	usage := fmt.Errorf("error: usage: newsletter add <name> | newsletter list | newsletter receive")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+	switch cmd.Args[0] {
+	case "add":
+		return MiddlewareLoggedIn(addNewsletter)(ctx, s, cmd)
+	case "list":
+		return MiddlewareLoggedIn(listNewsletters)(ctx, s, cmd)
+	case "receive":
+		if len(cmd.Args) != 1 {
+			return usage
+		}
+		return receiveNewsletters(ctx, s)
+	}
+	return usage
+}
+
+// newsletter add helper, a feed with its own address that the current user follows
+func addNewsletter(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// name check
+	name := strings.TrimSpace(strings.Join(cmd.Args[1:], " "))
+	if name == "" {
+		return fmt.Errorf("error: usage: newsletter add <name>")
+	}
+
+	// create the address token
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+
+	// random check
+	if err != nil {
+		return fmt.Errorf("error generating newsletter address: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	// create the feed and its follow in one transaction, like addfeed
+	tx, err := s.Conn.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit
+
+	// run the queries inside the transaction
+	queries := s.DB.WithTx(tx)
+	now := time.Now()
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+		Url:       newsletterScheme + token,
+		UserID:    user.ID,
+	})
+
+	// feed check
+	if err != nil {
+		return fmt.Errorf("error adding newsletter feed: %w", err)
+	}
+	_, err = queries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+
+	// follow check
+	if err != nil {
+		return fmt.Errorf("error following newsletter feed: %w", err)
+	}
+
+	// commit check
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing newsletter feed: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Newsletter feed '%s' added, subscribe to newsletters with:\n\n%s\n\n", name, newsletterAddress(s, feed.Url))
+	fmt.Fprintln(s.Stdout, "Mail to it shows up as posts while newsletter receive runs; removefeed stops it.")
+	return nil
+}
+
+// newsletter list helper
+func listNewsletters(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// args check
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("error: usage: newsletter list")
+	}
+
+	// get the feeds
+	feeds, err := s.DB.ListNewsletterFeeds(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error getting newsletter feeds: %w", err)
+	}
+
+	// machine-readable check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "address", "created_at", "last_received_at"}}
+		for _, feed := range feeds {
+			table.Add(feed.Name, newsletterAddress(s, feed.Url), feed.CreatedAt, nullTime(feed.LastFetchedAt))
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// none check
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "No newsletter feeds yet, add one with: newsletter add <name>")
+		return nil
+	}
+	for _, feed := range feeds {
+		received := "nothing received yet"
+		if feed.LastFetchedAt.Valid {
+			received = "last received " + feed.LastFetchedAt.Time.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(s.Stdout, "%s\n  %s (%s)\n", feed.Name, newsletterAddress(s, feed.Url), received)
+	}
+	return nil
+}
+
+// a newsletter feed's email address helper
+func newsletterAddress(s *app.State, feedURL string) string {
+	token := strings.TrimPrefix(feedURL, newsletterScheme)
+
+	// domain check
+	if s.Config.NewsletterDomain == nil || *s.Config.NewsletterDomain == "" {
+		return token + "@<newsletter_domain> (set it with: config set newsletter_domain <domain>)"
+	}
+	return token + "@" + *s.Config.NewsletterDomain
+}
+
+// newsletter receive helper, an smtp server turning mail to newsletter addresses into posts until ctrl+c
+func receiveNewsletters(ctx context.Context, s *app.State) error {
+	// domain check
+	if s.Config.NewsletterDomain == nil || *s.Config.NewsletterDomain == "" {
+		return fmt.Errorf("error: no newsletter_domain configured, point a domain's mx at this host and: config set newsletter_domain <domain>")
+	}
+	domain := strings.ToLower(*s.Config.NewsletterDomain)
+
+	// listen check
+	addr := s.Config.NewsletterAddress()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %w", addr, err)
+	}
+
+	// the server, taking mail for existing newsletter feeds only
+	receiver := &mail.Receiver{
+		Hostname: domain,
+		Accept: func(recipient string) bool {
+			_, err := newsletterFeed(ctx, s, domain, recipient)
+			return err == nil
+		},
+		Deliver: func(recipients []string, data []byte) error {
+			return deliverNewsletter(ctx, s, domain, recipients, data)
+		},
+	}
+	slog.Info("receiving newsletters", "addr", addr, "domain", domain, "note", "ctrl+c to stop")
+	return receiver.Serve(ctx, listener)
+}
+
+// find the newsletter feed an address belongs to helper, sql.ErrNoRows if none
+func newsletterFeed(ctx context.Context, s *app.State, domain, address string) (database.GetFeedByURLRow, error) {
+	// domain check
+	token, addressDomain, _ := strings.Cut(address, "@")
+	if addressDomain != domain || token == "" {
+		return database.GetFeedByURLRow{}, sql.ErrNoRows
+	}
+
+	// plus addressing check, token+anything@domain is still token's
+	token, _, _ = strings.Cut(token, "+")
+	return s.DB.GetFeedByURL(ctx, newsletterScheme+token)
+}
+
+// store a received message as a post of each newsletter feed it was sent to helper
+func deliverNewsletter(ctx context.Context, s *app.State, domain string, recipients []string, data []byte) error {
+	// parse it (a broken message is dropped, the sender retrying wouldn't fix it)
+	received, err := mail.Parse(data)
+	if err != nil {
+		slog.Warn("dropping unreadable newsletter", "err", err)
+		return nil
+	}
+
+	// each feed once, however many of its addresses it was sent to
+	seen := map[string]bool{}
+	for _, recipient := range recipients {
+		feed, err := newsletterFeed(ctx, s, domain, recipient)
+
+		// feed removed since RCPT check
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error getting newsletter feed: %w", err)
+		}
+		if seen[feed.Url] {
+			continue
+		}
+		seen[feed.Url] = true
+
+		// store it
+		err = storeNewsletterPost(ctx, s, feed, received)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// store a received message as a newsletter feed's post helper, with the same rules and notifications as agg's posts
+func storeNewsletterPost(ctx context.Context, s *app.State, feed database.GetFeedByURLRow, received mail.Received) error {
+	// the title, the sender without a subject
+	title := received.Subject
+	if title == "" {
+		title = "Newsletter from " + received.From
+	}
+
+	// the body as html, plain text in paragraphs if there's no html part
+	description := received.HTML
+	if description == "" {
+		var paragraphs []string
+		for _, paragraph := range strings.Split(strings.ReplaceAll(received.Text, "\r\n", "\n"), "\n\n") {
+			if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+				paragraphs = append(paragraphs, "<p>"+strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>")+"</p>")
+			}
+		}
+		description = strings.Join(paragraphs, "\n")
+	}
+
+	// the post's url, unique per message and feed (there's no web page to link to)
+	postURL := feed.Url + "#" + received.MessageID
+
+	// when it was sent, or now without a date
+	publishedAt := received.Date
+	if publishedAt.IsZero() {
+		publishedAt = time.Now().UTC()
+	}
+
+	// store it and mark the feed as received in one transaction
+	tx, err := s.Conn.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit
+	queries := s.DB.WithTx(tx)
+	post := database.InsertPostsParams{
+		CreatedAt:      time.Now(),
+		FeedID:         feed.ID,
+		Ids:            []uuid.UUID{uuid.New()},
+		Titles:         []string{title},
+		Urls:           []string{postURL},
+		Descriptions:   []string{description},
+		PublishedAts:   []time.Time{publishedAt},
+		Languages:      []string{lang.Detect(title + "\n" + description)},
+		ReadingMinutes: []int32{int32(readtime.Minutes(description))},
+		Guids:          []string{""}, // newsletters have no guid
+	}
+	stored, err := queries.InsertPosts(ctx, post)
+
+	// insert check
+	if err != nil {
+		return fmt.Errorf("error storing newsletter post: %w", err)
+	}
+
+	// already stored check (the sender retried)
+	if len(stored) == 0 {
+		slog.Debug("newsletter already stored", "feed", feed.Name, "url", postURL)
+		return nil
+	}
+
+	// run the followers' rules on it
+	deliveries, err := applyRules(ctx, queries, feed.ID, []rulePost{{
+		ID:    post.Ids[0],
+		Match: rules.Post{FeedID: feed.ID, Title: title, Authors: []string{received.From}},
+		Hook: rules.WebhookPost{
+			Feed:        feed.Name,
+			FeedURL:     feed.Url,
+			Title:       title,
+			URL:         postURL,
+			Description: description,
+			PublishedAt: &publishedAt,
+		},
+	}})
+
+	// rules check
+	if err != nil {
+		return err
+	}
+
+	// tell watch about it, if the database supports it
+	if s.Dialect.SupportsNotify() {
+		payload, err := notify.Payload(notify.NewPosts{FeedID: feed.ID, FeedName: feed.Name, FeedURL: feed.Url, Count: 1})
+
+		// payload check
+		if err != nil {
+			return err
+		}
+		err = queries.NotifyNewPosts(ctx, database.NotifyNewPostsParams{Channel: notify.Channel(s.Config.SchemaName()), Payload: payload})
+
+		// notify check
+		if err != nil {
+			return fmt.Errorf("error notifying new posts: %w", err)
+		}
+	}
+
+	// mark the feed as received (newsletter list shows it)
+	err = queries.MarkFeedFetched(ctx, feed.ID)
+	if err != nil {
+		return fmt.Errorf("error marking newsletter feed received: %w", err)
+	}
+
+	// commit check
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing newsletter post: %w", err)
+	}
+
+	// notify and webhook rules, now the post is stored
+	deliverRules(ctx, s, deliveries)
+	slog.Info("received newsletter", "feed", feed.Name, "title", title)
+	return nil
+}
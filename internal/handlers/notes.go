@@ -0,0 +1,204 @@
+// notes.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // null highlights
+	"fmt"          // print errors
+	"strings"      // id prefixes
+	"time"         // created at
+
+	// external packages
+	"github.com/google/uuid" // note ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+)
+
+// note handler logic
+// NOTE: cmd will be note add <post id|url> "<text>" [--highlight "<passage>"] | list [<post id|url>] | delete <note id>
+func HandlerNote(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: note add <post id|url> \"<text>\" [--highlight \"<passage>\"] | note list [<post id|url>] | note delete <note id>")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// subcommand check
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "add":
+		return addNote(ctx, s, user, args)
+	case "list":
+		if len(args) > 1 {
+			return fmt.Errorf("error: usage: note list [<post id|url>]")
+		}
+		return listNotes(ctx, s, user, args)
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: note delete <note id>")
+		}
+		return deleteNote(ctx, s, user, args[0])
+	}
+	return usage
+}
+
+// note add helper, a note and/or a highlighted passage on a followed post
+func addNote(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// strip the optional highlight flag from the args
+	args, highlight, err := popFlagValue(args, "--highlight")
+	if err != nil {
+		return err
+	}
+
+	// usage check (a highlight alone is fine)
+	if len(args) < 1 || len(args) > 2 || (len(args) == 1 && highlight == "") {
+		return fmt.Errorf("error: usage: note add <post id|url> \"<text>\" [--highlight \"<passage>\"]")
+	}
+	body := ""
+	if len(args) == 2 {
+		body = strings.TrimSpace(args[1])
+	}
+	highlight = strings.TrimSpace(highlight)
+	if body == "" && highlight == "" {
+		return fmt.Errorf("error: the note is empty")
+	}
+
+	// find the post
+	post, err := findPost(ctx, s, user, args[0])
+	if err != nil {
+		return err
+	}
+
+	// add the note
+	note, err := s.DB.CreatePostNote(ctx, database.CreatePostNoteParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		PostID:    post.ID,
+		Body:      body,
+		Highlight: sql.NullString{String: highlight, Valid: highlight != ""},
+	})
+
+	// add check
+	if err != nil {
+		return fmt.Errorf("error adding note: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Added note %s to '%s'.\n", shortID(note.ID), post.Title)
+	return nil
+}
+
+// note list helper, all the user's notes or one post's, grouped by post
+func listNotes(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// one post check
+	var postID uuid.NullUUID
+	if len(args) == 1 {
+		post, err := findPost(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		postID = uuid.NullUUID{UUID: post.ID, Valid: true}
+	}
+
+	// get the notes
+	notes, err := s.DB.ListPostNotes(ctx, database.ListPostNotesParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing notes: %w", err)
+	}
+
+	// machine-readable output check (the export)
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"id", "created_at", "note", "highlight", "post_id", "post_title", "post_url", "feed"}}
+		for _, note := range notes {
+			table.Add(note.ID, note.CreatedAt, note.Body, nullString(note.Highlight), note.PostID, note.PostTitle, note.PostUrl, note.FeedName)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no notes check
+	if len(notes) == 0 {
+		fmt.Fprintln(s.Stdout, "No notes yet, add one with: note add <post id|url> \"<text>\"")
+		return nil
+	}
+
+	// print them under their posts
+	notes = groupNotesByPost(notes)
+	for i, note := range notes {
+		if i == 0 || notes[i-1].PostID != note.PostID {
+			if i > 0 {
+				fmt.Fprintln(s.Stdout)
+			}
+			fmt.Fprintf(s.Stdout, "%s (%s)\n%s\n", note.PostTitle, note.FeedName, note.PostUrl)
+		}
+		if note.Highlight.Valid {
+			fmt.Fprintf(s.Stdout, "  > %s\n", note.Highlight.String)
+		}
+		if note.Body != "" {
+			fmt.Fprintf(s.Stdout, "  %s\n", note.Body)
+		}
+		fmt.Fprintf(s.Stdout, "  -- note %s, %s\n", shortID(note.ID), note.CreatedAt.Local().Format(time.DateTime))
+	}
+	return nil
+}
+
+// note delete helper, by the start of its id
+func deleteNote(ctx context.Context, s *app.State, user database.User, ref string) error {
+	// id prefix check, LIKE wildcards would match anything
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" || strings.ContainsAny(ref, "%_\\") {
+		return fmt.Errorf("error: %q isn't a note id", ref)
+	}
+
+	// find it
+	notes, err := s.DB.FindPostNotes(ctx, database.FindPostNotesParams{UserID: user.ID, Prefix: ref})
+
+	// find check
+	if err != nil {
+		return fmt.Errorf("error finding note: %w", err)
+	}
+	if len(notes) == 0 {
+		return fmt.Errorf("error: no note %q", ref)
+	}
+	if len(notes) > 1 {
+		return fmt.Errorf("error: note id %q is ambiguous, give more of it", ref)
+	}
+
+	// delete it
+	_, err = s.DB.DeletePostNote(ctx, database.DeletePostNoteParams{ID: notes[0].ID, UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("error deleting note: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Deleted note %s.\n", shortID(notes[0].ID))
+	return nil
+}
+
+// group notes by post helper, posts in the order of their first note (notes keep their order)
+func groupNotesByPost(notes []database.ListPostNotesRow) []database.ListPostNotesRow {
+	grouped := make([]database.ListPostNotesRow, 0, len(notes))
+	done := map[uuid.UUID]bool{}
+	for _, first := range notes {
+		if done[first.PostID] {
+			continue
+		}
+		done[first.PostID] = true
+		for _, note := range notes {
+			if note.PostID == first.PostID {
+				grouped = append(grouped, note)
+			}
+		}
+	}
+	return grouped
+}
@@ -0,0 +1,285 @@
+// notify.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows and null tokens
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"log/slog"     // logging pushes
+	"time"         // push windows
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/push"     // ntfy and pushover
+)
+
+// package-wide constants
+const (
+	notifyEach      = 5  // posts per check that get their own notification, more are summed up in one
+	notifyPostLimit = 50 // most posts counted per check
+)
+
+// notify handler logic
+// NOTE: cmd will be notify ntfy <topic url> [token] | pushover <user key> <app token> | off | status | test | feed <url> on|off
+// NOTE: new posts of follows flagged with notify feed are pushed to the phone by agg
+func HandlerNotify(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: notify ntfy <topic url> [token] | notify pushover <user key> <app token> | notify off | notify status | notify test | notify feed <url> on|off")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// subcommand check
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "ntfy":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("error: usage: notify ntfy <topic url> [token]")
+		}
+		target := push.Target{Service: push.Ntfy, Target: args[0]}
+		if len(args) == 2 {
+			target.Token = args[1]
+		}
+		return setPushTarget(ctx, s, user, target)
+	case "pushover":
+		if len(args) != 2 {
+			return fmt.Errorf("error: usage: notify pushover <user key> <app token>")
+		}
+		return setPushTarget(ctx, s, user, push.Target{Service: push.Pushover, Target: args[0], Token: args[1]})
+	case "off":
+		rows, err := s.DB.DeletePushTarget(ctx, user.ID)
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error removing push target: %w", err)
+		}
+		if rows == 0 {
+			fmt.Fprintln(s.Stdout, "Notifications weren't set up.")
+			return nil
+		}
+		fmt.Fprintln(s.Stdout, "Notifications are off, your flagged feeds stay flagged for when you set them up again.")
+		return nil
+	case "status":
+		return notifyStatus(ctx, s, user)
+	case "test":
+		target, err := getPushTarget(ctx, s, user)
+		if err != nil {
+			return err
+		}
+		err = push.Send(ctx, target, push.Notification{Title: "Gator", Message: "Test notification, new posts of your flagged feeds arrive like this."})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "Test notification sent through %s.\n", target.Service)
+		return nil
+	case "feed":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return fmt.Errorf("error: usage: notify feed <url> on|off")
+		}
+		return flagNotifyFeed(ctx, s, user, args[0], args[1] == "on")
+	}
+	return usage
+}
+
+// notify ntfy/pushover helper, checks and saves where the user's notifications go
+func setPushTarget(ctx context.Context, s *app.State, user database.User, target push.Target) error {
+	// target check
+	err := target.Validate()
+	if err != nil {
+		return err
+	}
+
+	// save it
+	err = s.DB.SetPushTarget(ctx, database.SetPushTargetParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC(),
+		Service:   target.Service,
+		Target:    target.Target,
+		Token:     sql.NullString{String: target.Token, Valid: target.Token != ""},
+	})
+
+	// save check
+	if err != nil {
+		return fmt.Errorf("error saving push target: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Notifications go to %s now, try it with: notify test\n", target.Service)
+	fmt.Fprintln(s.Stdout, "Flag the feeds to be notified about with: notify feed <url> on (agg sends them)")
+	return nil
+}
+
+// get the user's push target helper
+func getPushTarget(ctx context.Context, s *app.State, user database.User) (push.Target, error) {
+	row, err := s.DB.GetPushTarget(ctx, user.ID)
+
+	// not set up check
+	if errors.Is(err, sql.ErrNoRows) {
+		return push.Target{}, fmt.Errorf("error: notifications aren't set up, use notify ntfy <topic url> or notify pushover <user key> <app token>")
+	}
+	if err != nil {
+		return push.Target{}, fmt.Errorf("error getting push target: %w", err)
+	}
+	return pushTarget(row), nil
+}
+
+// database row to push target helper
+func pushTarget(row database.PushTarget) push.Target {
+	return push.Target{Service: row.Service, Target: row.Target, Token: row.Token.String}
+}
+
+// notify feed helper, flags or unflags one of the user's follows
+func flagNotifyFeed(ctx context.Context, s *app.State, user database.User, feedURL string, notify bool) error {
+	rows, err := s.DB.SetFeedFollowNotify(ctx, database.SetFeedFollowNotifyParams{
+		Notify: notify,
+		Url:    feedURL,
+		UserID: user.ID,
+	})
+
+	// flag check
+	if err != nil {
+		return fmt.Errorf("error flagging feed: %w", err)
+	}
+
+	// following check
+	if rows == 0 {
+		return fmt.Errorf("error: you don't follow %s (see following)", feedURL)
+	}
+	if notify {
+		fmt.Fprintf(s.Stdout, "New posts of %s will be pushed to you.\n", feedURL)
+	} else {
+		fmt.Fprintf(s.Stdout, "New posts of %s won't be pushed anymore.\n", feedURL)
+	}
+	return nil
+}
+
+// notify status helper, where notifications go and for which feeds
+func notifyStatus(ctx context.Context, s *app.State, user database.User) error {
+	// the target
+	row, err := s.DB.GetPushTarget(ctx, user.ID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		fmt.Fprintln(s.Stdout, "Notifications aren't set up.")
+	case err != nil:
+		return fmt.Errorf("error getting push target: %w", err)
+	default:
+		fmt.Fprintf(s.Stdout, "Notifications go to %s, posts found up to %s were pushed\n", row.Service, row.PushedAt.Local().Format(time.DateTime))
+	}
+
+	// the flagged feeds
+	feeds, err := s.DB.ListNotifyFeeds(ctx, user.ID)
+
+	// feeds check
+	if err != nil {
+		return fmt.Errorf("error listing flagged feeds: %w", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "No feeds are flagged, flag one with: notify feed <url> on")
+		return nil
+	}
+	fmt.Fprintln(s.Stdout, "Flagged feeds:")
+	for _, feed := range feeds {
+		fmt.Fprintf(s.Stdout, "* %s (%s)\n", feed.Name, feed.Url)
+	}
+	return nil
+}
+
+// push the new posts of flagged follows helper, for agg (errors are logged, agg keeps going)
+// NOTE: each target is claimed before pushing, so two aggs never push the same posts
+func aggNotifications(ctx context.Context, s *app.State) {
+	// get the targets
+	targets, err := s.DB.ListPushTargets(ctx)
+
+	// targets check
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("error listing push targets", "err", err)
+		}
+		return
+	}
+
+	// push each target's posts
+	for _, target := range targets {
+		// posts found up to now, later ones go in the next push
+		now := time.Now().UTC()
+		posts, err := s.DB.ListNotifyPosts(ctx, database.ListNotifyPostsParams{
+			UserID:    target.UserID,
+			Since:     target.PushedAt,
+			Until:     now,
+			PostLimit: notifyPostLimit,
+		})
+
+		// posts check
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("error getting posts to notify", "err", err)
+			}
+			return
+		}
+
+		// nothing new check
+		if len(posts) == 0 {
+			continue
+		}
+
+		// claim them, unless another agg just did
+		claimed, err := s.DB.ClaimPushTarget(ctx, database.ClaimPushTargetParams{
+			PushedAt: now,
+			UserID:   target.UserID,
+			Previous: target.PushedAt,
+		})
+		if err != nil || claimed == 0 {
+			if err != nil && ctx.Err() == nil {
+				slog.Error("error claiming push target", "err", err)
+			}
+			continue
+		}
+
+		// a notification per post, the rest summed up
+		notifications := []push.Notification{}
+		for i, post := range posts {
+			if i == notifyEach {
+				more := fmt.Sprintf("%d more new posts in your flagged feeds", len(posts)-notifyEach)
+				if len(posts) == notifyPostLimit {
+					more = fmt.Sprintf("%d+ more new posts in your flagged feeds", len(posts)-notifyEach)
+				}
+				notifications = append(notifications, push.Notification{Title: "Gator", Message: more})
+				break
+			}
+			notifications = append(notifications, push.Notification{Title: post.FeedName, Message: post.Title, URL: post.Url})
+		}
+
+		// push them
+		for i, notification := range notifications {
+			err = push.Send(ctx, pushTarget(target), notification)
+			if err == nil {
+				continue
+			}
+			if ctx.Err() == nil {
+				slog.Error("error pushing notification", "service", target.Service, "err", err)
+			}
+
+			// nothing sent check, unclaim so the next try covers the same posts
+			if i == 0 {
+				unclaimErr := s.DB.UnclaimPushTarget(context.WithoutCancel(ctx), database.UnclaimPushTargetParams{
+					Previous: target.PushedAt,
+					UserID:   target.UserID,
+					PushedAt: now,
+				})
+				if unclaimErr != nil {
+					slog.Error("error unclaiming push target", "err", unclaimErr)
+				}
+			}
+			break
+		}
+		if err == nil {
+			slog.Info("pushed notifications", "service", target.Service, "posts", len(posts))
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// passwd.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"time"         // updated at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/keyring"  // reading passwords without echo
+	"github.com/PietPadda/aggregator/internal/password" // hashing passwords
+)
+
+// shortest password passwd accepts
+const minPasswordLength = 8
+
+// passwd handler logic
+// NOTE: cmd will be passwd [--remove], sets, changes or removes the current user's password
+func HandlerPasswd(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	remove := len(cmd.Args) == 1 && cmd.Args[0] == "--remove"
+	if len(cmd.Args) > 1 || (len(cmd.Args) == 1 && !remove) {
+		return fmt.Errorf("error: usage: passwd [--remove]")
+	}
+
+	// changing or removing a password needs the current one
+	hasPassword, err := checkPassword(ctx, s, user)
+
+	// current password check
+	if err != nil {
+		return err
+	}
+
+	// remove check
+	if remove {
+		// no password check
+		if !hasPassword {
+			fmt.Fprintf(s.Stdout, "%s has no password.\n", user.Name)
+			return nil
+		}
+
+		// delete the hash, and log the user's serve sessions out with it
+		err = changePassword(ctx, s, user, func(queries *database.Queries) error {
+			_, err := queries.DeleteUserPassword(ctx, user.ID)
+			return err
+		})
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error removing password: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Password removed, %s logs in by name again (serve sessions were logged out).\n", user.Name)
+		return nil
+	}
+
+	// ask for the new password twice
+	newPassword, err := keyring.ReadPassword(fmt.Sprintf("New password for %s: ", user.Name))
+	if err != nil {
+		return err
+	}
+	if len(newPassword) < minPasswordLength {
+		return fmt.Errorf("error: password must be at least %d characters", minPasswordLength)
+	}
+	confirm, err := keyring.ReadPassword("Repeat the new password: ")
+	if err != nil {
+		return err
+	}
+	if confirm != newPassword {
+		return fmt.Errorf("error: passwords don't match")
+	}
+
+	// hash it
+	hash, err := password.Hash(newPassword)
+
+	// hash check
+	if err != nil {
+		return err
+	}
+
+	// store the hash, and log the user's serve sessions out with it (a stolen cookie dies with the old password)
+	err = changePassword(ctx, s, user, func(queries *database.Queries) error {
+		return queries.SetUserPassword(ctx, database.SetUserPasswordParams{
+			UserID:       user.ID,
+			UpdatedAt:    time.Now().UTC(),
+			PasswordHash: hash,
+		})
+	})
+
+	// set check
+	if err != nil {
+		return fmt.Errorf("error saving password: %w", err)
+	}
+
+	// success
+	fmt.Fprintf(s.Stdout, "Password set for %s, login and the api's basic auth now ask for it (serve sessions were logged out).\n", user.Name)
+	return nil
+}
+
+// change a user's password and log their sessions out in one transaction helper
+func changePassword(ctx context.Context, s *app.State, user database.User, change func(queries *database.Queries) error) error {
+	// begin the transaction
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit
+
+	// change it, then end the sessions
+	queries := s.DB.WithTx(tx)
+	err = change(queries)
+	if err != nil {
+		return err
+	}
+	_, err = queries.DeleteSessionsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error logging sessions out: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ask for and verify a user's password helper, if they have one
+// returns whether the user has a password, and an error if it was wrong
+// NOTE: the cli trusts whoever runs it (current_user_name and GATOR_CURRENT_USER skip this), so it only
+// guards against logging in as the wrong user, accounts are only protected over serve (see api's checkPassword)
+func checkPassword(ctx context.Context, s *app.State, user database.User) (bool, error) {
+	// get the user's password hash
+	hash, err := s.DB.GetUserPasswordHash(ctx, user.ID)
+
+	// no password check
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error getting password: %w", err)
+	}
+
+	// ask for it
+	given, err := keyring.ReadPassword(fmt.Sprintf("Password for %s: ", user.Name))
+
+	// read check
+	if err != nil {
+		return true, err
+	}
+
+	// verify it
+	ok, err := password.Verify(given, hash)
+
+	// verify check
+	if err != nil {
+		return true, err
+	}
+	if !ok {
+		return true, fmt.Errorf("error: wrong password for %s", user.Name)
+	}
+	return true, nil
+}
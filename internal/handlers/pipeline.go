@@ -0,0 +1,234 @@
+// pipeline.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"log/slog" // structured logging
+	"runtime"  // parse workers
+	"sync"     // stage workers and metrics
+	"time"     // stage timings
+
+	// external packages
+	"github.com/google/uuid" // feed ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/adaptive" // store concurrency
+	"github.com/PietPadda/aggregator/internal/app"      // for State
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/rssfeed"  // raw feeds
+)
+
+// package-wide constants
+// NOTE: a full buffer makes the stage before it wait, so a slow database slows the fetches down instead of piling up bodies
+const pipelineBuffer = 4 // feeds waiting between two stages, per fetch worker
+
+// the pipeline's stages, in order
+const (
+	stageFetch = iota // network, http.concurrency workers
+	stageParse        // cpu, one worker per cpu
+	stageStore        // database, as many workers as the adaptive limiter allows
+	stageCount
+)
+
+// the stages' names, for the log and agg status
+var stageNames = [stageCount]string{"fetch", "parse", "store"}
+
+// a feed going through the pipeline
+type pipelineFeed struct {
+	job    database.FetchJob
+	feed   database.Feed
+	found  bool         // false = the feed was removed since it was queued, nothing to fetch
+	raw    *rssfeed.Raw // set by fetch, dropped by parse
+	parsed *parsedFeed  // set by parse
+	err    error        // the first stage's error, the later stages pass the feed on untouched
+	stored bool         // the store stage ran, so err is the database's (not a fetch's)
+}
+
+// a stage's totals
+type stageMetrics struct {
+	Stage  string        `json:"stage"`
+	Feeds  int           `json:"feeds"`   // feeds it worked on, failed or not
+	Failed int           `json:"failed"`  // feeds it failed (ctrl+c included)
+	Skip   int           `json:"skipped"` // feeds it skipped, their host's circuit was open (fetch only)
+	Busy   time.Duration `json:"busy"`    // time spent on them, summed over its workers
+}
+
+// the average time a feed spent in the stage
+func (m stageMetrics) Average() time.Duration {
+	// none check
+	if m.Feeds == 0 {
+		return 0
+	}
+	return m.Busy / time.Duration(m.Feeds)
+}
+
+// every stage's totals, recorded by their workers as they go
+type pipelineMetrics struct {
+	mu     sync.Mutex
+	stages [stageCount]stageMetrics
+}
+
+// create empty pipeline metrics
+func newPipelineMetrics() *pipelineMetrics {
+	metrics := &pipelineMetrics{}
+	for i, name := range stageNames {
+		metrics.stages[i].Stage = name
+	}
+	return metrics
+}
+
+// record one feed's time in a stage
+func (m *pipelineMetrics) record(stage int, started time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages[stage].Feeds++
+	m.stages[stage].Busy += time.Since(started)
+	if err != nil {
+		m.stages[stage].Failed++
+	}
+}
+
+// count a feed a stage skipped
+func (m *pipelineMetrics) skip(stage int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages[stage].Skip++
+}
+
+// add another run's totals, eg a tick's to the session's
+func (m *pipelineMetrics) add(other *pipelineMetrics) {
+	run := other.Snapshot()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.stages {
+		m.stages[i].Feeds += run[i].Feeds
+		m.stages[i].Failed += run[i].Failed
+		m.stages[i].Skip += run[i].Skip
+		m.stages[i].Busy += run[i].Busy
+	}
+}
+
+// a copy of the totals, in stage order
+func (m *pipelineMetrics) Snapshot() []stageMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]stageMetrics(nil), m.stages[:]...)
+}
+
+// run the claimed jobs through fetch, parse and store, calling done with each feed once it's through
+// fetchers is how many feeds are fetched at once, the limiter how many are stored at once
+// breakers skip the feeds of hosts that keep timing out, with a hostOpenError
+// NOTE: done is called from the store workers, in any order, and also for feeds that failed or were skipped
+// NOTE: on ctrl+c the feeds still in the pipeline pass through with ctx's error, so done can release their jobs
+func runFetchPipeline(ctx context.Context, s *app.State, jobs []database.FetchJob, feeds map[uuid.UUID]database.Feed, fetchers int, limiter *adaptive.Limiter, breakers *hostBreakers, metrics *pipelineMetrics, done func(item pipelineFeed)) {
+	// the channels between the stages
+	toFetch := make(chan pipelineFeed)
+	toParse := make(chan pipelineFeed, fetchers*pipelineBuffer)
+	toStore := make(chan pipelineFeed, fetchers*pipelineBuffer)
+
+	// hand out the jobs
+	go func() {
+		defer close(toFetch)
+		for _, job := range jobs {
+			feed, found := feeds[job.FeedID]
+			toFetch <- pipelineFeed{job: job, feed: feed, found: found}
+		}
+	}()
+
+	// fetch stage
+	runStage(fetchers, toFetch, toParse, func(item *pipelineFeed) {
+		// interrupted check, don't start new fetches on ctrl+c
+		if ctx.Err() != nil {
+			item.err = ctx.Err()
+			return
+		}
+
+		// host circuit check, a host that keeps timing out would hold a fetch slot for the whole timeout
+		host := feedHost(item.feed.Url)
+		if err := breakers.check(host, time.Now()); err != nil {
+			item.err = err
+			metrics.skip(stageFetch)
+			return
+		}
+		started := time.Now()
+		item.raw, item.err = fetchFeed(ctx, s, item.feed)
+		metrics.record(stageFetch, started, item.err)
+
+		// count the host's timeouts (ctrl+c and --timeout aren't the host's fault)
+		if ctx.Err() == nil {
+			breakers.record(ctx, s, host, item.err)
+		}
+	})
+
+	// parse stage
+	runStage(runtime.GOMAXPROCS(0), toParse, toStore, func(item *pipelineFeed) {
+		started := time.Now()
+		item.parsed, item.err = parseFeed(ctx, s, item.feed, item.raw)
+		item.raw = nil // parsed, the body isn't needed anymore
+		metrics.record(stageParse, started, item.err)
+	})
+
+	// store stage, up to the limiter's concurrency (it backs off when the database is slow)
+	var wg sync.WaitGroup
+	for item := range toStore {
+		// wait for a free slot, on ctrl+c the feed is passed on as interrupted
+		err := limiter.Acquire(ctx)
+		if err != nil {
+			if item.err == nil {
+				item.err = err
+			}
+			done(item)
+			continue
+		}
+		wg.Add(1)
+		go func(item pipelineFeed) {
+			defer wg.Done()
+			defer limiter.Release()
+
+			// anything to store check
+			if item.found && item.err == nil {
+				started := time.Now()
+				item.err = storeFeedWithRetry(ctx, s, item.feed, item.parsed)
+				item.stored = true
+				metrics.record(stageStore, started, item.err)
+			}
+			done(item)
+		}(item)
+	}
+	wg.Wait()
+}
+
+// start a stage's workers helper, each takes feeds from in, works on them and passes them to out
+// feeds that were removed or failed in an earlier stage are passed on as they are
+// out is closed once in is drained and every worker is done
+func runStage(workers int, in <-chan pipelineFeed, out chan<- pipelineFeed, work func(item *pipelineFeed)) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				if item.found && item.err == nil {
+					work(&item)
+				}
+				out <- item
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+}
+
+// log a run's stage totals helper (debug only, one line per stage)
+func logPipelineMetrics(metrics *pipelineMetrics) {
+	for _, stage := range metrics.Snapshot() {
+		// idle stage check
+		if stage.Feeds == 0 && stage.Skip == 0 {
+			continue
+		}
+		slog.Debug("fetch pipeline stage", "stage", stage.Stage, "feeds", stage.Feeds, "failed", stage.Failed, "skipped", stage.Skip, "busy", stage.Busy.Round(time.Millisecond), "avg", stage.Average().Round(time.Millisecond))
+	}
+}
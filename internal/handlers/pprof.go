@@ -0,0 +1,171 @@
+// pprof.go
+package handlers
+
+import (
+	// std go libs
+	"context"                    // stopping the server
+	"errors"                     // matching http.ErrServerClosed
+	"expvar"                     // runtime stats at /debug/vars
+	"fmt"                        // print errors
+	"log/slog"                   // logging
+	"net"                        // listening before returning
+	"net/http"                   // the debug server
+	"net/http/pprof"             // the profiles
+	"os"                         // profile files
+	"runtime"                    // gc before the heap profile
+	runtimepprof "runtime/pprof" // profile files
+	"runtime/trace"              // execution traces
+	"time"                       // header timeout
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app" // for State
+)
+
+// start serving pprof and runtime stats on pprof_addr helper, if it's set (until ctx is done)
+// NOTE: for profiling memory growth or goroutine leaks in agg and serve, eg go tool pprof http://127.0.0.1:6060/debug/pprof/heap
+func startPprof(ctx context.Context, s *app.State) error {
+	// get the address
+	addr, err := s.Config.PprofAddress()
+
+	// address check ("" = off)
+	if err != nil || addr == "" {
+		return err
+	}
+
+	// register the endpoints on a mux of our own, so they never end up on the api
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index) // also serves heap, goroutine, allocs, block, mutex and threadcreate
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler()) // memstats and cmdline as json
+
+	// listen now, so a taken port fails the command rather than just logging
+	listener, err := net.Listen("tcp", addr)
+
+	// listen check
+	if err != nil {
+		return fmt.Errorf("error listening for pprof on %s: %w", addr, err)
+	}
+
+	// serve in the background
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("error serving pprof", "err", err)
+		}
+	}()
+	slog.Info("serving pprof", "url", "http://"+listener.Addr().String()+"/debug/pprof/")
+
+	// stop with the command
+	go func() {
+		<-ctx.Done()
+		server.Close() // profiles in progress are cut short, it's only debugging
+	}()
+	return nil
+}
+
+// profile files asked for with --cpuprofile, --memprofile and --trace, written when the command stops
+// NOTE: for when pprof_addr can't be reached (or wasn't set), open them with go tool pprof or go tool trace
+type profileFiles struct {
+	cpuPath   string // "" = not asked for
+	memPath   string
+	tracePath string
+	cpu       *os.File
+	mem       *os.File
+	trace     *os.File
+}
+
+// strip the profiling flags from a command's args helper
+func popProfileFlags(args []string) ([]string, *profileFiles, error) {
+	profiles := &profileFiles{}
+	var err error
+	for flag, path := range map[string]*string{"--cpuprofile": &profiles.cpuPath, "--memprofile": &profiles.memPath, "--trace": &profiles.tracePath} {
+		args, *path, err = popFlagValue(args, flag)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return args, profiles, nil
+}
+
+// create the profile files and start the cpu profile and trace, until stop
+// NOTE: the heap profile's file is created now too, so a bad path fails the command rather than its end
+func (p *profileFiles) start() error {
+	// create the files
+	var err error
+	for _, file := range []struct {
+		path string
+		file **os.File
+	}{{p.cpuPath, &p.cpu}, {p.memPath, &p.mem}, {p.tracePath, &p.trace}} {
+		// not asked for check
+		if file.path == "" {
+			continue
+		}
+		*file.file, err = os.Create(file.path)
+		if err != nil {
+			p.stop()
+			return fmt.Errorf("error creating profile %s: %w", file.path, err)
+		}
+	}
+
+	// start the cpu profile
+	if p.cpu != nil {
+		err = runtimepprof.StartCPUProfile(p.cpu)
+		if err != nil {
+			p.stop()
+			return fmt.Errorf("error starting cpu profile: %w", err)
+		}
+	}
+
+	// start the trace
+	if p.trace != nil {
+		err = trace.Start(p.trace)
+		if err != nil {
+			p.stop()
+			return fmt.Errorf("error starting trace: %w", err)
+		}
+	}
+	return nil
+}
+
+// stop the cpu profile and trace, and write the heap profile
+// NOTE: runs on the way out, so failures are only logged (the command's own error matters more)
+func (p *profileFiles) stop() {
+	// cpu profile
+	if p.cpu != nil {
+		runtimepprof.StopCPUProfile() // no-op if it never started
+		closeProfile(p.cpu, "cpu profile")
+		p.cpu = nil
+	}
+
+	// trace
+	if p.trace != nil {
+		trace.Stop() // no-op if it never started
+		closeProfile(p.trace, "execution trace")
+		p.trace = nil
+	}
+
+	// heap profile, after a gc so it shows what's live
+	if p.mem != nil {
+		runtime.GC()
+		err := runtimepprof.WriteHeapProfile(p.mem)
+		if err != nil {
+			slog.Error("error writing memory profile", "path", p.mem.Name(), "err", err)
+		}
+		closeProfile(p.mem, "memory profile")
+		p.mem = nil
+	}
+}
+
+// close a profile file helper, logging where it went
+func closeProfile(file *os.File, kind string) {
+	err := file.Close()
+	if err != nil {
+		slog.Error("error writing "+kind, "path", file.Name(), "err", err)
+		return
+	}
+	slog.Info("wrote "+kind, "path", file.Name())
+}
@@ -0,0 +1,78 @@
+// profile.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"net/url"      // escaping the name
+	"time"         // shared since
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// profile handler logic
+// NOTE: cmd will be profile [on|off], publishes the feeds the current user follows on serve's /u/{name}
+func HandlerProfile(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// the profile's url on serve
+	profileURL := serveBaseURL(s) + "/u/" + url.PathEscape(user.Name)
+
+	// no args check, show whether it's shared
+	if len(cmd.Args) == 0 {
+		since, err := s.DB.GetProfileShare(ctx, user.ID)
+
+		// not shared check
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintln(s.Stdout, "Your profile isn't shared, share it with: profile on")
+			return nil
+		}
+
+		// get share check
+		if err != nil {
+			return fmt.Errorf("error getting profile share: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Your profile is shared since %s at %s\n", since.Local().Format(time.DateTime), profileURL)
+		return nil
+	}
+
+	// switch it on or off
+	switch cmd.Args[0] {
+	case "on":
+		err := s.DB.ShareProfile(ctx, database.ShareProfileParams{UserID: user.ID, CreatedAt: time.Now().UTC()})
+
+		// share check
+		if err != nil {
+			return fmt.Errorf("error sharing profile: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Your profile is now shared at %s, with an opml of your feeds at %s/feeds.opml (while serve runs)\n", profileURL, profileURL)
+		fmt.Fprintln(s.Stdout, "Anyone who can reach serve can see the feeds you follow, stop sharing with: profile off")
+		return nil
+	case "off":
+		rows, err := s.DB.UnshareProfile(ctx, user.ID)
+
+		// unshare check
+		if err != nil {
+			return fmt.Errorf("error unsharing profile: %w", err)
+		}
+
+		// wasn't shared check
+		if rows == 0 {
+			fmt.Fprintln(s.Stdout, "Your profile wasn't shared.")
+			return nil
+		}
+		fmt.Fprintln(s.Stdout, "Your profile is no longer shared.")
+		return nil
+	}
+
+	// unknown arg
+	return fmt.Errorf("error: usage: profile [on|off]")
+}
@@ -0,0 +1,155 @@
+// rank.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // null filters
+	"fmt"          // print errors
+	"strconv"      // parsing weights
+	"time"         // candidate windows
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+	"github.com/PietPadda/aggregator/internal/rank"     // the scoring model
+)
+
+// package-wide constants
+const (
+	rankWindow      = 7 * 24 * time.Hour  // browse --ranked picks from the unread posts found in this window
+	rankStatsWindow = 90 * 24 * time.Hour // a feed's engagement is counted over its posts in this window
+	rankCandidates  = 500                 // most posts scored per browse --ranked, the newest
+	maxFeedWeight   = 10                  // highest weight a follow can have
+)
+
+// weight handler logic
+// NOTE: cmd will be weight [<feed url> <weight>], lists or sets how much followed feeds count in browse --ranked
+func HandlerWeight(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// list check
+	if len(cmd.Args) == 0 {
+		return listFeedWeights(ctx, s, user)
+	}
+
+	// usage check
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("error: usage: weight [<feed url> <weight>] (0 to %d, 1 is normal)", maxFeedWeight)
+	}
+
+	// weight check
+	weight, err := strconv.ParseFloat(cmd.Args[1], 32)
+	if err != nil || weight < 0 || weight > maxFeedWeight {
+		return fmt.Errorf("error: invalid weight %q (use 0 to %d, 1 is normal)", cmd.Args[1], maxFeedWeight)
+	}
+
+	// set it
+	rows, err := s.DB.SetFeedFollowWeight(ctx, database.SetFeedFollowWeightParams{
+		Weight: float32(weight),
+		Url:    cmd.Args[0],
+		UserID: user.ID,
+	})
+
+	// set check
+	if err != nil {
+		return fmt.Errorf("error setting feed weight: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("error: you don't follow %s", cmd.Args[0])
+	}
+	fmt.Fprintf(s.Stdout, "%s now weighs %g in browse --ranked.\n", cmd.Args[0], weight)
+	return nil
+}
+
+// weight list helper, the follows with their weight and the user's engagement with them
+func listFeedWeights(ctx context.Context, s *app.State, user database.User) error {
+	feeds, err := s.DB.ListFeedWeights(ctx, database.ListFeedWeightsParams{
+		Since:  time.Now().Add(-rankStatsWindow),
+		UserID: user.ID,
+	})
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing feed weights: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "url", "weight", "posts", "reads", "stars"}}
+		for _, feed := range feeds {
+			table.Add(feed.Name, feed.Url, feed.Weight, feed.Posts, feed.Reads, feed.Stars)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no follows check
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "You don't follow any feeds yet.")
+		return nil
+	}
+	fmt.Fprintln(s.Stdout, "Feed weights in browse --ranked (reads and stars of the last 90 days' posts):")
+	for _, feed := range feeds {
+		fmt.Fprintf(s.Stdout, "* %s (%s): weight %g, %d posts, %d read, %d starred\n", feed.Name, feed.Url, feed.Weight, feed.Posts, feed.Reads, feed.Stars)
+	}
+	return nil
+}
+
+// browse --ranked helper, the user's unread posts of the last week, most likely interesting first
+func rankedPosts(ctx context.Context, s *app.State, user database.User, folder, language string, maxMinutes sql.NullInt32, postLimit int32) ([]database.Post, error) {
+	// get the candidates
+	now := time.Now()
+	rows, err := s.DB.GetRankCandidatesForUser(ctx, database.GetRankCandidatesForUserParams{
+		UserID:         user.ID,
+		StatsSince:     now.Add(-rankStatsWindow),
+		Since:          now.Add(-rankWindow),
+		Folder:         sql.NullString{String: folder, Valid: folder != ""},
+		Language:       sql.NullString{String: language, Valid: language != ""},
+		MaxMinutes:     maxMinutes,
+		CandidateLimit: rankCandidates,
+	})
+
+	// candidates check
+	if err != nil {
+		return nil, fmt.Errorf("error getting posts to rank: %w", err)
+	}
+
+	// score them
+	scored := make([]rank.Post, len(rows))
+	for i, row := range rows {
+		scored[i] = rank.Post{
+			Age:        now.Sub(row.CreatedAt),
+			FeedWeight: float64(row.Weight),
+			FeedPosts:  row.FeedPosts,
+			FeedReads:  row.FeedReads,
+			FeedStars:  row.FeedStars,
+			OtherReads: row.OtherReads,
+			OtherStars: row.OtherStars,
+		}
+	}
+
+	// the best ones
+	order := rank.Order(scored)
+	posts := make([]database.Post, 0, min(len(order), int(postLimit)))
+	for _, i := range order[:min(len(order), max(int(postLimit), 0))] {
+		row := rows[i]
+		posts = append(posts, database.Post{
+			ID:             row.ID,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt,
+			Title:          row.Title,
+			Url:            row.Url,
+			Description:    row.Description,
+			PublishedAt:    row.PublishedAt,
+			FeedID:         row.FeedID,
+			FeverID:        row.FeverID,
+			Language:       row.Language,
+			ReadingMinutes: row.ReadingMinutes,
+		})
+	}
+	return posts, nil
+}
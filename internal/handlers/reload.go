@@ -0,0 +1,191 @@
+// reload.go
+package handlers
+
+import (
+	// std go libs
+	"bytes"         // comparing http sections
+	"cmp"           // default timeout
+	"context"       // for context
+	"encoding/json" // comparing http sections
+	"log/slog"      // structured logging
+	"os"            // config file stat
+	"os/signal"     // SIGHUP
+	"syscall"       // SIGHUP
+	"time"          // polling
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"     // for State
+	"github.com/PietPadda/aggregator/internal/config"  // re-reading the config
+	"github.com/PietPadda/aggregator/internal/logging" // changing the log level
+	"github.com/PietPadda/aggregator/internal/rssfeed" // rebuilding the http client
+)
+
+// package-wide constants
+const configPollInterval = 2 * time.Second // how often a daemon checks the config file for changes
+
+// watch the config file of a long-running command, sending every valid new config
+// reloads when the file changes (polled, no extra deps), on SIGHUP or on asks (agg reload, nil = none)
+// SIGHUP and asks send the config even if it didn't change, so agg checks for new feeds right away
+// invalid configs are logged and skipped
+func watchConfig(ctx context.Context, s *app.State, asks <-chan struct{}) <-chan config.Config {
+	// create the reloads channel (never sends if there's no file path)
+	reloads := make(chan config.Config)
+
+	// get the config file's path
+	path, err := s.Config.Path()
+
+	// path check
+	if err != nil {
+		slog.Warn("not watching the config file", "err", err)
+		return reloads
+	}
+
+	// SIGHUP reloads right away (a no-op on windows)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// watch until the command ends
+	go func() {
+		defer signal.Stop(hup)
+		modTime := fileModTime(path)
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+
+		for {
+			// wait for a change, a SIGHUP or the end
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				slog.Info("got SIGHUP, reloading config", "path", path)
+			case <-asks:
+				slog.Info("reloading config", "path", path)
+			case <-ticker.C:
+				// changed check
+				if fileModTime(path).Equal(modTime) {
+					continue
+				}
+				slog.Info("config file changed, reloading", "path", path)
+			}
+			modTime = fileModTime(path)
+
+			// read and check the new config
+			cfg, err := loadConfig(path)
+
+			// new config check, keep running on the old one
+			if err != nil {
+				slog.Error("config not reloaded, keeping the current one", "err", err)
+				continue
+			}
+
+			// hand it over (or stop)
+			select {
+			case reloads <- cfg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// return the reloads
+	return reloads
+}
+
+// read a config and check what a reload applies helper, so a bad edit never reaches the daemon
+func loadConfig(path string) (config.Config, error) {
+	// read the file (and the env)
+	cfg, err := config.ReadFrom(path)
+
+	// read check
+	if err != nil {
+		return cfg, err
+	}
+
+	// http section check
+	_, err = cfg.HTTPOptions()
+	if err != nil {
+		return cfg, err
+	}
+
+	// fetch concurrency check
+	_, _, _, err = cfg.FetchLimits()
+	if err != nil {
+		return cfg, err
+	}
+
+	// safety floor check
+	_, err = cfg.MinAggInterval()
+	if err != nil {
+		return cfg, err
+	}
+
+	// log level check
+	_, err = logging.ParseLevel(cfg.LogLevelName())
+	if err != nil {
+		return cfg, err
+	}
+
+	// return the config
+	return cfg, nil
+}
+
+// apply a reloaded config to a running agg or serve helper, logging what changed
+// the interval only follows agg_interval if agg was started without one, and keeps the safety floor
+// returns the (new) interval
+func applyConfig(s *app.State, cfg config.Config, interval time.Duration, intervalFromConfig, force bool) time.Duration {
+	// interval check
+	if intervalFromConfig && cfg.AggInterval != nil {
+		newInterval, err := time.ParseDuration(*cfg.AggInterval)
+		minInterval, _ := cfg.MinAggInterval() // checked by loadConfig
+
+		switch {
+		case err != nil || newInterval <= 0:
+			slog.Error("invalid agg_interval, keeping the current interval", "agg_interval", *cfg.AggInterval, "interval", interval)
+		case newInterval < minInterval && !force:
+			slog.Error("agg_interval is below agg_min_interval, keeping the current interval", "agg_interval", newInterval, "min", minInterval, "interval", interval)
+		case newInterval != interval:
+			slog.Info("agg interval changed", "from", interval, "to", newInterval)
+			interval = newInterval
+		}
+	}
+
+	// http client check, rebuilt only if its settings changed (so connections are kept)
+	if !sameHTTP(s.Config.HTTP, cfg.HTTP) {
+		opts, _ := cfg.HTTPOptions() // checked by loadConfig
+		s.HTTP = rssfeed.NewClient(opts)
+		rssfeed.SetShared(s.HTTP)
+		slog.Info("http settings changed", "concurrency", cfg.FetchConcurrency(), "timeout", cmp.Or(opts.Timeout, rssfeed.DefaultTimeout))
+	}
+
+	// log level check (ignored if -v/-q pinned it)
+	oldLevel := logging.CurrentLevel()
+	logging.SetConfigLevel(cfg.LogLevelName()) // checked by loadConfig
+	if newLevel := logging.CurrentLevel(); newLevel != oldLevel {
+		slog.Info("log level changed", "from", oldLevel, "to", newLevel)
+	}
+
+	// swap in the new config (State.Config is shared, so every handler sees it)
+	*s.Config = cfg
+
+	// return the interval
+	return interval
+}
+
+// same http section helper
+func sameHTTP(a, b *config.HTTPConfig) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return bytes.Equal(aJSON, bJSON)
+}
+
+// a file's modification time helper, zero if it doesn't exist (yet)
+func fileModTime(path string) time.Time {
+	// stat the file
+	info, err := os.Stat(path)
+
+	// stat check
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
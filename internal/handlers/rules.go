@@ -0,0 +1,341 @@
+// rules.go
+package handlers
+
+import (
+	// std go libs
+	"cmp"          // the stored url, else the given one
+	"context"      // for context
+	"database/sql" // no rows and nullable columns
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"log/slog"     // logging rule actions
+	"strings"      // id prefixes
+	"time"         // created at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+	"github.com/PietPadda/aggregator/internal/push"     // notify push
+	"github.com/PietPadda/aggregator/internal/rules"    // matching posts
+	"github.com/PietPadda/aggregator/internal/telegram" // notify telegram
+	"github.com/google/uuid"                            // rule ids
+)
+
+// rule handler logic
+// NOTE: cmd will be rule add [--feed <url>] [--title <regexp>] [--category <c>] [--author <a>] <action> [argument] | list | delete <id>
+// NOTE: agg runs the rules of a feed's followers on each new post it stores
+func HandlerRule(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf("error: usage: rule add [--feed <url>] [--title <regexp>] [--category <category>] [--author <author>] <action> [argument] | rule list | rule delete <id>")
+	}
+
+	// subcommand check
+	switch cmd.Args[0] {
+	case "add":
+		return addRule(ctx, s, user, cmd.Args[1:])
+	case "list":
+		return listRules(ctx, s, user)
+	case "delete":
+		if len(cmd.Args) != 2 {
+			return fmt.Errorf("error: usage: rule delete <id> (see rule list)")
+		}
+		return deleteRule(ctx, s, user, cmd.Args[1])
+	}
+	return fmt.Errorf("error: unknown rule command %q (use add, list or delete)", cmd.Args[0])
+}
+
+// rule add helper
+func addRule(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// strip the match flags
+	args, feedURL, err := popFlagValue(args, "--feed")
+	if err != nil {
+		return err
+	}
+	args, title, err := popFlagValue(args, "--title")
+	if err != nil {
+		return err
+	}
+	args, category, err := popFlagValue(args, "--category")
+	if err != nil {
+		return err
+	}
+	args, author, err := popFlagValue(args, "--author")
+	if err != nil {
+		return err
+	}
+
+	// something to match check, a rule on every post is almost surely a mistake
+	if feedURL == "" && title == "" && category == "" && author == "" {
+		return fmt.Errorf("error: a rule needs at least one of --feed, --title, --category or --author")
+	}
+
+	// action check
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("error: usage: rule add [match flags] mute|star|tag <tag>|notify push|telegram|webhook <url>")
+	}
+	action, argument := args[0], ""
+	if len(args) == 2 {
+		argument = args[1]
+	}
+	err = rules.CheckAction(action, argument)
+	if err != nil {
+		return err
+	}
+
+	// title pattern check
+	_, err = rules.Compile(title)
+	if err != nil {
+		return err
+	}
+
+	// feed check
+	var feedID uuid.NullUUID
+	if feedURL != "" {
+		storedURL, _, err := findEquivalentFeed(ctx, s, feedURL) // maybe stored spelled differently
+		if err != nil {
+			return err
+		}
+		feed, err := s.DB.GetFeedByURL(ctx, cmp.Or(storedURL, feedURL))
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: no feed with url %s (see feeds)", feedURL)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting feed: %w", err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	// create the rule
+	id := uuid.New()
+	err = s.DB.CreateRule(ctx, database.CreateRuleParams{
+		ID:           id,
+		CreatedAt:    time.Now().UTC(),
+		UserID:       user.ID,
+		FeedID:       feedID,
+		TitlePattern: sql.NullString{String: title, Valid: title != ""},
+		Category:     sql.NullString{String: category, Valid: category != ""},
+		Author:       sql.NullString{String: author, Valid: author != ""},
+		Action:       action,
+		Argument:     sql.NullString{String: argument, Valid: argument != ""},
+	})
+
+	// create check
+	if err != nil {
+		return fmt.Errorf("error creating rule: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Rule %s added, it applies to posts agg stores from now on.\n", shortID(id))
+	return nil
+}
+
+// rule list helper
+func listRules(ctx context.Context, s *app.State, user database.User) error {
+	rows, err := s.DB.ListRules(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing rules: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"id", "feed", "title", "category", "author", "action", "argument", "created_at"}}
+		for _, row := range rows {
+			table.Add(row.ID, nullString(row.FeedUrl), nullString(row.TitlePattern), nullString(row.Category), nullString(row.Author), row.Action, nullString(row.Argument), row.CreatedAt)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no rules check
+	if len(rows) == 0 {
+		fmt.Fprintln(s.Stdout, "No rules yet, add one with: rule add --title <regexp> mute")
+		return nil
+	}
+
+	// print them
+	for _, row := range rows {
+		// what it matches
+		matches := []string{}
+		if row.FeedUrl.Valid {
+			matches = append(matches, "feed "+row.FeedUrl.String)
+		}
+		if row.TitlePattern.Valid {
+			matches = append(matches, fmt.Sprintf("title /%s/", row.TitlePattern.String))
+		}
+		if row.Category.Valid {
+			matches = append(matches, fmt.Sprintf("category %q", row.Category.String))
+		}
+		if row.Author.Valid {
+			matches = append(matches, fmt.Sprintf("author %q", row.Author.String))
+		}
+
+		// what it does
+		action := row.Action
+		if row.Argument.Valid {
+			action += " " + row.Argument.String
+		}
+		fmt.Fprintf(s.Stdout, "%s  %s -> %s\n", shortID(row.ID), strings.Join(matches, ", "), action)
+	}
+	return nil
+}
+
+// rule delete helper, by id or its start (as rule list shows it)
+func deleteRule(ctx context.Context, s *app.State, user database.User, prefix string) error {
+	rows, err := s.DB.ListRules(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing rules: %w", err)
+	}
+
+	// find the rule, the prefix must match exactly one
+	var matched []uuid.UUID
+	for _, row := range rows {
+		if strings.HasPrefix(row.ID.String(), strings.ToLower(prefix)) {
+			matched = append(matched, row.ID)
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("error: no rule %s (see rule list)", prefix)
+	}
+	if len(matched) > 1 {
+		return fmt.Errorf("error: %s matches %d rules, give more of the id", prefix, len(matched))
+	}
+
+	// delete it
+	_, err = s.DB.DeleteRule(ctx, database.DeleteRuleParams{ID: matched[0], UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("error deleting rule: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Rule %s deleted.\n", shortID(matched[0]))
+	return nil
+}
+
+// short id helper, the start of a uuid that's plenty to tell a user's rules apart
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
+
+// a new post as the scraper hands it to the rules
+type rulePost struct {
+	ID    uuid.UUID
+	Match rules.Post
+	Hook  rules.WebhookPost
+}
+
+// a rule action that reaches outside the database, taken once the posts are committed
+type ruleDelivery struct {
+	UserID   uuid.UUID
+	Action   string // rules.Notify or rules.Webhook
+	Argument string
+	Post     rules.WebhookPost
+}
+
+// run the rules on a feed's new posts helper, for the scraper (inside its transaction)
+// mute, star and tag are stored right away, notify and webhook are returned for deliverRules
+func applyRules(ctx context.Context, queries database.Store, feedID uuid.UUID, posts []rulePost) ([]ruleDelivery, error) {
+	// get the rules of the feed's followers
+	rows, err := queries.ListRulesForFeed(ctx, feedID)
+
+	// rules check
+	if err != nil {
+		return nil, fmt.Errorf("error getting rules: %w", err)
+	}
+
+	// run each rule on each post
+	var deliveries []ruleDelivery
+	for _, row := range rows {
+		// compile it (rule add checked it, so a bad one was hand edited, skip it)
+		title, err := rules.Compile(row.TitlePattern.String)
+		if err != nil {
+			slog.Warn("skipping rule with a bad title pattern", "rule", row.ID, "err", err)
+			continue
+		}
+		rule := rules.Rule{
+			FeedID:   row.FeedID,
+			Title:    title,
+			Category: row.Category.String,
+			Author:   row.Author.String,
+			Action:   row.Action,
+			Argument: row.Argument.String,
+		}
+
+		// match the posts
+		for _, post := range posts {
+			if !rule.Matches(post.Match) {
+				continue
+			}
+			slog.Debug("rule matched", "rule", row.ID, "action", rule.Action, "title", post.Match.Title)
+
+			// take the action
+			switch rule.Action {
+			case rules.Mute:
+				err = queries.MutePost(ctx, database.MutePostParams{UserID: row.UserID, PostID: post.ID})
+			case rules.Star:
+				err = queries.StarPost(ctx, database.StarPostParams{UserID: row.UserID, PostID: post.ID})
+			case rules.Tag:
+				err = queries.TagPost(ctx, database.TagPostParams{UserID: row.UserID, PostID: post.ID, Tag: rule.Argument})
+			case rules.Notify, rules.Webhook:
+				deliveries = append(deliveries, ruleDelivery{UserID: row.UserID, Action: rule.Action, Argument: rule.Argument, Post: post.Hook})
+			}
+
+			// action check
+			if err != nil {
+				return nil, fmt.Errorf("error applying rule %s: %w", shortID(row.ID), err)
+			}
+		}
+	}
+	return deliveries, nil
+}
+
+// take the rule actions that reach outside helper, for the scraper once its posts are committed
+// errors are logged, the posts are stored either way
+func deliverRules(ctx context.Context, s *app.State, deliveries []ruleDelivery) {
+	for _, delivery := range deliveries {
+		err := deliverRule(ctx, s, delivery)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error delivering rule action", "action", delivery.Action, "argument", delivery.Argument, "err", err)
+		}
+	}
+}
+
+// take one rule action helper
+func deliverRule(ctx context.Context, s *app.State, delivery ruleDelivery) error {
+	// webhook check
+	if delivery.Action == rules.Webhook {
+		return rules.SendWebhook(ctx, delivery.Argument, delivery.Post)
+	}
+
+	// notify through the channel
+	switch delivery.Argument {
+	case rules.ChannelPush:
+		row, err := s.DB.GetPushTarget(ctx, delivery.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: notifications aren't set up (see notify)")
+		}
+		if err != nil {
+			return fmt.Errorf("error getting push target: %w", err)
+		}
+		return push.Send(ctx, pushTarget(row), push.Notification{Title: delivery.Post.Feed, Message: delivery.Post.Title, URL: delivery.Post.URL})
+	case rules.ChannelTelegram:
+		if s.Config.TelegramToken == nil || *s.Config.TelegramToken == "" {
+			return fmt.Errorf("error: no telegram_token configured")
+		}
+		link, err := s.DB.GetTelegramLink(ctx, delivery.UserID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !link.ChatID.Valid) {
+			return fmt.Errorf("error: no telegram chat linked (see telegram link)")
+		}
+		if err != nil {
+			return fmt.Errorf("error getting telegram link: %w", err)
+		}
+		message := fmt.Sprintf("%s: %s\n%s", delivery.Post.Feed, delivery.Post.Title, delivery.Post.URL)
+		return telegram.New(*s.Config.TelegramToken).SendMessage(ctx, link.ChatID.Int64, message)
+	}
+	return fmt.Errorf("error: unknown notify channel %q", delivery.Argument)
+}
@@ -0,0 +1,88 @@
+// schedule.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // nullable schedule
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"strings"      // joining the cron fields
+	"time"         // next fetch
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/database"  // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/scheduler" // for parsing cron schedules
+)
+
+// setschedule handler logic
+// NOTE: cmd will be setschedule <url> "<cron>"|none, a cron schedule wins over the feed's refresh interval
+// only the user who added the feed (or an admin) may change it, like setinterval
+func HandlerSetSchedule(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get arguments input (the fields may come quoted or not)
+	feedURL := cmd.Args[0]
+	expr := strings.Join(cmd.Args[1:], " ")
+
+	// clear check, back to the refresh interval
+	var schedule sql.NullString
+	var next sql.NullTime
+	if expr != "none" {
+		// get when it's first due (also validates it)
+		first, err := scheduler.NextCronFetch(expr, time.Now())
+
+		// schedule check
+		if err != nil {
+			return err
+		}
+		schedule = sql.NullString{String: expr, Valid: true}
+		next = sql.NullTime{Time: first, Valid: true}
+	}
+
+	// owner check, only admins may change feeds someone else added (like setinterval)
+	owner, err := s.DB.GetFeedOwner(ctx, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: no feed found with url %s", feedURL)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+	if owner != user.ID {
+		err = requireAdmin(ctx, s, "changing the schedule of a feed someone else added")
+		if err != nil {
+			return err
+		}
+	}
+
+	// update the feed's schedule
+	rows, err := s.DB.SetFeedSchedule(ctx, database.SetFeedScheduleParams{
+		Url:         feedURL,
+		RefreshCron: schedule,
+		NextFetchAt: next,
+	})
+
+	// update check
+	if err != nil {
+		return fmt.Errorf("error setting feed schedule: %w", err)
+	}
+
+	// feed exists check
+	if rows == 0 {
+		return fmt.Errorf("error: no feed found with url %s", feedURL)
+	}
+
+	// print confirmation msg to user
+	if !schedule.Valid {
+		fmt.Fprintf(s.Stdout, "Feed %s is fetched on its refresh interval again\n", feedURL)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Feed %s is now fetched on schedule %q, next at %s\n", feedURL, expr, next.Time.Local().Format(time.DateTime))
+
+	// return success
+	return nil
+}
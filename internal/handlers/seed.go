@@ -0,0 +1,214 @@
+// seed.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // for sql errors
+	"errors"       // for error handling
+	"fmt"          // print errors
+	"log/slog"     // structured logging
+	"strings"      // filter text in strs
+	"time"         // created/updated at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/google/uuid"                            // for UUID generation
+)
+
+// package-wide constants
+const seedUserName = "demo" // the user seed creates (and logs in as)
+
+// a feed seed adds
+type seedFeed struct {
+	name string
+	url  string
+}
+
+// well-known rss feeds for trying gator out
+var seedFeeds = []seedFeed{
+	{name: "Hacker News", url: "https://news.ycombinator.com/rss"},
+	{name: "TechCrunch", url: "https://techcrunch.com/feed/"},
+	{name: "Boot.dev Blog", url: "https://blog.boot.dev/index.xml"},
+	{name: "Lobsters", url: "https://lobste.rs/rss"},
+	{name: "Wagslane", url: "https://www.wagslane.dev/index.xml"},
+}
+
+// seed handler logic
+// NOTE: cmd will be seed, creates the demo user, follows a handful of well-known feeds and fetches them once
+// so browse works right after install; safe to run again, it skips what already exists
+func HandlerSeed(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get or create the demo user
+	user, err := seedUser(ctx, s)
+
+	// seed user check
+	if err != nil {
+		return err
+	}
+
+	// log in as the demo user, so browse shows the demo feeds
+	err = s.Config.SetUser(user.Name)
+
+	// set user check
+	if err != nil {
+		return fmt.Errorf("error logging in as %s: %w", user.Name, err)
+	}
+	fmt.Fprintf(s.Stdout, "Logged in as '%s'\n", user.Name)
+
+	// add and follow the feeds, collecting the new ones to fetch
+	var newFeeds []database.Feed
+	for _, demo := range seedFeeds {
+		feed, created, err := seedFollow(ctx, s, user, demo)
+
+		// seed follow check
+		if err != nil {
+			return err
+		}
+
+		// new feed check (existing feeds are agg's job)
+		if created {
+			newFeeds = append(newFeeds, feed)
+		}
+	}
+
+	// fetch one round of posts for the new feeds
+	fetched := 0
+	for _, feed := range newFeeds {
+		err := scrapeFeed(ctx, s, feed)
+
+		// scrape feed check (just warn, the feed is still added, agg will retry it)
+		if err != nil {
+			slog.Warn("error fetching demo feed", "feed", feed.Name, "err", err)
+			recordFeedFailure(ctx, s, feed, err)
+			continue
+		}
+		fetched++
+	}
+
+	// print summary
+	fmt.Fprintf(s.Stdout, "Fetched %d of %d new demo feeds\n", fetched, len(newFeeds))
+	fmt.Fprintln(s.Stdout, "Try it out with: aggregator browse 5")
+
+	// return success
+	return nil
+}
+
+// get or create the demo user helper
+func seedUser(ctx context.Context, s *app.State) (database.User, error) {
+	// get the user
+	user, err := s.DB.GetUser(ctx, seedUserName)
+
+	// already exists check
+	if err == nil {
+		return user, nil
+	}
+
+	// get user check
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("error getting user from db: %w", err)
+	}
+
+	// create the user
+	currentTime := time.Now()
+	user, err = s.DB.CreateUser(ctx, database.CreateUserParams{
+		ID:        uuid.New(),   // generate new UUID
+		CreatedAt: currentTime,  // set created at to current time
+		UpdatedAt: currentTime,  // set updated at to current time
+		Name:      seedUserName, // the demo user
+	})
+
+	// create user check
+	if err != nil {
+		// unique name check, GetUser above skips soft deleted users, so the name belongs to one
+		if strings.Contains(err.Error(), "unique constraint") {
+			return database.User{}, fmt.Errorf("error: user '%s' was deleted, restore them with: undelete user %s", seedUserName, seedUserName)
+		}
+		return database.User{}, fmt.Errorf("error creating user %s: %w", seedUserName, err)
+	}
+	fmt.Fprintf(s.Stdout, "User '%s' created!\n", user.Name)
+
+	// return the new user
+	return user, nil
+}
+
+// add a demo feed (if it's not stored yet) and follow it helper
+// returns the feed if it was created, and whether it was
+func seedFollow(ctx context.Context, s *app.State, user database.User, demo seedFeed) (database.Feed, bool, error) {
+	// already stored check
+	_, err := s.DB.GetFeedByURL(ctx, demo.url)
+
+	// stored, just follow it (following it already is fine too)
+	if err == nil {
+		err = followFeed(ctx, s, user, demo.url)
+		if err != nil && !strings.Contains(err.Error(), "already following") {
+			return database.Feed{}, false, err
+		}
+		return database.Feed{}, false, nil
+	}
+
+	// get feed check
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, false, fmt.Errorf("error getting feed %s: %w", demo.url, err)
+	}
+
+	// create the feed and its follow in one transaction (like addfeed)
+	tx, err := s.Conn.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return database.Feed{}, false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit
+
+	// run the queries inside the transaction
+	queries := s.DB.WithTx(tx)
+
+	// create the feed
+	currentTime := time.Now()
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: currentTime,
+		UpdatedAt: currentTime,
+		Name:      demo.name,
+		Url:       demo.url,
+		UserID:    user.ID,
+		UrlKey:    feedURLKey(demo.url),
+	})
+
+	// create feed check
+	if err != nil {
+		return database.Feed{}, false, fmt.Errorf("error adding feed %s: %w", demo.name, err)
+	}
+
+	// follow it
+	_, err = queries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		ID:        uuid.New(),
+		CreatedAt: currentTime,
+		UpdatedAt: currentTime,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+
+	// feed follow check (rolls back the feed too)
+	if err != nil {
+		return database.Feed{}, false, fmt.Errorf("error following feed %s: %w", demo.name, err)
+	}
+
+	// commit the feed and follow together
+	err = tx.Commit()
+
+	// commit check
+	if err != nil {
+		return database.Feed{}, false, fmt.Errorf("error committing feed %s: %w", demo.name, err)
+	}
+	fmt.Fprintf(s.Stdout, "Added and followed '%s'\n", feed.Name)
+
+	// return the new feed
+	return feed, true, nil
+}
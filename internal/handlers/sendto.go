@@ -0,0 +1,248 @@
+// sendto.go
+package handlers
+
+import (
+	// std go libs
+	"bufio"         // waiting for pocket's authorization
+	"context"       // for context
+	"database/sql"  // no rows
+	"encoding/json" // account settings
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // print errors
+	"os"            // for stdin and stdout
+	"strings"       // id prefixes
+	"time"          // login times
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/database"  // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"    // machine-readable output
+	"github.com/PietPadda/aggregator/internal/readlater" // pocket, instapaper and wallabag
+)
+
+// package-wide constants
+const (
+	pocketRedirect = "https://getpocket.com/" // pocket wants a redirect, a cli has nowhere to go
+)
+
+// sendto handler logic
+// NOTE: cmd will be sendto <service> <post id|url> | login <service> ... | logout <service> | accounts
+// NOTE: post ids are the start of the id browse prints, urls must be of a followed feed's post
+func HandlerSendTo(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: sendto pocket|instapaper|wallabag <post id|url> | sendto login <service> ... | sendto logout <service> | sendto accounts")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// subcommand check
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "login":
+		return readLaterLogin(ctx, s, user, args)
+	case "logout":
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: sendto logout <service>")
+		}
+		rows, err := s.DB.DeleteReadLaterAccount(ctx, database.DeleteReadLaterAccountParams{UserID: user.ID, Service: args[0]})
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error removing read-later account: %w", err)
+		}
+		if rows == 0 {
+			fmt.Fprintf(s.Stdout, "You weren't logged in to %s.\n", args[0])
+			return nil
+		}
+		fmt.Fprintf(s.Stdout, "Logged out of %s.\n", args[0])
+		return nil
+	case "accounts":
+		return readLaterAccounts(ctx, s, user)
+	case readlater.Pocket, readlater.Instapaper, readlater.Wallabag:
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: sendto %s <post id|url>", cmd.Args[0])
+		}
+		return sendPost(ctx, s, user, cmd.Args[0], args[0])
+	}
+	return usage
+}
+
+// sendto login helper, each service logs in its own way
+func readLaterLogin(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// service check
+	if len(args) == 0 {
+		return fmt.Errorf("error: usage: sendto login pocket <consumer key> | instapaper <email> <password> | wallabag <url> <client id> <client secret> <user> <password>")
+	}
+
+	// build the account
+	var account readlater.Account
+	service, args := args[0], args[1:]
+	switch service {
+	case readlater.Pocket:
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: sendto login pocket <consumer key> (create an app at https://getpocket.com/developer/)")
+		}
+		token, err := pocketLogin(ctx, s, args[0])
+		if err != nil {
+			return err
+		}
+		account = readlater.Account{ConsumerKey: args[0], AccessToken: token}
+	case readlater.Instapaper:
+		if len(args) != 2 {
+			return fmt.Errorf("error: usage: sendto login instapaper <email> <password>")
+		}
+		account = readlater.Account{Username: args[0], Password: args[1]}
+	case readlater.Wallabag:
+		if len(args) != 5 {
+			return fmt.Errorf("error: usage: sendto login wallabag <url> <client id> <client secret> <user> <password>")
+		}
+		account = readlater.Account{URL: args[0], ClientID: args[1], ClientSecret: args[2], Username: args[3], Password: args[4]}
+	default:
+		return fmt.Errorf("error: unknown read-later service %q (use pocket, instapaper or wallabag)", service)
+	}
+
+	// login check, before it's saved
+	err := readlater.Check(ctx, service, account)
+	if err != nil {
+		return err
+	}
+
+	// save it
+	settings, err := json.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("error encoding read-later account: %w", err)
+	}
+	err = s.DB.SetReadLaterAccount(ctx, database.SetReadLaterAccountParams{
+		UserID:    user.ID,
+		Service:   service,
+		CreatedAt: time.Now().UTC(),
+		Settings:  settings,
+	})
+
+	// save check
+	if err != nil {
+		return fmt.Errorf("error saving read-later account: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Logged in to %s, send posts with: sendto %s <post id|url>\n", service, service)
+	return nil
+}
+
+// pocket oauth helper, the user authorizes gator in their browser then presses enter
+func pocketLogin(ctx context.Context, s *app.State, consumerKey string) (string, error) {
+	code, authURL, err := readlater.PocketAuthorize(ctx, consumerKey, pocketRedirect)
+	if err != nil {
+		return "", err
+	}
+
+	// wait for the user
+	fmt.Fprintf(s.Stdout, "Open this url and authorize gator:\n%s\n", authURL)
+	fmt.Fprint(s.Stdout, "Press Enter once done...")
+	_, err = bufio.NewReader(os.Stdin).ReadString('\n')
+
+	// read check
+	if err != nil {
+		return "", fmt.Errorf("error reading stdin: %w", err)
+	}
+
+	// trade the code for a token
+	token, username, err := readlater.PocketAccessToken(ctx, consumerKey, code)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(s.Stdout, "Authorized as %s.\n", username)
+	return token, nil
+}
+
+// sendto accounts helper, lists the services the user is logged in to
+func readLaterAccounts(ctx context.Context, s *app.State, user database.User) error {
+	accounts, err := s.DB.ListReadLaterAccounts(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing read-later accounts: %w", err)
+	}
+
+	// machine-readable output check (settings hold passwords, so never printed)
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"service", "logged_in_at"}}
+		for _, account := range accounts {
+			table.Add(account.Service, account.CreatedAt)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no accounts check
+	if len(accounts) == 0 {
+		fmt.Fprintln(s.Stdout, "Not logged in to any read-later service, use sendto login <service>.")
+		return nil
+	}
+	for _, account := range accounts {
+		fmt.Fprintf(s.Stdout, "%s (since %s)\n", account.Service, account.CreatedAt.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// sendto <service> helper, finds the post and saves it to the service
+func sendPost(ctx context.Context, s *app.State, user database.User, service, ref string) error {
+	// get the account
+	row, err := s.DB.GetReadLaterAccount(ctx, database.GetReadLaterAccountParams{UserID: user.ID, Service: service})
+
+	// not logged in check
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: not logged in to %s, use sendto login %s", service, service)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting read-later account: %w", err)
+	}
+	var account readlater.Account
+	err = json.Unmarshal(row.Settings, &account)
+	if err != nil {
+		return fmt.Errorf("error decoding read-later account: %w", err)
+	}
+
+	// find the post
+	post, err := findPost(ctx, s, user, ref)
+	if err != nil {
+		return err
+	}
+
+	// send it
+	err = readlater.Save(ctx, service, account, readlater.Item{URL: post.Url, Title: post.Title})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Sent '%s' to %s.\n", post.Title, service)
+	return nil
+}
+
+// find a followed post by url or id prefix helper
+func findPost(ctx context.Context, s *app.State, user database.User, ref string) (database.Post, error) {
+	// id prefix check, LIKE wildcards would match anything
+	ref = strings.TrimSpace(ref)
+	isURL := strings.Contains(ref, "://")
+	if !isURL {
+		ref = strings.ToLower(ref)
+		if ref == "" || strings.ContainsAny(ref, "%_\\") {
+			return database.Post{}, fmt.Errorf("error: %q isn't a post id or url", ref)
+		}
+	}
+
+	posts, err := s.DB.FindPostsForUser(ctx, database.FindPostsForUserParams{UserID: user.ID, Ref: ref})
+
+	// find check
+	if err != nil {
+		return database.Post{}, fmt.Errorf("error finding post: %w", err)
+	}
+	if len(posts) == 0 {
+		return database.Post{}, fmt.Errorf("error: no post %q in your followed feeds", ref)
+	}
+	if len(posts) > 1 && !isURL {
+		return database.Post{}, fmt.Errorf("error: post id %q is ambiguous, give more of it", ref)
+	}
+	return posts[0], nil
+}
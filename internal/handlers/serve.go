@@ -0,0 +1,109 @@
+// serve.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"errors"   // matching http.ErrServerClosed
+	"fmt"      // print errors
+	"log/slog" // structured logging
+	"net/http" // the api server
+	"time"     // shutdown grace period
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/api" // the json api
+	"github.com/PietPadda/aggregator/internal/app" // for State and Command
+)
+
+// package-wide constants
+const serveShutdownGrace = 5 * time.Second // in-flight requests get this long to finish on ctrl+c
+
+// serve handler logic
+// NOTE: cmd will be serve [addr] [--cpuprofile <file>] [--memprofile <file>] [--trace <file>], serves the users, feeds, follows and posts as a json api (until ctrl+c)
+func HandlerServe(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// strip the optional profiling flags from the args (written when serve stops)
+	args, profiles, err := popProfileFlags(cmd.Args)
+
+	// profiling flags check
+	if err != nil {
+		return err
+	}
+
+	// get the address, the arg or serve_addr (default :8080)
+	addr := s.Config.ServeAddress()
+	switch len(args) {
+	case 0:
+	case 1:
+		addr = args[0]
+	default:
+		return fmt.Errorf("error: usage: serve [addr] [--cpuprofile <file>] [--memprofile <file>] [--trace <file>]")
+	}
+
+	// pprof check, if pprof_addr is set
+	err = startPprof(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	// profiles check, if asked for
+	err = profiles.start()
+	if err != nil {
+		return err
+	}
+	defer profiles.stop()
+
+	// create the server
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           api.NewServer(s.DB, imageCache(s)).Handler(),
+		ReadHeaderTimeout: 10 * time.Second, // slow clients can't hold connections open
+	}
+
+	// watch the config, so http settings and log level changes apply without a restart (like agg)
+	// NOTE: the listen address and the rest of the config need a restart
+	reloads := watchConfig(ctx, s, nil)
+	go func() {
+		for {
+			select {
+			case cfg := <-reloads:
+				applyConfig(s, cfg, 0, false, false) // serve has no interval
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// serve in the background, so ctrl+c can shut it down
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	slog.Info("serving api", "addr", addr)
+	fmt.Fprintf(s.Stdout, "Serving the api on %s (ctrl+c to stop)...\n", addr)
+
+	// wait for ctrl+c (or --timeout), or the server failing
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("error serving api on %s: %w", addr, err)
+	case <-ctx.Done():
+	}
+
+	// shut down, letting in-flight requests finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownGrace)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+
+	// shutdown check
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error shutting down api: %w", err)
+	}
+
+	// return success
+	fmt.Fprintln(s.Stdout, "Stopped serving the api.")
+	return nil
+}
@@ -0,0 +1,150 @@
+// share.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows and null messages
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"strings"      // joining the message
+	"time"         // created and seen at
+
+	// external packages
+	"github.com/google/uuid" // share ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+)
+
+// most shares inbox shows at once
+const inboxLimit = 50
+
+// share handler logic
+// NOTE: cmd will be share <post id|url> <user> [message], puts the post in the other user's inbox
+func HandlerShare(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// the optional message (Run checked the post and user are there)
+	message := strings.TrimSpace(strings.Join(cmd.Args[2:], " "))
+
+	// find the post
+	post, err := findPost(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	// find the recipient
+	recipient, err := s.DB.GetUser(ctx, cmd.Args[1])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: no user named %s", cmd.Args[1])
+	}
+	if err != nil {
+		return fmt.Errorf("error getting user %s: %w", cmd.Args[1], err)
+	}
+	if recipient.ID == user.ID {
+		return fmt.Errorf("error: that's you, share it with someone else")
+	}
+
+	// share it
+	_, err = s.DB.CreatePostShare(ctx, database.CreatePostShareParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC(),
+		FromUserID: user.ID,
+		ToUserID:   recipient.ID,
+		PostID:     post.ID,
+		Message:    sql.NullString{String: message, Valid: message != ""},
+	})
+
+	// share check
+	if err != nil {
+		return fmt.Errorf("error sharing post: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Shared '%s' with %s.\n", post.Title, recipient.Name)
+	return nil
+}
+
+// inbox handler logic
+// NOTE: cmd will be inbox [--all], shows the posts others shared with the current user and marks them seen
+func HandlerInbox(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// strip the optional all flag from the args (seen shares too)
+	args, all := popFlag(cmd.Args, "--all")
+	if len(args) != 0 {
+		return fmt.Errorf("error: usage: inbox [--all]")
+	}
+
+	// get the shares
+	shares, err := s.DB.ListInbox(ctx, database.ListInboxParams{
+		UserID:     user.ID,
+		AllShares:  all,
+		ShareLimit: inboxLimit,
+	})
+
+	// inbox check
+	if err != nil {
+		return fmt.Errorf("error getting inbox: %w", err)
+	}
+
+	// show them
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"from", "title", "url", "feed", "message", "shared_at", "seen_at"}}
+		for _, share := range shares {
+			table.Add(share.FromUser, share.PostTitle, share.PostUrl, share.FeedName, nullString(share.Message), share.CreatedAt, nullTime(share.SeenAt))
+		}
+		err = output.Write(s.Stdout, s.Output, table)
+	} else if len(shares) == 0 && all {
+		fmt.Fprintln(s.Stdout, "Nobody shared a post with you yet.")
+	} else if len(shares) == 0 {
+		fmt.Fprintln(s.Stdout, "No new shared posts (inbox --all shows the earlier ones).")
+	} else {
+		for _, share := range shares {
+			marker := ""
+			if !share.SeenAt.Valid {
+				marker = " [new]"
+			}
+			fmt.Fprintf(s.Stdout, "From %s, %s%s\n", share.FromUser, share.CreatedAt.Local().Format(time.DateTime), marker)
+			fmt.Fprintf(s.Stdout, "  %s (%s)\n  %s\n", share.PostTitle, share.FeedName, share.PostUrl)
+			if share.Message.Valid {
+				fmt.Fprintf(s.Stdout, "  \"%s\"\n", share.Message.String)
+			}
+			fmt.Fprintln(s.Stdout)
+		}
+	}
+
+	// output check
+	if err != nil {
+		return err
+	}
+
+	// mark the new ones seen
+	ids := []uuid.UUID{}
+	for _, share := range shares {
+		if !share.SeenAt.Valid {
+			ids = append(ids, share.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err = s.DB.MarkInboxSeen(ctx, database.MarkInboxSeenParams{
+		SeenAt: time.Now().UTC(),
+		UserID: user.ID,
+		Ids:    ids,
+	})
+
+	// mark check
+	if err != nil {
+		return fmt.Errorf("error marking inbox seen: %w", err)
+	}
+	return nil
+}
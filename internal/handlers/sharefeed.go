@@ -0,0 +1,97 @@
+// sharefeed.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"net/url"      // escaping the name
+	"strings"      // serve addresses without a host
+	"time"         // shared since
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+)
+
+// sharefeed handler logic
+// NOTE: cmd will be sharefeed [on|off], publishes the current user's timeline as rss on serve's /users/{name}/feed.xml
+func HandlerShareFeed(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// the feed's url on serve
+	feedURL := timelineFeedURL(s, user.Name)
+
+	// no args check, show whether it's shared
+	if len(cmd.Args) == 0 {
+		since, err := s.DB.GetTimelineShare(ctx, user.ID)
+
+		// not shared check
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintln(s.Stdout, "Your timeline isn't shared, share it with: sharefeed on")
+			return nil
+		}
+
+		// get share check
+		if err != nil {
+			return fmt.Errorf("error getting timeline share: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Your timeline is shared since %s at %s\n", since.Local().Format(time.DateTime), feedURL)
+		return nil
+	}
+
+	// switch it on or off
+	switch cmd.Args[0] {
+	case "on":
+		err := s.DB.ShareTimeline(ctx, database.ShareTimelineParams{UserID: user.ID, CreatedAt: time.Now().UTC()})
+
+		// share check
+		if err != nil {
+			return fmt.Errorf("error sharing timeline: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Your timeline is now shared as rss at %s (while serve runs)\n", feedURL)
+		fmt.Fprintln(s.Stdout, "Anyone who can reach serve can read it, stop sharing with: sharefeed off")
+		return nil
+	case "off":
+		rows, err := s.DB.UnshareTimeline(ctx, user.ID)
+
+		// unshare check
+		if err != nil {
+			return fmt.Errorf("error unsharing timeline: %w", err)
+		}
+
+		// wasn't shared check
+		if rows == 0 {
+			fmt.Fprintln(s.Stdout, "Your timeline wasn't shared.")
+			return nil
+		}
+		fmt.Fprintln(s.Stdout, "Your timeline is no longer shared.")
+		return nil
+	}
+
+	// unknown arg
+	return fmt.Errorf("error: usage: sharefeed [on|off]")
+}
+
+// the url of a user's shared timeline on serve helper
+func timelineFeedURL(s *app.State, name string) string {
+	return serveBaseURL(s) + "/users/" + url.PathEscape(name) + "/feed.xml"
+}
+
+// serve's base url helper, for links outside it (feeds, emails)
+// public_url if set, else serve_addr (localhost when it has no host)
+func serveBaseURL(s *app.State) string {
+	if base := s.Config.PublicBaseURL(); base != "" {
+		return base
+	}
+	addr := s.Config.ServeAddress()
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return "http://" + addr
+}
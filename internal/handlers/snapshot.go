@@ -0,0 +1,118 @@
+// snapshot.go
+package handlers
+
+import (
+	// std go libs
+	"bytes"         // gzip buffers
+	"compress/gzip" // compressing snapshots
+	"context"       // for context
+	"database/sql"  // for sql errors
+	"errors"        // for error handling
+	"fmt"           // print errors
+	"io"            // reading snapshots
+	"log/slog"      // structured logging
+	"time"          // fetched at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/rssfeed"  // for raw feeds
+)
+
+// package-wide constants
+const maxSnapshotBytes = 1 << 20 // 1 MiB uncompressed, bigger bodies are truncated
+
+// store a feed's raw body helper (snapshot_feeds debug mode)
+// NOTE: failures are only logged, a snapshot must never break the fetch
+func storeSnapshot(ctx context.Context, s *app.State, feed database.Feed, raw *rssfeed.Raw) {
+	// size cap check
+	body := raw.Body
+	truncated := len(body) > maxSnapshotBytes
+	if truncated {
+		body = body[:maxSnapshotBytes]
+	}
+
+	// gzip the body (xml compresses really well)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(body)
+	if err == nil {
+		err = zw.Close()
+	}
+
+	// compress check
+	if err != nil {
+		slog.Warn("error compressing feed snapshot", "feed", feed.Name, "err", err)
+		return
+	}
+
+	// store it, replacing the previous snapshot
+	err = s.DB.UpsertFeedSnapshot(ctx, database.UpsertFeedSnapshotParams{
+		FeedID:      feed.ID,
+		FetchedAt:   time.Now().UTC(),
+		ContentType: raw.ContentType,
+		Body:        buf.Bytes(),
+		Size:        int32(len(body)),
+		Truncated:   truncated,
+	})
+
+	// store check
+	if err != nil {
+		slog.Warn("error storing feed snapshot", "feed", feed.Name, "err", err)
+		return
+	}
+	slog.Debug("stored feed snapshot", "feed", feed.Name, "bytes", len(body), "compressed", buf.Len(), "truncated", truncated)
+}
+
+// snapshot handler logic
+// NOTE: cmd will be snapshot <feed_url>, prints the last raw body agg stored for the feed
+// the body goes to stdout (redirect it to a file), the details to stderr
+func HandlerSnapshot(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get arguments input (Run checked there's ONE: the feed URL)
+	feedURL := cmd.Args[0] // not needed, but nicely readable!
+
+	// get the snapshot
+	snapshot, err := s.DB.GetFeedSnapshotByURL(ctx, feedURL)
+
+	// snapshot exists check
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: no snapshot for %s (set \"snapshot_feeds\": true in ~/.gatorconfig.json and run agg)", feedURL)
+	}
+
+	// get check
+	if err != nil {
+		return fmt.Errorf("error getting snapshot: %w", err)
+	}
+
+	// decompress the body
+	zr, err := gzip.NewReader(bytes.NewReader(snapshot.Body))
+
+	// gzip check
+	if err != nil {
+		return fmt.Errorf("error reading snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	// print the details to stderr, so stdout is just the body
+	fmt.Fprintf(s.Stderr, "Snapshot of %s fetched at %s (%s, %d bytes", feedURL, snapshot.FetchedAt.Format(time.DateTime), snapshot.ContentType, snapshot.Size)
+	if snapshot.Truncated {
+		fmt.Fprintf(s.Stderr, ", truncated")
+	}
+	fmt.Fprintln(s.Stderr, ")")
+
+	// write the body
+	_, err = io.Copy(s.Stdout, zr)
+
+	// write check
+	if err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	// return success
+	return nil
+}
@@ -0,0 +1,132 @@
+// snooze.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // null snooze times
+	"fmt"          // print errors
+	"strconv"      // day and week counts
+	"time"         // snooze times
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+)
+
+// snooze handler logic
+// NOTE: cmd will be snooze [<feed url> <duration|date>|off], hides a followed feed's posts until then
+func HandlerSnooze(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// list check
+	if len(cmd.Args) == 0 {
+		return listSnoozedFeeds(ctx, s, user)
+	}
+
+	// usage check
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("error: usage: snooze [<feed url> <duration|date>|off] (e.g. 3d, 2w or 2026-08-31)")
+	}
+	feedURL := cmd.Args[0]
+
+	// resume or snooze check
+	var until sql.NullTime
+	if cmd.Args[1] != "off" {
+		snoozedUntil, err := parseSnooze(cmd.Args[1], time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		until = sql.NullTime{Time: snoozedUntil, Valid: true}
+	}
+
+	// store it
+	rows, err := s.DB.SetFeedFollowSnooze(ctx, database.SetFeedFollowSnoozeParams{
+		SnoozedUntil: until,
+		Url:          feedURL,
+		UserID:       user.ID,
+	})
+
+	// snooze check
+	if err != nil {
+		return fmt.Errorf("error snoozing feed: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("error: you don't follow %s", feedURL)
+	}
+
+	// resumed check
+	if !until.Valid {
+		fmt.Fprintf(s.Stdout, "%s is back in browse and digests.\n", feedURL)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "%s is snoozed until %s.\n", feedURL, until.Time.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+// snooze list helper, the user's follows that are snoozed right now
+func listSnoozedFeeds(ctx context.Context, s *app.State, user database.User) error {
+	feeds, err := s.DB.ListSnoozedFeeds(ctx, database.ListSnoozedFeedsParams{
+		UserID: user.ID,
+		Now:    time.Now().UTC(),
+	})
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing snoozed feeds: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "url", "snoozed_until"}}
+		for _, feed := range feeds {
+			table.Add(feed.Name, feed.Url, nullTime(feed.SnoozedUntil))
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// nothing snoozed check
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "No feeds are snoozed.")
+		return nil
+	}
+	fmt.Fprintln(s.Stdout, "Snoozed feeds:")
+	for _, feed := range feeds {
+		fmt.Fprintf(s.Stdout, "* %s (%s) until %s\n", feed.Name, feed.Url, feed.SnoozedUntil.Time.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// parse a snooze length helper, like 3d, 2w or 12h (any Go duration), or the date to resume on
+func parseSnooze(value string, now time.Time) (time.Time, error) {
+	// date check, resumes at the start of that (local) day
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if !date.After(now) {
+			return time.Time{}, fmt.Errorf("error: %s has already started", value)
+		}
+		return date.UTC(), nil
+	}
+
+	// days and weeks check, Go durations stop at hours
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	length, err := time.ParseDuration(value)
+	if value != "" && units[value[len(value)-1]] != 0 {
+		n, convErr := strconv.Atoi(value[:len(value)-1])
+		length, err = time.Duration(n)*units[value[len(value)-1]], convErr
+	}
+
+	// parse check
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error: invalid snooze %q (use e.g. 3d, 2w, 12h or a date like 2026-08-31)", value)
+	}
+
+	// length check
+	if length <= 0 {
+		return time.Time{}, fmt.Errorf("error: snooze %q isn't in the future", value)
+	}
+	return now.Add(length), nil
+}
@@ -0,0 +1,100 @@
+// states.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"strings" // trimming guids
+	"time"    // export time
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/urlnorm"  // cleaning item and feed urls
+	"github.com/PietPadda/aggregator/internal/userdata" // the state export's json schema
+)
+
+// export --states helper, writes the user's read and starred items to a json file
+func exportStates(ctx context.Context, s *app.State, user database.User, path string) error {
+	// the items
+	rows, err := s.DB.ListItemStates(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting read and starred posts: %w", err)
+	}
+	states := userdata.States{ExportedAt: time.Now().UTC()}
+	read, starred := 0, 0
+	for _, row := range rows {
+		states.Items = append(states.Items, userdata.Item{
+			URL:       row.Url,
+			GUID:      row.Guid.String,
+			FeedURL:   row.FeedUrl,
+			Title:     row.Title,
+			ReadAt:    timePtr(row.ReadAt),
+			StarredAt: timePtr(row.SavedAt),
+		})
+		if row.ReadAt.Valid {
+			read++
+		}
+		if row.SavedAt.Valid {
+			starred++
+		}
+	}
+
+	// write them
+	err = userdata.WriteStates(path, states)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Exported %d read and %d starred items of %s to %s\n", read, starred, user.Name, path)
+	return nil
+}
+
+// import states helper, marks the stored posts of a state export's items read or starred
+// NOTE: only ever adds, states already set are kept, so importing the same file twice changes nothing
+func importStates(ctx context.Context, s *app.State, user database.User, path string) error {
+	// read it
+	states, err := userdata.ReadStates(path)
+	if err != nil {
+		return err
+	}
+
+	// the items as columns, matched to posts by url, else by guid within their feed
+	items := database.RestoreItemStatesParams{UserID: user.ID}
+	skipped := 0
+	for _, item := range states.Items {
+		// nothing to match or mark check
+		guid := strings.TrimSpace(item.GUID)
+		if (item.URL == "" && guid == "") || (item.ReadAt == nil && item.StarredAt == nil) {
+			skipped++
+			continue
+		}
+
+		// clean the urls like agg stores them (feeds as they were added, like the export has them)
+		feedURL, err := urlnorm.Clean(item.FeedURL)
+		if err != nil {
+			feedURL = item.FeedURL // only used to match guids, a url that's off matches nothing
+		}
+		items.Urls = append(items.Urls, urlnorm.StripTracking(item.URL))
+		items.Guids = append(items.Guids, guid)
+		items.FeedUrls = append(items.FeedUrls, feedURL)
+		items.ReadAts = append(items.ReadAts, timeOrZero(item.ReadAt))
+		items.SavedAts = append(items.SavedAts, timeOrZero(item.StarredAt))
+	}
+
+	// mark them
+	var matched int64
+	if len(items.Urls) > 0 {
+		matched, err = s.DB.RestoreItemStates(ctx, items)
+		if err != nil {
+			return fmt.Errorf("error storing read and starred states: %w", err)
+		}
+	}
+
+	// print the totals
+	fmt.Fprintf(s.Stdout, "Marked the posts of %d of %d items read or starred, keeping states already set (%d items had nothing to match or mark).\n", matched, len(states.Items), skipped)
+	if missing := int64(len(items.Urls)) - matched; missing > 0 {
+		fmt.Fprintf(s.Stdout, "%d items have no post here yet, follow their feeds and run agg, then import the file again.\n", missing)
+	}
+	return nil
+}
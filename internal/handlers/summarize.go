@@ -0,0 +1,203 @@
+// summarize.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"fmt"      // print errors
+	"log/slog" // logging summaries
+	"strconv"  // run limit
+	"time"     // the daily limit's day
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/database"  // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/summarize" // summarizer providers
+	"github.com/google/uuid"                             // post ids
+)
+
+// package-wide constants
+const (
+	summarizeBatch  = 20               // most posts agg summarizes per tick, so a backlog doesn't stall fetching
+	summarizeWindow = 48 * time.Hour   // only posts found this recently get summaries, older ones have been read
+	summarizeStale  = 10 * time.Minute // a claim this old is from an agg that died while summarizing
+)
+
+// summarize handler logic
+// NOTE: cmd will be summarize status | run [limit]
+// NOTE: agg summarizes new posts by itself when summarize.provider is set, run does it now
+func HandlerSummarize(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: summarize status | summarize run [limit]")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// subcommand check
+	switch cmd.Args[0] {
+	case "status":
+		opts, limit, err := s.Config.SummarizeOptions()
+		if err != nil {
+			return err
+		}
+		used, err := s.DB.CountPostSummariesSince(ctx, summarizeDay())
+
+		// count check
+		if err != nil {
+			return fmt.Errorf("error counting summaries: %w", err)
+		}
+		fmt.Fprintf(s.Stdout, "Summarizer: %s\n", opts.Provider)
+		fmt.Fprintf(s.Stdout, "Summarized today: %d of %d (the limit resets at midnight UTC)\n", used, limit)
+		return nil
+	case "run":
+		if len(cmd.Args) > 2 {
+			return usage
+		}
+		most := summarizeBatch
+		if len(cmd.Args) == 2 {
+			n, err := strconv.Atoi(cmd.Args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("error: invalid limit %q (use a number of posts)", cmd.Args[1])
+			}
+			most = n
+		}
+		done, err := summarizePosts(ctx, s, most)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "Posts summarized: %d\n", done)
+		return nil
+	}
+	return usage
+}
+
+// the start of the daily limit's day helper, midnight UTC
+func summarizeDay() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// summarize new posts helper, at most most of them and never past the daily limit
+// each post is claimed first, so two aggs never pay for the same summary
+// stops at the first failure (the provider is likely down or out of credit), returns how many were summarized
+func summarizePosts(ctx context.Context, s *app.State, most int) (int, error) {
+	// the summarizer
+	opts, limit, err := s.Config.SummarizeOptions()
+	if err != nil {
+		return 0, err
+	}
+	summarizer, err := summarize.New(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	// daily limit check
+	used, err := s.DB.CountPostSummariesSince(ctx, summarizeDay())
+	if err != nil {
+		return 0, fmt.Errorf("error counting summaries: %w", err)
+	}
+	remaining := min(int64(most), int64(limit)-used)
+	if remaining <= 0 {
+		slog.Debug("summarize daily limit reached", "limit", limit)
+		return 0, nil
+	}
+
+	// get the posts
+	now := time.Now().UTC()
+	posts, err := s.DB.ListPostsToSummarize(ctx, database.ListPostsToSummarizeParams{
+		Since:       time.Now().Add(-summarizeWindow),
+		MinLength:   summarize.MinText,
+		StaleBefore: now.Add(-summarizeStale),
+		PostLimit:   int32(remaining),
+	})
+
+	// list check
+	if err != nil {
+		return 0, fmt.Errorf("error listing posts to summarize: %w", err)
+	}
+
+	// summarize them one by one
+	done := 0
+	for _, post := range posts {
+		// claim it
+		rows, err := s.DB.ClaimPostSummary(ctx, database.ClaimPostSummaryParams{
+			PostID:      post.ID,
+			CreatedAt:   time.Now().UTC(),
+			StaleBefore: now.Add(-summarizeStale),
+		})
+
+		// claim check (0 rows = another agg has it)
+		if err != nil {
+			return done, fmt.Errorf("error claiming post: %w", err)
+		}
+		if rows == 0 {
+			continue
+		}
+
+		// summarize it
+		summary, err := summarizer.Summarize(ctx, post.Title, summarize.Text(post.Description.String))
+
+		// summarize check, give the post back so it's tried again
+		if err != nil {
+			unclaimErr := s.DB.UnclaimPostSummary(context.WithoutCancel(ctx), post.ID)
+			if unclaimErr != nil {
+				slog.Warn("unclaiming post summary failed", "post", post.ID, "error", unclaimErr)
+			}
+			return done, fmt.Errorf("error summarizing %q: %w", post.Title, err)
+		}
+
+		// save it
+		err = s.DB.SetPostSummary(ctx, database.SetPostSummaryParams{PostID: post.ID, Summary: summary})
+
+		// save check
+		if err != nil {
+			return done, fmt.Errorf("error saving summary: %w", err)
+		}
+		done++
+		slog.Debug("summarized post", "title", post.Title)
+	}
+	return done, nil
+}
+
+// summarize new posts from agg helper, if summarize.provider is set (errors are logged, agg keeps going)
+func aggSummaries(ctx context.Context, s *app.State) {
+	// summaries off check
+	if s.Config.Summarize == nil || s.Config.Summarize.Provider == nil {
+		return
+	}
+
+	// summarize them
+	done, err := summarizePosts(ctx, s, summarizeBatch)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("error summarizing posts", "err", err)
+	}
+	if done > 0 {
+		slog.Info("summarized posts", "count", done)
+	}
+}
+
+// get the posts' summaries helper, for browse (posts without one are left out)
+func getSummaries(ctx context.Context, s *app.State, posts []database.Post) (map[uuid.UUID]string, error) {
+	// collect the post ids
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	// get their summaries in one query
+	summaries, err := s.DB.GetPostSummaries(ctx, postIDs)
+
+	// get check
+	if err != nil {
+		return nil, fmt.Errorf("error getting summaries: %w", err)
+	}
+	byPost := make(map[uuid.UUID]string, len(summaries))
+	for _, summary := range summaries {
+		byPost[summary.PostID] = summary.Summary
+	}
+	return byPost, nil
+}
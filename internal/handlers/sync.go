@@ -0,0 +1,420 @@
+// sync.go
+package handlers
+
+import (
+	// std go libs
+	"context"       // for context
+	"database/sql"  // no rows and sync times
+	"encoding/json" // account settings
+	"errors"        // matching sql.ErrNoRows
+	"fmt"           // print errors
+	"strings"       // instance urls
+	"time"          // login and sync times
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/api"      // syncing with another gator
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+	"github.com/PietPadda/aggregator/internal/readers"  // feedbin and feedly
+	"github.com/PietPadda/aggregator/internal/urlnorm"  // matching entry urls to posts
+)
+
+// another gator instance, synced through its api (see api/sync.go)
+const gatorService = "gator"
+
+// another gator instance's login and how far each side's changes have been exchanged (stored as json)
+type gatorInstance struct {
+	URL      string    `json:"url"`
+	Token    string    `json:"token"`    // an api token of the user's there
+	Sent     time.Time `json:"sent"`     // the newest local change sent
+	Received time.Time `json:"received"` // the newest change received, the instance's own time
+}
+
+// sync handler logic
+// NOTE: cmd will be sync [service] | login <service> ... | logout <service> | push-read <service> on|off | accounts
+// NOTE: syncing pulls the service's feeds and starred entries, then pushes read state back if push-read is on
+// NOTE: another gator instance exchanges follows and read, starred and muted state both ways, the newer change wins
+func HandlerSync(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// subcommand check
+	args := cmd.Args
+	if len(args) == 0 {
+		return syncAll(ctx, s, user, "")
+	}
+	switch args[0] {
+	case "login":
+		return syncLogin(ctx, s, user, args[1:])
+	case "logout":
+		if len(args) != 2 {
+			return fmt.Errorf("error: usage: sync logout <service>")
+		}
+		rows, err := s.DB.DeleteSyncAccount(ctx, database.DeleteSyncAccountParams{UserID: user.ID, Service: args[1]})
+
+		// delete check
+		if err != nil {
+			return fmt.Errorf("error removing sync account: %w", err)
+		}
+		if rows == 0 {
+			fmt.Fprintf(s.Stdout, "You weren't syncing with %s.\n", args[1])
+			return nil
+		}
+		fmt.Fprintf(s.Stdout, "Stopped syncing with %s, what was synced stays in Gator.\n", args[1])
+		return nil
+	case "push-read":
+		if len(args) != 3 || (args[2] != "on" && args[2] != "off") {
+			return fmt.Errorf("error: usage: sync push-read <service> on|off")
+		}
+		if args[1] == gatorService {
+			return fmt.Errorf("error: syncing with gator always exchanges read state both ways")
+		}
+		rows, err := s.DB.SetSyncPushRead(ctx, database.SetSyncPushReadParams{UserID: user.ID, Service: args[1], PushRead: args[2] == "on"})
+
+		// update check
+		if err != nil {
+			return fmt.Errorf("error setting push-read: %w", err)
+		}
+		if rows == 0 {
+			_, err = getSyncAccount(ctx, s, user, args[1])
+			return err
+		}
+		if args[2] == "on" {
+			fmt.Fprintf(s.Stdout, "Posts you read in Gator will be marked read on %s when syncing.\n", args[1])
+		} else {
+			fmt.Fprintf(s.Stdout, "Syncing with %s only pulls from it now.\n", args[1])
+		}
+		return nil
+	case "accounts":
+		return syncAccounts(ctx, s, user)
+	case readers.Feedbin, readers.Feedly, gatorService:
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: sync %s", args[0])
+		}
+		return syncAll(ctx, s, user, args[0])
+	}
+	return fmt.Errorf("error: usage: sync [feedbin|feedly|gator] | sync login <service> ... | sync logout <service> | sync push-read <service> on|off | sync accounts")
+}
+
+// sync login helper, checks the login before it's saved
+func syncLogin(ctx context.Context, s *app.State, user database.User, args []string) error {
+	// build the account
+	usage := fmt.Errorf("error: usage: sync login feedbin <email> <password> | sync login feedly <access token> | sync login gator <url> <api token>")
+	if len(args) == 0 {
+		return usage
+	}
+	var account readers.Account
+	service, args := args[0], args[1:]
+	switch service {
+	case readers.Feedbin:
+		if len(args) != 2 {
+			return fmt.Errorf("error: usage: sync login feedbin <email> <password>")
+		}
+		account = readers.Account{Username: args[0], Secret: args[1]}
+	case readers.Feedly:
+		if len(args) != 1 {
+			return fmt.Errorf("error: usage: sync login feedly <access token> (from https://feedly.com/i/team/api)")
+		}
+		account = readers.Account{Secret: args[0]}
+	case gatorService:
+		if len(args) != 2 {
+			return fmt.Errorf("error: usage: sync login gator <url> <api token> (from token create on the other instance)")
+		}
+		return syncLoginGator(ctx, s, user, args[0], args[1])
+	default:
+		return usage
+	}
+
+	// login check
+	err := readers.Check(ctx, service, account)
+	if err != nil {
+		return err
+	}
+
+	// save it
+	settings, err := json.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("error encoding sync account: %w", err)
+	}
+	err = s.DB.SetSyncAccount(ctx, database.SetSyncAccountParams{
+		UserID:    user.ID,
+		Service:   service,
+		CreatedAt: time.Now().UTC(),
+		Settings:  settings,
+	})
+
+	// save check
+	if err != nil {
+		return fmt.Errorf("error saving sync account: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Logged in to %s, bring over your feeds and starred entries with: sync %s\n", service, service)
+	return nil
+}
+
+// sync accounts helper, the services the user syncs with
+func syncAccounts(ctx context.Context, s *app.State, user database.User) error {
+	accounts, err := s.DB.ListSyncAccounts(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error getting sync accounts: %w", err)
+	}
+
+	// machine-readable check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"service", "push_read", "logged_in_at", "synced_at"}}
+		for _, account := range accounts {
+			table.Add(account.Service, account.PushRead, account.CreatedAt, nullTime(account.SyncedAt))
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// none check
+	if len(accounts) == 0 {
+		fmt.Fprintln(s.Stdout, "Not syncing with any service, log in with: sync login feedbin <email> <password> | sync login feedly <access token> | sync login gator <url> <api token>")
+		return nil
+	}
+	for _, account := range accounts {
+		synced := "never synced"
+		if account.SyncedAt.Valid {
+			synced = "last synced " + account.SyncedAt.Time.Local().Format(time.DateTime)
+		}
+		push := "pull only"
+		if account.PushRead {
+			push = "pushes read state"
+		}
+		if account.Service == gatorService {
+			push = "both ways"
+		}
+		fmt.Fprintf(s.Stdout, "%s (%s, %s)\n", account.Service, synced, push)
+	}
+	return nil
+}
+
+// sync every service the user is logged in to helper, or only one
+func syncAll(ctx context.Context, s *app.State, user database.User, only string) error {
+	// one service check
+	if only != "" {
+		account, err := getSyncAccount(ctx, s, user, only)
+		if err != nil {
+			return err
+		}
+		return syncAccount(ctx, s, user, account)
+	}
+
+	// all of them
+	accounts, err := s.DB.ListSyncAccounts(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting sync accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		fmt.Fprintln(s.Stdout, "Not syncing with any service, log in with: sync login feedbin <email> <password> | sync login feedly <access token> | sync login gator <url> <api token>")
+		return nil
+	}
+	for _, account := range accounts {
+		err = syncAccount(ctx, s, user, account)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sync one service helper, pull then (with push-read) push
+func syncAccount(ctx context.Context, s *app.State, user database.User, row database.SyncAccount) error {
+	// another gator check
+	if row.Service == gatorService {
+		return syncGator(ctx, s, user, row)
+	}
+
+	// the login
+	var account readers.Account
+	err := json.Unmarshal(row.Settings, &account)
+	if err != nil {
+		return fmt.Errorf("error decoding %s account: %w", row.Service, err)
+	}
+
+	// pull the feeds and starred entries
+	fmt.Fprintf(s.Stdout, "Syncing with %s...\n", row.Service)
+	export, err := readers.Fetch(ctx, row.Service, account)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Found %d feeds and %d starred entries.\n", len(export.Feeds), len(export.Entries))
+	err = applyReaderExport(ctx, s, user, export)
+	if err != nil {
+		return err
+	}
+
+	// push read state check
+	if row.PushRead {
+		err = pushReadState(ctx, s, user, row.Service, account)
+		if err != nil {
+			return err
+		}
+	}
+
+	// note the sync
+	err = s.DB.MarkSyncAccountSynced(ctx, database.MarkSyncAccountSyncedParams{
+		UserID:   user.ID,
+		Service:  row.Service,
+		SyncedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error marking %s synced: %w", row.Service, err)
+	}
+	fmt.Fprintln(s.Stdout)
+	return nil
+}
+
+// sync login gator helper, checks the api token works on the other instance before it's saved
+func syncLoginGator(ctx context.Context, s *app.State, user database.User, instanceURL, token string) error {
+	// login check
+	name, err := api.CheckInstance(ctx, instanceURL, token)
+	if err != nil {
+		return err
+	}
+
+	// save it (logging in again starts over, everything is exchanged)
+	settings, err := json.Marshal(gatorInstance{URL: strings.TrimRight(instanceURL, "/"), Token: token})
+	if err != nil {
+		return fmt.Errorf("error encoding sync account: %w", err)
+	}
+	err = s.DB.SetSyncAccount(ctx, database.SetSyncAccountParams{
+		UserID:    user.ID,
+		Service:   gatorService,
+		CreatedAt: time.Now().UTC(),
+		Settings:  settings,
+	})
+	if err != nil {
+		return fmt.Errorf("error saving sync account: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Logged in to gator at %s as %s, exchange your follows and read state with: sync gator\n", instanceURL, name)
+	return nil
+}
+
+// sync with another gator helper, sends the changes since the last sync and takes the instance's
+func syncGator(ctx context.Context, s *app.State, user database.User, row database.SyncAccount) error {
+	// the login and cursors
+	var instance gatorInstance
+	err := json.Unmarshal(row.Settings, &instance)
+	if err != nil {
+		return fmt.Errorf("error decoding gator account: %w", err)
+	}
+
+	// the local changes
+	fmt.Fprintf(s.Stdout, "Syncing with gator at %s...\n", instance.URL)
+	follows, states, sent, err := api.SyncChanges(ctx, s.DB, user.ID, instance.Sent)
+	if err != nil {
+		return err
+	}
+
+	// exchange them
+	reply, err := api.SyncWith(ctx, instance.URL, instance.Token, api.SyncRequest{Since: instance.Received, Follows: follows, States: states})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Sent %d follows and %d post states (%d were newer there), received %d follows and %d post states.\n",
+		len(follows), len(states), reply.Applied, len(reply.Follows), len(reply.States))
+
+	// the instance's follows, then its states (their posts need the feeds)
+	followed := 0
+	for _, follow := range reply.Follows {
+		_, isNew, err := importFollow(ctx, s, user, readers.Feed{Title: follow.Name, URL: follow.URL})
+		if err != nil {
+			fmt.Fprintf(s.Stdout, "  Not followed: %s (%s)\n", follow.URL, err)
+			continue
+		}
+		if isNew {
+			followed++
+		}
+	}
+	applied, err := api.ApplySyncStates(ctx, s.DB, user.ID, reply.States)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Followed %d new feeds, and took %d newer post states.\n", followed, applied)
+
+	// save where both sides are up to
+	instance.Sent, instance.Received = sent, reply.Until
+	settings, err := json.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("error encoding sync account: %w", err)
+	}
+	err = s.DB.SetSyncAccount(ctx, database.SetSyncAccountParams{
+		UserID:    user.ID,
+		Service:   gatorService,
+		CreatedAt: row.CreatedAt,
+		Settings:  settings,
+	})
+	if err != nil {
+		return fmt.Errorf("error saving sync progress: %w", err)
+	}
+	err = s.DB.MarkSyncAccountSynced(ctx, database.MarkSyncAccountSyncedParams{
+		UserID:   user.ID,
+		Service:  gatorService,
+		SyncedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error marking gator synced: %w", err)
+	}
+	fmt.Fprintln(s.Stdout)
+	return nil
+}
+
+// push read state helper, marks the service's unread entries read if their post is read in gator
+func pushReadState(ctx context.Context, s *app.State, user database.User, service string, account readers.Account) error {
+	// the service's unread entries, by url
+	unread, err := readers.Unread(ctx, service, account)
+	if err != nil {
+		return err
+	}
+	ids := map[string][]string{}
+	var urls []string
+	for _, entry := range unread {
+		postURL := urlnorm.StripTracking(entry.URL)
+		if _, ok := ids[postURL]; !ok {
+			urls = append(urls, postURL)
+		}
+		ids[postURL] = append(ids[postURL], entry.ID)
+	}
+	if len(urls) == 0 {
+		fmt.Fprintf(s.Stdout, "Nothing unread on %s.\n", service)
+		return nil
+	}
+
+	// the ones read in gator
+	read, err := s.DB.ListReadPostURLs(ctx, database.ListReadPostURLsParams{UserID: user.ID, Urls: urls})
+	if err != nil {
+		return fmt.Errorf("error getting read posts: %w", err)
+	}
+	var toMark []string
+	for _, url := range read {
+		toMark = append(toMark, ids[url]...)
+	}
+
+	// mark them
+	if len(toMark) > 0 {
+		err = readers.MarkRead(ctx, service, account, toMark)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(s.Stdout, "Marked %d entries read on %s.\n", len(toMark), service)
+	return nil
+}
+
+// get a user's sync account helper, erroring if they aren't logged in to the service
+func getSyncAccount(ctx context.Context, s *app.State, user database.User, service string) (database.SyncAccount, error) {
+	account, err := s.DB.GetSyncAccount(ctx, database.GetSyncAccountParams{UserID: user.ID, Service: service})
+	if errors.Is(err, sql.ErrNoRows) {
+		return account, fmt.Errorf("error: you aren't syncing with %s, log in with: sync login %s ...", service, service)
+	}
+	if err != nil {
+		return account, fmt.Errorf("error getting sync account: %w", err)
+	}
+	return account, nil
+}
@@ -0,0 +1,100 @@
+// systemd.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"log/slog" // structured logging
+	"sync"     // guarding the progress
+	"time"     // watchdog pings
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/systemd" // sd_notify
+)
+
+// agg's systemd watchdog, pings it only while the agg loop makes progress
+// NOTE: pinging from a timer alone would keep a hung scrape alive forever, so a scrape must finish feeds to count
+type aggWatchdog struct {
+	interval time.Duration // WatchdogSec= of the unit, 0 = off
+	mu       sync.Mutex    // guards busy and progress
+	busy     bool          // scraping (waiting for the next due feed can't hang)
+	progress time.Time     // when the scrape last finished a feed
+	stalled  bool          // already warned about this stall
+}
+
+// start pinging systemd's watchdog, if the unit has one (until ctx is done)
+func startAggWatchdog(ctx context.Context) *aggWatchdog {
+	// watchdog check
+	w := &aggWatchdog{interval: systemd.WatchdogInterval(), progress: time.Now()}
+	if w.interval == 0 {
+		return w
+	}
+	slog.Debug("pinging the systemd watchdog", "every", w.interval/2)
+
+	// ping twice per interval, like systemd recommends
+	go func() {
+		ticker := time.NewTicker(w.interval / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// progress check, no ping lets systemd restart a hung agg
+				if !w.healthy() {
+					continue
+				}
+				sdNotify(systemd.Watchdog)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return w
+}
+
+// a scrape is starting method
+func (w *aggWatchdog) Busy() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.busy, w.progress, w.stalled = true, time.Now(), false
+}
+
+// the scrape finished a feed method
+func (w *aggWatchdog) Progress() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.progress, w.stalled = time.Now(), false
+}
+
+// the scrape is done, agg waits for the next due feed method
+func (w *aggWatchdog) Idle() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.busy, w.stalled = false, false
+}
+
+// did agg make progress within the watchdog interval method
+func (w *aggWatchdog) healthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// idle or recent progress check
+	stuck := time.Since(w.progress)
+	if !w.busy || stuck < w.interval {
+		return true
+	}
+
+	// warn once per stall
+	if !w.stalled {
+		slog.Warn("agg finished no feed for too long, stopping watchdog pings so systemd restarts it", "stuck", stuck.Round(time.Second))
+		w.stalled = true
+	}
+	return false
+}
+
+// notify systemd helper, errors are only logged (agg works without systemd)
+func sdNotify(states ...string) {
+	_, err := systemd.Notify(states...)
+	if err != nil {
+		slog.Debug("error notifying systemd", "err", err)
+	}
+}
@@ -0,0 +1,424 @@
+// telegram.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"crypto/rand"  // link codes
+	"database/sql" // no rows and null ids
+	"encoding/hex" // link codes
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"log/slog"     // logging the bot
+	"strconv"      // /browse and /search limits
+	"strings"      // parsing commands
+	"time"         // push interval
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // plain text for chats
+	"github.com/PietPadda/aggregator/internal/telegram" // the bot api
+	"github.com/google/uuid"                            // the code's user
+)
+
+// package-wide constants
+const (
+	telegramPushEvery = time.Minute      // how often the bot pushes new posts to linked chats
+	telegramPushLimit = 20               // most posts per push, the newest of each feed
+	telegramRetryWait = 5 * time.Second  // wait after a failed getUpdates
+	telegramCodeTTL   = 10 * time.Minute // how long a link code works, so one left in a terminal's scrollback goes stale
+	telegramHelp      = `Commands:
+/follow <url> - follow a feed
+/unfollow <url> - unfollow a feed
+/following - the feeds you follow
+/browse [n] - your latest n posts (default 2)
+/search <term> - search your posts
+/stop - unlink this chat
+
+New posts of the feeds you follow are sent here as they're found.`
+)
+
+// telegram handler logic
+// NOTE: cmd will be telegram link | unlink | status | bot
+// NOTE: link, unlink and status are the current user's, bot runs the bot for every linked user until ctrl+c
+func HandlerTelegram(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("error: usage: telegram link | telegram unlink | telegram status | telegram bot")
+	}
+
+	// subcommand check
+	switch cmd.Args[0] {
+	case "link":
+		return MiddlewareLoggedIn(linkTelegram)(ctx, s, cmd)
+	case "unlink":
+		return MiddlewareLoggedIn(unlinkTelegram)(ctx, s, cmd)
+	case "status":
+		return MiddlewareLoggedIn(telegramStatus)(ctx, s, cmd)
+	case "bot":
+		return runTelegramBot(ctx, s)
+	}
+	return fmt.Errorf("error: usage: telegram link | telegram unlink | telegram status | telegram bot")
+}
+
+// telegram link helper, a one-time code that links the chat it's sent from to the user
+func linkTelegram(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// create the code
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+
+	// random check
+	if err != nil {
+		return fmt.Errorf("error generating link code: %w", err)
+	}
+	code := hex.EncodeToString(buf)
+
+	// store it
+	err = s.DB.CreateTelegramLinkCode(ctx, database.CreateTelegramLinkCodeParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC(),
+		LinkCode:  sql.NullString{String: code, Valid: true},
+	})
+
+	// store check
+	if err != nil {
+		return fmt.Errorf("error creating link code: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Send this to your bot on Telegram, while telegram bot runs:\n\n/start %s\n\n", code)
+	fmt.Fprintf(s.Stdout, "The code works once, for %d minutes; anyone who has it can link their chat to you, so keep it to yourself.\n", int(telegramCodeTTL.Minutes()))
+	return nil
+}
+
+// telegram unlink helper
+func unlinkTelegram(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	rows, err := s.DB.UnlinkTelegramUser(ctx, user.ID)
+
+	// unlink check
+	if err != nil {
+		return fmt.Errorf("error unlinking telegram: %w", err)
+	}
+
+	// wasn't linked check
+	if rows == 0 {
+		fmt.Fprintln(s.Stdout, "No Telegram chat was linked.")
+		return nil
+	}
+	fmt.Fprintln(s.Stdout, "Telegram unlinked, the bot won't answer or push to your chat anymore.")
+	return nil
+}
+
+// telegram status helper
+func telegramStatus(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	link, err := s.DB.GetTelegramLink(ctx, user.ID)
+
+	// not linked check
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !link.ChatID.Valid) {
+		fmt.Fprintln(s.Stdout, "No Telegram chat is linked, link one with: telegram link")
+		return nil
+	}
+
+	// get link check
+	if err != nil {
+		return fmt.Errorf("error getting telegram link: %w", err)
+	}
+	fmt.Fprintf(s.Stdout, "Telegram chat %d is linked, posts found up to %s were pushed\n", link.ChatID.Int64, link.PushedAt.Local().Format(time.DateTime))
+	return nil
+}
+
+// telegram bot helper, answers linked chats' commands and pushes their new posts until ctrl+c
+func runTelegramBot(ctx context.Context, s *app.State) error {
+	// token check
+	if s.Config.TelegramToken == nil || *s.Config.TelegramToken == "" {
+		return fmt.Errorf("error: no telegram_token configured, create a bot with @BotFather and: config set telegram_token <token>")
+	}
+	bot := telegram.New(*s.Config.TelegramToken)
+
+	// token works check
+	me, err := bot.GetMe(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("telegram bot running", "bot", "@"+me.Username, "note", "ctrl+c to stop")
+
+	// answer messages and push posts until stopped
+	var offset int64
+	var pushed time.Time
+	for ctx.Err() == nil {
+		// push the new posts, every so often
+		if time.Since(pushed) >= telegramPushEvery {
+			pushTelegramPosts(ctx, s, bot)
+			pushed = time.Now()
+		}
+
+		// wait for messages
+		updates, err := bot.GetUpdates(ctx, offset)
+
+		// updates check, wait a bit so a network outage doesn't spin
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			slog.Error("error getting telegram updates", "err", err)
+			select {
+			case <-time.After(telegramRetryWait):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		// answer each message
+		for _, update := range updates {
+			offset = update.UpdateID + 1 // confirmed with the next getUpdates
+			if update.Message == nil || update.Message.Text == "" {
+				continue
+			}
+			reply := telegramReply(ctx, s, update.Message.Chat.ID, update.Message.Text)
+			err = bot.SendMessage(ctx, update.Message.Chat.ID, reply)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("error answering telegram message", "chat", update.Message.Chat.ID, "err", err)
+			}
+		}
+	}
+	slog.Info("telegram bot stopped")
+	return nil
+}
+
+// the bot's answer to a message helper
+// NOTE: commands run the same handlers as the cli, as the chat's user, and answer with what they print
+func telegramReply(ctx context.Context, s *app.State, chatID int64, text string) string {
+	// split the command, dropping the @bot suffix group chats add
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return telegramHelp
+	}
+	command, _, _ := strings.Cut(fields[0], "@")
+	args := fields[1:]
+	chat := sql.NullInt64{Int64: chatID, Valid: true}
+
+	// commands that work before linking
+	switch command {
+	case "/start":
+		// no code check
+		if len(args) != 1 {
+			return "Hi! Link this chat to your gator user: run telegram link, and send me what it prints."
+		}
+
+		// code check first, so a wrong code leaves the chat linked as it was
+		code := sql.NullString{String: args[0], Valid: true}
+		notBefore := sql.NullTime{Time: time.Now().UTC().Add(-telegramCodeTTL), Valid: true}
+		userID, err := s.DB.GetTelegramLinkCodeUser(ctx, database.GetTelegramLinkCodeUserParams{
+			LinkCode:  code,
+			NotBefore: notBefore,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return "Unknown, used or expired code, get a new one with: telegram link"
+		}
+		if err != nil {
+			slog.Error("error getting telegram link code", "err", err)
+			return "error linking this chat"
+		}
+
+		// use the code
+		name, err := linkTelegramChat(ctx, s, userID, database.LinkTelegramChatParams{
+			ChatID:    chat,
+			PushedAt:  time.Now().UTC(),
+			LinkCode:  code,
+			NotBefore: notBefore,
+		})
+
+		// link check, the code may have been used (or replaced) since
+		if errors.Is(err, sql.ErrNoRows) {
+			return "Unknown, used or expired code, get a new one with: telegram link"
+		}
+		if err != nil {
+			slog.Error("error linking telegram chat", "err", err)
+			return "error linking this chat"
+		}
+		return fmt.Sprintf("Linked to %s!\n\n%s", name, telegramHelp)
+	case "/help":
+		return telegramHelp
+	}
+
+	// get the chat's user
+	name, err := s.DB.GetTelegramChatUser(ctx, chat)
+
+	// linked check
+	if errors.Is(err, sql.ErrNoRows) {
+		return "This chat isn't linked, run telegram link and send me what it prints."
+	}
+	if err != nil {
+		slog.Error("error getting telegram chat user", "err", err)
+		return "error getting your user"
+	}
+
+	// run the command as the chat's user (its own config copy, so the cli's login isn't touched)
+	config := *s.Config
+	config.Name = &name
+	userState := *s
+	userState.Config = &config
+	userState.Output = output.Text
+
+	// map the command onto a handler
+	var handler func(context.Context, *app.State, app.Command, database.User) error
+	switch command {
+	case "/follow":
+		if len(args) != 1 {
+			return "Usage: /follow <url>"
+		}
+		handler = HandlerFollow
+	case "/unfollow":
+		if len(args) != 1 {
+			return "Usage: /unfollow <url>"
+		}
+		handler = HandlerUnfollow
+	case "/following":
+		handler = HandlerFollowing
+	case "/browse":
+		handler = HandlerBrowse
+	case "/search":
+		if len(args) == 0 {
+			return "Usage: /search <term>"
+		}
+		handler = func(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+			return telegramSearch(ctx, s, user, strings.Join(cmd.Args, " "))
+		}
+	case "/stop":
+		_, err := s.DB.UnlinkTelegramChat(ctx, chat)
+		if err != nil {
+			return "error unlinking this chat"
+		}
+		return "Unlinked, bye! Link again with telegram link."
+	default:
+		return "Unknown command.\n\n" + telegramHelp
+	}
+
+	// run it, answering with its output (or error)
+	var out strings.Builder
+	userState.Stdout = &out
+	err = MiddlewareLoggedIn(handler)(ctx, &userState, app.Command{Name: strings.TrimPrefix(command, "/"), Args: args})
+	if err != nil {
+		out.WriteString(err.Error())
+	}
+	if strings.TrimSpace(out.String()) == "" {
+		return "Done."
+	}
+	return out.String()
+}
+
+// link a chat to a code's user helper, unlinking it from anyone else in the same transaction
+// NOTE: chat_id is unique, so the old link goes first, and is rolled back if the code doesn't link
+func linkTelegramChat(ctx context.Context, s *app.State, userID uuid.UUID, link database.LinkTelegramChatParams) (string, error) {
+	// begin the transaction
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op after commit, keeps the old link on any error return
+
+	// unlink the chat from anyone else, a chat follows one user
+	queries := s.DB.WithTx(tx)
+	_, err = queries.UnlinkTelegramChatFromOthers(ctx, database.UnlinkTelegramChatFromOthersParams{ChatID: link.ChatID, UserID: userID})
+	if err != nil {
+		return "", fmt.Errorf("error unlinking chat: %w", err)
+	}
+
+	// use the code
+	name, err := queries.LinkTelegramChat(ctx, link)
+	if err != nil {
+		return "", err
+	}
+	return name, tx.Commit()
+}
+
+// /search helper, the user's posts matching a term
+func telegramSearch(ctx context.Context, s *app.State, user database.User, term string) error {
+	posts, err := s.DB.ListPostsForUser(ctx, database.ListPostsForUserParams{
+		UserID:    user.ID,
+		Query:     sql.NullString{String: term, Valid: true},
+		PostLimit: 10,
+	})
+
+	// search check
+	if err != nil {
+		return fmt.Errorf("error searching posts: %w", err)
+	}
+
+	// none found check
+	if len(posts) == 0 {
+		fmt.Fprintf(s.Stdout, "No posts match %s.\n", strconv.Quote(term))
+		return nil
+	}
+
+	// print them
+	for _, post := range posts {
+		fmt.Fprintf(s.Stdout, "%s\n%s\n\n", post.Title, post.Url)
+	}
+	return nil
+}
+
+// push the linked chats' new posts helper (errors are logged, the bot keeps going)
+func pushTelegramPosts(ctx context.Context, s *app.State, bot *telegram.Client) {
+	// get the linked chats
+	chats, err := s.DB.ListTelegramChats(ctx)
+
+	// chats check
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("error listing telegram chats", "err", err)
+		}
+		return
+	}
+
+	// push each chat's posts
+	for _, chat := range chats {
+		// posts found up to now, later ones go in the next push
+		now := time.Now().UTC()
+		posts, err := s.DB.ListDigestPosts(ctx, database.ListDigestPostsParams{
+			UserID:    chat.UserID,
+			Since:     chat.PushedAt,
+			PostLimit: telegramPushLimit,
+		})
+
+		// posts check
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("error getting posts to push", "err", err)
+			}
+			return
+		}
+
+		// build the message
+		var message strings.Builder
+		for _, post := range posts {
+			if post.CreatedAt.After(now) {
+				continue
+			}
+			fmt.Fprintf(&message, "%s: %s\n%s\n\n", post.FeedName, post.Title, post.Url)
+		}
+
+		// send it, if there's anything new
+		if message.Len() > 0 {
+			err = bot.SendMessage(ctx, chat.ChatID.Int64, message.String())
+
+			// send check, the next push tries again
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("error pushing posts to telegram", "chat", chat.ChatID.Int64, "err", err)
+				}
+				continue
+			}
+		}
+
+		// record the push
+		err = s.DB.SetTelegramPushedAt(ctx, database.SetTelegramPushedAtParams{UserID: chat.UserID, PushedAt: now})
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error recording telegram push", "err", err)
+		}
+	}
+}
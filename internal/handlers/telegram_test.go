@@ -0,0 +1,102 @@
+// telegram_test.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // for sql errors
+	"strings"      // matching replies
+	"testing"      // go tests
+	"time"         // link code ages
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for the fake store
+	"github.com/google/uuid"                            // user ids
+)
+
+// a fake database where chat 42 is linked to the first user, and the code "stale" was made 11 minutes ago
+type fakeTelegramStore struct {
+	*fakeStore
+	unlinked *bool // a chat was unlinked
+}
+
+func (f fakeTelegramStore) GetTelegramLinkCodeUser(ctx context.Context, arg database.GetTelegramLinkCodeUserParams) (uuid.UUID, error) {
+	if arg.LinkCode.String != "stale" || !time.Now().Add(-11*time.Minute).After(arg.NotBefore.Time) {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	return f.users[0].ID, nil
+}
+
+func (f fakeTelegramStore) UnlinkTelegramChat(ctx context.Context, chatID sql.NullInt64) (int64, error) {
+	*f.unlinked = true
+	return 1, nil
+}
+
+func (f fakeTelegramStore) UnlinkTelegramChatFromOthers(ctx context.Context, arg database.UnlinkTelegramChatFromOthersParams) (int64, error) {
+	*f.unlinked = true
+	return 1, nil
+}
+
+func (f fakeTelegramStore) GetTelegramChatUser(ctx context.Context, chatID sql.NullInt64) (string, error) {
+	if chatID.Int64 != 42 {
+		return "", sql.ErrNoRows
+	}
+	return f.users[0].Name, nil
+}
+
+// the bot runs the handlers in-process, through MiddlewareLoggedIn, and answers with what they print
+func TestTelegramReply(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"following", "/following", "Feed name: Boot.dev Blog"},
+		{"unfollow", "/unfollow https://blog.boot.dev/index.xml", "Feed successfully unfollowed!"},
+		{"unfollow not followed", "/unfollow https://example.com/feed", "kahya is not following this feed!"},
+		{"browse", "/browse 5", "Post name: Learn Go"},
+		{"unfollow usage", "/unfollow", "Usage: /unfollow <url>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore()
+			s, _ := newTestState(t, store)
+			s.DB = fakeTelegramStore{fakeStore: store}
+
+			// the bot's config isn't logged in as anyone, the chat decides the user
+			s.Config.Name = nil
+
+			reply := telegramReply(context.Background(), s, 42, tt.text)
+			if !strings.Contains(reply, tt.want) {
+				t.Errorf("reply %q, want it to contain %q", reply, tt.want)
+			}
+		})
+	}
+
+	// unlinked chats get told to link
+	s, _ := newTestState(t, newTestStore())
+	s.DB = fakeTelegramStore{fakeStore: newTestStore()}
+	reply := telegramReply(context.Background(), s, 7, "/following")
+	if !strings.Contains(reply, "isn't linked") {
+		t.Errorf("unlinked chat reply %q, want it to ask for linking", reply)
+	}
+}
+
+// an unknown or expired code is refused before the chat is touched, so it stays linked to its user
+func TestTelegramStartBadCode(t *testing.T) {
+	for _, code := range []string{"wrong", "stale"} {
+		t.Run(code, func(t *testing.T) {
+			var unlinked bool
+			s, _ := newTestState(t, newTestStore())
+			s.DB = fakeTelegramStore{fakeStore: newTestStore(), unlinked: &unlinked}
+
+			reply := telegramReply(context.Background(), s, 42, "/start "+code)
+			if !strings.Contains(reply, "Unknown, used or expired code") {
+				t.Errorf("reply %q, want the code refused", reply)
+			}
+			if unlinked {
+				t.Errorf("chat was unlinked by a refused code")
+			}
+		})
+	}
+}
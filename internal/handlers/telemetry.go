@@ -0,0 +1,218 @@
+// telemetry.go
+package handlers
+
+import (
+	// std go libs
+	"context"       // for context
+	"encoding/json" // printing the report
+	"errors"        // matching a missing file
+	"fmt"           // print errors
+	"io/fs"         // a missing file
+	"log/slog"      // structured logging
+	"os"            // removing the counts
+	"time"          // report times
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/telemetry" // usage reports
+)
+
+// telemetry handler logic
+// NOTE: cmd will be telemetry on [url]|off|status, nothing is counted or sent until it's turned on
+func HandlerTelemetry(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get the counts file
+	path, err := s.Config.TelemetryPath()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cmd.Args[0] == "status" && len(cmd.Args) == 1:
+		return telemetryStatus(ctx, s, path)
+	case cmd.Args[0] == "off" && len(cmd.Args) == 1:
+		// turn it off in the config
+		err = s.Config.Set("telemetry", "false")
+		if err != nil {
+			return err
+		}
+
+		// forget the id and the counts
+		err = os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error removing %s: %w", path, err)
+		}
+		fmt.Fprintln(s.Stdout, "Telemetry is off, nothing is counted or sent (the id and counts were deleted).")
+		return nil
+	case cmd.Args[0] == "on" && len(cmd.Args) <= 2:
+		// set where the reports go, if given
+		if len(cmd.Args) == 2 {
+			err = s.Config.Set("telemetry_url", cmd.Args[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		// somewhere to send them check
+		if s.Config.TelemetryURL == nil {
+			return fmt.Errorf("error: no telemetry_url to send usage reports to, use: telemetry on <url>")
+		}
+
+		// start counting, under a new id (unless it's on already)
+		_, ok, err := telemetry.Load(path)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			err = telemetry.New().Save(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		// turn it on in the config
+		err = s.Config.Set("telemetry", "true")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "Telemetry is on: agg sends an anonymous usage report to %s once a day.\n", *s.Config.TelemetryURL)
+		fmt.Fprintln(s.Stdout, "See exactly what's sent with: telemetry status, turn it off with: telemetry off")
+		return nil
+	}
+
+	// unknown args
+	return fmt.Errorf("error: usage: telemetry on [url]|off|status")
+}
+
+// print whether telemetry is on, and the next report as it would be sent helper
+func telemetryStatus(ctx context.Context, s *app.State, path string) error {
+	// get the counts
+	counts, ok, err := telemetry.Load(path)
+	if err != nil {
+		return err
+	}
+
+	// off check
+	if !s.Config.Telemetry || !ok {
+		fmt.Fprintln(s.Stdout, "Telemetry is off, nothing is counted or sent. Turn it on with: telemetry on <url>")
+		return nil
+	}
+
+	// build the next report
+	report, err := telemetryReport(ctx, s, counts)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding usage report: %w", err)
+	}
+
+	// print it
+	if s.Config.TelemetryURL == nil {
+		fmt.Fprintln(s.Stdout, "Telemetry is on, but telemetry_url isn't set, so nothing is sent.")
+	} else {
+		fmt.Fprintf(s.Stdout, "Telemetry is on, agg sends a usage report to %s once a day.\n", *s.Config.TelemetryURL)
+	}
+	if !counts.LastSent.IsZero() {
+		fmt.Fprintf(s.Stdout, "Last report sent %s.\n", counts.LastSent.Local().Format(time.DateTime))
+	}
+	fmt.Fprintf(s.Stdout, "The next is due %s (sent by the first agg run after that).\n", counts.NextDue().Local().Format(time.DateTime))
+	fmt.Fprintln(s.Stdout, "The next report, as it would be sent:")
+	fmt.Fprintln(s.Stdout, string(data))
+	return nil
+}
+
+// the report of the counts helper, with the users and feeds counted now
+func telemetryReport(ctx context.Context, s *app.State, counts telemetry.Counts) (telemetry.Report, error) {
+	// count them
+	totals, err := s.DB.CountUsersAndFeeds(ctx)
+
+	// count check
+	if err != nil {
+		return telemetry.Report{}, fmt.Errorf("error counting users and feeds: %w", err)
+	}
+	return counts.Report(string(s.Dialect), totals.Users, totals.Feeds, time.Now()), nil
+}
+
+// count a command run helper, for main once the command is done (only while telemetry is on)
+// NOTE: failures are only logged, counting must never break a command
+func CountCommand(s *app.State, name string) {
+	// on check
+	if s == nil || s.Config == nil || !s.Config.Telemetry {
+		return
+	}
+
+	// get the counts file
+	path, err := s.Config.TelemetryPath()
+	if err == nil {
+		err = telemetry.Count(path, name)
+	}
+
+	// count check
+	if err != nil {
+		slog.Debug("error counting command for telemetry", "command", name, "err", err)
+	}
+}
+
+// send the usage report helper, for agg, once it's due (errors are logged, agg keeps going)
+func aggTelemetry(ctx context.Context, s *app.State) {
+	// on check
+	if !s.Config.Telemetry || s.Config.TelemetryURL == nil {
+		return
+	}
+
+	// get the counts
+	path, err := s.Config.TelemetryPath()
+	if err != nil {
+		slog.Warn("error getting telemetry counts", "err", err)
+		return
+	}
+	counts, ok, err := telemetry.Load(path)
+	if err != nil {
+		slog.Warn("error getting telemetry counts", "err", err)
+		return
+	}
+
+	// due check (turned on by the config alone, without telemetry on, there's no id to send)
+	now := time.Now().UTC()
+	if !ok || now.Before(counts.NextDue()) {
+		return
+	}
+
+	// build and send the report
+	report, err := telemetryReport(ctx, s, counts)
+	if err == nil {
+		err = telemetry.Send(ctx, *s.Config.TelemetryURL, report)
+	}
+
+	// send check, tried again next tick
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("error sending usage report", "err", err)
+		}
+		return
+	}
+	slog.Info("sent usage report", "url", *s.Config.TelemetryURL)
+
+	// start counting again, keeping the runs counted meanwhile
+	latest, ok, err := telemetry.Load(path)
+	if err != nil || !ok {
+		return // turned off meanwhile
+	}
+	for command, runs := range report.Commands {
+		latest.Commands[command] -= runs
+		if latest.Commands[command] <= 0 {
+			delete(latest.Commands, command)
+		}
+	}
+	latest.Since, latest.LastSent = now, now
+	err = latest.Save(path)
+	if err != nil {
+		slog.Warn("error saving telemetry counts", "err", err)
+	}
+}
@@ -0,0 +1,147 @@
+// token.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"strings" // unique constraint errors
+	"time"    // created at
+
+	// external packages
+	"github.com/google/uuid" // token ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/api"      // generating and hashing tokens
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+)
+
+// token handler logic
+// NOTE: cmd will be token create <name>|list|revoke <name>, manages the current user's tokens for serve's json api
+func HandlerToken(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// cmd input check
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("error: usage: token create <name> | token list | token revoke <name>")
+	}
+
+	// run the subcommand
+	switch cmd.Args[0] {
+	case "create":
+		// name check
+		if len(cmd.Args) != 2 {
+			return fmt.Errorf("error: usage: token create <name>")
+		}
+		return createToken(ctx, s, user, cmd.Args[1])
+	case "list":
+		return listTokens(ctx, s, user)
+	case "revoke":
+		// name check
+		if len(cmd.Args) != 2 {
+			return fmt.Errorf("error: usage: token revoke <name>")
+		}
+		return revokeToken(ctx, s, user, cmd.Args[1])
+	}
+
+	// unknown subcommand
+	return fmt.Errorf("error: unknown token subcommand %q (use create, list or revoke)", cmd.Args[0])
+}
+
+// token create helper
+func createToken(ctx context.Context, s *app.State, user database.User, name string) error {
+	// generate the token
+	token, err := api.NewAPIToken()
+
+	// generate check
+	if err != nil {
+		return err
+	}
+
+	// store its hash
+	_, err = s.DB.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: api.HashAPIToken(token),
+	})
+
+	// create check
+	if err != nil {
+		// check if unique (per user)
+		if strings.Contains(err.Error(), "unique constraint") {
+			return fmt.Errorf("error: you already have a token named '%s', revoke it first", name)
+		}
+		return fmt.Errorf("error creating token: %w", err)
+	}
+
+	// show the token, the only time it can be seen
+	fmt.Fprintf(s.Stdout, "Token '%s' created for %s:\n\n%s\n\n", name, user.Name, token)
+	fmt.Fprintln(s.Stdout, "Copy it now, it won't be shown again. Send it to serve's api as: Authorization: Bearer <token>")
+	return nil
+}
+
+// token list helper
+func listTokens(ctx context.Context, s *app.State, user database.User) error {
+	// get the user's tokens
+	tokens, err := s.DB.ListAPITokens(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing tokens: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "created_at", "last_used_at"}}
+		for _, token := range tokens {
+			table.Add(token.Name, token.CreatedAt, optionalTime(token.LastUsedAt.Time))
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no tokens check
+	if len(tokens) == 0 {
+		fmt.Fprintln(s.Stdout, "No api tokens yet, create one with: token create <name>")
+		return nil
+	}
+
+	// print the tokens
+	fmt.Fprintf(s.Stdout, "API tokens of %s:\n", user.Name)
+	for _, token := range tokens {
+		lastUsed := "never used"
+		if token.LastUsedAt.Valid {
+			lastUsed = "last used " + token.LastUsedAt.Time.Format(time.DateTime)
+		}
+		fmt.Fprintf(s.Stdout, "* %s (created %s, %s)\n", token.Name, token.CreatedAt.Format(time.DateTime), lastUsed)
+	}
+
+	// return success
+	return nil
+}
+
+// token revoke helper
+func revokeToken(ctx context.Context, s *app.State, user database.User, name string) error {
+	// delete the token
+	deleted, err := s.DB.DeleteAPIToken(ctx, database.DeleteAPITokenParams{UserID: user.ID, Name: name})
+
+	// delete check
+	if err != nil {
+		return fmt.Errorf("error revoking token: %w", err)
+	}
+
+	// no such token check
+	if deleted == 0 {
+		return fmt.Errorf("error: you have no token named '%s'", name)
+	}
+
+	// success
+	fmt.Fprintf(s.Stdout, "Token '%s' revoked, requests using it are now refused.\n", name)
+	return nil
+}
@@ -0,0 +1,275 @@
+// translate.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // null descriptions
+	"fmt"          // print errors
+	"log/slog"     // logging failed auto-translations
+	"strings"      // joining text
+	"time"         // created at
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"       // for State and Command
+	"github.com/PietPadda/aggregator/internal/database"  // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/lang"      // language codes
+	"github.com/PietPadda/aggregator/internal/output"    // for --output formats
+	"github.com/PietPadda/aggregator/internal/translate" // translation providers
+	"github.com/google/uuid"                             // post ids
+)
+
+// package-wide constants
+const translateBatch = 50 // most texts per provider request (deepl's limit)
+
+// translate handler logic
+// NOTE: cmd will be translate feed <url> <code>|off | list | text <code> <text>
+// NOTE: browse shows the posts of auto-translated follows in their language, browse --translate does it for every post
+func HandlerTranslate(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	usage := fmt.Errorf("error: usage: translate feed <url> <code>|off | translate list | translate text <code> <text>")
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	// subcommand check
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "feed":
+		if len(args) != 2 {
+			return fmt.Errorf("error: usage: translate feed <url> <code>|off")
+		}
+		return setTranslateFeed(ctx, s, user, args[0], args[1])
+	case "list":
+		return listTranslateFeeds(ctx, s, user)
+	case "text":
+		if len(args) < 2 {
+			return fmt.Errorf("error: usage: translate text <code> <text>")
+		}
+		target := lang.Normalize(args[0])
+		if target == "" {
+			return fmt.Errorf("error: %s is not a language code (e.g. en, de, pt-BR)", args[0])
+		}
+		translator, err := newTranslator(s)
+		if err != nil {
+			return err
+		}
+		translated, err := translator.Translate(ctx, []string{strings.Join(args[1:], " ")}, target)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(s.Stdout, translated[0])
+		return nil
+	}
+	return usage
+}
+
+// the configured translation provider helper
+func newTranslator(s *app.State) (translate.Translator, error) {
+	opts, err := s.Config.TranslateOptions()
+	if err != nil {
+		return nil, err
+	}
+	return translate.New(opts)
+}
+
+// translate feed helper, sets or clears the language one of the user's follows is auto-translated to
+func setTranslateFeed(ctx context.Context, s *app.State, user database.User, feedURL, code string) error {
+	// language check (off clears it)
+	target := ""
+	if code != "off" {
+		target = lang.Normalize(code)
+		if target == "" {
+			return fmt.Errorf("error: %s is not a language code (e.g. en, de, pt-BR), or off", code)
+		}
+	}
+
+	// save it
+	rows, err := s.DB.SetFeedFollowTranslate(ctx, database.SetFeedFollowTranslateParams{
+		TranslateTo: sql.NullString{String: target, Valid: target != ""},
+		Url:         feedURL,
+		UserID:      user.ID,
+	})
+
+	// save check
+	if err != nil {
+		return fmt.Errorf("error setting feed translation: %w", err)
+	}
+
+	// following check
+	if rows == 0 {
+		return fmt.Errorf("error: you don't follow %s (see following)", feedURL)
+	}
+	if target == "" {
+		fmt.Fprintf(s.Stdout, "Posts of %s are shown as they are again.\n", feedURL)
+	} else {
+		fmt.Fprintf(s.Stdout, "browse shows the posts of %s in %s now.\n", feedURL, target)
+	}
+	return nil
+}
+
+// translate list helper, the user's auto-translated follows
+func listTranslateFeeds(ctx context.Context, s *app.State, user database.User) error {
+	feeds, err := s.DB.ListTranslateFeeds(ctx, user.ID)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing translated feeds: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"name", "url", "language"}}
+		for _, feed := range feeds {
+			table.Add(feed.Name, feed.Url, feed.TranslateTo)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// empty check
+	if len(feeds) == 0 {
+		fmt.Fprintln(s.Stdout, "No feeds are auto-translated, translate one with: translate feed <url> <code>")
+		return nil
+	}
+	for _, feed := range feeds {
+		fmt.Fprintf(s.Stdout, "* %s (%s) -> %s\n", feed.Name, feed.Url, feed.TranslateTo)
+	}
+	return nil
+}
+
+// translate posts helper, for browse: to target ("" = each follow's own auto-translate language, if any)
+// posts already in the language are left alone, translations are stored so each post is only translated once
+// an explicit target errors if the provider fails, auto-translations just log it and show the posts as they are
+func translatePosts(ctx context.Context, s *app.State, user database.User, posts []database.Post, target string) ([]database.Post, error) {
+	// which language each feed's posts go to
+	feedTargets := map[uuid.UUID]string{}
+	if target == "" {
+		feeds, err := s.DB.ListTranslateFeeds(ctx, user.ID)
+
+		// list check
+		if err != nil {
+			return nil, fmt.Errorf("error listing translated feeds: %w", err)
+		}
+		for _, feed := range feeds {
+			feedTargets[feed.ID] = feed.TranslateTo
+		}
+	}
+
+	// the posts to translate, by language
+	byLanguage := map[string][]int{}
+	for i, post := range posts {
+		postTarget := target
+		if postTarget == "" {
+			postTarget = feedTargets[post.FeedID]
+		}
+		if postTarget == "" || post.Language.String == postTarget {
+			continue
+		}
+		byLanguage[postTarget] = append(byLanguage[postTarget], i)
+	}
+
+	// nothing to translate check (no provider needed then)
+	if len(byLanguage) == 0 {
+		return posts, nil
+	}
+
+	// translate each language's posts
+	translated := make([]database.Post, len(posts))
+	copy(translated, posts)
+	for language, indexes := range byLanguage {
+		err := translateLanguage(ctx, s, translated, indexes, language)
+
+		// translate check
+		if err != nil && target != "" {
+			return nil, err
+		}
+		if err != nil {
+			slog.Warn("auto-translating posts failed, showing them as they are", "language", language, "error", err)
+		}
+	}
+	return translated, nil
+}
+
+// translate some of the posts to one language helper, in place
+// stored translations are used first, the rest go to the provider in batches and are stored
+func translateLanguage(ctx context.Context, s *app.State, posts []database.Post, indexes []int, language string) error {
+	// get the stored translations
+	ids := make([]uuid.UUID, len(indexes))
+	for i, index := range indexes {
+		ids[i] = posts[index].ID
+	}
+	stored, err := s.DB.GetPostTranslations(ctx, database.GetPostTranslationsParams{PostIds: ids, Language: language})
+
+	// stored check
+	if err != nil {
+		return fmt.Errorf("error getting stored translations: %w", err)
+	}
+	storedByPost := map[uuid.UUID]database.PostTranslation{}
+	for _, translation := range stored {
+		storedByPost[translation.PostID] = translation
+	}
+
+	// use them, and collect the texts of the others (titles and non-empty descriptions)
+	var missing []int
+	var texts []string
+	for _, index := range indexes {
+		if translation, ok := storedByPost[posts[index].ID]; ok {
+			posts[index].Title = translation.Title
+			posts[index].Description = translation.Description
+			continue
+		}
+		missing = append(missing, index)
+		texts = append(texts, posts[index].Title)
+		if posts[index].Description.String != "" {
+			texts = append(texts, posts[index].Description.String)
+		}
+	}
+
+	// all stored check
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// translate the rest in batches
+	translator, err := newTranslator(s)
+	if err != nil {
+		return err
+	}
+	var results []string
+	for start := 0; start < len(texts); start += translateBatch {
+		batch, err := translator.Translate(ctx, texts[start:min(start+translateBatch, len(texts))], language)
+		if err != nil {
+			return err
+		}
+		results = append(results, batch...)
+	}
+
+	// put them on the posts (in the order they were collected) and store them
+	next := 0
+	for _, index := range missing {
+		posts[index].Title = results[next]
+		next++
+		if posts[index].Description.String != "" {
+			posts[index].Description = sql.NullString{String: results[next], Valid: true}
+			next++
+		}
+		err = s.DB.SavePostTranslation(ctx, database.SavePostTranslationParams{
+			PostID:      posts[index].ID,
+			Language:    language,
+			CreatedAt:   time.Now().UTC(),
+			Title:       posts[index].Title,
+			Description: posts[index].Description,
+		})
+
+		// save check (the translation is still shown, it's just not remembered)
+		if err != nil {
+			slog.Warn("storing post translation failed", "post", posts[index].ID, "error", err)
+		}
+	}
+	return nil
+}
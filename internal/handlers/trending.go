@@ -0,0 +1,81 @@
+// trending.go
+package handlers
+
+import (
+	// std go libs
+	"context" // for context
+	"fmt"     // print errors
+	"strconv" // parsing the limit
+	"time"    // trending windows
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // machine-readable output
+)
+
+// the trending windows, by name
+var trendingPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// trending handler logic
+// NOTE: cmd will be trending [24h|7d] [limit], the instance's most read and starred posts
+func HandlerTrending(ctx context.Context, s *app.State, cmd app.Command) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// get the optional period and limit, in either order
+	period := "24h"
+	limit := 10
+	for _, arg := range cmd.Args {
+		// period check
+		if _, ok := trendingPeriods[arg]; ok {
+			period = arg
+			continue
+		}
+
+		// limit check
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return fmt.Errorf("error: usage: trending [24h|7d] [limit]")
+		}
+		limit = n
+	}
+
+	// get the posts
+	posts, err := s.DB.ListTrendingPosts(ctx, database.ListTrendingPostsParams{
+		Since:     time.Now().Add(-trendingPeriods[period]),
+		PostLimit: int32(limit),
+	})
+
+	// trending check
+	if err != nil {
+		return fmt.Errorf("error getting trending posts: %w", err)
+	}
+
+	// machine-readable output check
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"title", "url", "feed", "users", "reads", "stars", "published_at", "id"}}
+		for _, post := range posts {
+			table.Add(post.Title, post.Url, post.FeedName, post.Users, post.Reads, post.Stars, nullTime(post.PublishedAt), post.ID)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// nothing read check
+	if len(posts) == 0 {
+		fmt.Fprintf(s.Stdout, "Nothing was read or starred in the last %s.\n", period)
+		return nil
+	}
+	fmt.Fprintf(s.Stdout, "Trending in the last %s:\n", period)
+	for i, post := range posts {
+		fmt.Fprintf(s.Stdout, "%d. %s (%s)\n", i+1, post.Title, post.FeedName)
+		fmt.Fprintf(s.Stdout, "   %s\n", post.Url)
+		fmt.Fprintf(s.Stdout, "   %d read, %d starred, by %d users (post id %s)\n", post.Reads, post.Stars, post.Users, shortID(post.ID))
+	}
+	return nil
+}
@@ -0,0 +1,532 @@
+// userdata.go
+package handlers
+
+import (
+	// std go libs
+	"context"      // for context
+	"database/sql" // no rows and nullable columns
+	"errors"       // matching sql.ErrNoRows
+	"fmt"          // print errors
+	"time"         // export and state times
+
+	// external packages
+	"github.com/google/uuid" // post, mute and rule ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/lang"     // post languages
+	"github.com/PietPadda/aggregator/internal/readers"  // following like the reader imports
+	"github.com/PietPadda/aggregator/internal/readtime" // post reading times
+	"github.com/PietPadda/aggregator/internal/userdata" // the export's json schema
+)
+
+// export handler logic
+// NOTE: cmd will be export --all <file>, writes everything of the user's to a json file (see import <file>)
+// NOTE: or export --states <file>, writes only the read and starred items (see import states <file>)
+func HandlerExport(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// usage check
+	args, all := popFlag(cmd.Args, "--all")
+	args, states := popFlag(args, "--states")
+	if all == states || len(args) != 1 {
+		return fmt.Errorf("error: usage: export --all <file> | export --states <file>")
+	}
+
+	// states only check
+	if states {
+		return exportStates(ctx, s, user, args[0])
+	}
+
+	// gather it
+	export, err := exportUserData(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	// write it
+	err = userdata.Write(args[0], export)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Exported %d follows, %d posts, %d mutes and %d rules of %s to %s\n", len(export.Follows), len(export.Posts), len(export.Mutes), len(export.Rules), user.Name, args[0])
+	return nil
+}
+
+// gather a user's data helper
+func exportUserData(ctx context.Context, s *app.State, user database.User) (userdata.Export, error) {
+	export := userdata.Export{
+		ExportedAt: time.Now().UTC(),
+		User:       userdata.User{Name: user.Name, CreatedAt: user.CreatedAt},
+	}
+
+	// preferences (no rows = not set)
+	languages, err := s.DB.GetLanguagePreference(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return export, fmt.Errorf("error getting languages: %w", err)
+	}
+	export.Preferences.Languages = languages.Languages
+	digest, err := s.DB.GetDigestPreference(ctx, user.ID)
+	if err == nil {
+		export.Preferences.Digest = &userdata.Digest{
+			Cadence:    digest.Cadence,
+			Weekday:    int(digest.Weekday),
+			SendMinute: int(digest.SendMinute),
+			Timezone:   digest.Timezone,
+			Channel:    digest.Channel,
+			Folders:    digest.Folders,
+			Tags:       digest.Tags,
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return export, fmt.Errorf("error getting digest preferences: %w", err)
+	}
+	_, err = s.DB.GetTimelineShare(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return export, fmt.Errorf("error getting timeline share: %w", err)
+	}
+	export.Preferences.ShareTimeline = err == nil
+	_, err = s.DB.GetProfileShare(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return export, fmt.Errorf("error getting profile share: %w", err)
+	}
+	export.Preferences.ShareProfile = err == nil
+
+	// folders, empty ones too
+	folders, err := s.DB.ListFoldersForUser(ctx, user.ID)
+	if err != nil {
+		return export, fmt.Errorf("error getting folders: %w", err)
+	}
+	for _, folder := range folders {
+		export.Folders = append(export.Folders, folder.Name)
+	}
+
+	// follows
+	follows, err := s.DB.ListUserDataFollows(ctx, user.ID)
+	if err != nil {
+		return export, fmt.Errorf("error getting follows: %w", err)
+	}
+	for _, follow := range follows {
+		item := userdata.Follow{
+			Name:         follow.Name,
+			URL:          follow.Url,
+			Folder:       follow.Folder.String,
+			FollowedAt:   follow.CreatedAt,
+			Notify:       follow.Notify,
+			TranslateTo:  follow.TranslateTo.String,
+			SnoozedUntil: timePtr(follow.SnoozedUntil),
+		}
+		if follow.Weight != 1 {
+			item.Weight = &follow.Weight
+		}
+		export.Follows = append(export.Follows, item)
+	}
+
+	// posts, with their tags and notes
+	posts, err := s.DB.ListUserDataPosts(ctx, user.ID)
+	if err != nil {
+		return export, fmt.Errorf("error getting posts: %w", err)
+	}
+	index := map[string]int{}
+	for _, post := range posts {
+		index[post.Url] = len(export.Posts)
+		export.Posts = append(export.Posts, userdata.Post{
+			URL:         post.Url,
+			FeedURL:     post.FeedUrl,
+			Title:       post.Title,
+			Description: post.Description.String,
+			PublishedAt: timePtr(post.PublishedAt),
+			ReadAt:      timePtr(post.ReadAt),
+			StarredAt:   timePtr(post.SavedAt),
+			MutedAt:     timePtr(post.MutedAt),
+		})
+	}
+	tags, err := s.DB.ListUserPostTags(ctx, user.ID)
+	if err != nil {
+		return export, fmt.Errorf("error getting tags: %w", err)
+	}
+	for _, tag := range tags {
+		if i, ok := index[tag.Url]; ok {
+			export.Posts[i].Tags = append(export.Posts[i].Tags, tag.Tag)
+		}
+	}
+	notes, err := s.DB.ListPostNotes(ctx, database.ListPostNotesParams{UserID: user.ID})
+	if err != nil {
+		return export, fmt.Errorf("error getting notes: %w", err)
+	}
+	for _, note := range notes {
+		if i, ok := index[note.PostUrl]; ok {
+			export.Posts[i].Notes = append(export.Posts[i].Notes, userdata.Note{
+				ID:        note.ID,
+				CreatedAt: note.CreatedAt,
+				Body:      note.Body,
+				Highlight: note.Highlight.String,
+			})
+		}
+	}
+
+	// mutes and rules
+	mutes, err := s.DB.ListMutes(ctx, user.ID)
+	if err != nil {
+		return export, fmt.Errorf("error getting mutes: %w", err)
+	}
+	for _, mute := range mutes {
+		export.Mutes = append(export.Mutes, userdata.Mute{Pattern: mute.Pattern, Regex: mute.IsRegex})
+	}
+	rules, err := s.DB.ListRules(ctx, user.ID)
+	if err != nil {
+		return export, fmt.Errorf("error getting rules: %w", err)
+	}
+	for _, rule := range rules {
+		export.Rules = append(export.Rules, userdata.Rule{
+			FeedURL:      rule.FeedUrl.String,
+			TitlePattern: rule.TitlePattern.String,
+			Category:     rule.Category.String,
+			Author:       rule.Author.String,
+			Action:       rule.Action,
+			Argument:     rule.Argument.String,
+		})
+	}
+	return export, nil
+}
+
+// import a user data export helper (import <file>), only ever adding to what the user has:
+// new follows get their folder and settings, states and preferences already set are kept
+func importUserData(ctx context.Context, s *app.State, user database.User, path string) error {
+	// read it
+	export, err := userdata.Read(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "Importing %s's data from %s (exported %s)...\n\n", export.User.Name, path, export.ExportedAt.Local().Format(time.DateTime))
+
+	// folders, empty ones too
+	folders := map[string]uuid.UUID{}
+	for _, name := range export.Folders {
+		folders[name], err = importFolder(ctx, s, user, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// follows, noting where each feed is stored
+	stored := map[string]database.GetFeedByURLRow{}
+	followed, failed := 0, 0
+	for _, follow := range export.Follows {
+		// stopping check
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		row, isNew, err := importFollow(ctx, s, user, readers.Feed{Title: follow.Name, URL: follow.URL})
+		if err != nil {
+			fmt.Fprintf(s.Stdout, "  Not followed: %s (%s)\n", follow.URL, err)
+			failed++
+			continue
+		}
+		stored[follow.URL] = row
+		if !isNew {
+			continue
+		}
+		followed++
+		err = restoreFollowSettings(ctx, s, user, row.Url, follow, folders)
+		if err != nil {
+			return err
+		}
+	}
+
+	// posts of followed feeds (posts already stored are kept as they are)
+	batches := map[string]*database.InsertPostsParams{}
+	states := database.RestorePostStatesParams{UserID: user.ID}
+	tags := database.RestorePostTagsParams{UserID: user.ID}
+	notes := database.RestorePostNotesParams{UserID: user.ID}
+	createdAt := time.Now()
+	for _, post := range export.Posts {
+		// its state, tags and notes, by url
+		states.Urls = append(states.Urls, post.URL)
+		states.ReadAts = append(states.ReadAts, timeOrZero(post.ReadAt))
+		states.SavedAts = append(states.SavedAts, timeOrZero(post.StarredAt))
+		states.MutedAts = append(states.MutedAts, timeOrZero(post.MutedAt))
+		for _, tag := range post.Tags {
+			tags.Urls = append(tags.Urls, post.URL)
+			tags.Tags = append(tags.Tags, tag)
+		}
+		for _, note := range post.Notes {
+			notes.Ids = append(notes.Ids, note.ID)
+			notes.CreatedAts = append(notes.CreatedAts, note.CreatedAt)
+			notes.Urls = append(notes.Urls, post.URL)
+			notes.Bodies = append(notes.Bodies, note.Body)
+			notes.Highlights = append(notes.Highlights, note.Highlight)
+		}
+
+		// feed not followed check, the rest still applies if the post is stored
+		feed, ok := stored[post.FeedURL]
+		if !ok {
+			continue
+		}
+		batch := batches[feed.Url]
+		if batch == nil {
+			batch = &database.InsertPostsParams{CreatedAt: createdAt, FeedID: feed.ID}
+			batches[feed.Url] = batch
+		}
+		batch.Ids = append(batch.Ids, uuid.New())
+		batch.Titles = append(batch.Titles, post.Title)
+		batch.Urls = append(batch.Urls, post.URL)
+		batch.Descriptions = append(batch.Descriptions, post.Description)
+		batch.PublishedAts = append(batch.PublishedAts, timeOrZero(post.PublishedAt))
+		batch.Languages = append(batch.Languages, lang.Detect(post.Title+"\n"+post.Description))
+		batch.ReadingMinutes = append(batch.ReadingMinutes, int32(readtime.Minutes(post.Description)))
+		batch.Guids = append(batch.Guids, "") // user data exports have no guids
+	}
+	posts := 0
+	for _, batch := range batches {
+		added, err := s.DB.InsertPosts(ctx, *batch)
+		if err != nil {
+			return fmt.Errorf("error storing imported posts: %w", err)
+		}
+		posts += len(added)
+	}
+
+	// then the states, tags and notes
+	var marked, tagged, noted int64
+	if len(states.Urls) > 0 {
+		marked, err = s.DB.RestorePostStates(ctx, states)
+		if err != nil {
+			return fmt.Errorf("error storing read, starred and muted states: %w", err)
+		}
+	}
+	if len(tags.Urls) > 0 {
+		tagged, err = s.DB.RestorePostTags(ctx, tags)
+		if err != nil {
+			return fmt.Errorf("error storing tags: %w", err)
+		}
+	}
+	if len(notes.Urls) > 0 {
+		noted, err = s.DB.RestorePostNotes(ctx, notes)
+		if err != nil {
+			return fmt.Errorf("error storing notes: %w", err)
+		}
+	}
+
+	// mutes and rules
+	var muted int64
+	for _, mute := range export.Mutes {
+		rows, err := s.DB.AddMute(ctx, database.AddMuteParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			Pattern:   mute.Pattern,
+			IsRegex:   mute.Regex,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding mute %s: %w", mute.Pattern, err)
+		}
+		muted += rows
+	}
+	rules, err := restoreRules(ctx, s, user, export.Rules, stored)
+	if err != nil {
+		return err
+	}
+
+	// preferences
+	err = restorePreferences(ctx, s, user, export.Preferences)
+	if err != nil {
+		return err
+	}
+
+	// print the totals
+	fmt.Fprintf(s.Stdout, "\nFollowed %d new feeds (%d already followed, %d failed) in %d folders.\n", followed, len(stored)-followed, failed, len(folders))
+	fmt.Fprintf(s.Stdout, "Imported %d posts, the state of %d, %d tags and %d notes.\n", posts, marked, tagged, noted)
+	fmt.Fprintf(s.Stdout, "Added %d mutes and %d rules.\n", muted, rules)
+	return nil
+}
+
+// a new follow's folder and settings helper
+func restoreFollowSettings(ctx context.Context, s *app.State, user database.User, url string, follow userdata.Follow, folders map[string]uuid.UUID) error {
+	// folder (created if the export didn't list it)
+	if follow.Folder != "" {
+		folderID, ok := folders[follow.Folder]
+		if !ok {
+			var err error
+			folderID, err = importFolder(ctx, s, user, follow.Folder)
+			if err != nil {
+				return err
+			}
+			folders[follow.Folder] = folderID
+		}
+		_, err := s.DB.MoveFeedFollowToFolder(ctx, database.MoveFeedFollowToFolderParams{
+			FolderID: uuid.NullUUID{UUID: folderID, Valid: true},
+			Url:      url,
+			UserID:   user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error moving feed %s to folder %s: %w", url, follow.Folder, err)
+		}
+	}
+
+	// notify, translate, weight and snooze (only when not the default)
+	if follow.Notify {
+		_, err := s.DB.SetFeedFollowNotify(ctx, database.SetFeedFollowNotifyParams{Notify: true, Url: url, UserID: user.ID})
+		if err != nil {
+			return fmt.Errorf("error setting notify for %s: %w", url, err)
+		}
+	}
+	if follow.TranslateTo != "" {
+		_, err := s.DB.SetFeedFollowTranslate(ctx, database.SetFeedFollowTranslateParams{
+			TranslateTo: sql.NullString{String: follow.TranslateTo, Valid: true},
+			Url:         url,
+			UserID:      user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error setting translation for %s: %w", url, err)
+		}
+	}
+	if follow.Weight != nil && *follow.Weight >= 0 {
+		_, err := s.DB.SetFeedFollowWeight(ctx, database.SetFeedFollowWeightParams{Weight: *follow.Weight, Url: url, UserID: user.ID})
+		if err != nil {
+			return fmt.Errorf("error setting weight for %s: %w", url, err)
+		}
+	}
+	if follow.SnoozedUntil != nil && follow.SnoozedUntil.After(time.Now()) {
+		_, err := s.DB.SetFeedFollowSnooze(ctx, database.SetFeedFollowSnoozeParams{
+			SnoozedUntil: sql.NullTime{Time: follow.SnoozedUntil.UTC(), Valid: true},
+			Url:          url,
+			UserID:       user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error snoozing %s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+// add the exported rules the user doesn't have yet helper, returns how many were added
+// NOTE: a rule for a feed that isn't stored here is skipped, rules need their feed
+func restoreRules(ctx context.Context, s *app.State, user database.User, rules []userdata.Rule, stored map[string]database.GetFeedByURLRow) (int, error) {
+	// the rules already there
+	existing, err := s.DB.ListRules(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting rules: %w", err)
+	}
+	have := map[userdata.Rule]bool{}
+	for _, rule := range existing {
+		have[userdata.Rule{
+			FeedURL:      rule.FeedUrl.String,
+			TitlePattern: rule.TitlePattern.String,
+			Category:     rule.Category.String,
+			Author:       rule.Author.String,
+			Action:       rule.Action,
+			Argument:     rule.Argument.String,
+		}] = true
+	}
+
+	// add the rest
+	added := 0
+	for _, rule := range rules {
+		if have[rule] {
+			continue
+		}
+		var feedID uuid.NullUUID
+		if feed, ok := stored[rule.FeedURL]; ok {
+			feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		} else if rule.FeedURL != "" {
+			feed, err := s.DB.GetFeedByURL(ctx, rule.FeedURL)
+			if errors.Is(err, sql.ErrNoRows) {
+				fmt.Fprintf(s.Stdout, "  Rule skipped, feed %s isn't stored here\n", rule.FeedURL)
+				continue
+			}
+			if err != nil {
+				return added, fmt.Errorf("error getting feed %s: %w", rule.FeedURL, err)
+			}
+			feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		}
+		err = s.DB.CreateRule(ctx, database.CreateRuleParams{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now().UTC(),
+			UserID:       user.ID,
+			FeedID:       feedID,
+			TitlePattern: sql.NullString{String: rule.TitlePattern, Valid: rule.TitlePattern != ""},
+			Category:     sql.NullString{String: rule.Category, Valid: rule.Category != ""},
+			Author:       sql.NullString{String: rule.Author, Valid: rule.Author != ""},
+			Action:       rule.Action,
+			Argument:     sql.NullString{String: rule.Argument, Valid: rule.Argument != ""},
+		})
+		if err != nil {
+			return added, fmt.Errorf("error adding %s rule: %w", rule.Action, err)
+		}
+		have[rule] = true
+		added++
+	}
+	return added, nil
+}
+
+// the exported preferences the user hasn't set helper
+func restorePreferences(ctx context.Context, s *app.State, user database.User, prefs userdata.Preferences) error {
+	now := time.Now().UTC()
+
+	// languages
+	if len(prefs.Languages) > 0 {
+		_, err := s.DB.GetLanguagePreference(ctx, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = s.DB.SetLanguagePreference(ctx, database.SetLanguagePreferenceParams{UserID: user.ID, UpdatedAt: now, Languages: prefs.Languages})
+		}
+		if err != nil {
+			return fmt.Errorf("error setting languages: %w", err)
+		}
+	}
+
+	// digest schedule
+	if prefs.Digest != nil {
+		_, err := s.DB.GetDigestPreference(ctx, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = s.DB.SetDigestPreference(ctx, database.SetDigestPreferenceParams{
+				UserID:     user.ID,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+				Cadence:    prefs.Digest.Cadence,
+				Weekday:    int32(prefs.Digest.Weekday),
+				SendMinute: int32(prefs.Digest.SendMinute),
+				Timezone:   prefs.Digest.Timezone,
+				Channel:    prefs.Digest.Channel,
+				Folders:    prefs.Digest.Folders,
+				Tags:       prefs.Digest.Tags,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("error setting digest preferences: %w", err)
+		}
+	}
+
+	// shares
+	if prefs.ShareTimeline {
+		err := s.DB.ShareTimeline(ctx, database.ShareTimelineParams{UserID: user.ID, CreatedAt: now})
+		if err != nil {
+			return fmt.Errorf("error sharing timeline: %w", err)
+		}
+	}
+	if prefs.ShareProfile {
+		err := s.DB.ShareProfile(ctx, database.ShareProfileParams{UserID: user.ID, CreatedAt: now})
+		if err != nil {
+			return fmt.Errorf("error sharing profile: %w", err)
+		}
+	}
+	return nil
+}
+
+// nullable time as a pointer helper, NULL = nil
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// pointer time as an array value helper, nil = the zero time (NULL to the restore queries)
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,90 @@
+// watch.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"fmt"      // print errors
+	"log/slog" // structured logging
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/app"      // for State and Command
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/notify"   // for new posts notifications
+)
+
+// watch handler logic
+// NOTE: cmd will be watch, prints new posts from followed feeds as agg stores them (until ctrl+c)
+// agg NOTIFYs after every fetch with new posts, so nothing here polls the posts table
+func HandlerWatch(ctx context.Context, s *app.State, cmd app.Command, user database.User) error {
+	// state ptr check
+	if s == nil {
+		return fmt.Errorf("error: State is nil")
+	}
+
+	// dialect check, cockroachdb has no LISTEN/NOTIFY
+	if !s.Dialect.SupportsNotify() {
+		return fmt.Errorf("error: watch needs LISTEN/NOTIFY, which db_dialect %s doesn't support", s.Dialect)
+	}
+
+	// tell the user what's happening
+	fmt.Fprintf(s.Stdout, "Watching for new posts for %s (ctrl+c to stop)...\n", user.Name)
+
+	// print the announced posts of followed feeds
+	show := func(n notify.NewPosts) {
+		// followed check (follows may change while watching, so check every time)
+		follows, err := s.DB.GetFeedFollowsForUser(ctx, user.ID)
+
+		// get follows check
+		if err != nil {
+			slog.Error("error getting feed follows", "err", err)
+			return
+		}
+
+		// is this feed followed?
+		followed := false
+		for _, follow := range follows {
+			if follow.FeedID == n.FeedID {
+				followed = true
+				break
+			}
+		}
+		if !followed {
+			return // someone else's feed
+		}
+
+		// get the new posts (newest stored first)
+		posts, err := s.DB.GetPostsForFeed(ctx, database.GetPostsForFeedParams{FeedID: n.FeedID, Limit: int32(n.Count)})
+
+		// get posts check
+		if err != nil {
+			slog.Error("error getting new posts", "feed", n.FeedName, "err", err)
+			return
+		}
+
+		// print the posts
+		fmt.Fprintf(s.Stdout, "%d new posts from %s:\n", n.Count, n.FeedName)
+		for _, post := range posts {
+			fmt.Fprintf(s.Stdout, "* %s\n  %s\n", post.Title, post.Url)
+		}
+	}
+
+	// get the db url (the listener opens its own connection)
+	dbURL, err := s.Config.DatabaseURL()
+
+	// db url check
+	if err != nil {
+		return err
+	}
+
+	// listen until ctrl+c (or --timeout)
+	err = notify.Listen(ctx, dbURL, notify.Channel(s.Config.SchemaName()), show)
+
+	// listen check
+	if err != nil {
+		return fmt.Errorf("error watching for new posts: %w", err)
+	}
+
+	// return success
+	return nil
+}
@@ -0,0 +1,162 @@
+// worker.go
+package handlers
+
+import (
+	// std go libs
+	"context"  // for context
+	"fmt"      // print errors
+	"log/slog" // structured logging
+	"os"       // hostname, pid and stdout for --output
+	"sync"     // stopping the heartbeat once
+	"time"     // heartbeats
+
+	// external packages
+	"github.com/google/uuid" // worker ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/adaptive" // fetch concurrency
+	"github.com/PietPadda/aggregator/internal/app"      // for State
+	"github.com/PietPadda/aggregator/internal/database" // for DB Go code from SQLC
+	"github.com/PietPadda/aggregator/internal/output"   // for --output formats
+)
+
+// worker heartbeat settings
+const workerHeartbeat = 10 * time.Second // how often a running agg says it's alive
+const workerDeadAfter = time.Minute      // a worker silent this long is dead, its jobs go to the others
+
+// a running agg, registered in agg_workers so the others can tell when it died
+type aggWorker struct {
+	id       uuid.UUID
+	cancel   context.CancelFunc // stops the heartbeat
+	done     chan struct{}      // closed once the heartbeat stopped
+	stopOnce sync.Once
+	limiter  *adaptive.Limiter // its store concurrency, kept between ticks so it remembers a slow database
+	stages   *pipelineMetrics  // its fetch pipeline's totals since it started, for agg status
+	breakers *hostBreakers     // the host circuit breakers, reloaded every tick
+}
+
+// register this agg as a worker and keep heartbeating until Stop helper
+func startAggWorker(ctx context.Context, s *app.State) (*aggWorker, error) {
+	// describe this agg (the host tells workers on different machines apart)
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	// get the fetch concurrency limits, it starts at the most
+	fewest, most, target, err := s.Config.FetchLimits()
+
+	// limits check
+	if err != nil {
+		return nil, err
+	}
+	worker := &aggWorker{id: uuid.New(), done: make(chan struct{}), limiter: adaptive.New(fewest, most, target), stages: newPipelineMetrics(), breakers: newHostBreakers()}
+	started := time.Now().UTC()
+
+	// one heartbeat helper, it also re-adds a worker that was taken for dead
+	beat := func(ctx context.Context) error {
+		return s.DB.HeartbeatAggWorker(ctx, database.HeartbeatAggWorkerParams{
+			ID:          worker.id,
+			Hostname:    hostname,
+			Pid:         int32(os.Getpid()),
+			StartedAt:   started,
+			HeartbeatAt: time.Now().UTC(),
+		})
+	}
+
+	// register
+	err = beat(ctx)
+
+	// register check
+	if err != nil {
+		return nil, fmt.Errorf("error registering agg worker: %w", err)
+	}
+	slog.Debug("registered agg worker", "worker", worker.id, "host", hostname)
+
+	// heartbeat in the background, even while a long fetch run is going
+	ctx, worker.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(worker.done)
+		ticker := time.NewTicker(workerHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			// heartbeat check (just log it, the next one may get through before we're taken for dead)
+			err := beat(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("error sending agg worker heartbeat", "err", err)
+			}
+		}
+	}()
+
+	// return the worker
+	return worker, nil
+}
+
+// the worker's id as stored on its jobs
+func (w *aggWorker) ID() uuid.NullUUID {
+	return uuid.NullUUID{UUID: w.id, Valid: true}
+}
+
+// stop heartbeating and unregister helper, call it once the worker's jobs are finished or released
+func (w *aggWorker) Stop(ctx context.Context, s *app.State) {
+	w.stopOnce.Do(func() {
+		w.cancel()
+		<-w.done
+
+		// unregister, even on ctrl+c (that's usually why!)
+		err := s.DB.DeleteAggWorker(context.WithoutCancel(ctx), w.id)
+
+		// unregister check (just log it, it's taken for dead after a minute anyway)
+		if err != nil {
+			slog.Debug("error unregistering agg worker", "err", err)
+		}
+	})
+}
+
+// agg workers helper, lists the aggs currently fetching feeds
+func aggWorkersCmd(ctx context.Context, s *app.State) error {
+	// get the workers
+	workers, err := s.DB.ListAggWorkers(ctx)
+
+	// list check
+	if err != nil {
+		return fmt.Errorf("error listing agg workers: %w", err)
+	}
+
+	// machine-readable output check
+	now := time.Now().UTC()
+	if s.Output != output.Text {
+		table := output.Table{Columns: []string{"id", "host", "pid", "started_at", "heartbeat_at", "alive", "running_jobs"}}
+		for _, worker := range workers {
+			table.Add(worker.ID, worker.Hostname, worker.Pid, worker.StartedAt, worker.HeartbeatAt,
+				now.Sub(worker.HeartbeatAt) < workerDeadAfter, worker.RunningJobs)
+		}
+		return output.Write(s.Stdout, s.Output, table)
+	}
+
+	// no workers check
+	if len(workers) == 0 {
+		fmt.Fprintln(s.Stdout, "No agg is running.")
+		return nil
+	}
+
+	// print the workers
+	fmt.Fprintln(s.Stdout, "Agg workers:")
+	for _, worker := range workers {
+		alive := fmt.Sprintf("last heartbeat %v ago", now.Sub(worker.HeartbeatAt).Round(time.Second))
+		if now.Sub(worker.HeartbeatAt) >= workerDeadAfter {
+			alive += ", dead (its jobs go to the others)"
+		}
+		fmt.Fprintf(s.Stdout, "* %s pid %d, running since %s, %d running jobs, %s\n",
+			worker.Hostname, worker.Pid, worker.StartedAt.Local().Format(time.DateTime), worker.RunningJobs, alive)
+	}
+
+	// return success
+	return nil
+}
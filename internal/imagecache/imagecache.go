@@ -0,0 +1,114 @@
+// imagecache.go
+package imagecache
+
+import (
+	// std go libraries
+	"crypto/sha256" // file names from urls
+	"encoding/hex"  // file names from urls
+	"errors"        // matching fs.ErrNotExist
+	"fmt"           // printing errors
+	"io/fs"         // missing files
+	"net/http"      // sniffing content types
+	"os"            // the files
+	"path/filepath" // the files' paths
+	"regexp"        // key check
+	"strings"       // content type check
+)
+
+// a cached image's key, the hex sha256 of its url
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// images cached on disk, one file per image url
+// NOTE: files are named by key only, the content type is sniffed when they're served
+type Cache struct {
+	dir string
+}
+
+// create a cache in dir (created when the first image is stored)
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// the key of an image url (its file name, and the image proxy path's last part)
+func Key(imageURL string) string {
+	sum := sha256.Sum256([]byte(imageURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// the file of a key helper, in a subdir per first 2 characters so no dir gets huge
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// check an image url is cached
+func (c *Cache) Has(imageURL string) bool {
+	_, err := os.Stat(c.path(Key(imageURL)))
+	return err == nil
+}
+
+// store an image, refusing anything that isn't one (error pages, svgs that could carry scripts...)
+func (c *Cache) Store(imageURL string, data []byte) error {
+	// image check
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("error: %s isn't an image (%s)", imageURL, contentType)
+	}
+
+	// create the subdir
+	file := c.path(Key(imageURL))
+	err := os.MkdirAll(filepath.Dir(file), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating image cache dir: %w", err)
+	}
+
+	// write it to a temp file then rename, so a half-written image is never served
+	temp, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error caching image: %w", err)
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), file)
+	}
+
+	// write check
+	if err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("error caching image: %w", err)
+	}
+	return nil
+}
+
+// open a cached image by key, with its sniffed content type (fs.ErrNotExist if it isn't cached)
+func (c *Cache) Open(key string) (*os.File, string, error) {
+	// key check, so paths can't be made up
+	if !keyPattern.MatchString(key) {
+		return nil, "", fs.ErrNotExist
+	}
+
+	// open it
+	file, err := os.Open(c.path(key))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// sniff its type
+	head := make([]byte, 512)
+	n, err := file.Read(head)
+	if err == nil {
+		_, err = file.Seek(0, 0)
+	}
+	if err != nil {
+		file.Close()
+		return nil, "", fmt.Errorf("error reading cached image: %w", err)
+	}
+	return file, http.DetectContentType(head[:n]), nil
+}
+
+// check an error is a missing image helper
+func IsNotCached(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,113 @@
+// keyring.go
+package keyring
+
+import (
+	// std go libs
+	"bufio"   // reading passwords from stdin
+	"errors"  // not found errors
+	"fmt"     // printing errors
+	"io"      // end of input
+	"os"      // stdin and stderr
+	"regexp"  // validating aliases
+	"strings" // trimming newlines
+)
+
+// package-wide constants
+const service = "gator" // the keyring service (macOS, Secret Service) or target prefix (Windows) passwords are stored under
+
+// returned by Get when the keyring has no password for the alias
+var ErrNotFound = errors.New("password not found in keyring")
+
+// aliases we accept, safe as command args and credential names
+var aliasName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// get the password stored under alias from the os keyring
+// (macOS Keychain, Secret Service on linux/bsd, Windows Credential Manager)
+func Get(alias string) (string, error) {
+	// alias check
+	err := CheckAlias(alias)
+	if err != nil {
+		return "", err
+	}
+
+	// get the password from the os keyring
+	password, err := get(alias)
+
+	// get check
+	if err != nil {
+		return "", fmt.Errorf("error reading keyring password %q: %w", alias, err)
+	}
+
+	// return the password
+	return password, nil
+}
+
+// store password under alias in the os keyring, replacing any previous one
+func Set(alias, password string) error {
+	// alias check
+	err := CheckAlias(alias)
+	if err != nil {
+		return err
+	}
+
+	// empty check
+	if password == "" {
+		return fmt.Errorf("error: password is empty")
+	}
+
+	// store it in the os keyring
+	err = set(alias, password)
+
+	// set check
+	if err != nil {
+		return fmt.Errorf("error storing keyring password %q: %w", alias, err)
+	}
+
+	// return success
+	return nil
+}
+
+// read a password from stdin, without echoing it on a terminal
+func ReadPassword(prompt string) (string, error) {
+	// print the prompt on stderr (stdout may be piped)
+	fmt.Fprint(os.Stderr, prompt)
+
+	// stop echoing, if stdin is a terminal
+	restore := echoOff()
+	defer restore()
+
+	// read the password line
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Fprintln(os.Stderr) // the user's enter wasn't echoed either
+
+	// read check (EOF is fine, eg piped without a newline)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("error reading password: %w", err)
+	}
+
+	// return the password without its newline
+	return strings.TrimRight(password, "\r\n"), nil
+}
+
+// check an alias is a valid keyring name
+func CheckAlias(alias string) error {
+	// valid name check
+	if !aliasName.MatchString(alias) {
+		return fmt.Errorf("error: invalid keyring alias %q (use letters, digits, _, . and -)", alias)
+	}
+	return nil
+}
+
+// is stdin a terminal helper
+func stdinIsTerminal() bool {
+	// get stdin's file info
+	info, err := os.Stdin.Stat()
+
+	// stat check
+	if err != nil {
+		return false
+	}
+
+	// terminals are character devices, pipes and files aren't
+	return info.Mode()&os.ModeCharDevice != 0
+}
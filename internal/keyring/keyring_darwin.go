@@ -0,0 +1,60 @@
+// keyring_darwin.go
+//go:build darwin
+
+package keyring
+
+import (
+	// std go libs
+	"errors"  // exit errors
+	"os"      // stdin for stty
+	"os/exec" // running security and stty
+	"strings" // trimming output
+)
+
+// get a password from the macOS Keychain, with the security tool
+func get(alias string) (string, error) {
+	// look up the generic password (-w prints only the password)
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", alias, "-w").Output()
+
+	// not found check (security exits 44 for missing items)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+		return "", ErrNotFound
+	}
+
+	// security check
+	if err != nil {
+		return "", err
+	}
+
+	// return the password without its newline
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// store a password in the macOS Keychain, -U updates an existing one
+// NOTE: security only takes the password as an arg, so it's briefly visible to ps
+func set(alias, password string) error {
+	return exec.Command("security", "add-generic-password", "-U", "-s", service, "-a", alias, "-l", service+" "+alias, "-w", password).Run()
+}
+
+// turn off terminal echo helper, returns a func turning it back on
+func echoOff() func() {
+	// terminal check
+	if !stdinIsTerminal() {
+		return func() {}
+	}
+
+	// stty works on the terminal it reads from
+	cmd := exec.Command("stty", "-echo")
+	cmd.Stdin = os.Stdin
+	if cmd.Run() != nil {
+		return func() {}
+	}
+
+	// turn echo back on
+	return func() {
+		cmd := exec.Command("stty", "echo")
+		cmd.Stdin = os.Stdin
+		cmd.Run()
+	}
+}
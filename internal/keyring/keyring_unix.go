@@ -0,0 +1,71 @@
+// keyring_unix.go
+//go:build !darwin && !windows
+
+package keyring
+
+import (
+	// std go libs
+	"errors"  // exit errors
+	"fmt"     // wrapping errors
+	"os"      // stdin for stty
+	"os/exec" // running secret-tool and stty
+	"strings" // passing the password on stdin
+)
+
+// get a password from the Secret Service (gnome-keyring, kwallet...), with secret-tool
+func get(alias string) (string, error) {
+	// look up the secret by its attributes
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "alias", alias).Output()
+
+	// not found check (secret-tool exits 1 without output for missing items)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) == 0 && len(exitErr.Stderr) == 0 {
+		return "", ErrNotFound
+	}
+
+	// secret-tool check
+	if err != nil {
+		return "", toolHint(err)
+	}
+
+	// return the password (secret-tool adds no newline when piped)
+	return string(out), nil
+}
+
+// store a password in the Secret Service, secret-tool reads it from stdin
+func set(alias, password string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+alias, "service", service, "alias", alias)
+	cmd.Stdin = strings.NewReader(password)
+	return toolHint(cmd.Run())
+}
+
+// missing secret-tool helper, says which package has it
+func toolHint(err error) error {
+	// not installed check
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w (install libsecret-tools, or libsecret on fedora and arch)", err)
+	}
+	return err
+}
+
+// turn off terminal echo helper, returns a func turning it back on
+func echoOff() func() {
+	// terminal check
+	if !stdinIsTerminal() {
+		return func() {}
+	}
+
+	// stty works on the terminal it reads from
+	cmd := exec.Command("stty", "-echo")
+	cmd.Stdin = os.Stdin
+	if cmd.Run() != nil {
+		return func() {}
+	}
+
+	// turn echo back on
+	return func() {
+		cmd := exec.Command("stty", "echo")
+		cmd.Stdin = os.Stdin
+		cmd.Run()
+	}
+}
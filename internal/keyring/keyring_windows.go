@@ -0,0 +1,137 @@
+// keyring_windows.go
+//go:build windows
+
+package keyring
+
+import (
+	// std go libs
+	"errors"        // not found errors
+	"os"            // stdin handle
+	"syscall"       // calling the credential manager
+	"unicode/utf16" // passwords are stored as utf-16
+	"unsafe"        // credential structs
+)
+
+// windows api procs (advapi32 for credentials, kernel32 for the console)
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+// credential manager constants
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = 1168 // ERROR_NOT_FOUND
+	enableEchoInput         = 0x4
+)
+
+// CREDENTIALW, as in wincred.h
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credential target name helper, eg gator:prod
+func target(alias string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(service + ":" + alias)
+}
+
+// get a password from the Windows Credential Manager
+func get(alias string) (string, error) {
+	// target name
+	name, err := target(alias)
+	if err != nil {
+		return "", err
+	}
+
+	// read the generic credential
+	var cred *credential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+
+	// read check
+	if ret == 0 {
+		var errno syscall.Errno
+		if errors.As(err, &errno) && errno == errorNotFound {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	// decode the utf-16 blob
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	chars := make([]uint16, len(blob)/2)
+	for i := range chars {
+		chars[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+	}
+
+	// return the password
+	return string(utf16.Decode(chars)), nil
+}
+
+// store a password in the Windows Credential Manager, replacing any previous one
+func set(alias, password string) error {
+	// target name
+	name, err := target(alias)
+	if err != nil {
+		return err
+	}
+
+	// encode the password as utf-16 bytes (what the credential manager ui shows)
+	chars := utf16.Encode([]rune(password))
+	blob := make([]byte, 2*len(chars))
+	for i, char := range chars {
+		blob[2*i] = byte(char)
+		blob[2*i+1] = byte(char >> 8)
+	}
+
+	// write the generic credential
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+	}
+	ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+
+	// write check
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// turn off console echo helper, returns a func turning it back on
+func echoOff() func() {
+	// console check
+	handle := os.Stdin.Fd()
+	var mode uint32
+	ret, _, _ := procGetConsoleMode.Call(handle, uintptr(unsafe.Pointer(&mode)))
+	if ret == 0 {
+		return func() {}
+	}
+
+	// turn echo off, then back on
+	procSetConsoleMode.Call(handle, uintptr(mode&^enableEchoInput))
+	return func() {
+		procSetConsoleMode.Call(handle, uintptr(mode))
+	}
+}
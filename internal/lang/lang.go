@@ -0,0 +1,168 @@
+// lang.go
+package lang
+
+import (
+	// std go libraries
+	"regexp"  // stripping html tags
+	"strings" // splitting words
+	"unicode" // scripts and letters
+)
+
+// package-wide constants
+const (
+	minHits   = 2   // a latin text needs at least this many stopwords to be called
+	minMargin = 1.5 // and the winner needs this many times the runner up's hits
+	minScript = 0.3 // share of letters in a non-latin script to call its language
+)
+
+// html tags in descriptions, their attribute names aren't words
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// a language code: 2 or 3 letters, optionally a region/script ("en", "en-US", "zh_Hant")
+var codePattern = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[-_][a-zA-Z0-9]{2,8})*$`)
+
+// the most common short words of each latin-script language (ISO 639-1)
+// words two languages share ("de", "la", "en") are left out of both where possible
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on", "are", "this", "you", "be", "at", "by", "from", "have", "not", "what", "how", "why", "new"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "sich", "auf", "für", "ein", "eine", "dem", "den", "auch", "wie", "wird", "bei", "nach", "aus", "oder", "über", "neue", "zum", "zur"},
+	"fr": {"le", "les", "et", "est", "une", "des", "du", "pour", "dans", "qui", "que", "sur", "pas", "avec", "sont", "ce", "au", "aux", "mais", "nous", "vous", "ou", "cette", "nouveau", "plus"},
+	"es": {"el", "los", "las", "y", "es", "del", "que", "por", "con", "para", "una", "su", "al", "lo", "como", "más", "pero", "sus", "le", "ya", "entre", "cuando", "muy", "sin", "sobre"},
+	"it": {"il", "gli", "e", "è", "di", "che", "per", "con", "della", "delle", "del", "non", "una", "sono", "nel", "alla", "anche", "come", "più", "ma", "questo", "dal", "nuovo", "tra", "ha"},
+	"pt": {"o", "os", "as", "e", "é", "do", "da", "dos", "das", "que", "em", "para", "com", "uma", "não", "no", "na", "por", "mais", "ao", "seu", "sua", "como", "mas", "novo"},
+	"nl": {"het", "een", "van", "en", "is", "dat", "op", "te", "zijn", "voor", "met", "niet", "aan", "er", "ook", "als", "bij", "door", "naar", "maar", "wordt", "nieuwe", "om", "uit", "deze"},
+	"sv": {"och", "att", "det", "som", "är", "på", "för", "med", "av", "till", "inte", "om", "har", "den", "ett", "jag", "var", "men", "från", "nya", "kan", "sig", "vid", "så", "efter"},
+	"da": {"og", "at", "det", "er", "på", "til", "med", "af", "for", "ikke", "den", "som", "har", "et", "fra", "men", "kan", "vil", "nye", "eller", "efter", "også", "når", "hvor", "blev"},
+	"pl": {"i", "w", "się", "nie", "na", "jest", "do", "że", "z", "to", "jak", "o", "dla", "od", "po", "ale", "czy", "tak", "jego", "już", "przez", "przy", "nowy", "są", "oraz"},
+	"tr": {"ve", "bir", "bu", "için", "ile", "da", "de", "çok", "olarak", "gibi", "daha", "en", "ne", "ama", "olan", "yeni", "kadar", "sonra", "her", "değil", "mı", "mi", "nasıl", "neden", "var"},
+	"fi": {"ja", "on", "ei", "se", "että", "oli", "ovat", "kuin", "mutta", "myös", "tai", "sen", "hän", "kun", "joka", "uusi", "tämä", "ole", "jo", "voi", "vain", "niin", "sekä", "miten", "nyt"},
+}
+
+// a script and the language its letters mean (when they make up enough of the text)
+type script struct {
+	table *unicode.RangeTable
+	code  string
+}
+
+// non-latin scripts, kana before han so japanese (which mixes both) isn't called chinese
+var scripts = []script{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// word -> the languages it's a stopword of, built once
+var index = map[string][]string{}
+
+// build the index
+func init() {
+	for code, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], code)
+		}
+	}
+}
+
+// detect the language of a text (a post's title and description, html is fine)
+// returns an ISO 639-1 code, or "" when there's too little to tell
+func Detect(text string) string {
+	// strip the html, it's not language
+	text = tagPattern.ReplaceAllString(text, " ")
+
+	// non-latin scripts give the language away
+	if code := detectScript(text); code != "" {
+		return code
+	}
+
+	// count each language's stopwords
+	hits := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, code := range index[word] {
+			hits[code]++
+		}
+	}
+
+	// find the winner and the runner up (ties go to the smaller code, so it's stable)
+	best, bestHits, secondHits := "", 0, 0
+	for code, n := range hits {
+		switch {
+		case n > bestHits || (n == bestHits && code < best):
+			best, bestHits, secondHits = code, n, bestHits
+		case n > secondHits:
+			secondHits = n
+		}
+	}
+
+	// confidence check
+	if bestHits < minHits || float64(bestHits) < minMargin*float64(secondHits) {
+		return ""
+	}
+	return best
+}
+
+// non-latin script helper, the language of the script most of the letters are in
+// "" if the text is mostly latin (or has no letters)
+func detectScript(text string) string {
+	letters := 0
+	counts := map[string]int{}
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.code]++
+				break
+			}
+		}
+	}
+
+	// no letters check
+	if letters == 0 {
+		return ""
+	}
+
+	// any kana at all means japanese (its kanji would count as chinese otherwise)
+	if counts["ja"] > 0 && float64(counts["ja"]+counts["zh"]) >= minScript*float64(letters) {
+		return "ja"
+	}
+
+	// ukrainian has letters russian doesn't
+	if counts["ru"] > 0 && strings.ContainsAny(strings.ToLower(text), "іїєґ") {
+		counts["uk"], counts["ru"] = counts["ru"], 0
+	}
+
+	// the biggest script, if it's enough of the text
+	best, bestCount := "", 0
+	for code, n := range counts {
+		if n > bestCount || (n == bestCount && code < best) {
+			best, bestCount = code, n
+		}
+	}
+	if float64(bestCount) < minScript*float64(letters) {
+		return ""
+	}
+	return best
+}
+
+// normalize a language code to its lowercase primary subtag ("en-US" -> "en")
+// returns "" if it isn't a language code
+func Normalize(code string) string {
+	match := codePattern.FindStringSubmatch(strings.TrimSpace(code))
+
+	// not a code check
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
@@ -0,0 +1,102 @@
+// llm.go
+package llm
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"encoding/json" // the chat completions api
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"net/http"      // calling the api
+	"strings"       // urls and error bodies
+	"time"          // request timeout
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// package-wide constants
+const (
+	DefaultURL     = "https://api.openai.com/v1"
+	DefaultModel   = "gpt-4o-mini"
+	requestTimeout = 60 * time.Second // how long a completion may take (local models are slow)
+)
+
+// shared client for completions, via the http config's proxy and user agent
+func client() *http.Client {
+	return rssfeed.Service(requestTimeout)
+}
+
+// how to reach an openai-compatible chat completions api (openai, ollama, llama.cpp, vllm...)
+type Options struct {
+	URL    string // the api's base url ("" = DefaultURL), eg http://localhost:11434/v1 for ollama
+	APIKey string // "" = none (fine for local servers)
+	Model  string // "" = DefaultModel
+}
+
+// run a chat completion, a system prompt and a user message, and return the reply
+func Complete(ctx context.Context, opts Options, system, user string) (string, error) {
+	// defaults
+	if opts.URL == "" {
+		opts.URL = DefaultURL
+	}
+	if opts.Model == "" {
+		opts.Model = DefaultModel
+	}
+
+	// encode the request
+	body, err := json.Marshal(map[string]any{
+		"model": opts.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+		"temperature": 0,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding completion request: %w", err)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(opts.URL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("error creating completion request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.APIKey)
+	}
+
+	// send it
+	res, err := client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling %s: %w", opts.URL, err)
+	}
+	defer res.Body.Close()
+
+	// status check, with the start of the body as apis explain errors there
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return "", fmt.Errorf("error: %s returned %s: %s", opts.URL, res.Status, strings.TrimSpace(string(detail)))
+	}
+
+	// decode the reply
+	var reply struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&reply)
+	if err != nil {
+		return "", fmt.Errorf("error decoding completion reply: %w", err)
+	}
+
+	// no reply check
+	if len(reply.Choices) == 0 {
+		return "", fmt.Errorf("error: %s sent no reply", opts.URL)
+	}
+	return strings.TrimSpace(reply.Choices[0].Message.Content), nil
+}
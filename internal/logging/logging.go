@@ -0,0 +1,127 @@
+// logging.go
+package logging
+
+import (
+	// std go libraries
+	"fmt"      // printing errors
+	"io"       // log writer
+	"log/slog" // structured logging
+	"strings"  // parsing level names
+)
+
+// extra level below debug for -vv (http details, every post)
+const LevelTrace = slog.Level(-8)
+
+// the logger's level, a LevelVar so it can change while running (config reloads)
+var level slog.LevelVar
+
+// set by the -v/-q flags, they win over the config's log_level
+var pinned bool
+
+// pick the log level from the verbosity flags
+// --quiet = warnings and errors only, -v = debug, -vv = trace
+func Level(verbosity int, quiet bool) slog.Level {
+	// quiet wins over verbose
+	if quiet {
+		return slog.LevelWarn
+	}
+
+	// match verbosity count to a level
+	switch {
+	case verbosity >= 2:
+		return LevelTrace
+	case verbosity == 1:
+		return slog.LevelDebug
+	}
+
+	// default level
+	return slog.LevelInfo
+}
+
+// create a logger writing to w, and make it the slog default
+// format is "text" (key=value) or "json" (one object per line)
+func Setup(w io.Writer, lvl slog.Level, format string) (*slog.Logger, error) {
+	// set the level (changeable later, see SetConfigLevel)
+	level.Set(lvl)
+
+	// handler options
+	opts := &slog.HandlerOptions{
+		Level:     &level,
+		AddSource: lvl <= LevelTrace, // source file:line only at -vv
+		// name our custom level, otherwise it prints as DEBUG-4
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && a.Value.Any() == LevelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	}
+
+	// create handler for the format
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("error: unknown log format %q (use text or json)", format)
+	}
+
+	// create the logger and make it the default for slog.Info() etc
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	// return the logger
+	return logger, nil
+}
+
+// keep the level from the -v/-q flags, ignoring the config's log_level
+func Pin() {
+	pinned = true
+}
+
+// set the level from the config's log_level ("" = info), unless -v/-q pinned it
+func SetConfigLevel(name string) error {
+	// pinned check
+	if pinned {
+		return nil
+	}
+
+	// parse the name
+	lvl, err := ParseLevel(name)
+
+	// level check
+	if err != nil {
+		return err
+	}
+
+	// set it (takes effect on the next log line)
+	level.Set(lvl)
+	return nil
+}
+
+// the current level
+func CurrentLevel() slog.Level {
+	return level.Level()
+}
+
+// parse a level name: trace, debug, info, warn or error ("" = info)
+func ParseLevel(name string) (slog.Level, error) {
+	// match the name
+	switch strings.ToLower(name) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+
+	// unknown level
+	return 0, fmt.Errorf("error: unknown log level %q (use trace, debug, info, warn or error)", name)
+}
@@ -0,0 +1,186 @@
+// mail.go
+package mail
+
+import (
+	// std go libraries
+	"bytes"                // building the message
+	"crypto/rand"          // mime boundaries
+	"crypto/tls"           // starttls and implicit tls
+	"encoding/hex"         // mime boundaries
+	"fmt"                  // printing errors
+	"mime"                 // encoding the subject
+	"mime/quotedprintable" // encoding the bodies
+	"net"                  // dialing the server
+	"net/mail"             // parsing addresses
+	"net/smtp"             // talking to the server
+	"strings"              // header values
+	"time"                 // Date header and dial timeout
+)
+
+// package-wide constants
+const dialTimeout = 30 * time.Second // how long connecting to the smtp server may take
+
+// how to connect to the smtp server
+const (
+	TLSStartTLS = "starttls" // plain connection upgraded with STARTTLS (port 587), the default
+	TLSImplicit = "tls"      // tls from the start (port 465)
+	TLSNone     = "none"     // no tls at all, only sensible for a local relay
+)
+
+// smtp server settings, from the config's smtp section
+type Options struct {
+	Addr     string // host:port of the server
+	Username string // "" = no auth
+	Password string
+	From     string // sender address, eg "Gator <gator@example.com>"
+	TLS      string // TLSStartTLS (default), TLSImplicit or TLSNone
+}
+
+// an email to send, with html and plain text bodies
+type Message struct {
+	To          string
+	Subject     string
+	HTML        string
+	Text        string
+	Unsubscribe string // url for List-Unsubscribe, with one-click unsubscribe (optional)
+}
+
+// check an email address helper, returning it without a display name
+func ParseAddress(address string) (string, error) {
+	// parse check
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("error: invalid email address %q", address)
+	}
+	return parsed.Address, nil
+}
+
+// send a message through the smtp server
+func Send(opts Options, msg Message) error {
+	// sender check
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return fmt.Errorf("error: invalid smtp.from %q", opts.From)
+	}
+
+	// build the message
+	body, err := build(opts.From, msg)
+	if err != nil {
+		return err
+	}
+
+	// connect
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return fmt.Errorf("error: invalid smtp.addr %q (use host:port)", opts.Addr)
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	if opts.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", opts.Addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", opts.Addr)
+	}
+
+	// dial check
+	if err != nil {
+		return fmt.Errorf("error connecting to smtp server %s: %w", opts.Addr, err)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error talking to smtp server %s: %w", opts.Addr, err)
+	}
+	defer client.Close()
+
+	// starttls check (the default, refusing servers that can't)
+	if opts.TLS == "" || opts.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("error: smtp server %s doesn't support STARTTLS (set smtp.tls to tls or none)", opts.Addr)
+		}
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return fmt.Errorf("error starting tls with %s: %w", opts.Addr, err)
+		}
+	}
+
+	// auth check
+	if opts.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", opts.Username, opts.Password, host))
+		if err != nil {
+			return fmt.Errorf("error logging in to smtp server %s: %w", opts.Addr, err)
+		}
+	}
+
+	// send it
+	err = client.Mail(from.Address)
+	if err != nil {
+		return fmt.Errorf("error sending mail from %s: %w", from.Address, err)
+	}
+	err = client.Rcpt(msg.To)
+	if err != nil {
+		return fmt.Errorf("error sending mail to %s: %w", msg.To, err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	_, err = writer.Write(body)
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+
+	// say goodbye (a failing QUIT doesn't unsend it)
+	client.Quit()
+	return nil
+}
+
+// build the raw message helper, a multipart/alternative with the text and html bodies
+func build(from string, msg Message) ([]byte, error) {
+	// random boundary
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("error creating mime boundary: %w", err)
+	}
+	boundary := "gator-" + hex.EncodeToString(random)
+
+	// headers
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@gator>", hex.EncodeToString(random)))
+	header("MIME-Version", "1.0")
+	if msg.Unsubscribe != "" {
+		header("List-Unsubscribe", "<"+msg.Unsubscribe+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+
+	// the bodies, plain text first (clients show the last one they understand)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writer := quotedprintable.NewWriter(&buf)
+		writer.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n")))
+		writer.Close()
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	// return the message
+	return buf.Bytes(), nil
+}
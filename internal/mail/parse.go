@@ -0,0 +1,160 @@
+// parse.go
+package mail
+
+import (
+	// std go libraries
+	"bytes"                // reading the message
+	"crypto/sha256"        // ids of messages without a Message-ID
+	"encoding/base64"      // base64 bodies
+	"encoding/hex"         // ids of messages without a Message-ID
+	"fmt"                  // printing errors
+	"io"                   // reading the parts
+	"mime"                 // content types and encoded words
+	"mime/multipart"       // multipart bodies
+	"mime/quotedprintable" // quoted-printable bodies
+	"net/mail"             // parsing the message
+	"strings"              // header values
+	"time"                 // Date header
+	"unicode/utf8"         // latin-1 bodies
+)
+
+// most parts looked at in a message, so a crafted one can't nest forever
+const maxParts = 100
+
+// a received email, as much of it as a post needs
+type Received struct {
+	MessageID string    // without the <>, a hash of the message if it has none
+	From      string    // the sender, display name and address
+	Subject   string    // decoded
+	Date      time.Time // zero if missing or unparsable
+	HTML      string    // the html body, "" if there's none
+	Text      string    // the plain text body, "" if there's none
+}
+
+// parse a received email, picking its html and plain text bodies out of any multipart nesting
+func Parse(data []byte) (Received, error) {
+	// parse check
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return Received{}, fmt.Errorf("error parsing email: %w", err)
+	}
+
+	// the headers
+	var decoder mime.WordDecoder
+	received := Received{MessageID: strings.Trim(strings.TrimSpace(msg.Header.Get("Message-ID")), "<>")}
+	if received.MessageID == "" {
+		sum := sha256.Sum256(data)
+		received.MessageID = hex.EncodeToString(sum[:16])
+	}
+	received.Subject, err = decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		received.Subject = msg.Header.Get("Subject")
+	}
+	received.Subject = strings.Join(strings.Fields(received.Subject), " ")
+	if from, err := msg.Header.AddressList("From"); err == nil && len(from) > 0 {
+		received.From = from[0].String()
+		if from[0].Name != "" {
+			received.From = from[0].Name
+		}
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		received.Date = date.UTC()
+	}
+
+	// the bodies
+	parts := 0
+	err = readPart(msg.Header, msg.Body, &received, &parts)
+	if err != nil {
+		return Received{}, err
+	}
+	return received, nil
+}
+
+// read one part of a message into received helper, recursing into multiparts
+// NOTE: the first html and plain text parts win, attachments are skipped
+func readPart(header map[string][]string, body io.Reader, received *Received, parts *int) error {
+	// too many parts check
+	*parts++
+	if *parts > maxParts {
+		return nil
+	}
+
+	// attachment check
+	get := func(key string) string {
+		if values := header[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	if disposition, _, err := mime.ParseMediaType(get("Content-Disposition")); err == nil && disposition == "attachment" {
+		return nil
+	}
+
+	// the content type, plain text without one
+	mediaType, params, err := mime.ParseMediaType(get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	// multipart check, read each part
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("error reading email part: %w", err)
+			}
+			err = readPart(part.Header, part, received, parts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// text parts only, the first of each kind
+	if (mediaType != "text/html" || received.HTML != "") && (mediaType != "text/plain" || received.Text != "") {
+		return nil
+	}
+
+	// undo the transfer encoding
+	switch strings.ToLower(strings.TrimSpace(get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body) // skips line breaks itself
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("error reading email body: %w", err)
+	}
+
+	// latin-1 check, the only charset besides utf-8 converted (others are kept as they are)
+	text := string(content)
+	switch strings.ToLower(params["charset"]) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		text = latin1(content)
+	}
+	if mediaType == "text/html" {
+		received.HTML = text
+	} else {
+		received.Text = text
+	}
+	return nil
+}
+
+// latin-1 to utf-8 helper
+func latin1(content []byte) string {
+	var b strings.Builder
+	b.Grow(len(content))
+	for _, c := range content {
+		if c < utf8.RuneSelf {
+			b.WriteByte(c)
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,219 @@
+// receive.go
+package mail
+
+import (
+	// std go libraries
+	"context"       // stopping the server
+	"errors"        // matching net.ErrClosed
+	"fmt"           // replies
+	"io"            // reading the data
+	"log/slog"      // connection errors
+	"net"           // the listener
+	"net/mail"      // parsing addresses
+	"net/textproto" // the smtp dialogue
+	"strings"       // commands
+	"sync"          // waiting for connections
+	"time"          // idle timeouts
+)
+
+// receiving limits
+const (
+	DefaultMaxBytes = 10 << 20         // biggest message accepted without MaxBytes, newsletters with inline images fit
+	maxRecipients   = 100              // recipients per message
+	idleTimeout     = 5 * time.Minute  // a client silent this long is dropped
+	sessionTimeout  = 30 * time.Minute // whole connection, so none is held open forever
+)
+
+// a minimal smtp server receiving mail for some addresses, for newsletters
+// NOTE: no tls and no auth, so it's meant for a port the mx (or a relay) forwards to
+type Receiver struct {
+	Hostname string                                       // in the greeting ("" = gator)
+	MaxBytes int64                                        // biggest message taken (0 = DefaultMaxBytes)
+	Accept   func(recipient string) bool                  // whether mail for a (lower-case) address is taken
+	Deliver  func(recipients []string, data []byte) error // store a message, an error makes the sender retry later
+}
+
+// serve smtp on the listener until ctx is done, then wait for open connections to finish
+func (rc *Receiver) Serve(ctx context.Context, listener net.Listener) error {
+	// close the listener when stopping, which ends Accept below
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	// serve each connection
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+
+		// accept check
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("error accepting smtp connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			rc.session(conn)
+		}()
+	}
+}
+
+// one smtp session helper
+func (rc *Receiver) session(conn net.Conn) {
+	// defaults
+	hostname := rc.Hostname
+	if hostname == "" {
+		hostname = "gator"
+	}
+	maxBytes := rc.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	// the dialogue, with deadlines
+	text := textproto.NewConn(conn)
+	deadline := time.Now().Add(sessionTimeout)
+	reply := func(format string, args ...any) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 %s ESMTP gator", hostname) {
+		return
+	}
+
+	// the message being received
+	from, recipients := "", []string(nil)
+	for {
+		idle := time.Now().Add(idleTimeout)
+		if idle.After(deadline) {
+			idle = deadline
+		}
+		conn.SetDeadline(idle)
+		line, err := text.ReadLine()
+
+		// read check (client gone or timed out)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				slog.Debug("smtp connection closed", "remote", conn.RemoteAddr(), "err", err)
+			}
+			return
+		}
+
+		// the command and its argument
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			from, recipients = "", nil
+			reply("250 %s", hostname)
+		case "EHLO":
+			from, recipients = "", nil
+			reply("250-%s\r\n250-SIZE %d\r\n250-8BITMIME\r\n250 PIPELINING", hostname, maxBytes)
+		case "MAIL":
+			// sender, any (bounces have none)
+			address, ok := pathArg(arg, "FROM:")
+			if !ok {
+				reply("501 syntax: MAIL FROM:<address>")
+				continue
+			}
+			from, recipients = address, nil
+			if from == "" {
+				from = "<>"
+			}
+			reply("250 OK")
+		case "RCPT":
+			// recipient, only the ones taken
+			address, ok := pathArg(arg, "TO:")
+			switch {
+			case from == "":
+				reply("503 MAIL first")
+			case !ok || address == "":
+				reply("501 syntax: RCPT TO:<address>")
+			case len(recipients) >= maxRecipients:
+				reply("452 too many recipients")
+			case !rc.Accept(strings.ToLower(address)):
+				reply("550 no such mailbox: %s", address)
+			default:
+				recipients = append(recipients, strings.ToLower(address))
+				reply("250 OK")
+			}
+		case "DATA":
+			// the message, ending with a lone dot
+			if len(recipients) == 0 {
+				reply("503 RCPT first")
+				continue
+			}
+			if !reply("354 end with <CR><LF>.<CR><LF>") {
+				return
+			}
+			dot := text.DotReader()
+			data, err := io.ReadAll(io.LimitReader(dot, maxBytes+1))
+			if err != nil {
+				return
+			}
+
+			// too big check (the rest is read too, so the dialogue goes on)
+			if int64(len(data)) > maxBytes {
+				io.Copy(io.Discard, dot)
+				reply("552 message is bigger than %d bytes", maxBytes)
+			} else if err := rc.Deliver(recipients, data); err != nil {
+				slog.Error("error storing received mail", "from", from, "err", err)
+				reply("451 error storing the message, try again later")
+			} else {
+				reply("250 OK")
+			}
+			from, recipients = "", nil
+		case "RSET":
+			from, recipients = "", nil
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "VRFY":
+			reply("252 send some mail and see")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 %s isn't supported", verb)
+		}
+	}
+}
+
+// parse a MAIL FROM:<address> or RCPT TO:<address> argument helper, "" for <>
+// NOTE: esmtp parameters after the address (SIZE=, BODY=) are ignored
+func pathArg(arg, prefix string) (string, bool) {
+	// prefix check
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+
+	// the <address> part
+	if !strings.HasPrefix(path, "<") {
+		path, _, _ = strings.Cut(path, " ")
+		path = "<" + path + ">"
+	}
+	end := strings.Index(path, ">")
+	if end < 0 {
+		return "", false
+	}
+	address := path[1:end]
+	if address == "" {
+		return "", true
+	}
+
+	// source routes check (<@relay:user@host> is still user@host)
+	if i := strings.LastIndex(address, ":"); strings.HasPrefix(address, "@") && i > 0 {
+		address = address[i+1:]
+	}
+
+	// address check
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", false
+	}
+	return parsed.Address, true
+}
@@ -0,0 +1,339 @@
+// migrate.go
+package migrate
+
+import (
+	// std go libraries
+	"context"      // for context
+	"database/sql" // running migrations
+	"fmt"          // printing errors
+	"io/fs"        // reading the embedded migration files
+	"path"         // file names
+	"sort"         // ordering migrations
+	"strconv"      // parsing versions
+	"strings"      // splitting up/down sections
+	"time"         // applied at
+
+	// external packages
+	"github.com/lib/pq" // quoting the schema name
+)
+
+// package-wide constants
+// NOTE: same table goose uses, so databases migrated with the goose CLI keep working!
+const versionTable = "goose_db_version"
+
+// migration struct, one per NNN_name.sql file
+type Migration struct {
+	Version int64  // NNN prefix of the file name
+	Name    string // file name
+	Up      string // sql under -- +goose Up
+	Down    string // sql under -- +goose Down
+}
+
+// migration status struct, for migrate status
+type Status struct {
+	Migration Migration
+	Applied   bool         // applied to the database?
+	AppliedAt sql.NullTime // when it was applied (NULL if not)
+}
+
+// load and parse all .sql migrations in fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	// find the migration files
+	names, err := fs.Glob(fsys, "*.sql")
+
+	// glob check
+	if err != nil {
+		return nil, fmt.Errorf("error listing migrations: %w", err)
+	}
+
+	// create nil slice for the migrations
+	var migrations []Migration
+
+	// parse each file
+	for _, name := range names {
+		// read the file
+		data, err := fs.ReadFile(fsys, name)
+
+		// read check
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", name, err)
+		}
+
+		// parse the file using helper
+		migration, err := parse(path.Base(name), string(data))
+
+		// parse check
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+
+	// order by version (file names sort the same, but be explicit!)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	// return the migrations
+	return migrations, nil
+}
+
+// apply all pending migrations, returns the ones applied
+func Up(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	// get the applied versions
+	applied, err := appliedVersions(ctx, db)
+
+	// applied check
+	if err != nil {
+		return nil, err
+	}
+
+	// create nil slice for the newly applied migrations
+	var done []Migration
+
+	// apply each pending migration in order
+	for _, migration := range migrations {
+		// already applied check
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		// run the up sql and record the version (in one transaction)
+		err := run(ctx, db, migration.Up, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, true)`, migration.Version)
+
+		// run check
+		if err != nil {
+			return done, fmt.Errorf("error applying migration %s: %w", migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	// return the applied migrations
+	return done, nil
+}
+
+// roll back the latest applied migration, returns it (nil if nothing to roll back)
+func Down(ctx context.Context, db *sql.DB, migrations []Migration) (*Migration, error) {
+	// get the applied versions
+	applied, err := appliedVersions(ctx, db)
+
+	// applied check
+	if err != nil {
+		return nil, err
+	}
+
+	// find the latest applied migration (walk backwards)
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+
+		// not applied check
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		// run the down sql and remove the version (in one transaction)
+		err := run(ctx, db, migration.Down, `DELETE FROM `+versionTable+` WHERE version_id = $1`, migration.Version)
+
+		// run check
+		if err != nil {
+			return nil, fmt.Errorf("error rolling back migration %s: %w", migration.Name, err)
+		}
+		return &migration, nil
+	}
+
+	// nothing to roll back
+	return nil, nil
+}
+
+// get the status of every migration
+func GetStatus(ctx context.Context, db *sql.DB, migrations []Migration) ([]Status, error) {
+	// get the applied versions
+	applied, err := appliedVersions(ctx, db)
+
+	// applied check
+	if err != nil {
+		return nil, err
+	}
+
+	// match each migration to its applied version
+	statuses := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+
+	// return the statuses
+	return statuses, nil
+}
+
+// create the instance's schema if it doesn't exist yet (before the first Up)
+// NOTE: the connection's search_path already points at it, but postgres won't create it
+func EnsureSchema(ctx context.Context, db *sql.DB, schema string) error {
+	// create the schema
+	_, err := db.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS `+pq.QuoteIdentifier(schema))
+
+	// create check
+	if err != nil {
+		return fmt.Errorf("error creating schema %s: %w", schema, err)
+	}
+
+	// return success
+	return nil
+}
+
+// version helper, the latest applied version (0 = none)
+func Version(ctx context.Context, db *sql.DB) (int64, error) {
+	// get the applied versions
+	applied, err := appliedVersions(ctx, db)
+
+	// applied check
+	if err != nil {
+		return 0, err
+	}
+
+	// find the highest one
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// parse a goose style migration file helper
+func parse(name, contents string) (Migration, error) {
+	// version is the numeric prefix, eg 001_users.sql
+	prefix, _, found := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+
+	// version check
+	if !found || err != nil {
+		return Migration{}, fmt.Errorf("error: migration %s has no numeric version prefix", name)
+	}
+
+	// split the file into its up and down sections
+	migration := Migration{Version: version, Name: name}
+	var up, down strings.Builder
+	var section *strings.Builder // section we're currently reading into (nil = before -- +goose Up)
+
+	// read line by line
+	for _, line := range strings.Split(contents, "\n") {
+		// section annotation check
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = &up
+			continue
+		case "-- +goose Down":
+			section = &down
+			continue
+		case "-- +goose StatementBegin", "-- +goose StatementEnd":
+			continue // we run each section as one script anyway
+		}
+
+		// write line to its section
+		if section != nil {
+			section.WriteString(line + "\n")
+		}
+	}
+
+	// up section check (a migration that does nothing is a mistake)
+	migration.Up = strings.TrimSpace(up.String())
+	migration.Down = strings.TrimSpace(down.String())
+	if migration.Up == "" {
+		return Migration{}, fmt.Errorf("error: migration %s has no -- +goose Up section", name)
+	}
+
+	// return the migration
+	return migration, nil
+}
+
+// run a migration script and its version bookkeeping in one transaction helper
+func run(ctx context.Context, db *sql.DB, script, bookkeeping string, version int64) error {
+	// begin the transaction
+	tx, err := db.BeginTx(ctx, nil)
+
+	// begin check
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op after commit
+
+	// run the script (lib/pq runs multiple statements without args in one go)
+	if script != "" {
+		_, err = tx.ExecContext(ctx, script)
+		if err != nil {
+			return err
+		}
+	}
+
+	// record the version change
+	_, err = tx.ExecContext(ctx, bookkeeping, version)
+	if err != nil {
+		return err
+	}
+
+	// commit
+	return tx.Commit()
+}
+
+// applied versions helper, creates the version table if it's missing
+// returns the applied versions mapped to when they were applied
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]sql.NullTime, error) {
+	// create the version table (same shape as goose's)
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+		id SERIAL PRIMARY KEY,
+		version_id BIGINT NOT NULL,
+		is_applied BOOLEAN NOT NULL,
+		tstamp TIMESTAMP DEFAULT NOW()
+	)`)
+
+	// create check
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s table: %w", versionTable, err)
+	}
+
+	// latest row per version decides if it's applied (older goose versions logged downs as rows)
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+		FROM `+versionTable+`
+		ORDER BY version_id, id DESC`)
+
+	// query check
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s table: %w", versionTable, err)
+	}
+	defer rows.Close()
+
+	// collect the applied versions (0 is goose's "empty database" marker, skip it)
+	applied := make(map[int64]sql.NullTime)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var appliedAt sql.NullTime
+
+		// scan check
+		if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, fmt.Errorf("error scanning %s row: %w", versionTable, err)
+		}
+
+		// applied check
+		if isApplied && version > 0 {
+			applied[version] = appliedAt
+		}
+	}
+
+	// return applied versions, and any iteration error
+	return applied, rows.Err()
+}
+
+// format a status's applied time for printing
+func (s Status) AppliedAtString() string {
+	// not applied check
+	if !s.Applied {
+		return "Pending"
+	}
+
+	// applied before we tracked times check
+	if !s.AppliedAt.Valid {
+		return "Applied"
+	}
+	return s.AppliedAt.Time.Format(time.DateTime)
+}
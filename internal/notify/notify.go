@@ -0,0 +1,109 @@
+// notify.go
+package notify
+
+import (
+	// std go libraries
+	"context"       // stopping the listener
+	"encoding/json" // notification payloads
+	"fmt"           // printing errors
+	"log/slog"      // structured logging
+	"time"          // reconnect backoff
+
+	// external packages
+	"github.com/google/uuid" // feed ids
+	"github.com/lib/pq"      // postgres LISTEN
+)
+
+// the channel agg NOTIFYs after storing new posts
+const channelName = "gator_new_posts"
+
+// listener reconnect backoff (lib/pq doubles from min up to max)
+const (
+	minReconnect = 10 * time.Second
+	maxReconnect = time.Minute
+)
+
+// payload of a new posts notification
+// NOTE: postgres caps payloads at 8000 bytes, so it names the feed instead of listing the posts
+type NewPosts struct {
+	FeedID   uuid.UUID `json:"feed_id"`
+	FeedName string    `json:"feed_name"`
+	FeedURL  string    `json:"feed_url"`
+	Count    int       `json:"count"`
+}
+
+// get the channel of a schema, so instances sharing a database don't hear each other
+// NOTE: notifications are per database, not per schema
+func Channel(schema string) string {
+	// default schema check
+	if schema == "" {
+		return channelName
+	}
+	return channelName + "_" + schema
+}
+
+// encode a notification payload
+func Payload(n NewPosts) (string, error) {
+	// marshal to json
+	data, err := json.Marshal(n)
+
+	// marshal check
+	if err != nil {
+		return "", fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	// return the payload
+	return string(data), nil
+}
+
+// LISTEN for new posts on channel and call fn for each notification, blocks until ctx is done
+// NOTE: uses its own connection (LISTEN can't share the sql.DB pool), reconnects on its own
+func Listen(ctx context.Context, dbURL, channel string, fn func(NewPosts)) error {
+	// log connection events, lib/pq reconnects by itself
+	events := func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("notification listener", "event", event, "err", err)
+		}
+	}
+
+	// create the listener
+	listener := pq.NewListener(dbURL, minReconnect, maxReconnect, events)
+	defer listener.Close()
+
+	// listen on our channel
+	err := listener.Listen(channel)
+
+	// listen check
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %w", channel, err)
+	}
+	slog.Debug("listening for notifications", "channel", channel)
+
+	// wait for notifications
+	for {
+		select {
+		case <-ctx.Done():
+			return nil // stopped, not an error
+		case notification := <-listener.Notify:
+			// reconnect check (nil = the connection was re-established, notifications may have been missed)
+			if notification == nil {
+				slog.Warn("notification listener reconnected, notifications may have been missed")
+				continue
+			}
+
+			// decode the payload
+			var n NewPosts
+			err := json.Unmarshal([]byte(notification.Extra), &n)
+
+			// decode check (skip, a bad payload shouldn't stop the listener)
+			if err != nil {
+				slog.Warn("invalid notification payload", "payload", notification.Extra, "err", err)
+				continue
+			}
+			fn(n)
+		case <-time.After(90 * time.Second):
+			// ping the connection now and then, so a dead one is noticed
+			go listener.Ping()
+		}
+	}
+}
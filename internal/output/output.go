@@ -0,0 +1,131 @@
+// output.go
+package output
+
+import (
+	// std go libraries
+	"encoding/csv"  // csv and tsv writing
+	"encoding/json" // json writing
+	"fmt"           // printing
+	"io"            // output writer
+	"time"          // formatting timestamps
+)
+
+// output format type (set with the --output global flag)
+type Format string
+
+// supported output formats
+const (
+	Text Format = "text" // human readable, each handler prints its own
+	JSON Format = "json" // array of objects, for jq
+	CSV  Format = "csv"  // comma separated, for spreadsheets
+	TSV  Format = "tsv"  // tab separated, for cut/awk
+)
+
+// table struct, what handlers hand over to be formatted
+type Table struct {
+	Columns []string // column names (json keys, csv header)
+	Rows    [][]any  // one slice of values per record, same order as Columns
+}
+
+// parse an --output flag value into a Format
+func ParseFormat(input string) (Format, error) {
+	// match input to a supported format
+	switch Format(input) {
+	case Text, JSON, CSV, TSV:
+		return Format(input), nil
+	}
+
+	// unsupported format
+	return "", fmt.Errorf("error: unknown output format %q (use text, json, csv or tsv)", input)
+}
+
+// add a record to the table
+func (t *Table) Add(values ...any) {
+	t.Rows = append(t.Rows, values)
+}
+
+// write the table to w in the given machine-readable format
+func Write(w io.Writer, format Format, table Table) error {
+	// pick the writer for the format
+	switch format {
+	case JSON:
+		return writeJSON(w, table)
+	case CSV:
+		return writeDelimited(w, table, ',')
+	case TSV:
+		return writeDelimited(w, table, '\t')
+	}
+
+	// text is printed by the handlers themselves
+	return fmt.Errorf("error: output format %q is not machine-readable", format)
+}
+
+// json writer helper, one object per row keyed by column name
+func writeJSON(w io.Writer, table Table) error {
+	// create records slice (not nil, so empty tables print [] not null!)
+	records := make([]map[string]any, 0, len(table.Rows))
+
+	// map each row's values to its columns
+	for _, row := range table.Rows {
+		record := make(map[string]any, len(table.Columns))
+		for i, column := range table.Columns {
+			record[column] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	// encode with indent (prettified, jq doesn't mind!)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// csv/tsv writer helper, header row then one line per row
+func writeDelimited(w io.Writer, table Table, delimiter rune) error {
+	// csv writer handles quoting for us
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	// write header
+	err := writer.Write(table.Columns)
+
+	// header check
+	if err != nil {
+		return fmt.Errorf("error writing header: %w", err)
+	}
+
+	// write rows
+	for _, row := range table.Rows {
+		// convert values to strings
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatValue(value)
+		}
+
+		// write row
+		err := writer.Write(record)
+
+		// row check
+		if err != nil {
+			return fmt.Errorf("error writing row: %w", err)
+		}
+	}
+
+	// flush the buffered rows to w
+	writer.Flush()
+	return writer.Error()
+}
+
+// value formatter helper for csv/tsv cells
+func formatValue(value any) string {
+	// format by type
+	switch v := value.(type) {
+	case nil:
+		return "" // NULLs are empty cells
+	case time.Time:
+		return v.Format(time.RFC3339) // same as json
+	}
+
+	// everything else prints as-is
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,95 @@
+// password.go
+package password
+
+import (
+	// std go libraries
+	"crypto/pbkdf2"   // the key derivation
+	"crypto/rand"     // salts
+	"crypto/sha256"   // pbkdf2's hash
+	"crypto/subtle"   // comparing hashes in constant time
+	"encoding/base64" // salts and keys in the stored hash
+	"fmt"             // printing errors
+	"strconv"         // parsing the iterations
+	"strings"         // splitting the stored hash
+)
+
+// hashing parameters, stored in each hash so they can be raised without breaking old ones
+// NOTE: pbkdf2 rather than bcrypt or argon2, as those need golang.org/x/crypto and pbkdf2 is in the standard library
+// (FIPS 140 approved); at OWASP's iteration count it's slower to crack than bcrypt's default cost, only without
+// argon2's memory hardness. serve only hashes once per login (see api's auth cache) and limits failed attempts
+const (
+	scheme     = "pbkdf2-sha256"
+	iterations = 600_000 // OWASP's recommendation for pbkdf2-sha256
+	saltLength = 16
+	keyLength  = 32
+)
+
+// hash a password for storing, as pbkdf2-sha256$<iterations>$<salt>$<key>
+func Hash(password string) (string, error) {
+	// random salt
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+
+	// salt check
+	if err != nil {
+		return "", fmt.Errorf("error generating salt: %w", err)
+	}
+
+	// derive the key
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, keyLength)
+
+	// derive check
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+
+	// return the encoded hash
+	encode := base64.RawStdEncoding.EncodeToString
+	return fmt.Sprintf("%s$%d$%s$%s", scheme, iterations, encode(salt), encode(key)), nil
+}
+
+// spend as long as Verify does on a password, without a hash to check it against
+// for unknown users and users without a password, so the response time doesn't tell them apart from real ones
+func VerifyNone(password string) {
+	pbkdf2.Key(sha256.New, password, noneSalt, iterations, keyLength)
+}
+
+// the salt VerifyNone hashes with, any salt takes as long
+var noneSalt = make([]byte, saltLength)
+
+// check a password against a stored hash
+// returns an error only for a malformed hash, a wrong password is false
+func Verify(password, hash string) (bool, error) {
+	// split the hash
+	parts := strings.Split(hash, "$")
+
+	// format check
+	if len(parts) != 4 || parts[0] != scheme {
+		return false, fmt.Errorf("error: unsupported password hash format")
+	}
+
+	// get the parameters
+	rounds, err := strconv.Atoi(parts[1])
+	if err != nil || rounds < 1 {
+		return false, fmt.Errorf("error: invalid password hash iterations %q", parts[1])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, fmt.Errorf("error: invalid password hash salt: %w", err)
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false, fmt.Errorf("error: invalid password hash key")
+	}
+
+	// derive the key of the given password
+	got, err := pbkdf2.Key(sha256.New, password, salt, rounds, len(want))
+
+	// derive check
+	if err != nil {
+		return false, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	// compare in constant time, so timing doesn't leak how much matched
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
@@ -0,0 +1,98 @@
+// plugins.go
+package plugins
+
+import (
+	// std go libraries
+	"context"       // cancelling plugins
+	"io"            // plugin output
+	"os"            // PATH and the plugin's stdin
+	"os/exec"       // running plugins
+	"path/filepath" // scanning PATH
+	"runtime"       // windows executables
+	"sort"          // ordering plugins
+	"strings"       // plugin names
+)
+
+// package-wide constants
+const Prefix = "aggregator-" // aggregator kindle runs aggregator-kindle
+
+// a plugin found on PATH
+type Plugin struct {
+	Name string // the command it adds, eg kindle
+	Path string // the executable
+}
+
+// what a plugin is run with, besides its args
+type Env struct {
+	Config string // the config file in use, as GATOR_CONFIG
+	User   string // the logged in user, as GATOR_USER ("" = nobody)
+	Output string // the --output format, as GATOR_OUTPUT
+	DBURL  string // the database url (schema and keyring password applied), as GATOR_DB_URL ("" = not set)
+}
+
+// find the plugin for a command name on PATH
+func Find(name string) (Plugin, bool) {
+	// names with a path in them would run something else than a plugin
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return Plugin{}, false
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return Plugin{}, false
+	}
+	return Plugin{Name: name, Path: path}, true
+}
+
+// list the plugins on PATH by name, the first one on PATH wins like the shell's
+func List() []Plugin {
+	seen := map[string]bool{}
+	var found []Plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // missing dirs on PATH are common
+		}
+		for _, entry := range entries {
+			// name check
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+
+			// executable check (LookPath knows each OS's rules)
+			path, err := exec.LookPath(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			seen[name] = true
+			found = append(found, Plugin{Name: name, Path: path})
+		}
+	}
+
+	// sort by name (PATH order only decides between equal names)
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
+
+// run a plugin with the command's args, wired to the cli's stdin and the given writers
+// NOTE: cancelling ctx (ctrl+c, --timeout) kills it
+func (p Plugin) Run(ctx context.Context, args []string, env Env, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, p.Path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// the environment, plus what the plugin needs to act like a built-in command
+	cmd.Env = append(os.Environ(),
+		"GATOR_CONFIG="+env.Config,
+		"GATOR_USER="+env.User,
+		"GATOR_OUTPUT="+env.Output,
+	)
+	if env.DBURL != "" {
+		cmd.Env = append(cmd.Env, "GATOR_DB_URL="+env.DBURL)
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,125 @@
+// progress.go
+package progress
+
+import (
+	// std go libraries
+	"fmt"     // printing
+	"io"      // progress writer
+	"os"      // tty check
+	"strings" // drawing the bar
+	"time"    // eta
+)
+
+// package-wide constants
+const (
+	barWidth     = 30               // bar width in chars on a tty
+	lineInterval = 10 * time.Second // time between plain lines when not a tty
+)
+
+// progress reporter struct, counts n of m items
+type Reporter struct {
+	w        io.Writer // where to draw, usually stderr
+	label    string    // what we count, eg "feeds"
+	total    int       // m
+	done     int       // n
+	tty      bool      // draw a bar (tty) or plain lines (pipe/file)
+	started  time.Time // for the eta
+	lastLine time.Time // last plain line written
+}
+
+// create a reporter for total items, drawing to w
+func New(w io.Writer, total int, label string) *Reporter {
+	// create reporter with start time
+	now := time.Now()
+	return &Reporter{
+		w:        w,
+		label:    label,
+		total:    total,
+		tty:      IsTerminal(w),
+		started:  now,
+		lastLine: now,
+	}
+}
+
+// check if w is a terminal (char device), without any extra deps
+func IsTerminal(w io.Writer) bool {
+	// only files can be terminals
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	// stat check
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	// terminals are char devices, pipes and files aren't
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// mark one item as done and report
+func (r *Reporter) Step() {
+	// nil reporter check (lets callers skip progress for small jobs)
+	if r == nil {
+		return
+	}
+
+	// count it
+	r.done++
+
+	// tty? redraw the bar on every step
+	if r.tty {
+		r.drawBar()
+		return
+	}
+
+	// not a tty? only write a line every so often (and at the end)
+	if time.Since(r.lastLine) >= lineInterval || r.done == r.total {
+		fmt.Fprintf(r.w, "progress: %d of %d %s%s\n", r.done, r.total, r.label, r.etaSuffix())
+		r.lastLine = time.Now()
+	}
+}
+
+// finish reporting (moves the tty cursor past the bar)
+func (r *Reporter) Done() {
+	// nil reporter check
+	if r == nil {
+		return
+	}
+
+	// end the bar's line
+	if r.tty {
+		fmt.Fprintln(r.w)
+	}
+}
+
+// bar drawing helper, \r redraws over the same line
+func (r *Reporter) drawBar() {
+	// filled part of the bar
+	filled := 0
+	if r.total > 0 {
+		filled = barWidth * r.done / r.total
+	}
+
+	// draw eg [=======>      ] 3 of 10 feeds (ETA 12s)
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	fmt.Fprintf(r.w, "\r[%s] %d of %d %s%s", bar, r.done, r.total, r.label, r.etaSuffix())
+}
+
+// eta helper, based on the average time per item so far
+func (r *Reporter) etaSuffix() string {
+	// nothing done or all done? no eta
+	if r.done == 0 || r.done >= r.total {
+		return ""
+	}
+
+	// average time per item, times the items left
+	perItem := time.Since(r.started) / time.Duration(r.done)
+	eta := perItem * time.Duration(r.total-r.done)
+	return fmt.Sprintf(" (ETA %s)", eta.Round(time.Second))
+}
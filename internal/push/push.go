@@ -0,0 +1,183 @@
+// push.go
+package push
+
+import (
+	// std go libraries
+	"bytes"         // ntfy request bodies
+	"context"       // cancelling requests
+	"encoding/json" // ntfy's json publishing
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"net/http"      // calling the services
+	"net/url"       // topic urls and pushover forms
+	"strings"       // topic paths
+	"time"          // request timeout
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// the push services notifications can go to
+const (
+	Ntfy     = "ntfy"     // ntfy.sh or a self-hosted ntfy server, target = topic url
+	Pushover = "pushover" // pushover.net, target = user key, token = application token
+)
+
+// package-wide constants
+const (
+	pushoverURL    = "https://api.pushover.net/1/messages.json"
+	requestTimeout = 15 * time.Second // how long a push may take
+)
+
+// shared client for pushes (the http config's transport)
+func client() *http.Client {
+	return rssfeed.Service(requestTimeout)
+}
+
+// where a notification goes
+type Target struct {
+	Service string // Ntfy or Pushover
+	Target  string // ntfy topic url, or pushover user key
+	Token   string // ntfy access token ("" = public topic), or pushover application token
+}
+
+// a notification, a new post
+type Notification struct {
+	Title   string // eg the feed's name
+	Message string // eg the post's title
+	URL     string // opened when it's tapped ("" = none)
+}
+
+// check a target helper, before it's saved
+func (t Target) Validate() error {
+	switch t.Service {
+	case Ntfy:
+		_, _, err := ntfyTopic(t.Target)
+		return err
+	case Pushover:
+		// pushover keys and tokens are 30 letters and digits
+		if !pushoverKey(t.Target) {
+			return fmt.Errorf("error: invalid pushover user key %q (30 letters and digits, see your pushover dashboard)", t.Target)
+		}
+		if !pushoverKey(t.Token) {
+			return fmt.Errorf("error: invalid pushover application token (30 letters and digits, create an application on pushover.net)")
+		}
+		return nil
+	}
+	return fmt.Errorf("error: unknown push service %q (use ntfy or pushover)", t.Service)
+}
+
+// send a notification
+func Send(ctx context.Context, target Target, n Notification) error {
+	switch target.Service {
+	case Ntfy:
+		return sendNtfy(ctx, target, n)
+	case Pushover:
+		return sendPushover(ctx, target, n)
+	}
+	return fmt.Errorf("error: unknown push service %q", target.Service)
+}
+
+// ntfy helper, publishes as json to the server's root so titles needn't fit in headers
+func sendNtfy(ctx context.Context, target Target, n Notification) error {
+	// split the topic url
+	server, topic, err := ntfyTopic(target.Target)
+	if err != nil {
+		return err
+	}
+
+	// encode the message
+	body, err := json.Marshal(map[string]string{
+		"topic":   topic,
+		"title":   n.Title,
+		"message": n.Message,
+		"click":   n.URL,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding ntfy message: %w", err)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating ntfy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if target.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+target.Token)
+	}
+	return do(req, "ntfy")
+}
+
+// pushover helper
+func sendPushover(ctx context.Context, target Target, n Notification) error {
+	// the form pushover expects
+	form := url.Values{
+		"token":   {target.Token},
+		"user":    {target.Target},
+		"title":   {n.Title},
+		"message": {n.Message},
+	}
+	if n.URL != "" {
+		form.Set("url", n.URL)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating pushover request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(req, "pushover")
+}
+
+// send a push request helper, erroring on anything but 2xx
+func do(req *http.Request, service string) error {
+	// send it
+	res, err := client().Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending %s notification: %w", service, err)
+	}
+	defer res.Body.Close()
+
+	// status check, with the start of the body as services explain errors there
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("error: %s answered %s: %s", service, res.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// split an ntfy topic url helper, eg https://ntfy.sh/mytopic into https://ntfy.sh/ and mytopic
+func ntfyTopic(topicURL string) (string, string, error) {
+	// url check
+	u, err := url.Parse(topicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", fmt.Errorf("error: invalid ntfy topic url %q (eg https://ntfy.sh/my-secret-topic)", topicURL)
+	}
+
+	// topic check, the last path segment
+	path := strings.Trim(u.Path, "/")
+	base, topic := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		base, topic = path[:i+1], path[i+1:]
+	}
+	if topic == "" {
+		return "", "", fmt.Errorf("error: ntfy url %q has no topic (eg https://ntfy.sh/my-secret-topic)", topicURL)
+	}
+	u.Path, u.RawQuery, u.Fragment = "/"+base, "", ""
+	return u.String(), topic, nil
+}
+
+// pushover key shape helper
+func pushoverKey(key string) bool {
+	if len(key) != 30 {
+		return false
+	}
+	for _, r := range key {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// rank.go
+package rank
+
+import (
+	// std go libraries
+	"math" // decay and logs
+	"sort" // ranking
+	"time" // post ages
+)
+
+// scoring model constants
+const (
+	HalfLife    = 24 * time.Hour // a post's recency counts half after this long
+	priorRate   = 0.1            // engagement rate assumed for a feed without history
+	priorWeight = 10             // posts that prior counts as, so a feed's first read doesn't max it out
+	starValue   = 3              // a star says more than a read
+)
+
+// what a post is scored on
+type Post struct {
+	Age        time.Duration // since it was found
+	FeedWeight float64       // the follow's weight (1 = normal, 0 = never first)
+	FeedPosts  int64         // the feed's recent posts
+	FeedReads  int64         // how many of them the user read (opened in a reader)
+	FeedStars  int64         // and starred
+	OtherReads int64         // how many other users read the post
+	OtherStars int64         // and starred it
+}
+
+// score a post, higher is more likely interesting
+// recency decay x feed weight x the user's engagement with the feed x the post's popularity
+func Score(post Post) float64 {
+	// recency, halving every HalfLife
+	recency := math.Exp2(-max(post.Age.Hours(), 0) / HalfLife.Hours())
+
+	// engagement, the feed's read and star rate smoothed towards the prior (1x to 3x)
+	engaged := float64(post.FeedReads) + starValue*float64(post.FeedStars)
+	rate := (engaged + priorRate*priorWeight) / (float64(post.FeedPosts) + priorWeight)
+	engagement := 1 + 2*min(rate, 1)
+
+	// popularity, growing slowly with the other users' reads and stars
+	popularity := 1 + math.Log1p(float64(post.OtherReads)+starValue*float64(post.OtherStars))/2
+
+	return recency * max(post.FeedWeight, 0) * engagement * popularity
+}
+
+// order of posts by score, best first (ties keep their order)
+func Order(posts []Post) []int {
+	order := make([]int, len(posts))
+	scores := make([]float64, len(posts))
+	for i, post := range posts {
+		order[i] = i
+		scores[i] = Score(post)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	return order
+}
@@ -0,0 +1,176 @@
+// feedbin.go
+package readers
+
+import (
+	// std go libraries
+	"context"  // cancelling requests
+	"net/http" // calling feedbin
+	"strconv"  // entry ids
+	"strings"  // id lists
+	"time"     // publication dates
+)
+
+// feedbin api constants
+const (
+	feedbinURL       = "https://api.feedbin.com/v2"
+	feedbinPageSize  = 100  // entries per entries.json?ids= request, the most feedbin takes
+	feedbinMarkLimit = 1000 // entries per unread_entries.json delete, the most feedbin takes
+)
+
+// a feedbin entry, as entries.json sends it
+type feedbinEntry struct {
+	ID        int64     `json:"id"`
+	FeedID    int64     `json:"feed_id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Content   string    `json:"content"`
+	Published time.Time `json:"published"`
+}
+
+// get a feedbin account's feeds, tags and starred entries helper
+// NOTE: feedbin only lists unread entries, so read state isn't imported
+func fetchFeedbin(ctx context.Context, account Account) (Export, error) {
+	// the feeds
+	var subscriptions []struct {
+		FeedID  int64  `json:"feed_id"`
+		Title   string `json:"title"`
+		FeedURL string `json:"feed_url"`
+	}
+	err := feedbinGet(ctx, "/subscriptions.json", account, &subscriptions)
+	if err != nil {
+		return Export{}, err
+	}
+
+	// their tags, the first one is the category
+	var taggings []struct {
+		FeedID int64  `json:"feed_id"`
+		Name   string `json:"name"`
+	}
+	err = feedbinGet(ctx, "/taggings.json", account, &taggings)
+	if err != nil {
+		return Export{}, err
+	}
+	categories := map[int64]string{}
+	for _, tagging := range taggings {
+		if _, ok := categories[tagging.FeedID]; !ok {
+			categories[tagging.FeedID] = tagging.Name
+		}
+	}
+	var export Export
+	feedURLs := map[int64]string{}
+	for _, subscription := range subscriptions {
+		export.Feeds = append(export.Feeds, Feed{Title: subscription.Title, URL: subscription.FeedURL, Category: categories[subscription.FeedID]})
+		feedURLs[subscription.FeedID] = subscription.FeedURL
+	}
+
+	// the starred entries, by id
+	var starred []int64
+	err = feedbinGet(ctx, "/starred_entries.json", account, &starred)
+	if err != nil {
+		return Export{}, err
+	}
+	entries, err := feedbinEntries(ctx, account, starred)
+	if err != nil {
+		return Export{}, err
+	}
+	seen := map[string]int{}
+	for _, entry := range entries {
+		export.add(Entry{
+			ID:          strconv.FormatInt(entry.ID, 10),
+			FeedURL:     feedURLs[entry.FeedID],
+			Title:       entry.Title,
+			URL:         entry.URL,
+			Content:     entry.Content,
+			PublishedAt: entry.Published,
+			Starred:     true,
+		}, seen)
+	}
+	return export, nil
+}
+
+// get a feedbin account's newest unread entries helper
+func feedbinUnread(ctx context.Context, account Account) ([]Entry, error) {
+	// the ids (oldest first)
+	var unread []int64
+	err := feedbinGet(ctx, "/unread_entries.json", account, &unread)
+	if err != nil {
+		return nil, err
+	}
+	if len(unread) > maxReadEntries {
+		unread = unread[len(unread)-maxReadEntries:]
+	}
+
+	// the entries, for their urls
+	entries, err := feedbinEntries(ctx, account, unread)
+	if err != nil {
+		return nil, err
+	}
+	var result []Entry
+	for _, entry := range entries {
+		result = append(result, Entry{ID: strconv.FormatInt(entry.ID, 10), Title: entry.Title, URL: entry.URL, PublishedAt: entry.Published})
+	}
+	return result, nil
+}
+
+// mark feedbin entries read helper, by deleting them from the unread ones
+func feedbinMarkRead(ctx context.Context, account Account, ids []string) error {
+	for start := 0; start < len(ids); start += feedbinMarkLimit {
+		// the batch, as numbers
+		var batch []int64
+		for _, id := range ids[start:min(start+feedbinMarkLimit, len(ids))] {
+			if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+				batch = append(batch, n)
+			}
+		}
+		req, err := feedbinRequest(ctx, http.MethodDelete, "/unread_entries.json", account, map[string][]int64{"unread_entries": batch})
+		if err != nil {
+			return err
+		}
+		err = do(req, Feedbin, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get feedbin entries by id helper, a page of ids at a time
+func feedbinEntries(ctx context.Context, account Account, ids []int64) ([]feedbinEntry, error) {
+	var entries []feedbinEntry
+	for start := 0; start < len(ids); start += feedbinPageSize {
+		var batch []string
+		for _, id := range ids[start:min(start+feedbinPageSize, len(ids))] {
+			batch = append(batch, strconv.FormatInt(id, 10))
+		}
+		var page []feedbinEntry
+		err := feedbinGet(ctx, "/entries.json?ids="+strings.Join(batch, ","), account, &page)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+	}
+	return entries, nil
+}
+
+// feedbin api get helper
+func feedbinGet(ctx context.Context, path string, account Account, reply any) error {
+	req, err := feedbinRequest(ctx, http.MethodGet, path, account, nil)
+	if err != nil {
+		return err
+	}
+	return do(req, Feedbin, reply)
+}
+
+// feedbin api request helper, basic auth with the account's email and password
+func feedbinRequest(ctx context.Context, method, path string, account Account, body any) (*http.Request, error) {
+	base := feedbinURL
+	if account.URL != "" {
+		base = strings.TrimRight(account.URL, "/")
+	}
+	req, err := jsonRequest(ctx, method, base+path, body, Feedbin)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(account.Username, account.Secret)
+	return req, nil
+}
@@ -0,0 +1,210 @@
+// feedly.go
+package readers
+
+import (
+	// std go libraries
+	"context"  // cancelling requests
+	"fmt"      // printing errors
+	"net/http" // calling feedly
+	"net/url"  // query strings
+	"strconv"  // page sizes
+	"strings"  // feed ids
+	"time"     // publication dates
+)
+
+// feedly api constants
+const (
+	feedlyURL       = "https://cloud.feedly.com/v3"
+	feedlyPageSize  = 250 // entries per streams/contents request
+	feedlyMarkLimit = 500 // entries per markers request
+)
+
+// a feedly entry, as streams/contents sends it
+type feedlyEntry struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	CanonicalURL string `json:"canonicalUrl"`
+	Alternate    []struct {
+		Href string `json:"href"`
+	} `json:"alternate"`
+	Published int64 `json:"published"` // unix milliseconds
+	Origin    struct {
+		StreamID string `json:"streamId"`
+	} `json:"origin"`
+	Content struct {
+		Content string `json:"content"`
+	} `json:"content"`
+	Summary struct {
+		Content string `json:"content"`
+	} `json:"summary"`
+	Unread bool `json:"unread"`
+}
+
+// the entry as a reader entry helper
+func (e feedlyEntry) entry() Entry {
+	entry := Entry{
+		ID:      e.ID,
+		FeedURL: strings.TrimPrefix(e.Origin.StreamID, "feed/"),
+		Title:   e.Title,
+		URL:     e.CanonicalURL,
+		Content: e.Content.Content,
+		Read:    !e.Unread,
+	}
+	if entry.URL == "" && len(e.Alternate) > 0 {
+		entry.URL = e.Alternate[0].Href
+	}
+	if entry.Content == "" {
+		entry.Content = e.Summary.Content
+	}
+	if e.Published > 0 {
+		entry.PublishedAt = time.UnixMilli(e.Published).UTC()
+	}
+	return entry
+}
+
+// get a feedly account's feeds, categories and saved entries helper
+// NOTE: feedly only keeps read state for a month, so it isn't imported
+func fetchFeedly(ctx context.Context, account Account) (Export, error) {
+	// the feeds, with their first category (feed ids are feed/<url>)
+	var subscriptions []struct {
+		ID         string `json:"id"`
+		Title      string `json:"title"`
+		Categories []struct {
+			Label string `json:"label"`
+		} `json:"categories"`
+	}
+	err := feedlyGet(ctx, "/subscriptions", nil, account, &subscriptions)
+	if err != nil {
+		return Export{}, err
+	}
+	var export Export
+	for _, subscription := range subscriptions {
+		feed := Feed{Title: subscription.Title, URL: strings.TrimPrefix(subscription.ID, "feed/")}
+		if len(subscription.Categories) > 0 {
+			feed.Category = subscription.Categories[0].Label
+		}
+		export.Feeds = append(export.Feeds, feed)
+	}
+
+	// the saved entries
+	userID, err := feedlyUserID(ctx, account)
+	if err != nil {
+		return Export{}, err
+	}
+	entries, err := feedlyStream(ctx, account, "user/"+userID+"/tag/global.saved", false, -1)
+	if err != nil {
+		return Export{}, err
+	}
+	seen := map[string]int{}
+	for _, entry := range entries {
+		entry.Starred = true
+		export.add(entry, seen)
+	}
+	return export, nil
+}
+
+// get a feedly account's newest unread entries helper
+func feedlyUnread(ctx context.Context, account Account) ([]Entry, error) {
+	userID, err := feedlyUserID(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	return feedlyStream(ctx, account, "user/"+userID+"/category/global.all", true, maxReadEntries)
+}
+
+// mark feedly entries read helper
+func feedlyMarkRead(ctx context.Context, account Account, ids []string) error {
+	for start := 0; start < len(ids); start += feedlyMarkLimit {
+		req, err := feedlyRequest(ctx, http.MethodPost, "/markers", account, map[string]any{
+			"action":   "markAsRead",
+			"type":     "entries",
+			"entryIds": ids[start:min(start+feedlyMarkLimit, len(ids))],
+		})
+		if err != nil {
+			return err
+		}
+		err = do(req, Feedly, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the feedly user id of an access token helper, the streams are named after it
+func feedlyUserID(ctx context.Context, account Account) (string, error) {
+	var profile struct {
+		ID string `json:"id"`
+	}
+	err := feedlyGet(ctx, "/profile", nil, account, &profile)
+	if err != nil {
+		return "", err
+	}
+	if profile.ID == "" {
+		return "", fmt.Errorf("error: feedly sent no user id")
+	}
+	return profile.ID, nil
+}
+
+// get a feedly stream's entries helper, newest first, up to limit (-1 = all of them)
+func feedlyStream(ctx context.Context, account Account, streamID string, unreadOnly bool, limit int) ([]Entry, error) {
+	var entries []Entry
+	continuation := ""
+	for limit < 0 || len(entries) < limit {
+		// the page
+		query := url.Values{"streamId": {streamID}, "count": {strconv.Itoa(feedlyPageSize)}, "ranked": {"newest"}}
+		if unreadOnly {
+			query.Set("unreadOnly", "true")
+		}
+		if continuation != "" {
+			query.Set("continuation", continuation)
+		}
+		var page struct {
+			Items        []feedlyEntry `json:"items"`
+			Continuation string        `json:"continuation"`
+		}
+		err := feedlyGet(ctx, "/streams/contents", query, account, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			entries = append(entries, item.entry())
+		}
+
+		// last page check
+		continuation = page.Continuation
+		if continuation == "" || len(page.Items) == 0 {
+			break
+		}
+	}
+	if limit >= 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// feedly api get helper
+func feedlyGet(ctx context.Context, path string, query url.Values, account Account, reply any) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	req, err := feedlyRequest(ctx, http.MethodGet, path, account, nil)
+	if err != nil {
+		return err
+	}
+	return do(req, Feedly, reply)
+}
+
+// feedly api request helper, authenticated with the access token
+func feedlyRequest(ctx context.Context, method, path string, account Account, body any) (*http.Request, error) {
+	base := feedlyURL
+	if account.URL != "" {
+		base = strings.TrimRight(account.URL, "/")
+	}
+	req, err := jsonRequest(ctx, method, base+path, body, Feedly)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "OAuth "+account.Secret)
+	return req, nil
+}
@@ -0,0 +1,187 @@
+// freshrss.go
+package readers
+
+import (
+	// std go libraries
+	"bufio"    // the login reply
+	"context"  // cancelling requests
+	"fmt"      // printing errors
+	"net/http" // calling freshrss
+	"net/url"  // forms and query strings
+	"slices"   // item states
+	"strings"  // the server's url and login reply
+	"time"     // publication dates
+)
+
+// google reader api constants
+const (
+	greaderPageSize = 1000 // items per stream request, the most freshrss sends
+	greaderRead     = "user/-/state/com.google/read"
+	greaderStarred  = "user/-/state/com.google/starred"
+	greaderLabel    = "user/-/label/"
+)
+
+// a google reader api item, as stream/contents sends it
+type greaderItem struct {
+	Title      string   `json:"title"`
+	Published  int64    `json:"published"` // unix seconds
+	Categories []string `json:"categories"`
+	Canonical  []struct {
+		Href string `json:"href"`
+	} `json:"canonical"`
+	Alternate []struct {
+		Href string `json:"href"`
+	} `json:"alternate"`
+	Origin struct {
+		StreamID string `json:"streamId"`
+	} `json:"origin"`
+	Summary struct {
+		Content string `json:"content"`
+	} `json:"summary"`
+	Content struct {
+		Content string `json:"content"`
+	} `json:"content"`
+}
+
+// get a freshrss server's data helper, through its google reader api
+func fetchFreshRSS(ctx context.Context, baseURL, user, apiPassword string) (Export, error) {
+	// the api lives under /api/greader.php
+	base := strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(base, "/greader.php") {
+		base = strings.TrimSuffix(base, "/api") + "/api/greader.php"
+	}
+
+	// log in
+	auth, err := greaderLogin(ctx, base, user, apiPassword)
+	if err != nil {
+		return Export{}, err
+	}
+
+	// the feeds, with their first label as category
+	var subscriptions struct {
+		Subscriptions []struct {
+			ID         string `json:"id"`
+			Title      string `json:"title"`
+			URL        string `json:"url"`
+			Categories []struct {
+				ID    string `json:"id"`
+				Label string `json:"label"`
+			} `json:"categories"`
+		} `json:"subscriptions"`
+	}
+	err = greaderGet(ctx, base, "/reader/api/0/subscription/list", url.Values{"output": {"json"}}, auth, &subscriptions)
+	if err != nil {
+		return Export{}, err
+	}
+	var export Export
+	feedURLs := map[string]string{}
+	for _, subscription := range subscriptions.Subscriptions {
+		feed := Feed{Title: subscription.Title, URL: subscription.URL}
+		for _, category := range subscription.Categories {
+			if strings.HasPrefix(category.ID, greaderLabel) {
+				feed.Category = category.Label
+				break
+			}
+		}
+		// older freshrss versions only send the feed url in the id
+		if feed.URL == "" {
+			feed.URL = strings.TrimPrefix(subscription.ID, "feed/")
+		}
+		export.Feeds = append(export.Feeds, feed)
+		feedURLs[subscription.ID] = feed.URL
+	}
+
+	// the starred items, then the newest read ones
+	seen := map[string]int{}
+	for _, stream := range []string{greaderStarred, greaderRead} {
+		continuation := ""
+		for fetched := 0; ; fetched += greaderPageSize {
+			// read limit check (starred items are all taken)
+			if stream == greaderRead && fetched >= maxReadEntries {
+				break
+			}
+
+			// the page, newest first
+			query := url.Values{"output": {"json"}, "n": {fmt.Sprint(greaderPageSize)}}
+			if continuation != "" {
+				query.Set("c", continuation)
+			}
+			var page struct {
+				Items        []greaderItem `json:"items"`
+				Continuation string        `json:"continuation"`
+			}
+			err = greaderGet(ctx, base, "/reader/api/0/stream/contents/"+stream, query, auth, &page)
+			if err != nil {
+				return Export{}, err
+			}
+			for _, item := range page.Items {
+				entry := Entry{
+					FeedURL: feedURLs[item.Origin.StreamID],
+					Title:   item.Title,
+					Content: item.Content.Content,
+					Read:    slices.Contains(item.Categories, greaderRead),
+					Starred: slices.Contains(item.Categories, greaderStarred),
+				}
+				if entry.Content == "" {
+					entry.Content = item.Summary.Content
+				}
+				if len(item.Canonical) > 0 {
+					entry.URL = item.Canonical[0].Href
+				} else if len(item.Alternate) > 0 {
+					entry.URL = item.Alternate[0].Href
+				}
+				if item.Published > 0 {
+					entry.PublishedAt = time.Unix(item.Published, 0).UTC()
+				}
+				export.add(entry, seen)
+			}
+
+			// last page check
+			continuation = page.Continuation
+			if continuation == "" || len(page.Items) == 0 {
+				break
+			}
+		}
+	}
+	return export, nil
+}
+
+// google reader api login helper, returns the auth token for the Authorization header
+func greaderLogin(ctx context.Context, base, user, apiPassword string) (string, error) {
+	// ask for a token
+	form := url.Values{"Email": {user}, "Passwd": {apiPassword}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/accounts/ClientLogin", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating freshrss request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling freshrss: %w", err)
+	}
+	defer res.Body.Close()
+
+	// login check
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error: freshrss refused the login (%s), use the api password from Settings > Profile", res.Status)
+	}
+
+	// the reply is key=value lines, Auth is the token
+	lines := bufio.NewScanner(res.Body)
+	for lines.Scan() {
+		if token, ok := strings.CutPrefix(lines.Text(), "Auth="); ok {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("error: freshrss sent no auth token")
+}
+
+// google reader api request helper, authenticated with the login's token
+func greaderGet(ctx context.Context, base, path string, query url.Values, auth string, reply any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("error creating freshrss request: %w", err)
+	}
+	req.Header.Set("Authorization", "GoogleLogin auth="+auth)
+	return do(req, FreshRSS, reply)
+}
@@ -0,0 +1,112 @@
+// miniflux.go
+package readers
+
+import (
+	// std go libraries
+	"context"  // cancelling requests
+	"fmt"      // printing errors
+	"net/http" // calling miniflux
+	"net/url"  // query strings
+	"strconv"  // paging
+	"strings"  // the server's url
+	"time"     // publication dates
+)
+
+// entries per miniflux request
+const minifluxPageSize = 250
+
+// a miniflux entry, as /v1/entries sends it
+type minifluxEntry struct {
+	FeedID      int64     `json:"feed_id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Content     string    `json:"content"`
+	PublishedAt time.Time `json:"published_at"`
+	Status      string    `json:"status"` // read, unread or removed
+	Starred     bool      `json:"starred"`
+}
+
+// get a miniflux server's data helper, through its rest api
+func fetchMiniflux(ctx context.Context, baseURL, apiKey string) (Export, error) {
+	base := strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1")
+
+	// the feeds, with their category
+	var feeds []struct {
+		ID       int64  `json:"id"`
+		Title    string `json:"title"`
+		FeedURL  string `json:"feed_url"`
+		Category struct {
+			Title string `json:"title"`
+		} `json:"category"`
+	}
+	err := minifluxGet(ctx, base, "/v1/feeds", nil, apiKey, &feeds)
+	if err != nil {
+		return Export{}, err
+	}
+	var export Export
+	feedURLs := map[int64]string{}
+	for _, feed := range feeds {
+		export.Feeds = append(export.Feeds, Feed{Title: feed.Title, URL: feed.FeedURL, Category: feed.Category.Title})
+		feedURLs[feed.ID] = feed.FeedURL
+	}
+
+	// the starred entries, then the newest read ones
+	seen := map[string]int{}
+	for _, filter := range []url.Values{{"starred": {"true"}}, {"status": {"read"}}} {
+		for offset := 0; ; offset += minifluxPageSize {
+			// read limit check (starred entries are all taken)
+			if filter.Has("status") && offset >= maxReadEntries {
+				break
+			}
+
+			// the page, newest first
+			query := url.Values{
+				"order":     {"published_at"},
+				"direction": {"desc"},
+				"limit":     {strconv.Itoa(minifluxPageSize)},
+				"offset":    {strconv.Itoa(offset)},
+			}
+			for key, values := range filter {
+				query[key] = values
+			}
+			var page struct {
+				Entries []minifluxEntry `json:"entries"`
+			}
+			err = minifluxGet(ctx, base, "/v1/entries", query, apiKey, &page)
+			if err != nil {
+				return Export{}, err
+			}
+			for _, entry := range page.Entries {
+				export.add(Entry{
+					FeedURL:     feedURLs[entry.FeedID],
+					Title:       entry.Title,
+					URL:         entry.URL,
+					Content:     entry.Content,
+					PublishedAt: entry.PublishedAt,
+					Read:        entry.Status == "read",
+					Starred:     entry.Starred,
+				}, seen)
+			}
+
+			// last page check
+			if len(page.Entries) < minifluxPageSize {
+				break
+			}
+		}
+	}
+	return export, nil
+}
+
+// miniflux api request helper, authenticated with the api key
+func minifluxGet(ctx context.Context, base, path string, query url.Values, apiKey string, reply any) error {
+	target := base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("error creating miniflux request: %w", err)
+	}
+	req.Header.Set("X-Auth-Token", apiKey)
+	return do(req, Miniflux, reply)
+}
@@ -0,0 +1,186 @@
+// readers.go
+package readers
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"encoding/json" // the readers' json apis
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"net/http"      // calling the readers
+	"strings"       // error bodies
+	"time"          // request timeout
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// the readers data can be imported from
+const (
+	Miniflux = "miniflux" // a miniflux server, its rest api with an api key
+	FreshRSS = "freshrss" // a freshrss server, its google reader api with the api password
+	Feedbin  = "feedbin"  // feedbin.com, its rest api with the account's email and password
+	Feedly   = "feedly"   // feedly.com, its cloud api with a developer access token
+)
+
+// package-wide constants
+const (
+	requestTimeout = 60 * time.Second // how long one page of entries may take
+	maxReadEntries = 5000             // newest read entries imported, older read state isn't worth the requests
+)
+
+// shared client for the imports (same transport as feed fetches)
+func client() *http.Client {
+	return rssfeed.Service(requestTimeout)
+}
+
+// a login with another reader (stored as json for sync)
+type Account struct {
+	URL      string `json:"url,omitempty"`      // the server, for self-hosted readers
+	Username string `json:"username,omitempty"` // freshrss user, feedbin email
+	Secret   string `json:"secret"`             // password, api key or access token
+}
+
+// a subscription in another reader
+type Feed struct {
+	Title    string
+	URL      string // the feed's own url
+	Category string // "" = none
+}
+
+// a starred or read entry in another reader
+type Entry struct {
+	ID          string // the reader's own id, for pushing read state back
+	FeedURL     string // the feed it's from, one of the export's
+	Title       string
+	URL         string
+	Content     string    // html, "" if the reader didn't send it
+	PublishedAt time.Time // zero if unknown
+	Read        bool
+	Starred     bool
+}
+
+// what's brought over from a reader
+type Export struct {
+	Feeds   []Feed
+	Entries []Entry // starred ones first, then the newest read ones
+}
+
+// get a reader's feeds, categories, starred entries and newest read entries (feedbin and feedly: no read ones)
+// NOTE: miniflux logs in with an api key (Settings > API Keys), freshrss with the user name and api password (Settings > Profile)
+func Fetch(ctx context.Context, reader string, account Account) (Export, error) {
+	switch reader {
+	case Miniflux:
+		return fetchMiniflux(ctx, account.URL, account.Secret)
+	case FreshRSS:
+		return fetchFreshRSS(ctx, account.URL, account.Username, account.Secret)
+	case Feedbin:
+		return fetchFeedbin(ctx, account)
+	case Feedly:
+		return fetchFeedly(ctx, account)
+	}
+	return Export{}, fmt.Errorf("error: unknown reader %q (use miniflux, freshrss, feedbin or feedly)", reader)
+}
+
+// check a login with a hosted reader works helper, before it's saved
+func Check(ctx context.Context, reader string, account Account) error {
+	switch reader {
+	case Feedbin:
+		req, err := feedbinRequest(ctx, http.MethodGet, "/authentication.json", account, nil)
+		if err != nil {
+			return err
+		}
+		return do(req, Feedbin, nil)
+	case Feedly:
+		_, err := feedlyUserID(ctx, account)
+		return err
+	}
+	return fmt.Errorf("error: unknown hosted reader %q (use feedbin or feedly)", reader)
+}
+
+// get a hosted reader's unread entries, newest first (up to maxReadEntries), with their ids and urls
+func Unread(ctx context.Context, reader string, account Account) ([]Entry, error) {
+	switch reader {
+	case Feedbin:
+		return feedbinUnread(ctx, account)
+	case Feedly:
+		return feedlyUnread(ctx, account)
+	}
+	return nil, fmt.Errorf("error: unknown hosted reader %q (use feedbin or feedly)", reader)
+}
+
+// mark entries read in a hosted reader, by the ids Unread gave
+func MarkRead(ctx context.Context, reader string, account Account, ids []string) error {
+	switch reader {
+	case Feedbin:
+		return feedbinMarkRead(ctx, account, ids)
+	case Feedly:
+		return feedlyMarkRead(ctx, account, ids)
+	}
+	return fmt.Errorf("error: unknown hosted reader %q (use feedbin or feedly)", reader)
+}
+
+// add an entry to the export helper, once per url (an entry can be starred and read)
+func (e *Export) add(entry Entry, seen map[string]int) {
+	// empty url check, it can't be matched to a post
+	if entry.URL == "" {
+		return
+	}
+
+	// seen check, merge the states
+	if i, ok := seen[entry.URL]; ok {
+		e.Entries[i].Read = e.Entries[i].Read || entry.Read
+		e.Entries[i].Starred = e.Entries[i].Starred || entry.Starred
+		return
+	}
+	seen[entry.URL] = len(e.Entries)
+	e.Entries = append(e.Entries, entry)
+}
+
+// json request helper, with body encoded as json (nil = no body)
+func jsonRequest(ctx context.Context, method, target string, body any, reader string) (*http.Request, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding %s request: %w", reader, err)
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, payload)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s request: %w", reader, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	return req, nil
+}
+
+// send a request helper, erroring on anything but 2xx, decoding the json reply into reply (nil = ignore it)
+func do(req *http.Request, reader string, reply any) error {
+	// send it
+	req.Header.Set("Accept", "application/json")
+	res, err := client().Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", reader, err)
+	}
+	defer res.Body.Close()
+
+	// status check
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("error: %s returned %s: %s", reader, res.Status, strings.TrimSpace(string(detail)))
+	}
+
+	// decode the reply
+	if reply == nil {
+		return nil
+	}
+	err = json.NewDecoder(res.Body).Decode(reply)
+	if err != nil {
+		return fmt.Errorf("error decoding %s reply: %w", reader, err)
+	}
+	return nil
+}
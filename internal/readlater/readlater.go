@@ -0,0 +1,234 @@
+// readlater.go
+package readlater
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"encoding/json" // pocket and wallabag's json apis
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"net/http"      // calling the services
+	"net/url"       // forms and urls
+	"strings"       // urls and error bodies
+	"time"          // request timeout
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// the read-later services posts can be sent to
+const (
+	Pocket     = "pocket"     // getpocket.com, oauth with a consumer key from its developer site
+	Instapaper = "instapaper" // instapaper.com, its simple api with the account's email and password
+	Wallabag   = "wallabag"   // a wallabag server (wallabag.it or self-hosted), oauth with an api client
+)
+
+// package-wide constants
+const (
+	pocketURL      = "https://getpocket.com"
+	instapaperURL  = "https://www.instapaper.com/api"
+	requestTimeout = 15 * time.Second // how long a save may take
+)
+
+// shared client for saves, with the http config's proxy
+func client() *http.Client {
+	return rssfeed.Service(requestTimeout)
+}
+
+// a user's login with a service, only the fields it uses are set (stored as json)
+type Account struct {
+	URL          string `json:"url,omitempty"`           // wallabag server
+	Username     string `json:"username,omitempty"`      // instapaper and wallabag
+	Password     string `json:"password,omitempty"`      // instapaper and wallabag
+	ClientID     string `json:"client_id,omitempty"`     // wallabag api client
+	ClientSecret string `json:"client_secret,omitempty"` // wallabag api client
+	ConsumerKey  string `json:"consumer_key,omitempty"`  // pocket application
+	AccessToken  string `json:"access_token,omitempty"`  // pocket, from the oauth flow
+}
+
+// a post to save
+type Item struct {
+	URL   string
+	Title string
+}
+
+// check an account works helper, before it's saved
+func Check(ctx context.Context, service string, account Account) error {
+	switch service {
+	case Pocket:
+		// pocket has no cheap check, the access token came from its oauth flow just now
+		if account.ConsumerKey == "" || account.AccessToken == "" {
+			return fmt.Errorf("error: pocket needs a consumer key and an access token")
+		}
+		return nil
+	case Instapaper:
+		req, err := instapaperRequest(ctx, "/authenticate", account, url.Values{})
+		if err != nil {
+			return err
+		}
+		return do(req, Instapaper, nil)
+	case Wallabag:
+		_, err := wallabagToken(ctx, account)
+		return err
+	}
+	return fmt.Errorf("error: unknown read-later service %q (use pocket, instapaper or wallabag)", service)
+}
+
+// save a post to a service
+func Save(ctx context.Context, service string, account Account, item Item) error {
+	switch service {
+	case Pocket:
+		return postJSON(ctx, pocketURL+"/v3/add", nil, map[string]string{
+			"url":          item.URL,
+			"title":        item.Title,
+			"consumer_key": account.ConsumerKey,
+			"access_token": account.AccessToken,
+		}, nil, Pocket)
+	case Instapaper:
+		req, err := instapaperRequest(ctx, "/add", account, url.Values{"url": {item.URL}, "title": {item.Title}})
+		if err != nil {
+			return err
+		}
+		return do(req, Instapaper, nil)
+	case Wallabag:
+		token, err := wallabagToken(ctx, account)
+		if err != nil {
+			return err
+		}
+		header := http.Header{"Authorization": {"Bearer " + token}}
+		return postJSON(ctx, strings.TrimRight(account.URL, "/")+"/api/entries.json", header, map[string]string{
+			"url":   item.URL,
+			"title": item.Title,
+		}, nil, Wallabag)
+	}
+	return fmt.Errorf("error: unknown read-later service %q", service)
+}
+
+// start pocket's oauth flow, returns the request code and the url the user authorizes it at
+// redirect is where pocket sends the browser after (any url works for a cli, the code is what matters)
+func PocketAuthorize(ctx context.Context, consumerKey, redirect string) (code, authURL string, err error) {
+	var reply struct {
+		Code string `json:"code"`
+	}
+	err = postJSON(ctx, pocketURL+"/v3/oauth/request", nil, map[string]string{
+		"consumer_key": consumerKey,
+		"redirect_uri": redirect,
+	}, &reply, Pocket)
+	if err != nil {
+		return "", "", err
+	}
+	authURL = pocketURL + "/auth/authorize?" + url.Values{"request_token": {reply.Code}, "redirect_uri": {redirect}}.Encode()
+	return reply.Code, authURL, nil
+}
+
+// finish pocket's oauth flow once the user authorized the code, returns the access token and the pocket user name
+func PocketAccessToken(ctx context.Context, consumerKey, code string) (token, username string, err error) {
+	var reply struct {
+		AccessToken string `json:"access_token"`
+		Username    string `json:"username"`
+	}
+	err = postJSON(ctx, pocketURL+"/v3/oauth/authorize", nil, map[string]string{
+		"consumer_key": consumerKey,
+		"code":         code,
+	}, &reply, Pocket)
+	if err != nil {
+		return "", "", err
+	}
+	return reply.AccessToken, reply.Username, nil
+}
+
+// instapaper simple api request helper, basic auth with the account's login
+func instapaperRequest(ctx context.Context, path string, account Account, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, instapaperURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating instapaper request: %w", err)
+	}
+	req.SetBasicAuth(account.Username, account.Password)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// wallabag access token helper, oauth's password grant with the api client and the user's login
+func wallabagToken(ctx context.Context, account Account) (string, error) {
+	// server check
+	if account.URL == "" {
+		return "", fmt.Errorf("error: wallabag needs its server's url")
+	}
+
+	// ask for a token
+	form := url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {account.ClientID},
+		"client_secret": {account.ClientSecret},
+		"username":      {account.Username},
+		"password":      {account.Password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(account.URL, "/")+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating wallabag request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var reply struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = do(req, Wallabag, &reply)
+	if err != nil {
+		return "", err
+	}
+
+	// token check
+	if reply.AccessToken == "" {
+		return "", fmt.Errorf("error: wallabag sent no access token")
+	}
+	return reply.AccessToken, nil
+}
+
+// post json helper, decoding the json reply into reply (nil = ignore it)
+func postJSON(ctx context.Context, url string, header http.Header, body, reply any, service string) error {
+	// encode the body
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding %s request: %w", service, err)
+	}
+
+	// create the request (pocket answers in form encoding without the accept header)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error creating %s request: %w", service, err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Accept", "application/json")
+	return do(req, service, reply)
+}
+
+// send a request helper, erroring on anything but 2xx, decoding the json reply into reply (nil = ignore it)
+func do(req *http.Request, service string, reply any) error {
+	// send it
+	res, err := client().Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", service, err)
+	}
+	defer res.Body.Close()
+
+	// status check, pocket explains errors in a header, the others in the body
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		if pocketError := res.Header.Get("X-Error"); pocketError != "" {
+			detail = []byte(pocketError)
+		}
+		return fmt.Errorf("error: %s returned %s: %s", service, res.Status, strings.TrimSpace(string(detail)))
+	}
+
+	// decode the reply
+	if reply != nil {
+		err = json.NewDecoder(res.Body).Decode(reply)
+		if err != nil {
+			return fmt.Errorf("error decoding %s reply: %w", service, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// readtime.go
+package readtime
+
+import (
+	// std go libraries
+	"html"    // unescaping entities
+	"strings" // counting words
+)
+
+// average adult silent reading speed, in words a minute
+const WordsPerMinute = 238
+
+// the words in some html (or plain text), tags aren't words
+func Words(content string) int {
+	// drop the tags
+	var out strings.Builder
+	inTag := false
+	for _, r := range content {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+			out.WriteRune(' ')
+		case !inTag:
+			out.WriteRune(r)
+		}
+	}
+	return len(strings.Fields(html.UnescapeString(out.String())))
+}
+
+// estimated minutes to read some html (or plain text), rounded up
+// 0 means there's nothing to read
+func Minutes(content string) int {
+	words := Words(content)
+
+	// empty check
+	if words == 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
@@ -0,0 +1,377 @@
+// dns.go
+package rssfeed
+
+import (
+	// std go libraries
+	"context"         // lookups and dials
+	"encoding/binary" // dns message fields
+	"net"             // resolving and dialing
+	"runtime"         // the os resolver on macOS and Windows
+	"sync"            // fetches dial in parallel
+	"time"            // ttls
+)
+
+// dns cache defaults
+const (
+	DefaultDNSCacheTTL = 5 * time.Minute        // the longest an answer is kept, even if its ttl is longer
+	dnsFallbackTTL     = time.Minute            // for answers whose ttl can't be seen (hosts file, the os resolver, dns over tcp)
+	dnsCacheSweepAt    = 1024                   // hosts cached before expired ones are swept out
+	dnsFallbackDelay   = 300 * time.Millisecond // before the other address family is raced, net.Dialer's default
+)
+
+// a host's cached addresses
+type dnsEntry struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// dns cache struct, dials hosts by their cached addresses so every fetch doesn't look them up again
+// NOTE: go's resolver doesn't tell the ttl, so the answers are read off the wire as they pass (see ttlConn)
+type dnsCache struct {
+	mu       sync.Mutex
+	entries  map[string]dnsEntry
+	maxTTL   time.Duration
+	dialer   *net.Dialer
+	resolver *net.Resolver
+	server   string // the dns server to ask instead of the system's (tests), "" = the system's
+}
+
+// create a dns cache that dials with dialer, keeping answers for at most maxTTL
+// NOTE: on macOS and Windows the os resolver stays in charge (it knows about vpns and per-domain servers),
+// the ttls can't be seen there, so answers are kept for dnsFallbackTTL
+func newDNSCache(dialer *net.Dialer, maxTTL time.Duration) *dnsCache {
+	cache := &dnsCache{entries: map[string]dnsEntry{}, maxTTL: maxTTL, dialer: dialer}
+	cache.resolver = &net.Resolver{
+		PreferGo: runtime.GOOS != "darwin" && runtime.GOOS != "windows", // already the default elsewhere (unless cgo is forced)
+		Dial:     cache.dialDNS,
+	}
+	return cache
+}
+
+// the resolver's dial helper, wrapping its udp conns so the answers' ttls can be read
+func (c *dnsCache) dialDNS(ctx context.Context, network, address string) (net.Conn, error) {
+	// server override check
+	if c.server != "" {
+		address = c.server
+	}
+	conn, err := c.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// lookup check, only udp answers are read (tcp frames them, it's rare enough to use the fallback)
+	ttls, ok := ctx.Value(ttlKey{}).(*ttlCollector)
+	packet, isPacket := conn.(net.PacketConn)
+	if !ok || !isPacket {
+		return conn, nil
+	}
+	return &ttlConn{Conn: conn, packet: packet, ttls: ttls}, nil
+}
+
+// dial a host:port by the host's cached addresses, looking them up if they aren't cached (or expired)
+// the first address's family is tried first, the other one joins after a short delay (happy eyeballs, like net.Dialer)
+func (c *dnsCache) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	// ip address check, nothing to look up
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, address)
+	}
+
+	// get the addresses
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	// split them by family, the first one's family goes first
+	var primaries, fallbacks []string
+	firstIPv4 := false
+	for _, addr := range addrs {
+		// address family check (tcp4/tcp6)
+		ipv4 := addr.IP.To4() != nil
+		if (network == "tcp4" && !ipv4) || (network == "tcp6" && ipv4) {
+			continue
+		}
+		if len(primaries) == 0 {
+			firstIPv4 = ipv4
+		}
+		target := net.JoinHostPort(addr.String(), port)
+		if ipv4 == firstIPv4 {
+			primaries = append(primaries, target)
+		} else {
+			fallbacks = append(fallbacks, target)
+		}
+	}
+
+	// nothing to dial check
+	if len(primaries) == 0 {
+		return nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+	}
+
+	// one family (or racing turned off) check
+	if len(fallbacks) == 0 || c.dialer.FallbackDelay < 0 {
+		return c.dialSerial(ctx, network, primaries)
+	}
+	return c.dialParallel(ctx, network, primaries, fallbacks)
+}
+
+// dial addresses in order until one answers helper
+func (c *dnsCache) dialSerial(ctx context.Context, network string, targets []string) (net.Conn, error) {
+	var firstErr error
+	for _, target := range targets {
+		conn, err := c.dialer.DialContext(ctx, network, target)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// race the primaries against the fallbacks helper, the fallbacks start after the fallback delay (or once the primaries fail)
+func (c *dnsCache) dialParallel(ctx context.Context, network string, primaries, fallbacks []string) (net.Conn, error) {
+	// stop the loser once there's a winner
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// dial each family on its own
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	results := make(chan dialResult, 2)
+	race := func(targets []string, primary bool) {
+		conn, err := c.dialSerial(ctx, network, targets)
+		results <- dialResult{conn: conn, err: err, primary: primary}
+	}
+	go race(primaries, true)
+
+	// start the fallbacks after the delay
+	delay := c.dialer.FallbackDelay
+	if delay == 0 {
+		delay = dnsFallbackDelay
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending, fallbackStarted := 1, false
+	var primaryErr, fallbackErr error
+	for {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				go race(fallbacks, false)
+				pending, fallbackStarted = pending+1, true
+			}
+		case result := <-results:
+			pending--
+
+			// winner check, the loser's conn is closed if it gets through too
+			if result.err == nil {
+				if pending > 0 {
+					go func() {
+						if loser := <-results; loser.conn != nil {
+							loser.conn.Close()
+						}
+					}()
+				}
+				return result.conn, nil
+			}
+			if result.primary {
+				primaryErr = result.err
+			} else {
+				fallbackErr = result.err
+			}
+
+			// the primaries failed before the delay, no need to wait for it
+			if !fallbackStarted {
+				go race(fallbacks, false)
+				pending, fallbackStarted = pending+1, true
+				continue
+			}
+			if pending == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+				return nil, fallbackErr
+			}
+		}
+	}
+}
+
+// get a host's addresses, from the cache while their ttl lasts
+// NOTE: failed lookups aren't cached, and go's resolver already merges lookups of the same host running at once
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	// cached check
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	// look it up, collecting the answers' ttls
+	ttls := &ttlCollector{}
+	addrs, err := c.resolver.LookupIPAddr(context.WithValue(ctx, ttlKey{}, ttls), host)
+	if err != nil {
+		return nil, err
+	}
+
+	// cache it for its ttl, at most maxTTL (a ttl of 0 = don't cache)
+	ttl, seen := ttls.min()
+	if !seen {
+		ttl = dnsFallbackTTL
+	}
+	ttl = min(ttl, c.maxTTL)
+	if ttl <= 0 {
+		return addrs, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= dnsCacheSweepAt {
+		for cached, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, cached)
+			}
+		}
+	}
+	c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(ttl)}
+	return addrs, nil
+}
+
+// the context key a lookup's ttl collector is passed to the resolver's Dial with
+type ttlKey struct{}
+
+// the lowest ttl of a lookup's answers (the A and AAAA lookups each answer on their own conn)
+type ttlCollector struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen bool
+}
+
+// note an answer's ttl
+func (t *ttlCollector) add(ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.seen || ttl < t.ttl {
+		t.ttl = ttl
+	}
+	t.seen = true
+}
+
+// the lowest ttl noted, false if none was
+func (t *ttlCollector) min() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ttl, t.seen
+}
+
+// a resolver's udp conn that notes the ttls of the answers read from it
+// NOTE: it must stay a net.PacketConn, go's resolver only sends plain udp messages over those (else it frames them like tcp)
+type ttlConn struct {
+	net.Conn
+	packet net.PacketConn // the same conn, for ReadFrom and WriteTo
+	ttls   *ttlCollector
+}
+
+// read a dns message, noting its answers' ttl (one udp read = one message)
+func (c *ttlConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.note(b[:n])
+	return n, err
+}
+
+// read a dns message from an address, noting its answers' ttl
+func (c *ttlConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.packet.ReadFrom(b)
+	c.note(b[:n])
+	return n, addr, err
+}
+
+// write a dns message to an address
+func (c *ttlConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.packet.WriteTo(b, addr)
+}
+
+// note a message's ttl helper
+func (c *ttlConn) note(msg []byte) {
+	if ttl, ok := answerTTL(msg); ok {
+		c.ttls.add(ttl)
+	}
+}
+
+// dns record types the ttl is taken from
+const (
+	dnsTypeA     = 1
+	dnsTypeCNAME = 5 // an alias's ttl counts too, the addresses are only as fresh as the alias
+	dnsTypeAAAA  = 28
+)
+
+// get the lowest ttl of a dns message's address answers, false if it has none (or doesn't parse)
+func answerTTL(msg []byte) (time.Duration, bool) {
+	// header check (id, flags, then the section counts)
+	if len(msg) < 12 {
+		return 0, false
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	answers := int(binary.BigEndian.Uint16(msg[6:]))
+
+	// skip the questions (a name, then type and class)
+	offset := 12
+	for range questions {
+		next, ok := skipName(msg, offset)
+		if !ok {
+			return 0, false
+		}
+		offset = next + 4
+	}
+
+	// the answers (a name, then type, class, ttl, data length and data)
+	var lowest uint32
+	found := false
+	for range answers {
+		next, ok := skipName(msg, offset)
+		if !ok || next+10 > len(msg) {
+			return 0, false
+		}
+		recordType := binary.BigEndian.Uint16(msg[next:])
+		ttl := binary.BigEndian.Uint32(msg[next+4:])
+		offset = next + 10 + int(binary.BigEndian.Uint16(msg[next+8:]))
+		if offset > len(msg) {
+			return 0, false
+		}
+		if recordType == dnsTypeA || recordType == dnsTypeAAAA || recordType == dnsTypeCNAME {
+			if !found || ttl < lowest {
+				lowest = ttl
+			}
+			found = true
+		}
+	}
+	return time.Duration(lowest) * time.Second, found
+}
+
+// skip a dns name helper, returns the offset after it
+// labels end at a 0 length, or at a pointer to a name earlier in the message (2 bytes)
+func skipName(msg []byte, offset int) (int, bool) {
+	for {
+		if offset >= len(msg) {
+			return 0, false
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			return offset + 1, true
+		case length&0xC0 == 0xC0:
+			return offset + 2, offset+2 <= len(msg)
+		default:
+			offset += 1 + length
+		}
+	}
+}
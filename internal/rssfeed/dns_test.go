@@ -0,0 +1,186 @@
+// dns_test.go
+package rssfeed
+
+import (
+	// std go libraries
+	"context"         // lookups and dials
+	"encoding/binary" // dns message fields
+	"net"             // the test servers
+	"sync/atomic"     // counting queries
+	"testing"         // go tests
+	"time"            // ttls
+)
+
+// a dns server on a local udp port, answering every A question with 127.0.0.1 and ttl (no AAAA answers)
+func startDNSServer(t *testing.T, ttl uint32) (string, *atomic.Int32) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting dns server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var queries atomic.Int32
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			queries.Add(1)
+			reply, ok := dnsReply(buf[:n], ttl)
+			if ok {
+				conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String(), &queries
+}
+
+// build the answer to a query, the question copied back and an A record for it
+func dnsReply(query []byte, ttl uint32) ([]byte, bool) {
+	// the question's end
+	end, ok := skipName(query, 12)
+	if !ok || end+4 > len(query) {
+		return nil, false
+	}
+	questionType := binary.BigEndian.Uint16(query[end:])
+	question := query[12 : end+4]
+
+	// header: same id, a recursive answer, one question
+	reply := binary.BigEndian.AppendUint16(nil, binary.BigEndian.Uint16(query))
+	reply = binary.BigEndian.AppendUint16(reply, 0x8180)
+	answers := uint16(0)
+	if questionType == dnsTypeA {
+		answers = 1
+	}
+	reply = binary.BigEndian.AppendUint16(reply, 1)
+	reply = binary.BigEndian.AppendUint16(reply, answers)
+	reply = binary.BigEndian.AppendUint16(reply, 0)
+	reply = binary.BigEndian.AppendUint16(reply, 0)
+	reply = append(reply, question...)
+
+	// the answer, its name pointing at the question's
+	if answers == 1 {
+		reply = append(reply, 0xC0, 12)
+		reply = binary.BigEndian.AppendUint16(reply, dnsTypeA)
+		reply = binary.BigEndian.AppendUint16(reply, 1) // class IN
+		reply = binary.BigEndian.AppendUint32(reply, ttl)
+		reply = binary.BigEndian.AppendUint16(reply, 4)
+		reply = append(reply, 127, 0, 0, 1)
+	}
+	return reply, true
+}
+
+// a tcp server to dial, closing every conn it accepts
+func startTCPServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting tcp server: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port
+}
+
+// hosts are looked up over plain udp, dialed, and cached for their answer's ttl
+func TestDNSCacheDial(t *testing.T) {
+	// the go resolver check (the os one is used on macOS and Windows, it can't be pointed at a test server)
+	cache := newDNSCache(&net.Dialer{Timeout: 5 * time.Second}, DefaultDNSCacheTTL)
+	if !cache.resolver.PreferGo {
+		t.Skip("the os resolver is used on this platform")
+	}
+	server, queries := startDNSServer(t, 30)
+	cache.server = server
+	port := startTCPServer(t)
+
+	// dial twice, the second from the cache
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for range 2 {
+		conn, err := cache.DialContext(ctx, "tcp", net.JoinHostPort("feed.example.", port))
+		if err != nil {
+			t.Fatalf("error dialing: %v", err)
+		}
+		conn.Close()
+	}
+
+	// one lookup, the A and AAAA questions
+	if got := queries.Load(); got != 2 {
+		t.Errorf("dns server got %d queries, want 2 (one lookup)", got)
+	}
+
+	// kept for the answer's ttl, not the fallback
+	cache.mu.Lock()
+	entry, ok := cache.entries["feed.example."]
+	cache.mu.Unlock()
+	if !ok {
+		t.Fatalf("host not cached")
+	}
+	if left := time.Until(entry.expires); left > 30*time.Second || left < 25*time.Second {
+		t.Errorf("cached for %v, want the answer's 30s ttl", left)
+	}
+}
+
+// the other address family takes over when the first one doesn't answer
+func TestDNSCacheDialFallback(t *testing.T) {
+	port := startTCPServer(t)
+	cache := newDNSCache(&net.Dialer{Timeout: 5 * time.Second}, DefaultDNSCacheTTL)
+	cache.entries["dual.example"] = dnsEntry{
+		// a v6 address nothing listens on first, then the v4 server
+		addrs:   []net.IPAddr{{IP: net.ParseIP("::1")}, {IP: net.ParseIP("127.0.0.1")}},
+		expires: time.Now().Add(time.Minute),
+	}
+
+	conn, err := cache.DialContext(context.Background(), "tcp", net.JoinHostPort("dual.example", port))
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if host, _, _ := net.SplitHostPort(conn.RemoteAddr().String()); host != "127.0.0.1" {
+		t.Errorf("dialed %s, want 127.0.0.1", host)
+	}
+
+	// tcp6 only tries the v6 address
+	_, err = cache.DialContext(context.Background(), "tcp6", net.JoinHostPort("dual.example", port))
+	if err == nil {
+		t.Errorf("tcp6 dial of a v4-only server worked, want an error")
+	}
+}
+
+// the ttl is read from A, AAAA and CNAME answers, the lowest wins
+func TestAnswerTTL(t *testing.T) {
+	query := []byte{0, 1, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 4, 'f', 'e', 'e', 'd', 0, 0, 1, 0, 1}
+	reply, _ := dnsReply(query, 120)
+
+	tests := []struct {
+		name   string
+		msg    []byte
+		want   time.Duration
+		wantOK bool
+	}{
+		{"answer", reply, 120 * time.Second, true},
+		{"no answers", query, 0, false},
+		{"short header", reply[:8], 0, false},
+		{"truncated answer", reply[:len(reply)-6], 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := answerTTL(tt.msg)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("answerTTL = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
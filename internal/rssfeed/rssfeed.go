@@ -4,14 +4,131 @@ package rssfeed
 import (
 	// std go libraries
 	"context"      // context for request timeout
+	"crypto/tls"   // turning http/2 off
 	"encoding/xml" // xml unmarshalling
 	"fmt"          // printing
 	"html"         // html unescaping
 	"io"           // file reading
+	"log/slog"     // structured logging
+	"net"          // dial timeouts
 	"net/http"     // http protocol
+	"net/url"      // proxy urls
 	"strings"      // checking str contains
+	"time"         // request timeouts
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/logging" // for the trace log level
+)
+
+// http client defaults, used when the config's http section leaves them out
+const (
+	DefaultTimeout        = 10 * time.Second
+	DefaultMaxRedirects   = 10 // same as net/http
+	DefaultUserAgent      = "Gator/0.1 (+https://github.com/PietPadda/aggregator)"
+	DefaultConnectTimeout = 5 * time.Second  // dial and tls handshake, each
+	DefaultIdleTimeout    = 90 * time.Second // same as net/http
+	DefaultMaxIdlePerHost = 8                // net/http keeps 2, too few when many feeds share a host
 )
 
+// http client options (see the config's http section)
+type Options struct {
+	Timeout      time.Duration // whole request, body included (0 = DefaultTimeout)
+	MaxRedirects int           // redirects followed before giving up (0 = none, -1 = DefaultMaxRedirects)
+	Proxy        *url.URL      // proxy for every request (nil = HTTP_PROXY/HTTPS_PROXY from the env)
+	NoProxy      bool          // ignore the env proxy, connect directly
+	UserAgent    string        // User-Agent header ("" = DefaultUserAgent)
+
+	// transport tuning, so connections are reused across fetches
+	ConnectTimeout time.Duration // dial and tls handshake (0 = DefaultConnectTimeout)
+	IdleTimeout    time.Duration // how long an unused connection is kept open (0 = DefaultIdleTimeout)
+	MaxIdlePerHost int           // unused connections kept open per host (0 = DefaultMaxIdlePerHost)
+	NoHTTP2        bool          // stick to http/1.1, for hosts with broken http/2
+	DNSCacheTTL    time.Duration // the longest a dns answer is cached, shorter if its ttl is (0 = DefaultDNSCacheTTL)
+	NoDNSCache     bool          // look every host up on every new connection
+}
+
+// feed fetching client, one shared by every fetch so connections are reused
+type Client struct {
+	http      *http.Client
+	userAgent string
+}
+
+// the client FetchFeed and FetchRaw use, with the defaults
+var Default = NewClient(Options{MaxRedirects: -1})
+
+// create a feed fetching client from options
+func NewClient(opts Options) *Client {
+	// defaults check
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxRedirects < 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	if opts.MaxIdlePerHost <= 0 {
+		opts.MaxIdlePerHost = DefaultMaxIdlePerHost
+	}
+
+	// transport, net/http's defaults with our timeouts and pool sizes
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.IdleConnTimeout = opts.IdleTimeout
+	transport.MaxIdleConnsPerHost = opts.MaxIdlePerHost
+	transport.MaxIdleConns = max(transport.MaxIdleConns, opts.MaxIdlePerHost)
+
+	// dns cache check, hosts are resolved once per ttl instead of on every new connection
+	if !opts.NoDNSCache {
+		if opts.DNSCacheTTL <= 0 {
+			opts.DNSCacheTTL = DefaultDNSCacheTTL
+		}
+		transport.DialContext = newDNSCache(dialer, opts.DNSCacheTTL).DialContext
+	}
+
+	// http/2 check (a non-nil empty TLSNextProto is how net/http is told not to negotiate it)
+	if opts.NoHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	// proxy, from the options or the env
+	switch {
+	case opts.NoProxy:
+		transport.Proxy = nil
+	case opts.Proxy != nil:
+		transport.Proxy = http.ProxyURL(opts.Proxy)
+	}
+
+	// redirect limit
+	maxRedirects := opts.MaxRedirects
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+
+	// return the client
+	return &Client{
+		http: &http.Client{
+			Timeout:       opts.Timeout,
+			Transport:     transport,
+			CheckRedirect: checkRedirect,
+		},
+		userAgent: opts.UserAgent,
+	}
+}
+
 type RSSFeed struct {
 	Channel Channel `xml:"channel"` // Feed channel info
 }
@@ -37,15 +154,60 @@ type AtomLink struct {
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`       // Post title
-	Link        string `xml:"link"`        // Post URL
-	PubDate     string `xml:"pubDate"`     // Post publication date
-	GUID        string `xml:"guid"`        // Unique ID
-	Description string `xml:"description"` // Post content
+	Title       string      `xml:"title"`                                               // Post title
+	Link        string      `xml:"link"`                                                // Post URL
+	PubDate     string      `xml:"pubDate"`                                             // Post publication date
+	GUID        string      `xml:"guid"`                                                // Unique ID
+	Description string      `xml:"description"`                                         // Post content
+	Content     string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`    // Full post content (content:encoded), if the feed has it
+	Enclosures  []Enclosure `xml:"enclosure"`                                           // Attachments (podcast audio, images...)
+	Author      string      `xml:"author"`                                              // Post author (often an email address)
+	Creator     string      `xml:"http://purl.org/dc/elements/1.1/ creator"`            // Post author, Dublin Core style (most blogs)
+	Categories  []string    `xml:"category"`                                            // Post categories/tags
+	OrigLink    string      `xml:"http://rssnamespace.org/feedburner/ext/1.0 origLink"` // The real post URL, when Link is a feedburner redirect
+}
+
+// Length is the size in bytes, kept as a string as feeds often send "" or junk
+type Enclosure struct {
+	URL    string `xml:"url,attr"`    // Attachment URL
+	Type   string `xml:"type,attr"`   // MIME type
+	Length string `xml:"length,attr"` // Size in bytes
 }
 
-// our RSS fetchfeed function
+// raw fetched feed, before any parsing (for snapshots)
+type Raw struct {
+	Body        []byte // response body, as sent
+	ContentType string // Content-Type header
+	StatusCode  int    // http status code
+}
+
+// our RSS fetchfeed function, with the default client
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	return Default.FetchFeed(ctx, feedURL)
+}
+
+// fetch a feed's raw body with the default client
+func FetchRaw(ctx context.Context, feedURL string) (*Raw, error) {
+	return Default.FetchRaw(ctx, feedURL)
+}
+
+// fetch and parse a feed
+func (c *Client) FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	// fetch the raw body
+	raw, err := c.FetchRaw(ctx, feedURL)
+
+	// fetch check
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the raw body
+	return Parse(feedURL, raw)
+}
+
+// fetch a feed's raw body, without parsing it
+// NOTE: split from FetchFeed, so the body can be kept even when it doesn't parse
+func (c *Client) FetchRaw(ctx context.Context, feedURL string) (*Raw, error) {
 	// handle empty url
 	if feedURL == "" {
 		return nil, fmt.Errorf("feed URL is empty")
@@ -64,17 +226,13 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	req.Header.Set("Accept", "application/rss+xml")
 	// this tells the server that we expect an RSS feed in XML format
 
-	// HTTP client
-	client := &http.Client{}
-	// client is used to send the HTTP request and get the response
-
 	// set the user agent after request created but before sending the request
-	req.Header.Set("User-Agent", "Gator/0.1 (+https://github.com/PietPadda/aggregator)")
+	req.Header.Set("User-Agent", c.userAgent)
 	// common practice for web scraping, to identify the client making the request
 	// and to avoid being blocked by the server
 
-	// Client do request
-	res, err := client.Do(req)
+	// Client do request (the shared client, so connections are reused)
+	res, err := c.http.Do(req)
 	// res is the client response to the HTTP request
 
 	// Do check
@@ -85,14 +243,6 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	// Close response body AFTER confirming non-nil response
 	defer res.Body.Close()
 
-	// response content type check
-	resType := res.Header.Get("Content-Type")
-
-	// response xml content type check
-	if !strings.Contains(resType, "xml") {
-		return nil, fmt.Errorf("invalid content type: %s", res.Header.Get("Content-Type"))
-	}
-
 	// get server status code
 	statusCode := res.StatusCode
 	// check if the status code is in the 2xx range
@@ -112,12 +262,117 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
+	// response content type
+	resType := res.Header.Get("Content-Type")
+
+	// log the http details (-vv only)
+	slog.Log(ctx, logging.LevelTrace, "fetched feed body", "url", feedURL, "status", res.StatusCode, "content_type", resType, "bytes", len(data))
+
+	// return the raw feed
+	return &Raw{Body: data, ContentType: resType, StatusCode: res.StatusCode}, nil
+}
+
+// most of a web page or image fetchFile reads, articles and pictures are far smaller
+const maxFileBytes = 5 << 20
+
+// fetch a web page's html (a post's article, for extracting its full content)
+func (c *Client) FetchPage(ctx context.Context, pageURL string) ([]byte, error) {
+	return c.fetchFile(ctx, pageURL, "text/html,application/xhtml+xml", "html")
+}
+
+// fetch an image (a post's picture, for the image cache)
+func (c *Client) FetchImage(ctx context.Context, imageURL string) ([]byte, error) {
+	return c.fetchFile(ctx, imageURL, "image/*", "image")
+}
+
+// follow a link's redirects to where it ends up (unshortening t.co, bit.ly... links)
+// NOTE: a HEAD request, or a GET for servers that refuse HEAD (its body is never read)
+func (c *Client) Resolve(ctx context.Context, link string) (string, error) {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		// HTTP request
+		req, err := http.NewRequestWithContext(ctx, method, link, nil)
+
+		// HTTP request check
+		if err != nil {
+			return "", fmt.Errorf("error creating HTTP request: %w", err)
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+
+		// Client do request, following the redirects
+		res, err := c.http.Do(req)
+
+		// Do check
+		if err != nil {
+			return "", fmt.Errorf("error resolving link: %w", err)
+		}
+		res.Body.Close()
+
+		// refused HEAD check, try a GET
+		if method == http.MethodHead && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusNotImplemented) {
+			continue
+		}
+
+		// status check, a dead end isn't the real link
+		if res.StatusCode > 299 {
+			return "", fmt.Errorf("error resolving link: %s", res.Status)
+		}
+		return res.Request.URL.String(), nil
+	}
+	return "", fmt.Errorf("error resolving link: refused")
+}
+
+// fetch a file that isn't a feed helper, refusing other content types than kind (unless the server sends none)
+func (c *Client) fetchFile(ctx context.Context, fileURL, accept, kind string) ([]byte, error) {
+	// HTTP get request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+
+	// HTTP request check
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	// Client do request
+	res, err := c.http.Do(req)
+
+	// Do check
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", kind, err)
+	}
+	defer res.Body.Close()
+
+	// status check
+	if res.StatusCode > 299 {
+		return nil, fmt.Errorf("error fetching %s: %s", kind, res.Status)
+	}
+
+	// content type check (a page that's a pdf has no article, an "image" that's html is an error page)
+	if resType := res.Header.Get("Content-Type"); resType != "" && !strings.Contains(resType, kind) {
+		return nil, fmt.Errorf("error fetching %s: got %s", kind, resType)
+	}
+
+	// read the body, capped
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxFileBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	return data, nil
+}
+
+// parse a raw fetched feed into an RSSFeed
+func Parse(feedURL string, raw *Raw) (*RSSFeed, error) {
+	// response xml content type check
+	if !strings.Contains(raw.ContentType, "xml") {
+		return nil, fmt.Errorf("invalid content type: %s", raw.ContentType)
+	}
+
 	// create RSSFeed instance initialised with empty fields
 	// this is the struct that will hold the unmarshalled XML data
 	var feed RSSFeed
 
 	// unmarshal XML data into go struct
-	err = xml.Unmarshal(data, &feed)
+	err := xml.Unmarshal(raw.Body, &feed)
 	// it takes the byte slice data and converts it into the RSSFeed struct
 
 	// unmarshal check
@@ -129,22 +384,22 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	// 4 fundamental checks: title, link, description, items > 0
 	// check if the feed has a title
 	if feed.Channel.Title == "" {
-		fmt.Println("Warning: feed has no title")
+		slog.Warn("feed has no title", "url", feedURL)
 	}
 
 	// check if the feed has a link
 	if feed.Channel.Link == "" {
-		fmt.Println("Warning: feed has no link")
+		slog.Warn("feed has no link", "url", feedURL)
 	}
 
 	// check if the feed has a description
 	if feed.Channel.Description == "" {
-		fmt.Println("Warning: feed has no description")
+		slog.Warn("feed has no description", "url", feedURL)
 	}
 
 	// check if the feed is empty
 	if len(feed.Channel.Items) == 0 {
-		fmt.Println("Warning: feed has no items")
+		slog.Warn("feed has no items", "url", feedURL)
 	}
 
 	// Unescape the HTML entitites
@@ -163,9 +418,19 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for i := range feed.Channel.Items {
 		feed.Channel.Items[i].Title = html.UnescapeString(feed.Channel.Items[i].Title)
 		feed.Channel.Items[i].Link = html.UnescapeString(feed.Channel.Items[i].Link)
+		feed.Channel.Items[i].OrigLink = html.UnescapeString(feed.Channel.Items[i].OrigLink)
 		feed.Channel.Items[i].PubDate = html.UnescapeString(feed.Channel.Items[i].PubDate)
 		feed.Channel.Items[i].GUID = html.UnescapeString(feed.Channel.Items[i].GUID)
 		feed.Channel.Items[i].Description = html.UnescapeString(feed.Channel.Items[i].Description)
+		feed.Channel.Items[i].Content = html.UnescapeString(feed.Channel.Items[i].Content)
+		feed.Channel.Items[i].Author = html.UnescapeString(feed.Channel.Items[i].Author)
+		feed.Channel.Items[i].Creator = html.UnescapeString(feed.Channel.Items[i].Creator)
+		for j := range feed.Channel.Items[i].Categories {
+			feed.Channel.Items[i].Categories[j] = html.UnescapeString(feed.Channel.Items[i].Categories[j])
+		}
+		for j := range feed.Channel.Items[i].Enclosures {
+			feed.Channel.Items[i].Enclosures[j].URL = html.UnescapeString(feed.Channel.Items[i].Enclosures[j].URL)
+		}
 	}
 
 	// return the feed
@@ -0,0 +1,57 @@
+// service.go
+package rssfeed
+
+import (
+	// std go libraries
+	"net/http"    // service clients
+	"sync/atomic" // the shared client is swapped on config reloads
+	"time"        // request timeouts
+)
+
+// the client every other outbound request goes through (llm, push, webhooks, telegram...), see Service
+// NOTE: main sets it from the config's http section, so a proxy or user agent applies to them too
+var shared atomic.Pointer[Client]
+
+// set the client services' requests go through, eg after reading (or reloading) the config
+func SetShared(c *Client) {
+	shared.Store(c)
+}
+
+// an http client for a service, through the shared client's transport (proxy, dns cache, connect timeouts)
+// timeout is the whole request's, a service's differs from a feed fetch's (eg an llm answer or a long poll)
+func Service(timeout time.Duration) *http.Client {
+	// set check, Default until main sets it
+	c := shared.Load()
+	if c == nil {
+		c = Default
+	}
+	return c.Service(timeout)
+}
+
+// an http client for a service, through this client's transport
+func (c *Client) Service(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     userAgentTransport{next: c.http.Transport, userAgent: c.userAgent},
+		CheckRedirect: c.http.CheckRedirect,
+	}
+}
+
+// a transport that sends the configured user agent, unless the service set its own
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+// send a request with the user agent
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// own user agent check
+	if req.Header.Get("User-Agent") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	// a round tripper mustn't change the request, so set it on a copy
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,48 @@
+// service_test.go
+package rssfeed
+
+import (
+	// std go libraries
+	"net/http"          // requests
+	"net/http/httptest" // a local service
+	"testing"           // go tests
+	"time"              // request timeouts
+)
+
+// services send the configured user agent through the shared client, unless they set their own
+func TestService(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+	}))
+	defer srv.Close()
+
+	SetShared(NewClient(Options{MaxRedirects: -1, UserAgent: "gator-test", NoDNSCache: true}))
+	defer SetShared(nil)
+	client := Service(time.Second)
+	if client.Timeout != time.Second {
+		t.Errorf("timeout %v, want the service's 1s", client.Timeout)
+	}
+
+	// configured user agent, then the service's own
+	for _, own := range []string{"", "my-service"} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatalf("error creating request: %v", err)
+		}
+		if own != "" {
+			req.Header.Set("User-Agent", own)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("error sending request: %v", err)
+		}
+		res.Body.Close()
+		if req.Header.Get("User-Agent") != own {
+			t.Errorf("the caller's request was changed")
+		}
+	}
+	if len(got) != 2 || got[0] != "gator-test" || got[1] != "my-service" {
+		t.Errorf("user agents %q, want gator-test then my-service", got)
+	}
+}
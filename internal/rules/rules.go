@@ -0,0 +1,182 @@
+// rules.go
+package rules
+
+import (
+	// std go libraries
+	"bytes"         // webhook bodies
+	"context"       // cancelling webhooks
+	"encoding/json" // webhook bodies
+	"fmt"           // printing errors
+	"net/http"      // sending webhooks
+	"net/url"       // checking webhook urls
+	"regexp"        // title patterns
+	"slices"        // matching authors
+	"strings"       // matching categories and authors
+	"time"          // webhook timeout
+
+	// external packages
+	"github.com/google/uuid" // feed ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// what a rule does to the posts it matches
+const (
+	Mute    = "mute"    // hide the post from the user
+	Star    = "star"    // save the post, like fever's saved
+	Tag     = "tag"     // tag the post, the argument is the tag
+	Notify  = "notify"  // notify the user, the argument is the channel
+	Webhook = "webhook" // post the post as json to the argument's url
+)
+
+// the channels a notify rule can use
+const (
+	ChannelPush     = "push"     // the user's ntfy or pushover target (see notify)
+	ChannelTelegram = "telegram" // the user's linked telegram chat (see telegram)
+)
+
+// a compiled rule
+type Rule struct {
+	FeedID   uuid.NullUUID  // the post's feed, invalid = any
+	Title    *regexp.Regexp // matches the post's title, nil = any
+	Category string         // one of the post's categories, any case ("" = any)
+	Author   string         // part of the post's author, any case ("" = any)
+	Action   string
+	Argument string
+}
+
+// webhooks get this long to answer
+const webhookTimeout = 10 * time.Second
+
+// shared client for webhooks, sent like feed fetches (proxy and user agent from the http config)
+func webhookClient() *http.Client {
+	return rssfeed.Service(webhookTimeout)
+}
+
+// what rules see of a post
+type Post struct {
+	FeedID     uuid.UUID
+	Title      string
+	Categories []string
+	Authors    []string // rss author and dc:creator, whichever the feed sends
+}
+
+// compile a rule's title pattern
+func Compile(pattern string) (*regexp.Regexp, error) {
+	// no pattern check
+	if pattern == "" {
+		return nil, nil
+	}
+
+	// compile check
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("error: invalid title pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// check an action and its argument
+func CheckAction(action, argument string) error {
+	switch action {
+	case Mute, Star:
+		if argument != "" {
+			return fmt.Errorf("error: %s takes no argument", action)
+		}
+		return nil
+	case Tag:
+		if strings.TrimSpace(argument) == "" {
+			return fmt.Errorf("error: tag needs the tag, eg: tag golang")
+		}
+		return nil
+	case Notify:
+		if argument != ChannelPush && argument != ChannelTelegram {
+			return fmt.Errorf("error: notify needs a channel: push (see notify) or telegram (see telegram)")
+		}
+		return nil
+	case Webhook:
+		u, err := url.Parse(argument)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("error: webhook needs an http or https url, eg: webhook https://example.com/hook")
+		}
+		return nil
+	}
+	return fmt.Errorf("error: unknown action %q (use mute, star, tag, notify or webhook)", action)
+}
+
+// whether a post matches the rule, every set condition must hold
+func (r Rule) Matches(post Post) bool {
+	// feed check
+	if r.FeedID.Valid && r.FeedID.UUID != post.FeedID {
+		return false
+	}
+
+	// title check
+	if r.Title != nil && !r.Title.MatchString(post.Title) {
+		return false
+	}
+
+	// category check
+	if r.Category != "" && !containsFold(post.Categories, r.Category) {
+		return false
+	}
+
+	// author check, a part is enough as rss authors are often "email (name)"
+	if r.Author != "" && !slices.ContainsFunc(post.Authors, func(author string) bool {
+		return strings.Contains(strings.ToLower(author), strings.ToLower(strings.TrimSpace(r.Author)))
+	}) {
+		return false
+	}
+	return true
+}
+
+// case-insensitive membership helper, ignoring surrounding spaces
+func containsFold(values []string, want string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// a post as a webhook rule sends it
+type WebhookPost struct {
+	Feed        string     `json:"feed"`
+	FeedURL     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Categories  []string   `json:"categories,omitempty"`
+}
+
+// post a matched post to a webhook rule's url as json, erroring on anything but 2xx
+func SendWebhook(ctx context.Context, hookURL string, post WebhookPost) error {
+	// encode the post
+	body, err := json.Marshal(post)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook post: %w", err)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// send it
+	res, err := webhookClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	// status check
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("error: webhook answered %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// cron.go
+package scheduler
+
+import (
+	// std go libraries
+	"fmt"     // printing errors
+	"strconv" // parsing field values
+	"strings" // splitting fields
+	"time"    // next times and time zones
+)
+
+// a parsed cron expression, the 5 standard fields as bit sets
+// NOTE: minute hour day-of-month month day-of-week, like crontab(5), with an optional CRON_TZ=<zone> prefix
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool           // * in the field, see matchDay
+	location                      *time.Location // the zone the fields are in, local by default
+	expr                          string         // as written, for printing
+}
+
+// a cron field's range and names
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+// the 5 fields, in order
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{ // 0 and 7 are both sunday
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// shorthands for common schedules
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// how far ahead Next looks, a schedule like "0 0 30 2 *" never fires
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+// parse a cron expression, eg "0 7 * * mon-fri" or "CRON_TZ=Europe/Berlin */30 6-22 * * *"
+func ParseCron(expr string) (Cron, error) {
+	// create the cron with the local zone
+	cron := Cron{location: time.Local, expr: strings.TrimSpace(expr)}
+	spec := cron.expr
+
+	// time zone check
+	if rest, ok := strings.CutPrefix(spec, "CRON_TZ="); ok {
+		zone, fields, _ := strings.Cut(rest, " ")
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return Cron{}, fmt.Errorf("error: unknown cron time zone %q: %w", zone, err)
+		}
+		cron.location, spec = location, strings.TrimSpace(fields)
+	}
+
+	// macro check
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	// field count check
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("error: cron expression %q needs 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+
+	// parse each field
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return Cron{}, fmt.Errorf("error: cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+	cron.minute, cron.hour, cron.dom, cron.month, cron.dow = sets[0], sets[1], sets[2], sets[3], sets[4]
+	cron.domAny, cron.dowAny = strings.HasPrefix(fields[2], "*"), strings.HasPrefix(fields[4], "*")
+
+	// sunday is both 0 and 7
+	if cron.dow&(1<<7) != 0 {
+		cron.dow |= 1
+	}
+	return cron, nil
+}
+
+// parse one cron field helper, eg "*", "*/15", "1-5", "mon,wed,fri" or "0-30/10"
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		// get the step
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", spec.name, stepPart)
+			}
+			step = parsed
+		}
+
+		// get the range
+		low, high := spec.min, spec.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			low, err = cronValue(lowPart, spec)
+			if err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				high, err = cronValue(highPart, spec)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = spec.max // "5/15" is 5 to the end, every 15
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid %s range %q", spec.name, rangePart)
+			}
+		}
+
+		// add the values
+		for value := low; value <= high; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// parse one cron value helper, a number or a name (jan, mon, ...)
+func cronValue(value string, spec cronField) (int, error) {
+	// name check
+	if number, ok := spec.names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+
+	// number check
+	number, err := strconv.Atoi(value)
+	if err != nil || number < spec.min || number > spec.max {
+		return 0, fmt.Errorf("invalid %s %q (use %d-%d)", spec.name, value, spec.min, spec.max)
+	}
+	return number, nil
+}
+
+// the next time after t the schedule fires, the zero time if it never does
+// NOTE: times are wall clock times in the schedule's zone (local by default), so on DST changes
+// a time in the skipped hour doesn't fire that day, and one in the repeated hour fires twice
+func (c Cron) Next(t time.Time) time.Time {
+	// start at the next whole minute, in the schedule's zone
+	t = t.In(c.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronHorizon)
+
+	// skip ahead field by field, the biggest first
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.location)
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.location)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute) // by duration, so DST changes don't loop
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// does the day match helper, like cron: when both day fields are restricted either may match
+func (c Cron) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// the expression as written method
+func (c Cron) String() string {
+	return c.expr
+}
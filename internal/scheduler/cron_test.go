@@ -0,0 +1,118 @@
+// cron_test.go
+package scheduler
+
+import (
+	// std go libraries
+	"testing" // go tests
+	"time"    // next times and time zones
+)
+
+// the next run of a schedule, in UTC so the test doesn't depend on the machine's zone
+func TestCronNext(t *testing.T) {
+	// 2026-03-02 is a monday
+	from := time.Date(2026, 3, 2, 10, 7, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", from, time.Date(2026, 3, 2, 10, 8, 0, 0, time.UTC)},
+		{"seconds round up to the next minute", "* * * * *", from.Add(30 * time.Second), time.Date(2026, 3, 2, 10, 8, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", from, time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC)},
+		{"step from a start", "5/20 * * * *", from, time.Date(2026, 3, 2, 10, 25, 0, 0, time.UTC)},
+		{"step in a range", "0-30/10 * * * *", from.Add(25 * time.Minute), time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)},
+		{"hour range", "0 6-8 * * *", from, time.Date(2026, 3, 3, 6, 0, 0, 0, time.UTC)},
+		{"list", "0 9,12,18 * * *", from, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)},
+		{"weekday names", "0 7 * * mon-fri", time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 7, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", from, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 jun *", from, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"next year", "0 0 1 1 *", from, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"macro", "@daily", from, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", from, time.Time{}},
+
+		// like cron, when both day fields are restricted either one matching is enough
+		{"day of month or day of week, the weekday first", "0 0 15 * fri", from, time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"day of month or day of week, the date first", "0 0 3 * fri", from, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"day of month with any weekday", "0 0 15 * *", from, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"weekday with any day of month", "0 0 * * fri", from, time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"stepped day of week is still any", "0 0 15 * */2", from, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron("CRON_TZ=UTC " + tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+			got := cron.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+// a run in the hour the clocks skip doesn't happen that day, one in the hour they repeat happens twice
+func TestCronNextDST(t *testing.T) {
+	// zone check, LoadLocation needs the system's zoneinfo
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// 2026-03-29 02:00 CET jumps to 03:00 CEST
+		{"skipped hour", "30 2 * * *", time.Date(2026, 3, 29, 0, 0, 0, 0, berlin), time.Date(2026, 3, 30, 2, 30, 0, 0, berlin)},
+		{"after the skipped hour", "30 3 * * *", time.Date(2026, 3, 29, 0, 0, 0, 0, berlin), time.Date(2026, 3, 29, 3, 30, 0, 0, berlin)},
+		{"every hour across the skip", "0 * * * *", time.Date(2026, 3, 29, 1, 0, 0, 0, berlin), time.Date(2026, 3, 29, 3, 0, 0, 0, berlin)},
+
+		// 2026-10-25 03:00 CEST goes back to 02:00 CET, so 02:30 happens at 00:30 and 01:30 UTC
+		{"repeated hour, first", "30 2 * * *", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC)},
+		{"repeated hour, second", "30 2 * * *", time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC), time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC)},
+		{"after the repeated hour", "30 2 * * *", time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC), time.Date(2026, 10, 26, 2, 30, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron("CRON_TZ=Europe/Berlin " + tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+			got := cron.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+// bad expressions are refused, with the field in the error
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"CRON_TZ=Nowhere/Atlantis * * * * *",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCron(expr); err == nil {
+				t.Errorf("ParseCron(%q) error = nil, want an error", expr)
+			}
+		})
+	}
+}
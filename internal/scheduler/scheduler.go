@@ -0,0 +1,109 @@
+// scheduler.go
+package scheduler
+
+import (
+	// std go libraries
+	"fmt"  // printing errors
+	"time" // due times and intervals
+
+	// external packages
+	"github.com/google/uuid" // feed ids of held feeds
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for Feed struct from SQLC
+)
+
+// get a feed's own refresh interval, falling back to the global (agg) interval
+func Interval(feed database.Feed, fallback time.Duration) time.Duration {
+	// feed has its own interval? use it!
+	if feed.RefreshIntervalSeconds.Valid && feed.RefreshIntervalSeconds.Int32 > 0 {
+		return time.Duration(feed.RefreshIntervalSeconds.Int32) * time.Second
+	}
+
+	// otherwise use the global one
+	return fallback
+}
+
+// compute when a feed is next due to be fetched
+// NOTE: a failing feed isn't due before its backoff ends, whatever its schedule says
+func NextDue(feed database.Feed, fallback time.Duration) time.Time {
+	due := scheduledDue(feed, fallback)
+
+	// backing off check, failed fetches don't move last_fetched_at
+	if feed.NextAttemptAt.Valid && feed.NextAttemptAt.Time.After(due) {
+		return feed.NextAttemptAt.Time
+	}
+	return due
+}
+
+// compute when a feed's schedule says it's due helper
+func scheduledDue(feed database.Feed, fallback time.Duration) time.Time {
+	// cron feed check, agg stored when it's due after each fetch
+	if feed.RefreshCron.Valid {
+		return feed.NextFetchAt.Time // zero (due right away) until it's set
+	}
+
+	// never fetched check
+	if !feed.LastFetchedAt.Valid {
+		return time.Time{} // zero time, ie due right away!
+	}
+
+	// due one interval after the last fetch
+	return feed.LastFetchedAt.Time.Add(Interval(feed, fallback))
+}
+
+// compute how long to wait until the next feed is due
+// held are feeds their fetch jobs hold back until a time (zero = until the job finishes)
+// NOTE: never waits longer than fallback, so newly added feeds get picked up
+func NextWake(feeds []database.Feed, held map[uuid.UUID]time.Time, now time.Time, fallback time.Duration) time.Duration {
+	// start with the longest wait
+	wait := fallback
+
+	// find the soonest due feed
+	for _, feed := range feeds {
+		// time until this feed is due
+		due := NextDue(feed, fallback)
+
+		// held back check, due once its job lets go (whichever is later)
+		if until, ok := held[feed.ID]; ok {
+			if until.IsZero() {
+				continue // being fetched right now
+			}
+			if until.After(due) {
+				due = until
+			}
+		}
+		untilDue := due.Sub(now)
+
+		// sooner check
+		if untilDue < wait {
+			wait = untilDue
+		}
+	}
+
+	// overdue check (don't return negative waits)
+	if wait < 0 {
+		wait = 0
+	}
+
+	// return the wait
+	return wait
+}
+
+// compute when a cron schedule is next due after now, in UTC like last_fetched_at
+func NextCronFetch(expr string, now time.Time) (time.Time, error) {
+	// parse the schedule
+	cron, err := ParseCron(expr)
+
+	// parse check
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// never fires check (eg "0 0 30 2 *"), it would be due forever
+	next := cron.Next(now)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("error: cron expression %q never fires", expr)
+	}
+	return next.UTC(), nil
+}
@@ -0,0 +1,39 @@
+// scheduler_test.go
+package scheduler
+
+import (
+	// std go libraries
+	"database/sql" // nullable feed times
+	"testing"      // go tests
+	"time"         // due times and intervals
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/database" // for Feed struct from SQLC
+)
+
+// a failing feed waits out its backoff instead of being due again right away
+func TestNextWakeBackoff(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fallback := 10 * time.Minute
+	fetched := sql.NullTime{Time: now.Add(-time.Hour), Valid: true} // long overdue
+
+	tests := []struct {
+		name string
+		feed database.Feed
+		want time.Duration
+	}{
+		{"overdue", database.Feed{LastFetchedAt: fetched}, 0},
+		{"backing off", database.Feed{LastFetchedAt: fetched, NextAttemptAt: sql.NullTime{Time: now.Add(4 * time.Minute), Valid: true}}, 4 * time.Minute},
+		{"backoff over", database.Feed{LastFetchedAt: fetched, NextAttemptAt: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}}, 0},
+		{"never fetched, backing off", database.Feed{NextAttemptAt: sql.NullTime{Time: now.Add(2 * time.Minute), Valid: true}}, 2 * time.Minute},
+		{"due later than the backoff", database.Feed{LastFetchedAt: sql.NullTime{Time: now, Valid: true}, NextAttemptAt: sql.NullTime{Time: now.Add(time.Minute), Valid: true}}, fallback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextWake([]database.Feed{tt.feed}, nil, now, fallback)
+			if got != tt.want {
+				t.Errorf("NextWake = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// summarize.go
+package summarize
+
+import (
+	// std go libraries
+	"bytes"   // command output
+	"context" // cancelling summaries
+	"fmt"     // printing errors
+	"html"    // unescaping descriptions
+	"os/exec" // the command provider
+	"strings" // cleaning text
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/llm" // openai-compatible completions
+)
+
+// the summarizer providers
+const (
+	OpenAI  = "openai"  // any openai-compatible chat completions api, hosted or local (ollama, llama.cpp...)
+	Command = "command" // a local program, gets the post on stdin and prints the summary
+)
+
+// package-wide constants
+const (
+	MinText  = 300  // posts with less text than this aren't worth summarizing (they're short already)
+	maxText  = 8000 // most characters of a post sent to the summarizer, to keep costs down
+	maxReply = 1000 // most characters of a summary kept, in case the model rambles
+)
+
+// the instructions the llm gets
+const prompt = "Summarize the article the user sends in 2 to 3 sentences, in the article's language. " +
+	"Reply with only the summary, no introduction."
+
+// summarizes posts
+type Summarizer interface {
+	// a 2-3 sentence summary of a post, its title and text (plain, see Text)
+	Summarize(ctx context.Context, title, text string) (string, error)
+}
+
+// how to reach a provider
+type Options struct {
+	Provider string // OpenAI or Command
+	URL      string // openai api url ("" = llm.DefaultURL)
+	APIKey   string // openai api key ("" = none, fine for local servers)
+	Model    string // openai model ("" = llm.DefaultModel)
+	Command  string // the command provider's program and its args, split on spaces
+}
+
+// create the provider's summarizer, erroring if the options don't make sense for it
+func New(opts Options) (Summarizer, error) {
+	switch opts.Provider {
+	case OpenAI:
+		return openAI{llm.Options{URL: opts.URL, APIKey: opts.APIKey, Model: opts.Model}}, nil
+	case Command:
+		// command check
+		args := strings.Fields(opts.Command)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("error: the command summarizer needs a command, eg summarize.command \"/usr/local/bin/summarize-post --sentences 3\"")
+		}
+		return command{args}, nil
+	}
+	return nil, fmt.Errorf("error: unknown summarizer provider %q (use openai or command)", opts.Provider)
+}
+
+// an openai-compatible api
+type openAI struct{ opts llm.Options }
+
+// summarize with an llm
+func (o openAI) Summarize(ctx context.Context, title, text string) (string, error) {
+	reply, err := llm.Complete(ctx, o.opts, prompt, title+"\n\n"+text)
+	if err != nil {
+		return "", err
+	}
+	return clean(reply)
+}
+
+// a local program
+type command struct{ args []string }
+
+// summarize with the program, the title and text on its stdin
+func (c command) Summarize(ctx context.Context, title, text string) (string, error) {
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdin = strings.NewReader(title + "\n\n" + text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	// run it
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return "", fmt.Errorf("error running %s: %w: %s", c.args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error running %s: %w", c.args[0], err)
+	}
+	return clean(string(out))
+}
+
+// the plain text of a post's description (html stripped, whitespace collapsed, capped at maxText)
+func Text(description string) string {
+	// drop the tags, descriptions are often html
+	var out strings.Builder
+	inTag := false
+	for _, r := range description {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+			out.WriteRune(' ')
+		case !inTag:
+			out.WriteRune(r)
+		}
+	}
+
+	// unescape entities, collapse whitespace, and cap it
+	text := []rune(strings.Join(strings.Fields(html.UnescapeString(out.String())), " "))
+	if len(text) > maxText {
+		text = text[:maxText]
+	}
+	return string(text)
+}
+
+// tidy a summary helper, erroring on an empty one
+func clean(summary string) (string, error) {
+	summary = strings.Join(strings.Fields(summary), " ")
+
+	// empty check
+	if summary == "" {
+		return "", fmt.Errorf("error: the summarizer returned an empty summary")
+	}
+	if runes := []rune(summary); len(runes) > maxReply {
+		summary = strings.TrimSpace(string(runes[:maxReply])) + "…"
+	}
+	return summary, nil
+}
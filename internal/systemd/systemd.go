@@ -0,0 +1,70 @@
+// systemd.go
+package systemd
+
+import (
+	// std go libraries
+	"fmt"     // printing errors
+	"net"     // the notify socket
+	"os"      // systemd's env vars
+	"strconv" // parsing the watchdog env vars
+	"strings" // joining states
+	"time"    // watchdog interval
+)
+
+// notification states (see sd_notify(3))
+const (
+	Ready     = "READY=1"    // started up, systemctl start returns
+	Stopping  = "STOPPING=1" // shutting down
+	Watchdog  = "WATCHDOG=1" // still alive, resets the watchdog timer
+	statusKey = "STATUS="    // free text shown by systemctl status
+)
+
+// tell systemd about the service's state, like sd_notify (several states are sent together)
+// returns false (and no error) when not run by systemd with Type=notify, ie there's no NOTIFY_SOCKET
+func Notify(states ...string) (bool, error) {
+	// notify socket check
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// connect to the socket (a leading @ is an abstract socket, which net handles)
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+
+	// dial check
+	if err != nil {
+		return false, fmt.Errorf("error connecting to systemd's notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	// send the states, one per line
+	_, err = conn.Write([]byte(strings.Join(states, "\n")))
+
+	// write check
+	if err != nil {
+		return false, fmt.Errorf("error notifying systemd: %w", err)
+	}
+	return true, nil
+}
+
+// a STATUS= notification, shown by systemctl status
+func Status(status string) string {
+	return statusKey + status
+}
+
+// how often systemd expects watchdog pings (WatchdogSec= in the unit), 0 if the watchdog is off
+func WatchdogInterval() time.Duration {
+	// meant for this process check (WATCHDOG_PID is optional)
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	// parse the interval
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+
+	// interval check (unset or junk = off)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,173 @@
+// telegram.go
+package telegram
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"encoding/json" // the bot api speaks json
+	"fmt"           // printing errors
+	"net/http"      // calling the bot api
+	"strings"       // splitting long messages
+	"time"          // long poll timeouts
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport
+)
+
+// package-wide constants
+const (
+	apiURL      = "https://api.telegram.org/bot" // + token + "/" + method
+	MaxMessage  = 4096                           // longest message telegram accepts, in characters
+	pollTimeout = 30 * time.Second               // how long getUpdates waits for new messages
+)
+
+// a bot api client
+type Client struct {
+	token string
+	http  *http.Client
+}
+
+// an incoming update, only messages are asked for
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// a message sent to the bot
+type Message struct {
+	Chat Chat   `json:"chat"`
+	Text string `json:"text"`
+}
+
+// the chat a message came from
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// the bot itself, from getMe
+type User struct {
+	Username string `json:"username"`
+}
+
+// the bot api's response envelope
+type response struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+// create a client for a bot token
+func New(token string) *Client {
+	return &Client{
+		token: token,
+		http:  rssfeed.Service(pollTimeout + 30*time.Second), // long polls hold the request open, the http config's proxy applies
+	}
+}
+
+// get the bot, also checks the token
+func (c *Client) GetMe(ctx context.Context) (User, error) {
+	var me User
+	err := c.call(ctx, "getMe", struct{}{}, &me)
+	return me, err
+}
+
+// wait for new messages after offset (the last update id + 1), up to pollTimeout
+func (c *Client) GetUpdates(ctx context.Context, offset int64) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(pollTimeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+// send a plain text message to a chat, split in several if it's too long
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	for _, part := range Split(text) {
+		err := c.call(ctx, "sendMessage", map[string]any{
+			"chat_id":                  chatID,
+			"text":                     part,
+			"disable_web_page_preview": true,
+		}, nil)
+
+		// send check
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// call a bot api method helper, decoding its result into result (nil = not needed)
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
+	// encode the params
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("error encoding telegram %s: %w", method, err)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating telegram %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// send it (errors hold the url, which holds the token, so they're not wrapped)
+	res, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("error calling telegram %s: request failed", method)
+	}
+	defer res.Body.Close()
+
+	// decode the envelope
+	var envelope response
+	err = json.NewDecoder(res.Body).Decode(&envelope)
+	if err != nil {
+		return fmt.Errorf("error decoding telegram %s response (status %d): %w", method, res.StatusCode, err)
+	}
+
+	// ok check
+	if !envelope.OK {
+		return fmt.Errorf("error: telegram %s failed: %s", method, envelope.Description)
+	}
+
+	// decode the result
+	if result == nil {
+		return nil
+	}
+	err = json.Unmarshal(envelope.Result, result)
+	if err != nil {
+		return fmt.Errorf("error decoding telegram %s result: %w", method, err)
+	}
+	return nil
+}
+
+// split a message into parts telegram accepts helper, at line breaks where possible
+func Split(text string) []string {
+	parts := []string{}
+	for {
+		runes := []rune(text)
+
+		// short enough check
+		if len(runes) <= MaxMessage {
+			if strings.TrimSpace(text) != "" {
+				parts = append(parts, text)
+			}
+			return parts
+		}
+
+		// cut at the last line break that fits, else anywhere
+		cut := strings.LastIndex(string(runes[:MaxMessage]), "\n")
+		if cut <= 0 {
+			cut = len(string(runes[:MaxMessage]))
+		}
+		parts = append(parts, text[:cut])
+		text = strings.TrimLeft(text[cut:], "\n")
+	}
+}
@@ -0,0 +1,192 @@
+// telemetry.go
+package telemetry
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"encoding/json" // the counts file and reports
+	"errors"        // matching a missing file
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"io/fs"         // a missing file
+	"net/http"      // posting reports
+	"os"            // the counts file
+	"path/filepath" // its dir
+	"runtime"       // os, arch and go version
+	"runtime/debug" // gator's version
+	"strings"       // error bodies
+	"time"          // report times
+
+	// external packages
+	"github.com/google/uuid" // anonymous ids
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// package-wide constants
+const (
+	Interval       = 24 * time.Hour   // how often agg sends a report
+	requestTimeout = 15 * time.Second // how long sending one may take
+)
+
+// shared client for reports (the http section's proxy applies to them too)
+func client() *http.Client {
+	return rssfeed.Service(requestTimeout)
+}
+
+// what's kept between reports, in ~/.gator/telemetry.json
+// NOTE: the id is random, made when telemetry is turned on, it only tells one install's reports apart
+type Counts struct {
+	ID       string           `json:"id"`
+	Commands map[string]int64 `json:"commands"` // runs per command since the last report
+	Since    time.Time        `json:"since"`    // when counting started (the last report, or turning it on)
+	LastSent time.Time        `json:"last_sent,omitzero"`
+}
+
+// a usage report, everything that's sent (telemetry status prints it)
+// NOTE: no names, urls, post contents or addresses, only counts and what gator runs on
+type Report struct {
+	ID        string           `json:"id"`
+	Version   string           `json:"version"`
+	GoVersion string           `json:"go_version"`
+	OS        string           `json:"os"`
+	Arch      string           `json:"arch"`
+	Dialect   string           `json:"dialect"`  // postgres, cockroachdb or neon
+	Commands  map[string]int64 `json:"commands"` // runs per command, since Since
+	Users     int64            `json:"users"`
+	Feeds     int64            `json:"feeds"`
+	Since     time.Time        `json:"since"`
+	Until     time.Time        `json:"until"`
+}
+
+// start counting, with a new random id
+func New() Counts {
+	return Counts{ID: uuid.NewString(), Commands: map[string]int64{}, Since: time.Now().UTC()}
+}
+
+// read the counts file, false if there's none (telemetry was never on, or was turned off)
+func Load(path string) (Counts, bool, error) {
+	// read it
+	data, err := os.ReadFile(path)
+
+	// missing check
+	if errors.Is(err, fs.ErrNotExist) {
+		return Counts{}, false, nil
+	}
+
+	// read check
+	if err != nil {
+		return Counts{}, false, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	// decode it
+	var counts Counts
+	err = json.Unmarshal(data, &counts)
+	if err != nil || counts.ID == "" {
+		return Counts{}, false, fmt.Errorf("error: %s is not a telemetry counts file, remove it (telemetry off)", path)
+	}
+	if counts.Commands == nil {
+		counts.Commands = map[string]int64{}
+	}
+	return counts, true, nil
+}
+
+// write the counts file, replacing it whole so a crash never leaves half of one
+func (c Counts) Save(path string) error {
+	// encode it
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding telemetry counts: %w", err)
+	}
+
+	// write it next to the old one, then swap them
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(path), err)
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o600)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// count a run of a command, if telemetry is on (there's a counts file)
+// NOTE: two commands finishing at once can lose one count, it's only a rough usage picture
+func Count(path, command string) error {
+	// on check
+	counts, ok, err := Load(path)
+	if err != nil || !ok {
+		return err
+	}
+	counts.Commands[command]++
+	return counts.Save(path)
+}
+
+// when the next report is due, a day after the last one (or after turning it on, so the first has counts to send)
+func (c Counts) NextDue() time.Time {
+	return c.Since.Add(Interval)
+}
+
+// the report of these counts, with the database's user and feed counts
+func (c Counts) Report(dialect string, users, feeds int64, now time.Time) Report {
+	return Report{
+		ID:        c.ID,
+		Version:   Version(),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Dialect:   dialect,
+		Commands:  c.Commands,
+		Users:     users,
+		Feeds:     feeds,
+		Since:     c.Since,
+		Until:     now.UTC(),
+	}
+}
+
+// gator's version, the module version go install built, "(devel)" for local builds
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "(devel)"
+	}
+	return info.Main.Version
+}
+
+// post a report as json to url
+func Send(ctx context.Context, url string, report Report) error {
+	// encode it
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("error encoding usage report: %w", err)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating usage report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// send it
+	res, err := client().Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending usage report to %s: %w", url, err)
+	}
+	defer res.Body.Close()
+
+	// status check, with the start of the body as servers explain errors there
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("error: %s returned %s: %s", url, res.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// translate.go
+package translate
+
+import (
+	// std go libraries
+	"bytes"         // request bodies
+	"context"       // cancelling requests
+	"encoding/json" // the providers' json apis
+	"fmt"           // printing errors
+	"io"            // reading error responses
+	"net/http"      // calling the providers
+	"strings"       // urls and replies
+	"time"          // request timeout
+
+	// internal packages
+	"github.com/PietPadda/aggregator/internal/llm"     // openai-compatible completions
+	"github.com/PietPadda/aggregator/internal/rssfeed" // the shared http transport (the config's http section)
+)
+
+// the translation providers
+const (
+	DeepL          = "deepl"          // deepl.com, needs an api key
+	LibreTranslate = "libretranslate" // a (usually self-hosted) libretranslate server, needs its url
+	OpenAI         = "openai"         // any openai-compatible chat completions api (openai, ollama, llama.cpp...)
+)
+
+// package-wide constants
+const (
+	deeplURL       = "https://api.deepl.com/v2/translate"
+	deeplFreeURL   = "https://api-free.deepl.com/v2/translate" // free plan keys end in :fx
+	requestTimeout = 60 * time.Second                          // how long a batch may take
+)
+
+// shared client for translations, a proxy in the http config is used here too
+func client() *http.Client {
+	return rssfeed.Service(requestTimeout)
+}
+
+// translates texts to a language
+type Translator interface {
+	// translate texts to target (an ISO 639-1 code), returns them in the same order
+	Translate(ctx context.Context, texts []string, target string) ([]string, error)
+}
+
+// how to reach a provider
+type Options struct {
+	Provider string // DeepL, LibreTranslate or OpenAI
+	URL      string // api url ("" = the provider's default, libretranslate has none)
+	APIKey   string // "" = none (fine for self-hosted servers)
+	Model    string // openai only ("" = llm.DefaultModel)
+}
+
+// create the provider's translator, erroring if the options don't make sense for it
+func New(opts Options) (Translator, error) {
+	switch opts.Provider {
+	case DeepL:
+		// key check
+		if opts.APIKey == "" {
+			return nil, fmt.Errorf("error: deepl needs an api key")
+		}
+		if opts.URL == "" {
+			opts.URL = deeplURL
+			if strings.HasSuffix(opts.APIKey, ":fx") {
+				opts.URL = deeplFreeURL
+			}
+		}
+		return deepl{opts}, nil
+	case LibreTranslate:
+		// url check
+		if opts.URL == "" {
+			return nil, fmt.Errorf("error: libretranslate needs the server's url, eg http://localhost:5000")
+		}
+		return libreTranslate{opts}, nil
+	case OpenAI:
+		return openAI{opts}, nil
+	}
+	return nil, fmt.Errorf("error: unknown translation provider %q (use deepl, libretranslate or openai)", opts.Provider)
+}
+
+// deepl's api
+type deepl struct{ opts Options }
+
+// translate with deepl, the whole batch in one request
+func (d deepl) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	var reply struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	header := http.Header{"Authorization": {"DeepL-Auth-Key " + d.opts.APIKey}}
+	err := postJSON(ctx, d.opts.URL, header, map[string]any{
+		"text":        texts,
+		"target_lang": strings.ToUpper(target),
+	}, &reply, DeepL)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect them
+	translated := make([]string, len(reply.Translations))
+	for i, t := range reply.Translations {
+		translated[i] = t.Text
+	}
+	return matchCount(translated, texts, DeepL)
+}
+
+// libretranslate's api
+type libreTranslate struct{ opts Options }
+
+// translate with libretranslate, the whole batch in one request (q may be a list)
+func (l libreTranslate) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	var reply struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	body := map[string]any{
+		"q":      texts,
+		"source": "auto",
+		"target": target,
+		"format": "text",
+	}
+	if l.opts.APIKey != "" {
+		body["api_key"] = l.opts.APIKey
+	}
+	err := postJSON(ctx, strings.TrimRight(l.opts.URL, "/")+"/translate", nil, body, &reply, LibreTranslate)
+	if err != nil {
+		return nil, err
+	}
+	return matchCount(reply.TranslatedText, texts, LibreTranslate)
+}
+
+// an openai-compatible chat completions api
+type openAI struct{ opts Options }
+
+// translate with an llm, asking for the batch back as a json array
+func (o openAI) Translate(ctx context.Context, texts []string, target string) ([]string, error) {
+	// the batch as json, so the texts can't blend into each other or the instructions
+	batch, err := json.Marshal(texts)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding texts: %w", err)
+	}
+
+	// ask for it
+	reply, err := llm.Complete(ctx, llm.Options{URL: o.opts.URL, APIKey: o.opts.APIKey, Model: o.opts.Model}, fmt.Sprintf(
+		"Translate each string of the JSON array the user sends to the language with ISO 639-1 code %q. "+
+			"Keep strings already in that language as they are. "+
+			"Reply with only a JSON array of the translated strings, in the same order.", target), string(batch))
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the array (models like to wrap it in a code block)
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+	var translated []string
+	err = json.Unmarshal([]byte(strings.TrimSpace(reply)), &translated)
+	if err != nil {
+		return nil, fmt.Errorf("error: %s replied with something other than a json array of strings: %w", OpenAI, err)
+	}
+	return matchCount(translated, texts, OpenAI)
+}
+
+// same number of translations as texts check helper, a mismatch would put them on the wrong posts
+func matchCount(translated, texts []string, provider string) ([]string, error) {
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("error: %s sent %d translations for %d texts", provider, len(translated), len(texts))
+	}
+	return translated, nil
+}
+
+// post json and decode the json reply helper, erroring on anything but 2xx
+func postJSON(ctx context.Context, url string, header http.Header, body, reply any, provider string) error {
+	// encode the body
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding %s request: %w", provider, err)
+	}
+
+	// create the request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error creating %s request: %w", provider, err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// send it
+	res, err := client().Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", provider, err)
+	}
+	defer res.Body.Close()
+
+	// status check, with the start of the body as apis explain errors there
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("error: %s returned %s: %s", provider, res.Status, strings.TrimSpace(string(detail)))
+	}
+
+	// decode the reply
+	err = json.NewDecoder(res.Body).Decode(reply)
+	if err != nil {
+		return fmt.Errorf("error decoding %s reply: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// urlnorm.go
+package urlnorm
+
+import (
+	// std go libraries
+	"fmt"     // printing errors
+	"net/url" // parsing urls
+	"strings" // case and prefixes
+)
+
+// query params that only track where a click came from, they never change the content
+var trackingParams = map[string]bool{
+	"fbclid":  true, // facebook
+	"gclid":   true, // google ads
+	"dclid":   true, // google display ads
+	"msclkid": true, // microsoft ads
+	"mc_cid":  true, // mailchimp campaign
+	"mc_eid":  true, // mailchimp subscriber
+	"igshid":  true, // instagram
+	"yclid":   true, // yandex
+	"_hsenc":  true, // hubspot
+	"_hsmi":   true, // hubspot
+	"ref_src": true, // twitter
+}
+
+// hosts that only redirect to the real link (see IsShortener)
+var shortenerHosts = map[string]bool{
+	"feedproxy.google.com": true, // feedburner
+	"t.co":                 true, // twitter
+	"bit.ly":               true,
+	"buff.ly":              true, // buffer
+	"dlvr.it":              true,
+	"ow.ly":                true, // hootsuite
+	"lnkd.in":              true, // linkedin
+	"tinyurl.com":          true,
+	"trib.al":              true,
+	"fb.me":                true,
+	"wp.me":                true, // wordpress.com
+}
+
+// check if a query param is a tracking param (utm_* or one of the known ones)
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
+
+// check if a url is a known shortener's, whose links only redirect to the real one
+// NOTE: feedburner's /~r/ click-tracking links on feeds.feedburner.com count too
+func IsShortener(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return shortenerHosts[host] || (host == "feeds.feedburner.com" && strings.HasPrefix(u.Path, "/~r/"))
+}
+
+// strip the tracking params from a post link, leaving everything else as it was (unlike Normalize)
+// unparseable urls are returned as they are
+func StripTracking(rawURL string) string {
+	// parse the url
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	// keep the other params, in their order and spelling
+	var kept []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if param != "" && !IsTrackingParam(name) {
+			kept = append(kept, param)
+		}
+	}
+
+	// nothing stripped check
+	query := strings.Join(kept, "&")
+	if query == u.RawQuery {
+		return rawURL
+	}
+	u.RawQuery = query
+	u.ForceQuery = false
+	return u.String()
+}
+
+// check a feed url and tidy it up for fetching, leaving it as the user wrote it otherwise
+// trims whitespace, and a missing scheme becomes https (see Normalize for comparing feeds)
+func Clean(rawURL string) (string, error) {
+	// trim whitespace from copy/pastes
+	rawURL = strings.TrimSpace(rawURL)
+
+	// missing scheme check, eg example.com/feed
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	// parse check
+	_, err := parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return rawURL, nil
+}
+
+// parse a feed url helper, only http(s) urls with a host are feeds
+func parse(rawURL string) (*url.URL, error) {
+	// parse the url
+	u, err := url.Parse(rawURL)
+
+	// parse check
+	if err != nil {
+		return nil, fmt.Errorf("error: invalid url %q: %w", rawURL, err)
+	}
+
+	// feeds are only fetched over http(s)
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("error: invalid url %q: scheme must be http or https", rawURL)
+	}
+
+	// host check
+	if u.Host == "" {
+		return nil, fmt.Errorf("error: invalid url %q: missing host", rawURL)
+	}
+	return u, nil
+}
+
+// normalize a feed url so equivalent spellings compare the same
+// lowercases scheme and host, drops default ports, fragments, tracking params and trailing slashes,
+// and sorts the query; a missing scheme becomes https
+// NOTE: only for comparing, feeds are fetched by the url as given (see Clean), some hosts care about case or slashes
+func Normalize(rawURL string) (string, error) {
+	// check the url
+	cleaned, err := Clean(rawURL)
+	if err != nil {
+		return "", err
+	}
+	u, _ := parse(cleaned) // checked by Clean
+
+	// hosts are case insensitive, paths are not!
+	u.Host = strings.ToLower(u.Host)
+
+	// default port check (http://x:80 is http://x)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+
+	// fragments never reach the server
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	// trailing slash check (/feed/ is /feed, and the bare root is no path)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	// strip the tracking params and sort the rest
+	u.RawQuery = cleanQuery(u.Query())
+
+	// return the normalized url
+	return u.String(), nil
+}
+
+// key for spotting equivalent feeds, the normalized url without its scheme (stored as feeds.url_key)
+// NOTE: http:// and https:// of the same feed share a key, but are stored as given
+func Key(rawURL string) (string, error) {
+	// normalize the url
+	normalized, err := Normalize(rawURL)
+
+	// normalize check
+	if err != nil {
+		return "", err
+	}
+
+	// drop the scheme
+	_, rest, _ := strings.Cut(normalized, "://")
+	return rest, nil
+}
+
+// query cleaning helper, drops tracking params and sorts the rest (url.Values.Encode sorts by key)
+func cleanQuery(query url.Values) string {
+	// drop the tracking params
+	for name := range query {
+		if IsTrackingParam(name) {
+			query.Del(name)
+		}
+	}
+
+	// encode sorts the keys
+	return query.Encode()
+}
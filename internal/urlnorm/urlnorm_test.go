@@ -0,0 +1,41 @@
+// urlnorm_test.go
+package urlnorm
+
+import (
+	// std go libraries
+	"testing" // go tests
+)
+
+// feeds are fetched by the url as given, equivalent spellings only share a key
+func TestFeedURLs(t *testing.T) {
+	tests := []struct {
+		raw     string
+		clean   string
+		key     string
+		wantErr bool
+	}{
+		{" example.com/Feed/ ", "https://example.com/Feed/", "example.com/Feed", false},
+		{"HTTP://Example.com:80/feed?b=2&a=1&utm_source=x#top", "HTTP://Example.com:80/feed?b=2&a=1&utm_source=x#top", "example.com/feed?a=1&b=2", false},
+		{"https://example.com/feed", "https://example.com/feed", "example.com/feed", false},
+		{"ftp://example.com/feed", "", "", true},
+		{"https:///feed", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			clean, err := Clean(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Clean = %q, want an error", clean)
+				}
+				return
+			}
+			if err != nil || clean != tt.clean {
+				t.Errorf("Clean = %q, %v, want %q", clean, err, tt.clean)
+			}
+			key, err := Key(tt.raw)
+			if err != nil || key != tt.key {
+				t.Errorf("Key = %q, %v, want %q", key, err, tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// states.go
+package userdata
+
+import (
+	// std go libraries
+	"encoding/json" // the export is one json document
+	"fmt"           // printing errors
+	"os"            // export files
+	"time"          // export and state times
+)
+
+// state export constants
+const (
+	StatesFormat  = "gator-states" // the export's format field, so other json files are refused
+	StatesVersion = 1              // bumped when a field changes meaning, new optional fields don't bump it
+)
+
+// a user's read and starred items, as export --states writes it and import states reads it
+// NOTE: only what any reader knows about an item, so other readers can read it and write it too
+type States struct {
+	Format     string    `json:"format"`  // always StatesFormat
+	Version    int       `json:"version"` // the schema version it was written with
+	ExportedAt time.Time `json:"exported_at"`
+	Items      []Item    `json:"items"`
+}
+
+// a read or starred item, matched by url, else by guid within its feed
+type Item struct {
+	URL       string     `json:"url"`
+	GUID      string     `json:"guid,omitempty"` // the feed's id for it, "" = the feed gave none
+	FeedURL   string     `json:"feed_url"`
+	Title     string     `json:"title,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"` // nil = unread
+	StarredAt *time.Time `json:"starred_at,omitempty"`
+}
+
+// write a state export to a json file, only readable by its owner (it's reading history)
+func WriteStates(path string, states States) error {
+	// stamp it
+	states.Format = StatesFormat
+	states.Version = StatesVersion
+
+	// marshal it (indented, so it can be read and diffed)
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding states: %w", err)
+	}
+
+	// write it
+	err = os.WriteFile(path, append(data, '\n'), 0600)
+	if err != nil {
+		return fmt.Errorf("error writing states: %w", err)
+	}
+	return nil
+}
+
+// read a state export from a json file, refusing other files and newer versions
+func ReadStates(path string) (States, error) {
+	// read it
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return States{}, fmt.Errorf("error reading states: %w", err)
+	}
+
+	// parse it
+	var states States
+	err = json.Unmarshal(data, &states)
+	if err != nil {
+		return States{}, fmt.Errorf("error parsing states %s: %w", path, err)
+	}
+
+	// format and version checks
+	if states.Format != StatesFormat {
+		return States{}, fmt.Errorf("error: %s isn't a gator state export (written by export --states)", path)
+	}
+	if states.Version < 1 || states.Version > StatesVersion {
+		return States{}, fmt.Errorf("error: %s is state export version %d, this gator reads up to version %d", path, states.Version, StatesVersion)
+	}
+	return states, nil
+}
@@ -0,0 +1,154 @@
+// userdata.go
+package userdata
+
+import (
+	// std go libraries
+	"encoding/json" // the export is one json document
+	"fmt"           // printing errors
+	"os"            // export files
+	"time"          // export, follow and state times
+
+	// external packages
+	"github.com/google/uuid" // note ids
+)
+
+// user data export constants
+const (
+	Format  = "gator-user-data" // the export's format field, so other json files are refused
+	Version = 1                 // bumped when a field changes meaning, new optional fields don't bump it
+)
+
+// a user's data, as export --all writes it and import reads it
+// NOTE: logins (passwords, tokens, sessions, other services' accounts) are left out on purpose
+type Export struct {
+	Format      string      `json:"format"`  // always Format
+	Version     int         `json:"version"` // the schema version it was written with
+	ExportedAt  time.Time   `json:"exported_at"`
+	User        User        `json:"user"`
+	Preferences Preferences `json:"preferences"`
+	Folders     []string    `json:"folders"` // every folder, empty ones too
+	Follows     []Follow    `json:"follows"`
+	Posts       []Post      `json:"posts"` // posts with a state, tag or note of the user's
+	Mutes       []Mute      `json:"mutes"`
+	Rules       []Rule      `json:"rules"`
+}
+
+// the exported user
+type User struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// the user's settings that aren't about one feed or post
+type Preferences struct {
+	Languages     []string `json:"languages,omitempty"` // empty = every language
+	Digest        *Digest  `json:"digest,omitempty"`    // nil = digests follow digest_interval
+	ShareTimeline bool     `json:"share_timeline"`
+	ShareProfile  bool     `json:"share_profile"`
+}
+
+// the user's digest schedule, channel and scope (see digest prefs)
+type Digest struct {
+	Cadence    string   `json:"cadence"`     // daily or weekly
+	Weekday    int      `json:"weekday"`     // weekly digests' day, 0 = sunday
+	SendMinute int      `json:"send_minute"` // minutes after midnight
+	Timezone   string   `json:"timezone,omitempty"`
+	Channel    string   `json:"channel"` // email, telegram or file
+	Folders    []string `json:"folders,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// a followed feed, with the user's settings for it
+type Follow struct {
+	Name         string     `json:"name"`
+	URL          string     `json:"url"`
+	Folder       string     `json:"folder,omitempty"` // "" = not in a folder
+	FollowedAt   time.Time  `json:"followed_at"`
+	Notify       bool       `json:"notify"`
+	TranslateTo  string     `json:"translate_to,omitempty"`
+	Weight       *float32   `json:"weight,omitempty"`        // nil = 1, normal
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"` // nil = not snoozed
+}
+
+// a post, with the user's state, tags and notes on it
+type Post struct {
+	URL         string     `json:"url"`
+	FeedURL     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"` // nil = unread
+	StarredAt   *time.Time `json:"starred_at,omitempty"`
+	MutedAt     *time.Time `json:"muted_at,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Notes       []Note     `json:"notes,omitempty"`
+}
+
+// a note on a post
+type Note struct {
+	ID        uuid.UUID `json:"id"` // kept on import, so importing twice doesn't double them
+	CreatedAt time.Time `json:"created_at"`
+	Body      string    `json:"body"`
+	Highlight string    `json:"highlight,omitempty"`
+}
+
+// a muted keyword or regex
+type Mute struct {
+	Pattern string `json:"pattern"`
+	Regex   bool   `json:"regex"`
+}
+
+// a rule, empty matches match anything (see rule add)
+type Rule struct {
+	FeedURL      string `json:"feed_url,omitempty"`
+	TitlePattern string `json:"title_pattern,omitempty"`
+	Category     string `json:"category,omitempty"`
+	Author       string `json:"author,omitempty"`
+	Action       string `json:"action"` // mute, star, tag, notify or webhook
+	Argument     string `json:"argument,omitempty"`
+}
+
+// write an export to a json file, only readable by its owner (it's personal data)
+func Write(path string, export Export) error {
+	// stamp it
+	export.Format = Format
+	export.Version = Version
+
+	// marshal it (indented, so it can be read and diffed)
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding user data: %w", err)
+	}
+
+	// write it
+	err = os.WriteFile(path, append(data, '\n'), 0600)
+	if err != nil {
+		return fmt.Errorf("error writing user data: %w", err)
+	}
+	return nil
+}
+
+// read an export from a json file, refusing other files and newer versions
+func Read(path string) (Export, error) {
+	// read it
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Export{}, fmt.Errorf("error reading user data: %w", err)
+	}
+
+	// parse it
+	var export Export
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		return Export{}, fmt.Errorf("error parsing user data %s: %w", path, err)
+	}
+
+	// format and version checks
+	if export.Format != Format {
+		return Export{}, fmt.Errorf("error: %s isn't a gator user data export (written by export --all)", path)
+	}
+	if export.Version < 1 || export.Version > Version {
+		return Export{}, fmt.Errorf("error: %s is user data version %d, this gator reads up to version %d", path, export.Version, Version)
+	}
+	return export, nil
+}
@@ -3,35 +3,133 @@ package main
 
 import (
 	// standard go libarries
+	"context" // cancelling commands
 	"database/sql"
-	"fmt" // for printing
-	"os"  // for file reading/writing
+	"embed"     // bundling the migrations into the binary
+	"errors"    // checking timeouts
+	"fmt"       // for printing
+	"io/fs"     // sub-directory of the embedded migrations
+	"log/slog"  // structured logging
+	"os"        // for file reading/writing
+	"os/signal" // catching ctrl+c and SIGTERM
+	"syscall"   // SIGTERM
+	"time"      // --timeout
 
 	// internal packages
 	"github.com/PietPadda/aggregator/internal/app"
 	"github.com/PietPadda/aggregator/internal/config"
 	"github.com/PietPadda/aggregator/internal/database"
+	"github.com/PietPadda/aggregator/internal/dialect"
 	"github.com/PietPadda/aggregator/internal/handlers"
+	"github.com/PietPadda/aggregator/internal/logging"
+	"github.com/PietPadda/aggregator/internal/output"
+	"github.com/PietPadda/aggregator/internal/plugins"
+	"github.com/PietPadda/aggregator/internal/rssfeed"
 
 	// package drivers
 	_ "github.com/lib/pq" // postgreSQL driver
 )
 
+// the goose migrations, embedded so migrate works without the repo
+//
+//go:embed sql/schema/*.sql
+var schemaFiles embed.FS
+
 func main() {
-	// read the config file
-	cfg, err := config.Read()
+	// parse the global flags (they go before the command)
+	flags, args, err := parseGlobalFlags(os.Args[1:])
+	// os.Args[0] is the program name, so we skip it
+
+	// global flags check
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1) // clean exit
+	}
+
+	// set up the structured logger (stderr, so it doesn't mix with command output)
+	_, err = logging.Setup(os.Stderr, logging.Level(flags.verbosity, flags.quiet), flags.logFormat)
+
+	// logger check
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1) // clean exit
+	}
+
+	// read the config file (--config, GATOR_CONFIG or ~/.gatorconfig.json)
+	cfg, err := config.ReadFrom(flags.config)
 	// _,. because we're only using it when printing to terminal!
 	// UPDATE: also for SetUser to work as a method!
 
-	// read check
-	if err != nil {
+	// commands that never touch the database, so they work before db_url is set (or with a broken config)
+	offline := len(args) > 0 && (args[0] == "config" || args[0] == "help")
+
+	// plugins too, they get the database url if there is one
+	if len(args) > 0 && !offline {
+		_, offline = plugins.Find(args[0])
+	}
+
+	// read check (config still runs, so config validate can explain what's wrong)
+	if err != nil && !offline {
 		fmt.Println("Error reading config file:", err)
 		os.Exit(1) // clean exit
 	}
 
+	// log level, -v/-q win over the config's log_level
+	if flags.verbosity > 0 || flags.quiet {
+		logging.Pin()
+	}
+	err = logging.SetConfigLevel(cfg.LogLevelName())
+
+	// log level check
+	if err != nil && !offline {
+		fmt.Println(err)
+		os.Exit(1) // clean exit
+	}
+
+	// output format check, --output wins over the config's output (default text)
+	if flags.output == "" {
+		flags.output, err = cfg.OutputFormat()
+
+		// config output check (config still runs, so the bad value can be fixed)
+		if err != nil && !offline {
+			fmt.Println(err)
+			os.Exit(1) // clean exit
+		}
+		if err != nil {
+			flags.output = output.Text
+		}
+	}
+
+	// get the http client settings
+	httpOpts, err := cfg.HTTPOptions()
+
+	// http settings check
+	if err != nil && !offline {
+		fmt.Println(err)
+		os.Exit(1) // clean exit
+	}
+
+	// get the db url, with the instance's schema as search_path (if configured)
+	dbURL, err := cfg.DatabaseURL()
+
+	// db url check
+	if err != nil && !offline {
+		fmt.Println(err)
+		os.Exit(1) // clean exit
+	}
+
+	// get the db dialect (postgres, cockroachdb or neon)
+	dbDialect, err := dialect.Parse(cfg.DialectName())
+
+	// dialect check
+	if err != nil && !offline {
+		fmt.Println(err)
+		os.Exit(1) // clean exit
+	}
+
 	// open connection to PostgreSQL database
-	db, err := sql.Open("postgres", *cfg.URL)
-	// takes driver + db connection string (ptr to config.URL)
+	db, err := sql.Open("postgres", dbURL)
+	// takes driver + db connection string
 
 	// db check
 	if err != nil {
@@ -40,103 +138,756 @@ func main() {
 	}
 
 	// create database instance
-	dbQueries := database.New(db) // create db queries instance
+	dbQueries := database.New(dialect.RetryDB{DB: db}) // create db queries instance, retrying statements on transient errors
 	// dbQueries is a ptr to the Queries struct in the database package
 	// provides methods to interact with the database instead of using raw SQL
+	store := app.NewCachedStore(dbQueries, app.StoreCacheTTL) // users and feeds are looked up on every command and request
+
+	// get the migrations from their embedded sql/schema dir
+	migrations, err := fs.Sub(schemaFiles, "sql/schema")
+
+	// migrations check
+	if err != nil {
+		fmt.Println("Error loading migrations:", err)
+		os.Exit(1) // clean exit
+	}
 
 	// create state instance and store config in
 	state := &app.State{ // app
-		Config: &cfg,
-		DB:     dbQueries,
+		Config:     &cfg,
+		DB:         store,
+		Conn:       db,                          // for transactions
+		Output:     flags.output,                // from --output or the config, default human readable text
+		Migrations: migrations,                  // for migrate
+		Dialect:    dbDialect,                   // gates what the database doesn't support
+		HTTP:       rssfeed.NewClient(httpOpts), // one client, so connections are reused
+		Stdout:     os.Stdout,                   // handlers print here, never to os.Stdout directly
+		Stderr:     os.Stderr,                   // progress bars and notes
 	}
 	// we declare state as a ptr to app.State, thus use &app! (our funcs use s *State !)
 	// Config is ptr in the State struct, thus we use &cfg
+	rssfeed.SetShared(state.HTTP) // llm, push, webhooks and the other services go through it too
 
 	// create commands instance with init map of handler functions
 	cmds := &app.Commands{
-		Handler: make(map[string]func(*app.State, app.Command) error), // matches the struct
+		Handler: make(map[string]func(context.Context, *app.State, app.Command) error), // matches the struct
+		Specs:   make(map[string]app.Spec),                                             // what Run checks and help prints
 	}
 	// we declare commands as a ptr to app.Commands, thus use &app! (our funcs use c *Commands !)
-	// Handler is in Commands struct, and we have to init the map! takes State ptr and Command!
+	// Handler is in Commands struct, and we have to init the map! takes ctx, State ptr and Command!
 	// why init the map? Because Go maps need to be init before they can be used! prevents Go panic
 
 	// register the handler function for the login cmd
-	cmds.Register("login", handlers.HandlerLogin)
+	cmds.Register(app.Spec{
+		Name:    "login",
+		Summary: "Log in as a user",
+		Usage:   []string{"<username>"},
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlers.HandlerLogin)
 	// Registers receivces commands
 	// "login" = the command we register
 	// HandlerLogin works on handlers, and registers "login" there
 
 	// register the handler function for the register cmd
-	cmds.Register("register", handlers.HandlerRegister)
+	cmds.Register(app.Spec{
+		Name:    "register",
+		Summary: "Create a user and log in as them",
+		Usage:   []string{"<username>"},
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlers.HandlerRegister)
 	// Registers receivces commands
 	// "register" = the command we register
 	// HandlerRegister works on handlers, and registers "register" there
 
 	// register the handler function for the reset cmd
-	cmds.Register("reset", handlers.HandlerReset)
+	cmds.Register(app.Spec{
+		Name:    "reset",
+		Summary: "Delete all users, feeds, follows and posts",
+		Usage:   []string{"[--force] [--dry-run] [--backup <file>]"},
+		MinArgs: 0,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerReset)
 	// resets users table to prevent down/up migration for each test
 	// "reset" = the command we register
 	// HandlerReset works on handlers, and registers "reset" there
 
 	// register the handler function for the users cmd
-	cmds.Register("users", handlers.HandlerGetUsers)
+	cmds.Register(app.Spec{
+		Name:    "users",
+		Summary: "List the users, marking the current one",
+		MinArgs: 0,
+		MaxArgs: 0,
+	}, handlers.HandlerGetUsers)
 	// prints all users in the database, and the current user
 	// "users" = the command we register
 	// HandlerReset works on handlers, and registers "users" there
 
 	// register the handler function for the agg cmd
-	cmds.Register("agg", handlers.HandlerAgg)
+	cmds.Register(app.Spec{
+		Name:    "agg",
+		Summary: "Fetch the feeds, forever or once",
+		Usage:   []string{"[duration] [--once] [--force] [--take-over <pid>] [--daemon] [--worker] [--batch <n>] [--cpuprofile <file>] [--memprofile <file>] [--trace <file>]", "status|stop|reload|workers"},
+		MinArgs: 0,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerAgg)
 	// prints the aggregated RSS feed
 	// "agg" = the command we register
 	// HandlerAgg works on handlers, and registers "agg" there
 
 	// register the handler function for the addfeed cmd
-	cmds.Register("addfeed", handlers.MiddlewareLoggedIn(handlers.HandlerAddFeed))
+	cmds.Register(app.Spec{
+		Name:     "addfeed",
+		Summary:  "Add a feed and follow it",
+		Usage:    []string{"<feed_name> <feed_url> [interval]"},
+		MinArgs:  2,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerAddFeed))
 	// adds a feed to the database (And follows it)
 	// "addfeed" = the command we register
 	// HandlerAgg works on handlers, and registers "addfeed" there
 
 	// register the handler function for the feeds cmd
-	cmds.Register("feeds", handlers.HandlerFeeds)
+	cmds.Register(app.Spec{
+		Name:    "feeds",
+		Summary: "List the feeds",
+		Usage:   []string{"[--broken]"},
+		MinArgs: 0,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerFeeds)
 	// lists all feeds in db with created user name
 	// "feeds" = the command we register
 	// HandlerFeeds works on handlers, and registers "feeds" there
 
 	// register the handler function for the follow cmd
-	cmds.Register("follow", handlers.MiddlewareLoggedIn(handlers.HandlerFollow))
+	cmds.Register(app.Spec{
+		Name:     "follow",
+		Summary:  "Follow feeds",
+		Usage:    []string{"<feed_url>..."},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerFollow))
 	// current user follows a feed
 	// "follow" = the command we register
 	// HandlerFollow works on handlers, and registers "follow" there
 
 	// register the handler function for the following cmd
-	cmds.Register("following", handlers.MiddlewareLoggedIn(handlers.HandlerFollowing))
+	cmds.Register(app.Spec{
+		Name:     "following",
+		Summary:  "List the feeds you follow",
+		MinArgs:  0,
+		MaxArgs:  0,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerFollowing))
 	// lists all feeds the current user follows
 	// "following" = the command we register
 	// HandlerFollowing works on handlers, and registers "following" there
 
+	// register the handler function for the discover cmd
+	cmds.Register(app.Spec{
+		Name:     "discover",
+		Summary:  "Suggest popular feeds you don't follow",
+		Usage:    []string{"[limit]"},
+		MinArgs:  0,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerDiscover))
+	// lists popular feeds other users follow, for finding new ones
+	// "discover" = the command we register
+	// HandlerDiscover works on handlers, and registers "discover" there
+
 	// register the handler function for the unfollow cmd
-	cmds.Register("unfollow", handlers.MiddlewareLoggedIn(handlers.HandlerUnfollow))
+	cmds.Register(app.Spec{
+		Name:     "unfollow",
+		Summary:  "Unfollow a feed",
+		Usage:    []string{"<feed_url>"},
+		MinArgs:  1,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerUnfollow))
 	// unfollows a feed for the logged in user
 	// "unfollow" = the command we register
 	// HandlerUnfollow works on handlers, and registers "unfollow" there
 
 	// register the handler function for the unfollow cmd
-	cmds.Register("browse", handlers.MiddlewareLoggedIn(handlers.HandlerBrowse))
+	cmds.Register(app.Spec{
+		Name:     "browse",
+		Summary:  "Show the newest posts of the feeds you follow",
+		Usage:    []string{"[limit] [flags]"},
+		MinArgs:  0,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerBrowse))
 	// browse lists all the posts for the logged in user
 	// "browse" = the command we register
 	// HandlerBrowse works on handlers, and registers "browse" there
 
+	// register the handler function for the watch cmd
+	cmds.Register(app.Spec{
+		Name:     "watch",
+		Summary:  "Print new posts as agg stores them",
+		MinArgs:  0,
+		MaxArgs:  0,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerWatch))
+	// watch prints new posts from followed feeds as agg stores them
+	// "watch" = the command we register
+	// HandlerWatch works on handlers, and registers "watch" there
+
+	// register the handler function for the setinterval cmd
+	cmds.Register(app.Spec{
+		Name:     "setinterval",
+		Summary:  "Set how often a feed is fetched",
+		Usage:    []string{"<feed_url> <interval|default>"},
+		MinArgs:  2,
+		MaxArgs:  2,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerSetInterval))
+	// sets a feed's own refresh interval used by the agg scheduler
+	// "setinterval" = the command we register
+	// HandlerSetInterval works on handlers, and registers "setinterval" there
+
+	// register the handler function for the removefeed cmd
+	cmds.Register(app.Spec{
+		Name:     "removefeed",
+		Summary:  "Remove a feed",
+		Usage:    []string{"<feed_url> [--dry-run] [--purge]"},
+		MinArgs:  1,
+		MaxArgs:  3,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerRemoveFeed))
+	// soft deletes a feed (or purges it with all its follows and posts)
+	// "removefeed" = the command we register
+	// HandlerRemoveFeed works on handlers, and registers "removefeed" there
+
+	// register the handler function for the deleteuser cmd
+	cmds.Register(app.Spec{
+		Name:    "deleteuser",
+		Summary: "Delete a user",
+		Usage:   []string{"<username> [--dry-run] [--purge]"},
+		MinArgs: 1,
+		MaxArgs: 3,
+	}, handlers.HandlerDeleteUser)
+	// soft deletes a user and their feeds (or purges them with all their follows)
+	// "deleteuser" = the command we register
+	// HandlerDeleteUser works on handlers, and registers "deleteuser" there
+
+	// register the handler function for the prune cmd
+	cmds.Register(app.Spec{
+		Name:    "prune",
+		Summary: "Delete posts older than an age",
+		Usage:   []string{"<max_age> [--dry-run] [--archive]"},
+		MinArgs: 1,
+		MaxArgs: 3,
+	}, handlers.HandlerPrune)
+	// deletes posts older than a given age
+	// "prune" = the command we register
+	// HandlerPrune works on handlers, and registers "prune" there
+
+	// register the handler function for the migrate cmd
+	cmds.Register(app.Spec{
+		Name:    "migrate",
+		Summary: "Migrate the database schema",
+		Usage:   []string{"up|down|status"},
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlers.HandlerMigrate)
+	// creates/upgrades the database schema from the embedded migrations
+	// "migrate" = the command we register
+	// HandlerMigrate works on handlers, and registers "migrate" there
+
+	// register the handler function for the archive cmd
+	cmds.Register(app.Spec{
+		Name:    "archive",
+		Summary: "Search archived posts",
+		Usage:   []string{"search <pattern>"},
+		MinArgs: 2,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerArchive)
+	// searches the posts archived by prune --archive
+	// "archive" = the command we register
+	// HandlerArchive works on handlers, and registers "archive" there
+
+	// register the handler function for the folder cmd
+	cmds.Register(app.Spec{
+		Name:     "folder",
+		Summary:  "Group followed feeds in folders",
+		Usage:    []string{"create <name>", "move <feed_url> <name|none>", "list"},
+		MinArgs:  1,
+		MaxArgs:  3,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerFolder))
+	// groups the current user's followed feeds into folders
+	// "folder" = the command we register
+	// HandlerFolder works on handlers, and registers "folder" there
+
+	// register the handler function for the undelete cmd
+	cmds.Register(app.Spec{
+		Name:    "undelete",
+		Summary: "Restore a removed user or feed",
+		Usage:   []string{"user <username>", "feed <feed_url>"},
+		MinArgs: 2,
+		MaxArgs: 2,
+	}, handlers.HandlerUndelete)
+	// restores a soft deleted user or feed
+	// "undelete" = the command we register
+	// HandlerUndelete works on handlers, and registers "undelete" there
+
+	// register the handler function for the snapshot cmd
+	cmds.Register(app.Spec{
+		Name:    "snapshot",
+		Summary: "Print the last fetched body of a feed",
+		Usage:   []string{"<feed_url>"},
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlers.HandlerSnapshot)
+	// prints the last raw body agg stored for a feed (snapshot_feeds debug mode)
+	// "snapshot" = the command we register
+	// HandlerSnapshot works on handlers, and registers "snapshot" there
+
+	// register the handler function for the bench cmd
+	cmds.Register(app.Spec{
+		Name:    "bench",
+		Summary: "Measure fetch, parse and store throughput",
+		Usage:   []string{"[<feed_url>...] [--snapshots] [--rounds <n>] [--no-store]"},
+		MinArgs: 0,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerBench)
+	// bench fetches (or replays) feeds and times parsing and writing them, rolling the writes back
+	// "bench" = the command we register
+	// HandlerBench works on handlers, and registers "bench" there
+
+	// register the handler function for the config cmd
+	cmds.Register(app.Spec{
+		Name:    "config",
+		Summary: "Read and change the config",
+		Usage:   []string{"get <key> [--reveal]", "set <key> <value>", "unset <key>", "list", "validate", "path", "set-password <alias>"},
+		MinArgs: 1,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerConfig)
+	// config gets, sets and lists config keys, with validation before saving
+	// "config" = the command we register
+	// HandlerConfig works on handlers, and registers "config" there
+
+	// register the handler function for the db cmd
+	cmds.Register(app.Spec{
+		Name:    "db",
+		Summary: "Check the database connection",
+		Usage:   []string{"ping"},
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlers.HandlerDB)
+	// db ping checks the db_url, connection, server version and migrations
+	// "db" = the command we register
+	// HandlerDB works on handlers, and registers "db" there
+
+	// register the handler function for the seed cmd
+	cmds.Register(app.Spec{
+		Name:    "seed",
+		Summary: "Add sample users and feeds",
+		MinArgs: 0,
+		MaxArgs: 0,
+	}, handlers.HandlerSeed)
+	// seed creates a demo user following a few well-known feeds, with posts to browse
+	// "seed" = the command we register
+	// HandlerSeed works on handlers, and registers "seed" there
+
+	// register the handler function for the serve cmd
+	cmds.Register(app.Spec{
+		Name:    "serve",
+		Summary: "Serve the JSON API and web UI",
+		Usage:   []string{"[addr] [--cpuprofile <file>] [--memprofile <file>] [--trace <file>]"},
+		MinArgs: 0,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerServe)
+	// serve runs a json api over users, feeds, follows and posts, for web and mobile clients
+	// "serve" = the command we register
+	// HandlerServe works on handlers, and registers "serve" there
+
+	// register the handler function for the fever cmd
+	cmds.Register(app.Spec{
+		Name:     "fever",
+		Summary:  "Set up the Fever API for mobile apps",
+		Usage:    []string{"set-password", "disable"},
+		MinArgs:  1,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerFever))
+	// fever sets or removes the current user's password for serve's fever api (Reeder, ReadKit, Unread)
+	// "fever" = the command we register
+	// HandlerFever works on handlers, and registers "fever" there
+
+	// register the handler function for the token cmd
+	cmds.Register(app.Spec{
+		Name:     "token",
+		Summary:  "Manage API tokens",
+		Usage:    []string{"create <name>", "list", "revoke <name>"},
+		MinArgs:  1,
+		MaxArgs:  2,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerToken))
+	// token creates, lists and revokes the current user's tokens for serve's json api
+	// "token" = the command we register
+	// HandlerToken works on handlers, and registers "token" there
+
+	// register the handler function for the passwd cmd
+	cmds.Register(app.Spec{
+		Name:     "passwd",
+		Summary:  "Set or remove your password",
+		Usage:    []string{"[--remove]"},
+		MinArgs:  0,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerPasswd))
+	// passwd sets, changes or removes the current user's password
+	// "passwd" = the command we register
+	// HandlerPasswd works on handlers, and registers "passwd" there
+
+	// register the handler function for the admin cmd
+	cmds.Register(app.Spec{
+		Name:    "admin",
+		Summary: "Promote or demote an admin",
+		Usage:   []string{"promote <username>", "demote <username>"},
+		MinArgs: 2,
+		MaxArgs: 2,
+	}, handlers.HandlerAdmin)
+	// admin promotes users to admins, or demotes them (admins may reset, and delete other users and their feeds)
+	// "admin" = the command we register
+	// HandlerAdmin works on handlers, and registers "admin" there
+
+	// register the handler function for the setschedule cmd
+	cmds.Register(app.Spec{
+		Name:     "setschedule",
+		Summary:  "Fetch a feed on a cron schedule",
+		Usage:    []string{"<feed_url> \"<cron>\"", "<feed_url> none"},
+		MinArgs:  2,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerSetSchedule))
+	// setschedule fetches a feed on a cron schedule (eg weekdays at 7am) instead of its interval
+	// "setschedule" = the command we register
+	// HandlerSetSchedule works on handlers, and registers "setschedule" there
+
+	// register the handler function for the jobs cmd
+	cmds.Register(app.Spec{
+		Name:    "jobs",
+		Summary: "List the fetch jobs",
+		Usage:   []string{"[pending|running|failed|done] [limit]"},
+		MinArgs: 0,
+		MaxArgs: 2,
+	}, handlers.HandlerJobs)
+	// jobs shows agg's fetch job queue: the backlog per state, or the latest jobs in a state
+	// "jobs" = the command we register
+	// HandlerJobs works on handlers, and registers "jobs" there
+
+	// register the handler function for the sharefeed cmd
+	cmds.Register(app.Spec{
+		Name:     "sharefeed",
+		Summary:  "Share your timeline as a public feed",
+		Usage:    []string{"[on|off]"},
+		MinArgs:  0,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerShareFeed))
+	// sharefeed publishes the current user's timeline as rss on serve, or stops publishing it
+	// "sharefeed" = the command we register
+	// HandlerShareFeed works on handlers, and registers "sharefeed" there
+
+	// register the handler function for the profile cmd
+	cmds.Register(app.Spec{
+		Name:     "profile",
+		Summary:  "Share your followed feeds as a public page",
+		Usage:    []string{"[on|off]"},
+		MinArgs:  0,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerProfile))
+	// profile publishes the feeds the current user follows as a page on serve, or stops publishing them
+	// "profile" = the command we register
+	// HandlerProfile works on handlers, and registers "profile" there
+
+	// register the handler function for the digest cmd
+	cmds.Register(app.Spec{
+		Name:    "digest",
+		Summary: "Get the new posts as a digest",
+		Usage:   []string{"subscribe <email>", "unsubscribe", "status", "prefs [set [flags]|clear]", "send [--dry-run]", "--epub <file> [--folder <name>]"},
+		MinArgs: 1,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerDigest)
+	// digest subscribes the current user to email digests of their new posts, or sends the due ones
+	// "digest" = the command we register
+	// HandlerDigest works on handlers, and registers "digest" there
+
+	// register the handler function for the newsletter cmd
+	cmds.Register(app.Spec{
+		Name:    "newsletter",
+		Summary: "Read email newsletters as feeds",
+		Usage:   []string{"add <name>", "list", "receive"},
+		MinArgs: 1,
+		MaxArgs: app.AnyArgs,
+	}, handlers.HandlerNewsletter)
+	// newsletter gives the current user addresses to subscribe newsletters with, or receives the mail as posts
+	// "newsletter" = the command we register
+	// HandlerNewsletter works on handlers, and registers "newsletter" there
+
+	// register the handler function for the telegram cmd
+	cmds.Register(app.Spec{
+		Name:    "telegram",
+		Summary: "Get posts in Telegram",
+		Usage:   []string{"link", "unlink", "status", "bot"},
+		MinArgs: 1,
+		MaxArgs: 1,
+	}, handlers.HandlerTelegram)
+	// telegram links the current user's telegram chat, or runs the bot that answers and pushes to linked chats
+	// "telegram" = the command we register
+	// HandlerTelegram works on handlers, and registers "telegram" there
+
+	// register the handler function for the notify cmd
+	cmds.Register(app.Spec{
+		Name:     "notify",
+		Summary:  "Get push notifications for new posts",
+		Usage:    []string{"ntfy <topic url> [token]", "pushover <user key> <app token>", "off", "status", "test", "feed <url> on|off"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerNotify))
+	// notify sets up ntfy or pushover push notifications, and flags the follows to be notified about
+	// "notify" = the command we register
+	// HandlerNotify works on handlers, and registers "notify" there
+
+	// register the handler function for the rule cmd
+	cmds.Register(app.Spec{
+		Name:     "rule",
+		Summary:  "Mute, star, tag or forward posts as they arrive",
+		Usage:    []string{"add [--feed <url>] [--title <regexp>] [--category <category>] [--author <author>] <action> [argument]", "list", "delete <id>"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerRule))
+	// rule adds, lists and deletes the current user's rules, which agg runs on new posts
+	// "rule" = the command we register
+	// HandlerRule works on handlers, and registers "rule" there
+
+	// register the handler function for the mute cmd
+	cmds.Register(app.Spec{
+		Name:     "mute",
+		Summary:  "Hide posts with keywords",
+		Usage:    []string{"add [--regex] <keyword or regex>", "list", "remove <keyword or regex>"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerMute))
+	// mute adds, lists and removes the keywords and regexes on the current user's mute list
+	// "mute" = the command we register
+	// HandlerMute works on handlers, and registers "mute" there
+
+	// register the handler function for the languages cmd
+	cmds.Register(app.Spec{
+		Name:     "languages",
+		Summary:  "Only see posts in some languages",
+		Usage:    []string{"[list]", "set <code>...", "clear"},
+		MinArgs:  0,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerLanguages))
+	// languages sets the current user's preferred languages, digests and notifications skip posts in others
+	// "languages" = the command we register
+	// HandlerLanguages works on handlers, and registers "languages" there
+
+	// register the handler function for the translate cmd
+	cmds.Register(app.Spec{
+		Name:     "translate",
+		Summary:  "Translate feeds' posts",
+		Usage:    []string{"feed <url> <code>|off", "list", "text <code> <text>"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerTranslate))
+	// translate sets which follows browse auto-translates, and tries the configured translation provider
+	// "translate" = the command we register
+	// HandlerTranslate works on handlers, and registers "translate" there
+
+	// register the handler function for the summarize cmd
+	cmds.Register(app.Spec{
+		Name:    "summarize",
+		Summary: "Summarize posts with an LLM",
+		Usage:   []string{"status", "run [limit]"},
+		MinArgs: 1,
+		MaxArgs: 2,
+	}, handlers.HandlerSummarize)
+	// summarize shows the summarizer's daily usage, and summarizes new posts now instead of waiting for agg
+	// "summarize" = the command we register
+	// HandlerSummarize works on handlers, and registers "summarize" there
+
+	// register the handler function for the sendto cmd
+	cmds.Register(app.Spec{
+		Name:     "sendto",
+		Summary:  "Send posts to a read-later service",
+		Usage:    []string{"pocket|instapaper|wallabag <post id|url>", "login <service> ...", "logout <service>", "accounts"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerSendTo))
+	// sendto saves a post to pocket, instapaper or wallabag, after sendto login <service>
+	// "sendto" = the command we register
+	// HandlerSendTo works on handlers, and registers "sendto" there
+
+	// register the handler function for the weight cmd
+	cmds.Register(app.Spec{
+		Name:     "weight",
+		Summary:  "Rank feeds higher or lower in browse --ranked",
+		Usage:    []string{"[<feed_url> <weight>]"},
+		MinArgs:  0,
+		MaxArgs:  2,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerWeight))
+	// weight lists or sets how much each followed feed counts in browse --ranked
+	// "weight" = the command we register
+	// HandlerWeight works on handlers, and registers "weight" there
+
+	// register the handler function for the trending cmd
+	cmds.Register(app.Spec{
+		Name:    "trending",
+		Summary: "List the links many feeds are posting",
+		Usage:   []string{"[24h|7d] [limit]"},
+		MinArgs: 0,
+		MaxArgs: 2,
+	}, handlers.HandlerTrending)
+	// trending shows the posts most read and starred across all users
+	// "trending" = the command we register
+	// HandlerTrending works on handlers, and registers "trending" there
+
+	// register the handler function for the snooze cmd
+	cmds.Register(app.Spec{
+		Name:     "snooze",
+		Summary:  "Hide a feed's posts for a while",
+		Usage:    []string{"[<feed_url> <duration|date>|off]"},
+		MinArgs:  0,
+		MaxArgs:  2,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerSnooze))
+	// snooze hides a followed feed's posts for a while, and pauses fetching it if no one else follows it
+	// "snooze" = the command we register
+	// HandlerSnooze works on handlers, and registers "snooze" there
+
+	// register the handler function for the note cmd
+	cmds.Register(app.Spec{
+		Name:     "note",
+		Summary:  "Keep notes on posts",
+		Usage:    []string{"add <post id|url> \"<text>\" [--highlight \"<passage>\"]", "list [<post id|url>]", "delete <note id>"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerNote))
+	// note adds, lists and deletes the current user's notes on posts
+	// "note" = the command we register
+	// HandlerNote works on handlers, and registers "note" there
+
+	// register the handler function for the share cmd
+	cmds.Register(app.Spec{
+		Name:     "share",
+		Summary:  "Share a post with another user",
+		Usage:    []string{"<post id|url> <user> [message]"},
+		MinArgs:  2,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerShare))
+	// share puts a post in another user's inbox, with an optional message
+	// "share" = the command we register
+	// HandlerShare works on handlers, and registers "share" there
+
+	// register the handler function for the inbox cmd
+	cmds.Register(app.Spec{
+		Name:     "inbox",
+		Summary:  "List the posts shared with you",
+		Usage:    []string{"[--all]"},
+		MinArgs:  0,
+		MaxArgs:  1,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerInbox))
+	// inbox shows the posts other users shared with the current user
+	// "inbox" = the command we register
+	// HandlerInbox works on handlers, and registers "inbox" there
+
+	// register the handler function for the import cmd
+	cmds.Register(app.Spec{
+		Name:     "import",
+		Summary:  "Import feeds and states from other apps",
+		Usage:    []string{"<file>", "bookmarks <file> [--yes]", "csv <file> [--dry-run]", "states <file>", "miniflux <url> <api key>", "freshrss <url> <user> <api password>"},
+		MinArgs:  1,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerImport))
+	// import finds feeds to follow in other apps' exports (browser bookmarks, csv feed lists),
+	// brings over miniflux and freshrss data, or brings back a user data or state export
+	// "import" = the command we register
+	// HandlerImport works on handlers, and registers "import" there
+
+	// register the handler function for the export cmd
+	cmds.Register(app.Spec{
+		Name:     "export",
+		Summary:  "Export your data as JSON",
+		Usage:    []string{"--all <file>", "--states <file>"},
+		MinArgs:  2,
+		MaxArgs:  2,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerExport))
+	// export --all writes all of the current user's data to a json file, --states only the read and starred items
+	// "export" = the command we register
+	// HandlerExport works on handlers, and registers "export" there
+
+	// register the handler function for the sync cmd
+	cmds.Register(app.Spec{
+		Name:     "sync",
+		Summary:  "Sync with Feedbin, Feedly or another Gator",
+		Usage:    []string{"[feedbin|feedly|gator]", "login <service> ...", "logout <service>", "push-read <service> on|off", "accounts"},
+		MinArgs:  0,
+		MaxArgs:  app.AnyArgs,
+		LoggedIn: true,
+	}, handlers.MiddlewareLoggedIn(handlers.HandlerSync))
+	// sync brings over feeds and starred entries from feedbin or feedly (and can push read state back),
+	// or exchanges follows and read state with another gator instance
+	// "sync" = the command we register
+	// HandlerSync works on handlers, and registers "sync" there
+
+	// register the handler function for the telemetry cmd
+	cmds.Register(app.Spec{
+		Name:    "telemetry",
+		Summary: "Turn anonymous usage reports on or off",
+		Usage:   []string{"on [url]", "off", "status"},
+		MinArgs: 1,
+		MaxArgs: 2,
+	}, handlers.HandlerTelemetry)
+	// telemetry turns the opt-in usage reports agg sends on or off, and shows what's sent
+	// "telemetry" = the command we register
+	// HandlerTelemetry works on handlers, and registers "telemetry" there
+
+	// register the help cmd, it lists the commands registered above (with their specs)
+	cmds.Register(app.Spec{
+		Name:    "help",
+		Summary: "List the commands, or print one's usage",
+		Usage:   []string{"[command]"},
+		MinArgs: 0,
+		MaxArgs: 1,
+	}, cmds.Help)
+	// "help" = the command we register
+	// Help is a method on cmds, so it sees every registered command's spec
+
 	// CLI args check
-	// 2 CLI args min! 1st = command, 2nd = arg
-	if len(os.Args) < 2 {
+	// 1 CLI arg min (after global flags)! 1st = command, rest = args
+	if len(args) < 1 {
 		fmt.Println("error: insufficient arguments!")
-		fmt.Println("Usage: aggregator <command> [args...]")
+		fmt.Println("Usage: aggregator [-v|-vv|--quiet] [--log-format text|json] [--output text|json|csv|tsv] [--timeout <duration>] <command> [args...]")
+		fmt.Println("Run aggregator help for the commands.")
 		os.Exit(1) // clean exit
 	}
 
 	// get args (not needed, readability!)
-	cmdName := os.Args[1]  // aggregator <command>, thus index 1
-	cmdArgs := os.Args[2:] // aggregator <command> [args...], thus index 2+
+	cmdName := args[0]  // aggregator <command>, thus index 0
+	cmdArgs := args[1:] // aggregator <command> [args...], thus index 1+
 
 	// use CLI args to create a command
 	cmd := app.Command{
@@ -144,8 +895,47 @@ func main() {
 		Args: cmdArgs, // args to the command
 	}
 
+	// create the command's context, cancelled on ctrl+c (SIGINT) or SIGTERM (docker stop, systemctl stop)
+	// Run hands it to the handler, which passes it on to every query and feed fetch
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop() // release the signal handler when main returns
+
+	// once stopping, let a 2nd ctrl+c kill the process as usual
+	go func(ctx context.Context) {
+		<-ctx.Done() // in-flight queries and requests see it too and stop, open transactions roll back
+		stop()       // back to the default signal handling
+		slog.Debug("stopping", "cause", context.Cause(ctx))
+	}(ctx) // this ctx, not the timeout one below
+
+	// per-command timeout check (0 = no timeout)
+	if flags.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flags.timeout)
+		defer cancel() // release the timer when main returns
+	}
+
+	// serverless check, wake a sleeping database (retrying) before the command's first query
+	if dbDialect.Serverless() && !offline {
+		err = dialect.Wake(ctx, db)
+
+		// wake check (just warn, the command reports the real error, db ping explains it)
+		if err != nil {
+			slog.Warn("database is not answering", "err", err)
+		}
+	}
+
 	// run the command
-	err = cmds.Run(state, cmd) // we created state, cmd and cmds above
+	err = cmds.Run(ctx, state, cmd) // we created ctx, state, cmd and cmds above
+
+	// count it for telemetry, only gator's own commands and only while it's on (nothing is counted otherwise)
+	if _, known := cmds.Specs[cmdName]; known {
+		handlers.CountCommand(state, cmdName)
+	}
+
+	// timeout check (nicer than a bare "context deadline exceeded")
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("error: command timed out after %v: %w", flags.timeout, err)
+	}
 
 	// run check
 	if err != nil {
@@ -153,3 +943,77 @@ func main() {
 		os.Exit(1) // clean exit
 	}
 }
+
+// global flags struct, parsed from the args before the command
+type globalFlags struct {
+	output    output.Format // --output
+	verbosity int           // -v = 1, -vv = 2
+	quiet     bool          // --quiet
+	logFormat string        // --log-format
+	timeout   time.Duration // --timeout, 0 = none
+	config    string        // --config, "" = GATOR_CONFIG or the default location
+}
+
+// parse global flags helper, returns the flags and the remaining args
+// NOTE: they stop at the command (or "--"), so its args are never taken as flags, eg config set db_url --timeout
+func parseGlobalFlags(args []string) (globalFlags, []string, error) {
+	// create flags with defaults
+	flags := globalFlags{logFormat: "text"} // output "" = not given, see the config's output
+
+	// look for global flags in each arg
+	for i := 0; i < len(args); i++ {
+		// valued flag check (these need the next arg)
+		switch args[i] {
+		case "--output", "-o", "--log-format", "--timeout", "--config":
+			// missing value check
+			if i+1 >= len(args) {
+				return flags, nil, fmt.Errorf("error: %s requires a value", args[i])
+			}
+		}
+
+		// check for a global flag
+		switch args[i] {
+		case "--output", "-o":
+			// parse the output format
+			format, err := output.ParseFormat(args[i+1])
+
+			// format check
+			if err != nil {
+				return flags, nil, err
+			}
+			flags.output = format
+			i++ // skip over the value
+		case "--log-format":
+			flags.logFormat = args[i+1]
+			i++ // skip over the value
+		case "--config":
+			flags.config = args[i+1]
+			i++ // skip over the value
+		case "--timeout":
+			// parse the timeout duration
+			timeout, err := time.ParseDuration(args[i+1])
+
+			// timeout check
+			if err != nil {
+				return flags, nil, fmt.Errorf("error: invalid --timeout: %w", err)
+			}
+			flags.timeout = timeout
+			i++ // skip over the value
+		case "-v", "--verbose":
+			flags.verbosity++ // -v -v works too!
+		case "-vv":
+			flags.verbosity += 2
+		case "-q", "--quiet":
+			flags.quiet = true
+		case "--":
+			// end of the global flags, the rest is the command
+			return flags, args[i+1:], nil
+		default:
+			// the command, it and everything after it is the command's
+			return flags, args[i:], nil
+		}
+	}
+
+	// no command, just flags
+	return flags, nil, nil
+}
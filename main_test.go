@@ -0,0 +1,44 @@
+// main_test.go
+package main
+
+import (
+	// std go libraries
+	"slices"  // comparing args
+	"testing" // go tests
+	"time"    // timeouts
+)
+
+// global flags are only taken before the command, its own args are left alone
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    globalFlags
+		rest    []string
+		wantErr bool
+	}{
+		{"before the command", []string{"-q", "--timeout", "30s", "browse", "10"}, globalFlags{logFormat: "text", quiet: true, timeout: 30 * time.Second}, []string{"browse", "10"}, false},
+		{"after the command", []string{"config", "get", "-q"}, globalFlags{logFormat: "text"}, []string{"config", "get", "-q"}, false},
+		{"a value that looks like a flag", []string{"config", "set", "db_url", "--timeout"}, globalFlags{logFormat: "text"}, []string{"config", "set", "db_url", "--timeout"}, false},
+		{"double dash", []string{"-v", "--", "-q"}, globalFlags{logFormat: "text", verbosity: 1}, []string{"-q"}, false},
+		{"no command", []string{"-vv"}, globalFlags{logFormat: "text", verbosity: 2}, nil, false},
+		{"missing value", []string{"--timeout"}, globalFlags{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, rest, err := parseGlobalFlags(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if flags != tt.want || !slices.Equal(rest, tt.rest) {
+				t.Errorf("got %+v %q, want %+v %q", flags, rest, tt.want, tt.rest)
+			}
+		})
+	}
+}
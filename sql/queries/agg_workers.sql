@@ -0,0 +1,30 @@
+-- agg_workers.sql
+
+-- name: HeartbeatAggWorker :exec
+-- register a worker, or keep it alive (it's re-added if it was taken for dead meanwhile)
+INSERT INTO agg_workers (id, hostname, pid, started_at, heartbeat_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE
+SET heartbeat_at = EXCLUDED.heartbeat_at;
+
+-- name: DeleteAggWorker :exec
+-- a worker stopped, its unfinished jobs were released already
+DELETE FROM agg_workers
+WHERE id = $1;
+
+-- name: DeleteDeadAggWorkers :execrows
+-- forget workers that stopped heartbeating, after their jobs were requeued
+DELETE FROM agg_workers
+WHERE heartbeat_at < $1;
+
+-- name: ListAggWorkers :many
+-- the workers with their running jobs (agg workers)
+SELECT
+    w.id,
+    w.hostname,
+    w.pid,
+    w.started_at,
+    w.heartbeat_at,
+    (SELECT COUNT(*) FROM fetch_jobs j WHERE j.worker_id = w.id AND j.state = 'running') AS running_jobs
+FROM agg_workers w
+ORDER BY w.started_at;
\ No newline at end of file
@@ -0,0 +1,46 @@
+-- api_tokens.sql
+
+-- name: CreateAPIToken :one
+-- add a token for a user (token create)
+INSERT INTO api_tokens (id, created_at, user_id, name, token_hash)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: ListAPITokens :many
+-- a user's tokens, oldest first (token list)
+SELECT * FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteAPIToken :execrows
+-- revoke a user's token by name (token revoke)
+DELETE FROM api_tokens
+WHERE user_id = $1
+AND name = $2;
+
+-- name: GetUserByAPIToken :one
+-- the user an api request's token belongs to, marking the token used
+WITH used AS (
+    UPDATE api_tokens
+    SET last_used_at = sqlc.arg(used_at)
+    WHERE token_hash = sqlc.arg(token_hash)
+    RETURNING user_id
+)
+SELECT
+    u.id,
+    u.created_at,
+    u.updated_at,
+    u.name,
+    u.deleted_at,
+    u.browse_cursor_at,
+    u.browse_cursor_post_id,
+    u.is_admin
+FROM users u
+INNER JOIN used ON used.user_id = u.id
+WHERE u.deleted_at IS NULL; -- soft deleted users can't use the api
@@ -0,0 +1,63 @@
+-- digest_preferences.sql
+
+-- name: SetDigestPreference :exec
+-- replace a user's digest schedule, channel and scope (keeping when the last one was sent)
+INSERT INTO digest_preferences (user_id, created_at, updated_at, cadence, weekday, send_minute, timezone, channel, folders, tags)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (user_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    cadence = EXCLUDED.cadence,
+    weekday = EXCLUDED.weekday,
+    send_minute = EXCLUDED.send_minute,
+    timezone = EXCLUDED.timezone,
+    channel = EXCLUDED.channel,
+    folders = EXCLUDED.folders,
+    tags = EXCLUDED.tags;
+
+-- name: GetDigestPreference :one
+-- a user's digest preferences (no rows = none, digests follow digest_interval)
+SELECT * FROM digest_preferences
+WHERE user_id = $1;
+
+-- name: DeleteDigestPreference :execrows
+-- drop a user's digest preferences, back to digest_interval
+DELETE FROM digest_preferences
+WHERE user_id = $1;
+
+-- name: ListDigestPreferences :many
+-- every user's digest preferences, with where each channel delivers (agg works out which are due)
+SELECT
+    dp.user_id,
+    u.name AS user_name,
+    dp.created_at,
+    dp.cadence,
+    dp.weekday,
+    dp.send_minute,
+    dp.timezone,
+    dp.channel,
+    dp.folders,
+    dp.tags,
+    dp.last_sent_at,
+    d.email,
+    d.unsubscribe_token,
+    t.chat_id
+FROM digest_preferences dp
+INNER JOIN users u ON u.id = dp.user_id
+-- left join the email subscription and telegram chat (the channel may not need them)
+LEFT JOIN digest_subscriptions d ON d.user_id = dp.user_id
+LEFT JOIN telegram_chats t ON t.user_id = dp.user_id
+ORDER BY u.name;
+
+-- name: ClaimScheduledDigest :execrows
+-- mark a scheduled digest as sent before sending it, unless another agg got there first (last_sent_at changed)
+UPDATE digest_preferences
+SET last_sent_at = sqlc.arg(sent_at)::timestamp
+WHERE user_id = sqlc.arg(user_id)
+AND last_sent_at IS NOT DISTINCT FROM sqlc.narg(previous)::timestamp;
+
+-- name: UnclaimScheduledDigest :exec
+-- sending failed, so the next try covers the same posts again
+UPDATE digest_preferences
+SET last_sent_at = sqlc.narg(previous)::timestamp
+WHERE user_id = sqlc.arg(user_id)
+AND last_sent_at = sqlc.arg(sent_at)::timestamp;
\ No newline at end of file
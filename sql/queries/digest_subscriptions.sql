@@ -0,0 +1,82 @@
+-- digest_subscriptions.sql
+
+-- name: SubscribeDigest :exec
+-- subscribe a user to digests, or change their address (keeping when the last one was sent)
+INSERT INTO digest_subscriptions (user_id, created_at, email, unsubscribe_token)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET email = EXCLUDED.email;
+
+-- name: UnsubscribeDigest :execrows
+-- stop a user's digests
+DELETE FROM digest_subscriptions
+WHERE user_id = $1;
+
+-- name: UnsubscribeDigestByToken :one
+-- stop digests from an email's unsubscribe link
+DELETE FROM digest_subscriptions
+WHERE unsubscribe_token = $1
+RETURNING email;
+
+-- name: GetDigestSubscription :one
+-- a user's digest subscription (no rows = not subscribed)
+SELECT * FROM digest_subscriptions
+WHERE user_id = $1;
+
+-- name: ListDueDigests :many
+-- subscriptions whose next digest is due: never sent, or last sent before sent_before
+SELECT
+    d.user_id,
+    u.name AS user_name,
+    d.created_at,
+    d.email,
+    d.last_sent_at,
+    d.unsubscribe_token
+FROM digest_subscriptions d
+INNER JOIN users u ON u.id = d.user_id
+WHERE (d.last_sent_at IS NULL OR d.last_sent_at < sqlc.arg(sent_before)::timestamp)
+AND NOT EXISTS (SELECT 1 FROM digest_preferences dp WHERE dp.user_id = d.user_id) -- sent on their own schedule instead (see digest prefs)
+ORDER BY u.name;
+
+-- name: ClaimDigest :execrows
+-- mark a digest as sent before sending it, unless another agg got there first (last_sent_at changed)
+UPDATE digest_subscriptions
+SET last_sent_at = sqlc.arg(sent_at)::timestamp
+WHERE user_id = sqlc.arg(user_id)
+AND last_sent_at IS NOT DISTINCT FROM sqlc.narg(previous)::timestamp;
+
+-- name: UnclaimDigest :exec
+-- sending failed, so the next try covers the same posts again
+UPDATE digest_subscriptions
+SET last_sent_at = sqlc.narg(previous)::timestamp
+WHERE user_id = sqlc.arg(user_id)
+AND last_sent_at = sqlc.arg(sent_at)::timestamp;
+
+-- name: ListDigestPosts :many
+-- a digest's posts: new posts of the feeds a user follows found since a time, by feed then newest first
+SELECT
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.created_at,
+    f.name AS feed_name,
+    su.summary
+FROM posts p
+INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+LEFT JOIN post_summaries su ON su.post_id = p.id -- written by the summarizer, if any ('' while it's being written)
+LEFT JOIN folders fo ON fo.id = ff.folder_id -- for the scope, follows without a folder still count otherwise
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (p.language IS NULL OR NOT EXISTS (SELECT 1 FROM language_preferences lp WHERE lp.user_id = ff.user_id AND NOT p.language = ANY(lp.languages))) -- not a preferred language
+AND p.created_at > sqlc.arg(since)::timestamp
+AND (
+    (sqlc.narg(folders)::text[] IS NULL AND sqlc.narg(tags)::text[] IS NULL) -- no scope, every post
+    OR fo.name = ANY(sqlc.narg(folders)::text[]) -- in one of the scope's folders
+    OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.user_id = ff.user_id AND pt.post_id = p.id AND pt.tag = ANY(sqlc.narg(tags)::text[])) -- or tagged with one of its tags
+)
+ORDER BY f.name, p.published_at DESC NULLS LAST, p.created_at DESC
+LIMIT sqlc.arg(post_limit);
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- enclosures.sql
+
+-- name: InsertEnclosures :exec
+-- bulk insert a fetch's enclosures, linked to their post by post url (the posts are inserted first)
+-- '' mime_type and 0 length mean NULL (arrays can't hold sql.Null* types)
+INSERT INTO enclosures (id, created_at, post_id, url, mime_type, length)
+SELECT
+    e.id,
+    sqlc.arg(created_at)::timestamp,
+    p.id,
+    e.url,
+    NULLIF(e.mime_type, ''),
+    NULLIF(e.length, 0)
+FROM UNNEST(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(post_urls)::text[],
+    sqlc.arg(urls)::text[],
+    sqlc.arg(mime_types)::text[],
+    sqlc.arg(lengths)::bigint[]
+) AS e(id, post_url, url, mime_type, length)
+INNER JOIN posts p ON p.url = e.post_url
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetEnclosuresForPosts :many
+-- the enclosures of a page of posts (browse --with-enclosures)
+SELECT * FROM enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY post_id, created_at, url;
\ No newline at end of file
@@ -46,8 +46,9 @@ FROM feed_follows ff
 INNER JOIN users u ON u.id = ff.user_id
 -- inner join feeds (omit other feeds)
 INNER JOIN feeds f ON f.id = ff.feed_id
--- where clause to filter by user_id
+-- where clause to filter by user_id (and hide soft deleted feeds)
 WHERE ff.user_id = $1
+AND f.deleted_at IS NULL
 -- order by created_at descending (otherwise random with where clause)
 ORDER BY ff.created_at DESC;
 
@@ -61,3 +62,130 @@ WHERE f.url = $1         -- matches url
   AND ff.user_id = $2    -- matches user_id
   AND ff.feed_id = f.id  -- feed follow id matches feed id
 RETURNING ff.*;          -- get the deleted record from feed follows table!
+
+-- name: SetFeedFollowNotify :execrows
+-- flag (or unflag) a user's follow of a feed, so its new posts are pushed to them
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  notify = sqlc.arg(notify)
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = sqlc.arg(url)
+AND ff.user_id = sqlc.arg(user_id);
+
+-- name: ListNotifyFeeds :many
+-- the feeds a user is notified about
+SELECT
+    f.name,
+    f.url
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+AND ff.notify
+ORDER BY f.name;
+
+-- name: SetFeedFollowTranslate :execrows
+-- set (or clear, NULL) the language a user's follow of a feed is auto-translated to
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  translate_to = sqlc.narg(translate_to)
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = sqlc.arg(url)
+AND ff.user_id = sqlc.arg(user_id);
+
+-- name: ListTranslateFeeds :many
+-- the feeds a user has auto-translated, and the language of each
+SELECT
+    f.id,
+    f.name,
+    f.url,
+    ff.translate_to::text AS translate_to
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+AND ff.translate_to IS NOT NULL
+ORDER BY f.name;
+
+-- name: SetFeedFollowWeight :execrows
+-- set how much a user's follow of a feed counts in browse --ranked
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  weight = sqlc.arg(weight)
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = sqlc.arg(url)
+AND ff.user_id = sqlc.arg(user_id);
+
+-- name: ListFeedWeights :many
+-- a user's follows with their weight, and how many of their posts since a time the user read and starred
+SELECT
+    f.name,
+    f.url,
+    ff.weight,
+    COUNT(p.id) AS posts,
+    COUNT(ps.read_at) AS reads,
+    COUNT(ps.saved_at) AS stars
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+LEFT JOIN posts p ON p.feed_id = ff.feed_id AND p.created_at > sqlc.arg(since)::timestamp
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+GROUP BY f.name, f.url, ff.weight
+ORDER BY ff.weight DESC, f.name;
+
+-- name: SetFeedFollowSnooze :execrows
+-- snooze a user's follow of a feed until a time, or resume it (NULL)
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  snoozed_until = sqlc.narg(snoozed_until)
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = sqlc.arg(url)
+AND ff.user_id = sqlc.arg(user_id);
+
+-- name: ListSnoozedFeeds :many
+-- a user's follows snoozed past a time, soonest to resume first
+SELECT
+    f.name,
+    f.url,
+    ff.snoozed_until
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND ff.snoozed_until > sqlc.arg(now)::timestamp
+ORDER BY ff.snoozed_until, f.name;
+
+
+-- name: ListUserDataFollows :many
+-- a user's follows with their folder and settings, oldest first (export --all)
+SELECT
+    f.name,
+    f.url,
+    fo.name AS folder,
+    ff.created_at,
+    ff.notify,
+    ff.translate_to,
+    ff.weight,
+    ff.snoozed_until
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+ORDER BY ff.created_at, f.url;
+
+-- name: ListFollowsChangedSince :many
+-- a user's follows made after a time, oldest first (gator sync sends them to the other instance)
+SELECT
+    f.name,
+    f.url,
+    ff.created_at
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = sqlc.arg(user_id)
+AND ff.created_at > sqlc.arg(since)::timestamp
+ORDER BY ff.created_at, f.url;
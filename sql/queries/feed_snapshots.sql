@@ -0,0 +1,25 @@
+-- feed_snapshots.sql
+
+-- name: UpsertFeedSnapshot :exec
+-- store the latest raw body of a feed, replacing the previous one
+INSERT INTO feed_snapshots (feed_id, fetched_at, content_type, body, size, truncated)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET
+  fetched_at = EXCLUDED.fetched_at,
+  content_type = EXCLUDED.content_type,
+  body = EXCLUDED.body,
+  size = EXCLUDED.size,
+  truncated = EXCLUDED.truncated;
+
+-- name: GetFeedSnapshotByURL :one
+SELECT s.* FROM feed_snapshots s
+INNER JOIN feeds f ON f.id = s.feed_id
+WHERE f.url = $1;
\ No newline at end of file
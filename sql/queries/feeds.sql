@@ -1,37 +1,203 @@
 -- feeds.sql
 
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, refresh_interval_seconds, url_key)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
 RETURNING *;
 
 -- name: ListFeedsWithCreator :many
-SELECT f.name AS feedName, f.url AS feedURL, u.name AS userName
+SELECT f.name AS feedName, f.url AS feedURL, u.name AS userName,
+       f.consecutive_failures, f.last_error, f.last_error_at -- for feeds --broken
 FROM feeds f INNER JOIN users u
-ON u.id = f.user_id;
+ON u.id = f.user_id
+WHERE f.deleted_at IS NULL;
 
 -- name: GetFeedByURL :one
 SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
 WHERE url = $1 -- url to match the inputy
+AND deleted_at IS NULL -- soft deleted feeds can't be followed
 LIMIT 1; -- ensure only one record is returned
 
 -- name: MarkFeedFetched :exec
 UPDATE feeds
 SET
   updated_at = NOW(),
-  last_fetched_at = NOW()
+  last_fetched_at = NOW() AT TIME ZONE 'UTC', -- UTC so the scheduler can compare it with Go's clock
+  last_error = NULL, -- it works again, clear the failure streak
+  last_error_at = NULL,
+  consecutive_failures = 0,
+  next_attempt_at = NULL
 WHERE id = $1; -- use feed_id (unique as it's a pk)
 
+-- name: MarkFeedFailed :exec
+-- record a failed fetch, backing off before the next attempt: 1, 2, 4... minutes (the old streak), at most 6 hours
+-- last_fetched_at stays, so without the backoff a broken feed would be due again right away
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  last_error = $2,
+  last_error_at = NOW() AT TIME ZONE 'UTC',
+  consecutive_failures = consecutive_failures + 1,
+  next_attempt_at = NOW() AT TIME ZONE 'UTC' + LEAST(60 * POWER(2, LEAST(consecutive_failures, 9)), 21600) * INTERVAL '1 second'
+WHERE id = $1;
+
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds          -- we return ALL cols for ScrapeFeeds
+WHERE deleted_at IS NULL     -- skip soft deleted feeds
+AND url NOT LIKE 'newsletter:%' -- skip newsletter feeds, their posts come by email
+AND NOT (                    -- skip feeds everyone following them snoozed
+    EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND ff.snoozed_until > NOW() AT TIME ZONE 'UTC')
+    AND NOT EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC'))
+)
 ORDER BY last_fetched_at ASC -- from oldest to newest
 NULLS FIRST                   -- any null fetched at record first, these are EVEN older!
-LIMIT 1;                     -- we should only get 1, as there MIGHT be more than one
\ No newline at end of file
+LIMIT 1;
+
+-- name: GetFeedsToFetch :many
+SELECT * FROM feeds          -- we return ALL cols for ScrapeFeed
+WHERE deleted_at IS NULL     -- skip soft deleted feeds
+AND url NOT LIKE 'newsletter:%' -- skip newsletter feeds, their posts come by email
+AND NOT (                    -- skip feeds everyone following them snoozed
+    EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND ff.snoozed_until > NOW() AT TIME ZONE 'UTC')
+    AND NOT EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = feeds.id AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC'))
+)
+ORDER BY last_fetched_at ASC -- from oldest to newest
+NULLS FIRST;                 -- any null fetched at record first, these are EVEN older!
+
+-- name: GetFeedsByIDs :many
+-- the feeds of the fetch jobs agg claimed
+SELECT * FROM feeds
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+AND deleted_at IS NULL; -- removed since it was queued? the job is simply done
+
+-- name: SetFeedRefreshInterval :execrows
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  refresh_interval_seconds = $2 -- NULL resets to the agg interval
+WHERE url = $1
+AND deleted_at IS NULL;
+
+-- name: DeleteFeedByURL :execrows
+DELETE FROM feeds
+WHERE url = $1;
+
+-- name: CountFeedDependents :one
+-- count everything deleting a feed cascades to (for --dry-run)
+SELECT
+    (SELECT COUNT(*) FROM feed_follows ff WHERE ff.feed_id = f.id) AS feed_follows,
+    (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id) AS posts
+FROM feeds f
+WHERE f.url = $1;
+
+-- name: ListAllFeeds :many
+-- full rows (bench)
+SELECT * FROM feeds
+ORDER BY created_at;
+
+-- name: SoftDeleteFeedByURL :execrows
+-- removefeed: mark the feed deleted, its follows and posts stay for undelete
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  deleted_at = NOW()
+WHERE url = $1
+AND deleted_at IS NULL;
+
+-- name: UndeleteFeedByURL :execrows
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  deleted_at = NULL
+WHERE url = $1
+AND deleted_at IS NOT NULL;
+
+-- name: ListFeedURLs :many
+-- the stored urls without a url_key (soft deleted too, they still hold their url) for spotting equivalent feeds
+-- feeds added before url keys, their key is worked out in go
+SELECT url, deleted_at FROM feeds
+WHERE url_key IS NULL
+ORDER BY created_at;
+
+-- name: GetFeedByURLKey :one
+-- the feed with a urlnorm.Key (soft deleted too, it still holds its key) for spotting equivalent feeds
+SELECT url, deleted_at FROM feeds
+WHERE url_key = $1;
+
+-- name: DiscoverFeeds :many
+-- discover: feeds other users follow that this user doesn't, most followed first, then most active
+SELECT
+    f.name,
+    f.url,
+    COUNT(DISTINCT ff.user_id) AS followers,
+    (SELECT COUNT(*) FROM posts p WHERE p.feed_id = f.id AND COALESCE(p.published_at, p.created_at) > sqlc.arg(active_since)) AS recent_posts
+FROM feeds f
+-- inner join feed_follows (only followed feeds)
+INNER JOIN feed_follows ff ON ff.feed_id = f.id
+-- inner join users (soft deleted users don't count)
+INNER JOIN users u ON u.id = ff.user_id AND u.deleted_at IS NULL
+WHERE f.deleted_at IS NULL
+AND ff.user_id != sqlc.arg(user_id)
+-- not the feeds the user already follows
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows mine
+    WHERE mine.feed_id = f.id
+    AND mine.user_id = sqlc.arg(user_id)
+)
+GROUP BY f.id
+ORDER BY followers DESC,
+         recent_posts DESC,
+         f.name
+LIMIT sqlc.arg(feed_limit);
+
+-- name: LockFeedForFetch :one
+-- claim a due feed inside the fetch's transaction, so two aggs never fetch it twice
+-- we should only get 1 agg per feed, as there MIGHT be more than one agg running
+-- SKIP LOCKED = no row if another agg is fetching it right now (instead of waiting for it)
+-- and the last_fetched_at check = no row if another agg fetched it since we listed the feeds
+SELECT * FROM feeds
+WHERE id = $1
+AND deleted_at IS NULL
+AND last_fetched_at IS NOT DISTINCT FROM $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: GetFeedOwner :one
+-- who added a feed, soft deleted or not (removefeed's permission check)
+SELECT user_id
+FROM feeds
+WHERE url = $1;
+
+-- name: SetFeedSchedule :execrows
+-- set or clear a feed's cron schedule (setschedule), with when it's first due
+UPDATE feeds
+SET
+  updated_at = NOW(),
+  refresh_cron = $2, -- NULL = back to the refresh interval
+  next_fetch_at = $3
+WHERE url = $1
+AND deleted_at IS NULL;
+
+-- name: SetFeedNextFetch :exec
+-- when a cron feed is due next, after it was fetched
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1;
+
+-- name: ListNewsletterFeeds :many
+-- the newsletter feeds a user added (see newsletter), their url is newsletter:<address token>
+SELECT id, created_at, name, url, last_fetched_at
+FROM feeds
+WHERE user_id = $1
+AND url LIKE 'newsletter:%'
+AND deleted_at IS NULL
+ORDER BY name;
\ No newline at end of file
@@ -0,0 +1,177 @@
+-- fetch_jobs.sql
+
+-- name: EnqueueDueFeeds :execrows
+-- queue a fetch job for each due feed, same rule as scheduler.NextDue:
+-- a cron feed once its next_fetch_at passed, otherwise
+-- never fetched, or one interval (its own, else the agg one) after the last fetch
+-- skips feeds with an open job, or whose last job failed less than an interval ago (they wait for their next turn)
+-- never fetched feeds get priority 1, so new feeds are fetched first
+-- NOTE: int * INTERVAL, not make_interval, so it works on cockroachdb too
+INSERT INTO fetch_jobs (created_at, updated_at, feed_id, priority, run_after)
+SELECT
+    sqlc.arg(now)::timestamp,
+    sqlc.arg(now)::timestamp,
+    f.id,
+    CASE WHEN f.last_fetched_at IS NULL THEN 1 ELSE 0 END,
+    sqlc.arg(now)::timestamp
+FROM feeds f
+WHERE f.deleted_at IS NULL
+AND f.url NOT LIKE 'newsletter:%' -- newsletter feeds aren't fetched, their posts come by email
+AND CASE
+    WHEN f.refresh_cron IS NOT NULL THEN f.next_fetch_at IS NULL OR f.next_fetch_at <= sqlc.arg(now)::timestamp
+    ELSE f.last_fetched_at IS NULL
+        OR f.last_fetched_at + COALESCE(NULLIF(f.refresh_interval_seconds, 0), sqlc.arg(fallback_seconds)::int) * INTERVAL '1 second' <= sqlc.arg(now)::timestamp
+END
+AND (f.next_attempt_at IS NULL OR f.next_attempt_at <= sqlc.arg(now)::timestamp) -- a failing feed backs off (see MarkFeedFailed)
+AND NOT EXISTS (
+    SELECT 1
+    FROM fetch_jobs j
+    WHERE j.feed_id = f.id
+    AND (
+        j.state IN ('pending', 'running')
+        OR (j.state = 'failed' AND j.finished_at + COALESCE(NULLIF(f.refresh_interval_seconds, 0), sqlc.arg(fallback_seconds)::int) * INTERVAL '1 second' > sqlc.arg(now)::timestamp)
+    )
+)
+-- paused while everyone following it snoozed it (see snooze)
+AND NOT (
+    EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = f.id AND ff.snoozed_until > sqlc.arg(now)::timestamp)
+    AND NOT EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = f.id AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= sqlc.arg(now)::timestamp))
+)
+ON CONFLICT DO NOTHING; -- another agg queued it meanwhile
+
+-- name: ClaimFetchJobs :many
+-- start the next pending jobs on a worker, highest priority first (at most batch_size of them, NULL = all)
+-- SKIP LOCKED = jobs another agg is claiming right now are left to it
+UPDATE fetch_jobs
+SET
+    state = 'running',
+    worker_id = sqlc.arg(worker_id),
+    attempts = attempts + 1,
+    started_at = sqlc.arg(now)::timestamp,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE id IN (
+    SELECT id
+    FROM fetch_jobs
+    WHERE state = 'pending'
+    AND run_after <= sqlc.arg(now)::timestamp
+    ORDER BY priority DESC, run_after, id
+    LIMIT sqlc.narg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FinishFetchJob :exec
+-- a job is done, or failed for good
+-- only while its worker still has it, a job reassigned meanwhile belongs to the new one
+UPDATE fetch_jobs
+SET
+    state = $2,
+    finished_at = $3,
+    updated_at = $3,
+    last_error = $4
+WHERE id = $1
+AND state = 'running'
+AND worker_id = $5;
+
+-- name: RetryFetchJob :exec
+-- a job failed but has attempts left, try again after run_after
+UPDATE fetch_jobs
+SET
+    state = 'pending',
+    run_after = $2,
+    updated_at = NOW(),
+    last_error = $3
+WHERE id = $1
+AND state = 'running'
+AND worker_id = $4;
+
+-- name: ReleaseFetchJob :exec
+-- a job was interrupted (ctrl+c), back to pending without counting the attempt
+UPDATE fetch_jobs
+SET
+    state = 'pending',
+    attempts = attempts - 1,
+    updated_at = NOW()
+WHERE id = $1
+AND state = 'running'
+AND worker_id = $2;
+
+-- name: DeferFetchJob :exec
+-- a job's host circuit is open (see host_breakers), back to pending until it closes without counting the attempt
+UPDATE fetch_jobs
+SET
+    state = 'pending',
+    attempts = attempts - 1,
+    run_after = $2,
+    updated_at = NOW(),
+    last_error = $3
+WHERE id = $1
+AND state = 'running'
+AND worker_id = $4;
+
+-- name: RequeueOrphanedFetchJobs :execrows
+-- running jobs a dead worker left behind (it crashed, was killed or lost the database), back to pending
+-- a worker is dead once it stopped heartbeating, jobs without one (it was removed) once they ran too long
+UPDATE fetch_jobs
+SET
+    state = 'pending',
+    worker_id = NULL,
+    updated_at = NOW()
+WHERE state = 'running'
+AND (
+    (worker_id IS NULL AND started_at < sqlc.arg(started_before)::timestamp)
+    OR worker_id IN (
+        SELECT id
+        FROM agg_workers
+        WHERE heartbeat_at < sqlc.arg(heartbeat_before)::timestamp
+    )
+);
+
+-- name: DeleteOldFetchJobs :execrows
+-- forget finished jobs, they're only kept for jobs' history
+DELETE FROM fetch_jobs
+WHERE state IN ('done', 'failed')
+AND finished_at < $1;
+
+-- name: ListFetchJobWakes :many
+-- when feeds held back by their jobs may be fetched again, for agg's next wake
+-- pending jobs wake at run_after, failed ones one interval after they failed, running ones not at all (NULL)
+SELECT
+    j.feed_id,
+    (CASE
+        WHEN j.state = 'pending' THEN j.run_after
+        WHEN j.state = 'failed' THEN j.finished_at + COALESCE(NULLIF(f.refresh_interval_seconds, 0), sqlc.arg(fallback_seconds)::int) * INTERVAL '1 second'
+    END)::timestamp AS wake_at
+FROM fetch_jobs j
+INNER JOIN feeds f ON f.id = j.feed_id
+WHERE j.state IN ('pending', 'running')
+OR (j.state = 'failed' AND j.finished_at + COALESCE(NULLIF(f.refresh_interval_seconds, 0), sqlc.arg(fallback_seconds)::int) * INTERVAL '1 second' > sqlc.arg(now)::timestamp);
+
+-- name: CountFetchJobs :many
+-- the queue's jobs per state, with the oldest's time (jobs)
+SELECT
+    state,
+    COUNT(*) AS jobs,
+    MIN(created_at)::timestamp AS oldest
+FROM fetch_jobs
+GROUP BY state
+ORDER BY state;
+
+-- name: ListFetchJobs :many
+-- the latest jobs in a state, with their feeds (jobs <state>)
+SELECT
+    j.id,
+    f.name AS feed_name,
+    f.url AS feed_url,
+    j.state,
+    j.priority,
+    j.attempts,
+    j.run_after,
+    j.started_at,
+    j.finished_at,
+    j.last_error
+FROM fetch_jobs j
+INNER JOIN feeds f ON f.id = j.feed_id
+WHERE j.state = $1
+ORDER BY j.updated_at DESC
+LIMIT $2;
\ No newline at end of file
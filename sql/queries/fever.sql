@@ -0,0 +1,158 @@
+-- fever.sql
+
+-- name: UpsertFeverAccount :exec
+-- set a user's fever api key (fever set-password), replacing the old one
+INSERT INTO fever_accounts (user_id, created_at, api_key_hash)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id) DO UPDATE
+SET api_key_hash = EXCLUDED.api_key_hash;
+
+-- name: DeleteFeverAccount :execrows
+-- turn off a user's fever access (fever disable)
+DELETE FROM fever_accounts
+WHERE user_id = $1;
+
+-- name: GetUserByFeverAPIKey :one
+-- the user a fever client's api_key belongs to
+SELECT
+    u.id,
+    u.created_at,
+    u.updated_at,
+    u.name,
+    u.deleted_at,
+    u.browse_cursor_at,
+    u.browse_cursor_post_id,
+    u.is_admin
+FROM users u
+INNER JOIN fever_accounts fa ON fa.user_id = u.id
+WHERE fa.api_key_hash = $1
+AND u.deleted_at IS NULL; -- soft deleted users can't sync
+
+-- name: ListFeverGroups :many
+-- a user's folders, as fever groups
+SELECT fever_id, name
+FROM folders
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: ListFeverFeeds :many
+-- the feeds a user follows, with the fever id of their folder (NULL = not in one)
+SELECT
+    f.fever_id,
+    f.name,
+    f.url,
+    f.last_fetched_at,
+    fo.fever_id AS group_id
+FROM feed_follows ff
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = ff.feed_id AND f.deleted_at IS NULL
+-- left join folders (follows without one are still listed)
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+ORDER BY f.name;
+
+-- name: ListFeverItems :many
+-- a page of a user's posts with their read and saved marks
+-- since_id and max_id 0 mean no bound, ids NULL means any post; newest_first pages down from max_id
+SELECT
+    p.fever_id,
+    f.fever_id AS feed_fever_id,
+    p.title,
+    p.url,
+    p.description,
+    COALESCE(p.published_at, p.created_at) AS created_on,
+    ps.read_at IS NOT NULL AS is_read,
+    ps.saved_at IS NOT NULL AS is_saved
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+-- left join post_states (no row = unread and not saved)
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND p.fever_id > sqlc.arg(since_id)
+AND (sqlc.arg(max_id)::bigint = 0 OR p.fever_id < sqlc.arg(max_id))
+AND (sqlc.narg(ids)::bigint[] IS NULL OR p.fever_id = ANY(sqlc.narg(ids)::bigint[]))
+ORDER BY CASE WHEN sqlc.arg(newest_first)::bool THEN -p.fever_id ELSE p.fever_id END
+LIMIT sqlc.arg(item_limit);
+
+-- name: CountFeverItems :one
+-- how many posts a user can read (fever's total_items)
+SELECT COUNT(*)
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = $1;
+
+-- name: ListUnreadFeverItemIDs :many
+-- the fever ids of a user's unread posts
+SELECT p.fever_id
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+AND ps.read_at IS NULL
+ORDER BY p.fever_id;
+
+-- name: ListSavedFeverItemIDs :many
+-- the fever ids of a user's saved posts (kept after an unfollow)
+SELECT p.fever_id
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+WHERE ps.user_id = $1
+AND ps.saved_at IS NOT NULL
+ORDER BY p.fever_id;
+
+-- name: SetPostRead :execrows
+-- mark a post read or unread for a user (keeps when it was first read)
+INSERT INTO post_states (user_id, post_id, updated_at, read_at)
+SELECT sqlc.arg(user_id), p.id, NOW(), CASE WHEN sqlc.arg(read)::bool THEN NOW() END
+FROM posts p
+WHERE p.fever_id = sqlc.arg(fever_id)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = CASE WHEN sqlc.arg(read)::bool THEN COALESCE(post_states.read_at, EXCLUDED.read_at) END;
+
+-- name: SetPostSaved :execrows
+-- save or unsave a post for a user (keeps when it was first saved)
+INSERT INTO post_states (user_id, post_id, updated_at, saved_at)
+SELECT sqlc.arg(user_id), p.id, NOW(), CASE WHEN sqlc.arg(saved)::bool THEN NOW() END
+FROM posts p
+WHERE p.fever_id = sqlc.arg(fever_id)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    saved_at = CASE WHEN sqlc.arg(saved)::bool THEN COALESCE(post_states.saved_at, EXCLUDED.saved_at) END;
+
+-- name: MarkFeverFeedRead :execrows
+-- mark a followed feed's posts read, up to before (so posts the client hasn't seen stay unread)
+INSERT INTO post_states (user_id, post_id, updated_at, read_at)
+SELECT ff.user_id, p.id, NOW(), NOW()
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND f.fever_id = sqlc.arg(feed_id)
+AND COALESCE(p.published_at, p.created_at) <= sqlc.arg(before)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at);
+
+-- name: MarkFeverGroupRead :execrows
+-- mark the posts of a folder's feeds read, up to before (group 0 = every followed feed)
+INSERT INTO post_states (user_id, post_id, updated_at, read_at)
+SELECT ff.user_id, p.id, NOW(), NOW()
+FROM posts p
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (sqlc.arg(group_id)::bigint = 0 OR fo.fever_id = sqlc.arg(group_id))
+AND COALESCE(p.published_at, p.created_at) <= sqlc.arg(before)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at);
\ No newline at end of file
@@ -0,0 +1,41 @@
+-- folders.sql
+
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, name, user_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetFolderByName :one
+-- folder names are only unique per user
+SELECT * FROM folders
+WHERE user_id = $1
+AND name = $2;
+
+-- name: ListFoldersForUser :many
+-- a user's folders, with how many followed feeds are in each
+SELECT
+    fo.name,
+    COUNT(ff.id) AS feeds
+FROM folders fo
+-- left join, so empty folders are listed too
+LEFT JOIN feed_follows ff ON ff.folder_id = fo.id
+WHERE fo.user_id = $1
+GROUP BY fo.id, fo.name
+ORDER BY fo.name;
+
+-- name: MoveFeedFollowToFolder :execrows
+-- put a user's follow of a feed in a folder (NULL = take it out of its folder)
+UPDATE feed_follows ff
+SET
+  updated_at = NOW(),
+  folder_id = sqlc.narg(folder_id)
+FROM feeds f
+WHERE ff.feed_id = f.id
+AND f.url = sqlc.arg(url)
+AND ff.user_id = sqlc.arg(user_id);
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- host_breakers.sql
+
+-- name: ListHostBreakers :many
+-- every host with timeouts in a row, its circuit open or not (agg, feeds --broken)
+SELECT * FROM host_breakers
+ORDER BY host;
+
+-- name: RecordHostTimeout :one
+-- a fetch from the host timed out, its circuit opens until open_until once threshold of them came in a row
+-- an open circuit's trial fetch after the cooldown timing out too opens it again right away
+INSERT INTO host_breakers (host, consecutive_timeouts, last_error, updated_at)
+VALUES (sqlc.arg(host), 1, sqlc.arg(last_error), sqlc.arg(now)::timestamp)
+ON CONFLICT (host) DO UPDATE
+SET
+    consecutive_timeouts = host_breakers.consecutive_timeouts + 1,
+    open_until = CASE
+        WHEN host_breakers.consecutive_timeouts + 1 >= sqlc.arg(threshold)::int THEN sqlc.arg(open_until)::timestamp
+        ELSE host_breakers.open_until
+    END,
+    last_error = EXCLUDED.last_error,
+    updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: CloseHostBreaker :exec
+-- the host answered, forget its timeouts
+DELETE FROM host_breakers
+WHERE host = $1;
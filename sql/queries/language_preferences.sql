@@ -0,0 +1,19 @@
+-- language_preferences.sql
+
+-- name: SetLanguagePreference :exec
+-- replace a user's preferred languages
+INSERT INTO language_preferences (user_id, updated_at, languages)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    languages = EXCLUDED.languages;
+
+-- name: GetLanguagePreference :one
+-- a user's preferred languages (no rows = no preference, every language is fine)
+SELECT * FROM language_preferences
+WHERE user_id = $1;
+
+-- name: DeleteLanguagePreference :execrows
+-- drop a user's preferred languages, back to every language
+DELETE FROM language_preferences
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- mutes.sql
+
+-- name: AddMute :execrows
+-- add a keyword or regex to a user's mute list (adding it twice is fine)
+INSERT INTO mutes (id, created_at, user_id, pattern, is_regex)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, pattern) DO NOTHING;
+
+-- name: ListMutes :many
+-- a user's mute list, oldest first
+SELECT * FROM mutes
+WHERE user_id = $1
+ORDER BY created_at, pattern;
+
+-- name: RemoveMute :execrows
+-- take a pattern off a user's mute list
+DELETE FROM mutes
+WHERE user_id = $1
+AND pattern = $2;
+
+-- name: CheckMuteRegex :exec
+-- errors if the database can't use a regex, so a bad one never breaks browsing
+SELECT '' ~* sqlc.arg(pattern)::text;
\ No newline at end of file
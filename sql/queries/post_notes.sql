@@ -0,0 +1,60 @@
+-- post_notes.sql
+
+-- name: CreatePostNote :one
+-- add a note to a post
+INSERT INTO post_notes (id, created_at, user_id, post_id, body, highlight)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListPostNotes :many
+-- a user's notes with their posts, oldest first, optionally only one post's
+SELECT
+    n.id,
+    n.created_at,
+    n.body,
+    n.highlight,
+    p.id AS post_id,
+    p.title AS post_title,
+    p.url AS post_url,
+    f.name AS feed_name
+FROM post_notes n
+INNER JOIN posts p ON p.id = n.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE n.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(post_id)::uuid IS NULL OR n.post_id = sqlc.narg(post_id))
+ORDER BY n.created_at, n.id;
+
+-- name: FindPostNotes :many
+-- a user's notes whose id starts with a prefix (note list prints the first 8 characters)
+-- limit 2 so callers can tell an ambiguous prefix apart
+SELECT * FROM post_notes
+WHERE user_id = sqlc.arg(user_id)
+AND id::text LIKE sqlc.arg(prefix) || '%'
+LIMIT 2;
+
+-- name: DeletePostNote :execrows
+-- delete one of a user's notes
+DELETE FROM post_notes
+WHERE id = $1
+AND user_id = $2;
+
+-- name: RestorePostNotes :execrows
+-- notes brought over from a user data export (see import), by post url, keeping their ids
+-- '' highlight means NULL, skips urls without a post, and notes already stored (importing twice is fine)
+INSERT INTO post_notes (id, created_at, user_id, post_id, body, highlight)
+SELECT
+    n.id,
+    n.created_at,
+    sqlc.arg(user_id)::uuid,
+    p.id,
+    n.body,
+    NULLIF(n.highlight, '')
+FROM UNNEST(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(created_ats)::timestamp[],
+    sqlc.arg(urls)::text[],
+    sqlc.arg(bodies)::text[],
+    sqlc.arg(highlights)::text[]
+) AS n(id, created_at, url, body, highlight)
+INNER JOIN posts p ON p.url = n.url
+ON CONFLICT (id) DO NOTHING;
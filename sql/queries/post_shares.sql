@@ -0,0 +1,41 @@
+-- post_shares.sql
+
+-- name: CreatePostShare :one
+-- share a post with another user
+INSERT INTO post_shares (id, created_at, from_user_id, to_user_id, post_id, message)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListInbox :many
+-- the posts shared with a user, newest first, only the new ones unless all
+SELECT
+    sh.id,
+    sh.created_at,
+    sh.message,
+    sh.seen_at,
+    u.name AS from_user,
+    p.title AS post_title,
+    p.url AS post_url,
+    f.name AS feed_name
+FROM post_shares sh
+INNER JOIN users u ON u.id = sh.from_user_id
+INNER JOIN posts p ON p.id = sh.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE sh.to_user_id = sqlc.arg(user_id)
+AND (sqlc.arg(all_shares)::bool OR sh.seen_at IS NULL)
+ORDER BY sh.created_at DESC
+LIMIT sqlc.arg(share_limit);
+
+-- name: MarkInboxSeen :execrows
+-- mark the shares a user was shown as seen
+UPDATE post_shares
+SET seen_at = sqlc.arg(seen_at)::timestamp
+WHERE to_user_id = sqlc.arg(user_id)
+AND id = ANY(sqlc.arg(ids)::uuid[])
+AND seen_at IS NULL;
+
+-- name: CountNewShares :one
+-- how many new shares are in a user's inbox
+SELECT COUNT(*) FROM post_shares
+WHERE to_user_id = $1
+AND seen_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,48 @@
+-- post_summaries.sql
+
+-- name: ListPostsToSummarize :many
+-- posts found since a time that someone follows, with enough text and no summary yet, newest first
+-- empty summaries older than stale_before are from an agg that died while writing them, so they count as none
+SELECT
+    p.id,
+    p.title,
+    p.description
+FROM posts p
+WHERE p.created_at > sqlc.arg(since)
+AND LENGTH(p.description) >= sqlc.arg(min_length)::int
+AND EXISTS (SELECT 1 FROM feed_follows ff WHERE ff.feed_id = p.feed_id)
+AND NOT EXISTS (SELECT 1 FROM post_summaries su WHERE su.post_id = p.id AND (su.summary <> '' OR su.created_at > sqlc.arg(stale_before)))
+ORDER BY p.created_at DESC
+LIMIT sqlc.arg(post_limit);
+
+-- name: ClaimPostSummary :execrows
+-- claim a post to summarize (0 rows = another agg has it), its empty summary counts towards the daily limit
+INSERT INTO post_summaries (post_id, created_at, summary)
+VALUES (sqlc.arg(post_id), sqlc.arg(created_at), '')
+ON CONFLICT (post_id) DO UPDATE
+SET created_at = EXCLUDED.created_at
+WHERE post_summaries.summary = ''
+AND post_summaries.created_at <= sqlc.arg(stale_before);
+
+-- name: SetPostSummary :exec
+-- save a claimed post's summary
+UPDATE post_summaries
+SET summary = $2
+WHERE post_id = $1;
+
+-- name: UnclaimPostSummary :exec
+-- give a claimed post back after its summary failed
+DELETE FROM post_summaries
+WHERE post_id = $1
+AND summary = '';
+
+-- name: CountPostSummariesSince :one
+-- summaries written (or being written) since a time, for the daily limit
+SELECT COUNT(*) FROM post_summaries
+WHERE created_at >= $1;
+
+-- name: GetPostSummaries :many
+-- the summaries of posts, for browse
+SELECT * FROM post_summaries
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+AND summary <> '';
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- post_translations.sql
+
+-- name: GetPostTranslations :many
+-- the stored translations of posts to a language
+SELECT * FROM post_translations
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+AND language = sqlc.arg(language);
+
+-- name: SavePostTranslation :exec
+-- store a post's translation (a newer one replaces it)
+INSERT INTO post_translations (post_id, language, created_at, title, description)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (post_id, language) DO UPDATE
+SET created_at = EXCLUDED.created_at,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description;
\ No newline at end of file
@@ -23,14 +23,489 @@ SELECT
     p.url,
     p.description,
     p.published_at,
-    p.feed_id
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
 FROM posts p
 -- inner join feed_follows (omit other feeds and users)
 INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
 -- match with current user
-WHERE ff.user_id = $1
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (sqlc.narg(language)::text IS NULL OR p.language = sqlc.narg(language)) -- browse --lang
+AND (sqlc.narg(max_minutes)::int IS NULL OR p.reading_minutes <= sqlc.narg(max_minutes)) -- browse --max-minutes
 -- order by published_at descending, NULLS LAST (as they're older)
 -- THEN order by updated_ desc, to prevent random NULL selection
 ORDER BY p.published_at DESC NULLS LAST,
          p.created_at DESC
-LIMIT $2;
\ No newline at end of file
+LIMIT sqlc.arg(post_limit);
+
+-- name: GetPostsForUserInFolder :many
+-- same as GetPostsForUser, but only feeds the user put in the folder (browse --folder)
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join folders (omit follows in other folders, or none)
+INNER JOIN folders fo ON fo.id = ff.folder_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+-- match with current user and folder name
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND fo.name = sqlc.arg(name)
+AND (sqlc.narg(language)::text IS NULL OR p.language = sqlc.narg(language)) -- browse --lang
+AND (sqlc.narg(max_minutes)::int IS NULL OR p.reading_minutes <= sqlc.narg(max_minutes)) -- browse --max-minutes
+ORDER BY p.published_at DESC NULLS LAST,
+         p.created_at DESC
+LIMIT sqlc.arg(post_limit);
+
+-- name: DeletePostsOlderThan :execrows
+-- posts without a pubdate use their created_at instead
+DELETE FROM posts
+WHERE COALESCE(published_at, created_at) < sqlc.arg(cutoff)
+AND NOT EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = posts.id); -- posts with notes are kept
+
+-- name: CountPostsOlderThan :one
+-- count what prune would delete (for --dry-run)
+SELECT COUNT(*) FROM posts
+WHERE COALESCE(published_at, created_at) < sqlc.arg(cutoff)
+AND NOT EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = posts.id); -- posts with notes are kept
+
+-- name: InsertPosts :many
+-- bulk insert a fetch's posts in one round trip, the arrays are zipped into rows by UNNEST
+-- '' description, zero published_at, '' language, 0 reading_minutes and '' guid mean NULL (arrays can't hold sql.Null* types)
+-- skips urls already stored, and returns the urls that were new
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, language, reading_minutes, guid)
+SELECT
+    p.id,
+    sqlc.arg(created_at)::timestamp,
+    sqlc.arg(created_at)::timestamp,
+    p.title,
+    p.url,
+    NULLIF(p.description, ''),
+    NULLIF(p.published_at, '0001-01-01 00:00:00'::timestamp),
+    sqlc.arg(feed_id)::uuid,
+    NULLIF(p.language, ''),
+    NULLIF(p.reading_minutes, 0),
+    NULLIF(p.guid, '')
+FROM UNNEST(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(titles)::text[],
+    sqlc.arg(urls)::text[],
+    sqlc.arg(descriptions)::text[],
+    sqlc.arg(published_ats)::timestamp[],
+    sqlc.arg(languages)::text[],
+    sqlc.arg(reading_minutes)::int[],
+    sqlc.arg(guids)::text[]
+) AS p(id, title, url, description, published_at, language, reading_minutes, guid)
+ON CONFLICT (url) DO NOTHING
+RETURNING url;
+
+-- name: ArchivePostsOlderThan :many
+-- prune --archive: delete the old posts and return them (with their feed) to be archived
+DELETE FROM posts p
+USING feeds f
+WHERE p.feed_id = f.id
+AND COALESCE(p.published_at, p.created_at) < sqlc.arg(cutoff)
+AND NOT EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = p.id) -- posts with notes are kept
+RETURNING
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    f.name AS feed_name,
+    f.url AS feed_url;
+
+-- name: GetPostsForFeed :many
+-- a feed's newest posts (watch prints the ones a notification announced)
+SELECT * FROM posts
+WHERE feed_id = $1
+ORDER BY created_at DESC,
+         published_at DESC NULLS LAST
+LIMIT $2;
+
+-- name: NotifyNewPosts :exec
+-- tell LISTENing processes about new posts, only delivered when the transaction commits
+SELECT pg_notify(sqlc.arg(channel)::text, sqlc.arg(payload)::text);
+
+-- name: GetNewPostsForUser :many
+-- browse --new: posts that arrived after the user's cursor, oldest first so paging never skips any
+-- the zero time and nil uuid mean no cursor yet, folder NULL means every followed feed
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+-- left join folders (--folder, follows without one still count otherwise)
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (p.created_at, p.id) > (sqlc.arg(cursor_at)::timestamp, sqlc.arg(cursor_post_id)::uuid)
+AND (sqlc.narg(folder)::text IS NULL OR fo.name = sqlc.narg(folder))
+AND (sqlc.narg(language)::text IS NULL OR p.language = sqlc.narg(language)) -- browse --lang
+AND (sqlc.narg(max_minutes)::int IS NULL OR p.reading_minutes <= sqlc.narg(max_minutes)) -- browse --max-minutes
+ORDER BY p.created_at,
+         p.id
+LIMIT sqlc.arg(post_limit);
+
+-- name: ListPostsForUser :many
+-- serve's posts endpoint: a page of a user's posts, newest first
+-- folder NULL means every followed feed, query NULL means no search (else title or description ILIKE)
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+-- left join folders (folder filter, follows without one still count otherwise)
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (sqlc.narg(folder)::text IS NULL OR fo.name = sqlc.narg(folder))
+AND (sqlc.narg(query)::text IS NULL
+     OR p.title ILIKE '%' || sqlc.narg(query) || '%'
+     OR p.description ILIKE '%' || sqlc.narg(query) || '%')
+ORDER BY p.published_at DESC NULLS LAST,
+         p.created_at DESC,
+         p.id
+LIMIT sqlc.arg(post_limit)
+OFFSET sqlc.arg(post_offset);
+
+-- name: FindPostsForUser :many
+-- sendto: a followed post by its url or the start of its id (browse prints the first 8 characters)
+-- limit 2 so callers can tell an ambiguous id prefix apart
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (p.url = sqlc.arg(ref)::text OR p.id::text LIKE sqlc.arg(ref)::text || '%')
+ORDER BY p.created_at DESC
+LIMIT 2;
+
+-- name: ListUnreadPostsForUser :many
+-- digest --epub: a user's unread posts (folder NULL means every followed feed), grouped by feed, oldest first
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.language,
+    f.name AS feed_name
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+-- left join folders (--folder, follows without one still count otherwise)
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND (ps.read_at IS NOT NULL OR ps.muted_at IS NOT NULL)) -- read, or muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (sqlc.narg(folder)::text IS NULL OR fo.name = sqlc.narg(folder))
+ORDER BY f.name,
+         p.published_at NULLS LAST,
+         p.created_at
+LIMIT sqlc.arg(post_limit);
+
+-- name: GetRankCandidatesForUser :many
+-- browse --ranked: a user's unread posts since a time, with what the ranking scores them on
+-- feed_posts, feed_reads and feed_stars: the user's interest in the post's feed, over its posts since stats_since
+-- other_reads and other_stars: the post's popularity with the other users
+WITH feed_stats AS (
+    SELECT
+        p.feed_id,
+        COUNT(*) AS posts,
+        COUNT(ps.read_at) AS reads,
+        COUNT(ps.saved_at) AS stars
+    FROM posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = sqlc.arg(user_id)
+    LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+    WHERE p.created_at > sqlc.arg(stats_since)::timestamp
+    GROUP BY p.feed_id
+)
+SELECT
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.fever_id,
+    p.language,
+    p.reading_minutes,
+    ff.weight,
+    COALESCE(fs.posts, 0)::bigint AS feed_posts,
+    COALESCE(fs.reads, 0)::bigint AS feed_reads,
+    COALESCE(fs.stars, 0)::bigint AS feed_stars,
+    (SELECT COUNT(o.read_at) FROM post_states o WHERE o.post_id = p.id AND o.user_id <> ff.user_id)::bigint AS other_reads,
+    (SELECT COUNT(o.saved_at) FROM post_states o WHERE o.post_id = p.id AND o.user_id <> ff.user_id)::bigint AS other_stars
+FROM posts p
+-- inner join feed_follows (omit other feeds and users)
+INNER JOIN feed_follows ff ON p.feed_id = ff.feed_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+-- left join folders (--folder, follows without one still count otherwise)
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+LEFT JOIN feed_stats fs ON fs.feed_id = p.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND p.created_at > sqlc.arg(since)::timestamp
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND (ps.read_at IS NOT NULL OR ps.muted_at IS NOT NULL)) -- read, or muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (sqlc.narg(folder)::text IS NULL OR fo.name = sqlc.narg(folder))
+AND (sqlc.narg(language)::text IS NULL OR p.language = sqlc.narg(language)) -- browse --lang
+AND (sqlc.narg(max_minutes)::int IS NULL OR p.reading_minutes <= sqlc.narg(max_minutes)) -- browse --max-minutes
+ORDER BY p.created_at DESC
+LIMIT sqlc.arg(candidate_limit);
+
+
+-- name: ListTrendingPosts :many
+-- trending: the posts most read and starred across all users since a time (read = opened in a fever app)
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.published_at,
+    p.created_at,
+    f.name AS feed_name,
+    COUNT(DISTINCT ps.user_id)::bigint AS users,
+    COUNT(ps.read_at) FILTER (WHERE ps.read_at > sqlc.arg(since)::timestamp)::bigint AS reads,
+    COUNT(ps.saved_at) FILTER (WHERE ps.saved_at > sqlc.arg(since)::timestamp)::bigint AS stars
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+-- inner join feeds (omit soft deleted feeds)
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ps.read_at > sqlc.arg(since)::timestamp OR ps.saved_at > sqlc.arg(since)::timestamp
+GROUP BY p.id, f.name
+-- stars count three reads, like browse --ranked
+ORDER BY COUNT(ps.read_at) FILTER (WHERE ps.read_at > sqlc.arg(since)::timestamp) + 3 * COUNT(ps.saved_at) FILTER (WHERE ps.saved_at > sqlc.arg(since)::timestamp) DESC, p.created_at DESC
+LIMIT sqlc.arg(post_limit);
+
+
+-- name: ImportPostStates :execrows
+-- read and starred states brought over from another reader (see import), by post url
+-- keeps the states already set, and skips urls without a post
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at)
+SELECT
+    sqlc.arg(user_id)::uuid,
+    p.id,
+    NOW(),
+    CASE WHEN s.read THEN NOW() END,
+    CASE WHEN s.saved THEN NOW() END
+FROM UNNEST(
+    sqlc.arg(urls)::text[],
+    sqlc.arg(reads)::bool[],
+    sqlc.arg(saves)::bool[]
+) AS s(url, read, saved)
+INNER JOIN posts p ON p.url = s.url
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at);
+
+
+-- name: ListReadPostURLs :many
+-- which of some post urls a user has read (sync pushes them to hosted readers)
+SELECT p.url
+FROM posts p
+INNER JOIN post_states ps ON ps.post_id = p.id
+WHERE ps.user_id = sqlc.arg(user_id)
+AND ps.read_at IS NOT NULL
+AND p.url = ANY(sqlc.arg(urls)::text[]);
+
+-- name: ListUserDataPosts :many
+-- the posts a user has a state, tag or note on, with their feed's url (export --all)
+SELECT
+    p.url,
+    p.title,
+    p.description,
+    p.published_at,
+    f.url AS feed_url,
+    ps.read_at,
+    ps.saved_at,
+    ps.muted_at
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = sqlc.arg(user_id)
+WHERE ps.user_id IS NOT NULL
+OR EXISTS (SELECT 1 FROM post_tags pt WHERE pt.post_id = p.id AND pt.user_id = sqlc.arg(user_id))
+OR EXISTS (SELECT 1 FROM post_notes n WHERE n.post_id = p.id AND n.user_id = sqlc.arg(user_id))
+ORDER BY p.created_at, p.id;
+
+-- name: RestorePostStates :execrows
+-- read, starred and muted times brought over from a user data export (see import), by post url
+-- zero times mean NULL, keeps the states already set, and skips urls without a post
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at, muted_at)
+SELECT
+    sqlc.arg(user_id)::uuid,
+    p.id,
+    NOW(),
+    NULLIF(s.read_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.saved_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.muted_at, '0001-01-01 00:00:00'::timestamp)
+FROM UNNEST(
+    sqlc.arg(urls)::text[],
+    sqlc.arg(read_ats)::timestamp[],
+    sqlc.arg(saved_ats)::timestamp[],
+    sqlc.arg(muted_ats)::timestamp[]
+) AS s(url, read_at, saved_at, muted_at)
+INNER JOIN posts p ON p.url = s.url
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at),
+    muted_at = COALESCE(post_states.muted_at, EXCLUDED.muted_at);
+
+-- name: ListPostStatesChangedSince :many
+-- a user's post states changed after a time, with their post and feed, oldest first (gator sync)
+SELECT
+    p.url,
+    f.url AS feed_url,
+    p.title,
+    p.description,
+    p.published_at,
+    ps.read_at,
+    ps.saved_at,
+    ps.muted_at,
+    ps.updated_at
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE ps.user_id = sqlc.arg(user_id)
+AND ps.updated_at > sqlc.arg(since)::timestamp
+ORDER BY ps.updated_at, p.url;
+
+-- name: SyncPostStates :execrows
+-- post states from another gator instance, by post url, the newer change wins (whole rows, unread and unstarred too)
+-- zero times mean NULL, skips urls without a post, and keeps the sender's updated_at so it isn't sent back as new
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at, muted_at)
+SELECT
+    sqlc.arg(user_id)::uuid,
+    p.id,
+    s.updated_at,
+    NULLIF(s.read_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.saved_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.muted_at, '0001-01-01 00:00:00'::timestamp)
+FROM UNNEST(
+    sqlc.arg(urls)::text[],
+    sqlc.arg(read_ats)::timestamp[],
+    sqlc.arg(saved_ats)::timestamp[],
+    sqlc.arg(muted_ats)::timestamp[],
+    sqlc.arg(updated_ats)::timestamp[]
+) AS s(url, read_at, saved_at, muted_at, updated_at)
+INNER JOIN posts p ON p.url = s.url
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = EXCLUDED.read_at,
+    saved_at = EXCLUDED.saved_at,
+    muted_at = EXCLUDED.muted_at
+WHERE post_states.updated_at < EXCLUDED.updated_at;
+
+-- name: ListItemStates :many
+-- a user's read and starred posts with their guid and feed, oldest first (export --states)
+SELECT
+    p.url,
+    p.guid,
+    f.url AS feed_url,
+    p.title,
+    ps.read_at,
+    ps.saved_at
+FROM post_states ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE ps.user_id = $1
+AND (ps.read_at IS NOT NULL OR ps.saved_at IS NOT NULL)
+ORDER BY p.created_at, p.id;
+
+-- name: RestoreItemStates :execrows
+-- read and starred times from a state export (see import states), by post url, else by guid within the item's feed
+-- '' guids and zero times mean NULL, keeps the states already set, and skips items without a post
+INSERT INTO post_states (user_id, post_id, updated_at, read_at, saved_at)
+SELECT DISTINCT ON (p.id) -- one item per post, the url match if both match
+    sqlc.arg(user_id)::uuid,
+    p.id,
+    NOW(),
+    NULLIF(s.read_at, '0001-01-01 00:00:00'::timestamp),
+    NULLIF(s.saved_at, '0001-01-01 00:00:00'::timestamp)
+FROM UNNEST(
+    sqlc.arg(urls)::text[],
+    sqlc.arg(guids)::text[],
+    sqlc.arg(feed_urls)::text[],
+    sqlc.arg(read_ats)::timestamp[],
+    sqlc.arg(saved_ats)::timestamp[]
+) AS s(url, guid, feed_url, read_at, saved_at)
+INNER JOIN posts p ON p.url = s.url
+OR (s.guid <> '' AND p.guid = s.guid AND p.feed_id = (SELECT f.id FROM feeds f WHERE f.url = s.feed_url))
+ORDER BY p.id, p.url = s.url DESC
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at);
@@ -0,0 +1,41 @@
+-- profile_shares.sql
+
+-- name: ShareProfile :exec
+-- publish a user's profile (sharing it twice is fine)
+INSERT INTO profile_shares (user_id, created_at)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: UnshareProfile :execrows
+-- stop publishing a user's profile
+DELETE FROM profile_shares
+WHERE user_id = $1;
+
+-- name: GetProfileShare :one
+-- when a user started sharing their profile (no rows = not shared)
+SELECT created_at
+FROM profile_shares
+WHERE user_id = $1;
+
+-- name: GetSharedProfileUser :one
+-- the user behind a shared profile, by name (no rows = no such user, deleted, or not shared)
+SELECT
+    u.id,
+    u.name
+FROM users u
+INNER JOIN profile_shares ps ON ps.user_id = u.id
+WHERE u.name = $1
+AND u.deleted_at IS NULL;
+
+-- name: ListProfileFeeds :many
+-- the feeds a user follows for their profile, by folder then name ('' = not in a folder)
+SELECT
+    f.name,
+    f.url,
+    COALESCE(fo.name, '')::TEXT AS folder
+FROM feed_follows ff
+INNER JOIN feeds f ON f.id = ff.feed_id
+LEFT JOIN folders fo ON fo.id = ff.folder_id
+WHERE ff.user_id = $1
+AND f.deleted_at IS NULL
+ORDER BY folder, LOWER(f.name);
\ No newline at end of file
@@ -0,0 +1,59 @@
+-- push_targets.sql
+
+-- name: SetPushTarget :exec
+-- set where a user's notifications go, replacing any earlier service (posts found before now aren't pushed)
+INSERT INTO push_targets (user_id, created_at, service, target, token, pushed_at)
+VALUES ($1, $2, $3, $4, $5, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET service = EXCLUDED.service,
+    target = EXCLUDED.target,
+    token = EXCLUDED.token;
+
+-- name: DeletePushTarget :execrows
+-- stop a user's notifications
+DELETE FROM push_targets
+WHERE user_id = $1;
+
+-- name: GetPushTarget :one
+-- where a user's notifications go (no rows = nowhere)
+SELECT * FROM push_targets
+WHERE user_id = $1;
+
+-- name: ListPushTargets :many
+-- every user's push target, for sending notifications
+SELECT * FROM push_targets
+ORDER BY user_id;
+
+-- name: ClaimPushTarget :execrows
+-- move a target's pushed_at on before pushing, unless another agg got there first (pushed_at changed)
+UPDATE push_targets
+SET pushed_at = sqlc.arg(pushed_at)::timestamp
+WHERE user_id = sqlc.arg(user_id)
+AND pushed_at = sqlc.arg(previous)::timestamp;
+
+-- name: UnclaimPushTarget :exec
+-- pushing failed, so the next try covers the same posts again
+UPDATE push_targets
+SET pushed_at = sqlc.arg(previous)::timestamp
+WHERE user_id = sqlc.arg(user_id)
+AND pushed_at = sqlc.arg(pushed_at)::timestamp;
+
+-- name: ListNotifyPosts :many
+-- new posts of a user's flagged follows, found between two times, oldest first
+SELECT
+    p.title,
+    p.url,
+    p.created_at,
+    f.name AS feed_name
+FROM posts p
+INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.notify
+INNER JOIN feeds f ON f.id = p.feed_id AND f.deleted_at IS NULL
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (ff.snoozed_until IS NULL OR ff.snoozed_until <= NOW() AT TIME ZONE 'UTC') -- snoozed follow (see snooze)
+AND NOT EXISTS (SELECT 1 FROM post_states ps WHERE ps.user_id = ff.user_id AND ps.post_id = p.id AND ps.muted_at IS NOT NULL) -- muted by a rule
+AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ff.user_id AND CASE WHEN m.is_regex THEN p.title ~* m.pattern ELSE STRPOS(LOWER(p.title), LOWER(m.pattern)) > 0 END) -- on the mute list
+AND (p.language IS NULL OR NOT EXISTS (SELECT 1 FROM language_preferences lp WHERE lp.user_id = ff.user_id AND NOT p.language = ANY(lp.languages))) -- not a preferred language
+AND p.created_at > sqlc.arg(since)::timestamp
+AND p.created_at <= sqlc.arg(until)::timestamp
+ORDER BY p.created_at
+LIMIT sqlc.arg(post_limit);
\ No newline at end of file
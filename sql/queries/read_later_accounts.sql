@@ -0,0 +1,27 @@
+-- read_later_accounts.sql
+
+-- name: SetReadLaterAccount :exec
+-- save a user's login with a read-later service, replacing any earlier one
+INSERT INTO read_later_accounts (user_id, service, created_at, settings)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, service) DO UPDATE
+SET created_at = EXCLUDED.created_at,
+    settings = EXCLUDED.settings;
+
+-- name: GetReadLaterAccount :one
+-- a user's login with a read-later service (no rows = not logged in)
+SELECT * FROM read_later_accounts
+WHERE user_id = $1
+AND service = $2;
+
+-- name: ListReadLaterAccounts :many
+-- the read-later services a user is logged in to
+SELECT * FROM read_later_accounts
+WHERE user_id = $1
+ORDER BY service;
+
+-- name: DeleteReadLaterAccount :execrows
+-- forget a user's login with a read-later service
+DELETE FROM read_later_accounts
+WHERE user_id = $1
+AND service = $2;
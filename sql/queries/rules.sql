@@ -0,0 +1,86 @@
+-- rules.sql
+
+-- name: CreateRule :exec
+-- add a rule for a user
+INSERT INTO rules (id, created_at, user_id, feed_id, title_pattern, category, author, action, argument)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ListRules :many
+-- a user's rules with their feed's url, oldest first
+SELECT
+    r.id,
+    r.created_at,
+    r.feed_id,
+    f.url AS feed_url,
+    r.title_pattern,
+    r.category,
+    r.author,
+    r.action,
+    r.argument
+FROM rules r
+LEFT JOIN feeds f ON f.id = r.feed_id
+WHERE r.user_id = $1
+ORDER BY r.created_at, r.id;
+
+-- name: DeleteRule :execrows
+-- delete one of a user's rules
+DELETE FROM rules
+WHERE id = $1
+AND user_id = $2;
+
+-- name: ListRulesForFeed :many
+-- the rules that apply to a feed's new posts: of its followers, for this feed or any, with the feed's own first
+SELECT r.*
+FROM rules r
+INNER JOIN feed_follows ff ON ff.user_id = r.user_id AND ff.feed_id = sqlc.arg(feed_id)
+WHERE r.feed_id IS NULL
+OR r.feed_id = sqlc.arg(feed_id)
+ORDER BY r.user_id, r.created_at, r.id;
+
+-- name: MutePost :exec
+-- hide a post from a user (a rule matched it)
+INSERT INTO post_states (user_id, post_id, updated_at, muted_at)
+VALUES ($1, $2, NOW(), NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    muted_at = COALESCE(post_states.muted_at, EXCLUDED.muted_at);
+
+-- name: StarPost :exec
+-- save a post for a user, like fever's saved (a rule matched it)
+INSERT INTO post_states (user_id, post_id, updated_at, saved_at)
+VALUES ($1, $2, NOW(), NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    saved_at = COALESCE(post_states.saved_at, EXCLUDED.saved_at);
+
+-- name: TagPost :exec
+-- tag a post for a user (tagging it twice is fine)
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: ListUserPostTags :many
+-- a user's tags with their post's url (export --all)
+SELECT
+    p.url,
+    pt.tag
+FROM post_tags pt
+INNER JOIN posts p ON p.id = pt.post_id
+WHERE pt.user_id = $1
+ORDER BY p.url, pt.tag;
+
+-- name: RestorePostTags :execrows
+-- tags brought over from a user data export (see import), by post url
+-- skips urls without a post, and tags already set
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT
+    sqlc.arg(user_id)::uuid,
+    p.id,
+    t.tag,
+    NOW()
+FROM UNNEST(
+    sqlc.arg(urls)::text[],
+    sqlc.arg(tags)::text[]
+) AS t(url, tag)
+INNER JOIN posts p ON p.url = t.url
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
@@ -0,0 +1,46 @@
+-- sessions.sql
+
+-- name: CreateSession :exec
+-- start a session for a user who logged in
+INSERT INTO sessions (token_hash, created_at, expires_at, user_id, csrf_token)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: GetSessionUser :one
+-- the user of an unexpired session, with the session's csrf token
+SELECT
+    u.id,
+    u.created_at,
+    u.updated_at,
+    u.name,
+    u.deleted_at,
+    u.browse_cursor_at,
+    u.browse_cursor_post_id,
+    u.is_admin,
+    s.csrf_token,
+    s.expires_at
+FROM sessions s
+INNER JOIN users u ON u.id = s.user_id
+WHERE s.token_hash = sqlc.arg(token_hash)
+AND s.expires_at > sqlc.arg(now)
+AND u.deleted_at IS NULL; -- soft deleted users are logged out
+
+-- name: DeleteSession :execrows
+-- log a session out
+DELETE FROM sessions
+WHERE token_hash = $1;
+
+-- name: DeleteSessionsForUser :execrows
+-- log all of a user's sessions out, when their password changes or they lose access
+DELETE FROM sessions
+WHERE user_id = $1;
+
+-- name: DeleteExpiredSessions :execrows
+-- clean up sessions past their expiry
+DELETE FROM sessions
+WHERE expires_at <= $1;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- short_links.sql
+
+-- name: GetShortLinks :many
+-- where a fetch's shortened links lead, the ones resolved before
+SELECT * FROM short_links
+WHERE url = ANY(sqlc.arg(urls)::text[]);
+
+-- name: SaveShortLinks :exec
+-- remember where shortened links lead (a link another agg just saved is kept)
+INSERT INTO short_links (url, created_at, resolved_url)
+SELECT
+    l.url,
+    sqlc.arg(created_at)::timestamp,
+    l.resolved_url
+FROM UNNEST(
+    sqlc.arg(urls)::text[],
+    sqlc.arg(resolved_urls)::text[]
+) AS l(url, resolved_url)
+ON CONFLICT (url) DO NOTHING;
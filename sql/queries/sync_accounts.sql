@@ -0,0 +1,41 @@
+-- sync_accounts.sql
+
+-- name: SetSyncAccount :exec
+-- save a user's login with a hosted reader, replacing any earlier one (keeping its push_read and last sync)
+INSERT INTO sync_accounts (user_id, service, created_at, settings)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, service) DO UPDATE
+SET created_at = EXCLUDED.created_at,
+    settings = EXCLUDED.settings;
+
+-- name: GetSyncAccount :one
+-- a user's login with a hosted reader (no rows = not logged in)
+SELECT * FROM sync_accounts
+WHERE user_id = $1
+AND service = $2;
+
+-- name: ListSyncAccounts :many
+-- the hosted readers a user syncs with
+SELECT * FROM sync_accounts
+WHERE user_id = $1
+ORDER BY service;
+
+-- name: DeleteSyncAccount :execrows
+-- forget a user's login with a hosted reader
+DELETE FROM sync_accounts
+WHERE user_id = $1
+AND service = $2;
+
+-- name: SetSyncPushRead :execrows
+-- turn pushing read state to a hosted reader on or off
+UPDATE sync_accounts
+SET push_read = $3
+WHERE user_id = $1
+AND service = $2;
+
+-- name: MarkSyncAccountSynced :exec
+-- note when a hosted reader was last synced
+UPDATE sync_accounts
+SET synced_at = $3
+WHERE user_id = $1
+AND service = $2;
\ No newline at end of file
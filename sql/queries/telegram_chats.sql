@@ -0,0 +1,72 @@
+-- telegram_chats.sql
+
+-- name: CreateTelegramLinkCode :exec
+-- start linking a user's telegram chat, with a new code (an already linked chat stays linked until the code is used)
+INSERT INTO telegram_chats (user_id, created_at, link_code, link_code_at, pushed_at)
+VALUES ($1, $2, $3, $2, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET link_code = EXCLUDED.link_code,
+    link_code_at = EXCLUDED.link_code_at;
+
+-- name: GetTelegramLinkCodeUser :one
+-- the user a link code is for, if it was made after not_before (no rows = unknown, used or expired code)
+SELECT user_id
+FROM telegram_chats
+WHERE link_code = sqlc.arg(link_code)
+AND link_code_at > sqlc.arg(not_before);
+
+-- name: LinkTelegramChat :one
+-- link the chat a code was sent from, using the code up, and returning the user's name (no rows = unknown, used or expired code)
+UPDATE telegram_chats t
+SET chat_id = sqlc.arg(chat_id),
+    link_code = NULL,
+    link_code_at = NULL,
+    pushed_at = sqlc.arg(pushed_at)
+FROM users u
+WHERE t.link_code = sqlc.arg(link_code)
+AND t.link_code_at > sqlc.arg(not_before)
+AND u.id = t.user_id
+RETURNING u.name;
+
+-- name: UnlinkTelegramChat :execrows
+-- unlink a chat from whoever it's linked to (/stop)
+DELETE FROM telegram_chats
+WHERE chat_id = $1;
+
+-- name: UnlinkTelegramChatFromOthers :execrows
+-- unlink a chat from every user but the one linking it, a chat follows one user
+DELETE FROM telegram_chats
+WHERE chat_id = $1
+AND user_id <> $2;
+
+-- name: UnlinkTelegramUser :execrows
+-- unlink a user's chat, and drop their unused code
+DELETE FROM telegram_chats
+WHERE user_id = $1;
+
+-- name: GetTelegramLink :one
+-- a user's telegram link (no rows = none)
+SELECT * FROM telegram_chats
+WHERE user_id = $1;
+
+-- name: GetTelegramChatUser :one
+-- the name of the user a chat is linked to (no rows = not linked)
+SELECT u.name
+FROM users u
+INNER JOIN telegram_chats t ON t.user_id = u.id
+WHERE t.chat_id = $1;
+
+-- name: ListTelegramChats :many
+-- the linked chats, for pushing new posts
+SELECT
+    user_id,
+    chat_id,
+    pushed_at
+FROM telegram_chats
+WHERE chat_id IS NOT NULL;
+
+-- name: SetTelegramPushedAt :exec
+-- record up to when a chat's posts were pushed
+UPDATE telegram_chats
+SET pushed_at = $2
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- timeline_shares.sql
+
+-- name: ShareTimeline :exec
+-- publish a user's timeline (sharing it twice is fine)
+INSERT INTO timeline_shares (user_id, created_at)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: UnshareTimeline :execrows
+-- stop publishing a user's timeline
+DELETE FROM timeline_shares
+WHERE user_id = $1;
+
+-- name: GetTimelineShare :one
+-- when a user started sharing their timeline (no rows = not shared)
+SELECT created_at
+FROM timeline_shares
+WHERE user_id = $1;
+
+-- name: GetSharedTimelineUser :one
+-- the user behind a shared timeline, by name (no rows = no such user, deleted, or not shared)
+SELECT
+    u.id,
+    u.name
+FROM users u
+INNER JOIN timeline_shares ts ON ts.user_id = u.id
+WHERE u.name = $1
+AND u.deleted_at IS NULL;
\ No newline at end of file
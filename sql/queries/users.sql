@@ -13,6 +13,7 @@ RETURNING *;
 -- name: GetUser :one
 SELECT * FROM users
 WHERE name = $1
+AND deleted_at IS NULL -- soft deleted users can't log in
 LIMIT 1;
 
 -- name: Reset :exec
@@ -20,4 +21,118 @@ DELETE FROM users;
 
 -- name: GetUsers :many
 SELECT name FROM users
-ORDER BY name;
\ No newline at end of file
+WHERE deleted_at IS NULL
+ORDER BY name;
+
+-- name: CountResetRows :one
+-- count everything reset would delete (for --dry-run)
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM feeds) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+    (SELECT COUNT(*) FROM posts) AS posts;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE name = $1;
+
+-- name: CountUserDependents :one
+-- count everything deleting a user cascades to (for --dry-run)
+SELECT
+    (SELECT COUNT(*) FROM feeds f WHERE f.user_id = u.id) AS feeds,
+    -- their follows, and other users' follows of the feeds they added (those go with the feeds)
+    (SELECT COUNT(*) FROM feed_follows ff WHERE ff.user_id = u.id OR ff.feed_id IN (SELECT f.id FROM feeds f WHERE f.user_id = u.id)) AS feed_follows,
+    (SELECT COUNT(*) FROM feed_follows ff INNER JOIN feeds f ON f.id = ff.feed_id WHERE f.user_id = u.id AND ff.user_id <> u.id) AS other_follows,
+    (SELECT COUNT(*) FROM posts p INNER JOIN feeds f ON f.id = p.feed_id WHERE f.user_id = u.id) AS posts
+FROM users u
+WHERE u.name = $1;
+
+-- name: CountUsersAndFeeds :one
+-- how many users and feeds there are, deleted ones aside (telemetry)
+SELECT
+    (SELECT COUNT(*) FROM users WHERE deleted_at IS NULL) AS users,
+    (SELECT COUNT(*) FROM feeds WHERE deleted_at IS NULL) AS feeds;
+
+-- name: SoftDeleteUser :one
+-- deleteuser: mark the user and the feeds they added as deleted (same timestamp, so undelete finds them)
+WITH deleted_user AS (
+    UPDATE users
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE name = $1
+    AND deleted_at IS NULL
+    RETURNING id, deleted_at
+), deleted_feeds AS (
+    UPDATE feeds f
+    SET deleted_at = du.deleted_at, updated_at = NOW()
+    FROM deleted_user du
+    WHERE f.user_id = du.id
+    AND f.deleted_at IS NULL
+    RETURNING f.id
+)
+SELECT
+    (SELECT COUNT(*) FROM deleted_user) AS users,
+    (SELECT COUNT(*) FROM deleted_feeds) AS feeds;
+
+-- name: UndeleteUser :one
+-- undelete user: restore the user, and the feeds that were deleted along with them
+WITH restored_user AS (
+    UPDATE users u
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM users old
+    WHERE u.id = old.id
+    AND old.name = $1
+    AND old.deleted_at IS NOT NULL
+    RETURNING u.id, old.deleted_at
+), restored_feeds AS (
+    UPDATE feeds f
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM restored_user ru
+    WHERE f.user_id = ru.id
+    AND f.deleted_at = ru.deleted_at -- not the feeds removed on their own before
+    RETURNING f.id
+)
+SELECT
+    (SELECT COUNT(*) FROM restored_user) AS users,
+    (SELECT COUNT(*) FROM restored_feeds) AS feeds;
+
+-- name: SetBrowseCursor :exec
+-- move the user's browse --new cursor to the newest post it showed
+UPDATE users
+SET browse_cursor_at = $2, browse_cursor_post_id = $3
+WHERE id = $1;
+
+-- name: SetUserPassword :exec
+-- set or change a user's password hash (passwd)
+INSERT INTO user_passwords (user_id, updated_at, password_hash)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, password_hash = EXCLUDED.password_hash;
+
+-- name: DeleteUserPassword :execrows
+-- remove a user's password, they log in by name again (passwd --remove)
+DELETE FROM user_passwords
+WHERE user_id = $1;
+
+-- name: GetUserPasswordHash :one
+-- a user's password hash, no rows = the user has no password
+SELECT password_hash
+FROM user_passwords
+WHERE user_id = $1;
+
+-- name: SetUserAdmin :execrows
+-- make a user an admin or not (admin promote/demote)
+UPDATE users
+SET is_admin = $2, updated_at = NOW()
+WHERE name = $1
+AND deleted_at IS NULL;
+
+-- name: CountAdmins :one
+-- how many admins there are, with none every user may do admin things
+SELECT COUNT(*)
+FROM users
+WHERE is_admin
+AND deleted_at IS NULL;
\ No newline at end of file
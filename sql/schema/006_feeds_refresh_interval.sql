@@ -0,0 +1,9 @@
+-- 006_feeds_refresh_interval.sql
+
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN refresh_interval_seconds INTEGER; -- NULL = use the agg interval
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN refresh_interval_seconds;
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- 007_folders.sql
+
+-- +goose Up
+CREATE TABLE folders (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete folder if user deleted
+    -- folder names are unique per user only
+    UNIQUE (user_id, name)
+);
+
+-- a follow is in at most one folder (NULL = not in a folder)
+ALTER TABLE feed_follows
+ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN folder_id;
+
+DROP TABLE folders;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- 008_feeds_fetch_errors.sql
+
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN last_error TEXT,          -- NULL = last fetch worked (or never failed)
+ADD COLUMN last_error_at TIMESTAMP,
+ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN last_error,
+DROP COLUMN last_error_at,
+DROP COLUMN consecutive_failures;
\ No newline at end of file
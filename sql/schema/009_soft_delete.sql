@@ -0,0 +1,16 @@
+-- 009_soft_delete.sql
+
+-- +goose Up
+-- NULL = not deleted, otherwise when it was removed (undelete sets it back to NULL)
+ALTER TABLE users
+ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE feeds
+ADD COLUMN deleted_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN deleted_at;
+
+ALTER TABLE feeds
+DROP COLUMN deleted_at;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- 010_feed_snapshots.sql
+
+-- +goose Up
+-- last raw body fetched per feed (debug mode only), to reproduce parse bugs
+CREATE TABLE feed_snapshots (
+    -- define table columns
+    feed_id UUID PRIMARY KEY, -- one snapshot per feed, replaced on every fetch
+    fetched_at TIMESTAMP NOT NULL,
+    content_type TEXT NOT NULL,
+    body BYTEA NOT NULL, -- gzipped
+    size INTEGER NOT NULL, -- uncompressed bytes stored
+    truncated BOOLEAN NOT NULL, -- body was over the size cap
+    -- link to feeds
+    FOREIGN KEY (feed_id)
+        REFERENCES feeds(id)
+        ON DELETE CASCADE -- delete snapshot if feed deleted
+);
+
+-- +goose Down
+DROP TABLE feed_snapshots;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- 011_enclosures.sql
+
+-- +goose Up
+-- attachments of a post (podcast audio, images...), from <enclosure url="" type="" length="">
+CREATE TABLE enclosures (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT, -- NULL if the feed left it out
+    length BIGINT, -- bytes, NULL if the feed left it out (or sent 0)
+    -- link to posts
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE, -- delete enclosures if post deleted
+    -- the same attachment is only stored once per post
+    UNIQUE (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE enclosures;
\ No newline at end of file
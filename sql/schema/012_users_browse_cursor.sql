@@ -0,0 +1,12 @@
+-- 012_users_browse_cursor.sql
+
+-- +goose Up
+-- where browse --new left off: the newest post it showed (NULL = never used, everything is new)
+ALTER TABLE users
+ADD COLUMN browse_cursor_at TIMESTAMP, -- that post's created_at, ie when it arrived
+ADD COLUMN browse_cursor_post_id UUID; -- tie breaker, one fetch stores its posts with the same created_at
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN browse_cursor_at,
+DROP COLUMN browse_cursor_post_id;
\ No newline at end of file
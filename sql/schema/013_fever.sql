@@ -0,0 +1,57 @@
+-- 013_fever.sql
+
+-- +goose Up
+-- integer ids for the fever api (its clients can't use uuids), numbered in the order rows arrive
+ALTER TABLE posts
+ADD COLUMN fever_id BIGSERIAL UNIQUE;
+
+ALTER TABLE feeds
+ADD COLUMN fever_id BIGSERIAL UNIQUE;
+
+ALTER TABLE folders
+ADD COLUMN fever_id BIGSERIAL UNIQUE;
+
+-- a user's read and saved marks on posts (no row = unread and not saved)
+CREATE TABLE post_states (
+    -- define table columns
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP, -- NULL = unread
+    saved_at TIMESTAMP, -- NULL = not saved
+    -- one state per user and post
+    PRIMARY KEY (user_id, post_id),
+    -- link to users and posts
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete states if user deleted
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE -- delete states if post deleted (prune)
+);
+
+-- fever api keys, one per user (see fever set-password)
+CREATE TABLE fever_accounts (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    api_key_hash TEXT NOT NULL UNIQUE, -- sha256 of the md5("name:password") key fever clients send
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- delete account if user deleted
+);
+
+-- +goose Down
+DROP TABLE fever_accounts;
+
+DROP TABLE post_states;
+
+ALTER TABLE folders
+DROP COLUMN fever_id;
+
+ALTER TABLE feeds
+DROP COLUMN fever_id;
+
+ALTER TABLE posts
+DROP COLUMN fever_id;
\ No newline at end of file
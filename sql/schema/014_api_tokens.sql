@@ -0,0 +1,22 @@
+-- 014_api_tokens.sql
+
+-- +goose Up
+-- api tokens for serve's json api, a user can have several (one per app or machine)
+CREATE TABLE api_tokens (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE, -- sha256 of the token, the token itself is only shown once
+    last_used_at TIMESTAMP, -- NULL = never used
+    -- token names are unique per user
+    UNIQUE (user_id, name),
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- delete tokens if user deleted
+);
+
+-- +goose Down
+DROP TABLE api_tokens;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- 015_user_passwords.sql
+
+-- +goose Up
+-- optional user passwords (see passwd), users without one log in by name as before
+CREATE TABLE user_passwords (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    updated_at TIMESTAMP NOT NULL,
+    password_hash TEXT NOT NULL, -- pbkdf2-sha256$<iterations>$<salt>$<key>, never the password itself
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- delete password if user deleted
+);
+
+-- +goose Down
+DROP TABLE user_passwords;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- 016_sessions.sql
+
+-- +goose Up
+-- serve's cookie sessions, made by logging in with a password (POST /api/session)
+CREATE TABLE sessions (
+    -- define table columns
+    token_hash TEXT PRIMARY KEY, -- sha256 of the cookie's token, the token itself is only in the cookie
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    csrf_token TEXT NOT NULL, -- sent back in X-CSRF-Token on requests that change things
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- delete sessions if user deleted
+);
+
+-- finding expired sessions to clean up
+CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
+
+-- +goose Down
+DROP TABLE sessions;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- 017_users_is_admin.sql
+
+-- +goose Up
+-- admins may reset, delete other users and remove feeds they didn't add (see admin promote)
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- the oldest user becomes the first admin, so existing installs keep someone who can
+UPDATE users
+SET is_admin = TRUE
+WHERE id = (
+    SELECT id
+    FROM users
+    WHERE deleted_at IS NULL
+    ORDER BY created_at
+    LIMIT 1
+);
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_admin;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- 018_feeds_refresh_cron.sql
+
+-- +goose Up
+-- an optional cron schedule per feed (see setschedule), it wins over the refresh interval
+ALTER TABLE feeds
+ADD COLUMN refresh_cron TEXT, -- NULL = fetched on its interval
+ADD COLUMN next_fetch_at TIMESTAMP; -- when a cron feed is due next, in UTC (cron can't be computed in sql)
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN next_fetch_at,
+DROP COLUMN refresh_cron;
\ No newline at end of file
@@ -0,0 +1,35 @@
+-- 019_fetch_jobs.sql
+
+-- +goose Up
+-- agg's queue of feed fetches, one job per fetch (see jobs)
+CREATE TABLE fetch_jobs (
+    -- define table columns
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL,
+    state TEXT NOT NULL DEFAULT 'pending', -- pending, running, failed (out of attempts) or done
+    priority INT NOT NULL DEFAULT 0, -- higher first, never fetched feeds get 1
+    attempts INT NOT NULL DEFAULT 0, -- how often it was started
+    run_after TIMESTAMP NOT NULL, -- not before then (retries back off)
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    last_error TEXT,
+    -- only known states
+    CHECK (state IN ('pending', 'running', 'failed', 'done')),
+    -- link to feeds
+    FOREIGN KEY (feed_id)
+        REFERENCES feeds(id)
+        ON DELETE CASCADE -- delete jobs if feed deleted
+);
+
+-- at most one open job per feed, so a feed is never queued twice
+CREATE UNIQUE INDEX fetch_jobs_open_feed_idx ON fetch_jobs (feed_id)
+WHERE state IN ('pending', 'running');
+
+-- claiming the next pending jobs
+CREATE INDEX fetch_jobs_pending_idx ON fetch_jobs (priority DESC, run_after)
+WHERE state = 'pending';
+
+-- +goose Down
+DROP TABLE fetch_jobs;
\ No newline at end of file
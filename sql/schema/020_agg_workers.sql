@@ -0,0 +1,21 @@
+-- 020_agg_workers.sql
+
+-- +goose Up
+-- the aggs fetching feeds right now, each one heartbeats while it runs (see agg --worker)
+CREATE TABLE agg_workers (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    hostname TEXT NOT NULL,
+    pid INT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    heartbeat_at TIMESTAMP NOT NULL -- a worker that stops heartbeating is dead, its jobs are reassigned
+);
+
+-- which worker claimed a job
+ALTER TABLE fetch_jobs
+ADD COLUMN worker_id UUID REFERENCES agg_workers(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE fetch_jobs
+DROP COLUMN worker_id;
+DROP TABLE agg_workers;
\ No newline at end of file
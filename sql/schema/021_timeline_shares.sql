@@ -0,0 +1,16 @@
+-- 021_timeline_shares.sql
+
+-- +goose Up
+-- users who publish their timeline as rss, on serve's /users/{name}/feed.xml (see sharefeed)
+CREATE TABLE timeline_shares (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- stop sharing if user deleted
+);
+
+-- +goose Down
+DROP TABLE timeline_shares;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- 022_digest_subscriptions.sql
+
+-- +goose Up
+-- users who get emailed digests of their new posts (see digest)
+CREATE TABLE digest_subscriptions (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    email TEXT NOT NULL,
+    last_sent_at TIMESTAMP, -- NULL = never sent, the first digest covers posts since created_at
+    unsubscribe_token TEXT NOT NULL UNIQUE, -- in each digest's unsubscribe link, no login needed
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- unsubscribe if user deleted
+);
+
+-- +goose Down
+DROP TABLE digest_subscriptions;
\ No newline at end of file
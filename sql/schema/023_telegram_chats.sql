@@ -0,0 +1,19 @@
+-- 023_telegram_chats.sql
+
+-- +goose Up
+-- telegram chats linked to users, for telegram bot (see telegram)
+CREATE TABLE telegram_chats (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chat_id BIGINT UNIQUE, -- NULL = telegram link ran, but the code wasn't sent to the bot yet
+    link_code TEXT UNIQUE, -- the one-time code that links a chat, sent as /start <code>
+    pushed_at TIMESTAMP NOT NULL, -- posts found after this weren't pushed to the chat yet
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- unlink if user deleted
+);
+
+-- +goose Down
+DROP TABLE telegram_chats;
\ No newline at end of file
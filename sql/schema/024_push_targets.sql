@@ -0,0 +1,27 @@
+-- 024_push_targets.sql
+
+-- +goose Up
+-- follows can be flagged to notify, their new posts are pushed to the user's phone (see notify)
+ALTER TABLE feed_follows
+ADD COLUMN notify BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- where a user's notifications go, one push service per user
+CREATE TABLE push_targets (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    service TEXT NOT NULL CHECK (service IN ('ntfy', 'pushover')),
+    target TEXT NOT NULL, -- ntfy topic url, or pushover user key
+    token TEXT, -- ntfy access token (optional), or pushover application token
+    pushed_at TIMESTAMP NOT NULL, -- posts found after this weren't pushed yet
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- stop notifying if user deleted
+);
+
+-- +goose Down
+DROP TABLE push_targets;
+
+ALTER TABLE feed_follows
+DROP COLUMN notify;
\ No newline at end of file
@@ -0,0 +1,55 @@
+-- 025_rules.sql
+
+-- +goose Up
+-- a user's rules, run on the posts agg stores: when a post matches, the action is taken (see rule add)
+CREATE TABLE rules (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    -- what a post must match, NULL = anything (a rule has at least one)
+    feed_id UUID,
+    title_pattern TEXT, -- go regexp
+    category TEXT, -- one of the post's categories, any case
+    author TEXT, -- the post's author, any case
+    -- what's done to matching posts
+    action TEXT NOT NULL CHECK (action IN ('mute', 'star', 'tag', 'notify', 'webhook')),
+    argument TEXT, -- the tag, notify channel or webhook url
+    -- link to users and feeds
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete rules if user deleted
+    FOREIGN KEY (feed_id)
+        REFERENCES feeds(id)
+        ON DELETE CASCADE -- delete rules if feed deleted
+);
+
+-- posts muted by a rule are hidden from the user
+ALTER TABLE post_states
+ADD COLUMN muted_at TIMESTAMP;
+
+-- a user's tags on posts, set by rules
+CREATE TABLE post_tags (
+    -- define table columns
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    -- a tag once per user and post
+    PRIMARY KEY (user_id, post_id, tag),
+    -- link to users and posts
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete tags if user deleted
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE -- delete tags if post deleted (prune)
+);
+
+-- +goose Down
+DROP TABLE post_tags;
+
+ALTER TABLE post_states
+DROP COLUMN muted_at;
+
+DROP TABLE rules;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- 026_mutes.sql
+
+-- +goose Up
+-- a user's mute list, posts with a matching title are hidden from them (see mute)
+CREATE TABLE mutes (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    pattern TEXT NOT NULL,
+    is_regex BOOLEAN NOT NULL, -- false = a keyword, matched anywhere in the title ignoring case
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete mutes if user deleted
+    -- a pattern once per user
+    UNIQUE (user_id, pattern)
+);
+
+-- +goose Down
+DROP TABLE mutes;
\ No newline at end of file
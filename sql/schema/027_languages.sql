@@ -0,0 +1,21 @@
+-- 027_languages.sql
+
+-- +goose Up
+-- the language detected when a post was stored (ISO 639-1, NULL = too little text to tell)
+ALTER TABLE posts ADD COLUMN language TEXT;
+
+-- a user's preferred languages, digests and notifications skip posts in others (see languages)
+CREATE TABLE language_preferences (
+    -- define table columns
+    user_id UUID PRIMARY KEY, -- one list per user
+    updated_at TIMESTAMP NOT NULL,
+    languages TEXT[] NOT NULL,
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- delete preferences if user deleted
+);
+
+-- +goose Down
+DROP TABLE language_preferences;
+ALTER TABLE posts DROP COLUMN language;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- 028_translations.sql
+
+-- +goose Up
+-- follows can be auto-translated, browse shows their posts in this language (ISO 639-1, see translate)
+ALTER TABLE feed_follows
+ADD COLUMN translate_to TEXT;
+
+-- posts translated by the translation provider, so browsing them again doesn't call it again
+CREATE TABLE post_translations (
+    -- define table columns
+    post_id UUID NOT NULL,
+    language TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    -- one translation per post and language
+    PRIMARY KEY (post_id, language),
+    -- link to posts
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE -- delete translations if post deleted
+);
+
+-- +goose Down
+DROP TABLE post_translations;
+ALTER TABLE feed_follows DROP COLUMN translate_to;
\ No newline at end of file
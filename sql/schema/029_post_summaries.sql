@@ -0,0 +1,17 @@
+-- 029_post_summaries.sql
+
+-- +goose Up
+-- summaries agg wrote of posts with the configured summarizer (see summarize)
+CREATE TABLE post_summaries (
+    -- define table columns
+    post_id UUID PRIMARY KEY, -- one summary per post
+    created_at TIMESTAMP NOT NULL, -- when it was written, for the daily limit
+    summary TEXT NOT NULL, -- '' while an agg is writing it
+    -- link to posts
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE -- delete summary if post deleted
+);
+
+-- +goose Down
+DROP TABLE post_summaries;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- 030_posts_reading_minutes.sql
+
+-- +goose Up
+-- estimated minutes to read a post, from the word count of its content (NULL = no content to count)
+ALTER TABLE posts ADD COLUMN reading_minutes INTEGER;
+
+-- estimate the posts already stored from their description (html tags stripped), at 238 words a minute
+UPDATE posts
+SET reading_minutes = GREATEST(1, CEIL(
+    COALESCE(ARRAY_LENGTH(REGEXP_SPLIT_TO_ARRAY(BTRIM(REGEXP_REPLACE(description, '<[^>]*>', ' ', 'g')), '\s+'), 1), 0) / 238.0
+))
+WHERE BTRIM(REGEXP_REPLACE(description, '<[^>]*>', ' ', 'g')) <> '';
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN reading_minutes;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- 031_read_later_accounts.sql
+
+-- +goose Up
+-- a user's logins with read-later services, posts can be sent to them (see sendto)
+CREATE TABLE read_later_accounts (
+    -- define table columns
+    user_id UUID NOT NULL,
+    service TEXT NOT NULL CHECK (service IN ('pocket', 'instapaper', 'wallabag')),
+    created_at TIMESTAMP NOT NULL,
+    settings JSONB NOT NULL, -- the service's tokens and login, see readlater.Account
+    -- one login per service
+    PRIMARY KEY (user_id, service),
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- forget logins if user deleted
+);
+
+-- +goose Down
+DROP TABLE read_later_accounts;
\ No newline at end of file
@@ -0,0 +1,41 @@
+-- 032_short_links.sql
+
+-- +goose Up
+-- where shortened post links (t.co, bit.ly, feedproxy...) lead, so each is only resolved once (see unshorten_links)
+CREATE TABLE short_links (
+    -- define table columns
+    url TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    resolved_url TEXT NOT NULL -- the url itself if it couldn't be resolved, so its post keeps one url
+);
+
+-- post links are stored without tracking params now, strip them from the stored ones too
+-- so the posts still in their feeds aren't stored again (a url that's stored already is left as it was)
+WITH stripped AS (
+    SELECT
+        id,
+        split_part(split_part(url, '#', 1), '?', 1)
+        || COALESCE('?' || NULLIF(array_to_string(ARRAY(
+            SELECT param
+            FROM unnest(string_to_array(split_part(split_part(url, '#', 1), '?', 2), '&')) WITH ORDINALITY AS q(param, n)
+            WHERE NOT (LOWER(split_part(param, '=', 1)) LIKE 'utm\_%'
+                       OR LOWER(split_part(param, '=', 1)) IN ('fbclid', 'gclid', 'dclid', 'msclkid', 'mc_cid', 'mc_eid', 'igshid', 'yclid', '_hsenc', '_hsmi', 'ref_src'))
+            ORDER BY n
+        ), '&'), ''), '')
+        || CASE WHEN STRPOS(url, '#') > 0 THEN SUBSTRING(url FROM STRPOS(url, '#')) ELSE '' END AS new_url
+    FROM posts
+    WHERE split_part(split_part(url, '#', 1), '?', 2) ~* '(^|&)(utm_[^=&]*|fbclid|gclid|dclid|msclkid|mc_cid|mc_eid|igshid|yclid|_hsenc|_hsmi|ref_src)(=|&|$)'
+), unique_urls AS (
+    SELECT DISTINCT ON (new_url) id, new_url
+    FROM stripped
+    WHERE NOT EXISTS (SELECT 1 FROM posts p WHERE p.url = stripped.new_url)
+    ORDER BY new_url, id
+)
+UPDATE posts
+SET url = unique_urls.new_url
+FROM unique_urls
+WHERE posts.id = unique_urls.id;
+
+-- +goose Down
+-- the stripped tracking params are gone for good
+DROP TABLE short_links;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- 033_feed_weights.sql
+
+-- +goose Up
+-- how much a user wants a followed feed's posts to rank in browse --ranked (1 = normal, 0 = never first)
+ALTER TABLE feed_follows
+ADD COLUMN weight REAL NOT NULL DEFAULT 1 CHECK (weight >= 0);
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN weight;
\ No newline at end of file
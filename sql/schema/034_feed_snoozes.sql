@@ -0,0 +1,10 @@
+-- 034_feed_snoozes.sql
+
+-- +goose Up
+-- a user's follow can be snoozed until a time (snooze), hiding its posts and, once all its followers snoozed it, pausing the feed's fetching
+ALTER TABLE feed_follows
+ADD COLUMN snoozed_until TIMESTAMP; -- NULL = not snoozed, past = resumed
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN snoozed_until;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- 035_digest_preferences.sql
+
+-- +goose Up
+-- when, where and what a user's digests are sent, instead of every digest_interval (see digest prefs)
+CREATE TABLE digest_preferences (
+    -- define table columns
+    user_id UUID PRIMARY KEY, -- one schedule per user
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    cadence TEXT NOT NULL CHECK (cadence IN ('daily', 'weekly')),
+    weekday INT NOT NULL DEFAULT 1 CHECK (weekday BETWEEN 0 AND 6), -- weekly digests' day, 0 = sunday
+    send_minute INT NOT NULL CHECK (send_minute BETWEEN 0 AND 1439), -- the time of day, in minutes after midnight
+    timezone TEXT NOT NULL DEFAULT '', -- '' = the time zone agg runs in
+    channel TEXT NOT NULL CHECK (channel IN ('email', 'telegram', 'file')),
+    -- the scope, both NULL = every post, otherwise posts in one of the folders or with one of the tags (set by rules)
+    folders TEXT[],
+    tags TEXT[],
+    last_sent_at TIMESTAMP, -- NULL = never sent, the first digest covers the cadence before it
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- delete preferences if user deleted
+);
+
+-- +goose Down
+DROP TABLE digest_preferences;
\ No newline at end of file
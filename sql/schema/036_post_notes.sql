@@ -0,0 +1,26 @@
+-- 036_post_notes.sql
+
+-- +goose Up
+-- a user's notes on posts, optionally with a highlighted passage (see note), prune keeps the posts they're on
+CREATE TABLE post_notes (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    body TEXT NOT NULL, -- '' = only a highlight
+    highlight TEXT, -- NULL = no highlight, a passage quoted from the post
+    -- link to users and posts
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete notes if user deleted
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE -- delete notes if post deleted (removefeed)
+);
+
+-- list a post's notes quickly (and prune's check)
+CREATE INDEX post_notes_post_idx ON post_notes (post_id);
+
+-- +goose Down
+DROP TABLE post_notes;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- 037_post_shares.sql
+
+-- +goose Up
+-- posts users shared with each other, the recipient's inbox (see share and inbox)
+CREATE TABLE post_shares (
+    -- define table columns
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    from_user_id UUID NOT NULL,
+    to_user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    message TEXT, -- NULL = shared without a message
+    seen_at TIMESTAMP, -- NULL = new in the recipient's inbox
+    -- link to users and posts
+    FOREIGN KEY (from_user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete shares if the sender is deleted
+    FOREIGN KEY (to_user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- delete shares if the recipient is deleted
+    FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE -- delete shares if post deleted (prune)
+);
+
+-- list a user's inbox quickly
+CREATE INDEX post_shares_to_user_idx ON post_shares (to_user_id, created_at);
+
+-- +goose Down
+DROP TABLE post_shares;
\ No newline at end of file
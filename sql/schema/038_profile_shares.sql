@@ -0,0 +1,16 @@
+-- 038_profile_shares.sql
+
+-- +goose Up
+-- users who publish the feeds they follow as a blogroll, on serve's /u/{name} (see profile)
+CREATE TABLE profile_shares (
+    -- define table columns
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- stop sharing if user deleted
+);
+
+-- +goose Down
+DROP TABLE profile_shares;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- 039_sync_accounts.sql
+
+-- +goose Up
+-- a user's logins with hosted readers, synced with gator (see sync)
+CREATE TABLE sync_accounts (
+    -- define table columns
+    user_id UUID NOT NULL,
+    service TEXT NOT NULL CHECK (service IN ('feedbin', 'feedly')),
+    created_at TIMESTAMP NOT NULL,
+    settings JSONB NOT NULL, -- the service's login, see readers.Account
+    push_read BOOLEAN NOT NULL DEFAULT FALSE, -- mark posts read in gator read on the service too
+    synced_at TIMESTAMP, -- NULL = never synced
+    -- one login per service
+    PRIMARY KEY (user_id, service),
+    -- link to users
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- forget logins if user deleted
+);
+
+-- +goose Down
+DROP TABLE sync_accounts;
\ No newline at end of file
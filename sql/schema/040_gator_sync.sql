@@ -0,0 +1,19 @@
+-- 040_gator_sync.sql
+
+-- +goose Up
+-- another gator instance can be synced with too, its settings hold the url, api token and sync cursors
+ALTER TABLE sync_accounts
+DROP CONSTRAINT sync_accounts_service_check;
+
+ALTER TABLE sync_accounts
+ADD CONSTRAINT sync_accounts_service_check CHECK (service IN ('feedbin', 'feedly', 'gator'));
+
+-- +goose Down
+DELETE FROM sync_accounts
+WHERE service = 'gator';
+
+ALTER TABLE sync_accounts
+DROP CONSTRAINT sync_accounts_service_check;
+
+ALTER TABLE sync_accounts
+ADD CONSTRAINT sync_accounts_service_check CHECK (service IN ('feedbin', 'feedly'));
@@ -0,0 +1,13 @@
+-- 041_posts_guid.sql
+
+-- +goose Up
+-- the guid the feed gave a post (NULL = none), stable when its link isn't, so read and starred states can be matched by it (see import states)
+ALTER TABLE posts ADD COLUMN guid TEXT;
+
+-- guids are only unique within their feed
+CREATE INDEX posts_feed_id_guid_idx ON posts (feed_id, guid);
+
+-- +goose Down
+DROP INDEX posts_feed_id_guid_idx;
+
+ALTER TABLE posts DROP COLUMN guid;
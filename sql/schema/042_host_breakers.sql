@@ -0,0 +1,15 @@
+-- 042_host_breakers.sql
+
+-- +goose Up
+-- a circuit breaker per feed host, shared by every agg: after a few timeouts in a row the host's circuit opens,
+-- and its feeds aren't fetched until open_until, so fetch slots aren't spent waiting on a host that's down (see feeds --broken)
+CREATE TABLE host_breakers (
+    host TEXT PRIMARY KEY, -- lowercased, without the port
+    consecutive_timeouts INTEGER NOT NULL DEFAULT 0,
+    open_until TIMESTAMP, -- UTC like the fetch jobs, NULL = closed (not enough timeouts yet)
+    last_error TEXT,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE host_breakers;
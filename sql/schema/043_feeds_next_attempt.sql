@@ -0,0 +1,10 @@
+-- 043_feeds_next_attempt.sql
+
+-- +goose Up
+-- a failing feed backs off: after each failed fetch it isn't queued again before next_attempt_at (see MarkFeedFailed)
+ALTER TABLE feeds
+ADD COLUMN next_attempt_at TIMESTAMP; -- UTC like last_fetched_at, NULL = not backing off
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN next_attempt_at;
@@ -0,0 +1,15 @@
+-- 044_feeds_url_key.sql
+
+-- +goose Up
+-- feeds are fetched by the url as it was added, url_key is only for spotting the same feed spelled differently
+-- (urlnorm.Key: lowercased host, no scheme, trailing slash or tracking params...), one feed per key
+ALTER TABLE feeds
+ADD COLUMN url_key TEXT; -- NULL for feeds added before it (and newsletters), compared in go instead
+
+CREATE UNIQUE INDEX feeds_url_key_idx ON feeds (url_key);
+
+-- +goose Down
+DROP INDEX feeds_url_key_idx;
+
+ALTER TABLE feeds
+DROP COLUMN url_key;
@@ -0,0 +1,10 @@
+-- 045_telegram_link_code_at.sql
+
+-- +goose Up
+-- telegram link codes expire (see telegramLinkCodeTTL), codes made before this never had a time and stop working
+ALTER TABLE telegram_chats
+ADD COLUMN link_code_at TIMESTAMP; -- UTC like created_at, when link_code was made, NULL = no code
+
+-- +goose Down
+ALTER TABLE telegram_chats
+DROP COLUMN link_code_at;
\ No newline at end of file